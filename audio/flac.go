@@ -0,0 +1,128 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// FLAC metadata block types
+const (
+	flacStreamInfo    = 0
+	flacVorbisComment = 4
+)
+
+// maxFLACBlock caps the metadata blocks read into memory
+const maxFLACBlock = 1 << 20
+
+func readFLAC(r io.ReaderAt, size int64, meta *Metadata) error {
+	// Some taggers put an ID3v2 tag before the stream marker
+	start, err := readID3v2(r, size, meta)
+	if err != nil {
+		return err
+	}
+
+	marker := make([]byte, 4)
+	if err := readAt(r, marker, start); err != nil || string(marker) != "fLaC" {
+		return errors.New("missing FLAC stream marker")
+	}
+
+	var totalSamples int64
+	pos := start + 4
+	header := make([]byte, 4)
+	for {
+		if err := readAt(r, header, pos); err != nil {
+			return err
+		}
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7F
+		length := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+		pos += 4
+
+		if (blockType == flacStreamInfo || blockType == flacVorbisComment) && length <= maxFLACBlock {
+			block := make([]byte, length)
+			if err := readAt(r, block, pos); err != nil {
+				return err
+			}
+			switch blockType {
+			case flacStreamInfo:
+				if len(block) < 18 {
+					return errors.New("short FLAC STREAMINFO block")
+				}
+				// 20 bits sample rate, 3 bits channels-1, 5 bits bits per sample-1, 36 bits total samples
+				packed := binary.BigEndian.Uint64(block[10:18])
+				meta.SampleRate = int(packed >> 44)
+				meta.Channels = int(packed>>41&0x7) + 1
+				totalSamples = int64(packed & 0xFFFFFFFFF)
+			case flacVorbisComment:
+				parseVorbisComment(block, meta)
+			}
+		}
+
+		pos += length
+		if last || pos >= size {
+			break
+		}
+	}
+
+	if meta.SampleRate > 0 && totalSamples > 0 {
+		seconds := float64(totalSamples) / float64(meta.SampleRate)
+		meta.Duration = time.Duration(seconds * float64(time.Second))
+		meta.Bitrate = int(float64(size-pos) * 8 / seconds / 1000)
+	}
+	return nil
+}
+
+// vorbisFields maps Vorbis comment field names to tag keys
+var vorbisFields = map[string]string{
+	"TITLE":        "title",
+	"ARTIST":       "artist",
+	"ALBUM":        "album",
+	"ALBUMARTIST":  "albumartist",
+	"ALBUM ARTIST": "albumartist",
+	"TRACKNUMBER":  "track",
+	"DISCNUMBER":   "disc",
+	"DATE":         "year",
+	"YEAR":         "year",
+}
+
+// parseVorbisComment reads a Vorbis comment block as used by FLAC, Ogg Vorbis and Opus
+func parseVorbisComment(b []byte, meta *Metadata) {
+	next := func() ([]byte, bool) {
+		if len(b) < 4 {
+			return nil, false
+		}
+		n := binary.LittleEndian.Uint32(b)
+		b = b[4:]
+		if uint64(n) > uint64(len(b)) {
+			return nil, false
+		}
+		s := b[:n]
+		b = b[n:]
+		return s, true
+	}
+
+	if _, ok := next(); !ok { // Vendor string
+		return
+	}
+	if len(b) < 4 {
+		return
+	}
+	count := binary.LittleEndian.Uint32(b)
+	b = b[4:]
+	for i := uint32(0); i < count; i++ {
+		comment, ok := next()
+		if !ok {
+			return
+		}
+		name, value, ok := strings.Cut(string(comment), "=")
+		if !ok {
+			continue
+		}
+		if key, ok := vorbisFields[strings.ToUpper(name)]; ok {
+			meta.setTag(key, value)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"unicode/utf16"
+)
+
+// id3v2Frames maps ID3v2.3/2.4 frame IDs and their ID3v2.2 equivalents to tag keys
+var id3v2Frames = map[string]string{
+	"TIT2": "title", "TT2": "title",
+	"TPE1": "artist", "TP1": "artist",
+	"TALB": "album", "TAL": "album",
+	"TPE2": "albumartist", "TP2": "albumartist",
+	"TRCK": "track", "TRK": "track",
+	"TPOS": "disc", "TPA": "disc",
+	"TDRC": "year", "TYER": "year", "TYE": "year", "TORY": "year", "TDOR": "year",
+}
+
+// maxID3Size caps the tag read into memory; tags with large cover art are read up to it
+const maxID3Size = 16 << 20
+
+// readID3v2 reads an ID3v2 tag at the start of the file and returns its total size, 0 if there is none
+func readID3v2(r io.ReaderAt, size int64, meta *Metadata) (int64, error) {
+	header := make([]byte, 10)
+	if size < 10 || readAt(r, header, 0) != nil || string(header[:3]) != "ID3" {
+		return 0, nil
+	}
+
+	version, flags := header[3], header[5]
+	tagSize := int64(syncsafe(header[6:10]))
+	total := 10 + tagSize
+	if flags&0x10 != 0 {
+		total += 10 // Footer
+	}
+	if version < 2 || version > 4 {
+		return total, nil
+	}
+
+	body := make([]byte, min(tagSize, maxID3Size, size-10))
+	if err := readAt(r, body, 10); err != nil {
+		return total, err
+	}
+	if version < 4 && flags&0x80 != 0 {
+		body = removeUnsync(body)
+	}
+
+	pos := 0
+	if flags&0x40 != 0 && version >= 3 && len(body) >= 4 {
+		if version == 3 {
+			pos = 4 + int(binary.BigEndian.Uint32(body[:4]))
+		} else {
+			pos = int(syncsafe(body[:4]))
+		}
+	}
+
+	idLen, headerLen := 4, 10
+	if version == 2 {
+		idLen, headerLen = 3, 6
+	}
+	for pos+headerLen <= len(body) {
+		id := string(body[pos : pos+idLen])
+		if id[0] == 0 {
+			break // Padding
+		}
+
+		var frameSize int
+		var frameFlags uint16
+		switch version {
+		case 2:
+			frameSize = int(body[pos+3])<<16 | int(body[pos+4])<<8 | int(body[pos+5])
+		case 3:
+			frameSize = int(binary.BigEndian.Uint32(body[pos+4 : pos+8]))
+			frameFlags = binary.BigEndian.Uint16(body[pos+8 : pos+10])
+		case 4:
+			frameSize = int(syncsafe(body[pos+4 : pos+8]))
+			frameFlags = binary.BigEndian.Uint16(body[pos+8 : pos+10])
+		}
+		pos += headerLen
+		if frameSize < 0 || pos+frameSize > len(body) {
+			break
+		}
+		data := body[pos : pos+frameSize]
+		pos += frameSize
+
+		key, ok := id3v2Frames[id]
+		if !ok {
+			if id == "TLEN" || id == "TLE" {
+				key = "length"
+			} else {
+				continue
+			}
+		}
+
+		// Compressed and encrypted frames are skipped
+		if version == 3 && frameFlags&0x00C0 != 0 || version == 4 && frameFlags&0x000C != 0 {
+			continue
+		}
+		if version == 4 {
+			if frameFlags&0x0002 != 0 {
+				data = removeUnsync(data)
+			}
+			if frameFlags&0x0001 != 0 && len(data) >= 4 {
+				data = data[4:] // Data length indicator
+			}
+		}
+
+		value := decodeID3Text(data)
+		if key == "length" {
+			if meta.Duration == 0 {
+				if ms := leadingNumber(value); ms > 0 {
+					meta.Duration = msDuration(ms)
+				}
+			}
+			continue
+		}
+		meta.setTag(key, value)
+	}
+	return total, nil
+}
+
+// readID3v1 fills blank fields from an ID3v1 tag at the end of the file and reports whether one was found
+func readID3v1(r io.ReaderAt, size int64, meta *Metadata) bool {
+	if size < 128 {
+		return false
+	}
+	tag := make([]byte, 128)
+	if readAt(r, tag, size-128) != nil || string(tag[:3]) != "TAG" {
+		return false
+	}
+
+	text := func(b []byte) string {
+		if i := bytes.IndexByte(b, 0); i >= 0 {
+			b = b[:i]
+		}
+		return latin1(b)
+	}
+	meta.setTag("title", text(tag[3:33]))
+	meta.setTag("artist", text(tag[33:63]))
+	meta.setTag("album", text(tag[63:93]))
+	meta.setTag("year", text(tag[93:97]))
+	if tag[125] == 0 && tag[126] != 0 {
+		setInt(&meta.TrackNumber, int(tag[126])) // ID3v1.1
+	}
+	return true
+}
+
+// decodeID3Text decodes a text frame, returning its first value
+func decodeID3Text(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	encoding, text := data[0], data[1:]
+
+	switch encoding {
+	case 1, 2: // UTF-16 with BOM, UTF-16BE
+		bigEndian := encoding == 2
+		if len(text) >= 2 {
+			switch {
+			case text[0] == 0xFF && text[1] == 0xFE:
+				bigEndian, text = false, text[2:]
+			case text[0] == 0xFE && text[1] == 0xFF:
+				bigEndian, text = true, text[2:]
+			}
+		}
+		units := make([]uint16, 0, len(text)/2)
+		for i := 0; i+1 < len(text); i += 2 {
+			var u uint16
+			if bigEndian {
+				u = binary.BigEndian.Uint16(text[i:])
+			} else {
+				u = binary.LittleEndian.Uint16(text[i:])
+			}
+			if u == 0 {
+				break
+			}
+			units = append(units, u)
+		}
+		return string(utf16.Decode(units))
+	case 3: // UTF-8
+		if i := bytes.IndexByte(text, 0); i >= 0 {
+			text = text[:i]
+		}
+		return string(text)
+	}
+
+	if i := bytes.IndexByte(text, 0); i >= 0 {
+		text = text[:i]
+	}
+	return latin1(text)
+}
+
+func latin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+func syncsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7F)<<21 | uint32(b[1]&0x7F)<<14 | uint32(b[2]&0x7F)<<7 | uint32(b[3]&0x7F)
+}
+
+// removeUnsync reverses ID3 unsynchronisation, which inserts a zero byte after each 0xFF
+func removeUnsync(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		out = append(out, b[i])
+		if b[i] == 0xFF && i+1 < len(b) && b[i+1] == 0 {
+			i++
+		}
+	}
+	return out
+}
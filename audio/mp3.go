@@ -0,0 +1,192 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+// MPEG audio versions as encoded in the frame header
+const (
+	mpeg25 = 0
+	mpeg2  = 2
+	mpeg1  = 3
+)
+
+// Bitrates in kbps by [MPEG-1][layer]; index 0 is "free" and 15 is invalid
+var mpegBitrates = [2][4][16]int{
+	{ // MPEG-2 and 2.5
+		{},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},      // Layer III
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},      // Layer II
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256}, // Layer I
+	},
+	{ // MPEG-1
+		{},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},     // Layer III
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},    // Layer II
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448}, // Layer I
+	},
+}
+
+var mpegSampleRates = map[int][3]int{
+	mpeg1:  {44100, 48000, 32000},
+	mpeg2:  {22050, 24000, 16000},
+	mpeg25: {11025, 12000, 8000},
+}
+
+// mpegFrame is a decoded MPEG audio frame header
+type mpegFrame struct {
+	version    int
+	layer      int // 1 = Layer III, 2 = Layer II, 3 = Layer I, as encoded
+	bitrate    int // kbps
+	sampleRate int
+	channels   int
+	length     int // Bytes including the header
+	samples    int // Samples per frame
+}
+
+func parseMPEGFrame(h []byte) (mpegFrame, bool) {
+	if len(h) < 4 || h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return mpegFrame{}, false
+	}
+	f := mpegFrame{
+		version: int(h[1]>>3) & 3,
+		layer:   int(h[1]>>1) & 3,
+	}
+	bitrateIndex, rateIndex := int(h[2]>>4), int(h[2]>>2)&3
+	if f.version == 1 || f.layer == 0 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return mpegFrame{}, false
+	}
+
+	v1 := 0
+	if f.version == mpeg1 {
+		v1 = 1
+	}
+	f.bitrate = mpegBitrates[v1][f.layer][bitrateIndex]
+	f.sampleRate = mpegSampleRates[f.version][rateIndex]
+	f.channels = 2
+	if h[3]>>6 == 3 {
+		f.channels = 1
+	}
+
+	padding := int(h[2]>>1) & 1
+	switch {
+	case f.layer == 3:
+		f.samples = 384
+		f.length = (12*f.bitrate*1000/f.sampleRate + padding) * 4
+	case f.layer == 1 && f.version != mpeg1:
+		f.samples = 576
+		f.length = 72*f.bitrate*1000/f.sampleRate + padding
+	default:
+		f.samples = 1152
+		f.length = 144*f.bitrate*1000/f.sampleRate + padding
+	}
+	return f, f.length > 4
+}
+
+// mp3ScanLimit is how far past the tag the first frame is looked for
+const mp3ScanLimit = 256 << 10
+
+func readMP3(r io.ReaderAt, size int64, meta *Metadata) error {
+	start, err := readID3v2(r, size, meta)
+	if err != nil {
+		return err
+	}
+	end := size
+	if readID3v1(r, size, meta) {
+		end -= 128
+	}
+
+	// Find the first frame, confirmed by the frame after it
+	buf := make([]byte, min(int64(mp3ScanLimit), max(end-start, 0)))
+	if err := readAt(r, buf, start); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	offset := -1
+	var first mpegFrame
+	for i := 0; i+4 <= len(buf); i++ {
+		f, ok := parseMPEGFrame(buf[i:])
+		if !ok {
+			continue
+		}
+		next := i + f.length
+		if next+4 <= len(buf) {
+			if _, ok := parseMPEGFrame(buf[next:]); !ok {
+				continue
+			}
+		} else if int64(next) < end-start {
+			continue
+		}
+		offset, first = i, f
+		break
+	}
+	if offset < 0 {
+		if meta.Duration > 0 {
+			return nil // The tag's length is all there is
+		}
+		return errors.New("no MPEG audio frames found")
+	}
+
+	meta.SampleRate = first.sampleRate
+	meta.Channels = first.channels
+	audioBytes := end - start - int64(offset)
+
+	// A Xing/Info or VBRI header in the first frame gives the exact frame count
+	frames, frameBytes := vbrHeader(buf[offset:min(offset+first.length, len(buf))], first)
+	if frames > 0 {
+		seconds := float64(frames) * float64(first.samples) / float64(first.sampleRate)
+		meta.Duration = time.Duration(seconds * float64(time.Second))
+		if frameBytes == 0 {
+			frameBytes = int(audioBytes)
+		}
+		if seconds > 0 {
+			meta.Bitrate = int(float64(frameBytes) * 8 / seconds / 1000)
+		}
+		return nil
+	}
+
+	// Constant bitrate: the stream length follows from the byte count
+	meta.Bitrate = first.bitrate
+	meta.Duration = time.Duration(float64(audioBytes) * 8 / float64(first.bitrate*1000) * float64(time.Second))
+	return nil
+}
+
+// vbrHeader reads the frame and byte counts of a Xing/Info or VBRI header
+func vbrHeader(frame []byte, f mpegFrame) (frames, bytes int) {
+	sideInfo := 32
+	switch {
+	case f.version == mpeg1 && f.channels == 1:
+		sideInfo = 17
+	case f.version != mpeg1 && f.channels == 2:
+		sideInfo = 17
+	case f.version != mpeg1:
+		sideInfo = 9
+	}
+
+	if x := 4 + sideInfo; len(frame) >= x+8 {
+		if tag := string(frame[x : x+4]); tag == "Xing" || tag == "Info" {
+			flags := binary.BigEndian.Uint32(frame[x+4:])
+			pos := x + 8
+			if flags&1 != 0 && len(frame) >= pos+4 {
+				frames = int(binary.BigEndian.Uint32(frame[pos:]))
+				pos += 4
+			}
+			if flags&2 != 0 && len(frame) >= pos+4 {
+				bytes = int(binary.BigEndian.Uint32(frame[pos:]))
+			}
+			return frames, bytes
+		}
+	}
+
+	if v := 4 + 32; len(frame) >= v+18 && string(frame[v:v+4]) == "VBRI" {
+		bytes = int(binary.BigEndian.Uint32(frame[v+10:]))
+		frames = int(binary.BigEndian.Uint32(frame[v+14:]))
+	}
+	return frames, bytes
+}
+
+func msDuration(ms int) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
@@ -0,0 +1,136 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	oggPageHeader = 27
+	// oggTailSize is how much of the end of the file is searched for the last page
+	oggTailSize = 64 << 10
+	// maxOggPacket caps header packets, which may carry embedded cover art
+	maxOggPacket = 4 << 20
+)
+
+// oggReader reassembles packets from the pages at the start of an Ogg stream
+type oggReader struct {
+	r   io.ReaderAt
+	pos int64
+	// Segments of the current page not yet consumed
+	segments []byte
+	data     []byte
+}
+
+func (o *oggReader) nextPage() error {
+	header := make([]byte, oggPageHeader)
+	if err := readAt(o.r, header, o.pos); err != nil {
+		return err
+	}
+	if string(header[:4]) != "OggS" {
+		return errors.New("invalid Ogg page")
+	}
+	segments := make([]byte, header[26])
+	if err := readAt(o.r, segments, o.pos+oggPageHeader); err != nil {
+		return err
+	}
+	length := 0
+	for _, s := range segments {
+		length += int(s)
+	}
+	data := make([]byte, length)
+	if err := readAt(o.r, data, o.pos+oggPageHeader+int64(len(segments))); err != nil {
+		return err
+	}
+	o.pos += oggPageHeader + int64(len(segments)) + int64(length)
+	o.segments, o.data = segments, data
+	return nil
+}
+
+// packet returns the next complete packet, following it across pages
+func (o *oggReader) packet() ([]byte, error) {
+	var packet []byte
+	for {
+		for len(o.segments) == 0 {
+			if err := o.nextPage(); err != nil {
+				return nil, err
+			}
+		}
+		n := int(o.segments[0])
+		o.segments = o.segments[1:]
+		packet = append(packet, o.data[:n]...)
+		o.data = o.data[n:]
+		if len(packet) > maxOggPacket {
+			return nil, errors.New("Ogg header packet too large")
+		}
+		if n < 255 {
+			return packet, nil
+		}
+	}
+}
+
+func readOgg(r io.ReaderAt, size int64, meta *Metadata) error {
+	o := &oggReader{r: r}
+	ident, err := o.packet()
+	if err != nil {
+		return err
+	}
+
+	var preSkip int64
+	var nominalBitrate int
+	var commentPrefix []byte
+	switch {
+	case len(ident) >= 30 && string(ident[:7]) == "\x01vorbis":
+		meta.Channels = int(ident[11])
+		meta.SampleRate = int(binary.LittleEndian.Uint32(ident[12:]))
+		nominalBitrate = int(int32(binary.LittleEndian.Uint32(ident[20:])))
+		commentPrefix = []byte("\x03vorbis")
+	case len(ident) >= 19 && string(ident[:8]) == "OpusHead":
+		meta.Channels = int(ident[9])
+		preSkip = int64(binary.LittleEndian.Uint16(ident[10:]))
+		// Opus always decodes at 48 kHz; the header only records the input rate
+		meta.SampleRate = 48000
+		commentPrefix = []byte("OpusTags")
+	default:
+		return errors.New("unsupported Ogg codec")
+	}
+
+	if comment, err := o.packet(); err == nil && bytes.HasPrefix(comment, commentPrefix) {
+		parseVorbisComment(comment[len(commentPrefix):], meta)
+	}
+
+	granule, err := lastGranule(r, size)
+	if err != nil {
+		return err
+	}
+	if samples := granule - preSkip; samples > 0 && meta.SampleRate > 0 {
+		seconds := float64(samples) / float64(meta.SampleRate)
+		meta.Duration = time.Duration(seconds * float64(time.Second))
+		meta.Bitrate = int(float64(size) * 8 / seconds / 1000)
+	} else if nominalBitrate > 0 {
+		meta.Bitrate = nominalBitrate / 1000
+	}
+	return nil
+}
+
+// lastGranule returns the granule position of the last page, the stream's length in samples
+func lastGranule(r io.ReaderAt, size int64) (int64, error) {
+	tailSize := min(size, oggTailSize)
+	tail := make([]byte, tailSize)
+	if err := readAt(r, tail, size-tailSize); err != nil {
+		return 0, err
+	}
+	for i := bytes.LastIndex(tail, []byte("OggS")); i >= 0; i = bytes.LastIndex(tail[:i], []byte("OggS")) {
+		if i+oggPageHeader > len(tail) || tail[i+4] != 0 {
+			continue
+		}
+		granule := int64(binary.LittleEndian.Uint64(tail[i+6:]))
+		if granule >= 0 {
+			return granule, nil
+		}
+	}
+	return 0, errors.New("no Ogg page found at end of file")
+}
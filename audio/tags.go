@@ -0,0 +1,168 @@
+// Package audio reads tags and stream details from local audio files without external tools
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported formats
+const (
+	FormatMP3  = "mp3"
+	FormatFLAC = "flac"
+	FormatOgg  = "ogg" // Ogg Vorbis
+	FormatOpus = "opus"
+)
+
+var extensionFormats = map[string]string{
+	".mp3":  FormatMP3,
+	".flac": FormatFLAC,
+	".ogg":  FormatOgg,
+	".oga":  FormatOgg,
+	".opus": FormatOpus,
+}
+
+// ErrUnsupportedFormat is returned for files whose extension is not a supported format
+var ErrUnsupportedFormat = errors.New("unsupported audio format")
+
+// Metadata holds the tags and stream details read from an audio file
+// Fields the file does not carry are left empty
+type Metadata struct {
+	Format      string
+	Title       string
+	Artist      string
+	Album       string
+	AlbumArtist string
+	TrackNumber int
+	DiscNumber  int
+	Year        int
+	Duration    time.Duration // Decoded from the stream headers
+	Bitrate     int           // Average kbps
+	SampleRate  int
+	Channels    int
+}
+
+// FormatOf returns the format of a file by its extension, or "" when it is not supported
+func FormatOf(path string) string {
+	return extensionFormats[strings.ToLower(filepath.Ext(path))]
+}
+
+// ReadFile reads the tags and stream details of an audio file
+func ReadFile(path string) (*Metadata, error) {
+	format := FormatOf(path)
+	if format == "" {
+		return nil, ErrUnsupportedFormat
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &Metadata{Format: format}
+	switch format {
+	case FormatMP3:
+		err = readMP3(f, info.Size(), meta)
+	case FormatFLAC:
+		err = readFLAC(f, info.Size(), meta)
+	case FormatOgg, FormatOpus:
+		err = readOgg(f, info.Size(), meta)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return meta, nil
+}
+
+// setTag fills a field from a tag, keeping the first value seen
+func (m *Metadata) setTag(key, value string) {
+	value = strings.TrimSpace(strings.TrimRight(value, "\x00"))
+	if value == "" {
+		return
+	}
+
+	switch key {
+	case "title":
+		setString(&m.Title, value)
+	case "artist":
+		setString(&m.Artist, value)
+	case "album":
+		setString(&m.Album, value)
+	case "albumartist":
+		setString(&m.AlbumArtist, value)
+	case "track":
+		setInt(&m.TrackNumber, leadingNumber(value))
+	case "disc":
+		setInt(&m.DiscNumber, leadingNumber(value))
+	case "year":
+		if len(value) >= 4 {
+			setInt(&m.Year, leadingNumber(value[:4]))
+		}
+	}
+}
+
+func setString(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+func setInt(field *int, value int) {
+	if *field == 0 {
+		*field = value
+	}
+}
+
+// leadingNumber reads the number at the start of values like "3/12"
+func leadingNumber(value string) int {
+	end := 0
+	for end < len(value) && value[end] >= '0' && value[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(value[:end])
+	return n
+}
+
+// readAt reads exactly len(buf) bytes at off
+func readAt(r io.ReaderAt, buf []byte, off int64) error {
+	_, err := r.ReadAt(buf, off)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// FileURL returns the file:// URL stored in Track.AudioFileURL for a local file
+func FileURL(path string) string {
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // Windows drive paths
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// PathFromURL returns the local path of a file:// URL
+func PathFromURL(raw string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "file" || u.Path == "" {
+		return "", false
+	}
+	p := u.Path
+	if len(p) >= 3 && p[0] == '/' && p[2] == ':' {
+		p = p[1:] // "/C:/Music/..." on Windows
+	}
+	return filepath.FromSlash(p), true
+}
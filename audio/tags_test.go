@@ -0,0 +1,195 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+func id3Frame(id string, data []byte) []byte {
+	frame := append([]byte(id), 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(frame[4:8], uint32(len(data)))
+	return append(frame, data...)
+}
+
+func id3Tag(frames ...[]byte) []byte {
+	body := bytes.Join(frames, nil)
+	body = append(body, make([]byte, 32)...) // Padding
+	n := len(body)
+	header := []byte{'I', 'D', '3', 3, 0, 0, byte(n >> 21 & 0x7F), byte(n >> 14 & 0x7F), byte(n >> 7 & 0x7F), byte(n & 0x7F)}
+	return append(header, body...)
+}
+
+func utf16Text(s string) []byte {
+	b := []byte{1, 0xFF, 0xFE}
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = binary.LittleEndian.AppendUint16(b, u)
+	}
+	return b
+}
+
+func vorbisComment(comments ...string) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, 4)
+	b = append(b, "test"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(comments)))
+	for _, c := range comments {
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(c)))
+		b = append(b, c...)
+	}
+	return b
+}
+
+func oggPage(granule int64, seq uint32, packet []byte) []byte {
+	var segments []byte
+	n := len(packet)
+	for ; n >= 255; n -= 255 {
+		segments = append(segments, 255)
+	}
+	segments = append(segments, byte(n))
+
+	page := []byte("OggS\x00\x00")
+	page = binary.LittleEndian.AppendUint64(page, uint64(granule))
+	page = binary.LittleEndian.AppendUint32(page, 1)
+	page = binary.LittleEndian.AppendUint32(page, seq)
+	page = append(page, 0, 0, 0, 0, byte(len(segments)))
+	page = append(page, segments...)
+	return append(page, packet...)
+}
+
+func writeTestFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadMP3(t *testing.T) {
+	tag := id3Tag(
+		id3Frame("TIT2", append([]byte{0}, "So What"...)),
+		id3Frame("TPE1", utf16Text("Miles Davis")),
+		id3Frame("TALB", append([]byte{0}, "Kind of Blue"...)),
+		id3Frame("TRCK", append([]byte{0}, "1/5"...)),
+		id3Frame("TYER", append([]byte{0}, "1959"...)),
+	)
+
+	// MPEG-1 Layer III, 128 kbps, 44.1 kHz, stereo: 417 byte frames
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	data := append(tag, bytes.Repeat(frame, 100)...)
+
+	meta, err := ReadFile(writeTestFile(t, "01 So What.mp3", data))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	if meta.Format != FormatMP3 || meta.Title != "So What" || meta.Artist != "Miles Davis" || meta.Album != "Kind of Blue" {
+		t.Errorf("tags = %+v", meta)
+	}
+	if meta.TrackNumber != 1 || meta.Year != 1959 {
+		t.Errorf("TrackNumber, Year = %d, %d, want 1, 1959", meta.TrackNumber, meta.Year)
+	}
+	if meta.Bitrate != 128 || meta.SampleRate != 44100 || meta.Channels != 2 {
+		t.Errorf("stream = %d kbps, %d Hz, %d ch", meta.Bitrate, meta.SampleRate, meta.Channels)
+	}
+	// 100 frames * 417 bytes * 8 / 128000
+	if want := 2606 * time.Millisecond; meta.Duration.Truncate(time.Millisecond) != want {
+		t.Errorf("Duration = %v, want %v", meta.Duration, want)
+	}
+}
+
+func TestReadMP3ID3v1(t *testing.T) {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	tag := make([]byte, 128)
+	copy(tag, "TAG")
+	copy(tag[3:], "Blue in Green")
+	copy(tag[33:], "Miles Davis")
+	tag[126] = 3
+
+	meta, err := ReadFile(writeTestFile(t, "track.mp3", append(bytes.Repeat(frame, 10), tag...)))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if meta.Title != "Blue in Green" || meta.Artist != "Miles Davis" || meta.TrackNumber != 3 {
+		t.Errorf("tags = %+v", meta)
+	}
+	if meta.Duration <= 0 {
+		t.Errorf("Duration = %v, want > 0", meta.Duration)
+	}
+}
+
+func TestReadFLAC(t *testing.T) {
+	info := make([]byte, 34)
+	// 44.1 kHz, 2 channels, 16 bits, 441000 samples
+	packed := uint64(44100)<<44 | uint64(1)<<41 | uint64(15)<<36 | 441000
+	binary.BigEndian.PutUint64(info[10:], packed)
+	comment := vorbisComment("TITLE=Freddie Freeloader", "artist=Miles Davis", "ALBUM=Kind of Blue", "TRACKNUMBER=2", "DATE=1959-08-17")
+
+	data := []byte("fLaC")
+	data = append(data, 0, 0, 0, 34)
+	data = append(data, info...)
+	data = append(data, 0x84, 0, byte(len(comment)>>8), byte(len(comment)))
+	data = append(data, comment...)
+	data = append(data, make([]byte, 1000)...)
+
+	meta, err := ReadFile(writeTestFile(t, "track.flac", data))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if meta.Title != "Freddie Freeloader" || meta.Artist != "Miles Davis" || meta.TrackNumber != 2 || meta.Year != 1959 {
+		t.Errorf("tags = %+v", meta)
+	}
+	if meta.Duration != 10*time.Second || meta.SampleRate != 44100 || meta.Channels != 2 {
+		t.Errorf("stream = %v, %d Hz, %d ch", meta.Duration, meta.SampleRate, meta.Channels)
+	}
+}
+
+func TestReadOpus(t *testing.T) {
+	head := []byte("OpusHead\x01\x02")
+	head = binary.LittleEndian.AppendUint16(head, 312)
+	head = binary.LittleEndian.AppendUint32(head, 44100)
+	head = append(head, 0, 0, 0)
+	// Long enough to span a lacing boundary
+	tags := append([]byte("OpusTags"), vorbisComment("TITLE=All Blues", "ARTIST=Miles Davis", "COMMENT="+string(bytes.Repeat([]byte("x"), 300)))...)
+
+	data := oggPage(0, 0, head)
+	data = append(data, oggPage(0, 1, tags)...)
+	data = append(data, oggPage(5*48000+312, 2, make([]byte, 200))...)
+
+	meta, err := ReadFile(writeTestFile(t, "track.opus", data))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if meta.Format != FormatOpus || meta.Title != "All Blues" || meta.Artist != "Miles Davis" {
+		t.Errorf("tags = %+v", meta)
+	}
+	if meta.Duration != 5*time.Second || meta.Channels != 2 {
+		t.Errorf("stream = %v, %d ch", meta.Duration, meta.Channels)
+	}
+}
+
+func TestReadFileUnsupported(t *testing.T) {
+	if _, err := ReadFile("cover.jpg"); err != ErrUnsupportedFormat {
+		t.Errorf("ReadFile() error = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func TestFileURL(t *testing.T) {
+	url := FileURL("/music/Miles Davis/Kind of Blue/01 So What.flac")
+	if url != "file:///music/Miles%20Davis/Kind%20of%20Blue/01%20So%20What.flac" {
+		t.Errorf("FileURL() = %q", url)
+	}
+	path, ok := PathFromURL(url)
+	if !ok || path != filepath.FromSlash("/music/Miles Davis/Kind of Blue/01 So What.flac") {
+		t.Errorf("PathFromURL() = %q, %v", path, ok)
+	}
+	if _, ok := PathFromURL("https://example.com/track.mp3"); ok {
+		t.Error("PathFromURL() accepted an http URL")
+	}
+}
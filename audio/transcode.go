@@ -0,0 +1,82 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContentType returns the MIME type a format is served with
+func ContentType(format string) string {
+	switch format {
+	case FormatMP3:
+		return "audio/mpeg"
+	case FormatFLAC:
+		return "audio/flac"
+	case FormatOgg:
+		return "audio/ogg"
+	case FormatOpus:
+		return "audio/ogg; codecs=opus"
+	}
+	return "application/octet-stream"
+}
+
+// Transcoder converts a file to another format while it is streamed
+type Transcoder interface {
+	// ContentType is the MIME type of the output
+	ContentType() string
+	// Transcode writes the file from start onwards to w, stopping when ctx is cancelled
+	Transcode(ctx context.Context, path string, start time.Duration, w io.Writer) error
+}
+
+// FFmpegTranscoder transcodes with an ffmpeg binary
+type FFmpegTranscoder struct {
+	Binary  string // Path to ffmpeg
+	Muxer   string // ffmpeg output format, such as "mp3" or "ogg"
+	Codec   string // ffmpeg audio encoder, such as "libmp3lame" or "libopus"
+	Bitrate int    // kbps
+	MIME    string
+}
+
+func (t FFmpegTranscoder) ContentType() string {
+	return t.MIME
+}
+
+func (t FFmpegTranscoder) Transcode(ctx context.Context, path string, start time.Duration, w io.Writer) error {
+	args := []string{"-hide_banner", "-loglevel", "error", "-nostdin"}
+	if start > 0 {
+		args = append(args, "-ss", strconv.FormatFloat(start.Seconds(), 'f', 3, 64))
+	}
+	args = append(args, "-i", path, "-vn", "-map_metadata", "-1",
+		"-c:a", t.Codec, "-b:a", fmt.Sprintf("%dk", t.Bitrate), "-f", t.Muxer, "pipe:1")
+
+	cmd := exec.CommandContext(ctx, t.Binary, args...)
+	var stderr bytes.Buffer
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("ffmpeg: %w: %s", err, msg)
+		}
+		return fmt.Errorf("ffmpeg: %w", err)
+	}
+	return nil
+}
+
+// DefaultTranscoders returns MP3 and Opus transcoders keyed by output format when ffmpeg is
+// installed, or nil when it is not
+func DefaultTranscoders() map[string]Transcoder {
+	binary, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil
+	}
+	return map[string]Transcoder{
+		FormatMP3:  FFmpegTranscoder{Binary: binary, Muxer: "mp3", Codec: "libmp3lame", Bitrate: 192, MIME: ContentType(FormatMP3)},
+		FormatOpus: FFmpegTranscoder{Binary: binary, Muxer: "ogg", Codec: "libopus", Bitrate: 128, MIME: ContentType(FormatOpus)},
+	}
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPCacheConfig sizes the cache of external metadata API responses
+type HTTPCacheConfig struct {
+	Enabled    bool `env:"HTTP_CACHE_ENABLED" envDefault:"true"`
+	MaxEntries int  `env:"HTTP_CACHE_MAX_ENTRIES" envDefault:"50000"`
+	MaxSizeMB  int  `env:"HTTP_CACHE_MAX_SIZE_MB" envDefault:"256"`
+}
+
+var HTTPCache = loadHTTPCacheConfig()
+
+func loadHTTPCacheConfig() HTTPCacheConfig {
+	cfg := HTTPCacheConfig{
+		Enabled:    true,
+		MaxEntries: 50000,
+		MaxSizeMB:  256,
+	}
+
+	if v := os.Getenv("HTTP_CACHE_ENABLED"); v != "" {
+		cfg.Enabled = v == "true" || v == "1"
+	}
+
+	if v := os.Getenv("HTTP_CACHE_MAX_ENTRIES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.MaxEntries = n
+		}
+	}
+
+	if v := os.Getenv("HTTP_CACHE_MAX_SIZE_MB"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.MaxSizeMB = n
+		}
+	}
+
+	return cfg
+}
+
+// HTTPCacheTTL returns the TTL set for a source with HTTP_CACHE_TTL_<SOURCE>, e.g.
+// HTTP_CACHE_TTL_DISCOGS=12h
+func HTTPCacheTTL(source string) (time.Duration, bool) {
+	v := os.Getenv("HTTP_CACHE_TTL_" + strings.ToUpper(source))
+	if v == "" {
+		return 0, false
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, false
+	}
+	return d, true
+}
@@ -1,10 +1,14 @@
 package config
 
 import (
+	"log"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
+	"vinylfo/httpreplay"
+
 	"github.com/joho/godotenv"
 )
 
@@ -56,21 +60,53 @@ func loadHTTPConfig() HTTPConfig {
 	return cfg
 }
 
+var (
+	transportOnce sync.Once
+	transport     http.RoundTripper
+)
+
+// Transport returns the transport of the external API clients: the default one, or one that
+// records or replays responses when HTTP_REPLAY_MODE is record, replay or auto
+// Fixtures are kept in HTTP_REPLAY_DIR (default fixtures/http)
+func Transport() http.RoundTripper {
+	transportOnce.Do(func() {
+		mode := os.Getenv("HTTP_REPLAY_MODE")
+		if mode == "" {
+			return
+		}
+		dir := os.Getenv("HTTP_REPLAY_DIR")
+		if dir == "" {
+			dir = "fixtures/http"
+		}
+		t, err := httpreplay.New(mode, dir, nil)
+		if err != nil {
+			log.Printf("Warning: %v, sending requests as usual", err)
+			return
+		}
+		log.Printf("HTTP replay: %s mode, fixtures in %s", mode, dir)
+		transport = t
+	})
+	return transport
+}
+
 func DefaultClient() *http.Client {
 	return &http.Client{
-		Timeout: HTTP.DefaultTimeout,
+		Timeout:   HTTP.DefaultTimeout,
+		Transport: Transport(),
 	}
 }
 
 func DiscogsClient() *http.Client {
 	return &http.Client{
-		Timeout: HTTP.DiscogsTimeout,
+		Timeout:   HTTP.DiscogsTimeout,
+		Transport: Transport(),
 	}
 }
 
 func DurationClient() *http.Client {
 	return &http.Client{
-		Timeout: HTTP.DurationTimeout,
+		Timeout:   HTTP.DurationTimeout,
+		Transport: Transport(),
 	}
 }
 
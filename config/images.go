@@ -0,0 +1,24 @@
+package config
+
+import (
+	"os"
+)
+
+// ImagesConfig locates the on-disk store of album images
+type ImagesConfig struct {
+	Dir string `env:"IMAGE_STORE_DIR" envDefault:"images"`
+}
+
+var Images = loadImagesConfig()
+
+func loadImagesConfig() ImagesConfig {
+	cfg := ImagesConfig{
+		Dir: "images",
+	}
+
+	if v := os.Getenv("IMAGE_STORE_DIR"); v != "" {
+		cfg.Dir = v
+	}
+
+	return cfg
+}
@@ -5,9 +5,10 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"strings"
 
+	"vinylfo/coverart"
 	"vinylfo/models"
+	"vinylfo/search"
 	"vinylfo/services"
 
 	"github.com/gin-gonic/gin"
@@ -44,11 +45,15 @@ func (c *AlbumController) GetAlbums(ctx *gin.Context) {
 
 	// Validate sort field
 	allowedSorts := map[string]bool{
-		"title":        true,
-		"artist":       true,
-		"release_year": true,
-		"genre":        true,
-		"created_at":   true,
+		"title":            true,
+		"artist":           true,
+		"release_year":     true,
+		"genre":            true,
+		"created_at":       true,
+		"format":           true,
+		"catalog_number":   true,
+		"purchase_date":    true,
+		"storage_location": true,
 	}
 	if !allowedSorts[sortBy] {
 		sortBy = "artist"
@@ -64,13 +69,27 @@ func (c *AlbumController) GetAlbums(ctx *gin.Context) {
 	var albums []models.Album
 	var total int64
 
-	c.db.Model(&models.Album{}).Count(&total)
+	query, err := applyAlbumFilters(c.db.Model(&models.Album{}), ctx)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Collapse editions of the same master release into the first copy added
+	if ctx.Query("group_editions") == "true" {
+		firstEditions, _ := applyAlbumFilters(c.db.Model(&models.Album{}), ctx)
+		query = query.Where("discogs_master_id IS NULL OR id IN (?)",
+			firstEditions.Select("MIN(id)").Where("discogs_master_id IS NOT NULL").Group("discogs_master_id"))
+	}
+
+	query.Session(&gorm.Session{}).Count(&total)
 
-	result := c.db.Order(fmt.Sprintf("LOWER(%s) %s", sortBy, order)).Offset(offset).Limit(limit).Find(&albums)
+	result := query.Order(fmt.Sprintf("LOWER(%s) %s", sortBy, order)).Offset(offset).Limit(limit).Find(&albums)
 	if result.Error != nil {
 		ctx.JSON(500, gin.H{"error": "Failed to fetch albums"})
 		return
 	}
+	countEditions(c.db, albums)
 
 	totalPages := int(total) / limit
 	if int(total)%limit > 0 {
@@ -97,7 +116,7 @@ func (c *AlbumController) SearchAlbums(ctx *gin.Context) {
 
 	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "25"))
-	sortBy := ctx.DefaultQuery("sort", "artist")
+	sortBy := ctx.DefaultQuery("sort", "relevance")
 	order := ctx.DefaultQuery("order", "asc")
 
 	if page < 1 {
@@ -117,9 +136,10 @@ func (c *AlbumController) SearchAlbums(ctx *gin.Context) {
 		"release_year": true,
 		"genre":        true,
 		"created_at":   true,
+		"relevance":    true,
 	}
 	if !allowedSorts[sortBy] {
-		sortBy = "artist"
+		sortBy = "relevance"
 	}
 
 	// Validate order
@@ -131,14 +151,56 @@ func (c *AlbumController) SearchAlbums(ctx *gin.Context) {
 
 	var albums []models.Album
 	var total int64
-	searchTerm := "%" + strings.ToLower(query) + "%"
 
-	c.db.Model(&models.Album{}).Where("LOWER(title) LIKE ? OR LOWER(artist) LIKE ?", searchTerm, searchTerm).Count(&total)
+	index := search.NewIndex(c.db)
+	parsed := search.ParseQuery(query)
+	parsed.Types = nil
 
-	result := c.db.Where("LOWER(title) LIKE ? OR LOWER(artist) LIKE ?", searchTerm, searchTerm).Order(fmt.Sprintf("LOWER(%s) %s", sortBy, order)).Offset(offset).Limit(limit).Find(&albums)
-	if result.Error != nil {
-		ctx.JSON(500, gin.H{"error": "Failed to search albums"})
-		return
+	if sortBy == "relevance" {
+		results, err := index.Search(parsed, search.Options{Types: []string{search.DocAlbum}, Limit: limit, Offset: offset})
+		if err != nil {
+			log.Printf("Album search failed for %q: %v", query, err)
+			ctx.JSON(500, gin.H{"error": "Failed to search albums"})
+			return
+		}
+		total = results.Total
+
+		ids := make([]uint, len(results.Hits))
+		for i, hit := range results.Hits {
+			ids[i] = hit.ID
+		}
+		var found []models.Album
+		if len(ids) > 0 {
+			if err := c.db.Where("id IN ?", ids).Find(&found).Error; err != nil {
+				ctx.JSON(500, gin.H{"error": "Failed to search albums"})
+				return
+			}
+		}
+		byID := make(map[uint]models.Album, len(found))
+		for _, album := range found {
+			byID[album.ID] = album
+		}
+		albums = make([]models.Album, 0, len(ids))
+		for _, id := range ids {
+			if album, ok := byID[id]; ok {
+				albums = append(albums, album)
+			}
+		}
+	} else {
+		matches, err := index.Filter(parsed, search.DocAlbum)
+		if err != nil {
+			log.Printf("Album search failed for %q: %v", query, err)
+			ctx.JSON(500, gin.H{"error": "Failed to search albums"})
+			return
+		}
+
+		c.db.Model(&models.Album{}).Where("id IN (?)", matches).Count(&total)
+
+		result := c.db.Where("id IN (?)", matches).Order(fmt.Sprintf("LOWER(%s) %s", sortBy, order)).Offset(offset).Limit(limit).Find(&albums)
+		if result.Error != nil {
+			ctx.JSON(500, gin.H{"error": "Failed to search albums"})
+			return
+		}
 	}
 
 	totalPages := int(total) / limit
@@ -174,6 +236,8 @@ func (c *AlbumController) GetAlbumByID(ctx *gin.Context) {
 	ctx.JSON(200, album)
 }
 
+// GetAlbumImage serves the album cover, resized with ?size=thumb|small|medium
+// Covers not moved to the image store yet are served full size from the albums table
 func (c *AlbumController) GetAlbumImage(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -182,13 +246,24 @@ func (c *AlbumController) GetAlbumImage(ctx *gin.Context) {
 		return
 	}
 	var album models.Album
-	result := c.db.First(&album, id)
+	result := c.db.Select("id, cover_image_hash").First(&album, id)
 	if result.Error != nil {
 		log.Printf("GetAlbumImage DB error: %v", result.Error)
 		ctx.JSON(404, gin.H{"error": "Album not found"})
 		return
 	}
 
+	if album.CoverImageHash != "" {
+		serveStoredImage(ctx, album.CoverImageHash, ctx.Query("size"), revalidateCacheControl)
+		return
+	}
+
+	if err := c.db.Select("id, discogs_cover_image, discogs_cover_image_type").First(&album, id).Error; err != nil {
+		log.Printf("GetAlbumImage DB error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to load album image"})
+		return
+	}
+
 	if len(album.DiscogsCoverImage) == 0 {
 		ctx.JSON(404, gin.H{"error": "No image found for this album"})
 		return
@@ -242,10 +317,13 @@ func (c *AlbumController) UpdateAlbumImage(ctx *gin.Context) {
 	}
 
 	// Update the album with the new image
-	album.DiscogsCoverImage = imageData
+	album.CoverImageHash, album.DiscogsCoverImage = services.StoreCoverImage(imageData, imageType)
+	album.CoverPalette = services.CoverPalette(album.CoverImageHash)
 	album.DiscogsCoverImageType = imageType
 	album.CoverImageURL = req.ImageURL
 	album.CoverImageFailed = false
+	album.CoverImageSource = coverart.SourceManual
+	album.CoverImageWidth, album.CoverImageHeight = 0, 0
 
 	result = c.db.Save(&album)
 	if result.Error != nil {
@@ -300,6 +378,10 @@ func (c *AlbumController) CreateAlbum(ctx *gin.Context) {
 		ctx.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	if err := normalizeAlbumMedia(&album); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 
 	result := c.db.Create(&album)
 	if result.Error != nil {
@@ -323,11 +405,16 @@ func (c *AlbumController) UpdateAlbum(ctx *gin.Context) {
 		ctx.JSON(404, gin.H{"error": "Album not found"})
 		return
 	}
+	before := album
 
 	if err := ctx.ShouldBindJSON(&album); err != nil {
 		ctx.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	if err := normalizeAlbumMedia(&album); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 
 	result = c.db.Save(&album)
 	if result.Error != nil {
@@ -335,6 +422,9 @@ func (c *AlbumController) UpdateAlbum(ctx *gin.Context) {
 		ctx.JSON(500, gin.H{"error": "Failed to update album"})
 		return
 	}
+	if err := services.QueueAlbumChanges(c.db, &before, &album); err != nil {
+		log.Printf("UpdateAlbum: failed to queue Discogs collection changes: %v", err)
+	}
 	ctx.JSON(200, album)
 }
 
@@ -565,11 +655,14 @@ func (c *AlbumController) DeleteAlbum(ctx *gin.Context) {
 			}
 		}
 
-		// 5. Delete track history entries
+		// 5. Delete track history entries and play events
 		if len(trackIDs) > 0 {
 			if err := tx.Where("track_id IN ?", trackIDs).Delete(&models.TrackHistory{}).Error; err != nil {
 				return err
 			}
+			if err := tx.Where("track_id IN ?", trackIDs).Delete(&models.PlayEvent{}).Error; err != nil {
+				return err
+			}
 		}
 
 		// 6. Delete session playlist entries (removes tracks from all playlists)
@@ -632,6 +725,16 @@ func (c *AlbumController) DeleteAlbum(ctx *gin.Context) {
 			}
 		}
 
+		// 7b. Forget playlists' preference for this edition
+		if err := tx.Where("album_id = ?", id).Delete(&models.PlaylistEdition{}).Error; err != nil {
+			return err
+		}
+
+		// 7c. Queue removal from the Discogs collection
+		if err := services.QueueCollectionRemoval(tx, &album); err != nil {
+			return err
+		}
+
 		// 8. Delete the tracks themselves
 		if err := tx.Where("album_id = ?", id).Delete(&models.Track{}).Error; err != nil {
 			return err
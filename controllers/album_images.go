@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"vinylfo/coverart"
+	"vinylfo/imagestore"
+	"vinylfo/models"
+	"vinylfo/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Stored images never change under a hash; album covers can be replaced, so clients revalidate them
+const (
+	immutableCacheControl  = "public, max-age=31536000, immutable"
+	revalidateCacheControl = "no-cache"
+)
+
+// AlbumImageResponse is an album image with the URLs of its sizes
+type AlbumImageResponse struct {
+	models.AlbumImage
+	URLs map[string]string `json:"urls"`
+}
+
+func imageURLs(hash string) map[string]string {
+	urls := map[string]string{imagestore.SizeFull: "/images/" + hash}
+	for size := range imagestore.Sizes {
+		urls[size] = "/images/" + hash + "?size=" + size
+	}
+	return urls
+}
+
+// acceptsWebP reports whether the client listed WebP in its Accept header
+func acceptsWebP(ctx *gin.Context) bool {
+	return strings.Contains(ctx.GetHeader("Accept"), "image/webp")
+}
+
+// serveStoredImage writes a size of a stored image, as WebP to clients that accept it; clients
+// sending its ETag get a 304
+func serveStoredImage(ctx *gin.Context, hash, size, cacheControl string) {
+	store := imagestore.Default()
+	if store == nil {
+		ctx.JSON(404, gin.H{"error": "Image not found"})
+		return
+	}
+
+	lookup := store.Path
+	if acceptsWebP(ctx) {
+		lookup = store.WebPPath
+	}
+	path, contentType, err := lookup(hash, size)
+	switch {
+	case errors.Is(err, imagestore.ErrUnknownSize):
+		ctx.JSON(400, gin.H{"error": "Unknown image size, use thumb, small, medium or full"})
+		return
+	case errors.Is(err, imagestore.ErrInvalidHash), errors.Is(err, imagestore.ErrNotFound):
+		ctx.JSON(404, gin.H{"error": "Image not found"})
+		return
+	case err != nil:
+		log.Printf("serveStoredImage error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to load image"})
+		return
+	}
+
+	if size == "" {
+		size = imagestore.SizeFull
+	}
+	format := ""
+	if contentType == "image/webp" {
+		format = "-webp"
+	}
+	ctx.Header("ETag", fmt.Sprintf(`"%s-%s%s"`, hash, size, format))
+	ctx.Header("Vary", "Accept")
+	ctx.Header("Cache-Control", cacheControl)
+	ctx.Header("Content-Type", contentType)
+	ctx.File(path)
+}
+
+// GetImage serves a stored image by hash, resized with ?size=thumb|small|medium
+func (c *AlbumController) GetImage(ctx *gin.Context) {
+	serveStoredImage(ctx, ctx.Param("hash"), ctx.Query("size"), immutableCacheControl)
+}
+
+// GetAlbumImages lists the front and back covers, labels and other images of an album
+func (c *AlbumController) GetAlbumImages(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid album ID"})
+		return
+	}
+
+	var album models.Album
+	if err := c.db.Select("id, cover_image_hash").First(&album, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(404, gin.H{"error": "Album not found"})
+			return
+		}
+		log.Printf("GetAlbumImages DB error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to load album"})
+		return
+	}
+
+	var rows []models.AlbumImage
+	if err := c.db.Where("album_id = ?", id).Order("position").Find(&rows).Error; err != nil {
+		log.Printf("GetAlbumImages DB error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to load album images"})
+		return
+	}
+
+	images := make([]AlbumImageResponse, 0, len(rows))
+	for _, row := range rows {
+		images = append(images, AlbumImageResponse{AlbumImage: row, URLs: imageURLs(row.Hash)})
+	}
+	ctx.JSON(200, gin.H{
+		"album_id":   album.ID,
+		"cover_hash": album.CoverImageHash,
+		"images":     images,
+	})
+}
+
+// UpdateAlbumImageKind sets what an album image shows
+// Making an image the front cover also makes it the album's cover
+func (c *AlbumController) UpdateAlbumImageKind(ctx *gin.Context) {
+	albumID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid album ID"})
+		return
+	}
+	imageID, err := strconv.ParseUint(ctx.Param("imageId"), 10, 32)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid image ID"})
+		return
+	}
+
+	var req struct {
+		Kind string `json:"kind" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil || !slices.Contains(models.ImageKinds, req.Kind) {
+		ctx.JSON(400, gin.H{"error": "Kind must be one of front, back, label, inner or other"})
+		return
+	}
+
+	var image models.AlbumImage
+	if err := c.db.Where("id = ? AND album_id = ?", imageID, albumID).First(&image).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(404, gin.H{"error": "Image not found"})
+			return
+		}
+		log.Printf("UpdateAlbumImageKind DB error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to load image"})
+		return
+	}
+
+	err = c.db.Transaction(func(tx *gorm.DB) error {
+		if req.Kind == models.ImageFront {
+			if err := tx.Model(&models.AlbumImage{}).
+				Where("album_id = ? AND kind = ? AND id <> ?", albumID, models.ImageFront, image.ID).
+				Update("kind", models.ImageOther).Error; err != nil {
+				return err
+			}
+			updates := map[string]interface{}{
+				"cover_image_hash":         image.Hash,
+				"cover_image_url":          image.SourceURL,
+				"discogs_cover_image":      nil,
+				"discogs_cover_image_type": image.ContentType,
+				"cover_image_failed":       false,
+				"cover_image_source":       coverart.SourceDiscogs,
+				"cover_image_width":        image.Width,
+				"cover_image_height":       image.Height,
+			}
+			for column, value := range services.CoverPaletteUpdates(image.Hash) {
+				updates[column] = value
+			}
+			if err := tx.Model(&models.Album{}).Where("id = ?", albumID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&image).Update("kind", req.Kind).Error
+	})
+	if err != nil {
+		log.Printf("UpdateAlbumImageKind save error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to update image"})
+		return
+	}
+
+	ctx.JSON(200, AlbumImageResponse{AlbumImage: image, URLs: imageURLs(image.Hash)})
+}
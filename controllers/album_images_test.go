@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"vinylfo/imagestore"
+	"vinylfo/models"
+)
+
+func TestGetAlbumImage_ServesStoredSizes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	controller := NewAlbumController(db, nil)
+
+	store, err := imagestore.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("open image store: %v", err)
+	}
+	imagestore.SetDefault(store)
+	defer imagestore.SetDefault(nil)
+
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 600, 600)), nil)
+	info, _ := store.Put(buf.Bytes())
+
+	stored := models.Album{Title: "Blue Train", Artist: "John Coltrane", CoverImageHash: info.Hash}
+	legacy := models.Album{Title: "Giant Steps", Artist: "John Coltrane", DiscogsCoverImage: []byte("legacy"), DiscogsCoverImageType: "image/jpeg"}
+	db.Create(&stored)
+	db.Create(&legacy)
+
+	router := gin.New()
+	router.GET("/albums/:id/image", controller.GetAlbumImage)
+
+	get := func(path, etag string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/albums/1/image?size=thumb", "")
+	if w.Code != 200 || w.Header().Get("Content-Type") != "image/jpeg" {
+		t.Fatalf("thumb: %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	thumb, _, err := image.DecodeConfig(w.Body)
+	if err != nil || thumb.Width != 150 {
+		t.Errorf("thumb is %d px wide (%v), want 150", thumb.Width, err)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" || w.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf("thumb headers: ETag=%q Cache-Control=%q", etag, w.Header().Get("Cache-Control"))
+	}
+
+	if w := get("/albums/1/image?size=thumb", etag); w.Code != http.StatusNotModified {
+		t.Errorf("revalidation returned %d, want 304", w.Code)
+	}
+	// Clients accepting WebP get the WebP variant under its own ETag
+	store.SetWebPEncoder(fakeWebPEncoder{})
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/albums/1/image?size=thumb", nil)
+	req.Header.Set("Accept", "image/avif,image/webp,*/*")
+	router.ServeHTTP(w, req)
+	if w.Code != 200 || w.Header().Get("Content-Type") != "image/webp" || w.Header().Get("ETag") == etag || w.Header().Get("Vary") != "Accept" {
+		t.Errorf("webp thumb: %d %s ETag=%q Vary=%q", w.Code, w.Header().Get("Content-Type"), w.Header().Get("ETag"), w.Header().Get("Vary"))
+	}
+	if w := get("/albums/1/image?size=thumb", ""); w.Header().Get("Content-Type") != "image/jpeg" {
+		t.Errorf("thumb without Accept: %s, want image/jpeg", w.Header().Get("Content-Type"))
+	}
+
+	if w := get("/albums/1/image?size=poster", ""); w.Code != 400 {
+		t.Errorf("unknown size returned %d, want 400", w.Code)
+	}
+	if w := get("/albums/2/image?size=thumb", ""); w.Code != 200 || w.Body.String() != "legacy" {
+		t.Errorf("legacy cover: %d %q", w.Code, w.Body.String())
+	}
+}
+
+type fakeWebPEncoder struct{}
+
+func (fakeWebPEncoder) EncodeWebP(ctx context.Context, src, dst string) error {
+	return os.WriteFile(dst, []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), 0644)
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"vinylfo/models"
+	"vinylfo/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var validRPMs = map[int]bool{0: true, 33: true, 45: true, 78: true}
+
+// normalizeAlbumMedia validates the physical media fields of an album and
+// rewrites grades and dates to their canonical form
+func normalizeAlbumMedia(album *models.Album) error {
+	if album.MediaCondition != "" {
+		grade := models.NormalizeGrade(album.MediaCondition)
+		if grade == "" || grade == models.SleeveGeneric || grade == models.SleeveNoCover {
+			return fmt.Errorf("Invalid media_condition %q: use a Goldmine grade (M, NM, VG+, VG, G+, G, F, P)", album.MediaCondition)
+		}
+		album.MediaCondition = grade
+	}
+
+	if album.SleeveCondition != "" {
+		grade := models.NormalizeGrade(album.SleeveCondition)
+		if grade == "" {
+			return fmt.Errorf("Invalid sleeve_condition %q: use a Goldmine grade, Generic or No Cover", album.SleeveCondition)
+		}
+		album.SleeveCondition = grade
+	}
+
+	if !validRPMs[album.RPM] {
+		return fmt.Errorf("Invalid rpm %d: must be 33, 45 or 78", album.RPM)
+	}
+	if album.DiscCount < 0 {
+		return fmt.Errorf("disc_count cannot be negative")
+	}
+	if album.Rating < 0 || album.Rating > 5 {
+		return fmt.Errorf("Invalid rating %d: must be between 0 and 5", album.Rating)
+	}
+	if album.PurchasePrice != nil && *album.PurchasePrice < 0 {
+		return fmt.Errorf("purchase_price cannot be negative")
+	}
+	album.PurchaseCurrency = strings.ToUpper(strings.TrimSpace(album.PurchaseCurrency))
+	if album.PurchaseCurrency != "" && len(album.PurchaseCurrency) != 3 {
+		return fmt.Errorf("Invalid purchase_currency %q: use a 3-letter ISO code", album.PurchaseCurrency)
+	}
+
+	if album.PurchaseDate != "" {
+		date := services.NormalizeDate(album.PurchaseDate)
+		if date == "" {
+			return fmt.Errorf("Invalid purchase_date %q: use YYYY-MM-DD", album.PurchaseDate)
+		}
+		album.PurchaseDate = date
+	}
+
+	return nil
+}
+
+// applyAlbumFilters narrows an album query by the physical media query parameters
+func applyAlbumFilters(query *gorm.DB, ctx *gin.Context) (*gorm.DB, error) {
+	if format := ctx.Query("format"); format != "" {
+		query = query.Where("format = ?", format)
+	}
+	if rpm := ctx.Query("rpm"); rpm != "" {
+		value, err := strconv.Atoi(rpm)
+		if err != nil || !validRPMs[value] {
+			return nil, fmt.Errorf("Invalid rpm: must be 33, 45 or 78")
+		}
+		query = query.Where("rpm = ?", value)
+	}
+
+	for _, param := range []string{"media_condition", "sleeve_condition"} {
+		if value := ctx.Query(param); value != "" {
+			grade := models.NormalizeGrade(value)
+			if grade == "" {
+				return nil, fmt.Errorf("Invalid %s: %s", param, value)
+			}
+			query = query.Where(param+" = ?", grade)
+		}
+		if value := ctx.Query("min_" + param); value != "" {
+			grades := models.GradesAtLeast(models.NormalizeGrade(value))
+			if grades == nil {
+				return nil, fmt.Errorf("Invalid min_%s: use a Goldmine grade", param)
+			}
+			query = query.Where(param+" IN ?", grades)
+		}
+	}
+
+	if catno := ctx.Query("catalog_number"); catno != "" {
+		query = query.Where("catalog_number = ?", catno)
+	}
+	if barcode := ctx.Query("barcode"); barcode != "" {
+		query = query.Where("barcode = ?", barcode)
+	}
+	if location := ctx.Query("location"); location != "" {
+		query = query.Where("storage_location LIKE ?", "%"+location+"%")
+	}
+
+	if after := ctx.Query("purchased_after"); after != "" {
+		date := services.NormalizeDate(after)
+		if date == "" {
+			return nil, fmt.Errorf("Invalid purchased_after: use YYYY-MM-DD")
+		}
+		query = query.Where("purchase_date >= ?", date)
+	}
+	if before := ctx.Query("purchased_before"); before != "" {
+		date := services.NormalizeDate(before)
+		if date == "" {
+			return nil, fmt.Errorf("Invalid purchased_before: use YYYY-MM-DD")
+		}
+		query = query.Where("purchase_date <> '' AND purchase_date <= ?", date)
+	}
+
+	if minPrice := ctx.Query("min_price"); minPrice != "" {
+		value, err := strconv.ParseFloat(minPrice, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid min_price")
+		}
+		query = query.Where("purchase_price >= ?", value)
+	}
+	if maxPrice := ctx.Query("max_price"); maxPrice != "" {
+		value, err := strconv.ParseFloat(maxPrice, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid max_price")
+		}
+		query = query.Where("purchase_price <= ?", value)
+	}
+
+	if orphaned := ctx.Query("orphaned"); orphaned != "" {
+		value, err := strconv.ParseBool(orphaned)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid orphaned: use true or false")
+		}
+		if value {
+			query = query.Where("discogs_orphaned_at IS NOT NULL")
+		} else {
+			query = query.Where("discogs_orphaned_at IS NULL")
+		}
+	}
+
+	return query, nil
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"vinylfo/models"
+)
+
+func TestGetAlbumsMediaFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	controller := NewAlbumController(db, nil)
+
+	price := 20.0
+	db.Create(&models.Album{Title: "Animals", Artist: "Pink Floyd", Format: "LP", RPM: 33, MediaCondition: "NM", StorageLocation: "Shelf A1", PurchaseDate: "2022-03-01", PurchasePrice: &price})
+	db.Create(&models.Album{Title: "Arnold Layne", Artist: "Pink Floyd", Format: `7"`, RPM: 45, MediaCondition: "VG", StorageLocation: "Box 2"})
+	db.Create(&models.Album{Title: "Wish You Were Here", Artist: "Pink Floyd", Format: "LP", RPM: 33, MediaCondition: "G+", StorageLocation: "Shelf A2"})
+
+	router := gin.New()
+	router.GET("/albums", controller.GetAlbums)
+
+	tests := []struct {
+		query     string
+		wantCode  int
+		wantTotal int
+	}{
+		{"", 200, 3},
+		{"?format=LP", 200, 2},
+		{"?rpm=45", 200, 1},
+		{"?min_media_condition=VG", 200, 2},
+		{"?media_condition=near+mint", 200, 1},
+		{"?location=shelf", 200, 2},
+		{"?purchased_after=2022-01-01", 200, 1},
+		{"?min_price=10&max_price=25", 200, 1},
+		{"?rpm=16", 400, 0},
+		{"?min_media_condition=shiny", 400, 0},
+		{"?purchased_before=soon", 400, 0},
+		{"?orphaned=true", 200, 0},
+		{"?orphaned=maybe", 400, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/albums"+tt.query, nil)
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			if tt.wantCode != 200 {
+				return
+			}
+
+			var response struct {
+				Data  []models.Album `json:"data"`
+				Total int            `json:"total"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if response.Total != tt.wantTotal || len(response.Data) != tt.wantTotal {
+				t.Errorf("Expected %d albums, got total=%d len=%d", tt.wantTotal, response.Total, len(response.Data))
+			}
+		})
+	}
+}
+
+func TestUpdateAlbumMediaValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	controller := NewAlbumController(db, nil)
+
+	album := models.Album{Title: "Meddle", Artist: "Pink Floyd"}
+	db.Create(&album)
+
+	router := gin.New()
+	router.PUT("/albums/:id", controller.UpdateAlbum)
+
+	send := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/albums/1", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(`{"media_condition":"Very Good Plus (VG+)","sleeve_condition":"generic","rpm":33,"purchase_date":"2020/05/17","purchase_currency":"eur"}`)
+	if w.Code != 200 {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var saved models.Album
+	db.First(&saved, album.ID)
+	if saved.MediaCondition != "VG+" || saved.SleeveCondition != "Generic" || saved.PurchaseDate != "2020-05-17" || saved.PurchaseCurrency != "EUR" {
+		t.Errorf("Fields not normalized: %+v", saved)
+	}
+
+	for _, body := range []string{
+		`{"media_condition":"Generic"}`,
+		`{"rpm":16}`,
+		`{"disc_count":-1}`,
+		`{"purchase_price":-5}`,
+		`{"purchase_date":"someday"}`,
+	} {
+		if w := send(body); w.Code != 400 {
+			t.Errorf("Expected status 400 for %s, got %d", body, w.Code)
+		}
+	}
+}
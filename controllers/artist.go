@@ -0,0 +1,256 @@
+package controllers
+
+import (
+	"log"
+	"sort"
+	"strconv"
+
+	"vinylfo/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ArtistController struct {
+	db *gorm.DB
+}
+
+func NewArtistController(db *gorm.DB) *ArtistController {
+	return &ArtistController{db: db}
+}
+
+// artistAlbum is an album in an artist's discography with the artist's roles on it
+type artistAlbum struct {
+	ID                    uint     `json:"id"`
+	Title                 string   `json:"title"`
+	Artist                string   `json:"artist"`
+	ReleaseYear           int      `json:"release_year"`
+	Genre                 string   `json:"genre"`
+	Format                string   `json:"format"`
+	CoverImageURL         string   `json:"cover_image_url"`
+	DiscogsCoverImageType string   `json:"discogs_cover_image_type"`
+	Roles                 []string `json:"roles"`         // Roles on the album as a whole
+	TrackCredits          int      `json:"track_credits"` // Tracks credited individually
+}
+
+// artistTrack is a track the artist is credited on individually
+type artistTrack struct {
+	ID         uint     `json:"id"`
+	Title      string   `json:"title"`
+	Position   string   `json:"position"`
+	Duration   int      `json:"duration"`
+	AlbumID    uint     `json:"album_id"`
+	AlbumTitle string   `json:"album_title"`
+	Roles      []string `json:"roles"`
+	Details    []string `json:"details"`
+}
+
+// creditView is an album or track credit with the artist name resolved
+type creditView struct {
+	ArtistID   uint   `json:"artist_id"`
+	ArtistName string `json:"artist_name"`
+	TrackID    *uint  `json:"track_id,omitempty"`
+	Role       string `json:"role"`
+	Detail     string `json:"detail,omitempty"`
+	Joiner     string `json:"join,omitempty"`
+}
+
+// GetArtists lists credited artists with the number of albums they appear on
+func (c *ArtistController) GetArtists(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	role := ctx.Query("role")
+	query := ctx.Query("q")
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	base := c.db.Table("artists").
+		Joins("JOIN credits ON credits.artist_id = artists.id")
+	if role != "" {
+		base = base.Where("credits.role = ?", role)
+	}
+	if query != "" {
+		base = base.Where("LOWER(artists.name) LIKE LOWER(?)", "%"+query+"%")
+	}
+
+	var total int64
+	if err := base.Session(&gorm.Session{}).Distinct("artists.id").Count(&total).Error; err != nil {
+		log.Printf("GetArtists count error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to fetch artists"})
+		return
+	}
+
+	var artists []struct {
+		ID         uint   `json:"id"`
+		Name       string `json:"name"`
+		DiscogsID  *int   `json:"discogs_id"`
+		AlbumCount int    `json:"album_count"`
+	}
+	err := base.Select("artists.id, artists.name, artists.discogs_id, COUNT(DISTINCT credits.album_id) AS album_count").
+		Group("artists.id, artists.name, artists.discogs_id").
+		Order("LOWER(artists.name) ASC").
+		Offset((page - 1) * limit).Limit(limit).
+		Scan(&artists).Error
+	if err != nil {
+		log.Printf("GetArtists error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to fetch artists"})
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"data":       artists,
+		"page":       page,
+		"limit":      limit,
+		"total":      total,
+		"totalPages": (int(total) + limit - 1) / limit,
+	})
+}
+
+// GetArtistByID returns an artist with their discography from the local collection
+func (c *ArtistController) GetArtistByID(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid artist ID"})
+		return
+	}
+
+	var artist models.Artist
+	if err := c.db.First(&artist, id).Error; err != nil {
+		ctx.JSON(404, gin.H{"error": "Artist not found"})
+		return
+	}
+
+	var credits []models.Credit
+	if err := c.db.Where("artist_id = ?", artist.ID).Order("album_id, position").Find(&credits).Error; err != nil {
+		log.Printf("GetArtistByID credits error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to fetch artist credits"})
+		return
+	}
+
+	albumIDs := make([]uint, 0)
+	trackIDs := make([]uint, 0)
+	seenAlbum := make(map[uint]bool)
+	for _, credit := range credits {
+		if !seenAlbum[credit.AlbumID] {
+			seenAlbum[credit.AlbumID] = true
+			albumIDs = append(albumIDs, credit.AlbumID)
+		}
+		if credit.TrackID != nil {
+			trackIDs = append(trackIDs, *credit.TrackID)
+		}
+	}
+
+	albums := make([]artistAlbum, 0, len(albumIDs))
+	if len(albumIDs) > 0 {
+		c.db.Model(&models.Album{}).
+			Select("id, title, artist, release_year, genre, format, cover_image_url, discogs_cover_image_type").
+			Where("id IN ?", albumIDs).
+			Scan(&albums)
+	}
+	var tracks []models.Track
+	if len(trackIDs) > 0 {
+		c.db.Where("id IN ?", trackIDs).Find(&tracks)
+	}
+
+	albumIndex := make(map[uint]*artistAlbum, len(albums))
+	for i := range albums {
+		albums[i].Roles = []string{}
+		albumIndex[albums[i].ID] = &albums[i]
+	}
+	trackByID := make(map[uint]models.Track, len(tracks))
+	for _, t := range tracks {
+		trackByID[t.ID] = t
+	}
+
+	appearances := make([]artistTrack, 0)
+	trackIndex := make(map[uint]int)
+	for _, credit := range credits {
+		album, ok := albumIndex[credit.AlbumID]
+		if !ok {
+			continue
+		}
+		if credit.TrackID == nil {
+			album.Roles = appendUnique(album.Roles, credit.Role)
+			continue
+		}
+
+		track, ok := trackByID[*credit.TrackID]
+		if !ok {
+			continue
+		}
+		i, seen := trackIndex[track.ID]
+		if !seen {
+			album.TrackCredits++
+			i = len(appearances)
+			trackIndex[track.ID] = i
+			appearances = append(appearances, artistTrack{
+				ID:         track.ID,
+				Title:      track.Title,
+				Position:   track.Position,
+				Duration:   track.Duration,
+				AlbumID:    album.ID,
+				AlbumTitle: album.Title,
+				Roles:      []string{},
+				Details:    []string{},
+			})
+		}
+		appearances[i].Roles = appendUnique(appearances[i].Roles, credit.Role)
+		if credit.Detail != "" {
+			appearances[i].Details = appendUnique(appearances[i].Details, credit.Detail)
+		}
+	}
+
+	sort.SliceStable(albums, func(i, j int) bool {
+		if albums[i].ReleaseYear != albums[j].ReleaseYear {
+			return albums[i].ReleaseYear < albums[j].ReleaseYear
+		}
+		return albums[i].Title < albums[j].Title
+	})
+
+	ctx.JSON(200, gin.H{
+		"artist": artist,
+		"albums": albums,
+		"tracks": appearances,
+	})
+}
+
+// GetAlbumCredits returns the credits of an album and its tracks
+func (c *ArtistController) GetAlbumCredits(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid album ID"})
+		return
+	}
+
+	credits := make([]creditView, 0)
+	err = c.db.Table("credits").
+		Select("credits.artist_id, artists.name AS artist_name, credits.track_id, credits.role, credits.detail, credits.joiner").
+		Joins("JOIN artists ON artists.id = credits.artist_id").
+		Where("credits.album_id = ?", id).
+		Order("credits.track_id, credits.position").
+		Scan(&credits).Error
+	if err != nil {
+		log.Printf("GetAlbumCredits error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to fetch credits"})
+		return
+	}
+
+	ctx.JSON(200, credits)
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"vinylfo/models"
+)
+
+func TestGetArtistByID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.Artist{}, &models.Credit{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	controller := NewArtistController(db)
+
+	nile := models.Artist{Name: "Nile Rodgers"}
+	db.Create(&nile)
+	chic := models.Album{Title: "C'est Chic", Artist: "Chic", ReleaseYear: 1978}
+	ram := models.Album{Title: "Random Access Memories", Artist: "Daft Punk", ReleaseYear: 2013}
+	db.Create(&chic)
+	db.Create(&ram)
+	getLucky := models.Track{AlbumID: ram.ID, Title: "Get Lucky", Position: "D1"}
+	db.Create(&getLucky)
+
+	db.Create(&models.Credit{ArtistID: nile.ID, AlbumID: ram.ID, TrackID: &getLucky.ID, Role: models.CreditRolePerformer, Detail: "Guitar"})
+	db.Create(&models.Credit{ArtistID: nile.ID, AlbumID: chic.ID, Role: models.CreditRoleProducer})
+	db.Create(&models.Credit{ArtistID: nile.ID, AlbumID: chic.ID, Role: models.CreditRolePerformer, Position: 1})
+
+	router := gin.New()
+	router.GET("/artists/:id", controller.GetArtistByID)
+	router.GET("/artists", controller.GetArtists)
+
+	t.Run("discography", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/artists/1", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != 200 {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var response struct {
+			Artist models.Artist `json:"artist"`
+			Albums []struct {
+				Title        string   `json:"title"`
+				Roles        []string `json:"roles"`
+				TrackCredits int      `json:"track_credits"`
+			} `json:"albums"`
+			Tracks []struct {
+				Title   string   `json:"title"`
+				Details []string `json:"details"`
+			} `json:"tracks"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		if len(response.Albums) != 2 || response.Albums[0].Title != "C'est Chic" {
+			t.Fatalf("Expected both albums oldest first, got %+v", response.Albums)
+		}
+		if len(response.Albums[0].Roles) != 2 || response.Albums[1].TrackCredits != 1 {
+			t.Errorf("Unexpected roles/track credits: %+v", response.Albums)
+		}
+		if len(response.Tracks) != 1 || response.Tracks[0].Title != "Get Lucky" || response.Tracks[0].Details[0] != "Guitar" {
+			t.Errorf("Expected Get Lucky track credit, got %+v", response.Tracks)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/artists/99", nil)
+		router.ServeHTTP(w, req)
+		if w.Code != 404 {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("list filtered by role", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/artists?role=producer", nil)
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Data []struct {
+				Name       string `json:"name"`
+				AlbumCount int    `json:"album_count"`
+			} `json:"data"`
+			Total int `json:"total"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if response.Total != 1 || response.Data[0].AlbumCount != 1 {
+			t.Errorf("Expected one producer on one album, got %+v", response)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"vinylfo/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CollectionController struct {
+	db *gorm.DB
+}
+
+func NewCollectionController(db *gorm.DB) *CollectionController {
+	return &CollectionController{db: db}
+}
+
+// ExportCollection downloads the whole collection as versioned JSON, or as CSV in the
+// Discogs collection export layout
+// GET /collection/export?format=json|csv
+func (c *CollectionController) ExportCollection(ctx *gin.Context) {
+	format := strings.ToLower(ctx.DefaultQuery("format", "json"))
+	if format != "json" && format != "csv" {
+		ctx.JSON(400, gin.H{"error": "Format must be json or csv"})
+		return
+	}
+
+	export, err := services.ExportCollection(c.db)
+	if err != nil {
+		log.Printf("ExportCollection error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to export collection"})
+		return
+	}
+
+	filename := fmt.Sprintf("vinylfo-collection-%s.%s", time.Now().Format("2006-01-02"), format)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	if format == "json" {
+		ctx.IndentedJSON(200, export)
+		return
+	}
+
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	if err := services.WriteCollectionCSV(ctx.Writer, export.Albums); err != nil {
+		log.Printf("ExportCollection CSV error: %v", err)
+	}
+}
+
+// ImportCollection adds albums from a JSON or CSV export, or previews the changes with dry_run
+// The export is sent as the "file" form field or as the request body
+// POST /collection/import?dry_run=true&overwrite=false&format=json|csv
+func (c *CollectionController) ImportCollection(ctx *gin.Context) {
+	format := strings.ToLower(ctx.Query("format"))
+	var body io.Reader = ctx.Request.Body
+	if file, err := ctx.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			ctx.JSON(400, gin.H{"error": "Failed to read uploaded file"})
+			return
+		}
+		defer f.Close()
+		body = f
+		if format == "" && strings.EqualFold(filepath.Ext(file.Filename), ".csv") {
+			format = "csv"
+		}
+	} else if format == "" && strings.Contains(ctx.ContentType(), "csv") {
+		format = "csv"
+	}
+
+	var albums []services.CollectionAlbum
+	switch format {
+	case "", "json":
+		format = "json"
+		export, err := services.ParseCollectionJSON(body)
+		if err != nil {
+			ctx.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		albums = export.Albums
+	case "csv":
+		var err error
+		if albums, err = services.ParseCollectionCSV(body); err != nil {
+			ctx.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	default:
+		ctx.JSON(400, gin.H{"error": "Format must be json or csv"})
+		return
+	}
+
+	opts := services.ImportOptions{
+		DryRun:    ctx.Query("dry_run") == "true",
+		Overwrite: ctx.Query("overwrite") == "true",
+	}
+	result, err := services.ImportCollection(c.db, albums, opts)
+	if err != nil {
+		log.Printf("ImportCollection error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to import collection: " + err.Error()})
+		return
+	}
+
+	if !opts.DryRun {
+		log.Printf("ImportCollection: imported %s - created=%d, updated=%d, unchanged=%d, skipped=%d",
+			format, result.Created, result.Updated, result.Unchanged, result.Skipped)
+	}
+	ctx.JSON(200, result)
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"vinylfo/coverart"
+	"vinylfo/jobs"
+	"vinylfo/models"
+	"vinylfo/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CoverArtController struct {
+	db         *gorm.DB
+	jobManager *jobs.Manager
+	covers     *services.CoverArtService
+}
+
+func NewCoverArtController(db *gorm.DB, jobManager *jobs.Manager) *CoverArtController {
+	return &CoverArtController{
+		db:         db,
+		jobManager: jobManager,
+		covers:     services.NewCoverArtService(db),
+	}
+}
+
+// CoverRefreshPayload is the payload of cover refresh jobs
+type CoverRefreshPayload struct{}
+
+func coverAlbumID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid album ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetCandidates lists the covers the album could have, from Discogs, local files, the
+// Cover Art Archive and iTunes
+// GET /albums/:id/covers
+func (c *CoverArtController) GetCandidates(ctx *gin.Context) {
+	id, ok := coverAlbumID(ctx)
+	if !ok {
+		return
+	}
+
+	candidates, err := c.covers.Candidates(ctx.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(404, gin.H{"error": "Album not found"})
+			return
+		}
+		log.Printf("GetCandidates error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to look up covers"})
+		return
+	}
+	if candidates == nil {
+		candidates = []coverart.Candidate{}
+	}
+	ctx.JSON(200, gin.H{"album_id": id, "candidates": candidates})
+}
+
+// ApplyCandidate makes one of the album's candidates its cover
+// POST /albums/:id/covers
+func (c *CoverArtController) ApplyCandidate(ctx *gin.Context) {
+	id, ok := coverAlbumID(ctx)
+	if !ok {
+		return
+	}
+	var candidate coverart.Candidate
+	if err := ctx.ShouldBindJSON(&candidate); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if candidate.Source == "" || candidate.URL == "" {
+		ctx.JSON(400, gin.H{"error": "source and url are required"})
+		return
+	}
+
+	album, err := c.covers.Apply(ctx.Request.Context(), id, candidate)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			ctx.JSON(404, gin.H{"error": "Album not found"})
+		case errors.Is(err, services.ErrNotACoverCandidate):
+			ctx.JSON(400, gin.H{"error": "Not a cover candidate of this album"})
+		default:
+			log.Printf("ApplyCandidate error: %v", err)
+			ctx.JSON(502, gin.H{"error": "Failed to fetch cover: " + err.Error()})
+		}
+		return
+	}
+
+	log.Printf("ApplyCandidate: album %d cover set from %s: %s", album.ID, candidate.Source, candidate.URL)
+
+	ctx.JSON(200, gin.H{
+		"message":            "Album cover updated successfully",
+		"album_id":           album.ID,
+		"cover_image_hash":   album.CoverImageHash,
+		"cover_image_source": album.CoverImageSource,
+		"cover_image_width":  album.CoverImageWidth,
+		"cover_image_height": album.CoverImageHeight,
+	})
+}
+
+// StartRefresh queues a search for missing and better covers
+// POST /api/covers/refresh
+func (c *CoverArtController) StartRefresh(ctx *gin.Context) {
+	if job := c.jobManager.Pending(models.JobKindCoverRefresh); job != nil {
+		ctx.JSON(409, gin.H{"error": "A cover refresh is already running", "job": job})
+		return
+	}
+
+	job, err := c.jobManager.Enqueue(models.JobKindCoverRefresh, CoverRefreshPayload{})
+	if err != nil {
+		log.Printf("StartRefresh error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to queue cover refresh"})
+		return
+	}
+	ctx.JSON(202, gin.H{"job": job})
+}
+
+// RunRefreshJob looks for missing and better covers as a background job
+func (c *CoverArtController) RunRefreshJob(jc *jobs.Context, payload CoverRefreshPayload) error {
+	result, err := c.covers.RefreshCovers(jc.Context(), jobProgress(jc))
+	if errors.Is(err, services.ErrNoImageStore) {
+		return jobs.Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	jc.SetResult(fmt.Sprintf("Checked %d albums: %d covers found, %d upgraded, %d measured",
+		result.Checked, result.Found, result.Upgraded, result.Measured))
+	return nil
+}
+
+// ScheduledRefresh looks for missing and better covers for the scheduler
+func (c *CoverArtController) ScheduledRefresh(runCtx context.Context) (string, error) {
+	if c.jobManager.Pending(models.JobKindCoverRefresh) != nil {
+		return "", services.ErrTaskBusy
+	}
+	return runJob(runCtx, c.jobManager, models.JobKindCoverRefresh, CoverRefreshPayload{})
+}
@@ -2,11 +2,11 @@ package controllers
 
 import (
 	"log"
-	"os"
 	"strconv"
 	"strings"
 
 	"vinylfo/discogs"
+	"vinylfo/jobs"
 	"vinylfo/models"
 	"vinylfo/services"
 	"vinylfo/sync"
@@ -24,52 +24,19 @@ func getDiscogsClient() *discogs.Client {
 type DiscogsController struct {
 	db              *gorm.DB
 	progressService *services.SyncProgressService
+	jobManager      *jobs.Manager
 }
 
-func NewDiscogsController(db *gorm.DB) *DiscogsController {
+func NewDiscogsController(db *gorm.DB, jobManager *jobs.Manager) *DiscogsController {
 	return &DiscogsController{
 		db:              db,
 		progressService: services.NewSyncProgressService(db),
+		jobManager:      jobManager,
 	}
 }
 
 func (c *DiscogsController) getDiscogsClientWithOAuth() *discogs.Client {
-	var config models.AppConfig
-	err := c.db.First(&config).Error
-	if err != nil {
-		log.Printf("OAUTH: ERROR loading config from database: %v", err)
-		return nil
-	}
-
-	// Check if tokens exist before trying to decrypt
-	if config.DiscogsAccessToken == "" || config.DiscogsAccessSecret == "" {
-		log.Printf("OAUTH: No tokens stored - Discogs not connected")
-		return nil
-	}
-
-	// Decrypt the stored tokens
-	accessToken, err := utils.Decrypt(config.DiscogsAccessToken)
-	if err != nil {
-		log.Printf("OAUTH: ERROR decrypting access token: %v", err)
-		return nil
-	}
-
-	accessSecret, err := utils.Decrypt(config.DiscogsAccessSecret)
-	if err != nil {
-		log.Printf("OAUTH: ERROR decrypting access secret: %v", err)
-		return nil
-	}
-
-	consumerKey := os.Getenv("DISCOGS_CONSUMER_KEY")
-	consumerSecret := os.Getenv("DISCOGS_CONSUMER_SECRET")
-
-	oauth := &discogs.OAuthConfig{
-		ConsumerKey:    consumerKey,
-		ConsumerSecret: consumerSecret,
-		AccessToken:    accessToken,
-		AccessSecret:   accessSecret,
-	}
-	return discogs.NewClientWithOAuth("", oauth)
+	return services.NewDiscogsClientFromConfig(c.db)
 }
 
 type SyncBatch = sync.SyncBatch
@@ -260,6 +227,7 @@ func (c *DiscogsController) CreateAlbum(ctx *gin.Context) {
 	}
 
 	var album models.Album
+	var release map[string]interface{}
 
 	if input.FromDiscogs && input.DiscogsID > 0 {
 		client := c.getDiscogsClientWithOAuth()
@@ -268,6 +236,7 @@ func (c *DiscogsController) CreateAlbum(ctx *gin.Context) {
 		} else {
 			discogsData, err := client.GetAlbum(input.DiscogsID)
 			if err == nil {
+				release = discogsData
 				if v, ok := discogsData["title"].(string); ok {
 					album.Title = v
 				}
@@ -299,6 +268,9 @@ func (c *DiscogsController) CreateAlbum(ctx *gin.Context) {
 					album.CoverImageURL = v
 				}
 				album.DiscogsID = utils.IntPtr(input.DiscogsID)
+				if v, ok := discogsData["master_id"].(int); ok {
+					album.DiscogsMasterID = utils.IntPtr(v)
+				}
 
 				if tracks, ok := discogsData["tracklist"].([]map[string]interface{}); ok {
 					input.Tracks = []struct {
@@ -403,8 +375,9 @@ func (c *DiscogsController) CreateAlbum(ctx *gin.Context) {
 			log.Printf("CreateAlbum: failed to download image: %v", imageErr)
 			album.CoverImageFailed = true
 		} else {
-			album.DiscogsCoverImage = imageData
+			album.CoverImageHash, album.DiscogsCoverImage = services.StoreCoverImage(imageData, imageType)
 			album.DiscogsCoverImageType = imageType
+			album.CoverPalette = services.CoverPalette(album.CoverImageHash)
 		}
 	} else if album.CoverImageURL != "" {
 		imageData, imageType, imageErr := downloadImage(album.CoverImageURL)
@@ -412,8 +385,9 @@ func (c *DiscogsController) CreateAlbum(ctx *gin.Context) {
 			log.Printf("CreateAlbum: failed to download image from Discogs: %v", imageErr)
 			album.CoverImageFailed = true
 		} else {
-			album.DiscogsCoverImage = imageData
+			album.CoverImageHash, album.DiscogsCoverImage = services.StoreCoverImage(imageData, imageType)
 			album.DiscogsCoverImageType = imageType
+			album.CoverPalette = services.CoverPalette(album.CoverImageHash)
 		}
 	}
 
@@ -443,6 +417,18 @@ func (c *DiscogsController) CreateAlbum(ctx *gin.Context) {
 		}
 	}
 
+	if release != nil {
+		if err := services.SaveReleaseCredits(c.db, album.ID, release); err != nil {
+			log.Printf("CreateAlbum: failed to save credits for album %d: %v", album.ID, err)
+		}
+	}
+
+	if input.FromDiscogs && album.DiscogsID != nil {
+		if err := services.QueueCollectionAdd(c.db, &album, discogs.DefaultCollectionFolder); err != nil {
+			log.Printf("CreateAlbum: failed to queue Discogs collection add for album %d: %v", album.ID, err)
+		}
+	}
+
 	c.db.Preload("Tracks").First(&album, album.ID)
 	ctx.JSON(201, album)
 }
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"vinylfo/discogs"
+	"vinylfo/jobs"
+	"vinylfo/models"
+	"vinylfo/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CodeImportPayload is the payload of batch barcode and catalog number imports
+type CodeImportPayload struct {
+	Codes   []string `json:"codes"`
+	Country string   `json:"country"`
+	Format  string   `json:"format"`
+}
+
+// LookupCode finds the Discogs releases for a barcode or catalog number, best match first
+// GET /api/discogs/lookup?code=075992731314&country=US&format=Vinyl
+func (c *DiscogsController) LookupCode(ctx *gin.Context) {
+	code := strings.TrimSpace(ctx.Query("code"))
+	if code == "" {
+		ctx.JSON(400, gin.H{"error": "Barcode or catalog number is required"})
+		return
+	}
+
+	client, ok := c.lookupClient(ctx)
+	if !ok {
+		return
+	}
+
+	prefs := services.LookupPreferences{Country: ctx.Query("country"), Format: ctx.Query("format")}
+	results, err := services.NewCodeImporter(c.db, client).Lookup(code, prefs)
+	if err != nil {
+		log.Printf("LookupCode error: %v", err)
+		ctx.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	value, codeType := services.ClassifyCode(code)
+	ctx.JSON(200, gin.H{
+		"code":      value,
+		"code_type": codeType,
+		"results":   results,
+		"count":     len(results),
+	})
+}
+
+// ImportCode adds the release for a barcode or catalog number in one step: the chosen
+// discogs_id, or the best match when it is left out
+// POST /api/discogs/lookup/import
+func (c *DiscogsController) ImportCode(ctx *gin.Context) {
+	var input struct {
+		Code      string `json:"code" binding:"required"`
+		DiscogsID int    `json:"discogs_id"`
+		Country   string `json:"country"`
+		Format    string `json:"format"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	client, ok := c.lookupClient(ctx)
+	if !ok {
+		return
+	}
+
+	prefs := services.LookupPreferences{Country: input.Country, Format: input.Format}
+	album, err := services.NewCodeImporter(c.db, client).Import(input.Code, input.DiscogsID, prefs)
+	switch {
+	case errors.Is(err, services.ErrAlreadyInCollection):
+		ctx.JSON(409, gin.H{"error": "Release is already in the collection", "album_id": album.ID})
+		return
+	case errors.Is(err, services.ErrNoReleaseFound):
+		ctx.JSON(404, gin.H{"error": "No Discogs release found for " + input.Code})
+		return
+	case err != nil:
+		log.Printf("ImportCode error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to import release: " + err.Error()})
+		return
+	}
+
+	c.db.Preload("Tracks").First(album, album.ID)
+	ctx.JSON(201, album)
+}
+
+// ImportCodeBatch queues a background import of many barcodes or catalog numbers, sent as
+// JSON or as a scanner's CSV in the "file" form field
+// POST /api/discogs/lookup/batch
+func (c *DiscogsController) ImportCodeBatch(ctx *gin.Context) {
+	var payload CodeImportPayload
+	if file, err := ctx.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			ctx.JSON(400, gin.H{"error": "Failed to read uploaded file"})
+			return
+		}
+		defer f.Close()
+
+		payload.Codes, err = services.ParseCodeList(f)
+		if err != nil {
+			ctx.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		payload.Country = ctx.PostForm("country")
+		payload.Format = ctx.PostForm("format")
+	} else if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes := make([]string, 0, len(payload.Codes))
+	seen := make(map[string]bool)
+	for _, code := range payload.Codes {
+		code = strings.TrimSpace(code)
+		if code != "" && !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	payload.Codes = codes
+
+	if len(payload.Codes) == 0 {
+		ctx.JSON(400, gin.H{"error": "No barcodes or catalog numbers to import"})
+		return
+	}
+	if len(payload.Codes) > services.MaxCodeImportBatch {
+		ctx.JSON(400, gin.H{"error": fmt.Sprintf("A batch can import at most %d codes", services.MaxCodeImportBatch)})
+		return
+	}
+
+	if _, ok := c.lookupClient(ctx); !ok {
+		return
+	}
+
+	job, err := c.jobManager.Enqueue(models.JobKindCodeImport, payload)
+	if err != nil {
+		log.Printf("ImportCodeBatch error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to queue import"})
+		return
+	}
+	ctx.JSON(202, gin.H{
+		"job":   job,
+		"codes": len(payload.Codes),
+	})
+}
+
+// GetCodeBatch returns a batch import's job and the outcome of each of its codes
+// GET /api/discogs/lookup/batch/:id
+func (c *DiscogsController) GetCodeBatch(ctx *gin.Context) {
+	id, ok := jobID(ctx)
+	if !ok {
+		return
+	}
+
+	job, err := c.jobManager.Get(id)
+	if err != nil || job.Kind != models.JobKindCodeImport {
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("GetCodeBatch error: %v", err)
+		}
+		ctx.JSON(404, gin.H{"error": "Batch import not found"})
+		return
+	}
+
+	var rows []models.CodeImport
+	if err := c.db.Where("job_id = ?", id).Order("id ASC").Find(&rows).Error; err != nil {
+		log.Printf("GetCodeBatch error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to load batch import"})
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, row := range rows {
+		counts[row.Status]++
+	}
+	ctx.JSON(200, gin.H{
+		"job":    job,
+		"codes":  rows,
+		"counts": counts,
+	})
+}
+
+// RunCodeImportJob imports a batch of codes as a background job, pausing between codes
+func (c *DiscogsController) RunCodeImportJob(jc *jobs.Context, payload CodeImportPayload) error {
+	client := c.getDiscogsClientWithOAuth()
+	if client == nil {
+		return jobs.Permanent(fmt.Errorf("failed to get Discogs client - not authenticated"))
+	}
+
+	prefs := services.LookupPreferences{Country: payload.Country, Format: payload.Format}
+	summary, err := services.NewCodeImporter(c.db, client).ImportBatch(jc.Context(), jc.ID(), payload.Codes, prefs, jobProgress(jc))
+	jc.SetResult(fmt.Sprintf("Imported %d of %d codes: %d already in the collection, %d not found, %d failed",
+		summary.Imported, summary.Total, summary.Exists, summary.NotFound, summary.Failed))
+	return err
+}
+
+// lookupClient returns the Discogs client for code lookups, responding with an error when
+// Discogs is not connected
+func (c *DiscogsController) lookupClient(ctx *gin.Context) (*discogs.Client, bool) {
+	var config models.AppConfig
+	if err := c.db.First(&config).Error; err != nil {
+		ctx.JSON(500, gin.H{"error": "Failed to load config"})
+		return nil, false
+	}
+	if !config.IsDiscogsConnected {
+		ctx.JSON(401, gin.H{"error": "Discogs connection required", "hint": "Connect your Discogs account in Settings to look up releases"})
+		return nil, false
+	}
+
+	client := c.getDiscogsClientWithOAuth()
+	if client == nil {
+		ctx.JSON(500, gin.H{"error": "Failed to get Discogs client - not authenticated"})
+		return nil, false
+	}
+	return client, true
+}
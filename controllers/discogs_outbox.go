@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"vinylfo/models"
+	"vinylfo/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetOutbox lists collection changes waiting to be pushed to Discogs, with a review of them
+func (c *DiscogsController) GetOutbox(ctx *gin.Context) {
+	statuses := []string{models.ChangeStatusPending, models.ChangeStatusConflict, models.ChangeStatusFailed}
+	if status := ctx.Query("status"); status != "" {
+		statuses = []string{status}
+	}
+
+	var changes []models.CollectionChange
+	if err := c.db.Where("status IN ?", statuses).Order("id ASC").Find(&changes).Error; err != nil {
+		log.Printf("GetOutbox error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to load collection changes"})
+		return
+	}
+
+	review, err := services.OutboxReview(c.db)
+	if err != nil {
+		log.Printf("GetOutbox review error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to review collection changes"})
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"changes": changes,
+		"total":   len(changes),
+		"review":  review,
+	})
+}
+
+// PushOutbox pushes pending collection changes now instead of waiting for the next sync
+func (c *DiscogsController) PushOutbox(ctx *gin.Context) {
+	state := getSyncState()
+	if state.IsActive() {
+		ctx.JSON(409, gin.H{"error": "A sync is in progress; changes are pushed when it starts"})
+		return
+	}
+
+	var config models.AppConfig
+	if err := c.db.First(&config).Error; err != nil {
+		ctx.JSON(500, gin.H{"error": "Failed to load config"})
+		return
+	}
+	if !config.IsDiscogsConnected || config.DiscogsUsername == "" {
+		ctx.JSON(400, gin.H{"error": "Discogs not connected"})
+		return
+	}
+
+	client := services.NewDiscogsClientFromConfig(c.db)
+	if client == nil {
+		ctx.JSON(500, gin.H{"error": "Failed to get Discogs client - not authenticated"})
+		return
+	}
+
+	result, err := services.PushCollectionChanges(c.db, client, config.DiscogsUsername)
+	if err != nil {
+		log.Printf("PushOutbox error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to push collection changes"})
+		return
+	}
+	ctx.JSON(200, result)
+}
+
+// ResolveOutboxChange settles a conflict by keeping either the local or the Discogs value
+func (c *DiscogsController) ResolveOutboxChange(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid change ID"})
+		return
+	}
+
+	var input struct {
+		Keep string `json:"keep" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	change, err := services.ResolveCollectionChange(c.db, uint(id), input.Keep)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(404, gin.H{"error": "Change not found"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(200, change)
+}
+
+// DiscardOutboxChange drops a change so it is never pushed
+func (c *DiscogsController) DiscardOutboxChange(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid change ID"})
+		return
+	}
+
+	var change models.CollectionChange
+	if err := c.db.First(&change, id).Error; err != nil {
+		ctx.JSON(404, gin.H{"error": "Change not found"})
+		return
+	}
+	if change.Status == models.ChangeStatusPushed {
+		ctx.JSON(400, gin.H{"error": "Change has already been pushed"})
+		return
+	}
+
+	if err := c.db.Model(&change).Update("status", models.ChangeStatusDiscarded).Error; err != nil {
+		log.Printf("DiscardOutboxChange error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to discard change"})
+		return
+	}
+	ctx.JSON(200, gin.H{"message": "Change discarded", "id": change.ID})
+}
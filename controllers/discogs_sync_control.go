@@ -1,12 +1,10 @@
 package controllers
 
 import (
-	"context"
 	"log"
 	"time"
 
 	"vinylfo/models"
-	"vinylfo/services"
 	"vinylfo/sync"
 
 	"github.com/gin-gonic/gin"
@@ -91,20 +89,16 @@ func (c *DiscogsController) ResumeSync(ctx *gin.Context) {
 	log.Printf("ResumeSync: starting sync from folder %d, page %d, processed=%d, folders_count=%d",
 		existingProgress.FolderID, existingProgress.CurrentPage, existingProgress.Processed, len(getSyncState().Folders))
 
-	updatedState := getSyncState()
-	workerCtx, workerCancel := context.WithCancel(context.Background())
-	worker := services.NewSyncWorker(c.db, client, syncManager, services.SyncConfig{
-		Username:      config.DiscogsUsername,
-		BatchSize:     config.SyncBatchSize,
-		SyncMode:      existingProgress.SyncMode,
-		CurrentFolder: existingProgress.FolderID,
-		Folders:       &updatedState.Folders,
-	}, workerCtx, workerCancel)
-	go worker.Run()
+	job, err := c.startSyncJob(existingProgress.SyncMode, existingProgress.FolderID)
+	if err != nil {
+		ctx.JSON(500, gin.H{"error": "Failed to start sync: " + err.Error()})
+		return
+	}
 
 	ctx.JSON(200, gin.H{
 		"message":    "Sync resumed",
 		"sync_state": state,
+		"job_id":     job.ID,
 	})
 }
 
@@ -253,19 +247,16 @@ func (c *DiscogsController) ResumeSyncFromPause(ctx *gin.Context) {
 
 		newState := getSyncState()
 		log.Printf("ResumeSyncFromPause: restarting sync worker at page %d, processed=%d", newState.CurrentPage, newState.Processed)
-		workerCtx, workerCancel := context.WithCancel(context.Background())
-		worker := services.NewSyncWorker(c.db, client, syncManager, services.SyncConfig{
-			Username:      config.DiscogsUsername,
-			BatchSize:     config.SyncBatchSize,
-			SyncMode:      newState.SyncMode,
-			CurrentFolder: newState.CurrentFolder,
-			Folders:       &newState.Folders,
-		}, workerCtx, workerCancel)
-		go worker.Run()
+		job, err := c.startSyncJob(newState.SyncMode, newState.CurrentFolder)
+		if err != nil {
+			ctx.JSON(500, gin.H{"error": "Failed to start sync: " + err.Error()})
+			return
+		}
 
 		ctx.JSON(200, gin.H{
 			"message":    "Sync resumed from pause",
 			"sync_state": newState,
+			"job_id":     job.ID,
 		})
 		return
 	}
@@ -344,20 +335,16 @@ func (c *DiscogsController) ResumeSyncFromPause(ctx *gin.Context) {
 		log.Printf("ResumeSyncFromPause: resuming sync from folder %d, page %d, processed=%d, folders_count=%d",
 			existingProgress.FolderID, existingProgress.CurrentPage, existingProgress.Processed, len(getSyncState().Folders))
 
-		updatedState := getSyncState()
-		workerCtx, workerCancel := context.WithCancel(context.Background())
-		worker := services.NewSyncWorker(c.db, client, syncManager, services.SyncConfig{
-			Username:      config.DiscogsUsername,
-			BatchSize:     config.SyncBatchSize,
-			SyncMode:      existingProgress.SyncMode,
-			CurrentFolder: existingProgress.FolderID,
-			Folders:       &updatedState.Folders,
-		}, workerCtx, workerCancel)
-		go worker.Run()
+		job, err := c.startSyncJob(existingProgress.SyncMode, existingProgress.FolderID)
+		if err != nil {
+			ctx.JSON(500, gin.H{"error": "Failed to start sync: " + err.Error()})
+			return
+		}
 
 		ctx.JSON(200, gin.H{
 			"message":    "Sync resumed from pause",
 			"sync_state": state,
+			"job_id":     job.ID,
 		})
 		return
 	}
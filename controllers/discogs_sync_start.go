@@ -2,11 +2,13 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"time"
 
 	"vinylfo/discogs"
+	"vinylfo/jobs"
 	"vinylfo/models"
 	"vinylfo/services"
 	"vinylfo/sync"
@@ -226,6 +228,8 @@ func (c *DiscogsController) StartSync(ctx *gin.Context) {
 		releases, totalItems, err = client.GetUserCollectionByFolder(config.DiscogsUsername, folderID, 1, config.SyncBatchSize)
 	} else if input.SyncMode == "specific" {
 		releases, totalItems, err = client.GetUserCollectionByFolder(config.DiscogsUsername, input.FolderID, 1, config.SyncBatchSize)
+	} else if input.SyncMode == services.SyncModeIncremental {
+		// The worker scans for what changed since the last sync before fetching anything
 	} else {
 		releases, err = client.GetUserCollection(config.DiscogsUsername, 1, config.SyncBatchSize)
 		totalItems = len(releases)
@@ -254,19 +258,154 @@ func (c *DiscogsController) StartSync(ctx *gin.Context) {
 
 	setSyncState(state)
 
-	workerCtx, workerCancel := context.WithCancel(context.Background())
+	job, err := c.startSyncJob(input.SyncMode, state.CurrentFolder)
+	if err != nil {
+		ctx.JSON(500, gin.H{"error": "Failed to start sync: " + err.Error()})
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"message":   "Sync started",
+		"sync_mode": input.SyncMode,
+		"folder_id": state.CurrentFolder,
+		"job_id":    job.ID,
+	})
+}
+
+// ScheduledSync runs an incremental sync for the scheduler and waits for it to finish.
+// A sync that is running, paused or waiting to be resumed is left alone
+func (c *DiscogsController) ScheduledSync(runCtx context.Context) (string, error) {
+	state := getSyncState()
+	if state.IsActive() || c.jobManager.Pending(models.JobKindDiscogsSync) != nil {
+		return "", services.ErrTaskBusy
+	}
+
+	if progress := c.progressService.Load(state); progress != nil {
+		if progress.Status != "completed" {
+			return "", fmt.Errorf("%w: an interrupted sync is waiting to be resumed", services.ErrTaskBusy)
+		}
+		c.progressService.ArchiveToHistory(progress)
+		c.progressService.Delete(progress.ID)
+	}
+
+	var config models.AppConfig
+	if err := c.db.First(&config).Error; err != nil {
+		return "", fmt.Errorf("failed to load config: %w", err)
+	}
+	if !config.IsDiscogsConnected || config.DiscogsUsername == "" {
+		return "", fmt.Errorf("Discogs not connected")
+	}
+
+	client := c.getDiscogsClientWithOAuth()
+	if client == nil {
+		return "", fmt.Errorf("failed to get Discogs client - not authenticated")
+	}
+
+	ResetSyncState()
+	state = sync.SyncState{
+		Status:       sync.SyncStatusRunning,
+		CurrentPage:  1,
+		SyncMode:     services.SyncModeIncremental,
+		LastActivity: time.Now(),
+		LastBatch:    &SyncBatch{ID: 1},
+	}
+	setSyncState(state)
+
+	job, err := c.startSyncJob(services.SyncModeIncremental, 0)
+	if err != nil {
+		return "", err
+	}
+	return waitForJob(runCtx, c.jobManager, job.ID)
+}
+
+// SyncJobPayload is the payload of Discogs sync jobs. The sync state is set up by the
+// endpoint that queues the job; the job runs the worker over it
+type SyncJobPayload struct {
+	SyncMode      string `json:"sync_mode"`
+	CurrentFolder int    `json:"current_folder"`
+}
+
+// RunSyncJob runs the sync worker as a background job. The sync endpoints keep working on
+// the run through the shared sync state; cancelling the job leaves its progress to resume
+func (c *DiscogsController) RunSyncJob(jc *jobs.Context, payload SyncJobPayload) error {
+	state := getSyncState()
+	if !state.IsActive() {
+		return jobs.Permanent(fmt.Errorf("no sync to run; syncs are started through the Discogs sync endpoints"))
+	}
+
+	var config models.AppConfig
+	if err := c.db.First(&config).Error; err != nil {
+		updateSyncState(func(s *sync.SyncState) {
+			s.Status = sync.SyncStatusIdle
+		})
+		return jobs.Permanent(fmt.Errorf("failed to load config: %w", err))
+	}
+	client := c.getDiscogsClientWithOAuth()
+	if client == nil {
+		updateSyncState(func(s *sync.SyncState) {
+			s.Status = sync.SyncStatusIdle
+		})
+		return jobs.Permanent(fmt.Errorf("failed to get Discogs client - not authenticated"))
+	}
+
+	jc.OnPause(func() {
+		c.progressService.Save(getSyncState())
+		syncManager.RequestPause()
+		c.progressService.Save(getSyncState())
+	})
+	jc.OnResume(func() {
+		syncManager.RequestResume()
+	})
+
+	started := time.Now()
+	workerCtx, workerCancel := context.WithCancel(jc.Context())
+	defer workerCancel()
 	worker := services.NewSyncWorker(c.db, client, syncManager, services.SyncConfig{
 		Username:      config.DiscogsUsername,
 		BatchSize:     config.SyncBatchSize,
-		SyncMode:      input.SyncMode,
-		CurrentFolder: state.CurrentFolder,
+		SyncMode:      payload.SyncMode,
+		CurrentFolder: payload.CurrentFolder,
 		Folders:       &state.Folders,
 	}, workerCtx, workerCancel)
-	go worker.Run()
 
-	ctx.JSON(200, gin.H{
-		"message":   "Sync started",
-		"sync_mode": input.SyncMode,
-		"folder_id": state.CurrentFolder,
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		worker.Run()
+	}()
+
+	jc.Watch(done, func() {
+		current := getSyncState()
+		processed := current.UniqueProcessed
+		if processed == 0 {
+			processed = current.Processed
+		}
+		jc.SetPaused(current.IsPaused())
+		jc.SetProgress(processed, current.Total, fmt.Sprintf("Page %d", current.CurrentPage))
+	})
+
+	var history models.SyncHistory
+	c.db.Where("created_at >= ?", started).Order("id DESC").Limit(1).Find(&history)
+	if history.ID == 0 {
+		return jobs.ErrCancelled
+	}
+	jc.SetResult(fmt.Sprintf("Processed %d albums: %d added, %d orphaned", history.Processed, history.Added, history.Orphaned))
+	return nil
+}
+
+// startSyncJob queues the sync worker once the sync state is set up, putting the sync back
+// to idle if the job cannot be queued
+func (c *DiscogsController) startSyncJob(mode string, folderID int) (*models.Job, error) {
+	job, err := c.jobManager.Enqueue(models.JobKindDiscogsSync, SyncJobPayload{
+		SyncMode:      mode,
+		CurrentFolder: folderID,
 	})
+	if err != nil {
+		log.Printf("startSyncJob: failed to queue sync: %v", err)
+		updateSyncState(func(s *sync.SyncState) {
+			s.Status = sync.SyncStatusIdle
+		})
+		return nil, err
+	}
+	return job, nil
 }
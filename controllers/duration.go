@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	"vinylfo/jobs"
 	"vinylfo/models"
 	"vinylfo/services"
 
@@ -16,17 +17,22 @@ import (
 type DurationController struct {
 	db              *gorm.DB
 	resolverService *services.DurationResolverService
+	jobManager      *jobs.Manager
 }
 
-func NewDurationController(db *gorm.DB) *DurationController {
+func NewDurationController(db *gorm.DB, jobManager *jobs.Manager) *DurationController {
 	config := services.DefaultDurationResolverConfig()
 	config.ContactEmail = "https://github.com/xphox2/Vinylfo"
 	config.YouTubeAPIKey = os.Getenv("YOUTUBE_API_KEY")
 	config.LastFMAPIKey = os.Getenv("LASTFM_API_KEY")
+	if v := os.Getenv("DURATION_AUTO_APPLY_LOCAL_FILE"); v != "" {
+		config.AutoApplyLocalFile = v == "true" || v == "1"
+	}
 
 	return &DurationController{
 		db:              db,
 		resolverService: services.NewDurationResolverService(db, config),
+		jobManager:      jobManager,
 	}
 }
 
@@ -237,13 +243,16 @@ func (c *DurationController) ResolveAlbum(ctx *gin.Context) {
 		return
 	}
 
-	tracks, err := c.resolverService.GetTracksNeedingResolutionForAlbum(uint(albumID))
+	resolveCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	result, err := c.resolverService.ResolveAlbum(resolveCtx, album.ID)
 	if err != nil {
 		ctx.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
 
-	if len(tracks) == 0 {
+	if len(result.Resolutions) == 0 && result.Errors == 0 {
 		ctx.JSON(200, gin.H{
 			"message":      "No tracks need resolution in this album",
 			"resolved":     0,
@@ -253,19 +262,8 @@ func (c *DurationController) ResolveAlbum(ctx *gin.Context) {
 		return
 	}
 
-	resolveCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-	defer cancel()
-
-	var resolved, needsReview, failed int
-	var resolutions []models.DurationResolution
-
-	for _, track := range tracks {
-		resolution, err := c.resolverService.ResolveTrackDuration(resolveCtx, track)
-		if err != nil {
-			failed++
-			continue
-		}
-
+	resolved, needsReview, failed := 0, 0, result.Errors
+	for _, resolution := range result.Resolutions {
 		switch resolution.Status {
 		case "resolved":
 			resolved++
@@ -274,16 +272,22 @@ func (c *DurationController) ResolveAlbum(ctx *gin.Context) {
 		case "failed":
 			failed++
 		}
-		resolutions = append(resolutions, *resolution)
+	}
+
+	var releaseID string
+	if result.Release != nil {
+		releaseID = result.Release.ID
 	}
 
 	ctx.JSON(200, gin.H{
-		"message":      "Album resolution completed",
-		"total_tracks": len(tracks),
-		"resolved":     resolved,
-		"needs_review": needsReview,
-		"failed":       failed,
-		"resolutions":  resolutions,
+		"message":                "Album resolution completed",
+		"total_tracks":           len(result.Resolutions) + result.Errors,
+		"resolved":               resolved,
+		"needs_review":           needsReview,
+		"failed":                 failed,
+		"musicbrainz_release_id": releaseID,
+		"aligned_tracks":         result.Aligned,
+		"resolutions":            result.Resolutions,
 	})
 }
 
@@ -2,10 +2,13 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"sync"
 
 	"vinylfo/duration"
+	"vinylfo/jobs"
+	"vinylfo/models"
 	"vinylfo/services"
 
 	"github.com/gin-gonic/gin"
@@ -22,28 +25,25 @@ func (c *DurationController) StartBulkResolution(ctx *gin.Context) {
 	bulkMutex.Lock()
 	defer bulkMutex.Unlock()
 
-	if bulkStateManager != nil && bulkStateManager.IsRunning() {
+	if c.resolutionBusy() {
 		ctx.JSON(http.StatusConflict, gin.H{"error": "Bulk resolution already running"})
 		return
 	}
 
-	bulkStateManager = duration.NewStateManager()
-	workerCtx, cancel := context.WithCancel(context.Background())
-	bulkCancel = cancel
-
-	bulkWorker = services.NewDurationWorker(
-		c.db,
-		c.resolverService,
-		bulkStateManager,
-		workerCtx,
-		cancel,
-	)
-
-	go bulkWorker.Run()
+	job, err := c.jobManager.Enqueue(models.JobKindResolveDurations, ResolveDurationsPayload{})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start bulk resolution: " + err.Error()})
+		return
+	}
+	// Progress from the last run would otherwise show until the job starts
+	bulkStateManager = nil
+	bulkWorker = nil
+	bulkCancel = nil
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"message": "Bulk resolution started",
 		"status":  "running",
+		"job_id":  job.ID,
 	})
 }
 
@@ -97,6 +97,16 @@ func (c *DurationController) CancelBulkResolution(ctx *gin.Context) {
 
 func (c *DurationController) GetBulkProgress(ctx *gin.Context) {
 	if bulkStateManager == nil {
+		if job := c.jobManager.Pending(models.JobKindResolveDurations); job != nil {
+			ctx.JSON(http.StatusOK, gin.H{
+				"status":           "running",
+				"job_id":           job.ID,
+				"total_tracks":     0,
+				"processed_tracks": 0,
+			})
+			return
+		}
+
 		progressService := services.NewDurationProgressService(c.db)
 		saved, err := progressService.Load()
 		if err != nil || saved == nil {
@@ -155,3 +165,72 @@ func (c *DurationController) GetBulkProgress(ctx *gin.Context) {
 		"percent_complete":   percentComplete,
 	})
 }
+
+// ResolveDurationsPayload is the payload of bulk resolution jobs, which take no options
+type ResolveDurationsPayload struct{}
+
+// RunResolutionJob runs the duration worker as a background job. The bulk endpoints keep
+// working on the run through the shared state manager
+func (c *DurationController) RunResolutionJob(jc *jobs.Context, payload ResolveDurationsPayload) error {
+	stateManager := duration.NewStateManager()
+	stateManager.SetStatus(duration.ResolverStatusRunning)
+	workerCtx, cancel := context.WithCancel(jc.Context())
+	defer cancel()
+	worker := services.NewDurationWorker(c.db, c.resolverService, stateManager, workerCtx, cancel)
+
+	bulkMutex.Lock()
+	bulkStateManager = stateManager
+	bulkWorker = worker
+	bulkCancel = cancel
+	bulkMutex.Unlock()
+
+	jc.OnPause(stateManager.RequestPause)
+	jc.OnResume(stateManager.RequestResume)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		worker.Run()
+	}()
+
+	jc.Watch(done, func() {
+		state := stateManager.GetState()
+		// A paused worker only notices the cancel once it is woken
+		if workerCtx.Err() != nil && state.Status == duration.ResolverStatusPaused {
+			stateManager.RequestResume()
+		}
+		jc.SetPaused(state.Status == duration.ResolverStatusPaused)
+		jc.SetProgress(state.ProcessedTracks, state.TotalTracks, state.CurrentTrack)
+	})
+
+	state := stateManager.GetState()
+	switch state.Status {
+	case duration.ResolverStatusFailed:
+		return fmt.Errorf("bulk resolution failed: %s", state.LastError)
+	case duration.ResolverStatusIdle:
+		return jobs.ErrCancelled
+	}
+	jc.SetResult(fmt.Sprintf("Processed %d tracks: %d resolved, %d need review, %d failed",
+		state.ProcessedTracks, state.ResolvedCount, state.NeedsReviewCount, state.FailedCount))
+	return nil
+}
+
+// ScheduledResolution resolves the tracks that still need a duration for the scheduler and
+// waits for the job to finish. A paused run blocks until it is resumed or cancelled
+func (c *DurationController) ScheduledResolution(runCtx context.Context) (string, error) {
+	bulkMutex.Lock()
+	busy := c.resolutionBusy()
+	bulkMutex.Unlock()
+	if busy {
+		return "", services.ErrTaskBusy
+	}
+	return runJob(runCtx, c.jobManager, models.JobKindResolveDurations, ResolveDurationsPayload{})
+}
+
+// resolutionBusy reports whether a bulk resolution is queued, running or paused; callers hold bulkMutex
+func (c *DurationController) resolutionBusy() bool {
+	if bulkStateManager != nil && (bulkStateManager.IsRunning() || bulkStateManager.IsPaused()) {
+		return true
+	}
+	return c.jobManager.Pending(models.JobKindResolveDurations) != nil
+}
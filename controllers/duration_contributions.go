@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"vinylfo/models"
+	"vinylfo/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GenerateContributions rebuilds the pending Discogs and MusicBrainz edit packages
+func (c *DurationReviewController) GenerateContributions(ctx *gin.Context) {
+	result, err := services.GenerateContributions(c.db)
+	if err != nil {
+		log.Printf("GenerateContributions error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to generate contributions"})
+		return
+	}
+	ctx.JSON(200, result)
+}
+
+// GetContributions lists edit packages, optionally filtered by status and target
+func (c *DurationReviewController) GetContributions(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	query := c.db.Model(&models.DurationContribution{})
+	if status := ctx.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if target := ctx.Query("target"); target != "" {
+		query = query.Where("target = ?", target)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var contributions []models.DurationContribution
+	if err := query.Order("updated_at DESC, id DESC").Offset((page - 1) * limit).Limit(limit).Find(&contributions).Error; err != nil {
+		log.Printf("GetContributions error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to load contributions"})
+		return
+	}
+
+	counts := gin.H{}
+	for _, status := range []string{models.ContributionPending, models.ContributionSubmitted, models.ContributionAccepted, models.ContributionRejected} {
+		var n int64
+		c.db.Model(&models.DurationContribution{}).Where("status = ?", status).Count(&n)
+		counts[status] = n
+	}
+
+	ctx.JSON(200, gin.H{
+		"items":       contributions,
+		"counts":      counts,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+		"total_pages": (total + int64(limit) - 1) / int64(limit),
+	})
+}
+
+// GetContribution returns one edit package
+func (c *DurationReviewController) GetContribution(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid contribution ID"})
+		return
+	}
+
+	var contribution models.DurationContribution
+	if err := c.db.First(&contribution, id).Error; err != nil {
+		ctx.JSON(404, gin.H{"error": "Contribution not found"})
+		return
+	}
+	ctx.JSON(200, contribution)
+}
+
+// UpdateContribution records that a package was submitted, accepted or rejected
+func (c *DurationReviewController) UpdateContribution(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid contribution ID"})
+		return
+	}
+
+	var input struct {
+		Status        string `json:"status" binding:"required"`
+		SubmissionID  string `json:"submission_id"`
+		SubmissionURL string `json:"submission_url"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	contribution, err := services.UpdateContributionStatus(c.db, uint(id), input.Status, input.SubmissionID, input.SubmissionURL)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(404, gin.H{"error": "Contribution not found"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(200, contribution)
+}
@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"log"
+	"strconv"
+
+	"vinylfo/models"
+	"vinylfo/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// editionView is one local edition of a master release
+type editionView struct {
+	ID            uint   `json:"id"`
+	Title         string `json:"title"`
+	Artist        string `json:"artist"`
+	ReleaseYear   int    `json:"release_year"`
+	Country       string `json:"country"`
+	Label         string `json:"label"`
+	Format        string `json:"format"`
+	FormatDetails string `json:"format_details"`
+	CatalogNumber string `json:"catalog_number"`
+	DiscogsID     *int   `json:"discogs_id"`
+	MasterID      int    `json:"discogs_master_id"`
+}
+
+// playlistEditionGroup is a master release in a playlist with the editions it could play
+type playlistEditionGroup struct {
+	MasterID         int           `json:"master_id"`
+	PreferredAlbumID *uint         `json:"preferred_album_id"`
+	AlbumIDs         []uint        `json:"album_ids"` // Editions the playlist's tracks come from now
+	Editions         []editionView `json:"editions"`
+}
+
+// findEditions loads the local editions of the given master releases, oldest release first
+func findEditions(db *gorm.DB, masterIDs []int) ([]editionView, error) {
+	editions := make([]editionView, 0)
+	if len(masterIDs) == 0 {
+		return editions, nil
+	}
+	err := db.Model(&models.Album{}).
+		Select("id, title, artist, release_year, country, label, format, format_details, catalog_number, discogs_id, discogs_master_id AS master_id").
+		Where("discogs_master_id IN ?", masterIDs).
+		Order("release_year, id").
+		Scan(&editions).Error
+	return editions, err
+}
+
+// countEditions fills in how many local editions share each album's master release
+func countEditions(db *gorm.DB, albums []models.Album) {
+	masterIDs := make([]int, 0)
+	for _, album := range albums {
+		if album.DiscogsMasterID != nil {
+			masterIDs = append(masterIDs, *album.DiscogsMasterID)
+		}
+	}
+	if len(masterIDs) == 0 {
+		return
+	}
+
+	var counts []struct {
+		MasterID int
+		Count    int
+	}
+	err := db.Model(&models.Album{}).
+		Select("discogs_master_id AS master_id, COUNT(*) AS count").
+		Where("discogs_master_id IN ?", masterIDs).
+		Group("discogs_master_id").
+		Scan(&counts).Error
+	if err != nil {
+		log.Printf("countEditions error: %v", err)
+		return
+	}
+
+	byMaster := make(map[int]int, len(counts))
+	for _, c := range counts {
+		byMaster[c.MasterID] = c.Count
+	}
+	for i := range albums {
+		if albums[i].DiscogsMasterID != nil {
+			albums[i].EditionCount = byMaster[*albums[i].DiscogsMasterID]
+		}
+	}
+}
+
+// GetAlbumEditions lists the local editions of an album's master release, the album included
+func (c *AlbumController) GetAlbumEditions(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid album ID"})
+		return
+	}
+
+	var album models.Album
+	if err := c.db.Select("id", "discogs_master_id").First(&album, id).Error; err != nil {
+		ctx.JSON(404, gin.H{"error": "Album not found"})
+		return
+	}
+	if album.DiscogsMasterID == nil {
+		ctx.JSON(200, gin.H{"master_id": nil, "editions": []editionView{}})
+		return
+	}
+
+	editions, err := findEditions(c.db, []int{*album.DiscogsMasterID})
+	if err != nil {
+		log.Printf("GetAlbumEditions error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to fetch editions"})
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"master_id": *album.DiscogsMasterID,
+		"editions":  editions,
+	})
+}
+
+// GetPlaylistEditions lists the master releases in a playlist that have more than
+// one local edition, with the edition the playlist prefers
+func (c *PlaylistController) GetPlaylistEditions(ctx *gin.Context) {
+	sessionID := ctx.Param("id")
+
+	var rows []struct {
+		AlbumID  uint
+		MasterID int
+	}
+	err := c.db.Table("session_playlists").
+		Select("DISTINCT tracks.album_id, albums.discogs_master_id AS master_id").
+		Joins("JOIN tracks ON tracks.id = session_playlists.track_id").
+		Joins("JOIN albums ON albums.id = tracks.album_id").
+		Where("session_playlists.session_id = ? AND albums.discogs_master_id IS NOT NULL", sessionID).
+		Scan(&rows).Error
+	if err != nil {
+		log.Printf("GetPlaylistEditions error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to fetch playlist editions"})
+		return
+	}
+
+	groups := make([]*playlistEditionGroup, 0)
+	byMaster := make(map[int]*playlistEditionGroup)
+	masterIDs := make([]int, 0)
+	for _, row := range rows {
+		group, ok := byMaster[row.MasterID]
+		if !ok {
+			group = &playlistEditionGroup{MasterID: row.MasterID, AlbumIDs: []uint{}, Editions: []editionView{}}
+			byMaster[row.MasterID] = group
+			groups = append(groups, group)
+			masterIDs = append(masterIDs, row.MasterID)
+		}
+		group.AlbumIDs = append(group.AlbumIDs, row.AlbumID)
+	}
+
+	editions, err := findEditions(c.db, masterIDs)
+	if err != nil {
+		log.Printf("GetPlaylistEditions editions error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to fetch playlist editions"})
+		return
+	}
+	for _, edition := range editions {
+		byMaster[edition.MasterID].Editions = append(byMaster[edition.MasterID].Editions, edition)
+	}
+
+	var preferences []models.PlaylistEdition
+	c.db.Where("playlist_id = ?", sessionID).Find(&preferences)
+	for _, p := range preferences {
+		if group, ok := byMaster[p.MasterID]; ok {
+			albumID := p.AlbumID
+			group.PreferredAlbumID = &albumID
+		}
+	}
+
+	result := make([]*playlistEditionGroup, 0, len(groups))
+	for _, group := range groups {
+		if len(group.Editions) > 1 {
+			result = append(result, group)
+		}
+	}
+	ctx.JSON(200, result)
+}
+
+// SetPlaylistEdition makes an album the playlist's preferred edition of its master
+// release and switches the playlist's tracks from other editions onto it
+func (c *PlaylistController) SetPlaylistEdition(ctx *gin.Context) {
+	sessionID := ctx.Param("id")
+
+	var req struct {
+		AlbumID uint `json:"album_id" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var album models.Album
+	if err := c.db.Select("id", "discogs_master_id").First(&album, req.AlbumID).Error; err != nil {
+		ctx.JSON(404, gin.H{"error": "Album not found"})
+		return
+	}
+	if album.DiscogsMasterID == nil {
+		ctx.JSON(400, gin.H{"error": "Album is not linked to a Discogs master release"})
+		return
+	}
+
+	switched, err := services.SetPreferredEdition(c.db, sessionID, album.ID)
+	if err != nil {
+		log.Printf("SetPlaylistEdition error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to set preferred edition"})
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"master_id":       *album.DiscogsMasterID,
+		"album_id":        album.ID,
+		"tracks_switched": switched,
+	})
+}
+
+// ClearPlaylistEdition removes the playlist's preferred edition of a master release
+func (c *PlaylistController) ClearPlaylistEdition(ctx *gin.Context) {
+	sessionID := ctx.Param("id")
+	masterID, err := strconv.Atoi(ctx.Param("master_id"))
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid master ID"})
+		return
+	}
+
+	if err := services.ClearPreferredEdition(c.db, sessionID, masterID); err != nil {
+		log.Printf("ClearPlaylistEdition error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to clear preferred edition"})
+		return
+	}
+	ctx.JSON(200, gin.H{"message": "Preferred edition cleared"})
+}
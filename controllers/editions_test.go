@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"vinylfo/models"
+)
+
+func TestAlbumEditions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	controller := NewAlbumController(db, nil)
+
+	master := 10362
+	original := models.Album{Title: "The Dark Side Of The Moon", Artist: "Pink Floyd", ReleaseYear: 1973, DiscogsMasterID: &master}
+	reissue := models.Album{Title: "The Dark Side Of The Moon", Artist: "Pink Floyd", ReleaseYear: 2016, DiscogsMasterID: &master}
+	// Both editions share a title and artist, which used to violate a unique index
+	if err := db.Create(&reissue).Error; err != nil {
+		t.Fatalf("create reissue: %v", err)
+	}
+	if err := db.Create(&original).Error; err != nil {
+		t.Fatalf("create original: %v", err)
+	}
+	db.Create(&models.Album{Title: "Animals", Artist: "Pink Floyd", ReleaseYear: 1977})
+
+	router := gin.New()
+	router.GET("/albums", controller.GetAlbums)
+	router.GET("/albums/:id/editions", controller.GetAlbumEditions)
+
+	t.Run("list counts editions", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/albums?sort=release_year", nil)
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Data  []models.Album `json:"data"`
+			Total int            `json:"total"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Total != 3 {
+			t.Fatalf("Expected 3 albums, got %d", response.Total)
+		}
+		for _, album := range response.Data {
+			want := 0
+			if album.DiscogsMasterID != nil {
+				want = 2
+			}
+			if album.EditionCount != want {
+				t.Errorf("%s (%d) edition_count = %d, want %d", album.Title, album.ReleaseYear, album.EditionCount, want)
+			}
+		}
+	})
+
+	t.Run("group editions", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/albums?group_editions=true", nil)
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Data  []models.Album `json:"data"`
+			Total int            `json:"total"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Total != 2 || len(response.Data) != 2 {
+			t.Fatalf("Expected 2 albums, got total=%d len=%d", response.Total, len(response.Data))
+		}
+	})
+
+	t.Run("editions of an album", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/albums/2/editions", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != 200 {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			MasterID int `json:"master_id"`
+			Editions []struct {
+				ID          uint `json:"id"`
+				ReleaseYear int  `json:"release_year"`
+			} `json:"editions"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.MasterID != master {
+			t.Errorf("master_id = %d, want %d", response.MasterID, master)
+		}
+		if len(response.Editions) != 2 || response.Editions[0].ReleaseYear != 1973 {
+			t.Errorf("Expected both editions, oldest first, got %+v", response.Editions)
+		}
+	})
+}
+
+func TestPlaylistEditions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.PlaylistEdition{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	controller := NewPlaylistController(db)
+
+	master := 10362
+	original := models.Album{Title: "The Dark Side Of The Moon", Artist: "Pink Floyd", DiscogsMasterID: &master}
+	reissue := models.Album{Title: "The Dark Side Of The Moon", Artist: "Pink Floyd", DiscogsMasterID: &master}
+	db.Create(&original)
+	db.Create(&reissue)
+	origTime := models.Track{AlbumID: original.ID, Title: "Time", Position: "A4"}
+	reTime := models.Track{AlbumID: reissue.ID, Title: "Time", Position: "A4"}
+	db.Create(&origTime)
+	db.Create(&reTime)
+	db.Create(&models.SessionPlaylist{SessionID: "Sunday", TrackID: origTime.ID, Order: 1})
+
+	router := gin.New()
+	router.GET("/sessions/playlist/:id/editions", controller.GetPlaylistEditions)
+	router.PUT("/sessions/playlist/:id/editions", controller.SetPlaylistEdition)
+	router.DELETE("/sessions/playlist/:id/editions/:master_id", controller.ClearPlaylistEdition)
+
+	w := httptest.NewRecorder()
+	body, _ := json.Marshal(map[string]uint{"album_id": reissue.ID})
+	req, _ := http.NewRequest("PUT", "/sessions/playlist/Sunday/editions", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var entry models.SessionPlaylist
+	db.Where("session_id = ?", "Sunday").First(&entry)
+	if entry.TrackID != reTime.ID {
+		t.Errorf("playlist track = %d, want the reissue's %d", entry.TrackID, reTime.ID)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/sessions/playlist/Sunday/editions", nil)
+	router.ServeHTTP(w, req)
+	var groups []struct {
+		MasterID         int           `json:"master_id"`
+		PreferredAlbumID *uint         `json:"preferred_album_id"`
+		Editions         []interface{} `json:"editions"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &groups)
+	if len(groups) != 1 || groups[0].PreferredAlbumID == nil || *groups[0].PreferredAlbumID != reissue.ID || len(groups[0].Editions) != 2 {
+		t.Errorf("Unexpected playlist editions: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/sessions/playlist/Sunday/editions/10362", nil)
+	router.ServeHTTP(w, req)
+	var count int64
+	db.Model(&models.PlaylistEdition{}).Count(&count)
+	if w.Code != 200 || count != 0 {
+		t.Errorf("Expected preference cleared, got status %d and %d rows", w.Code, count)
+	}
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"log"
+
+	"vinylfo/httpcache"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HTTPCacheController struct{}
+
+func NewHTTPCacheController() *HTTPCacheController {
+	return &HTTPCacheController{}
+}
+
+// GetStats reports what the external API response cache holds and how often it was used
+func (c *HTTPCacheController) GetStats(ctx *gin.Context) {
+	cache := httpcache.Default()
+	if cache == nil {
+		ctx.JSON(200, gin.H{"enabled": false})
+		return
+	}
+
+	stats, err := cache.Stats()
+	if err != nil {
+		log.Printf("GetStats error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to load cache stats"})
+		return
+	}
+	ctx.JSON(200, gin.H{
+		"enabled":     true,
+		"entries":     stats.Entries,
+		"bytes":       stats.Bytes,
+		"max_entries": stats.MaxEntries,
+		"max_bytes":   stats.MaxBytes,
+		"sources":     stats.Sources,
+	})
+}
+
+// Purge deletes cached responses, optionally of one source or only expired ones
+func (c *HTTPCacheController) Purge(ctx *gin.Context) {
+	cache := httpcache.Default()
+	if cache == nil {
+		ctx.JSON(400, gin.H{"error": "HTTP cache is disabled"})
+		return
+	}
+
+	source := ctx.Query("source")
+	deleted, err := cache.Purge(source, ctx.Query("expired") == "true")
+	if err != nil {
+		log.Printf("Purge error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to purge cache"})
+		return
+	}
+	ctx.JSON(200, gin.H{"message": "Cache purged", "deleted": deleted, "source": source})
+}
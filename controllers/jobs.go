@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"vinylfo/jobs"
+	"vinylfo/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// JobController lists background jobs, controls them and streams their progress
+type JobController struct {
+	db      *gorm.DB
+	manager *jobs.Manager
+}
+
+func NewJobController(db *gorm.DB, manager *jobs.Manager) *JobController {
+	return &JobController{db: db, manager: manager}
+}
+
+// GetJobs lists jobs, newest first, optionally filtered by status and kind
+func (c *JobController) GetJobs(ctx *gin.Context) {
+	limit := 50
+	if v, err := strconv.Atoi(ctx.Query("limit")); err == nil && v > 0 && v <= 500 {
+		limit = v
+	}
+
+	query := c.db.Model(&models.Job{})
+	if status := ctx.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if kind := ctx.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	list, err := c.manager.List(query, limit)
+	if err != nil {
+		log.Printf("GetJobs error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to load jobs"})
+		return
+	}
+	ctx.JSON(200, gin.H{
+		"jobs":  list,
+		"count": len(list),
+		"kinds": c.manager.Kinds(),
+	})
+}
+
+// GetJob returns one job with its live progress
+func (c *JobController) GetJob(ctx *gin.Context) {
+	id, ok := jobID(ctx)
+	if !ok {
+		return
+	}
+	job, err := c.manager.Get(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(404, gin.H{"error": "Job not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("GetJob error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to load job"})
+		return
+	}
+	ctx.JSON(200, job)
+}
+
+// CreateJob queues a job of any registered kind
+func (c *JobController) CreateJob(ctx *gin.Context) {
+	var input struct {
+		Kind    string          `json:"kind" binding:"required"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := c.manager.Enqueue(input.Kind, input.Payload)
+	if errors.Is(err, jobs.ErrUnknownKind) {
+		ctx.JSON(400, gin.H{"error": "Unknown job kind", "kinds": c.manager.Kinds()})
+		return
+	}
+	if err != nil {
+		log.Printf("CreateJob error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to queue job"})
+		return
+	}
+	ctx.JSON(202, job)
+}
+
+// PauseJob pauses a running job
+func (c *JobController) PauseJob(ctx *gin.Context) {
+	c.control(ctx, "paused", c.manager.Pause)
+}
+
+// ResumeJob continues a paused job
+func (c *JobController) ResumeJob(ctx *gin.Context) {
+	c.control(ctx, "resumed", c.manager.Resume)
+}
+
+// CancelJob stops a running job or drops a waiting one
+func (c *JobController) CancelJob(ctx *gin.Context) {
+	c.control(ctx, "cancelled", c.manager.Cancel)
+}
+
+// RetryJob queues a failed or cancelled job again
+func (c *JobController) RetryJob(ctx *gin.Context) {
+	id, ok := jobID(ctx)
+	if !ok {
+		return
+	}
+	job, err := c.manager.Retry(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(404, gin.H{"error": "Job not found"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(409, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(202, job)
+}
+
+// StreamEvents is the SSE endpoint for job progress. It starts with the running jobs and
+// then sends every update; ?id= limits it to one job
+func (c *JobController) StreamEvents(ctx *gin.Context) {
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	var only uint
+	if v := ctx.Query("id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			ctx.JSON(400, gin.H{"error": "Invalid job ID"})
+			return
+		}
+		only = uint(id)
+	}
+
+	updates, unsubscribe := c.manager.Subscribe()
+	defer unsubscribe()
+
+	send := func(w io.Writer, job models.Job) {
+		if only != 0 && job.ID != only {
+			return
+		}
+		data, _ := json.Marshal(job)
+		ctx.SSEvent("job", string(data))
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+
+	initial := c.manager.Active()
+	if only != 0 {
+		if job, err := c.manager.Get(only); err == nil {
+			initial = []models.Job{*job}
+		}
+	}
+
+	ctx.Stream(func(w io.Writer) bool {
+		if initial != nil {
+			for _, job := range initial {
+				send(w, job)
+			}
+			initial = nil
+			return true
+		}
+
+		select {
+		case job, ok := <-updates:
+			if !ok {
+				return false
+			}
+			send(w, job)
+			return true
+		case <-ctx.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+func (c *JobController) control(ctx *gin.Context, done string, action func(id uint) error) {
+	id, ok := jobID(ctx)
+	if !ok {
+		return
+	}
+	err := action(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(404, gin.H{"error": "Job not found"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(409, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(200, gin.H{"message": "Job " + done, "id": id})
+}
+
+func jobID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid job ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// jobProgress reports a service's per-item progress to the job and stops at its pause points
+func jobProgress(jc *jobs.Context) func(done, total int, last string) error {
+	return func(done, total int, last string) error {
+		jc.SetProgress(done, total, last)
+		return jc.Checkpoint()
+	}
+}
+
+// runJob queues a job for a scheduled task and waits for it, so the scheduler's run
+// records the job's outcome
+func runJob(runCtx context.Context, m *jobs.Manager, kind string, payload interface{}) (string, error) {
+	job, err := m.Enqueue(kind, payload)
+	if err != nil {
+		return "", err
+	}
+	return waitForJob(runCtx, m, job.ID)
+}
+
+// waitForJob waits for a job and returns its result, or an error if it did not succeed
+func waitForJob(runCtx context.Context, m *jobs.Manager, id uint) (string, error) {
+	job, err := m.Wait(runCtx, id)
+	if err != nil {
+		return "", err
+	}
+
+	switch job.Status {
+	case models.JobStatusFailed:
+		return "", fmt.Errorf("job %d failed: %s", job.ID, job.Error)
+	case models.JobStatusCancelled:
+		return "", fmt.Errorf("job %d was cancelled", job.ID)
+	}
+	return job.Result, nil
+}
@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"vinylfo/jobs"
+	"vinylfo/models"
+	"vinylfo/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// libraryWatchInterval is how often the library folders are checked for changes
+const libraryWatchInterval = time.Minute
+
+type LibraryController struct {
+	db         *gorm.DB
+	jobManager *jobs.Manager
+	library    *services.AudioLibrary
+}
+
+func NewLibraryController(db *gorm.DB, jobManager *jobs.Manager) *LibraryController {
+	return &LibraryController{
+		db:         db,
+		jobManager: jobManager,
+		library:    services.NewAudioLibrary(db),
+	}
+}
+
+// LibraryScanPayload is the payload of local audio library scan jobs
+type LibraryScanPayload struct {
+	Full bool `json:"full"` // Read every file again, not only new and changed ones
+}
+
+// GetStatus returns the library folders, file counts and the scan in progress
+// GET /api/library
+func (c *LibraryController) GetStatus(ctx *gin.Context) {
+	var stats struct {
+		Files    int64
+		Linked   int64
+		Failed   int64
+		Size     int64
+		LastScan *time.Time
+		ByFormat []struct {
+			Format string `json:"format"`
+			Count  int64  `json:"count"`
+		}
+	}
+	files := c.db.Model(&models.AudioFile{})
+	files.Session(&gorm.Session{}).Count(&stats.Files)
+	files.Session(&gorm.Session{}).Where("track_id IS NOT NULL").Count(&stats.Linked)
+	files.Session(&gorm.Session{}).Where("scan_error <> ''").Count(&stats.Failed)
+	files.Session(&gorm.Session{}).Select("COALESCE(SUM(size), 0)").Scan(&stats.Size)
+	files.Session(&gorm.Session{}).Select("format, COUNT(*) AS count").Group("format").Scan(&stats.ByFormat)
+
+	var latest models.AudioFile
+	if err := c.db.Order("scanned_at DESC").Limit(1).Find(&latest).Error; err == nil && latest.ID != 0 {
+		stats.LastScan = &latest.ScannedAt
+	}
+
+	ctx.JSON(200, gin.H{
+		"paths":        services.LibraryPaths(c.db),
+		"files":        stats.Files,
+		"linked":       stats.Linked,
+		"unlinked":     stats.Files - stats.Linked,
+		"failed":       stats.Failed,
+		"total_size":   stats.Size,
+		"formats":      stats.ByFormat,
+		"last_scan_at": stats.LastScan,
+		"scan_job":     c.jobManager.Pending(models.JobKindLibraryScan),
+	})
+}
+
+// GetFiles lists library files, optionally only linked or unlinked ones or those matching a search
+// GET /api/library/files?status=linked|unlinked|failed&q=&format=&page=&limit=
+func (c *LibraryController) GetFiles(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	query := c.db.Model(&models.AudioFile{})
+	switch ctx.Query("status") {
+	case "":
+	case "linked":
+		query = query.Where("track_id IS NOT NULL")
+	case "unlinked":
+		query = query.Where("track_id IS NULL")
+	case "failed":
+		query = query.Where("scan_error <> ''")
+	default:
+		ctx.JSON(400, gin.H{"error": "Status must be linked, unlinked or failed"})
+		return
+	}
+	if format := ctx.Query("format"); format != "" {
+		query = query.Where("format = ?", format)
+	}
+	if q := ctx.Query("q"); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("LOWER(title) LIKE LOWER(?) OR LOWER(artist) LIKE LOWER(?) OR LOWER(album) LIKE LOWER(?) OR LOWER(path) LIKE LOWER(?)",
+			like, like, like, like)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		log.Printf("GetFiles count error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to fetch library files"})
+		return
+	}
+
+	var files []models.AudioFile
+	if err := query.Order("path ASC").Offset((page - 1) * limit).Limit(limit).Find(&files).Error; err != nil {
+		log.Printf("GetFiles error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to fetch library files"})
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"data":       files,
+		"page":       page,
+		"limit":      limit,
+		"total":      total,
+		"totalPages": (int(total) + limit - 1) / limit,
+	})
+}
+
+// StartScan queues a library scan
+// POST /api/library/scan?full=true
+func (c *LibraryController) StartScan(ctx *gin.Context) {
+	if len(services.LibraryPaths(c.db)) == 0 {
+		ctx.JSON(400, gin.H{"error": "No audio library folders configured", "hint": "Add folders to audio_library_paths in Settings"})
+		return
+	}
+	if job := c.jobManager.Pending(models.JobKindLibraryScan); job != nil {
+		ctx.JSON(409, gin.H{"error": "A library scan is already running", "job": job})
+		return
+	}
+
+	job, err := c.jobManager.Enqueue(models.JobKindLibraryScan, LibraryScanPayload{Full: ctx.Query("full") == "true"})
+	if err != nil {
+		log.Printf("StartScan error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to queue library scan"})
+		return
+	}
+	ctx.JSON(202, gin.H{"job": job})
+}
+
+// LinkFile links a library file to a track; the link is kept by later scans
+// PUT /api/library/files/:id/track
+func (c *LibraryController) LinkFile(ctx *gin.Context) {
+	id, ok := fileID(ctx)
+	if !ok {
+		return
+	}
+	var input struct {
+		TrackID uint `json:"track_id" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := c.library.Link(id, input.TrackID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(404, gin.H{"error": "File or track not found"})
+			return
+		}
+		log.Printf("LinkFile error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to link file"})
+		return
+	}
+	ctx.JSON(200, file)
+}
+
+// UnlinkFile detaches a library file from its track; later scans will not link it again
+// DELETE /api/library/files/:id/track
+func (c *LibraryController) UnlinkFile(ctx *gin.Context) {
+	id, ok := fileID(ctx)
+	if !ok {
+		return
+	}
+
+	file, err := c.library.Unlink(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(404, gin.H{"error": "File not found"})
+			return
+		}
+		log.Printf("UnlinkFile error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to unlink file"})
+		return
+	}
+	ctx.JSON(200, file)
+}
+
+func fileID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid file ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// RunScanJob scans the library as a background job
+func (c *LibraryController) RunScanJob(jc *jobs.Context, payload LibraryScanPayload) error {
+	result, err := c.library.Scan(jc.Context(), payload.Full, jobProgress(jc))
+	if errors.Is(err, services.ErrNoLibraryPaths) {
+		return jobs.Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	jc.SetResult(fmt.Sprintf("Scanned %d files: %d added, %d updated, %d removed, %d unreadable, %d linked to tracks",
+		result.Files, result.Added, result.Updated, result.Removed, result.Failed, result.Linked))
+	return nil
+}
+
+// ScheduledScan rescans the library for the scheduler
+func (c *LibraryController) ScheduledScan(runCtx context.Context) (string, error) {
+	if c.jobManager.Pending(models.JobKindLibraryScan) != nil {
+		return "", services.ErrTaskBusy
+	}
+	return runJob(runCtx, c.jobManager, models.JobKindLibraryScan, LibraryScanPayload{})
+}
+
+// Watch queues a scan whenever files in the library folders change, until ctx is cancelled
+func (c *LibraryController) Watch(ctx context.Context) {
+	c.library.Watch(ctx, libraryWatchInterval, func() {
+		if c.jobManager.Pending(models.JobKindLibraryScan) != nil {
+			return
+		}
+		if _, err := c.jobManager.Enqueue(models.JobKindLibraryScan, LibraryScanPayload{}); err != nil {
+			log.Printf("Library watch: failed to queue scan: %v", err)
+		}
+	})
+}
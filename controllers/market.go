@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"vinylfo/discogs"
+	"vinylfo/models"
+	"vinylfo/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type MarketController struct {
+	db      *gorm.DB
+	tracker *services.PriceTracker
+}
+
+func NewMarketController(db *gorm.DB) *MarketController {
+	newClient := func() *discogs.Client {
+		return services.NewDiscogsClientFromConfig(db)
+	}
+	return &MarketController{
+		db:      db,
+		tracker: services.NewPriceTracker(db, newClient),
+	}
+}
+
+// wantlistEntry is a wantlist item with its latest lowest price
+type wantlistEntry struct {
+	models.WantlistItem
+	LowestPrice *float64 `json:"lowest_price"`
+	NumForSale  *int     `json:"num_for_sale"`
+	Owned       bool     `json:"owned"` // Another pressing of the same master is in the collection
+}
+
+// GetWantlist lists the locally synced Discogs wantlist
+func (c *MarketController) GetWantlist(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	query := ctx.Query("q")
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	base := c.db.Model(&models.WantlistItem{})
+	if query != "" {
+		like := "%" + query + "%"
+		base = base.Where("LOWER(title) LIKE LOWER(?) OR LOWER(artist) LIKE LOWER(?)", like, like)
+	}
+
+	var total int64
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		log.Printf("GetWantlist count error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to fetch wantlist"})
+		return
+	}
+
+	var items []models.WantlistItem
+	if err := base.Order("date_added DESC, id DESC").Offset((page - 1) * limit).Limit(limit).Find(&items).Error; err != nil {
+		log.Printf("GetWantlist error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to fetch wantlist"})
+		return
+	}
+
+	currency := services.MarketCurrency(c.db)
+	entries := make([]wantlistEntry, 0, len(items))
+	for _, item := range items {
+		entry := wantlistEntry{WantlistItem: item}
+		var snapshot models.PriceSnapshot
+		err := c.db.Where("discogs_id = ? AND currency = ?", item.DiscogsID, currency).
+			Order("fetched_at DESC").First(&snapshot).Error
+		if err == nil {
+			entry.LowestPrice = snapshot.LowestPrice
+			entry.NumForSale = &snapshot.NumForSale
+		}
+		if item.DiscogsMasterID != nil {
+			var owned int64
+			c.db.Model(&models.Album{}).Where("discogs_master_id = ?", *item.DiscogsMasterID).Count(&owned)
+			entry.Owned = owned > 0
+		}
+		entries = append(entries, entry)
+	}
+
+	ctx.JSON(200, gin.H{
+		"data":       entries,
+		"currency":   currency,
+		"page":       page,
+		"limit":      limit,
+		"total":      total,
+		"totalPages": (int(total) + limit - 1) / limit,
+	})
+}
+
+// SyncWantlist pulls the user's wantlist from Discogs
+func (c *MarketController) SyncWantlist(ctx *gin.Context) {
+	var config models.AppConfig
+	if err := c.db.First(&config).Error; err != nil {
+		ctx.JSON(500, gin.H{"error": "Failed to load config"})
+		return
+	}
+	if !config.IsDiscogsConnected || config.DiscogsUsername == "" {
+		ctx.JSON(400, gin.H{"error": "Discogs not connected"})
+		return
+	}
+
+	client := services.NewDiscogsClientFromConfig(c.db)
+	if client == nil {
+		ctx.JSON(500, gin.H{"error": "Failed to get Discogs client - not authenticated"})
+		return
+	}
+
+	result, err := services.SyncWantlist(c.db, client, config.DiscogsUsername)
+	if err != nil {
+		log.Printf("SyncWantlist error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to sync wantlist"})
+		return
+	}
+
+	ctx.JSON(200, result)
+}
+
+// GetCollectionValue returns the collection's estimated value, its history and biggest movers
+func (c *MarketController) GetCollectionValue(ctx *gin.Context) {
+	days, err := strconv.Atoi(ctx.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 365 {
+		ctx.JSON(400, gin.H{"error": "days must be between 1 and 365"})
+		return
+	}
+
+	value, err := services.ValueCollection(c.db, services.MarketCurrency(c.db), days)
+	if err != nil {
+		log.Printf("GetCollectionValue error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to value collection"})
+		return
+	}
+
+	ctx.JSON(200, value)
+}
+
+// RefreshPrices starts fetching prices for releases that have none or are out of date
+func (c *MarketController) RefreshPrices(ctx *gin.Context) {
+	var config models.AppConfig
+	if err := c.db.First(&config).Error; err != nil {
+		ctx.JSON(500, gin.H{"error": "Failed to load config"})
+		return
+	}
+	if !config.IsDiscogsConnected {
+		ctx.JSON(400, gin.H{"error": "Discogs not connected"})
+		return
+	}
+
+	if !c.tracker.RefreshNow() {
+		ctx.JSON(409, gin.H{"error": "A price refresh is already running"})
+		return
+	}
+
+	ctx.JSON(202, gin.H{"message": "Price refresh started"})
+}
+
+// GetAlbumPrices returns the price history of an album's release
+func (c *MarketController) GetAlbumPrices(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid album ID"})
+		return
+	}
+
+	var album models.Album
+	if err := c.db.First(&album, id).Error; err != nil {
+		ctx.JSON(404, gin.H{"error": "Album not found"})
+		return
+	}
+	currency := services.MarketCurrency(c.db)
+	if album.DiscogsID == nil {
+		ctx.JSON(200, gin.H{"album_id": album.ID, "currency": currency, "prices": []interface{}{}})
+		return
+	}
+
+	var snapshots []models.PriceSnapshot
+	if err := c.db.Where("discogs_id = ? AND currency = ?", *album.DiscogsID, currency).Order("fetched_at ASC").Find(&snapshots).Error; err != nil {
+		log.Printf("GetAlbumPrices error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to fetch prices"})
+		return
+	}
+
+	type pricePoint struct {
+		FetchedAt      string             `json:"fetched_at"`
+		LowestPrice    *float64           `json:"lowest_price"`
+		NumForSale     int                `json:"num_for_sale"`
+		SuggestedPrice map[string]float64 `json:"suggested_prices,omitempty"`
+	}
+	prices := make([]pricePoint, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		point := pricePoint{
+			FetchedAt:   snapshot.FetchedAt.Format(time.RFC3339),
+			LowestPrice: snapshot.LowestPrice,
+			NumForSale:  snapshot.NumForSale,
+		}
+		if snapshot.SuggestedPrices != "" {
+			json.Unmarshal([]byte(snapshot.SuggestedPrices), &point.SuggestedPrice)
+		}
+		prices = append(prices, point)
+	}
+
+	ctx.JSON(200, gin.H{
+		"album_id":        album.ID,
+		"discogs_id":      *album.DiscogsID,
+		"media_condition": album.MediaCondition,
+		"currency":        currency,
+		"prices":          prices,
+	})
+}
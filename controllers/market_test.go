@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"vinylfo/models"
+)
+
+func TestMarketEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.AppConfig{}, &models.WantlistItem{}, &models.PriceSnapshot{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	db.Create(&models.AppConfig{MarketCurrency: "USD"})
+	controller := NewMarketController(db)
+
+	master, release, wanted := 64476, 1873013, 42
+	lowest := 35.0
+	album := models.Album{Title: "Blue Train", Artist: "John Coltrane", DiscogsID: &release, DiscogsMasterID: &master, MediaCondition: "VG+"}
+	db.Create(&album)
+	db.Create(&models.WantlistItem{DiscogsID: wanted, DiscogsMasterID: &master, Title: "Blue Train", Artist: "John Coltrane"})
+	db.Create(&models.PriceSnapshot{DiscogsID: wanted, FetchedAt: time.Now(), Currency: "USD", LowestPrice: &lowest, NumForSale: 4})
+	db.Create(&models.PriceSnapshot{DiscogsID: release, FetchedAt: time.Now(), Currency: "USD", SuggestedPrices: `{"VG+":60}`})
+
+	router := gin.New()
+	router.GET("/wantlist", controller.GetWantlist)
+	router.GET("/collection/value", controller.GetCollectionValue)
+	router.GET("/albums/:id/prices", controller.GetAlbumPrices)
+
+	t.Run("wantlist", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/wantlist", nil)
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Data []struct {
+				DiscogsID   int      `json:"discogs_id"`
+				LowestPrice *float64 `json:"lowest_price"`
+				Owned       bool     `json:"owned"`
+			} `json:"data"`
+			Total int `json:"total"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Total != 1 || len(response.Data) != 1 {
+			t.Fatalf("Expected 1 wantlist item, got %s", w.Body.String())
+		}
+		item := response.Data[0]
+		if item.DiscogsID != wanted || item.LowestPrice == nil || *item.LowestPrice != lowest || !item.Owned {
+			t.Errorf("Unexpected wantlist item: %s", w.Body.String())
+		}
+	})
+
+	t.Run("collection value", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/collection/value?days=7", nil)
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Currency   string        `json:"currency"`
+			TotalValue float64       `json:"total_value"`
+			History    []interface{} `json:"history"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if w.Code != 200 || response.Currency != "USD" || response.TotalValue != 60 || len(response.History) != 7 {
+			t.Errorf("Unexpected valuation: %s", w.Body.String())
+		}
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/collection/value?days=0", nil)
+		router.ServeHTTP(w, req)
+		if w.Code != 400 {
+			t.Errorf("Expected status 400 for days=0, got %d", w.Code)
+		}
+	})
+
+	t.Run("album prices", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/albums/1/prices", nil)
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Prices []struct {
+				SuggestedPrices map[string]float64 `json:"suggested_prices"`
+			} `json:"prices"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if len(response.Prices) != 1 || response.Prices[0].SuggestedPrices["VG+"] != 60 {
+			t.Errorf("Unexpected album prices: %s", w.Body.String())
+		}
+	})
+}
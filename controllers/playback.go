@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"vinylfo/models"
+	"vinylfo/services"
 	"vinylfo/utils"
 
 	"github.com/gin-gonic/gin"
@@ -23,6 +24,16 @@ type PlaybackController struct {
 
 	sseClientsMux sync.RWMutex
 	sseClients    map[string]*playbackSSEClient
+
+	// Length of the last looked up track's local audio file, which the timer checks every second
+	localDurationMux sync.Mutex
+	localDuration    localTrackDuration
+}
+
+type localTrackDuration struct {
+	trackID uint
+	url     string
+	seconds int
 }
 
 type playbackSSEClient struct {
@@ -64,6 +75,8 @@ func (c *PlaybackController) advanceAfterTrackEnd(playlistID string) error {
 		return result.Error
 	}
 
+	c.logPlay(&playbackState, false, models.PlaySourceTimer)
+
 	playlistSize := c.getPlaylistSize(playlistID)
 	if playlistSize == 0 {
 		// No queue to advance; stop playback.
@@ -481,6 +494,7 @@ func (c *PlaybackController) Previous(ctx *gin.Context) {
 		utils.BadRequest(ctx, "No previous track in queue")
 		return
 	}
+	c.logPlay(&playbackState, true, models.PlaySourceManual)
 
 	playbackState.QueueIndex--
 	playbackState.QueuePosition = 0
@@ -696,6 +710,7 @@ func (c *PlaybackController) buildTrackResponse(track models.Track, album models
 		"audio_file_url":         track.AudioFileURL,
 		"release_year":           album.ReleaseYear,
 		"album_genre":            album.Genre,
+		"album_palette":          album.CoverPalette,
 		"created_at":             track.CreatedAt,
 		"updated_at":             track.UpdatedAt,
 	}
@@ -776,6 +791,11 @@ func (c *PlaybackController) StartPlaylist(ctx *gin.Context) {
 		return
 	}
 
+	// Play the playlist's preferred editions of its albums
+	if preferred, err := services.PreferredTrackIDs(c.db, req.PlaylistID, req.TrackIDs); err == nil {
+		req.TrackIDs = preferred
+	}
+
 	for _, trackID := range req.TrackIDs {
 		var track models.Track
 		if err := c.db.First(&track, trackID).Error; err != nil {
@@ -987,6 +1007,7 @@ func (c *PlaybackController) Skip(ctx *gin.Context) {
 		ctx.JSON(400, gin.H{"error": "No next track in queue"})
 		return
 	}
+	c.logPlay(&playbackState, true, models.PlaySourceManual)
 
 	playbackState.QueueIndex++
 	playbackState.QueuePosition = 0
@@ -1055,6 +1076,7 @@ func (c *PlaybackController) PlayIndex(ctx *gin.Context) {
 		ctx.JSON(400, gin.H{"error": "Invalid queue index"})
 		return
 	}
+	c.logPlay(&playbackState, true, models.PlaySourceManual)
 
 	playbackState.QueueIndex = queueIndex
 	playbackState.QueuePosition = 0
@@ -1265,8 +1287,8 @@ func (c *PlaybackController) Seek(ctx *gin.Context) {
 		}
 	}
 
-	if track.Duration > 0 && req.Position > track.Duration {
-		req.Position = track.Duration
+	if trackDuration := c.playbackDuration(&track); trackDuration > 0 && req.Position > trackDuration {
+		req.Position = trackDuration
 	}
 	if req.Position < 0 {
 		req.Position = 0
@@ -1319,15 +1341,21 @@ func (c *PlaybackController) SimulateTimer(ctx context.Context) {
 			var positionPlaylistID string
 			var positionToBroadcast int
 
+			current := c.playbackManager.GetCurrentTrack()
+			trackDuration := 0
+			if current != nil {
+				trackDuration = c.playbackDuration(current)
+			}
+
 			c.playbackManager.Lock()
 			playlistID := c.playbackManager.playlistID
 			sess := c.playbackManager.sessions[playlistID]
 			track := c.playbackManager.currentTrack
-			if playlistID != "" && sess != nil && track != nil && sess.IsPlaying && !sess.IsPaused {
+			if playlistID != "" && sess != nil && track != nil && track == current && sess.IsPlaying && !sess.IsPaused {
 				elapsed := int(time.Since(sess.PlaybackSession.UpdatedAt).Seconds())
 				currentPosition := sess.PlaybackSession.BasePositionSeconds + elapsed
 
-				if track.Duration <= 0 || currentPosition < track.Duration {
+				if trackDuration <= 0 || currentPosition < trackDuration {
 					sess.Position = currentPosition
 					positionPlaylistID = playlistID
 					positionToBroadcast = currentPosition
@@ -1352,6 +1380,29 @@ func (c *PlaybackController) SimulateTimer(ctx context.Context) {
 	}
 }
 
+// playbackDuration returns how long a track plays for: the length of its local audio file
+// when it has one, since rips rarely match the Discogs duration exactly, otherwise its duration
+func (c *PlaybackController) playbackDuration(track *models.Track) int {
+	c.localDurationMux.Lock()
+	cached := c.localDuration
+	c.localDurationMux.Unlock()
+
+	if cached.trackID != track.ID || cached.url != track.AudioFileURL {
+		cached = localTrackDuration{trackID: track.ID, url: track.AudioFileURL}
+		if local, err := services.FindLocalAudio(c.db, track); err == nil {
+			cached.seconds = local.Duration
+		}
+		c.localDurationMux.Lock()
+		c.localDuration = cached
+		c.localDurationMux.Unlock()
+	}
+
+	if cached.seconds > 0 {
+		return cached.seconds
+	}
+	return track.Duration
+}
+
 func (c *PlaybackController) GetPlaybackManager() *PlaybackManager {
 	return c.playbackManager
 }
@@ -1,9 +1,11 @@
 package controllers
 
 import (
+	"log"
 	"time"
 
 	"vinylfo/models"
+	"vinylfo/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -145,10 +147,14 @@ func (c *PlaybackController) GetTrackHistory(ctx *gin.Context) {
 	ctx.JSON(200, history)
 }
 
+// UpdateHistory counts a listen of a track reported by a player and logs it as a play event
+// Seconds is how long it played, the whole track when left out; Skipped marks a track left early
 func (c *PlaybackController) UpdateHistory(ctx *gin.Context) {
 	var req struct {
 		TrackID    uint   `json:"track_id"`
 		PlaylistID string `json:"playlist_id"`
+		Seconds    int    `json:"seconds"`
+		Skipped    bool   `json:"skipped"`
 	}
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(400, gin.H{"error": err.Error()})
@@ -165,5 +171,59 @@ func (c *PlaybackController) UpdateHistory(ctx *gin.Context) {
 	history.LastPlayed = time.Now()
 	c.db.Save(&history)
 
+	var track models.Track
+	if err := c.db.First(&track, req.TrackID).Error; err == nil {
+		play := services.Play{
+			Track:      &track,
+			PlaylistID: req.PlaylistID,
+			Seconds:    req.Seconds,
+			Duration:   c.playbackDuration(&track),
+			Left:       req.Skipped,
+			Source:     models.PlaySourceManual,
+		}
+		if req.Seconds <= 0 && !req.Skipped {
+			play.Seconds = play.Duration
+		}
+		if _, err := services.RecordPlay(c.db, play); err != nil {
+			log.Printf("UpdateHistory: failed to record play of track %d: %v", track.ID, err)
+		}
+	}
+
 	ctx.JSON(200, gin.H{"status": "History updated"})
 }
+
+// sessionPosition returns how far into its track a stored session is
+func (c *PlaybackController) sessionPosition(state *models.PlaybackSession) int {
+	if c.playbackManager.IsPlaying(state.PlaylistID) && !c.playbackManager.IsPaused(state.PlaylistID) {
+		return state.BasePositionSeconds + int(time.Since(state.UpdatedAt).Seconds())
+	}
+	return state.QueuePosition
+}
+
+// logPlay records a play of the session's current track, either played to its end or left
+// where the session is now
+func (c *PlaybackController) logPlay(state *models.PlaybackSession, left bool, source string) {
+	if state.TrackID == 0 {
+		return
+	}
+	var track models.Track
+	if err := c.db.First(&track, state.TrackID).Error; err != nil {
+		return
+	}
+
+	play := services.Play{
+		Track:      &track,
+		PlaylistID: state.PlaylistID,
+		Duration:   c.playbackDuration(&track),
+		Left:       left,
+		Source:     source,
+	}
+	if left {
+		play.Seconds = c.sessionPosition(state)
+	} else {
+		play.Seconds = play.Duration
+	}
+	if _, err := services.RecordPlay(c.db, play); err != nil {
+		log.Printf("[Playback] failed to record play of track %d: %v", track.ID, err)
+	}
+}
@@ -2,10 +2,14 @@ package controllers
 
 import (
 	"context"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"vinylfo/models"
+
+	"github.com/gin-gonic/gin"
 )
 
 func TestNewPlaybackManager(t *testing.T) {
@@ -314,3 +318,53 @@ func TestSimulateTimer_ContextCancellation(t *testing.T) {
 		t.Error("Position should not decrease after cancellation")
 	}
 }
+
+func TestPlayLog_SkipAndTrackEnd(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.PlayEvent{}, &models.TrackHistory{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	album := models.Album{Title: "Blue Train", Artist: "John Coltrane"}
+	db.Create(&album)
+	tracks := []models.Track{
+		{AlbumID: album.ID, Title: "Blue Train", TrackNumber: 1, Duration: 643},
+		{AlbumID: album.ID, Title: "Moment's Notice", TrackNumber: 2, Duration: 549},
+		{AlbumID: album.ID, Title: "Locomotion", TrackNumber: 3, Duration: 434},
+	}
+	db.Create(&tracks)
+	for i, track := range tracks {
+		db.Create(&models.SessionPlaylist{SessionID: "side-a", TrackID: track.ID, Order: i + 1})
+	}
+	db.Create(&models.PlaybackSession{PlaylistID: "side-a", TrackID: tracks[0].ID, QueuePosition: 60, Status: "paused"})
+
+	controller := NewPlaybackController(db)
+	router := gin.New()
+	router.POST("/playback/skip", controller.Skip)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/playback/skip", strings.NewReader(`{"playlist_id":"side-a"}`)))
+	if w.Code != 200 {
+		t.Fatalf("Skip status = %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := controller.advanceAfterTrackEnd("side-a"); err != nil {
+		t.Fatalf("advanceAfterTrackEnd() error = %v", err)
+	}
+
+	var events []models.PlayEvent
+	db.Order("id").Find(&events)
+	if len(events) != 2 {
+		t.Fatalf("got %d play events, want the skip and the track end", len(events))
+	}
+	skipped, ended := events[0], events[1]
+	if skipped.TrackID != tracks[0].ID || skipped.Seconds != 60 || !skipped.Skipped || skipped.Source != models.PlaySourceManual {
+		t.Errorf("skip event = %+v", skipped)
+	}
+	if ended.TrackID != tracks[1].ID || ended.Seconds != 549 || ended.PercentPlayed != 100 || ended.Skipped || ended.Source != models.PlaySourceTimer {
+		t.Errorf("track end event = %+v", ended)
+	}
+	if ended.AlbumID != album.ID || ended.PlaylistID != "side-a" || ended.EndedAt.Sub(ended.StartedAt) != 549*time.Second {
+		t.Errorf("track end event = %+v", ended)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"vinylfo/models"
+	"vinylfo/services"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -446,6 +447,7 @@ func (c *PlaylistController) DeletePlaylist(ctx *gin.Context) {
 		ctx.JSON(500, gin.H{"error": "Failed to delete playlist"})
 		return
 	}
+	c.db.Where("playlist_id = ?", sessionID).Delete(&models.PlaylistEdition{})
 
 	// Delete the PlaybackSession itself (including YouTube sync info)
 	c.db.Where("playlist_id = ?", sessionID).Delete(&models.PlaybackSession{})
@@ -483,6 +485,7 @@ func (c *PlaylistController) DeletePlaylistWithSessions(ctx *gin.Context) {
 		ctx.JSON(500, gin.H{"error": "Failed to delete playlist"})
 		return
 	}
+	c.db.Where("playlist_id = ?", sessionID).Delete(&models.PlaylistEdition{})
 
 	// Delete the PlaybackSession itself (including YouTube sync info)
 	c.db.Where("playlist_id = ?", sessionID).Delete(&models.PlaybackSession{})
@@ -507,9 +510,15 @@ func (c *PlaylistController) AddTrackToPlaylist(ctx *gin.Context) {
 		maxOrder = 0
 	}
 
+	// Add the track from the playlist's preferred edition of the album, if it has one
+	trackID := req.TrackID
+	if preferred, err := services.PreferredTrackIDs(c.db, sessionID, []uint{req.TrackID}); err == nil {
+		trackID = preferred[0]
+	}
+
 	entry := models.SessionPlaylist{
 		SessionID: sessionID,
-		TrackID:   req.TrackID,
+		TrackID:   trackID,
 		Order:     maxOrder + 1,
 	}
 
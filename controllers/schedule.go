@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"vinylfo/models"
+	"vinylfo/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ScheduleController manages scheduled background jobs and their run history
+type ScheduleController struct {
+	db        *gorm.DB
+	scheduler *services.Scheduler
+}
+
+func NewScheduleController(db *gorm.DB, scheduler *services.Scheduler) *ScheduleController {
+	return &ScheduleController{db: db, scheduler: scheduler}
+}
+
+type scheduledJobView struct {
+	models.ScheduledJob
+	Running bool           `json:"running"`
+	LastRun *models.JobRun `json:"last_run"`
+}
+
+// GetSchedules lists the scheduled jobs with their latest run
+func (c *ScheduleController) GetSchedules(ctx *gin.Context) {
+	var jobs []models.ScheduledJob
+	if err := c.db.Order("id ASC").Find(&jobs).Error; err != nil {
+		log.Printf("GetSchedules error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to load schedules"})
+		return
+	}
+
+	views := make([]scheduledJobView, 0, len(jobs))
+	for _, job := range jobs {
+		view := scheduledJobView{ScheduledJob: job, Running: c.scheduler.IsRunning(job.ID)}
+		var runs []models.JobRun
+		c.db.Where("job_id = ?", job.ID).Order("id DESC").Limit(1).Find(&runs)
+		if len(runs) > 0 {
+			view.LastRun = &runs[0]
+		}
+		views = append(views, view)
+	}
+
+	ctx.JSON(200, gin.H{
+		"schedules": views,
+		"total":     len(views),
+		"kinds":     c.scheduler.Kinds(),
+	})
+}
+
+// CreateSchedule adds a job that runs a task kind on a cron schedule
+func (c *ScheduleController) CreateSchedule(ctx *gin.Context) {
+	var input struct {
+		Name     string `json:"name" binding:"required"`
+		Kind     string `json:"kind" binding:"required"`
+		Schedule string `json:"schedule" binding:"required"`
+		Enabled  bool   `json:"enabled"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	job := models.ScheduledJob{
+		Name:     strings.TrimSpace(input.Name),
+		Kind:     input.Kind,
+		Schedule: strings.TrimSpace(input.Schedule),
+		Enabled:  input.Enabled,
+	}
+	if !c.validateJob(ctx, &job) {
+		return
+	}
+
+	if err := c.db.Create(&job).Error; err != nil {
+		log.Printf("CreateSchedule error: %v", err)
+		ctx.JSON(400, gin.H{"error": "Failed to create schedule; the name may already be in use"})
+		return
+	}
+	ctx.JSON(201, job)
+}
+
+// UpdateSchedule changes a job's name, cron expression or enabled flag
+func (c *ScheduleController) UpdateSchedule(ctx *gin.Context) {
+	job, ok := c.loadJob(ctx)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Name     *string `json:"name"`
+		Schedule *string `json:"schedule"`
+		Enabled  *bool   `json:"enabled"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Name != nil {
+		job.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Schedule != nil {
+		job.Schedule = strings.TrimSpace(*input.Schedule)
+	}
+	if input.Enabled != nil {
+		job.Enabled = *input.Enabled
+	}
+	if !c.validateJob(ctx, &job) {
+		return
+	}
+
+	if err := c.db.Save(&job).Error; err != nil {
+		log.Printf("UpdateSchedule error: %v", err)
+		ctx.JSON(400, gin.H{"error": "Failed to update schedule; the name may already be in use"})
+		return
+	}
+	ctx.JSON(200, job)
+}
+
+// DeleteSchedule removes a job and its run history
+func (c *ScheduleController) DeleteSchedule(ctx *gin.Context) {
+	job, ok := c.loadJob(ctx)
+	if !ok {
+		return
+	}
+
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("job_id = ?", job.ID).Delete(&models.JobRun{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&job).Error
+	})
+	if err != nil {
+		log.Printf("DeleteSchedule error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to delete schedule"})
+		return
+	}
+	ctx.JSON(200, gin.H{"message": "Schedule deleted", "id": job.ID})
+}
+
+// RunSchedule starts a job now; the run finishes in the background
+func (c *ScheduleController) RunSchedule(ctx *gin.Context) {
+	job, ok := c.loadJob(ctx)
+	if !ok {
+		return
+	}
+
+	run, err := c.scheduler.RunNow(job.ID)
+	if err != nil {
+		log.Printf("RunSchedule error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to start job: " + err.Error()})
+		return
+	}
+	if run.Status == models.JobRunSkipped {
+		ctx.JSON(409, gin.H{"error": "Job is already running", "run": run})
+		return
+	}
+	ctx.JSON(202, run)
+}
+
+// GetScheduleRuns lists job runs, newest first, for one job or for all of them
+func (c *ScheduleController) GetScheduleRuns(ctx *gin.Context) {
+	limit := 50
+	if v, err := strconv.Atoi(ctx.Query("limit")); err == nil && v > 0 && v <= 500 {
+		limit = v
+	}
+
+	query := c.db.Model(&models.JobRun{})
+	if ctx.Param("id") != "" {
+		job, ok := c.loadJob(ctx)
+		if !ok {
+			return
+		}
+		query = query.Where("job_id = ?", job.ID)
+	}
+	if status := ctx.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var runs []models.JobRun
+	if err := query.Order("id DESC").Limit(limit).Find(&runs).Error; err != nil {
+		log.Printf("GetScheduleRuns error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to load job runs"})
+		return
+	}
+	ctx.JSON(200, gin.H{
+		"runs":  runs,
+		"count": len(runs),
+	})
+}
+
+func (c *ScheduleController) loadJob(ctx *gin.Context) (models.ScheduledJob, bool) {
+	var job models.ScheduledJob
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid schedule ID"})
+		return job, false
+	}
+
+	err = c.db.First(&job, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(404, gin.H{"error": "Schedule not found"})
+		return job, false
+	}
+	if err != nil {
+		log.Printf("loadJob error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to load schedule"})
+		return job, false
+	}
+	return job, true
+}
+
+// validateJob checks the job and sets its next run time, responding with 400 when it is invalid
+func (c *ScheduleController) validateJob(ctx *gin.Context, job *models.ScheduledJob) bool {
+	if job.Name == "" {
+		ctx.JSON(400, gin.H{"error": "Name is required"})
+		return false
+	}
+	if !c.scheduler.HasTask(job.Kind) {
+		ctx.JSON(400, gin.H{"error": "Unknown job kind", "kinds": c.scheduler.Kinds()})
+		return false
+	}
+
+	next, err := services.NextRunTime(*job, time.Now())
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return false
+	}
+	job.NextRunAt = next
+	return true
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"vinylfo/search"
+	"vinylfo/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SearchController struct {
+	db    *gorm.DB
+	index *search.Index
+}
+
+func NewSearchController(db *gorm.DB) *SearchController {
+	return &SearchController{db: db, index: search.NewIndex(db)}
+}
+
+// Search runs a ranked full-text search across albums and tracks
+// Query syntax supports artist:, album:, title:, label:, genre:, style:, credit:,
+// year:1970..1979 and type:album|track qualifiers alongside free text
+func (c *SearchController) Search(ctx *gin.Context) {
+	query := search.ParseQuery(ctx.Query("q"))
+	if query.IsEmpty() {
+		utils.BadRequest(ctx, "Search query is required")
+		return
+	}
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "25"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 25
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	opts := search.Options{Limit: limit, Offset: (page - 1) * limit}
+	if types := ctx.Query("type"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			// The frontend asks for "albums,tracks"
+			t = strings.TrimSuffix(strings.TrimSpace(t), "s")
+			if t == search.DocAlbum || t == search.DocTrack {
+				opts.Types = append(opts.Types, t)
+			}
+		}
+	}
+
+	results, err := c.index.Search(query, opts)
+	if err != nil {
+		log.Printf("Search failed for %q: %v", query.Raw, err)
+		utils.InternalError(ctx, "Failed to search")
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"data":            results.Hits,
+		"page":            page,
+		"limit":           limit,
+		"total":           results.Total,
+		"totalPages":      (int(results.Total) + limit - 1) / limit,
+		"corrected_query": results.CorrectedQuery,
+	})
+}
+
+// RebuildIndex drops and repopulates the search index from the albums and tracks tables
+func (c *SearchController) RebuildIndex(ctx *gin.Context) {
+	if err := c.index.Rebuild(); err != nil {
+		log.Printf("Search index rebuild failed: %v", err)
+		utils.InternalError(ctx, "Failed to rebuild search index")
+		return
+	}
+
+	ctx.JSON(200, gin.H{"message": "Search index rebuilt"})
+}
@@ -1,11 +1,16 @@
 package controllers
 
 import (
+	"encoding/json"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"vinylfo/duration"
 	"vinylfo/models"
+	"vinylfo/services"
 	"vinylfo/utils"
 
 	"github.com/gin-gonic/gin"
@@ -46,6 +51,10 @@ func (c *SettingsController) Get(ctx *gin.Context) {
 		"youtube_connected":     c.youtube.IsAuthenticated(),
 		"youtube_is_configured": c.youtube.IsConfigured(),
 		"log_retention_count":   config.LogRetentionCount,
+		"market_currency":       config.MarketCurrency,
+		"audio_library_paths":   services.LibraryPaths(c.db),
+
+		"duration_source_weights": services.SourceWeights(c.db),
 	})
 }
 
@@ -194,8 +203,12 @@ func (c *SettingsController) UpdateFeedSettings(ctx *gin.Context) {
 
 func (c *SettingsController) Update(ctx *gin.Context) {
 	var input struct {
-		ItemsPerPage      *int `json:"items_per_page"`
-		LogRetentionCount *int `json:"log_retention_count"`
+		ItemsPerPage      *int      `json:"items_per_page"`
+		LogRetentionCount *int      `json:"log_retention_count"`
+		MarketCurrency    *string   `json:"market_currency"`
+		AudioLibraryPaths *[]string `json:"audio_library_paths"`
+
+		DurationSourceWeights *map[string]services.SourceWeight `json:"duration_source_weights"`
 	}
 
 	if err := ctx.ShouldBindJSON(&input); err != nil {
@@ -203,7 +216,7 @@ func (c *SettingsController) Update(ctx *gin.Context) {
 		return
 	}
 
-	if input.ItemsPerPage == nil && input.LogRetentionCount == nil {
+	if input.ItemsPerPage == nil && input.LogRetentionCount == nil && input.MarketCurrency == nil && input.AudioLibraryPaths == nil && input.DurationSourceWeights == nil {
 		ctx.JSON(400, gin.H{"error": "No valid fields to update"})
 		return
 	}
@@ -226,6 +239,57 @@ func (c *SettingsController) Update(ctx *gin.Context) {
 		updates["log_retention_count"] = *input.LogRetentionCount
 	}
 
+	if input.MarketCurrency != nil {
+		currency := strings.ToUpper(strings.TrimSpace(*input.MarketCurrency))
+		if len(currency) != 3 {
+			ctx.JSON(400, gin.H{"error": "Market currency must be a 3-letter currency code"})
+			return
+		}
+		updates["market_currency"] = currency
+	}
+
+	if input.AudioLibraryPaths != nil {
+		paths := make([]string, 0, len(*input.AudioLibraryPaths))
+		for _, path := range *input.AudioLibraryPaths {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				continue
+			}
+			if !filepath.IsAbs(path) {
+				ctx.JSON(400, gin.H{"error": "Audio library folders must be absolute paths: " + path})
+				return
+			}
+			if info, err := os.Stat(path); err != nil || !info.IsDir() {
+				ctx.JSON(400, gin.H{"error": "Audio library folder not found: " + path})
+				return
+			}
+			paths = append(paths, filepath.Clean(path))
+		}
+		encoded, _ := json.Marshal(paths)
+		updates["audio_library_paths"] = string(encoded)
+	}
+
+	if input.DurationSourceWeights != nil {
+		// Only what differs from the defaults is stored, so later default changes still apply
+		defaults := services.DefaultSourceWeights()
+		overrides := make(map[string]services.SourceWeight)
+		for source, weight := range *input.DurationSourceWeights {
+			if weight.Trust < 0 || weight.Trust > 1 {
+				ctx.JSON(400, gin.H{"error": "Source trust must be between 0 and 1: " + source})
+				return
+			}
+			if weight.ToleranceSeconds < 0 || weight.ToleranceSeconds > 60 {
+				ctx.JSON(400, gin.H{"error": "Source tolerance must be between 0 and 60 seconds: " + source})
+				return
+			}
+			if weight != defaults[source] {
+				overrides[source] = weight
+			}
+		}
+		encoded, _ := json.Marshal(overrides)
+		updates["duration_source_weights"] = string(encoded)
+	}
+
 	result := c.db.Model(&models.AppConfig{}).Where("id = ?", 1).Updates(updates)
 	if result.Error != nil {
 		ctx.JSON(500, gin.H{"error": "Failed to update settings"})
@@ -252,21 +316,38 @@ func (c *SettingsController) ResetDatabase(ctx *gin.Context) {
 		"session_notes",
 		"session_sharings",
 		"session_playlists",
+		"playlist_editions",
 		// Duration tables reference tracks
 		"duration_sources",
 		"duration_resolver_progress",
 		"duration_resolutions",
-		// Track history references tracks
+		// Track history and the play log reference tracks
 		"track_histories",
+		"play_events",
 		// Playback sessions reference tracks
 		"playback_sessions",
+		// Credits reference tracks, albums and artists
+		"credits",
+		// Local audio library files reference tracks
+		"audio_files",
+		// Collection copies reference albums
+		"album_instances",
 		// Main data tables
 		"tracks",
 		"albums",
+		"artists",
+		// Discogs marketplace tables
+		"wantlist_items",
+		"price_snapshots",
 		// Sync tables
+		"collection_changes",
 		"sync_logs",
 		"sync_progresses",
 		"sync_histories",
+		// Background jobs and scheduled job history (the schedules themselves are settings)
+		"jobs",
+		"code_imports",
+		"job_runs",
 		// System tables (safe to delete)
 		"pkce_states",
 		"audit_logs",
@@ -289,7 +370,7 @@ func (c *SettingsController) ResetDatabase(ctx *gin.Context) {
 
 	ctx.JSON(200, gin.H{
 		"message": "Database reset successful",
-		"note":    "All music data, sync progress, and duration resolution data has been cleared. Your OAuth settings and preferences have been preserved.",
+		"note":    "All music data, sync progress, price history, and duration resolution data has been cleared. Your OAuth settings and preferences have been preserved.",
 	})
 }
 
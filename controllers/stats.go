@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"vinylfo/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultStatsDays is how many days listening stats cover when no range is given
+const defaultStatsDays = 30
+
+// maxStatsYears is the longest range the timeline of each period can cover, so a request cannot
+// ask for millions of empty buckets
+var maxStatsYears = map[string]int{
+	services.StatsPeriodDay:   3,
+	services.StatsPeriodWeek:  10,
+	services.StatsPeriodMonth: 50,
+}
+
+type StatsController struct {
+	db *gorm.DB
+}
+
+func NewStatsController(db *gorm.DB) *StatsController {
+	return &StatsController{db: db}
+}
+
+// statsRange reads the from and to dates (YYYY-MM-DD, both included) of a stats request in local
+// time, defaulting to the last defaultStatsDays days; to is returned as the start of the next day
+func statsRange(ctx *gin.Context) (time.Time, time.Time, error) {
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1)
+	if v := ctx.Query("to"); v != "" {
+		day, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("to must be a date (YYYY-MM-DD)")
+		}
+		to = day.AddDate(0, 0, 1)
+	}
+	from := to.AddDate(0, 0, -defaultStatsDays)
+	if v := ctx.Query("from"); v != "" {
+		day, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("from must be a date (YYYY-MM-DD)")
+		}
+		from = day
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must not be after to")
+	}
+	return from, to, nil
+}
+
+// GetListeningStats returns plays and listening time by day, week or month with the top artists,
+// albums, tracks, genres, labels and decades and the listening streaks of a date range
+func (c *StatsController) GetListeningStats(ctx *gin.Context) {
+	from, to, err := statsRange(ctx)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	period := ctx.DefaultQuery("period", services.StatsPeriodDay)
+	years, ok := maxStatsYears[period]
+	if !ok {
+		ctx.JSON(400, gin.H{"error": "period must be day, week or month"})
+		return
+	}
+	if to.After(from.AddDate(years, 0, 0)) {
+		ctx.JSON(400, gin.H{"error": fmt.Sprintf("A %s timeline covers at most %d years", period, years)})
+		return
+	}
+
+	stats, err := services.SummarizeListening(c.db, from, to, period)
+	if err != nil {
+		log.Printf("GetListeningStats error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to load listening stats"})
+		return
+	}
+	ctx.JSON(200, stats)
+}
+
+// GetNeverPlayed returns the albums in the collection that were never played
+func (c *StatsController) GetNeverPlayed(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	albums, total, err := services.NeverPlayedAlbums(c.db, (page-1)*limit, limit)
+	if err != nil {
+		log.Printf("GetNeverPlayed error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to load never played albums"})
+		return
+	}
+	ctx.JSON(200, gin.H{
+		"albums": albums,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// GetSideCompletion returns how much of each record side played in a date range was played
+func (c *StatsController) GetSideCompletion(ctx *gin.Context) {
+	from, to, err := statsRange(ctx)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	sides, err := services.SideCompletions(c.db, from, to)
+	if err != nil {
+		log.Printf("GetSideCompletion error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to load side completion"})
+		return
+	}
+	completed := 0
+	for _, side := range sides {
+		if side.Complete {
+			completed++
+		}
+	}
+	ctx.JSON(200, gin.H{
+		"sides":     sides,
+		"started":   len(sides),
+		"completed": completed,
+	})
+}
+
+// wrappedYear reads the year of a year in review, the current year when none is given
+func wrappedYear(ctx *gin.Context) (int, bool) {
+	current := time.Now().Year()
+	if ctx.Param("year") == "" {
+		return current, true
+	}
+	year, err := strconv.Atoi(ctx.Param("year"))
+	if err != nil || year < 1900 || year > current {
+		return 0, false
+	}
+	return year, true
+}
+
+// GetWrapped returns the year in review of a year
+func (c *StatsController) GetWrapped(ctx *gin.Context) {
+	year, ok := wrappedYear(ctx)
+	if !ok {
+		ctx.JSON(400, gin.H{"error": "Invalid year"})
+		return
+	}
+
+	review, err := services.ReviewYear(c.db, year, time.Local)
+	if err != nil {
+		log.Printf("GetWrapped error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to build year in review"})
+		return
+	}
+	ctx.JSON(200, review)
+}
+
+// wrappedMonth is a bar of the monthly chart of the year in review page
+type wrappedMonth struct {
+	Label   string
+	Plays   int
+	Percent int
+}
+
+// GetWrappedPage renders the year in review as a standalone page that can be saved or shared
+func (c *StatsController) GetWrappedPage(ctx *gin.Context) {
+	year, ok := wrappedYear(ctx)
+	if !ok {
+		ctx.String(400, "Invalid year")
+		return
+	}
+
+	review, err := services.ReviewYear(c.db, year, time.Local)
+	if err != nil {
+		log.Printf("GetWrappedPage error: %v", err)
+		ctx.String(500, "Failed to build year in review")
+		return
+	}
+
+	most := 0
+	for _, month := range review.Months {
+		most = max(most, month.Plays)
+	}
+	months := make([]wrappedMonth, 0, len(review.Months))
+	for _, month := range review.Months {
+		bar := wrappedMonth{Label: month.Start.Format("Jan"), Plays: month.Plays}
+		if most > 0 {
+			bar.Percent = month.Plays * 100 / most
+		}
+		months = append(months, bar)
+	}
+
+	data := gin.H{
+		"review":  review,
+		"hours":   review.Totals.Seconds / 3600,
+		"minutes": review.Totals.Seconds / 60,
+		"months":  months,
+	}
+	if year > 1900 {
+		data["previousYear"] = year - 1
+	}
+	if year < time.Now().Year() {
+		data["nextYear"] = year + 1
+	}
+	if review.BusiestDay != nil {
+		data["busiestDay"] = review.BusiestDay.Start.Format("Monday, January 2")
+	}
+	if review.BusiestMonth != nil {
+		data["busiestMonth"] = review.BusiestMonth.Start.Format("January")
+	}
+	ctx.HTML(200, "wrapped.html", data)
+}
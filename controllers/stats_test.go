@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"vinylfo/models"
+	"vinylfo/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWrapped(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.PlayEvent{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	album := models.Album{Title: "Blue Train", Artist: "John Coltrane", Genre: "Jazz", ReleaseYear: 1957}
+	db.Create(&album)
+	track := models.Track{AlbumID: album.ID, Title: "Locomotion", Position: "B1", Duration: 434}
+	db.Create(&track)
+	year := time.Now().Year()
+	started := time.Date(year, time.January, 3, 20, 0, 0, 0, time.Local)
+	db.Create(&models.PlayEvent{TrackID: track.ID, AlbumID: album.ID, StartedAt: started, EndedAt: started.Add(434 * time.Second), Seconds: 434})
+
+	controller := NewStatsController(db)
+	router := gin.New()
+	router.LoadHTMLFiles("../templates/wrapped.html")
+	router.GET("/stats/listening", controller.GetListeningStats)
+	router.GET("/stats/wrapped/:year", controller.GetWrapped)
+	router.GET("/wrapped/:year", controller.GetWrappedPage)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/stats/wrapped/"+time.Now().Format("2006"), nil))
+	var review services.YearInReview
+	if w.Code != 200 || json.Unmarshal(w.Body.Bytes(), &review) != nil {
+		t.Fatalf("GetWrapped status = %d: %s", w.Code, w.Body.String())
+	}
+	if review.Totals.Plays != 1 || len(review.TopAlbums) != 1 || review.SidesCompleted != 1 {
+		t.Errorf("review = %+v, want one play completing side B", review)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/wrapped/"+time.Now().Format("2006"), nil))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "Blue Train") || !strings.Contains(w.Body.String(), "January was the busiest month") {
+		t.Errorf("GetWrappedPage status = %d: %s", w.Code, w.Body.String())
+	}
+
+	for _, path := range []string{"/wrapped/1850", "/stats/wrapped/next", "/stats/listening?period=year", "/stats/listening?from=2026-03-10&to=2026-03-01",
+		"/stats/listening?from=0001-01-01&to=2026-03-01", "/stats/listening?period=week&from=2000-01-01&to=2026-03-01"} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != 400 {
+			t.Errorf("GET %s status = %d, want 400", path, w.Code)
+		}
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"strconv"
 	"strings"
 
+	"vinylfo/audio"
 	"vinylfo/models"
+	"vinylfo/search"
 	"vinylfo/utils"
 
 	"github.com/gin-gonic/gin"
@@ -15,11 +17,12 @@ import (
 )
 
 type TrackController struct {
-	db *gorm.DB
+	db          *gorm.DB
+	transcoders map[string]audio.Transcoder // Keyed by output format; empty without ffmpeg
 }
 
 func NewTrackController(db *gorm.DB) *TrackController {
-	return &TrackController{db: db}
+	return &TrackController{db: db, transcoders: audio.DefaultTranscoders()}
 }
 
 func (c *TrackController) GetTracks(ctx *gin.Context) {
@@ -187,7 +190,7 @@ func (c *TrackController) SearchTracks(ctx *gin.Context) {
 		UpdatedAt      string `json:"updated_at"`
 	}
 
-	sortBy := ctx.DefaultQuery("sort", "title")
+	sortBy := ctx.DefaultQuery("sort", "relevance")
 	order := ctx.DefaultQuery("order", "asc")
 
 	allowedSorts := map[string]bool{
@@ -197,9 +200,10 @@ func (c *TrackController) SearchTracks(ctx *gin.Context) {
 		"track_number": true,
 		"duration":     true,
 		"created_at":   true,
+		"relevance":    true,
 	}
 	if !allowedSorts[sortBy] {
-		sortBy = "title"
+		sortBy = "relevance"
 	}
 	if order != "asc" && order != "desc" {
 		order = "asc"
@@ -236,32 +240,84 @@ func (c *TrackController) SearchTracks(ctx *gin.Context) {
 	}
 
 	offset := (page - 1) * limit
-	searchTerm := "%" + strings.ToLower(query) + "%"
 
 	var tracks []TrackResult
 	var total int64
 
+	excluded := make(map[uint64]bool)
+	if excludeIDs != "" {
+		for _, idStr := range strings.Split(excludeIDs, ",") {
+			if id, err := strconv.ParseUint(strings.TrimSpace(idStr), 10, 32); err == nil {
+				excluded[id] = true
+			}
+		}
+	}
+
 	baseQuery := c.db.Model(&models.Track{}).
 		Table("tracks").
 		Select("tracks.*, albums.title as album_title, albums.artist as album_artist").
 		Joins("left join albums on tracks.album_id = albums.id").
-		Where("tracks.title IS NOT NULL AND tracks.title != '' AND (LOWER(tracks.title) LIKE ? OR LOWER(albums.title) LIKE ? OR LOWER(albums.artist) LIKE ?)", searchTerm, searchTerm, searchTerm)
+		Where("tracks.title IS NOT NULL AND tracks.title != ''")
 
-	if excludeIDs != "" {
-		idStrings := strings.Split(excludeIDs, ",")
-		for _, idStr := range idStrings {
-			if id, err := strconv.ParseUint(strings.TrimSpace(idStr), 10, 32); err == nil {
-				baseQuery = baseQuery.Where("tracks.id != ?", id)
+	index := search.NewIndex(c.db)
+	parsed := search.ParseQuery(query)
+	parsed.Types = nil
+
+	if sortBy == "relevance" {
+		exclude := make([]uint, 0, len(excluded))
+		for id := range excluded {
+			exclude = append(exclude, uint(id))
+		}
+		results, err := index.Search(parsed, search.Options{Types: []string{search.DocTrack}, Limit: limit, Offset: offset, Exclude: exclude})
+		if err != nil {
+			log.Printf("Track search failed for %q: %v", query, err)
+			ctx.JSON(500, gin.H{"error": "Failed to search tracks"})
+			return
+		}
+		total = results.Total
+
+		pageIDs := make([]uint, 0, len(results.Hits))
+		for _, hit := range results.Hits {
+			pageIDs = append(pageIDs, hit.ID)
+		}
+
+		var found []TrackResult
+		if len(pageIDs) > 0 {
+			if err := baseQuery.Where("tracks.id IN ?", pageIDs).Find(&found).Error; err != nil {
+				ctx.JSON(500, gin.H{"error": "Failed to search tracks"})
+				return
 			}
 		}
-	}
+		byID := make(map[uint]TrackResult, len(found))
+		for _, t := range found {
+			byID[t.ID] = t
+		}
+		tracks = make([]TrackResult, 0, len(pageIDs))
+		for _, id := range pageIDs {
+			if t, ok := byID[id]; ok {
+				tracks = append(tracks, t)
+			}
+		}
+	} else {
+		matches, err := index.Filter(parsed, search.DocTrack)
+		if err != nil {
+			log.Printf("Track search failed for %q: %v", query, err)
+			ctx.JSON(500, gin.H{"error": "Failed to search tracks"})
+			return
+		}
 
-	baseQuery.Count(&total)
-	result := baseQuery.Order(fmt.Sprintf("LOWER(%s) %s", sortColumn, order)).Offset(offset).Limit(limit).Find(&tracks)
+		baseQuery = baseQuery.Where("tracks.id IN (?)", matches)
+		for id := range excluded {
+			baseQuery = baseQuery.Where("tracks.id != ?", id)
+		}
 
-	if result.Error != nil {
-		ctx.JSON(500, gin.H{"error": "Failed to search tracks"})
-		return
+		baseQuery.Count(&total)
+		result := baseQuery.Order(fmt.Sprintf("LOWER(%s) %s", sortColumn, order)).Offset(offset).Limit(limit).Find(&tracks)
+
+		if result.Error != nil {
+			ctx.JSON(500, gin.H{"error": "Failed to search tracks"})
+			return
+		}
 	}
 
 	if len(tracks) > 0 {
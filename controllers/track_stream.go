@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"vinylfo/audio"
+	"vinylfo/models"
+	"vinylfo/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StreamTrack serves a track's local audio file, with Range requests for seeking
+// format transcodes the file when a transcoder for it is available, starting at start seconds;
+// transcoded streams cannot be seeked with Range
+// GET /tracks/:id/stream?format=mp3|opus&start=
+func (c *TrackController) StreamTrack(ctx *gin.Context) {
+	var track models.Track
+	if err := c.db.First(&track, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(404, gin.H{"error": "Track not found"})
+		return
+	}
+
+	local, err := services.FindLocalAudio(c.db, &track)
+	if errors.Is(err, services.ErrNoLocalAudio) {
+		ctx.JSON(404, gin.H{"error": "Track has no local audio file"})
+		return
+	}
+	if err != nil {
+		log.Printf("StreamTrack error: %v", err)
+		ctx.JSON(500, gin.H{"error": "Failed to find audio file"})
+		return
+	}
+
+	if format := strings.ToLower(ctx.Query("format")); format != "" && format != local.Format {
+		c.streamTranscoded(ctx, local, format)
+		return
+	}
+
+	f, err := os.Open(local.Path)
+	if err != nil {
+		log.Printf("StreamTrack error: %v", err)
+		ctx.JSON(404, gin.H{"error": "Track has no local audio file"})
+		return
+	}
+	defer f.Close()
+
+	ctx.Header("Content-Type", audio.ContentType(local.Format))
+	http.ServeContent(ctx.Writer, ctx.Request, filepath.Base(local.Path), local.ModTime, f)
+}
+
+func (c *TrackController) streamTranscoded(ctx *gin.Context, local *services.LocalAudio, format string) {
+	transcoder, ok := c.transcoders[format]
+	if !ok {
+		formats := make([]string, 0, len(c.transcoders))
+		for f := range c.transcoders {
+			formats = append(formats, f)
+		}
+		sort.Strings(formats)
+		ctx.JSON(400, gin.H{"error": "Transcoding to " + format + " is not available", "formats": formats})
+		return
+	}
+
+	start, _ := strconv.ParseFloat(ctx.Query("start"), 64)
+	ctx.Header("Content-Type", transcoder.ContentType())
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Status(200)
+
+	offset := time.Duration(max(start, 0) * float64(time.Second))
+	if err := transcoder.Transcode(ctx.Request.Context(), local.Path, offset, ctx.Writer); err != nil && ctx.Request.Context().Err() == nil {
+		log.Printf("StreamTrack transcode error for %s: %v", local.Path, err)
+	}
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"vinylfo/audio"
+	"vinylfo/models"
+)
+
+func TestStreamTrack(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.AppConfig{}, &models.AudioFile{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	root := t.TempDir()
+	paths, _ := json.Marshal([]string{root})
+	db.Create(&models.AppConfig{AudioLibraryPaths: string(paths)})
+
+	data := []byte("fLaC0123456789")
+	linkedPath := filepath.Join(root, "linked.flac")
+	urlPath := filepath.Join(root, "by-url.mp3")
+	outside := filepath.Join(t.TempDir(), "outside.mp3")
+	for _, path := range []string{linkedPath, urlPath, outside} {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	album := models.Album{Title: "Kind of Blue", Artist: "Miles Davis"}
+	db.Create(&album)
+	linked := models.Track{AlbumID: album.ID, Title: "So What"}
+	byURL := models.Track{AlbumID: album.ID, Title: "Freddie Freeloader", AudioFileURL: audio.FileURL(urlPath)}
+	escaped := models.Track{AlbumID: album.ID, Title: "Blue in Green", AudioFileURL: audio.FileURL(outside)}
+	web := models.Track{AlbumID: album.ID, Title: "All Blues", AudioFileURL: "https://example.com/all_blues.mp3"}
+	db.Create(&linked)
+	db.Create(&byURL)
+	db.Create(&escaped)
+	db.Create(&web)
+	db.Create(&models.AudioFile{Path: linkedPath, Format: audio.FormatFLAC, Duration: 562, TrackID: &linked.ID})
+
+	router := gin.New()
+	router.GET("/tracks/:id/stream", NewTrackController(db).StreamTrack)
+
+	stream := func(id uint, header string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/tracks/"+strconv.FormatUint(uint64(id), 10)+"/stream", nil)
+		if header != "" {
+			req.Header.Set("Range", header)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("whole file", func(t *testing.T) {
+		w := stream(linked.ID, "")
+		if w.Code != 200 || w.Body.String() != string(data) {
+			t.Fatalf("Expected the file, got %d: %q", w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "audio/flac" {
+			t.Errorf("Content-Type = %q, want audio/flac", ct)
+		}
+		if w.Header().Get("Accept-Ranges") != "bytes" {
+			t.Error("Expected Accept-Ranges: bytes")
+		}
+	})
+
+	t.Run("range", func(t *testing.T) {
+		w := stream(linked.ID, "bytes=4-7")
+		if w.Code != 206 || w.Body.String() != "0123" {
+			t.Fatalf("Expected 206 with bytes 4-7, got %d: %q", w.Code, w.Body.String())
+		}
+		if cr := w.Header().Get("Content-Range"); cr != "bytes 4-7/14" {
+			t.Errorf("Content-Range = %q", cr)
+		}
+	})
+
+	t.Run("file URL in library", func(t *testing.T) {
+		w := stream(byURL.ID, "")
+		if w.Code != 200 || w.Header().Get("Content-Type") != "audio/mpeg" {
+			t.Fatalf("Expected an MP3 stream, got %d %q", w.Code, w.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("not served", func(t *testing.T) {
+		for _, id := range []uint{escaped.ID, web.ID, 999} {
+			if w := stream(id, ""); w.Code != 404 {
+				t.Errorf("Track %d: expected 404, got %d", id, w.Code)
+			}
+		}
+	})
+
+	t.Run("unavailable transcoding", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/tracks/"+strconv.FormatUint(uint64(linked.ID), 10)+"/stream?format=wav", nil)
+		router.ServeHTTP(w, req)
+		if w.Code != 400 {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+	})
+}
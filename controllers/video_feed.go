@@ -15,6 +15,7 @@ import (
 
 	"vinylfo/duration"
 	"vinylfo/models"
+	"vinylfo/services"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -59,6 +60,11 @@ type VideoTrackInfo struct {
 	VideoDuration  int     `json:"video_duration,omitempty"`
 	ThumbnailURL   string  `json:"thumbnail_url,omitempty"`
 	MatchScore     float64 `json:"match_score,omitempty"`
+	HasAudio       bool    `json:"has_audio"` // A local audio file is linked, played when there is no video
+	AudioURL       string  `json:"audio_url,omitempty"`
+	AudioDuration  int     `json:"audio_duration,omitempty"`
+
+	AlbumPalette *models.CoverPalette `json:"album_palette,omitempty"` // Colors to theme the feed with, once the cover has been analysed
 }
 
 func NewVideoFeedController(db *gorm.DB, playbackController *PlaybackController, youtubeOAuth *duration.YouTubeOAuthClient) *VideoFeedController {
@@ -364,6 +370,7 @@ func (c *VideoFeedController) Next(ctx *gin.Context) {
 		ctx.JSON(400, gin.H{"error": "No next track in queue"})
 		return
 	}
+	c.playbackController.logPlay(&playbackState, true, models.PlaySourceVideoFeed)
 
 	playbackState.QueueIndex++
 	playbackState.QueuePosition = 0
@@ -426,6 +433,7 @@ func (c *VideoFeedController) Previous(ctx *gin.Context) {
 		ctx.JSON(400, gin.H{"error": "No previous track in queue"})
 		return
 	}
+	c.playbackController.logPlay(&playbackState, true, models.PlaySourceVideoFeed)
 
 	playbackState.QueueIndex--
 	playbackState.QueuePosition = 0
@@ -514,6 +522,9 @@ func (c *VideoFeedController) buildVideoTrackInfo(track *models.Track) VideoTrac
 		Duration:    track.Duration,
 		HasVideo:    false,
 	}
+	if album.CoverPalette.Dominant != "" {
+		info.AlbumPalette = &album.CoverPalette
+	}
 
 	// Get YouTube match if exists
 	var youtubeMatch models.TrackYouTubeMatch
@@ -550,6 +561,12 @@ func (c *VideoFeedController) buildVideoTrackInfo(track *models.Track) VideoTrac
 		}
 	}
 
+	if local, err := services.FindLocalAudio(c.db, track); err == nil {
+		info.HasAudio = true
+		info.AudioURL = fmt.Sprintf("/tracks/%d/stream", track.ID)
+		info.AudioDuration = local.Duration
+	}
+
 	return info
 }
 
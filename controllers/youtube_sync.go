@@ -1,9 +1,12 @@
 package controllers
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"strconv"
 
+	"vinylfo/jobs"
 	"vinylfo/models"
 	"vinylfo/services"
 
@@ -13,18 +16,19 @@ import (
 
 // YouTubeSyncController handles YouTube playlist sync operations
 type YouTubeSyncController struct {
-	db      *gorm.DB
-	service *services.YouTubeSyncService
+	db         *gorm.DB
+	service    *services.YouTubeSyncService
+	jobManager *jobs.Manager
 }
 
 // NewYouTubeSyncController creates a new sync controller
-func NewYouTubeSyncController(db *gorm.DB) *YouTubeSyncController {
+func NewYouTubeSyncController(db *gorm.DB, jobManager *jobs.Manager) *YouTubeSyncController {
 	service, err := services.NewYouTubeSyncService(db)
 	if err != nil {
 		// Service creation failed, but we'll handle it in the endpoints
-		return &YouTubeSyncController{db: db, service: nil}
+		return &YouTubeSyncController{db: db, service: nil, jobManager: jobManager}
 	}
-	return &YouTubeSyncController{db: db, service: service}
+	return &YouTubeSyncController{db: db, service: service, jobManager: jobManager}
 }
 
 // MatchTrack matches a single track to YouTube videos
@@ -54,7 +58,8 @@ func (c *YouTubeSyncController) MatchTrack(ctx *gin.Context) {
 }
 
 // MatchPlaylist matches all tracks in a playlist to YouTube videos
-// POST /api/youtube/match-playlist/:playlist_id?force=true
+// POST /api/youtube/match-playlist/:playlist_id?force=true&async=true
+// With async the matching runs as a background job and the job is returned
 func (c *YouTubeSyncController) MatchPlaylist(ctx *gin.Context) {
 	if c.service == nil {
 		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "YouTube sync service not available"})
@@ -78,7 +83,21 @@ func (c *YouTubeSyncController) MatchPlaylist(ctx *gin.Context) {
 		input.YouTubeApiFallback = false
 	}
 
-	result, err := c.service.MatchPlaylist(ctx.Request.Context(), playlistID, force, input.YouTubeApiFallback)
+	if ctx.Query("async") == "true" {
+		job, err := c.jobManager.Enqueue(models.JobKindYouTubeMatchPlaylist, MatchPlaylistPayload{
+			PlaylistID:  playlistID,
+			Force:       force,
+			APIFallback: input.YouTubeApiFallback,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusAccepted, job)
+		return
+	}
+
+	result, err := c.service.MatchPlaylist(ctx.Request.Context(), playlistID, force, input.YouTubeApiFallback, nil)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -414,3 +433,53 @@ func (c *YouTubeSyncController) ClearWebCache(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"status": "cleared"})
 }
+
+// MatchPlaylistPayload is the payload of playlist matching jobs
+type MatchPlaylistPayload struct {
+	PlaylistID  string `json:"playlist_id"`
+	Force       bool   `json:"force"`
+	APIFallback bool   `json:"api_fallback"`
+}
+
+// RematchPayload is the payload of jobs that retry tracks no video was found for
+type RematchPayload struct {
+	APIFallback bool `json:"api_fallback"`
+}
+
+// RunMatchPlaylistJob matches a playlist as a background job, pausing between tracks
+func (c *YouTubeSyncController) RunMatchPlaylistJob(jc *jobs.Context, payload MatchPlaylistPayload) error {
+	if c.service == nil {
+		return jobs.Permanent(fmt.Errorf("YouTube sync service not available"))
+	}
+
+	result, err := c.service.MatchPlaylist(jc.Context(), payload.PlaylistID, payload.Force, payload.APIFallback, jobProgress(jc))
+	if err != nil {
+		return err
+	}
+	jc.SetResult(fmt.Sprintf("Matched %d of %d tracks: %d need review, %d unavailable, %d errors",
+		result.Matched, result.TotalTracks, result.NeedsReview, result.Unavailable, result.Errors))
+	return nil
+}
+
+// RunRematchJob searches again for tracks no video was found for
+func (c *YouTubeSyncController) RunRematchJob(jc *jobs.Context, payload RematchPayload) error {
+	if c.service == nil {
+		return jobs.Permanent(fmt.Errorf("YouTube sync service not available"))
+	}
+
+	result, err := c.service.RematchUnavailable(jc.Context(), payload.APIFallback, jobProgress(jc))
+	if err != nil {
+		return err
+	}
+	jc.SetResult(fmt.Sprintf("Re-matched %d tracks: %d matched, %d need review, %d still unavailable, %d errors",
+		result.TotalTracks, result.Matched, result.NeedsReview, result.Unavailable, result.Errors))
+	return nil
+}
+
+// ScheduledRematch retries YouTube matching for the scheduler on tracks no video was found for
+func (c *YouTubeSyncController) ScheduledRematch(runCtx context.Context) (string, error) {
+	if c.jobManager.Pending(models.JobKindYouTubeRematch) != nil {
+		return "", services.ErrTaskBusy
+	}
+	return runJob(runCtx, c.jobManager, models.JobKindYouTubeRematch, RematchPayload{})
+}
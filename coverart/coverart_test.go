@@ -0,0 +1,109 @@
+package coverart
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCoverArtArchive_FallsBackToReleaseGroup(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/release/rel-1" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"images":[
+			{"front":false,"image":"http://caa/back.jpg","thumbnails":{"1200":"http://caa/back-1200.jpg"}},
+			{"front":true,"image":"http://caa/front.jpg","thumbnails":{"250":"http://caa/front-250.jpg","1200":"http://caa/front-1200.jpg"}}
+		]}`))
+	}))
+	defer server.Close()
+
+	client := NewCoverArtArchiveClient()
+	client.baseURL = server.URL
+	candidates, err := client.Candidates(context.Background(), Album{MusicBrainzReleaseID: "rel-1", MusicBrainzReleaseGroupID: "rg-1"})
+	if err != nil {
+		t.Fatalf("Candidates() error = %v", err)
+	}
+	if len(paths) != 2 || paths[1] != "/release-group/rg-1" {
+		t.Errorf("requested %v, want the release then its release group", paths)
+	}
+	if len(candidates) != 1 {
+		t.Fatalf("got %d candidates, want the front cover only", len(candidates))
+	}
+	c := candidates[0]
+	if c.Source != SourceCoverArtArchive || c.URL != "http://caa/front-1200.jpg" || c.ThumbnailURL != "http://caa/front-250.jpg" {
+		t.Errorf("candidate = %+v", c)
+	}
+}
+
+func TestCoverArtArchive_SkipsAlbumsWithoutMusicBrainzIDs(t *testing.T) {
+	client := NewCoverArtArchiveClient()
+	client.baseURL = "http://127.0.0.1:0"
+	candidates, err := client.Candidates(context.Background(), Album{Title: "Blue Train", Artist: "John Coltrane"})
+	if err != nil || len(candidates) != 0 {
+		t.Errorf("Candidates() = %v, %v, want nothing", candidates, err)
+	}
+}
+
+func TestITunes_KeepsMatchingAlbumsOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" || r.URL.Query().Get("entity") != "album" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"resultCount":2,"results":[
+			{"collectionName":"Greatest Hits","artistName":"Somebody Else","artworkUrl100":"http://itunes/other/100x100bb.jpg"},
+			{"collectionName":"Blue Train (Remastered)","artistName":"John Coltrane","artworkUrl100":"http://itunes/bt/100x100bb.jpg"}
+		]}`))
+	}))
+	defer server.Close()
+
+	client := NewITunesClient()
+	client.baseURL = server.URL
+	candidates, err := client.Candidates(context.Background(), Album{Title: "Blue Train", Artist: "John Coltrane"})
+	if err != nil {
+		t.Fatalf("Candidates() error = %v", err)
+	}
+	if len(candidates) != 1 {
+		t.Fatalf("got %d candidates, want 1: %+v", len(candidates), candidates)
+	}
+	if candidates[0].URL != "http://itunes/bt/1200x1200bb.jpg" || candidates[0].ThumbnailURL != "http://itunes/bt/250x250bb.jpg" {
+		t.Errorf("candidate = %+v", candidates[0])
+	}
+}
+
+func TestLocalProvider_FindsCoverFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"01 - Track.flac", "Cover.JPG", "folder.jpg", "scan.jpg", "back.png"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	album := Album{Dirs: []string{dir, filepath.Join(dir, "missing")}}
+
+	p := NewLocalProvider()
+	candidates, err := p.Candidates(context.Background(), album)
+	if err != nil {
+		t.Fatalf("Candidates() error = %v", err)
+	}
+	if len(candidates) != 2 {
+		t.Fatalf("got %d candidates, want folder.jpg and Cover.JPG: %+v", len(candidates), candidates)
+	}
+	if filepath.Base(candidates[0].URL) != "folder.jpg" || filepath.Base(candidates[1].URL) != "Cover.JPG" {
+		t.Errorf("candidates = %+v, want folder.jpg first", candidates)
+	}
+
+	if !p.IsCandidate(album, filepath.Join(dir, "folder.jpg")) {
+		t.Error("IsCandidate(folder.jpg) = false")
+	}
+	if p.IsCandidate(album, filepath.Join(dir, "scan.jpg")) || p.IsCandidate(album, "/etc/passwd") {
+		t.Error("IsCandidate accepted a file the provider does not offer")
+	}
+}
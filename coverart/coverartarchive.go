@@ -0,0 +1,114 @@
+package coverart
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"vinylfo/duration"
+)
+
+const (
+	coverArtArchiveBaseURL   = "https://coverartarchive.org"
+	coverArtArchiveRateLimit = 60
+)
+
+// CoverArtArchiveClient finds covers of MusicBrainz releases in the Cover Art Archive
+// Albums are only looked up once they have been matched to a MusicBrainz release
+type CoverArtArchiveClient struct {
+	*duration.BaseClient
+	baseURL string
+}
+
+type caaResponse struct {
+	Release string     `json:"release"`
+	Images  []caaImage `json:"images"`
+}
+
+type caaImage struct {
+	Front      bool              `json:"front"`
+	Types      []string          `json:"types"`
+	Image      string            `json:"image"`
+	Thumbnails map[string]string `json:"thumbnails"`
+}
+
+func NewCoverArtArchiveClient() *CoverArtArchiveClient {
+	return &CoverArtArchiveClient{
+		BaseClient: duration.NewBaseClient("Vinylfo/1.0 (Music Collection Manager)", coverArtArchiveRateLimit),
+		baseURL:    coverArtArchiveBaseURL,
+	}
+}
+
+func (c *CoverArtArchiveClient) Name() string {
+	return SourceCoverArtArchive
+}
+
+// Candidates returns the front covers of the album's release, or of its release group when the
+// release has none
+func (c *CoverArtArchiveClient) Candidates(ctx context.Context, album Album) ([]Candidate, error) {
+	lookups := []struct{ entity, id string }{
+		{"release", album.MusicBrainzReleaseID},
+		{"release-group", album.MusicBrainzReleaseGroupID},
+	}
+	for _, lookup := range lookups {
+		if lookup.id == "" {
+			continue
+		}
+		candidates, err := c.fronts(ctx, lookup.entity, lookup.id)
+		if err != nil || len(candidates) > 0 {
+			return candidates, err
+		}
+	}
+	return nil, nil
+}
+
+func (c *CoverArtArchiveClient) fronts(ctx context.Context, entity, id string) ([]Candidate, error) {
+	reqURL := fmt.Sprintf("%s/%s/%s", c.baseURL, entity, url.PathEscape(id))
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, body, err := c.DoWithRetry(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: %d - %s", resp.StatusCode, string(body))
+	}
+
+	var result caaResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	var candidates []Candidate
+	for _, img := range result.Images {
+		if !img.Front {
+			continue
+		}
+		// Originals can be scans of tens of megabytes; the 1200px thumbnail is plenty for a cover
+		full := img.Thumbnails["1200"]
+		if full == "" {
+			full = img.Image
+		}
+		thumb := img.Thumbnails["250"]
+		if thumb == "" {
+			thumb = img.Thumbnails["small"]
+		}
+		candidates = append(candidates, Candidate{
+			Source:       SourceCoverArtArchive,
+			URL:          full,
+			ThumbnailURL: thumb,
+			Description:  fmt.Sprintf("MusicBrainz %s %s", entity, id),
+		})
+	}
+	return candidates, nil
+}
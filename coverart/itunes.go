@@ -0,0 +1,122 @@
+package coverart
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"vinylfo/duration"
+)
+
+const (
+	iTunesBaseURL   = "https://itunes.apple.com"
+	iTunesRateLimit = 20
+
+	// iTunesMinMatchScore is how closely an album found on iTunes must match by title and artist
+	iTunesMinMatchScore = 0.75
+
+	// iTunesArtworkSize is the side of the artwork asked for; iTunes scales its artwork to any
+	// size in the URL, up to the size of the original
+	iTunesArtworkSize = 1200
+)
+
+// ITunesClient finds album artwork with the iTunes Search API
+type ITunesClient struct {
+	*duration.BaseClient
+	baseURL string
+}
+
+type itunesSearchResponse struct {
+	ResultCount int            `json:"resultCount"`
+	Results     []itunesResult `json:"results"`
+}
+
+type itunesResult struct {
+	CollectionName    string `json:"collectionName"`
+	ArtistName        string `json:"artistName"`
+	ArtworkURL100     string `json:"artworkUrl100"`
+	CollectionViewURL string `json:"collectionViewUrl"`
+}
+
+func NewITunesClient() *ITunesClient {
+	return &ITunesClient{
+		BaseClient: duration.NewBaseClient("Vinylfo/1.0 (Music Collection Manager)", iTunesRateLimit),
+		baseURL:    iTunesBaseURL,
+	}
+}
+
+func (c *ITunesClient) Name() string {
+	return SourceITunes
+}
+
+// Candidates returns the artwork of albums on iTunes whose title and artist match the album's
+func (c *ITunesClient) Candidates(ctx context.Context, album Album) ([]Candidate, error) {
+	if album.Title == "" || album.Artist == "" {
+		return nil, nil
+	}
+
+	query := url.Values{}
+	query.Set("term", album.Artist+" "+duration.NormalizeTitle(album.Title))
+	query.Set("media", "music")
+	query.Set("entity", "album")
+	query.Set("limit", "10")
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/search?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, body, err := c.DoWithRetry(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: %d - %s", resp.StatusCode, string(body))
+	}
+
+	var result itunesSearchResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	type scored struct {
+		Candidate
+		score float64
+	}
+	var matches []scored
+	for _, r := range result.Results {
+		if r.ArtworkURL100 == "" {
+			continue
+		}
+		score := duration.CalculateMatchScore(album.Title, album.Artist, r.CollectionName, r.ArtistName)
+		if score < iTunesMinMatchScore {
+			continue
+		}
+		matches = append(matches, scored{
+			Candidate: Candidate{
+				Source:       SourceITunes,
+				URL:          artworkURL(r.ArtworkURL100, iTunesArtworkSize),
+				ThumbnailURL: artworkURL(r.ArtworkURL100, 250),
+				Description:  fmt.Sprintf("%s by %s", r.CollectionName, r.ArtistName),
+			},
+			score: score,
+		})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	candidates := make([]Candidate, len(matches))
+	for i, m := range matches {
+		candidates[i] = m.Candidate
+	}
+	return candidates, nil
+}
+
+// artworkURL turns the 100px artwork URL of a search result into the URL of another size
+func artworkURL(url100 string, size int) string {
+	return strings.Replace(url100, "100x100bb", fmt.Sprintf("%dx%dbb", size, size), 1)
+}
@@ -0,0 +1,65 @@
+package coverart
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// localCoverNames are the file names, without extension, media players and rippers save covers as,
+// in order of preference
+var localCoverNames = []string{"folder", "cover", "front", "album"}
+
+var localCoverExts = map[string]bool{".jpg": true, ".jpeg": true, ".png": true}
+
+// LocalProvider finds cover images saved next to the album's ripped audio files
+type LocalProvider struct{}
+
+func NewLocalProvider() *LocalProvider {
+	return &LocalProvider{}
+}
+
+func (p *LocalProvider) Name() string {
+	return SourceLocal
+}
+
+// Candidates returns folder.jpg, cover.jpg and similar files in the album's audio folders
+func (p *LocalProvider) Candidates(ctx context.Context, album Album) ([]Candidate, error) {
+	var candidates []Candidate
+	for _, dir := range album.Dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, name := range localCoverNames {
+			for _, entry := range entries {
+				ext := filepath.Ext(entry.Name())
+				if entry.IsDir() || !localCoverExts[strings.ToLower(ext)] {
+					continue
+				}
+				if strings.EqualFold(strings.TrimSuffix(entry.Name(), ext), name) {
+					path := filepath.Join(dir, entry.Name())
+					candidates = append(candidates, Candidate{
+						Source:      SourceLocal,
+						URL:         path,
+						Description: path,
+					})
+				}
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// IsCandidate reports whether path is one of the album's local covers, so only files the
+// provider offered are ever read
+func (p *LocalProvider) IsCandidate(album Album, path string) bool {
+	candidates, _ := p.Candidates(context.Background(), album)
+	for _, c := range candidates {
+		if c.URL == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+// Package coverart finds album covers from sources other than Discogs: the Cover Art Archive,
+// the iTunes Search API and image files next to ripped audio
+package coverart
+
+import (
+	"context"
+)
+
+// Sources
+const (
+	SourceDiscogs         = "discogs"
+	SourceCoverArtArchive = "coverartarchive"
+	SourceITunes          = "itunes"
+	SourceLocal           = "local"
+	SourceManual          = "manual" // An image URL entered by the user
+)
+
+// Album is what providers know about the album they look for
+type Album struct {
+	Title                     string
+	Artist                    string
+	MusicBrainzReleaseID      string
+	MusicBrainzReleaseGroupID string
+	Dirs                      []string // Folders of the album's local audio files
+}
+
+// Candidate is a cover a provider offers
+type Candidate struct {
+	Source       string `json:"source"`
+	URL          string `json:"url"`                     // Full-size image, or the file of a local cover
+	ThumbnailURL string `json:"thumbnail_url,omitempty"` // Smaller image for pickers
+	Width        int    `json:"width,omitempty"`         // When the provider says; 0 when unknown
+	Height       int    `json:"height,omitempty"`
+	Description  string `json:"description,omitempty"` // What the image belongs to, e.g. the matched release
+}
+
+// Provider finds covers for albums
+type Provider interface {
+	// Name returns the source the provider's candidates carry
+	Name() string
+
+	// Candidates returns the provider's covers for the album, best first
+	// Albums the provider knows nothing about return no candidates and no error
+	Candidates(ctx context.Context, album Album) ([]Candidate, error)
+}
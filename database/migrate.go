@@ -12,6 +12,7 @@ import (
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"vinylfo/models"
+	"vinylfo/search"
 )
 
 // DB is the global database instance
@@ -80,8 +81,11 @@ func InitDB() (*gorm.DB, error) {
 	err = db.AutoMigrate(
 		&models.Album{},
 		&models.Track{},
+		&models.Artist{},
+		&models.Credit{},
 		&models.PlaybackSession{},
 		&models.SessionPlaylist{},
+		&models.PlaylistEdition{},
 		&models.SessionSharing{},
 		&models.SessionNote{},
 		&models.AppConfig{},
@@ -89,9 +93,22 @@ func InitDB() (*gorm.DB, error) {
 		&models.SyncLog{},
 		&models.SyncProgress{},
 		&models.SyncHistory{},
+		&models.CollectionChange{},
+		&models.WantlistItem{},
+		&models.PriceSnapshot{},
+		&models.ScheduledJob{},
+		&models.JobRun{},
+		&models.Job{},
+		&models.CodeImport{},
+		&models.AudioFile{},
 		&models.DurationSource{},
 		&models.DurationResolution{},
 		&models.DurationResolverProgress{},
+		&models.DurationContribution{},
+		&models.HTTPCacheEntry{},
+		&models.AlbumImage{},
+		&models.AlbumInstance{},
+		&models.PlayEvent{},
 		&models.PKCEState{},
 		&models.AuditLog{},
 		// YouTube Sync models
@@ -113,6 +130,16 @@ func InitDB() (*gorm.DB, error) {
 		}
 	}
 
+	// Migration: Albums are no longer unique by title+artist so several editions of
+	// the same master release can be kept side by side
+	if migrator.HasIndex(&models.Album{}, "idx_title_artist") {
+		if err := migrator.DropIndex(&models.Album{}, "idx_title_artist"); err != nil {
+			log.Printf("Note: Could not drop title+artist unique index: %v", err)
+		} else {
+			log.Println("Dropped title+artist unique index")
+		}
+	}
+
 	// Ensure exactly one AppConfig row exists
 	var count int64
 	db.Model(&models.AppConfig{}).Count(&count)
@@ -212,6 +239,20 @@ func InitDB() (*gorm.DB, error) {
 		}
 	}
 
+	// Full-text search index: FTS5 on SQLite, FULLTEXT on MySQL
+	searchIndex := search.NewIndex(db)
+	if created, err := searchIndex.EnsureSchema(); err != nil {
+		log.Printf("Warning: Failed to create search index, search will be unavailable: %v", err)
+	} else {
+		if created {
+			log.Println("Building search index...")
+			if err := searchIndex.Rebuild(); err != nil {
+				log.Printf("Warning: Failed to build search index: %v", err)
+			}
+		}
+		models.SetSearchIndexer(searchIndex)
+	}
+
 	DB = db
 	log.Println("Database connected successfully")
 
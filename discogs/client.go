@@ -1,6 +1,7 @@
 package discogs
 
 import (
+	"bytes"
 	"compress/gzip"
 	"crypto/hmac"
 	"crypto/sha1"
@@ -18,6 +19,7 @@ import (
 	"time"
 
 	"vinylfo/config"
+	"vinylfo/httpcache"
 	"vinylfo/utils"
 )
 
@@ -93,10 +95,44 @@ func (c *Client) IsAuthenticated() bool {
 	return c.OAuth != nil && c.OAuth.AccessToken != "" && c.OAuth.AccessSecret != ""
 }
 
+// cachedResponse answers a catalog request from the shared HTTP cache, failing on a cached 404
+// the way the request itself would
+func cachedResponse(method, requestURL string) (*http.Response, bool, error) {
+	cached, ok := httpcache.Get(method, requestURL)
+	if !ok {
+		return nil, false, nil
+	}
+	logToFile("API CACHE HIT: %s %s -> %d", method, requestURL, cached.Status)
+	if cached.Status != http.StatusOK {
+		return nil, true, fmt.Errorf("Discogs API error: %d - %s", cached.Status, string(cached.Body))
+	}
+	return cached.HTTPResponse(nil), true, nil
+}
+
+// cacheResponse stores a catalog response in the shared HTTP cache and returns it with its body
+// still readable
+func cacheResponse(method, requestURL string, resp *http.Response) (*http.Response, error) {
+	if !httpcache.Cacheable(method, requestURL) {
+		return resp, nil
+	}
+	bodyBytes, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	httpcache.Put(method, requestURL, resp.StatusCode, resp.Header.Get("Content-Type"), bodyBytes)
+	resp.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	return resp, nil
+}
+
 func (c *Client) makeRequest(method, requestURL string, body url.Values) (*http.Response, error) {
 	isAuth := c.APIKey != ""
 	logToFile("API REQUEST [%s]: %s %s", map[bool]string{true: "auth", false: "anon"}[isAuth], method, requestURL)
 
+	if resp, ok, err := cachedResponse(method, requestURL); ok {
+		return resp, err
+	}
+
 	// Check rate limit before making request - returns error if we need to wait
 	if err := c.RateLimiter.Wait(isAuth); err != nil {
 		logToFile("API REQUEST: Rate limit triggered in Wait(), returning error")
@@ -149,13 +185,14 @@ func (c *Client) makeRequest(method, requestURL string, body url.Values) (*http.
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != 201 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
+		httpcache.Put(method, requestURL, resp.StatusCode, resp.Header.Get("Content-Type"), bodyBytes)
 		return nil, fmt.Errorf("Discogs API error: %d - %s", resp.StatusCode, string(bodyBytes))
 	}
 
 	c.RateLimiter.Decrement(isAuth)
 
 	logToFile("API SUCCESS: %s %s -> %d", method, requestURL, resp.StatusCode)
-	return resp, nil
+	return cacheResponse(method, requestURL, resp)
 }
 
 func (c *Client) makeOAuthRequest(method, requestURL string, body url.Values) (*http.Response, error) {
@@ -171,6 +208,10 @@ func (c *Client) makeOAuthRequest(method, requestURL string, body url.Values) (*
 		return nil, fmt.Errorf("makeOAuthRequest: OAuth AccessToken is empty")
 	}
 
+	if resp, ok, err := cachedResponse(method, requestURL); ok {
+		return resp, err
+	}
+
 	// Check rate limit before making request - returns error if we need to wait
 	if err := c.RateLimiter.Wait(true); err != nil {
 		logToFile("makeOAuthRequest: Rate limit triggered in Wait(), returning error")
@@ -296,10 +337,12 @@ func (c *Client) makeOAuthRequest(method, requestURL string, body url.Values) (*
 		return nil, rateLimitErr
 	}
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != 201 {
+	// Collection writes answer 204 No Content
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != 201 && resp.StatusCode != http.StatusNoContent {
 		body, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
 		logToFile("makeOAuthRequest: API error %d - %s", resp.StatusCode, string(body))
+		httpcache.Put(method, requestURL, resp.StatusCode, resp.Header.Get("Content-Type"), body)
 		return nil, fmt.Errorf("Discogs API error: %d - %s", resp.StatusCode, string(body))
 	}
 
@@ -317,6 +360,7 @@ func (c *Client) makeOAuthRequest(method, requestURL string, body url.Values) (*
 		gzReader.Close()
 	}
 
+	httpcache.Put(method, requestURL, resp.StatusCode, resp.Header.Get("Content-Type"), bodyBytes)
 	resp.Body = io.NopCloser(strings.NewReader(string(bodyBytes)))
 	return resp, nil
 }
@@ -494,12 +538,16 @@ func (c *Client) GetUserCollection(username string, page, perPage int) ([]map[st
 
 	var collection struct {
 		Releases []struct {
-			ID               int    `json:"id"`
-			InstanceID       int    `json:"instance_id"`
-			DateAdded        string `json:"date_added"`
+			ID               int              `json:"id"`
+			InstanceID       int              `json:"instance_id"`
+			FolderID         int              `json:"folder_id"`
+			Rating           int              `json:"rating"`
+			DateAdded        string           `json:"date_added"`
+			Notes            []collectionNote `json:"notes"`
 			BasicInformation struct {
 				Title      string `json:"title"`
 				Year       int    `json:"year"`
+				MasterID   int    `json:"master_id"`
 				CoverImage string `json:"cover_image"`
 				Thumb      string `json:"thumb"`
 				Artists    []struct {
@@ -509,6 +557,8 @@ func (c *Client) GetUserCollection(username string, page, perPage int) ([]map[st
 					URI  string `json:"uri"`
 					Type string `json:"type"`
 				} `json:"images"`
+				Formats []collectionFormat `json:"formats"`
+				Labels  []collectionLabel  `json:"labels"`
 			} `json:"basic_information"`
 		} `json:"releases"`
 		Pagination struct {
@@ -556,16 +606,22 @@ func (c *Client) GetUserCollection(username string, page, perPage int) ([]map[st
 				r.ID, artistName, r.BasicInformation.Title)
 		}
 
-		releases = append(releases, map[string]interface{}{
+		release := map[string]interface{}{
 			"discogs_id":  r.ID,
 			"instance_id": r.InstanceID,
 			"title":       r.BasicInformation.Title,
 			"artist":      artistName,
 			"year":        r.BasicInformation.Year,
+			"master_id":   r.BasicInformation.MasterID,
 			"cover_image": coverImage,
 			"date_added":  r.DateAdded,
-			"folder_id":   0,
-		})
+			"folder_id":   r.FolderID,
+			"rating":      r.Rating,
+		}
+		for k, v := range collectionItemDetails(r.BasicInformation.Formats, r.BasicInformation.Labels, r.Notes) {
+			release[k] = v
+		}
+		releases = append(releases, release)
 	}
 
 	logToFile("DISCOGS_API: Success! Got %d releases", len(releases))
@@ -573,22 +629,32 @@ func (c *Client) GetUserCollection(username string, page, perPage int) ([]map[st
 }
 
 func (c *Client) GetUserCollectionByFolder(username string, folderID int, page, perPage int) ([]map[string]interface{}, int, error) {
+	return c.getCollectionPage("GetUserCollectionByFolder", username, folderID, page, perPage, "")
+}
+
+// GetCollectionByDateAdded returns a page of the whole collection, most recently added first
+func (c *Client) GetCollectionByDateAdded(username string, page, perPage int) ([]map[string]interface{}, int, error) {
+	return c.getCollectionPage("GetCollectionByDateAdded", username, 0, page, perPage, "&sort=added&sort_order=desc")
+}
+
+// getCollectionPage fetches one page of a collection folder along with the folder's item count
+func (c *Client) getCollectionPage(caller, username string, folderID int, page, perPage int, sort string) ([]map[string]interface{}, int, error) {
 	if username == "" {
-		return nil, 0, fmt.Errorf("GetUserCollectionByFolder: username is empty")
+		return nil, 0, fmt.Errorf("%s: username is empty", caller)
 	}
 
 	if c.OAuth == nil {
-		return nil, 0, fmt.Errorf("GetUserCollectionByFolder: OAuth is nil")
+		return nil, 0, fmt.Errorf("%s: OAuth is nil", caller)
 	}
 	if c.OAuth.ConsumerKey == "" {
-		return nil, 0, fmt.Errorf("GetUserCollectionByFolder: ConsumerKey is empty")
+		return nil, 0, fmt.Errorf("%s: ConsumerKey is empty", caller)
 	}
 	if c.OAuth.AccessToken == "" {
-		return nil, 0, fmt.Errorf("GetUserCollectionByFolder: AccessToken is empty")
+		return nil, 0, fmt.Errorf("%s: AccessToken is empty", caller)
 	}
 
-	requestURL := fmt.Sprintf("%s/users/%s/collection/folders/%d/releases?page=%d&per_page=%d",
-		APIURL, url.QueryEscape(username), folderID, page, perPage)
+	requestURL := fmt.Sprintf("%s/users/%s/collection/folders/%d/releases?page=%d&per_page=%d%s",
+		APIURL, url.QueryEscape(username), folderID, page, perPage, sort)
 
 	logToFile("DISCOGS_API: GET %s", requestURL)
 	logToFile("DISCOGS_API: Auth - ConsumerKey=%s, AccessToken=%s", maskValue(c.OAuth.ConsumerKey), maskValue(c.OAuth.AccessToken))
@@ -602,12 +668,16 @@ func (c *Client) GetUserCollectionByFolder(username string, folderID int, page,
 
 	var collection struct {
 		Releases []struct {
-			ID               int    `json:"id"`
-			InstanceID       int    `json:"instance_id"`
-			DateAdded        string `json:"date_added"`
+			ID               int              `json:"id"`
+			InstanceID       int              `json:"instance_id"`
+			FolderID         int              `json:"folder_id"`
+			Rating           int              `json:"rating"`
+			DateAdded        string           `json:"date_added"`
+			Notes            []collectionNote `json:"notes"`
 			BasicInformation struct {
 				Title      string `json:"title"`
 				Year       int    `json:"year"`
+				MasterID   int    `json:"master_id"`
 				CoverImage string `json:"cover_image"`
 				Thumb      string `json:"thumb"`
 				Artists    []struct {
@@ -617,6 +687,8 @@ func (c *Client) GetUserCollectionByFolder(username string, folderID int, page,
 					URI  string `json:"uri"`
 					Type string `json:"type"`
 				} `json:"images"`
+				Formats []collectionFormat `json:"formats"`
+				Labels  []collectionLabel  `json:"labels"`
 			} `json:"basic_information"`
 		} `json:"releases"`
 		Pagination struct {
@@ -664,16 +736,26 @@ func (c *Client) GetUserCollectionByFolder(username string, folderID int, page,
 				r.ID, artistName, r.BasicInformation.Title)
 		}
 
-		releases = append(releases, map[string]interface{}{
+		release := map[string]interface{}{
 			"discogs_id":  r.ID,
 			"instance_id": r.InstanceID,
 			"title":       r.BasicInformation.Title,
 			"artist":      artistName,
 			"year":        r.BasicInformation.Year,
+			"master_id":   r.BasicInformation.MasterID,
 			"cover_image": coverImage,
 			"date_added":  r.DateAdded,
 			"folder_id":   folderID,
-		})
+			"rating":      r.Rating,
+		}
+		// Folder 0 is "All"; keep the folder the copy actually lives in
+		if folderID == 0 {
+			release["folder_id"] = r.FolderID
+		}
+		for k, v := range collectionItemDetails(r.BasicInformation.Formats, r.BasicInformation.Labels, r.Notes) {
+			release[k] = v
+		}
+		releases = append(releases, release)
 	}
 
 	logToFile("DISCOGS_API: Success! Got %d releases from folder %d (total items: %d)", len(releases), folderID, collection.Pagination.Items)
@@ -895,13 +977,31 @@ func (c *Client) GetMainReleaseFromMaster(masterID int) (int, error) {
 	return 0, fmt.Errorf("no releases found for master %d", masterID)
 }
 
-// GetAllReleasesFromMaster returns all releases for a master release
+// GetAllReleasesFromMaster returns all releases for a master release, across every page
 // This is used to find releases with track durations when the main release lacks them
 func (c *Client) GetAllReleasesFromMaster(masterID int) ([]map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/masters/%d/releases?per_page=50", APIURL, masterID)
+	var releases []map[string]interface{}
+	for page := 1; ; page++ {
+		batch, pages, err := c.getMasterReleasesPage(masterID, page)
+		if err != nil {
+			return releases, err
+		}
+		releases = append(releases, batch...)
+		if page >= pages {
+			break
+		}
+	}
+
+	logToFile("GetAllReleasesFromMaster: Found %d releases for master %d", len(releases), masterID)
+	return releases, nil
+}
+
+// getMasterReleasesPage returns one page of a master's releases and the number of pages
+func (c *Client) getMasterReleasesPage(masterID, page int) ([]map[string]interface{}, int, error) {
+	url := fmt.Sprintf("%s/masters/%d/releases?page=%d&per_page=100", APIURL, masterID, page)
 	resp, err := c.makeAuthenticatedRequest("GET", url, nil)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
 
@@ -913,12 +1013,13 @@ func (c *Client) GetAllReleasesFromMaster(masterID int) ([]map[string]interface{
 			Format string `json:"format"`
 		} `json:"releases"`
 		Pagination struct {
+			Pages int `json:"pages"`
 			Items int `json:"items"`
 		} `json:"pagination"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&respData); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	releases := make([]map[string]interface{}, 0, len(respData.Releases))
@@ -930,9 +1031,7 @@ func (c *Client) GetAllReleasesFromMaster(masterID int) ([]map[string]interface{
 			"format": r.Format,
 		})
 	}
-
-	logToFile("GetAllReleasesFromMaster: Found %d releases for master %d (total items: %d)", len(releases), masterID, respData.Pagination.Items)
-	return releases, nil
+	return releases, respData.Pagination.Pages, nil
 }
 
 func parseAlbumResponse(resp *http.Response) (map[string]interface{}, error) {
@@ -949,8 +1048,10 @@ func parseAlbumResponse(resp *http.Response) (map[string]interface{}, error) {
 		Genres   []string `json:"genres"`
 		Styles   []string `json:"styles"`
 		Images   []struct {
-			URI  string `json:"uri"`
-			Type string `json:"type"`
+			URI    string `json:"uri"`
+			Type   string `json:"type"`
+			Width  int    `json:"width"`
+			Height int    `json:"height"`
 		} `json:"images"`
 		Artists []struct {
 			Name string `json:"name"`
@@ -964,7 +1065,11 @@ func parseAlbumResponse(resp *http.Response) (map[string]interface{}, error) {
 		} `json:"tracklist"`
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&discogsAlbum); err != nil {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &discogsAlbum); err != nil {
 		return nil, err
 	}
 
@@ -984,6 +1089,16 @@ func parseAlbumResponse(resp *http.Response) (map[string]interface{}, error) {
 		coverImage = discogsAlbum.Images[0].URI
 	}
 
+	images := make([]map[string]interface{}, 0, len(discogsAlbum.Images))
+	for _, img := range discogsAlbum.Images {
+		images = append(images, map[string]interface{}{
+			"uri":    img.URI,
+			"type":   img.Type,
+			"width":  img.Width,
+			"height": img.Height,
+		})
+	}
+
 	genre := ""
 	if len(discogsAlbum.Genres) > 0 {
 		genre = discogsAlbum.Genres[0]
@@ -994,6 +1109,14 @@ func parseAlbumResponse(resp *http.Response) (map[string]interface{}, error) {
 		styles = strings.Join(discogsAlbum.Styles, ", ")
 	}
 
+	tracklist := parseTracklist(discogsAlbum.Tracklist)
+	albumCredits, trackCredits := parseReleaseCredits(data)
+	if len(trackCredits) == len(tracklist) {
+		for i := range tracklist {
+			tracklist[i]["credits"] = trackCredits[i]
+		}
+	}
+
 	album := map[string]interface{}{
 		"discogs_id":   discogsAlbum.ID,
 		"title":        discogsAlbum.Title,
@@ -1006,7 +1129,9 @@ func parseAlbumResponse(resp *http.Response) (map[string]interface{}, error) {
 		"genre":        genre,
 		"style":        styles,
 		"cover_image":  coverImage,
-		"tracklist":    parseTracklist(discogsAlbum.Tracklist),
+		"images":       images,
+		"tracklist":    tracklist,
+		"credits":      albumCredits,
 	}
 
 	return album, nil
@@ -1257,6 +1382,16 @@ func (c *Client) CrossReferenceTimestamps(title, artist string, currentTracks []
 	return c.CrossReferenceTimestampsWithMaster(title, artist, currentTracks, 0)
 }
 
+// tracksHaveDurations reports whether any track lists a duration
+func tracksHaveDurations(tracks []map[string]interface{}) bool {
+	for _, track := range tracks {
+		if dur, _ := track["duration"].(int); dur > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // CrossReferenceTimestampsWithMaster finds track durations, checking master release first if provided
 func (c *Client) CrossReferenceTimestampsWithMaster(title, artist string, currentTracks []map[string]interface{}, masterID int) ([]map[string]interface{}, error) {
 	hasDurations := false
@@ -1319,83 +1454,38 @@ func (c *Client) CrossReferenceTimestampsWithMaster(title, artist string, curren
 		}
 	}
 
-	// Step 2: NEW APPROACH - Get ALL releases from master and check each one for durations
-	// This is more reliable than searching because all these releases ARE the same album
+	// Step 2: Check every release of the master for durations, the same walk the duration
+	// resolver uses; these releases ARE the same album, so this is more reliable than searching
 	if masterID > 0 {
 		logToFile("CrossReferenceTimestamps: Checking ALL releases from master %d for %s - %s", masterID, artist, title)
 
-		masterReleases, err := c.GetAllReleasesFromMaster(masterID)
-		if err != nil {
-			// 404 means master was deleted/private - this is normal, just skip quietly
-			if !strings.Contains(err.Error(), "404") {
-				logToFile("CrossReferenceTimestamps: Master %d no longer available (deleted/private), skipping duration lookup", masterID)
+		var matched []map[string]interface{}
+		releasesChecked := 0
+		err := c.EachMasterVersion(masterID, func(version MasterVersion) bool {
+			releasesChecked++
+			logToFile("CrossReferenceTimestamps: Checking master release %d (%s, %d, %s)", version.ReleaseID, version.Title, version.Year, version.Format)
+			if !tracksHaveDurations(version.Tracks) {
+				logToFile("CrossReferenceTimestamps: Master release %d has no durations, trying next release", version.ReleaseID)
+				return true
 			}
-		} else if len(masterReleases) > 0 {
-			// Check each release until we find one with durations
-			releasesChecked := 0
-			for _, release := range masterReleases {
-				releaseID, _ := release["id"].(int)
-				releaseTitle, _ := release["title"].(string)
-				releaseYear, _ := release["year"].(int)
-				releaseFormat, _ := release["format"].(string)
-
-				if releaseID == 0 {
-					continue
-				}
 
-				logToFile("CrossReferenceTimestamps: Checking master release %d (%s, %d, %s)", releaseID, releaseTitle, releaseYear, releaseFormat)
-
-				altTracks, err := c.GetTracksForAlbum(releaseID)
-				if err != nil {
-					logToFile("CrossReferenceTimestamps: Failed to fetch tracks for release %d: %v", releaseID, err)
-					continue
-				}
-
-				// Check if this release has any durations
-				altHasDurations := false
-				for j, track := range altTracks {
-					dur, _ := track["duration"].(int)
-					trackTitle, _ := track["title"].(string)
-					if dur > 0 {
-						altHasDurations = true
-						logToFile("CrossReferenceTimestamps: Master release %d track %d: %q duration=%d (HAS DATA)", releaseID, j, trackTitle, dur)
-					} else {
-						logToFile("CrossReferenceTimestamps: Master release %d track %d: %q duration=%d", releaseID, j, trackTitle, dur)
-					}
-				}
-
-				releasesChecked++
-
-				if !altHasDurations {
-					logToFile("CrossReferenceTimestamps: Master release %d has no durations, trying next release", releaseID)
-					continue
-				}
-
-				// Found a release with durations! Match tracks by name
-				logToFile("CrossReferenceTimestamps: Master release %d has durations, matching tracks (%d/%d checked)", releaseID, releasesChecked, len(masterReleases))
-
-				matchedTracks := matchTracksByName(currentTracks, altTracks)
-
-				matchedWithDurations := 0
-				for j, track := range matchedTracks {
-					dur, _ := track["duration"].(int)
-					trackTitle, _ := track["title"].(string)
-					logToFile("CrossReferenceTimestamps: Matched track %d: %q duration=%d", j, trackTitle, dur)
-					if dur > 0 {
-						matchedWithDurations++
-					}
-				}
-
-				logToFile("CrossReferenceTimestamps: Matched %d/%d tracks with durations from master release %d",
-					matchedWithDurations, len(matchedTracks), releaseID)
-
-				if matchedWithDurations > 0 {
-					logToFile("CrossReferenceTimestamps: SUCCESS - matched %d tracks from master release %d (%d/%d releases checked)",
-						len(matchedTracks), releaseID, releasesChecked, len(masterReleases))
-					return matchedTracks, nil
-				}
+			matchedTracks := matchTracksByName(currentTracks, version.Tracks)
+			if !tracksHaveDurations(matchedTracks) {
+				logToFile("CrossReferenceTimestamps: No tracks matched with durations from master release %d", version.ReleaseID)
+				return true
 			}
-
+			logToFile("CrossReferenceTimestamps: SUCCESS - matched %d tracks from master release %d (%d releases checked)",
+				len(matchedTracks), version.ReleaseID, releasesChecked)
+			matched = matchedTracks
+			return false
+		})
+		if matched != nil {
+			return matched, nil
+		}
+		if err != nil && !strings.Contains(err.Error(), "404") {
+			// 404 means master was deleted/private - this is normal, just skip quietly
+			logToFile("CrossReferenceTimestamps: Master %d not fully checked: %v", masterID, err)
+		} else if err == nil {
 			logToFile("CrossReferenceTimestamps: No releases from master %d had durations after checking %d releases", masterID, releasesChecked)
 		}
 	}
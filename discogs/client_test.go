@@ -1,10 +1,19 @@
 package discogs
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
+
+	"vinylfo/httpcache"
+	"vinylfo/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
 )
 
 func TestAuthenticationLogic(t *testing.T) {
@@ -813,3 +822,52 @@ func TestGetTracksForAlbumDataFlow(t *testing.T) {
 		}
 	}
 }
+
+func TestMakeRequest_UsesHTTPCache(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if strings.HasPrefix(r.URL.Path, "/releases/404") {
+			http.Error(w, `{"message": "Release not found."}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite memory db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.HTTPCacheEntry{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+	host, _ := url.Parse(server.URL)
+	opts := httpcache.DefaultOptions()
+	opts.Hosts = map[string]string{host.Host: "discogs"}
+	httpcache.SetDefault(httpcache.New(db, opts))
+	defer httpcache.SetDefault(nil)
+
+	client := &Client{HTTPClient: server.Client(), RateLimiter: NewRateLimiter()}
+	for i := 0; i < 2; i++ {
+		resp, err := client.makeRequest("GET", server.URL+"/releases/1", nil)
+		if err != nil {
+			t.Fatalf("makeRequest failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != `{"id": 1}` {
+			t.Errorf("body = %q", body)
+		}
+		if _, err := client.makeRequest("GET", server.URL+"/releases/404", nil); err == nil || !strings.Contains(err.Error(), "404") {
+			t.Errorf("missing release error = %v, want a 404", err)
+		}
+	}
+	if _, err := client.makeRequest("GET", server.URL+"/users/me/collection/folders", nil); err != nil {
+		t.Fatalf("makeRequest failed: %v", err)
+	}
+	client.makeRequest("GET", server.URL+"/users/me/collection/folders", nil)
+
+	if requests != 4 {
+		t.Errorf("server saw %d requests, want 4: releases cached, collections not", requests)
+	}
+}
@@ -0,0 +1,177 @@
+package discogs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// collectionFormat is one entry of basic_information.formats in a collection item
+type collectionFormat struct {
+	Name         string   `json:"name"`
+	Qty          string   `json:"qty"`
+	Text         string   `json:"text"`
+	Descriptions []string `json:"descriptions"`
+}
+
+// collectionLabel is one entry of basic_information.labels in a collection item
+type collectionLabel struct {
+	Name  string `json:"name"`
+	CatNo string `json:"catno"`
+}
+
+// collectionNote is a collection field value attached to a collection item
+// Field 1 is Media Condition, 2 is Sleeve Condition and 3 is Notes unless the user changed them
+type collectionNote struct {
+	FieldID int    `json:"field_id"`
+	Value   string `json:"value"`
+}
+
+// formatInfo summarizes a release's Discogs formats
+type formatInfo struct {
+	Format    string // LP, EP, 7", 10", 12", Box Set, or the Discogs format name (CD, Cassette...)
+	Details   string // Remaining descriptions and free text
+	RPM       int
+	DiscCount int
+}
+
+// sizeDescriptions are the format descriptions that identify the physical type, most specific first
+var sizeDescriptions = []string{"LP", "EP", `7"`, `10"`, `12"`, "Single", "Mini-Album", "Maxi-Single"}
+
+// parseFormats reduces Discogs format entries to a formatInfo
+func parseFormats(formats []collectionFormat) formatInfo {
+	var info formatInfo
+	var details []string
+	seen := make(map[string]bool)
+
+	for _, f := range formats {
+		if f.Name == "Box Set" || f.Name == "All Media" {
+			info.Format = "Box Set"
+			continue
+		}
+
+		qty, _ := strconv.Atoi(f.Qty)
+		if qty < 1 {
+			qty = 1
+		}
+		info.DiscCount += qty
+
+		for _, desc := range f.Descriptions {
+			switch desc {
+			case "33 ⅓ RPM", "33 1/3 RPM":
+				info.RPM = 33
+				continue
+			case "45 RPM":
+				info.RPM = 45
+				continue
+			case "78 RPM":
+				info.RPM = 78
+				continue
+			case "Box Set":
+				info.Format = "Box Set"
+				continue
+			}
+
+			if info.Format == "" && isSizeDescription(desc) {
+				info.Format = desc
+				continue
+			}
+			if !seen[desc] {
+				seen[desc] = true
+				details = append(details, desc)
+			}
+		}
+
+		if f.Text != "" && !seen[f.Text] {
+			seen[f.Text] = true
+			details = append(details, f.Text)
+		}
+		if info.Format == "" && f.Name != "" && f.Name != "Vinyl" {
+			info.Format = f.Name
+		}
+	}
+
+	if info.Format == "" && len(formats) > 0 {
+		info.Format = formats[0].Name
+	}
+	info.Details = strings.Join(details, ", ")
+	return info
+}
+
+func isSizeDescription(desc string) bool {
+	for _, s := range sizeDescriptions {
+		if desc == s {
+			return true
+		}
+	}
+	return false
+}
+
+// collectionItemDetails builds the physical-media keys shared by the collection release maps
+func collectionItemDetails(formats []collectionFormat, labels []collectionLabel, notes []collectionNote) map[string]interface{} {
+	info := parseFormats(formats)
+
+	label, catno := "", ""
+	if len(labels) > 0 {
+		label = labels[0].Name
+		catno = labels[0].CatNo
+		if strings.EqualFold(catno, "none") {
+			catno = ""
+		}
+	}
+
+	noteValues := make(map[int]string, len(notes))
+	for _, n := range notes {
+		if v := strings.TrimSpace(n.Value); v != "" {
+			noteValues[n.FieldID] = v
+		}
+	}
+
+	return map[string]interface{}{
+		"format":         info.Format,
+		"format_details": info.Details,
+		"rpm":            info.RPM,
+		"disc_count":     info.DiscCount,
+		"label":          label,
+		"catno":          catno,
+		"notes":          noteValues,
+	}
+}
+
+// GetCollectionFields returns the user's collection note fields keyed by field ID
+func (c *Client) GetCollectionFields(username string) (map[int]string, error) {
+	if username == "" {
+		return nil, fmt.Errorf("GetCollectionFields: username is empty")
+	}
+	if c.OAuth == nil || c.OAuth.AccessToken == "" {
+		return nil, fmt.Errorf("GetCollectionFields: OAuth is not configured")
+	}
+
+	requestURL := fmt.Sprintf("%s/users/%s/collection/fields", APIURL, url.QueryEscape(username))
+	logToFile("DISCOGS_API: GET %s", requestURL)
+
+	resp, err := c.makeOAuthRequest("GET", requestURL, nil)
+	if err != nil {
+		logToFile("DISCOGS_API: ERROR - %v", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var fieldsResponse struct {
+		Fields []struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		} `json:"fields"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&fieldsResponse); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[int]string, len(fieldsResponse.Fields))
+	for _, f := range fieldsResponse.Fields {
+		fields[f.ID] = f.Name
+	}
+	return fields, nil
+}
@@ -0,0 +1,68 @@
+package discogs
+
+import "testing"
+
+func TestParseFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		formats []collectionFormat
+		want    formatInfo
+	}{
+		{
+			name: "single LP",
+			formats: []collectionFormat{
+				{Name: "Vinyl", Qty: "1", Descriptions: []string{"LP", "Album", "Reissue", "33 ⅓ RPM"}},
+			},
+			want: formatInfo{Format: "LP", Details: "Album, Reissue", RPM: 33, DiscCount: 1},
+		},
+		{
+			name: "double LP with text",
+			formats: []collectionFormat{
+				{Name: "Vinyl", Qty: "2", Text: "Gatefold", Descriptions: []string{"LP", "Album"}},
+			},
+			want: formatInfo{Format: "LP", Details: "Album, Gatefold", DiscCount: 2},
+		},
+		{
+			name: "seven inch single",
+			formats: []collectionFormat{
+				{Name: "Vinyl", Qty: "1", Descriptions: []string{`7"`, "Single", "45 RPM"}},
+			},
+			want: formatInfo{Format: `7"`, Details: "Single", RPM: 45, DiscCount: 1},
+		},
+		{
+			name: "box set",
+			formats: []collectionFormat{
+				{Name: "Box Set", Qty: "1", Descriptions: []string{"Compilation"}},
+				{Name: "Vinyl", Qty: "5", Descriptions: []string{"LP"}},
+			},
+			want: formatInfo{Format: "Box Set", Details: "LP", DiscCount: 5},
+		},
+		{
+			name: "CD",
+			formats: []collectionFormat{
+				{Name: "CD", Qty: "1", Descriptions: []string{"Album"}},
+			},
+			want: formatInfo{Format: "CD", Details: "Album", DiscCount: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseFormats(tt.formats); got != tt.want {
+				t.Errorf("parseFormats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectionItemDetailsDropsNoneCatalogNumber(t *testing.T) {
+	details := collectionItemDetails(nil, []collectionLabel{{Name: "Not On Label", CatNo: "none"}}, []collectionNote{{FieldID: 1, Value: " Mint (M) "}, {FieldID: 3, Value: ""}})
+
+	if details["catno"] != "" {
+		t.Errorf("catno = %q, want empty", details["catno"])
+	}
+	notes := details["notes"].(map[int]string)
+	if len(notes) != 1 || notes[1] != "Mint (M)" {
+		t.Errorf("notes = %v, want only trimmed field 1", notes)
+	}
+}
@@ -0,0 +1,174 @@
+package discogs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultCollectionFolder is the Uncategorized folder new collection items go to
+const DefaultCollectionFolder = 1
+
+// CollectionInstance is one copy of a release in the user's collection
+type CollectionInstance struct {
+	InstanceID int
+	FolderID   int
+	Rating     int
+	Notes      map[int]string // Collection field ID -> value
+}
+
+// instanceURL is the collection endpoint of one copy of a release
+func instanceURL(username string, folderID, releaseID, instanceID int) string {
+	return fmt.Sprintf("%s/users/%s/collection/folders/%d/releases/%d/instances/%d",
+		APIURL, url.QueryEscape(username), folderID, releaseID, instanceID)
+}
+
+// requireCollectionAuth checks the client can edit the collection of username
+func (c *Client) requireCollectionAuth(caller, username string) error {
+	if username == "" {
+		return fmt.Errorf("%s: username is empty", caller)
+	}
+	if c.OAuth == nil || c.OAuth.AccessToken == "" {
+		return fmt.Errorf("%s: OAuth is not configured", caller)
+	}
+	return nil
+}
+
+// collectionWrite sends a collection edit and discards the empty response
+func (c *Client) collectionWrite(method, requestURL string) error {
+	logToFile("DISCOGS_API: %s %s", method, requestURL)
+
+	resp, err := c.makeOAuthRequest(method, requestURL, nil)
+	if err != nil {
+		logToFile("DISCOGS_API: ERROR - %v", err)
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return nil
+}
+
+// parseReleaseInstances reads the response of /users/{username}/collection/releases/{release_id}
+func parseReleaseInstances(data []byte) ([]CollectionInstance, error) {
+	var response struct {
+		Releases []struct {
+			InstanceID int              `json:"instance_id"`
+			FolderID   int              `json:"folder_id"`
+			Rating     int              `json:"rating"`
+			Notes      []collectionNote `json:"notes"`
+		} `json:"releases"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+
+	instances := make([]CollectionInstance, 0, len(response.Releases))
+	for _, r := range response.Releases {
+		instance := CollectionInstance{
+			InstanceID: r.InstanceID,
+			FolderID:   r.FolderID,
+			Rating:     r.Rating,
+			Notes:      make(map[int]string, len(r.Notes)),
+		}
+		for _, n := range r.Notes {
+			instance.Notes[n.FieldID] = n.Value
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// GetReleaseInstances returns every copy of a release in the user's collection
+// An empty slice means the release is not in the collection
+func (c *Client) GetReleaseInstances(username string, releaseID int) ([]CollectionInstance, error) {
+	if err := c.requireCollectionAuth("GetReleaseInstances", username); err != nil {
+		return nil, err
+	}
+
+	requestURL := fmt.Sprintf("%s/users/%s/collection/releases/%d", APIURL, url.QueryEscape(username), releaseID)
+	logToFile("DISCOGS_API: GET %s", requestURL)
+
+	resp, err := c.makeOAuthRequest("GET", requestURL, nil)
+	if err != nil {
+		logToFile("DISCOGS_API: ERROR - %v", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseReleaseInstances(data)
+}
+
+// AddToCollection adds a copy of a release to a collection folder and returns its instance ID
+func (c *Client) AddToCollection(username string, folderID, releaseID int) (int, error) {
+	if err := c.requireCollectionAuth("AddToCollection", username); err != nil {
+		return 0, err
+	}
+	if folderID <= 0 {
+		folderID = DefaultCollectionFolder
+	}
+
+	requestURL := fmt.Sprintf("%s/users/%s/collection/folders/%d/releases/%d",
+		APIURL, url.QueryEscape(username), folderID, releaseID)
+	logToFile("DISCOGS_API: POST %s", requestURL)
+
+	resp, err := c.makeOAuthRequest("POST", requestURL, nil)
+	if err != nil {
+		logToFile("DISCOGS_API: ERROR - %v", err)
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var added struct {
+		InstanceID int `json:"instance_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&added); err != nil {
+		return 0, err
+	}
+	return added.InstanceID, nil
+}
+
+// RemoveFromCollection deletes one copy of a release from the collection
+func (c *Client) RemoveFromCollection(username string, folderID, releaseID, instanceID int) error {
+	if err := c.requireCollectionAuth("RemoveFromCollection", username); err != nil {
+		return err
+	}
+	return c.collectionWrite("DELETE", instanceURL(username, folderID, releaseID, instanceID))
+}
+
+// MoveToFolder moves a copy of a release from folderID to newFolderID
+func (c *Client) MoveToFolder(username string, folderID, releaseID, instanceID, newFolderID int) error {
+	if err := c.requireCollectionAuth("MoveToFolder", username); err != nil {
+		return err
+	}
+	requestURL := instanceURL(username, folderID, releaseID, instanceID) + "?folder_id=" + strconv.Itoa(newFolderID)
+	return c.collectionWrite("POST", requestURL)
+}
+
+// SetRating sets the 1-5 star rating of a copy; 0 clears it
+func (c *Client) SetRating(username string, folderID, releaseID, instanceID, rating int) error {
+	if err := c.requireCollectionAuth("SetRating", username); err != nil {
+		return err
+	}
+	if rating < 0 || rating > 5 {
+		return fmt.Errorf("SetRating: rating must be between 0 and 5, got %d", rating)
+	}
+	requestURL := instanceURL(username, folderID, releaseID, instanceID) + "?rating=" + strconv.Itoa(rating)
+	return c.collectionWrite("POST", requestURL)
+}
+
+// SetCollectionField sets a collection note field, such as Media Condition or Notes, on a copy
+func (c *Client) SetCollectionField(username string, folderID, releaseID, instanceID, fieldID int, value string) error {
+	if err := c.requireCollectionAuth("SetCollectionField", username); err != nil {
+		return err
+	}
+	requestURL := fmt.Sprintf("%s/fields/%d?value=%s",
+		instanceURL(username, folderID, releaseID, instanceID), fieldID, strings.ReplaceAll(url.QueryEscape(value), "+", "%20"))
+	return c.collectionWrite("POST", requestURL)
+}
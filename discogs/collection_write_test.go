@@ -0,0 +1,56 @@
+package discogs
+
+import "testing"
+
+func TestParseReleaseInstances(t *testing.T) {
+	data := []byte(`{
+		"pagination": {"page": 1, "pages": 1, "items": 2},
+		"releases": [
+			{
+				"id": 1873013,
+				"instance_id": 148842,
+				"folder_id": 1,
+				"rating": 4,
+				"notes": [
+					{"field_id": 1, "value": "Very Good Plus (VG+)"},
+					{"field_id": 3, "value": "Ring wear on back"}
+				]
+			},
+			{"id": 1873013, "instance_id": 152207, "folder_id": 8}
+		]
+	}`)
+
+	instances, err := parseReleaseInstances(data)
+	if err != nil {
+		t.Fatalf("parseReleaseInstances: %v", err)
+	}
+	if len(instances) != 2 {
+		t.Fatalf("got %d instances, want 2", len(instances))
+	}
+
+	first := instances[0]
+	if first.InstanceID != 148842 || first.FolderID != 1 || first.Rating != 4 {
+		t.Errorf("unexpected first instance: %+v", first)
+	}
+	if first.Notes[1] != "Very Good Plus (VG+)" || first.Notes[3] != "Ring wear on back" {
+		t.Errorf("unexpected notes: %v", first.Notes)
+	}
+	if instances[1].FolderID != 8 || len(instances[1].Notes) != 0 {
+		t.Errorf("unexpected second instance: %+v", instances[1])
+	}
+
+	instances, err = parseReleaseInstances([]byte(`{"releases": []}`))
+	if err != nil || len(instances) != 0 {
+		t.Errorf("expected no instances, got %v (err %v)", instances, err)
+	}
+}
+
+func TestCollectionWritesRequireAuth(t *testing.T) {
+	client := &Client{}
+	if err := client.SetRating("collector", 1, 1873013, 148842, 3); err == nil {
+		t.Error("expected an error without OAuth")
+	}
+	if _, err := client.AddToCollection("", 1, 1873013); err == nil {
+		t.Error("expected an error without a username")
+	}
+}
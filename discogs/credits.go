@@ -0,0 +1,178 @@
+package discogs
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"vinylfo/models"
+)
+
+// Credit is one artist credit on a release or track
+type Credit struct {
+	ArtistID int    `json:"artist_id"` // Discogs artist ID (0 if Discogs has none)
+	Name     string `json:"name"`      // Discogs artist name, including any "(2)" disambiguation suffix
+	Role     string `json:"role"`      // One of the models.CreditRole constants
+	Detail   string `json:"detail"`    // Discogs role text, e.g. "Bass, Vocals" or "Mixed By"
+	Join     string `json:"join"`      // Joiner to the next main artist, e.g. "&" or "Feat."
+}
+
+// variousArtistsID is the Discogs placeholder artist for compilations
+const variousArtistsID = 194
+
+// releaseArtist is an entry of a release's or track's artists/extraartists
+type releaseArtist struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Join   string `json:"join"`
+	Role   string `json:"role"`
+	Tracks string `json:"tracks"`
+}
+
+// releaseCredits holds the credit fields of a /releases/{id} response
+type releaseCredits struct {
+	Artists      []releaseArtist `json:"artists"`
+	ExtraArtists []releaseArtist `json:"extraartists"`
+	Tracklist    []struct {
+		Position     string          `json:"position"`
+		Artists      []releaseArtist `json:"artists"`
+		ExtraArtists []releaseArtist `json:"extraartists"`
+	} `json:"tracklist"`
+}
+
+// parseReleaseCredits returns the release-level credits and the credits of each
+// tracklist entry (indexed like the tracklist). Extra artists limited to specific
+// tracks ("A1 to A3, B2") are attached to those tracks instead of the release
+func parseReleaseCredits(data []byte) ([]Credit, [][]Credit) {
+	var release releaseCredits
+	if err := json.Unmarshal(data, &release); err != nil {
+		return nil, nil
+	}
+
+	positions := make([]string, len(release.Tracklist))
+	trackCredits := make([][]Credit, len(release.Tracklist))
+	for i, track := range release.Tracklist {
+		positions[i] = track.Position
+		trackCredits[i] = append(mainArtistCredits(track.Artists), extraArtistCredits(track.ExtraArtists)...)
+	}
+
+	albumCredits := mainArtistCredits(release.Artists)
+	for _, extra := range release.ExtraArtists {
+		credit := extraArtistCredit(extra)
+		if credit.Name == "" {
+			continue
+		}
+		indexes := resolveTrackSpec(extra.Tracks, positions)
+		if len(indexes) == 0 {
+			albumCredits = append(albumCredits, credit)
+			continue
+		}
+		for _, i := range indexes {
+			trackCredits[i] = append(trackCredits[i], credit)
+		}
+	}
+
+	return albumCredits, trackCredits
+}
+
+func mainArtistCredits(artists []releaseArtist) []Credit {
+	credits := make([]Credit, 0, len(artists))
+	for i, a := range artists {
+		// Compilations credit "Various"; the real performers are on the tracks
+		if a.Name == "" || a.ID == variousArtistsID {
+			continue
+		}
+		role := models.CreditRolePerformer
+		// Artists joined by "Feat." are guests of the artist before them
+		if i > 0 && isFeaturingJoin(artists[i-1].Join) {
+			role = models.CreditRoleFeaturing
+		}
+		credits = append(credits, Credit{ArtistID: a.ID, Name: a.Name, Role: role, Join: strings.TrimSpace(a.Join)})
+	}
+	return credits
+}
+
+func extraArtistCredits(artists []releaseArtist) []Credit {
+	credits := make([]Credit, 0, len(artists))
+	for _, a := range artists {
+		if credit := extraArtistCredit(a); credit.Name != "" {
+			credits = append(credits, credit)
+		}
+	}
+	return credits
+}
+
+func extraArtistCredit(a releaseArtist) Credit {
+	detail := strings.TrimSpace(a.Role)
+	return Credit{ArtistID: a.ID, Name: a.Name, Role: classifyRole(detail), Detail: detail}
+}
+
+func isFeaturingJoin(join string) bool {
+	join = strings.ToLower(strings.TrimSpace(join))
+	return strings.HasPrefix(join, "feat") || join == "ft." || join == "with"
+}
+
+// classifyRole maps Discogs extra artist role text to a credit role
+func classifyRole(role string) string {
+	r := strings.ToLower(role)
+	switch {
+	case strings.HasPrefix(r, "feat") || strings.Contains(r, "featuring"):
+		return models.CreditRoleFeaturing
+	case strings.Contains(r, "producer") || strings.Contains(r, "produced"):
+		return models.CreditRoleProducer
+	case strings.Contains(r, "engineer") || strings.Contains(r, "mixed by") || strings.Contains(r, "mastered by") ||
+		strings.Contains(r, "recorded by") || strings.Contains(r, "lacquer cut"):
+		return models.CreditRoleEngineer
+	case performanceRolePattern.MatchString(r):
+		return models.CreditRolePerformer
+	}
+	return models.CreditRoleContributor
+}
+
+// performanceRolePattern matches Discogs roles for people heard on the recording
+var performanceRolePattern = regexp.MustCompile(`\b(vocals|voice|guitar|bass|drums|percussion|keyboards|piano|organ|` +
+	`synthesizer|saxophone|trumpet|trombone|horn|violin|viola|cello|strings|harmonica|flute|clarinet|` +
+	`performer|musician|orchestra|choir|conductor|rap|dj mix|programmed by|turntables|banjo|mandolin)\b`)
+
+// resolveTrackSpec returns the tracklist indexes named by a Discogs "tracks" value
+// such as "A1, B2" or "A1 to A3". Unknown positions are ignored
+func resolveTrackSpec(spec string, positions []string) []int {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil
+	}
+
+	indexOf := func(position string) int {
+		position = strings.TrimSpace(position)
+		for i, p := range positions {
+			if p != "" && strings.EqualFold(p, position) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	var indexes []int
+	seen := make(map[int]bool)
+	add := func(i int) {
+		if i >= 0 && !seen[i] {
+			seen[i] = true
+			indexes = append(indexes, i)
+		}
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		if from, to, ok := strings.Cut(part, " to "); ok {
+			start, end := indexOf(from), indexOf(to)
+			if start < 0 || end < start {
+				continue
+			}
+			for i := start; i <= end; i++ {
+				add(i)
+			}
+			continue
+		}
+		add(indexOf(part))
+	}
+	return indexes
+}
@@ -0,0 +1,195 @@
+package discogs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"vinylfo/models"
+)
+
+// Want is a release on the user's Discogs wantlist
+type Want struct {
+	DiscogsID     int
+	MasterID      int
+	Title         string
+	Artist        string
+	Year          int
+	Format        string
+	FormatDetails string
+	Label         string
+	CatalogNumber string
+	CoverImage    string
+	Rating        int
+	Notes         string
+	DateAdded     string
+}
+
+// PriceStats is the marketplace summary of a release
+type PriceStats struct {
+	LowestPrice *float64 // Cheapest copy for sale, nil when none are listed
+	Currency    string
+	NumForSale  int
+	Blocked     bool // Release may not be sold on Discogs
+}
+
+// Price is an amount in a currency
+type Price struct {
+	Value    float64 `json:"value"`
+	Currency string  `json:"currency"`
+}
+
+// wantlistResponse is a page of /users/{username}/wants
+type wantlistResponse struct {
+	Wants []struct {
+		ID               int    `json:"id"`
+		Rating           int    `json:"rating"`
+		Notes            string `json:"notes"`
+		DateAdded        string `json:"date_added"`
+		BasicInformation struct {
+			Title      string             `json:"title"`
+			Year       int                `json:"year"`
+			MasterID   int                `json:"master_id"`
+			CoverImage string             `json:"cover_image"`
+			Thumb      string             `json:"thumb"`
+			Formats    []collectionFormat `json:"formats"`
+			Labels     []collectionLabel  `json:"labels"`
+			Artists    []struct {
+				Name string `json:"name"`
+			} `json:"artists"`
+		} `json:"basic_information"`
+	} `json:"wants"`
+	Pagination struct {
+		Page  int `json:"page"`
+		Pages int `json:"pages"`
+		Items int `json:"items"`
+	} `json:"pagination"`
+}
+
+// parseWantlist converts a wantlist page to Wants and returns the total number of pages
+func parseWantlist(data []byte) ([]Want, int, error) {
+	var page wantlistResponse
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, 0, err
+	}
+
+	wants := make([]Want, 0, len(page.Wants))
+	for _, w := range page.Wants {
+		info := w.BasicInformation
+		formats := parseFormats(info.Formats)
+		want := Want{
+			DiscogsID:     w.ID,
+			MasterID:      info.MasterID,
+			Title:         info.Title,
+			Year:          info.Year,
+			Format:        formats.Format,
+			FormatDetails: formats.Details,
+			CoverImage:    info.CoverImage,
+			Rating:        w.Rating,
+			Notes:         w.Notes,
+			DateAdded:     w.DateAdded,
+		}
+		if want.CoverImage == "" {
+			want.CoverImage = info.Thumb
+		}
+		if len(info.Artists) > 0 {
+			want.Artist = info.Artists[0].Name
+		}
+		if len(info.Labels) > 0 {
+			want.Label = info.Labels[0].Name
+			if !strings.EqualFold(info.Labels[0].CatNo, "none") {
+				want.CatalogNumber = info.Labels[0].CatNo
+			}
+		}
+		wants = append(wants, want)
+	}
+	return wants, page.Pagination.Pages, nil
+}
+
+// GetWantlist returns one page of the user's wantlist and the total number of pages
+func (c *Client) GetWantlist(username string, page, perPage int) ([]Want, int, error) {
+	if username == "" {
+		return nil, 0, fmt.Errorf("GetWantlist: username is empty")
+	}
+	if c.OAuth == nil || c.OAuth.AccessToken == "" {
+		return nil, 0, fmt.Errorf("GetWantlist: OAuth is not configured")
+	}
+
+	requestURL := fmt.Sprintf("%s/users/%s/wants?page=%d&per_page=%d", APIURL, url.QueryEscape(username), page, perPage)
+	logToFile("DISCOGS_API: GET %s", requestURL)
+
+	resp, err := c.makeOAuthRequest("GET", requestURL, nil)
+	if err != nil {
+		logToFile("DISCOGS_API: ERROR - %v", err)
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return parseWantlist(data)
+}
+
+// GetPriceStats returns the marketplace summary of a release in the given currency
+func (c *Client) GetPriceStats(releaseID int, currency string) (*PriceStats, error) {
+	requestURL := fmt.Sprintf("%s/marketplace/stats/%d", APIURL, releaseID)
+	if currency != "" {
+		requestURL += "?curr_abbr=" + url.QueryEscape(currency)
+	}
+
+	resp, err := c.makeAuthenticatedRequest("GET", requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var stats struct {
+		LowestPrice     *Price `json:"lowest_price"`
+		NumForSale      int    `json:"num_for_sale"`
+		BlockedFromSale bool   `json:"blocked_from_sale"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, err
+	}
+
+	result := &PriceStats{Currency: currency, NumForSale: stats.NumForSale, Blocked: stats.BlockedFromSale}
+	if stats.LowestPrice != nil {
+		value := stats.LowestPrice.Value
+		result.LowestPrice = &value
+		result.Currency = stats.LowestPrice.Currency
+	}
+	return result, nil
+}
+
+// GetPriceSuggestions returns Discogs' suggested price for a release in each media
+// condition, keyed by Goldmine grade. Discogs only answers for users with seller
+// settings filled in; prices are in the user's selling currency
+func (c *Client) GetPriceSuggestions(releaseID int) (map[string]Price, error) {
+	if !c.IsAuthenticated() {
+		return nil, fmt.Errorf("GetPriceSuggestions: OAuth is not configured")
+	}
+
+	requestURL := fmt.Sprintf("%s/marketplace/price_suggestions/%d", APIURL, releaseID)
+	resp, err := c.makeOAuthRequest("GET", requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var suggestions map[string]Price
+	if err := json.NewDecoder(resp.Body).Decode(&suggestions); err != nil {
+		return nil, err
+	}
+
+	byGrade := make(map[string]Price, len(suggestions))
+	for condition, price := range suggestions {
+		if grade := models.NormalizeGrade(condition); grade != "" {
+			byGrade[grade] = price
+		}
+	}
+	return byGrade, nil
+}
@@ -0,0 +1,62 @@
+package discogs
+
+import "testing"
+
+func TestParseWantlist(t *testing.T) {
+	data := []byte(`{
+		"pagination": {"page": 1, "pages": 3, "items": 250},
+		"wants": [
+			{
+				"id": 1873013,
+				"rating": 4,
+				"notes": "Original pressing only",
+				"date_added": "2024-03-01T10:00:00-08:00",
+				"basic_information": {
+					"title": "Blue Train",
+					"year": 1958,
+					"master_id": 64476,
+					"thumb": "https://i.discogs.com/thumb.jpg",
+					"formats": [{"name": "Vinyl", "qty": "1", "descriptions": ["LP", "Album", "Mono"]}],
+					"labels": [{"name": "Blue Note", "catno": "BLP 1577"}],
+					"artists": [{"name": "John Coltrane"}]
+				}
+			},
+			{
+				"id": 42,
+				"basic_information": {
+					"title": "Untitled",
+					"labels": [{"name": "Not On Label", "catno": "none"}]
+				}
+			}
+		]
+	}`)
+
+	wants, pages, err := parseWantlist(data)
+	if err != nil {
+		t.Fatalf("parseWantlist: %v", err)
+	}
+	if pages != 3 {
+		t.Errorf("pages = %d, want 3", pages)
+	}
+	if len(wants) != 2 {
+		t.Fatalf("got %d wants, want 2", len(wants))
+	}
+
+	want := wants[0]
+	if want.DiscogsID != 1873013 || want.MasterID != 64476 || want.Artist != "John Coltrane" || want.Year != 1958 {
+		t.Errorf("unexpected want: %+v", want)
+	}
+	if want.Format != "LP" || want.Label != "Blue Note" || want.CatalogNumber != "BLP 1577" {
+		t.Errorf("unexpected format or label: %+v", want)
+	}
+	if want.CoverImage != "https://i.discogs.com/thumb.jpg" {
+		t.Errorf("CoverImage = %q, want the thumbnail fallback", want.CoverImage)
+	}
+	if want.Rating != 4 || want.Notes != "Original pressing only" {
+		t.Errorf("unexpected rating or notes: %+v", want)
+	}
+
+	if wants[1].CatalogNumber != "" || wants[1].Artist != "" {
+		t.Errorf("expected empty catalog number and artist, got %+v", wants[1])
+	}
+}
@@ -0,0 +1,117 @@
+package discogs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MasterVersion is one release of a master with its tracklist
+type MasterVersion struct {
+	ReleaseID int
+	Title     string
+	Year      int
+	Format    string
+	Tracks    []map[string]interface{}
+}
+
+// TrackVersion is a track as listed on one release
+type TrackVersion struct {
+	ReleaseID int     `json:"release_id"`
+	Release   string  `json:"release"`
+	Year      int     `json:"year"`
+	Format    string  `json:"format"`
+	Position  string  `json:"position"`
+	Title     string  `json:"title"`
+	Duration  int     `json:"duration"` // Seconds
+	Score     float64 `json:"score"`    // Title similarity to the track searched for
+}
+
+// ReleaseURL returns the Discogs page of a release
+func ReleaseURL(releaseID int) string {
+	return fmt.Sprintf("https://www.discogs.com/release/%d", releaseID)
+}
+
+// EachMasterVersion fetches the tracklist of every release of a master, paging through its
+// releases, and passes each to visit until visit returns false; one request per release goes
+// through the shared rate limiter
+// A rate limit ends the walk early with ErrRateLimited, after the versions visited so far
+func (c *Client) EachMasterVersion(masterID int, visit func(MasterVersion) bool) error {
+	visited := 0
+	for page, pages := 1, 1; page <= pages; page++ {
+		releases, total, err := c.getMasterReleasesPage(masterID, page)
+		if err != nil {
+			return err
+		}
+		pages = total
+
+		for _, release := range releases {
+			releaseID, _ := release["id"].(int)
+			if releaseID == 0 {
+				continue
+			}
+
+			tracks, err := c.GetTracksForAlbum(releaseID)
+			if errors.Is(err, ErrRateLimited) {
+				logToFile("EachMasterVersion: Rate limited after %d releases of master %d", visited, masterID)
+				return err
+			}
+			if err != nil {
+				logToFile("EachMasterVersion: Failed to fetch tracks for release %d: %v", releaseID, err)
+				continue
+			}
+
+			title, _ := release["title"].(string)
+			year, _ := release["year"].(int)
+			format, _ := release["format"].(string)
+			visited++
+			if !visit(MasterVersion{ReleaseID: releaseID, Title: title, Year: year, Format: format, Tracks: tracks}) {
+				return nil
+			}
+		}
+	}
+
+	logToFile("EachMasterVersion: Visited %d releases of master %d", visited, masterID)
+	return nil
+}
+
+// GetMasterVersions fetches the tracklists of all releases of a master
+// A rate limit ends the search early; the versions fetched so far are returned with the error
+func (c *Client) GetMasterVersions(masterID int) ([]MasterVersion, error) {
+	var versions []MasterVersion
+	err := c.EachMasterVersion(masterID, func(version MasterVersion) bool {
+		versions = append(versions, version)
+		return true
+	})
+	return versions, err
+}
+
+// FindTrackVersions returns the closest title match from each version that lists a duration for
+// it, using the same matching as CrossReferenceTimestamps
+func FindTrackVersions(versions []MasterVersion, title string) []TrackVersion {
+	var found []TrackVersion
+	for _, version := range versions {
+		best, bestScore := bestTitleMatch(title, version.Tracks)
+		if best < 0 {
+			continue
+		}
+
+		track := version.Tracks[best]
+		seconds, _ := track["duration"].(int)
+		if seconds <= 0 {
+			continue
+		}
+		trackTitle, _ := track["title"].(string)
+		position, _ := track["position"].(string)
+		found = append(found, TrackVersion{
+			ReleaseID: version.ReleaseID,
+			Release:   version.Title,
+			Year:      version.Year,
+			Format:    version.Format,
+			Position:  position,
+			Title:     trackTitle,
+			Duration:  seconds,
+			Score:     bestScore,
+		})
+	}
+	return found
+}
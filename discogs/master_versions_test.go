@@ -0,0 +1,101 @@
+package discogs
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestFindTrackVersions(t *testing.T) {
+	versions := []MasterVersion{
+		{ReleaseID: 1, Title: "Kind Of Blue", Year: 1959, Format: "Vinyl", Tracks: []map[string]interface{}{
+			{"position": "A1", "title": "So What", "duration": 562},
+			{"position": "A2", "title": "Freddie Freeloader", "duration": 586},
+		}},
+		{ReleaseID: 2, Title: "Kind Of Blue", Year: 1997, Format: "CD", Tracks: []map[string]interface{}{
+			{"position": "1", "title": "So What (Take 3)", "duration": 0},
+			{"position": "2", "title": "So  What", "duration": 565},
+		}},
+		{ReleaseID: 3, Title: "Kind Of Blue", Year: 1987, Format: "CD", Tracks: []map[string]interface{}{
+			{"position": "1", "title": "Blue In Green", "duration": 337},
+		}},
+		{ReleaseID: 4, Title: "Kind Of Blue", Year: 2015, Format: "Vinyl", Tracks: []map[string]interface{}{
+			{"position": "A1", "title": "So What", "duration": 0},
+		}},
+	}
+
+	found := FindTrackVersions(versions, "So What")
+	if len(found) != 2 {
+		t.Fatalf("got %d versions, want 2: %+v", len(found), found)
+	}
+	if v := found[0]; v.ReleaseID != 1 || v.Position != "A1" || v.Duration != 562 || v.Score != 1 {
+		t.Errorf("first version = %+v", v)
+	}
+	if v := found[1]; v.ReleaseID != 2 || v.Position != "2" || v.Duration != 565 || v.Format != "CD" {
+		t.Errorf("second version = %+v", v)
+	}
+
+	if found := FindTrackVersions(versions, "All Blues"); len(found) != 0 {
+		t.Errorf("got %+v for a track on no release", found)
+	}
+}
+
+// rewriteTransport sends every request to a test server, keeping its path and query
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = t.target.Scheme, t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestEachMasterVersion_PagesThroughAllReleases(t *testing.T) {
+	fetched := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched[r.URL.Path]++
+		switch {
+		case r.URL.Path == "/masters/7/releases" && r.URL.Query().Get("page") == "2":
+			w.Write([]byte(`{"pagination": {"pages": 2}, "releases": [{"id": 3, "title": "Kind Of Blue", "year": 1997, "format": "CD"}]}`))
+		case r.URL.Path == "/masters/7/releases":
+			w.Write([]byte(`{"pagination": {"pages": 2}, "releases": [{"id": 1, "title": "Kind Of Blue"}, {"id": 2, "title": "Kind Of Blue"}]}`))
+		case r.URL.Path == "/releases/3":
+			w.Write([]byte(`{"id": 3, "title": "Kind Of Blue", "tracklist": [{"position": "1", "title": "So What", "duration": "9:25"}]}`))
+		default:
+			var id int
+			fmt.Sscanf(r.URL.Path, "/releases/%d", &id)
+			fmt.Fprintf(w, `{"id": %d, "title": "Kind Of Blue", "tracklist": [{"position": "A1", "title": "So What", "duration": ""}]}`, id)
+		}
+	}))
+	defer server.Close()
+	target, _ := url.Parse(server.URL)
+	client := &Client{HTTPClient: &http.Client{Transport: rewriteTransport{target}}, RateLimiter: NewRateLimiter()}
+
+	versions, err := client.GetMasterVersions(7)
+	if err != nil {
+		t.Fatalf("GetMasterVersions() error = %v", err)
+	}
+	if len(versions) != 3 || versions[2].ReleaseID != 3 || versions[2].Format != "CD" {
+		t.Fatalf("versions = %+v, want all three releases across both pages", versions)
+	}
+
+	// The import cross-reference walks the same releases, past the first page
+	tracks := []map[string]interface{}{{"position": "A1", "title": "So What", "duration": 0}}
+	matched, err := client.CrossReferenceTimestampsWithMaster("Kind Of Blue", "Miles Davis", tracks, 7)
+	if err != nil || matched[0]["duration"] != 565 {
+		t.Errorf("CrossReferenceTimestampsWithMaster() = %v, %v, want the duration from the second page", matched, err)
+	}
+
+	clear(fetched)
+	visited := 0
+	client.EachMasterVersion(7, func(MasterVersion) bool {
+		visited++
+		return false
+	})
+	if visited != 1 || fetched["/releases/2"] != 0 {
+		t.Errorf("walk went on after visit stopped it: %d visited, fetched %v", visited, fetched)
+	}
+}
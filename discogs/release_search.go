@@ -0,0 +1,130 @@
+package discogs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ReleaseMatch is a release found by searching Discogs for a barcode or catalog number
+type ReleaseMatch struct {
+	DiscogsID     int      `json:"discogs_id"`
+	MasterID      int      `json:"master_id"`
+	Title         string   `json:"title"`
+	Artist        string   `json:"artist"`
+	Year          int      `json:"year"`
+	Country       string   `json:"country"`
+	Formats       []string `json:"formats"` // As listed by Discogs, e.g. ["Vinyl", "LP", "Album"]
+	Format        string   `json:"format"`
+	FormatDetails string   `json:"format_details"`
+	Label         string   `json:"label"`
+	CatalogNumber string   `json:"catalog_number"`
+	Barcodes      []string `json:"barcodes"`
+	CoverImage    string   `json:"cover_image"`
+	Have          int      `json:"have"` // Discogs users who own it
+}
+
+// releaseSearchResponse is a page of /database/search with type=release
+type releaseSearchResponse struct {
+	Results []struct {
+		ID         int      `json:"id"`
+		MasterID   int      `json:"master_id"`
+		Title      string   `json:"title"` // "Artist - Title"
+		Year       any      `json:"year"`
+		Country    string   `json:"country"`
+		Format     []string `json:"format"`
+		Label      []string `json:"label"`
+		CatNo      string   `json:"catno"`
+		Barcode    []string `json:"barcode"`
+		CoverImage string   `json:"cover_image"`
+		Thumb      string   `json:"thumb"`
+		Community  struct {
+			Have int `json:"have"`
+		} `json:"community"`
+	} `json:"results"`
+}
+
+// parseReleaseSearch converts a release search page to ReleaseMatches
+func parseReleaseSearch(data []byte) ([]ReleaseMatch, error) {
+	var page releaseSearchResponse
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, err
+	}
+
+	matches := make([]ReleaseMatch, 0, len(page.Results))
+	for _, r := range page.Results {
+		match := ReleaseMatch{
+			DiscogsID:  r.ID,
+			MasterID:   r.MasterID,
+			Title:      r.Title,
+			Country:    r.Country,
+			Formats:    r.Format,
+			Barcodes:   r.Barcode,
+			CoverImage: r.CoverImage,
+			Have:       r.Community.Have,
+		}
+		if artist, title, ok := strings.Cut(r.Title, " - "); ok {
+			match.Artist = artist
+			match.Title = title
+		}
+		switch v := r.Year.(type) {
+		case float64:
+			match.Year = int(v)
+		case string:
+			match.Year, _ = strconv.Atoi(v)
+		}
+		if match.CoverImage == "" {
+			match.CoverImage = r.Thumb
+		}
+		if len(r.Label) > 0 {
+			match.Label = r.Label[0]
+		}
+		if !strings.EqualFold(r.CatNo, "none") {
+			match.CatalogNumber = r.CatNo
+		}
+
+		// Search results list formats flat, so treat them as one format with descriptions
+		if len(r.Format) > 0 {
+			formats := parseFormats([]collectionFormat{{Name: r.Format[0], Descriptions: r.Format[1:]}})
+			match.Format = formats.Format
+			match.FormatDetails = formats.Details
+		}
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// SearchReleasesByCode searches Discogs releases by barcode, or by catalog number when
+// barcode is empty, and returns up to the first 50 matches in Discogs' order
+func (c *Client) SearchReleasesByCode(barcode, catno string) ([]ReleaseMatch, error) {
+	params := url.Values{}
+	params.Set("type", "release")
+	params.Set("per_page", "50")
+	switch {
+	case barcode != "":
+		params.Set("barcode", barcode)
+	case catno != "":
+		params.Set("catno", catno)
+	default:
+		return nil, fmt.Errorf("SearchReleasesByCode: barcode or catalog number required")
+	}
+
+	requestURL := fmt.Sprintf("%s/database/search?%s", APIURL, params.Encode())
+	logToFile("DISCOGS_API: GET %s", requestURL)
+
+	resp, err := c.makeAuthenticatedRequest("GET", requestURL, nil)
+	if err != nil {
+		logToFile("DISCOGS_API: ERROR - %v", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseReleaseSearch(data)
+}
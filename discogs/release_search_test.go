@@ -0,0 +1,59 @@
+package discogs
+
+import "testing"
+
+func TestParseReleaseSearch(t *testing.T) {
+	data := []byte(`{
+		"pagination": {"page": 1, "pages": 1, "items": 2},
+		"results": [
+			{
+				"id": 1873013,
+				"master_id": 10362,
+				"title": "Pink Floyd - The Dark Side Of The Moon",
+				"year": "1973",
+				"country": "UK",
+				"format": ["Vinyl", "LP", "Album", "Gatefold"],
+				"label": ["Harvest", "EMI"],
+				"catno": "SHVL 804",
+				"barcode": ["5 099902 894819"],
+				"thumb": "https://i.discogs.com/thumb.jpg",
+				"community": {"have": 5120, "want": 900}
+			},
+			{
+				"id": 42,
+				"title": "Untitled",
+				"year": 2001,
+				"format": ["CD", "Album"],
+				"catno": "none",
+				"cover_image": "https://i.discogs.com/cover.jpg"
+			}
+		]
+	}`)
+
+	matches, err := parseReleaseSearch(data)
+	if err != nil {
+		t.Fatalf("parseReleaseSearch: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("got %d matches, want 2", len(matches))
+	}
+
+	m := matches[0]
+	if m.DiscogsID != 1873013 || m.MasterID != 10362 || m.Artist != "Pink Floyd" || m.Title != "The Dark Side Of The Moon" {
+		t.Errorf("unexpected match: %+v", m)
+	}
+	if m.Year != 1973 || m.Country != "UK" || m.Label != "Harvest" || m.CatalogNumber != "SHVL 804" {
+		t.Errorf("unexpected release details: %+v", m)
+	}
+	if m.Format != "LP" || m.FormatDetails != "Album, Gatefold" {
+		t.Errorf("Format = %q, FormatDetails = %q, want LP and \"Album, Gatefold\"", m.Format, m.FormatDetails)
+	}
+	if len(m.Barcodes) != 1 || m.Have != 5120 || m.CoverImage != "https://i.discogs.com/thumb.jpg" {
+		t.Errorf("unexpected barcodes, have count or cover: %+v", m)
+	}
+
+	m = matches[1]
+	if m.Title != "Untitled" || m.Artist != "" || m.Year != 2001 || m.CatalogNumber != "" || m.Format != "CD" {
+		t.Errorf("unexpected match without artist: %+v", m)
+	}
+}
@@ -1,6 +1,9 @@
 package discogs
 
 import (
+	"fmt"
+	"strings"
+
 	"vinylfo/models"
 )
 
@@ -305,3 +308,113 @@ func (s *DataReviewService) getNewFields(release map[string]interface{}) []Field
 func (s *DataReviewService) ShouldAutoApply(review *AlbumReview) bool {
 	return review.CanAutoApply
 }
+
+// ReviewCollectionChanges groups queued local collection edits by album so they can be
+// checked before they are pushed. Edits that collided with a change made on Discogs
+// are conflicts, with the Discogs value as the current value
+func (s *DataReviewService) ReviewCollectionChanges(changes []models.CollectionChange) *BatchReview {
+	review := &BatchReview{
+		Reviews: make([]AlbumReview, 0),
+	}
+
+	byAlbum := make(map[uint]int)
+	isNew := make(map[uint]bool)
+	for _, change := range changes {
+		i, ok := byAlbum[change.AlbumID]
+		if !ok {
+			review.Reviews = append(review.Reviews, AlbumReview{
+				AlbumID:   change.AlbumID,
+				DiscogsID: change.DiscogsID,
+				Title:     change.Title,
+				Artist:    change.Artist,
+				Changes:   make([]FieldChange, 0),
+			})
+			i = len(review.Reviews) - 1
+			byAlbum[change.AlbumID] = i
+		}
+		albumReview := &review.Reviews[i]
+
+		fieldChange := FieldChange{
+			Field:        collectionChangeField(change),
+			CurrentValue: change.OldValue,
+			NewValue:     change.NewValue,
+			Severity:     SeverityInfo,
+		}
+		switch change.Status {
+		case models.ChangeStatusConflict:
+			fieldChange.CurrentValue = change.RemoteValue
+			fieldChange.Severity = SeverityConflict
+			albumReview.HasConflicts = true
+		case models.ChangeStatusFailed:
+			fieldChange.Severity = SeverityWarning
+		}
+		albumReview.Changes = append(albumReview.Changes, fieldChange)
+
+		if change.Action == models.ChangeAdd {
+			isNew[change.AlbumID] = true
+		}
+	}
+
+	for i := range review.Reviews {
+		albumReview := &review.Reviews[i]
+		albumReview.CanAutoApply = !albumReview.HasConflicts
+		albumReview.Summary = s.collectionChangeSummary(albumReview)
+		if albumReview.HasConflicts {
+			review.ConflictCount++
+		}
+		if isNew[albumReview.AlbumID] {
+			review.NewAlbums++
+		} else {
+			review.UpdatedAlbums++
+		}
+	}
+	review.TotalAlbums = len(review.Reviews)
+
+	return review
+}
+
+// collectionChangeField names the album field a queued change affects
+func collectionChangeField(change models.CollectionChange) string {
+	switch change.Action {
+	case models.ChangeField:
+		return change.Field
+	case models.ChangeMove:
+		return "discogs_folder_id"
+	case models.ChangeRating:
+		return "rating"
+	}
+	return change.Action
+}
+
+func (s *DataReviewService) collectionChangeSummary(review *AlbumReview) string {
+	pending, failed, conflicts := 0, 0, 0
+	for _, change := range review.Changes {
+		switch change.Severity {
+		case SeverityConflict:
+			conflicts++
+		case SeverityWarning:
+			failed++
+		default:
+			pending++
+		}
+	}
+
+	parts := make([]string, 0, 3)
+	if pending > 0 {
+		parts = append(parts, countLabel(pending, "change to push", "changes to push"))
+	}
+	if failed > 0 {
+		parts = append(parts, countLabel(failed, "failed change", "failed changes"))
+	}
+	if conflicts > 0 {
+		parts = append(parts, countLabel(conflicts, "conflict", "conflicts"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func countLabel(count int, singular, plural string) string {
+	if count == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %s", count, plural)
+}
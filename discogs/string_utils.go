@@ -29,27 +29,7 @@ func matchTracksByName(currentTracks, altTracks []map[string]interface{}) []map[
 
 	for i, currentTrack := range currentTracks {
 		currentTitle, _ := currentTrack["title"].(string)
-		currentTitle = strings.TrimSpace(strings.ToLower(currentTitle))
-
-		bestMatch := -1
-		bestScore := 0.0
-
-		for j, altTrack := range altTracks {
-			altTitle, _ := altTrack["title"].(string)
-			altTitle = strings.TrimSpace(strings.ToLower(altTitle))
-
-			if currentTitle == altTitle {
-				bestMatch = j
-				bestScore = 1.0
-				break
-			}
-
-			score := stringSimilarity(currentTitle, altTitle)
-			if score > bestScore && score >= 0.7 {
-				bestScore = score
-				bestMatch = j
-			}
-		}
+		bestMatch, _ := bestTitleMatch(currentTitle, altTracks)
 
 		if bestMatch >= 0 {
 			altTrack := altTracks[bestMatch]
@@ -72,6 +52,24 @@ func matchTracksByName(currentTracks, altTracks []map[string]interface{}) []map[
 	return matched
 }
 
+// bestTitleMatch returns the index and similarity of the track whose title is closest to title,
+// or -1 when none is similar enough; shared by the import cross-reference and the duration resolver
+func bestTitleMatch(title string, tracks []map[string]interface{}) (int, float64) {
+	title = removeZeroWidthChars(title)
+	best, bestScore := -1, 0.0
+	for i, track := range tracks {
+		trackTitle, _ := track["title"].(string)
+		score := stringSimilarity(title, removeZeroWidthChars(trackTitle))
+		if score > bestScore && score >= 0.7 {
+			best, bestScore = i, score
+		}
+		if bestScore == 1 {
+			break
+		}
+	}
+	return best, bestScore
+}
+
 func removeZeroWidthChars(s string) string {
 	result := make([]rune, 0, len(s))
 	for _, r := range s {
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"vinylfo/config"
+	"vinylfo/httpcache"
 )
 
 // TrackSearchResult represents a track duration found by an external API
@@ -33,6 +34,10 @@ type TrackSearchResult struct {
 
 	// Debug info
 	RawResponse string `json:"raw_response"` // Full API response JSON
+
+	// Other releases the track was found on (e.g., versions of a Discogs master)
+	// The resolver stores each as its own source, but only the result itself counts toward consensus
+	Versions []TrackSearchResult `json:"versions,omitempty"`
 }
 
 // MusicAPIClient is the interface all external API clients must implement
@@ -96,12 +101,17 @@ func NewBaseClient(userAgent string, requestsPerMinute int) *BaseClient {
 // DoWithRetry executes an HTTP request with automatic retry on rate limits and transient errors
 // It handles 429 (Too Many Requests) and 503 (Service Unavailable) status codes
 // and respects Retry-After headers when present
+// Responses the shared HTTP cache holds are returned without a request
 func (c *BaseClient) DoWithRetry(ctx context.Context, req *http.Request) (*http.Response, []byte, error) {
 	return c.DoWithRetryConfig(ctx, req, DefaultRetryConfig())
 }
 
 // DoWithRetryConfig executes an HTTP request with configurable retry behavior
 func (c *BaseClient) DoWithRetryConfig(ctx context.Context, req *http.Request, cfg RetryConfig) (*http.Response, []byte, error) {
+	if cached, ok := httpcache.Lookup(req); ok {
+		return cached.HTTPResponse(req), cached.Body, nil
+	}
+
 	var lastErr error
 	var lastResp *http.Response
 
@@ -172,6 +182,7 @@ func (c *BaseClient) DoWithRetryConfig(ctx context.Context, req *http.Request, c
 		}
 
 		// Success or non-retryable error
+		httpcache.Save(req, resp, body)
 		return resp, body, nil
 	}
 
@@ -243,6 +254,11 @@ func CalculateMatchScore(searchTitle, searchArtist, resultTitle, resultArtist st
 	return (titleScore * 0.6) + (artistScore * 0.4)
 }
 
+// TitleSimilarity compares two titles after removing edition suffixes (0.0-1.0)
+func TitleSimilarity(a, b string) float64 {
+	return stringSimilarity(NormalizeTitle(a), NormalizeTitle(b))
+}
+
 // stringSimilarity returns similarity between two strings (0.0-1.0)
 // Uses case-insensitive comparison with Levenshtein distance
 func stringSimilarity(a, b string) float64 {
@@ -0,0 +1,60 @@
+package duration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"vinylfo/httpcache"
+	"vinylfo/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestDoWithRetry_UsesHTTPCache(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"recordings":[]}`))
+	}))
+	defer server.Close()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite memory db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.HTTPCacheEntry{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+	host, _ := url.Parse(server.URL)
+	opts := httpcache.DefaultOptions()
+	opts.Hosts = map[string]string{host.Host: "musicbrainz"}
+	httpcache.SetDefault(httpcache.New(db, opts))
+	defer httpcache.SetDefault(nil)
+
+	client := NewBaseClient("test", 600)
+	for _, path := range []string{"/recording", "/recording", "/missing", "/missing"} {
+		req, _ := http.NewRequest("GET", server.URL+path, nil)
+		resp, body, err := client.DoWithRetry(context.Background(), req)
+		if err != nil {
+			t.Fatalf("DoWithRetry(%s) error = %v", path, err)
+		}
+		if path == "/recording" && (resp.StatusCode != 200 || string(body) != `{"recordings":[]}`) {
+			t.Errorf("DoWithRetry(%s) = %d %q", path, resp.StatusCode, body)
+		}
+		if path == "/missing" && resp.StatusCode != 404 {
+			t.Errorf("DoWithRetry(%s) status = %d, want the cached 404", path, resp.StatusCode)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("server saw %d requests, want 2", requests)
+	}
+}
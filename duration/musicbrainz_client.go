@@ -133,32 +133,33 @@ func (c *MusicBrainzClient) SearchTrack(ctx context.Context, title, artist, albu
 	return result, nil
 }
 
-func (c *MusicBrainzClient) buildQuery(title, artist, album string) string {
-	escape := func(s string) string {
-		replacer := strings.NewReplacer(
-			`+`, `\+`,
-			`-`, `\-`,
-			`&&`, `\&&`,
-			`||`, `\||`,
-			`!`, `\!`,
-			`(`, `\(`,
-			`)`, `\)`,
-			`{`, `\{`,
-			`}`, `\}`,
-			`[`, `\[`,
-			`]`, `\]`,
-			`^`, `\^`,
-			`"`, `\"`,
-			`~`, `\~`,
-			`*`, `\*`,
-			`?`, `\?`,
-			`:`, `\:`,
-			`\`, `\\`,
-			`/`, `\/`,
-		)
-		return replacer.Replace(s)
-	}
+// escapeLucene escapes the characters that have a meaning in MusicBrainz search queries
+func escapeLucene(s string) string {
+	replacer := strings.NewReplacer(
+		`+`, `\+`,
+		`-`, `\-`,
+		`&&`, `\&&`,
+		`||`, `\||`,
+		`!`, `\!`,
+		`(`, `\(`,
+		`)`, `\)`,
+		`{`, `\{`,
+		`}`, `\}`,
+		`[`, `\[`,
+		`]`, `\]`,
+		`^`, `\^`,
+		`"`, `\"`,
+		`~`, `\~`,
+		`*`, `\*`,
+		`?`, `\?`,
+		`:`, `\:`,
+		`\`, `\\`,
+		`/`, `\/`,
+	)
+	return replacer.Replace(s)
+}
 
+func (c *MusicBrainzClient) buildQuery(title, artist, album string) string {
 	// Normalize artist name to remove disambiguation suffixes like "(2)" before querying
 	normalizedArtist := NormalizeArtistName(artist)
 	// Normalize title and album to remove edition suffixes like "(Remastered)"
@@ -166,12 +167,12 @@ func (c *MusicBrainzClient) buildQuery(title, artist, album string) string {
 	normalizedAlbum := NormalizeTitle(album)
 
 	parts := []string{
-		fmt.Sprintf(`recording:"%s"`, escape(normalizedTitle)),
-		fmt.Sprintf(`artist:"%s"`, escape(normalizedArtist)),
+		fmt.Sprintf(`recording:"%s"`, escapeLucene(normalizedTitle)),
+		fmt.Sprintf(`artist:"%s"`, escapeLucene(normalizedArtist)),
 	}
 
 	if normalizedAlbum != "" {
-		parts = append(parts, fmt.Sprintf(`release:"%s"`, escape(normalizedAlbum)))
+		parts = append(parts, fmt.Sprintf(`release:"%s"`, escapeLucene(normalizedAlbum)))
 	}
 
 	return strings.Join(parts, " AND ")
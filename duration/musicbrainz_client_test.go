@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 	"time"
+
+	"vinylfo/httpreplay"
 )
 
 func TestMusicBrainzClient_SearchTrack(t *testing.T) {
@@ -24,6 +26,23 @@ func TestMusicBrainzClient_SearchTrack(t *testing.T) {
 	}
 }
 
+func TestMusicBrainzClient_SearchTrack_Replay(t *testing.T) {
+	replay, err := httpreplay.New(httpreplay.ModeReplay, "testdata/http", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewMusicBrainzClient("test@example.com")
+	client.HTTPClient.Transport = replay
+
+	result, err := client.SearchTrack(context.Background(), "Bohemian Rhapsody", "Queen", "A Night at the Opera")
+	if err != nil {
+		t.Fatalf("SearchTrack() error = %v", err)
+	}
+	if result == nil || result.Duration != 354 || result.ExternalID != "b1a9c0e9-d987-4042-ae91-78d6a3267d69" {
+		t.Errorf("SearchTrack() = %+v, want the studio recording at 354s", result)
+	}
+}
+
 func TestMusicBrainzClient_buildQuery_EscapesSpecialChars(t *testing.T) {
 	client := NewMusicBrainzClient("test@example.com")
 
@@ -0,0 +1,220 @@
+package duration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// minReleaseMatchScore is the lowest title and artist match accepted for a release found by search
+const minReleaseMatchScore = 0.6
+
+// ReleaseQuery describes the album to find on MusicBrainz; empty fields are not searched on
+type ReleaseQuery struct {
+	Artist        string
+	Title         string
+	Barcode       string
+	CatalogNumber string
+	TrackCount    int // Preferred when several releases match equally well
+}
+
+// MBRelease is a MusicBrainz release with its full tracklist
+type MBRelease struct {
+	ID             string     `json:"id"`
+	ReleaseGroupID string     `json:"release_group_id"`
+	Title          string     `json:"title"`
+	Artist         string     `json:"artist"`
+	Barcode        string     `json:"barcode"`
+	Score          float64    `json:"score"` // 0.0-1.0 search score, 1 for releases looked up by ID
+	Media          []MBMedium `json:"media"`
+}
+
+type MBMedium struct {
+	Position int       `json:"position"`
+	Format   string    `json:"format"`
+	Tracks   []MBTrack `json:"tracks"`
+}
+
+type MBTrack struct {
+	ID          string `json:"id"`
+	RecordingID string `json:"recording_id"`
+	Number      string `json:"number"` // As printed, e.g. "A1" on vinyl or "3" on CD
+	Position    int    `json:"position"`
+	Title       string `json:"title"`
+	Length      int    `json:"length"` // Seconds, 0 when unknown
+}
+
+// Tracks returns the release's tracks across all media in order
+func (r *MBRelease) Tracks() []MBTrack {
+	var tracks []MBTrack
+	for _, medium := range r.Media {
+		tracks = append(tracks, medium.Tracks...)
+	}
+	return tracks
+}
+
+// URL returns the MusicBrainz page of the release
+func (r *MBRelease) URL() string {
+	return "https://musicbrainz.org/release/" + r.ID
+}
+
+type mbReleaseSearchResponse struct {
+	Releases []mbReleaseResult `json:"releases"`
+}
+
+type mbReleaseResult struct {
+	ID           string           `json:"id"`
+	Score        int              `json:"score"`
+	Title        string           `json:"title"`
+	Barcode      string           `json:"barcode"`
+	TrackCount   int              `json:"track-count"`
+	ArtistCredit []mbArtistCredit `json:"artist-credit"`
+	ReleaseGroup struct {
+		ID string `json:"id"`
+	} `json:"release-group"`
+	Media []struct {
+		Position int    `json:"position"`
+		Format   string `json:"format"`
+		Tracks   []struct {
+			ID        string `json:"id"`
+			Number    string `json:"number"`
+			Position  int    `json:"position"`
+			Title     string `json:"title"`
+			Length    *int   `json:"length"`
+			Recording struct {
+				ID     string `json:"id"`
+				Length *int   `json:"length"`
+			} `json:"recording"`
+		} `json:"tracks"`
+	} `json:"media"`
+}
+
+func (r mbReleaseResult) release() *MBRelease {
+	release := &MBRelease{
+		ID:             r.ID,
+		ReleaseGroupID: r.ReleaseGroup.ID,
+		Title:          r.Title,
+		Artist:         creditedArtist(r.ArtistCredit),
+		Barcode:        r.Barcode,
+		Score:          float64(r.Score) / 100.0,
+	}
+	for _, m := range r.Media {
+		medium := MBMedium{Position: m.Position, Format: m.Format}
+		for _, t := range m.Tracks {
+			length := t.Length
+			if length == nil {
+				length = t.Recording.Length
+			}
+			track := MBTrack{ID: t.ID, RecordingID: t.Recording.ID, Number: t.Number, Position: t.Position, Title: t.Title}
+			if length != nil {
+				track.Length = (*length + 500) / 1000
+			}
+			medium.Tracks = append(medium.Tracks, track)
+		}
+		release.Media = append(release.Media, medium)
+	}
+	return release
+}
+
+func creditedArtist(credits []mbArtistCredit) string {
+	if len(credits) == 0 {
+		return ""
+	}
+	return credits[0].Name
+}
+
+// FindRelease finds the release that best matches an album, by barcode, then catalog number, then
+// title, and returns it with its tracklist
+// Returns nil when nothing matches well enough
+func (c *MusicBrainzClient) FindRelease(ctx context.Context, q ReleaseQuery) (*MBRelease, error) {
+	if q.Artist == "" || q.Title == "" {
+		return nil, fmt.Errorf("title and artist are required")
+	}
+
+	artist := fmt.Sprintf(`artist:"%s"`, escapeLucene(NormalizeArtistName(q.Artist)))
+	var queries []string
+	if barcode := strings.ReplaceAll(q.Barcode, " ", ""); barcode != "" {
+		queries = append(queries, fmt.Sprintf(`barcode:%s`, escapeLucene(barcode)))
+	}
+	if q.CatalogNumber != "" {
+		queries = append(queries, fmt.Sprintf(`catno:"%s" AND %s`, escapeLucene(q.CatalogNumber), artist))
+	}
+	queries = append(queries, fmt.Sprintf(`release:"%s" AND %s`, escapeLucene(NormalizeTitle(q.Title)), artist))
+
+	for _, query := range queries {
+		var resp mbReleaseSearchResponse
+		if err := c.getJSON(ctx, fmt.Sprintf("%s/release?query=%s&fmt=json&limit=10", musicBrainzBaseURL, url.QueryEscape(query)), &resp); err != nil {
+			return nil, err
+		}
+
+		best := c.bestRelease(resp.Releases, q)
+		if best == nil {
+			log.Printf("MB: No release matched %q", query)
+			continue
+		}
+		log.Printf("MB: Release %s (%s by %s) matched %q", best.ID, best.Title, creditedArtist(best.ArtistCredit), query)
+
+		release, err := c.GetRelease(ctx, best.ID)
+		if err != nil {
+			return nil, err
+		}
+		release.Score = float64(best.Score) / 100.0
+		return release, nil
+	}
+	return nil, nil
+}
+
+func (c *MusicBrainzClient) bestRelease(results []mbReleaseResult, q ReleaseQuery) *mbReleaseResult {
+	var best *mbReleaseResult
+	var bestScore float64
+	for i, r := range results {
+		score := CalculateMatchScore(q.Title, q.Artist, r.Title, creditedArtist(r.ArtistCredit))
+		if score < minReleaseMatchScore {
+			continue
+		}
+		score = score*0.6 + float64(r.Score)/100.0*0.4
+		if q.TrackCount > 0 && r.TrackCount == q.TrackCount {
+			score += 0.1
+		}
+		if score > bestScore {
+			best, bestScore = &results[i], score
+		}
+	}
+	return best
+}
+
+// GetRelease looks a release up by its MBID
+func (c *MusicBrainzClient) GetRelease(ctx context.Context, id string) (*MBRelease, error) {
+	var result mbReleaseResult
+	reqURL := fmt.Sprintf("%s/release/%s?inc=recordings+artist-credits+release-groups&fmt=json", musicBrainzBaseURL, url.PathEscape(id))
+	if err := c.getJSON(ctx, reqURL, &result); err != nil {
+		return nil, err
+	}
+	result.Score = 100
+	return result.release(), nil
+}
+
+func (c *MusicBrainzClient) getJSON(ctx context.Context, reqURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, body, err := c.DoWithRetry(ctx, req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API error: %d - %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
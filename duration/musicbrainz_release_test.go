@@ -0,0 +1,67 @@
+package duration
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMBReleaseResult_release(t *testing.T) {
+	data := []byte(`{
+		"id": "f5093c06-23e3-404f-aeaa-40f72885ee3a",
+		"title": "Kind of Blue",
+		"barcode": "074646493524",
+		"artist-credit": [{"name": "Miles Davis", "artist": {"id": "561d854a-6a28-4aa7-8c99-323e6ce46c2a", "name": "Miles Davis"}}],
+		"release-group": {"id": "8e8a594f-2175-4b8c-a6b8-ea8c7a3a5e0e"},
+		"media": [{
+			"position": 1,
+			"format": "12\" Vinyl",
+			"tracks": [
+				{"id": "t1", "number": "A1", "position": 1, "title": "So What", "length": 562400, "recording": {"id": "rec1", "length": 562000}},
+				{"id": "t2", "number": "A2", "position": 2, "title": "Freddie Freeloader", "length": null, "recording": {"id": "rec2", "length": 585600}},
+				{"id": "t3", "number": "B1", "position": 3, "title": "All Blues", "recording": {"id": "rec3"}}
+			]
+		}]
+	}`)
+
+	var result mbReleaseResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	result.Score = 95
+	release := result.release()
+
+	if release.Artist != "Miles Davis" || release.ReleaseGroupID != "8e8a594f-2175-4b8c-a6b8-ea8c7a3a5e0e" || release.Score != 0.95 {
+		t.Errorf("release = %+v", release)
+	}
+	tracks := release.Tracks()
+	if len(tracks) != 3 {
+		t.Fatalf("got %d tracks, want 3", len(tracks))
+	}
+	if tracks[0].Number != "A1" || tracks[0].RecordingID != "rec1" || tracks[0].Length != 562 {
+		t.Errorf("first track = %+v", tracks[0])
+	}
+	if tracks[1].Length != 586 {
+		t.Errorf("track without its own length = %d, want the recording's 586", tracks[1].Length)
+	}
+	if tracks[2].Length != 0 {
+		t.Errorf("track without any length = %d, want 0", tracks[2].Length)
+	}
+}
+
+func TestMusicBrainzClient_bestRelease(t *testing.T) {
+	client := NewMusicBrainzClient("test@example.com")
+	credit := []mbArtistCredit{{Name: "Miles Davis"}}
+	results := []mbReleaseResult{
+		{ID: "compilation", Score: 100, Title: "The Best of Miles Davis", ArtistCredit: credit},
+		{ID: "cd", Score: 90, Title: "Kind of Blue", TrackCount: 6, ArtistCredit: credit},
+		{ID: "lp", Score: 90, Title: "Kind Of Blue", TrackCount: 5, ArtistCredit: credit},
+	}
+
+	best := client.bestRelease(results, ReleaseQuery{Artist: "Miles Davis", Title: "Kind of Blue", TrackCount: 5})
+	if best == nil || best.ID != "lp" {
+		t.Errorf("bestRelease = %+v, want the release with the same track count", best)
+	}
+	if best := client.bestRelease(results[:1], ReleaseQuery{Artist: "Miles Davis", Title: "Kind of Blue"}); best != nil {
+		t.Errorf("bestRelease = %+v, want nil for a different title", best)
+	}
+}
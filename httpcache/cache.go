@@ -0,0 +1,442 @@
+// Package httpcache keeps responses from the external metadata APIs in the database, so retries
+// and repeated lookups are answered without another request
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// Policy is how one source's responses are cached
+type Policy struct {
+	TTL         time.Duration
+	NegativeTTL time.Duration // For 404 responses
+	Paths       []string      // URL path prefixes to cache; every GET request when empty
+	AllowAuth   bool          // Cache requests that carry an Authorization header
+}
+
+// DefaultPolicies keeps catalog data for days, since releases and recordings rarely change once
+// entered, and Discogs data for a day since it is edited more often
+// Discogs is only cached for catalog resources; user resources such as collections never are
+func DefaultPolicies() map[string]Policy {
+	day := 24 * time.Hour
+	return map[string]Policy{
+		"musicbrainz": {TTL: 7 * day, NegativeTTL: day},
+		"wikipedia":   {TTL: 7 * day, NegativeTTL: day},
+		"lastfm":      {TTL: 3 * day, NegativeTTL: 12 * time.Hour},
+		"discogs": {TTL: day, NegativeTTL: 6 * time.Hour, AllowAuth: true,
+			Paths: []string{"/releases/", "/masters/", "/artists/", "/labels/", "/database/search"}},
+		"youtube": {TTL: 7 * day, NegativeTTL: day,
+			Paths: []string{"/youtube/v3/search", "/youtube/v3/videos"}},
+		"coverartarchive": {TTL: 7 * day, NegativeTTL: day},
+		"itunes":          {TTL: 3 * day, NegativeTTL: 12 * time.Hour, Paths: []string{"/search"}},
+	}
+}
+
+// DefaultHosts maps the API hosts the clients call to their source
+func DefaultHosts() map[string]string {
+	return map[string]string{
+		"musicbrainz.org":       "musicbrainz",
+		"en.wikipedia.org":      "wikipedia",
+		"ws.audioscrobbler.com": "lastfm",
+		"api.discogs.com":       "discogs",
+		"www.googleapis.com":    "youtube",
+		"coverartarchive.org":   "coverartarchive",
+		"itunes.apple.com":      "itunes",
+	}
+}
+
+// secretParams are query parameters left out of stored URLs
+var secretParams = []string{"api_key", "key", "token", "access_token"}
+
+// Options sizes a cache
+type Options struct {
+	Policies      map[string]Policy
+	Hosts         map[string]string
+	MaxEntries    int   // Least recently used entries are evicted beyond this
+	MaxBytes      int64 // ...or beyond this many bytes of bodies
+	MaxEntryBytes int   // Larger responses are not cached
+}
+
+// DefaultOptions returns the default policies and limits
+func DefaultOptions() Options {
+	return Options{
+		Policies:      DefaultPolicies(),
+		Hosts:         DefaultHosts(),
+		MaxEntries:    50000,
+		MaxBytes:      256 << 20,
+		MaxEntryBytes: 2 << 20,
+	}
+}
+
+// Counters count cache use since startup
+type Counters struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Stores    int64 `json:"stores"`
+	Evictions int64 `json:"evictions"`
+}
+
+// Cache is a database-backed cache of API responses
+type Cache struct {
+	db   *gorm.DB
+	opts Options
+
+	mu       sync.Mutex
+	counters map[string]*Counters
+
+	// Running totals of stored entries, so a Put does not count the table; loaded on first use
+	// and reloaded after changes whose size is not known, like a purge
+	usageMu    sync.Mutex
+	usageKnown bool
+	entries    int64
+	size       int64
+}
+
+// New creates a cache storing its entries in db
+func New(db *gorm.DB, opts Options) *Cache {
+	return &Cache{db: db, opts: opts, counters: make(map[string]*Counters)}
+}
+
+// Response is a cached response
+type Response struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// HTTPResponse returns the cached response as an *http.Response to req
+func (r *Response) HTTPResponse(req *http.Request) *http.Response {
+	header := make(http.Header)
+	if r.ContentType != "" {
+		header.Set("Content-Type", r.ContentType)
+	}
+	header.Set("X-Vinylfo-Cache", "hit")
+	return &http.Response{
+		Status:        http.StatusText(r.Status),
+		StatusCode:    r.Status,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// Source returns the source a URL belongs to, or "" when its responses are not cached
+func (c *Cache) Source(method, rawURL string) string {
+	if method != http.MethodGet {
+		return ""
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	source := c.opts.Hosts[strings.ToLower(u.Host)]
+	policy, ok := c.opts.Policies[source]
+	if !ok {
+		return ""
+	}
+	if len(policy.Paths) == 0 {
+		return source
+	}
+	for _, prefix := range policy.Paths {
+		if strings.HasPrefix(u.Path, prefix) {
+			return source
+		}
+	}
+	return ""
+}
+
+// Key returns the cache key of a request
+func Key(method, rawURL string) string {
+	sum := sha256.Sum256([]byte(method + " " + rawURL))
+	return hex.EncodeToString(sum[:])
+}
+
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	q := u.Query()
+	for _, param := range secretParams {
+		if q.Has(param) {
+			q.Set(param, "REDACTED")
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func (c *Cache) count(source string, fn func(*Counters)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counters, ok := c.counters[source]
+	if !ok {
+		counters = &Counters{}
+		c.counters[source] = counters
+	}
+	fn(counters)
+}
+
+// Get returns the cached response to a request, if it has one that has not expired
+func (c *Cache) Get(method, rawURL string) (*Response, bool) {
+	source := c.Source(method, rawURL)
+	if source == "" {
+		return nil, false
+	}
+
+	var entry models.HTTPCacheEntry
+	if err := c.db.Where("cache_key = ?", Key(method, rawURL)).Limit(1).Find(&entry).Error; err != nil || entry.ID == 0 {
+		c.count(source, func(n *Counters) { n.Misses++ })
+		return nil, false
+	}
+	now := time.Now()
+	if now.After(entry.ExpiresAt) {
+		if c.db.Delete(&entry).RowsAffected > 0 {
+			c.adjustUsage(-1, -int64(entry.Size))
+		}
+		c.count(source, func(n *Counters) { n.Misses++ })
+		return nil, false
+	}
+
+	c.db.Model(&entry).UpdateColumns(map[string]interface{}{"hits": gorm.Expr("hits + 1"), "accessed_at": now})
+	c.count(source, func(n *Counters) { n.Hits++ })
+	return &Response{Status: entry.Status, ContentType: entry.ContentType, Body: entry.Body}, true
+}
+
+// Put caches a response; only 200 responses and 404s are kept
+func (c *Cache) Put(method, rawURL string, status int, contentType string, body []byte) {
+	source := c.Source(method, rawURL)
+	if source == "" || (status != http.StatusOK && status != http.StatusNotFound) {
+		return
+	}
+	if c.opts.MaxEntryBytes > 0 && len(body) > c.opts.MaxEntryBytes {
+		return
+	}
+
+	policy := c.opts.Policies[source]
+	ttl := policy.TTL
+	if status == http.StatusNotFound {
+		ttl = policy.NegativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	now := time.Now()
+	key := Key(method, rawURL)
+	entry := models.HTTPCacheEntry{
+		CacheKey:    key,
+		Source:      source,
+		URL:         redactURL(rawURL),
+		Status:      status,
+		ContentType: contentType,
+		Body:        body,
+		Size:        len(body),
+		Negative:    status == http.StatusNotFound,
+		ExpiresAt:   now.Add(ttl),
+		AccessedAt:  now,
+	}
+	replaced := false
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("cache_key = ?", key).Delete(&models.HTTPCacheEntry{})
+		if result.Error != nil {
+			return result.Error
+		}
+		replaced = result.RowsAffected > 0
+		return tx.Create(&entry).Error
+	})
+	if err != nil {
+		log.Printf("httpcache: failed to store %s: %v", entry.URL, err)
+		c.forgetUsage()
+		return
+	}
+	c.count(source, func(n *Counters) { n.Stores++ })
+	if replaced {
+		c.forgetUsage()
+	} else {
+		c.adjustUsage(1, int64(entry.Size))
+	}
+	c.evict()
+}
+
+// evict drops expired entries once the cache is over its limits, then the least recently used
+// ones until it is within them
+func (c *Cache) evict() {
+	c.usageMu.Lock()
+	defer c.usageMu.Unlock()
+	if !c.usageKnown {
+		c.loadUsage()
+	}
+	if !c.overLimit(c.entries, c.size) {
+		return
+	}
+
+	result := c.db.Where("expires_at < ?", time.Now()).Delete(&models.HTTPCacheEntry{})
+	if result.RowsAffected > 0 {
+		c.loadUsage()
+	}
+
+	for c.overLimit(c.entries, c.size) {
+		var oldest []models.HTTPCacheEntry
+		batch := 10 // Over the size limit; sizes vary, so drop a few at a time
+		if excess := int(c.entries) - c.opts.MaxEntries; c.opts.MaxEntries > 0 && excess > 0 {
+			batch = excess
+		}
+		if err := c.db.Select("id", "source").Order("accessed_at ASC, id ASC").Limit(batch).Find(&oldest).Error; err != nil || len(oldest) == 0 {
+			return
+		}
+		ids := make([]uint, len(oldest))
+		for i, e := range oldest {
+			ids[i] = e.ID
+			c.count(e.Source, func(n *Counters) { n.Evictions++ })
+		}
+		if err := c.db.Delete(&models.HTTPCacheEntry{}, ids).Error; err != nil {
+			log.Printf("httpcache: eviction failed: %v", err)
+			c.usageKnown = false
+			return
+		}
+		c.loadUsage()
+	}
+}
+
+// loadUsage counts the stored entries and their size; the caller holds usageMu
+func (c *Cache) loadUsage() {
+	var entries, size int64
+	if err := c.db.Model(&models.HTTPCacheEntry{}).Count(&entries).Error; err != nil {
+		return
+	}
+	if err := c.db.Model(&models.HTTPCacheEntry{}).Select("COALESCE(SUM(size), 0)").Scan(&size).Error; err != nil {
+		return
+	}
+	c.entries, c.size, c.usageKnown = entries, size, true
+}
+
+// usage returns the number of stored entries and their size
+func (c *Cache) usage() (entries, size int64) {
+	c.usageMu.Lock()
+	defer c.usageMu.Unlock()
+	if !c.usageKnown {
+		c.loadUsage()
+	}
+	return c.entries, c.size
+}
+
+// adjustUsage adds to the running totals, if they are loaded
+func (c *Cache) adjustUsage(entries, size int64) {
+	c.usageMu.Lock()
+	defer c.usageMu.Unlock()
+	if c.usageKnown {
+		c.entries += entries
+		c.size += size
+	}
+}
+
+// forgetUsage makes the next eviction check count the table again
+func (c *Cache) forgetUsage() {
+	c.usageMu.Lock()
+	defer c.usageMu.Unlock()
+	c.usageKnown = false
+}
+
+func (c *Cache) overLimit(entries, size int64) bool {
+	return (c.opts.MaxEntries > 0 && entries > int64(c.opts.MaxEntries)) ||
+		(c.opts.MaxBytes > 0 && size > c.opts.MaxBytes)
+}
+
+// SourceStats describes one source's cached responses
+type SourceStats struct {
+	Source   string        `json:"source"`
+	Entries  int64         `json:"entries"`
+	Bytes    int64         `json:"bytes"`
+	Negative int64         `json:"negative"`
+	Expired  int64         `json:"expired"`
+	TTL      time.Duration `json:"ttl"`
+	Counters
+}
+
+// Stats describes the whole cache
+type Stats struct {
+	Entries    int64         `json:"entries"`
+	Bytes      int64         `json:"bytes"`
+	MaxEntries int           `json:"max_entries"`
+	MaxBytes   int64         `json:"max_bytes"`
+	Sources    []SourceStats `json:"sources"`
+}
+
+// Stats returns per-source entry counts and sizes, and hits and misses since startup
+func (c *Cache) Stats() (*Stats, error) {
+	var rows []struct {
+		Source   string
+		Entries  int64
+		Bytes    int64
+		Negative int64
+		Expired  int64
+	}
+	if err := c.db.Model(&models.HTTPCacheEntry{}).
+		Select("source, COUNT(*) AS entries, COALESCE(SUM(size), 0) AS bytes, "+
+			"SUM(CASE WHEN negative THEN 1 ELSE 0 END) AS negative, "+
+			"SUM(CASE WHEN expires_at < ? THEN 1 ELSE 0 END) AS expired", time.Now()).
+		Group("source").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	bySource := make(map[string]*SourceStats)
+	for source, policy := range c.opts.Policies {
+		bySource[source] = &SourceStats{Source: source, TTL: policy.TTL}
+	}
+	stats := &Stats{MaxEntries: c.opts.MaxEntries, MaxBytes: c.opts.MaxBytes}
+	for _, row := range rows {
+		s, ok := bySource[row.Source]
+		if !ok {
+			s = &SourceStats{Source: row.Source}
+			bySource[row.Source] = s
+		}
+		s.Entries, s.Bytes, s.Negative, s.Expired = row.Entries, row.Bytes, row.Negative, row.Expired
+		stats.Entries += row.Entries
+		stats.Bytes += row.Bytes
+	}
+
+	c.mu.Lock()
+	for source, counters := range c.counters {
+		if s, ok := bySource[source]; ok {
+			s.Counters = *counters
+		}
+	}
+	c.mu.Unlock()
+
+	for _, s := range bySource {
+		stats.Sources = append(stats.Sources, *s)
+	}
+	sort.Slice(stats.Sources, func(i, j int) bool { return stats.Sources[i].Source < stats.Sources[j].Source })
+	return stats, nil
+}
+
+// Purge deletes cached responses, of one source when source is set, and only expired ones when
+// expiredOnly is set; it returns how many were deleted
+func (c *Cache) Purge(source string, expiredOnly bool) (int64, error) {
+	query := c.db.Where("1 = 1")
+	if source != "" {
+		query = query.Where("source = ?", source)
+	}
+	if expiredOnly {
+		query = query.Where("expires_at < ?", time.Now())
+	}
+	result := query.Delete(&models.HTTPCacheEntry{})
+	c.forgetUsage()
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,153 @@
+package httpcache
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"vinylfo/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestCache(t *testing.T, opts Options) *Cache {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite memory db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.HTTPCacheEntry{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+	return New(db, opts)
+}
+
+const releaseURL = "https://musicbrainz.org/ws/2/release/abc?fmt=json"
+
+func TestCache_GetAndPut(t *testing.T) {
+	c := newTestCache(t, DefaultOptions())
+
+	if _, ok := c.Get("GET", releaseURL); ok {
+		t.Fatal("empty cache returned a response")
+	}
+	c.Put("GET", releaseURL, 200, "application/json", []byte(`{"id":"abc"}`))
+
+	cached, ok := c.Get("GET", releaseURL)
+	if !ok || cached.Status != 200 || string(cached.Body) != `{"id":"abc"}` {
+		t.Fatalf("Get() = %+v, %v", cached, ok)
+	}
+
+	stats, err := c.Stats()
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	for _, s := range stats.Sources {
+		if s.Source == "musicbrainz" && (s.Entries != 1 || s.Hits != 1 || s.Misses != 1 || s.Stores != 1) {
+			t.Errorf("musicbrainz stats = %+v", s)
+		}
+	}
+}
+
+func TestCache_SkipsUncacheableRequests(t *testing.T) {
+	c := newTestCache(t, DefaultOptions())
+
+	c.Put("POST", releaseURL, 200, "", []byte("{}"))
+	c.Put("GET", "https://example.com/x", 200, "", []byte("{}"))
+	c.Put("GET", "https://api.discogs.com/users/me/collection/folders", 200, "", []byte("{}"))
+	c.Put("GET", releaseURL, 503, "", []byte("busy"))
+
+	if entries, _ := c.usage(); entries != 0 {
+		t.Errorf("cached %d responses, want none", entries)
+	}
+
+	req, _ := http.NewRequest("GET", releaseURL, nil)
+	req.Header.Set("Authorization", "Bearer token")
+	if c.cacheable(req) {
+		t.Error("requests with credentials should not be cached for musicbrainz")
+	}
+	req, _ = http.NewRequest("GET", "https://api.discogs.com/releases/1", nil)
+	req.Header.Set("Authorization", "Discogs token=x")
+	if !c.cacheable(req) {
+		t.Error("Discogs catalog requests should be cached with credentials")
+	}
+}
+
+func TestCache_NegativeEntriesExpireSooner(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Policies["musicbrainz"] = Policy{TTL: time.Hour, NegativeTTL: time.Millisecond}
+	c := newTestCache(t, opts)
+
+	c.Put("GET", releaseURL, 404, "", []byte("Not Found"))
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := c.Get("GET", releaseURL); ok {
+		t.Error("expired 404 was returned")
+	}
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	opts := DefaultOptions()
+	opts.MaxEntries = 2
+	c := newTestCache(t, opts)
+
+	urls := []string{releaseURL + "&n=1", releaseURL + "&n=2", releaseURL + "&n=3"}
+	c.Put("GET", urls[0], 200, "", []byte("1"))
+	time.Sleep(2 * time.Millisecond)
+	c.Put("GET", urls[1], 200, "", []byte("2"))
+	time.Sleep(2 * time.Millisecond)
+	c.Get("GET", urls[0])
+	time.Sleep(2 * time.Millisecond)
+	c.Put("GET", urls[2], 200, "", []byte("3"))
+
+	if _, ok := c.Get("GET", urls[1]); ok {
+		t.Error("least recently used entry was kept")
+	}
+	if _, ok := c.Get("GET", urls[0]); !ok {
+		t.Error("recently read entry was evicted")
+	}
+}
+
+func TestCache_KeepsRunningTotals(t *testing.T) {
+	c := newTestCache(t, DefaultOptions())
+	c.Put("GET", releaseURL+"&n=1", 200, "", []byte("1234"))
+	c.Put("GET", releaseURL+"&n=2", 200, "", []byte("56"))
+
+	// Rows written behind the cache's back are not counted until the totals are reloaded
+	c.db.Create(&models.HTTPCacheEntry{CacheKey: "outside", Source: "musicbrainz", Size: 100, ExpiresAt: time.Now().Add(time.Hour)})
+	if entries, size := c.usage(); entries != 2 || size != 6 {
+		t.Errorf("usage = %d entries, %d bytes, want the running totals 2 and 6", entries, size)
+	}
+
+	c.Put("GET", releaseURL+"&n=1", 200, "", []byte("7"))
+	if entries, size := c.usage(); entries != 3 || size != 103 {
+		t.Errorf("usage after a replace = %d entries, %d bytes, want 3 and 103 from the table", entries, size)
+	}
+	if _, err := c.Purge("musicbrainz", false); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if entries, size := c.usage(); entries != 0 || size != 0 {
+		t.Errorf("usage after a purge = %d entries, %d bytes", entries, size)
+	}
+}
+
+func TestCache_Purge(t *testing.T) {
+	c := newTestCache(t, DefaultOptions())
+	c.Put("GET", releaseURL, 200, "", []byte("{}"))
+	c.Put("GET", "https://en.wikipedia.org/w/api.php?action=parse&page=Blue_Train", 200, "", []byte("{}"))
+
+	deleted, err := c.Purge("wikipedia", false)
+	if err != nil || deleted != 1 {
+		t.Fatalf("Purge(wikipedia) = %d, %v", deleted, err)
+	}
+	if _, ok := c.Get("GET", releaseURL); !ok {
+		t.Error("purging wikipedia dropped a musicbrainz entry")
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	got := redactURL("http://ws.audioscrobbler.com/2.0/?method=track.getInfo&api_key=secret&track=x")
+	if strings.Contains(got, "secret") {
+		t.Errorf("redactURL() = %q, still holds the API key", got)
+	}
+}
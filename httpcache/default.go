@@ -0,0 +1,100 @@
+package httpcache
+
+import (
+	"log"
+	"net/http"
+	"sync"
+
+	"vinylfo/config"
+
+	"gorm.io/gorm"
+)
+
+var (
+	defaultMu    sync.RWMutex
+	defaultCache *Cache
+)
+
+// Init sets up the shared cache the API clients use, with the limits and TTLs from the environment
+func Init(db *gorm.DB) {
+	cfg := config.HTTPCache
+	if !cfg.Enabled {
+		log.Println("httpcache: disabled")
+		SetDefault(nil)
+		return
+	}
+
+	opts := DefaultOptions()
+	opts.MaxEntries = cfg.MaxEntries
+	opts.MaxBytes = int64(cfg.MaxSizeMB) << 20
+	for source, policy := range opts.Policies {
+		if ttl, ok := config.HTTPCacheTTL(source); ok {
+			policy.TTL = ttl
+			opts.Policies[source] = policy
+		}
+	}
+	SetDefault(New(db, opts))
+}
+
+// SetDefault replaces the shared cache; nil turns caching off
+func SetDefault(c *Cache) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultCache = c
+}
+
+// Default returns the shared cache, or nil when there is none
+func Default() *Cache {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultCache
+}
+
+// Get looks a request up in the shared cache
+func Get(method, rawURL string) (*Response, bool) {
+	c := Default()
+	if c == nil {
+		return nil, false
+	}
+	return c.Get(method, rawURL)
+}
+
+// Put stores a response in the shared cache
+func Put(method, rawURL string, status int, contentType string, body []byte) {
+	if c := Default(); c != nil {
+		c.Put(method, rawURL, status, contentType, body)
+	}
+}
+
+// Cacheable reports whether the shared cache keeps responses to a request
+func Cacheable(method, rawURL string) bool {
+	c := Default()
+	return c != nil && c.Source(method, rawURL) != ""
+}
+
+// Lookup looks req up in the shared cache; requests carrying credentials are only looked up for
+// sources whose responses do not depend on the user
+func Lookup(req *http.Request) (*Response, bool) {
+	c := Default()
+	if c == nil || !c.cacheable(req) {
+		return nil, false
+	}
+	return c.Get(req.Method, req.URL.String())
+}
+
+// Save stores the response to req in the shared cache
+func Save(req *http.Request, resp *http.Response, body []byte) {
+	c := Default()
+	if c == nil || resp == nil || !c.cacheable(req) {
+		return
+	}
+	c.Put(req.Method, req.URL.String(), resp.StatusCode, resp.Header.Get("Content-Type"), body)
+}
+
+func (c *Cache) cacheable(req *http.Request) bool {
+	source := c.Source(req.Method, req.URL.String())
+	if source == "" {
+		return false
+	}
+	return req.Header.Get("Authorization") == "" || c.opts.Policies[source].AllowAuth
+}
@@ -0,0 +1,280 @@
+// Package httpreplay records responses from external APIs to fixture files and replays them, so
+// Discogs sync and duration resolution can be demoed, developed and tested without network access
+package httpreplay
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Modes
+const (
+	ModeRecord = "record" // Send every request and save its response when it can be replayed
+	ModeReplay = "replay" // Answer from fixtures only; requests without one fail
+	ModeAuto   = "auto"   // Replay recorded requests, send and record the others
+)
+
+// ErrNotRecorded is returned in replay mode for requests that have no fixture
+var ErrNotRecorded = errors.New("no recorded response")
+
+// secretParams are query parameters left out of fixtures and fixture keys, so recordings made
+// with credentials replay without them and never leak them
+var secretParams = map[string]bool{"api_key": true, "key": true, "token": true, "access_token": true}
+
+// droppedHeaders are response headers not saved to fixtures
+var droppedHeaders = []string{"Set-Cookie"}
+
+// passthrough reports whether a request is always sent and never recorded: OAuth exchanges
+// return tokens, and their signatures change on every request so they could not be replayed
+func passthrough(u *url.URL) bool {
+	return strings.Contains(u.Path, "/oauth/")
+}
+
+// recordable reports whether a response is saved as a fixture: successes and 404s, like the
+// HTTP cache. Rate limits and server errors are passed on but not kept, or they would replay forever
+func recordable(status int) bool {
+	return (status >= 200 && status < 300) || status == http.StatusNotFound
+}
+
+// Fixture is one recorded exchange
+type Fixture struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"` // With credentials removed
+	Status       int         `json:"status"`
+	Header       http.Header `json:"header"`
+	Body         string      `json:"body"`
+	BodyEncoding string      `json:"body_encoding,omitempty"` // "base64" for binary bodies such as images
+	RecordedAt   time.Time   `json:"recorded_at"`
+}
+
+// Transport is an http.RoundTripper that records or replays exchanges in a fixture directory
+// Fixtures are stored as <dir>/<host>/<method>_<path>_<hash>.json, one per distinct request
+type Transport struct {
+	Mode string
+	Dir  string
+	Base http.RoundTripper // Sends requests that are not replayed; http.DefaultTransport when nil
+
+	mu sync.Mutex
+}
+
+// New creates a transport in one of the modes, or returns an error for an unknown mode
+func New(mode, dir string, base http.RoundTripper) (*Transport, error) {
+	switch mode {
+	case ModeRecord, ModeReplay, ModeAuto:
+	default:
+		return nil, fmt.Errorf("unknown replay mode %q", mode)
+	}
+	return &Transport{Mode: mode, Dir: dir, Base: base}, nil
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if passthrough(req.URL) {
+		return t.base().RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	path := t.Path(req.Method, req.URL, body)
+
+	if t.Mode != ModeRecord {
+		fixture, err := Load(path)
+		if err == nil {
+			return fixture.Response(req)
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if t.Mode == ModeReplay {
+			return nil, fmt.Errorf("%w for %s %s (%s)", ErrNotRecorded, req.Method, redactURL(req.URL), path)
+		}
+	}
+
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	if !recordable(resp.StatusCode) {
+		return resp, nil
+	}
+	if err := t.save(path, newFixture(req, resp, respBody)); err != nil {
+		return nil, fmt.Errorf("failed to record %s: %w", redactURL(req.URL), err)
+	}
+	return resp, nil
+}
+
+func newFixture(req *http.Request, resp *http.Response, body []byte) *Fixture {
+	header := resp.Header.Clone()
+	for _, h := range droppedHeaders {
+		header.Del(h)
+	}
+	fixture := &Fixture{
+		Method:     req.Method,
+		URL:        redactURL(req.URL),
+		Status:     resp.StatusCode,
+		Header:     header,
+		RecordedAt: time.Now().UTC(),
+	}
+	if utf8.Valid(body) {
+		fixture.Body = string(body)
+	} else {
+		fixture.Body = base64.StdEncoding.EncodeToString(body)
+		fixture.BodyEncoding = "base64"
+	}
+	return fixture
+}
+
+func (t *Transport) save(path string, fixture *Fixture) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Load reads a fixture file
+func Load(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+	}
+	return &fixture, nil
+}
+
+// Response returns the recorded response to req
+func (f *Fixture) Response(req *http.Request) (*http.Response, error) {
+	body := []byte(f.Body)
+	if f.BodyEncoding == "base64" {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(f.Body); err != nil {
+			return nil, fmt.Errorf("invalid fixture body for %s: %w", f.URL, err)
+		}
+	}
+	header := f.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+		StatusCode:    f.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// Path returns the fixture file of a request
+// The hash covers the method, the URL with its query sorted and credentials removed, and the body
+func (t *Transport) Path(method string, u *url.URL, body []byte) string {
+	canonical := canonicalURL(u)
+	sum := sha256.New()
+	sum.Write([]byte(method + " " + canonical + "\n"))
+	sum.Write(body)
+	hash := hex.EncodeToString(sum.Sum(nil))[:16]
+
+	name := strings.Trim(unsafePathChars.ReplaceAllString(u.Path, "_"), "_")
+	if len(name) > 60 {
+		name = name[:60]
+	}
+	if name == "" {
+		name = "root"
+	}
+	host := unsafePathChars.ReplaceAllString(u.Host, "_")
+	return filepath.Join(t.Dir, host, fmt.Sprintf("%s_%s_%s.json", method, name, hash))
+}
+
+// canonicalURL returns the URL with its query parameters sorted and credentials removed
+func canonicalURL(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		if !secretParams[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	c := *u
+	c.RawQuery = strings.Join(parts, "&")
+	c.Fragment = ""
+	c.User = nil
+	return c.String()
+}
+
+func redactURL(u *url.URL) string {
+	c := *u
+	query := c.Query()
+	for k := range query {
+		if secretParams[k] {
+			query.Set(k, "REDACTED")
+		}
+	}
+	c.RawQuery = query.Encode()
+	c.User = nil
+	return c.String()
+}
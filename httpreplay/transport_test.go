@@ -0,0 +1,171 @@
+package httpreplay
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTransport_RecordThenReplay(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Write([]byte(`{"id": 249504, "title": "Blue Train"}`))
+	}))
+	dir := t.TempDir()
+
+	recorder, _ := New(ModeRecord, dir, nil)
+	client := &http.Client{Transport: recorder}
+	resp, err := client.Get(server.URL + "/releases/249504?api_key=secret&b=2&a=1")
+	if err != nil {
+		t.Fatalf("record request failed: %v", err)
+	}
+	resp.Body.Close()
+	server.Close()
+
+	path := recorder.Path("GET", resp.Request.URL, nil)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("fixture not written: %v", err)
+	}
+	if strings.Contains(string(data), "secret") || strings.Contains(string(data), "session=abc") {
+		t.Errorf("fixture holds credentials:\n%s", data)
+	}
+
+	// The server is gone; the fixture answers the same request with its query reordered and
+	// without the API key
+	replayer, _ := New(ModeReplay, dir, nil)
+	client = &http.Client{Transport: replayer}
+	resp, err = client.Get(server.URL + "/releases/249504?a=1&b=2")
+	if err != nil {
+		t.Fatalf("replay request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 200 || string(body) != `{"id": 249504, "title": "Blue Train"}` || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("replayed %d %s %q", resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	}
+	if requests != 1 {
+		t.Errorf("server saw %d requests, want 1", requests)
+	}
+}
+
+func TestTransport_ReplayWithoutFixtureFails(t *testing.T) {
+	replayer, _ := New(ModeReplay, t.TempDir(), nil)
+	client := &http.Client{Transport: replayer}
+
+	_, err := client.Get("https://musicbrainz.org/ws/2/recording?query=x&fmt=json")
+	if !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("error = %v, want ErrNotRecorded", err)
+	}
+}
+
+func TestTransport_AutoRecordsBinaryBodies(t *testing.T) {
+	image := []byte{0xff, 0xd8, 0xff, 0xe0, 0x00, 0x10, 0x4a, 0x46}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write(image)
+	}))
+	defer server.Close()
+
+	auto, _ := New(ModeAuto, t.TempDir(), nil)
+	client := &http.Client{Transport: auto}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL + "/cover.jpg")
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != string(image) {
+			t.Errorf("request %d body = %x", i, body)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("server saw %d requests, want 1", requests)
+	}
+}
+
+func TestTransport_BodyIsPartOfTheKey(t *testing.T) {
+	tr, _ := New(ModeReplay, "fixtures", nil)
+	req, _ := http.NewRequest("POST", "https://api.discogs.com/releases/1/rating", nil)
+	if tr.Path("POST", req.URL, []byte("rating=4")) == tr.Path("POST", req.URL, []byte("rating=5")) {
+		t.Error("requests with different bodies share a fixture")
+	}
+}
+
+func TestNew_RejectsUnknownMode(t *testing.T) {
+	if _, err := New("playback", "fixtures", nil); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestTransport_NeverRecordsOAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("oauth_token=abc&oauth_token_secret=xyz"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recorder, _ := New(ModeRecord, dir, nil)
+	client := &http.Client{Transport: recorder}
+	resp, err := client.Post(server.URL+"/oauth/access_token", "application/x-www-form-urlencoded", strings.NewReader("oauth_verifier=v"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("OAuth exchange was recorded: %v", entries)
+	}
+}
+
+func TestTransport_DoesNotRecordRateLimits(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	auto, _ := New(ModeAuto, dir, nil)
+	client := &http.Client{Transport: auto}
+	resp, err := client.Get(server.URL + "/releases/1")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", resp.StatusCode)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("rate-limited response was recorded: %v", entries)
+	}
+
+	// The retry reaches the server and its answer is the one kept
+	resp, err = client.Get(server.URL + "/releases/1")
+	if err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || requests != 2 {
+		t.Errorf("retry got %d after %d requests, want 200 after 2", resp.StatusCode, requests)
+	}
+	if _, err := os.Stat(auto.Path("GET", resp.Request.URL, nil)); err != nil {
+		t.Errorf("successful response not recorded: %v", err)
+	}
+}
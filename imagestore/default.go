@@ -0,0 +1,44 @@
+package imagestore
+
+import (
+	"log"
+	"sync"
+
+	"vinylfo/config"
+)
+
+var (
+	defaultMu    sync.RWMutex
+	defaultStore *Store
+)
+
+// Init opens the shared store in the directory set by IMAGE_STORE_DIR
+// When it cannot be opened, covers stay in the albums table as before
+func Init() {
+	store, err := New(config.Images.Dir)
+	if err != nil {
+		log.Printf("imagestore: %v; keeping covers in the database", err)
+		SetDefault(nil)
+		return
+	}
+	if enc := DefaultWebPEncoder(); enc != nil {
+		store.SetWebPEncoder(enc)
+	} else {
+		log.Printf("imagestore: cwebp and ffmpeg not found; image variants are served as JPEG only")
+	}
+	SetDefault(store)
+}
+
+// SetDefault replaces the shared store; nil turns it off
+func SetDefault(s *Store) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultStore = s
+}
+
+// Default returns the shared store, or nil when there is none
+func Default() *Store {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultStore
+}
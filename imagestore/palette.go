@@ -0,0 +1,162 @@
+package imagestore
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"sort"
+)
+
+// paletteSampleSide is the longest side images are scaled down to before their colors are counted
+const paletteSampleSide = 64
+
+// Saturation and lightness bounds of vibrant and muted colors; colors near black or white are
+// neither
+const (
+	vibrantMinSaturation = 0.35
+	mutedMaxSaturation   = 0.35
+	paletteMinLightness  = 0.2
+	paletteMaxLightness  = 0.8
+)
+
+// Palette holds colors picked from an image, as #rrggbb
+type Palette struct {
+	Dominant string `json:"dominant"` // Most common color
+	Vibrant  string `json:"vibrant"`  // Most common saturated color, for accents
+	Muted    string `json:"muted"`    // Most common unsaturated color, for backgrounds
+	Text     string `json:"text"`     // Black or white, whichever reads better on Dominant
+}
+
+type colorBucket struct {
+	r, g, b, n int
+}
+
+func (c colorBucket) rgb() (uint8, uint8, uint8) {
+	return uint8(c.r / c.n), uint8(c.g / c.n), uint8(c.b / c.n)
+}
+
+// ExtractPalette picks the palette of img
+// Colors are grouped into 4096 buckets and each bucket stands for the average of its pixels, so
+// the palette follows the large areas of the image rather than single pixels
+func ExtractPalette(img image.Image) Palette {
+	small := Resize(img, paletteSampleSide)
+
+	counts := make(map[int]*colorBucket)
+	for i := 0; i < len(small.Pix); i += 4 {
+		r, g, b := int(small.Pix[i]), int(small.Pix[i+1]), int(small.Pix[i+2])
+		key := r>>4<<8 | g>>4<<4 | b>>4
+		bucket := counts[key]
+		if bucket == nil {
+			bucket = &colorBucket{}
+			counts[key] = bucket
+		}
+		bucket.r += r
+		bucket.g += g
+		bucket.b += b
+		bucket.n++
+	}
+	buckets := make([]colorBucket, 0, len(counts))
+	for _, bucket := range counts {
+		buckets = append(buckets, *bucket)
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].n > buckets[j].n })
+	if len(buckets) == 0 {
+		return Palette{}
+	}
+
+	dominant := buckets[0]
+	vibrant, muted := dominant, dominant
+	var vibrantScore, mutedScore float64
+	for _, bucket := range buckets {
+		_, s, l := hsl(bucket.rgb())
+		if l < paletteMinLightness || l > paletteMaxLightness {
+			continue
+		}
+		if score := float64(bucket.n) * s; s >= vibrantMinSaturation && score > vibrantScore {
+			vibrant, vibrantScore = bucket, score
+		}
+		if score := float64(bucket.n); s < mutedMaxSaturation && score > mutedScore {
+			muted, mutedScore = bucket, score
+		}
+	}
+
+	return Palette{
+		Dominant: hexColor(dominant.rgb()),
+		Vibrant:  hexColor(vibrant.rgb()),
+		Muted:    hexColor(muted.rgb()),
+		Text:     textColor(dominant.rgb()),
+	}
+}
+
+// Palette extracts the palette of the stored image hash, from its thumbnail
+func (s *Store) Palette(hash string) (Palette, error) {
+	path, _, err := s.Path(hash, SizeThumb)
+	if err != nil {
+		return Palette{}, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return Palette{}, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return Palette{}, fmt.Errorf("failed to decode %s: %w", hash, err)
+	}
+	return ExtractPalette(img), nil
+}
+
+func hexColor(r, g, b uint8) string {
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+// hsl returns the saturation and lightness of a color, between 0 and 1, and its hue in degrees
+func hsl(r8, g8, b8 uint8) (h, s, l float64) {
+	r, g, b := float64(r8)/255, float64(g8)/255, float64(b8)/255
+	hi, lo := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	l = (hi + lo) / 2
+	if hi == lo {
+		return 0, 0, l
+	}
+
+	d := hi - lo
+	if l > 0.5 {
+		s = d / (2 - hi - lo)
+	} else {
+		s = d / (hi + lo)
+	}
+	switch hi {
+	case r:
+		h = math.Mod((g-b)/d+6, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h * 60, s, l
+}
+
+// textColor returns black or white, whichever contrasts more with the color by the WCAG
+// contrast ratio
+func textColor(r, g, b uint8) string {
+	bg := relativeLuminance(r, g, b)
+	onWhite := 1.05 / (bg + 0.05)
+	onBlack := (bg + 0.05) / 0.05
+	if onBlack > onWhite {
+		return "#000000"
+	}
+	return "#ffffff"
+}
+
+func relativeLuminance(r, g, b uint8) float64 {
+	channel := func(c uint8) float64 {
+		v := float64(c) / 255
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(r) + 0.7152*channel(g) + 0.0722*channel(b)
+}
@@ -0,0 +1,69 @@
+package imagestore
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// blocks returns an image filled with the colors, each covering its share of the rows
+func blocks(shares map[color.RGBA]int) image.Image {
+	total := 0
+	for _, n := range shares {
+		total += n
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 10, total))
+	y := 0
+	for c, n := range shares {
+		for end := y + n; y < end; y++ {
+			for x := 0; x < 10; x++ {
+				img.Set(x, y, c)
+			}
+		}
+	}
+	return img
+}
+
+func TestExtractPalette(t *testing.T) {
+	navy := color.RGBA{20, 30, 90, 255}
+	red := color.RGBA{220, 30, 40, 255}
+	grey := color.RGBA{120, 120, 125, 255}
+
+	got := ExtractPalette(blocks(map[color.RGBA]int{navy: 60, grey: 25, red: 15}))
+	want := Palette{Dominant: "#141e5a", Vibrant: "#141e5a", Muted: "#78787d", Text: "#ffffff"}
+	if got != want {
+		t.Errorf("ExtractPalette() = %+v, want %+v", got, want)
+	}
+
+	// A grey cover still gets a vibrant accent from its saturated areas
+	got = ExtractPalette(blocks(map[color.RGBA]int{grey: 60, red: 25, navy: 15}))
+	if got.Dominant != "#78787d" || got.Vibrant != "#dc1e28" || got.Text != "#000000" {
+		t.Errorf("ExtractPalette() = %+v, want grey dominant, red vibrant and black text", got)
+	}
+}
+
+func TestStore_Palette(t *testing.T) {
+	store, _ := New(t.TempDir())
+	var buf bytes.Buffer
+	png.Encode(&buf, blocks(map[color.RGBA]int{{255, 255, 255, 255}: 400}))
+	info, err := store.Put(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	palette, err := store.Palette(info.Hash)
+	if err != nil {
+		t.Fatalf("Palette() error = %v", err)
+	}
+	// White is too light to be vibrant or muted, so both fall back to the dominant color
+	want := Palette{Dominant: "#ffffff", Vibrant: "#ffffff", Muted: "#ffffff", Text: "#000000"}
+	if palette != want {
+		t.Errorf("Palette() = %+v, want %+v", palette, want)
+	}
+
+	if _, err := store.Palette("0000000000000000000000000000000000000000000000000000000000000000"); err == nil {
+		t.Error("Palette() of a missing image succeeded")
+	}
+}
@@ -0,0 +1,61 @@
+package imagestore
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Resize scales img down so its longest side is maxSide, averaging the source pixels each
+// destination pixel covers
+// Transparent areas are flattened onto white, as the variants are JPEG
+func Resize(img image.Image, maxSide int) *image.RGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Over)
+
+	dw, dh := w, h
+	if w >= h && w > maxSide {
+		dw, dh = maxSide, max(1, h*maxSide/w)
+	} else if h > w && h > maxSide {
+		dw, dh = max(1, w*maxSide/h), maxSide
+	}
+	if dw == w && dh == h {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := dy*h/dh, (dy+1)*h/dh
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := dx*w/dw, (dx+1)*w/dw
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, bl, n int
+			for y := y0; y < y1; y++ {
+				off := y*src.Stride + x0*4
+				for x := x0; x < x1; x++ {
+					r += int(src.Pix[off])
+					g += int(src.Pix[off+1])
+					bl += int(src.Pix[off+2])
+					off += 4
+					n++
+				}
+			}
+
+			i := dy*dst.Stride + dx*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(bl / n)
+			dst.Pix[i+3] = 255
+		}
+	}
+	return dst
+}
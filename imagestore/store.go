@@ -0,0 +1,260 @@
+// Package imagestore keeps album images on disk under the SHA-256 of their content, so an image
+// shared by several albums or downloaded twice is stored once, and serves resized variants of them
+package imagestore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Variant sizes, the longest side in pixels; "full" is the original file
+const (
+	SizeThumb  = "thumb"
+	SizeSmall  = "small"
+	SizeMedium = "medium"
+	SizeFull   = "full"
+)
+
+// Sizes maps each resized variant to the longest side of its image
+var Sizes = map[string]int{
+	SizeThumb:  150,
+	SizeSmall:  300,
+	SizeMedium: 600,
+}
+
+// variantQuality is the JPEG quality of resized variants
+const variantQuality = 85
+
+var (
+	ErrNotFound    = errors.New("image not found")
+	ErrInvalidHash = errors.New("invalid image hash")
+	ErrUnknownSize = errors.New("unknown image size")
+)
+
+var hashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Info describes a stored original
+type Info struct {
+	Hash        string `json:"hash"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int    `json:"size"`
+}
+
+// Store is a content-addressed directory of images
+// Originals are kept as <dir>/<first two hash characters>/<hash> and variants next to them
+// as <hash>_<size>.jpg, and <hash>_<size>.webp with a WebP encoder, generated the first time
+// they are asked for
+type Store struct {
+	dir  string
+	mu   sync.Mutex
+	webp WebPEncoder
+}
+
+// New opens the store in dir, creating the directory if needed
+func New(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create image store directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Dir returns the directory of the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// ValidHash reports whether hash can name a stored image
+func ValidHash(hash string) bool {
+	return hashPattern.MatchString(hash)
+}
+
+// Hash returns the name data is stored under
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Store) originalPath(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+func (s *Store) variantPath(hash, size string) string {
+	return filepath.Join(s.dir, hash[:2], hash+"_"+size+".jpg")
+}
+
+// Put stores data and returns what is known about it; storing an image already in the store
+// only returns its info
+func (s *Store) Put(data []byte) (*Info, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty image")
+	}
+	info := describe(data)
+	if !strings.HasPrefix(info.ContentType, "image/") {
+		return nil, fmt.Errorf("not an image (%s)", info.ContentType)
+	}
+
+	path := s.originalPath(info.Hash)
+	if _, err := os.Stat(path); err == nil {
+		return info, nil
+	}
+	if err := writeFile(path, data); err != nil {
+		return nil, fmt.Errorf("failed to store image: %w", err)
+	}
+	return info, nil
+}
+
+// Has reports whether the original of hash is stored
+func (s *Store) Has(hash string) bool {
+	if !ValidHash(hash) {
+		return false
+	}
+	_, err := os.Stat(s.originalPath(hash))
+	return err == nil
+}
+
+// Info describes the stored original of hash
+func (s *Store) Info(hash string) (*Info, error) {
+	if !ValidHash(hash) {
+		return nil, ErrInvalidHash
+	}
+	data, err := os.ReadFile(s.originalPath(hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return describe(data), nil
+}
+
+// Path returns the file and content type of a variant of hash, resizing the original the first
+// time a variant is asked for
+// Originals no larger than the variant, or in a format that cannot be decoded, are returned as
+// they are
+func (s *Store) Path(hash, size string) (string, string, error) {
+	if !ValidHash(hash) {
+		return "", "", ErrInvalidHash
+	}
+	original := s.originalPath(hash)
+	if size == "" || size == SizeFull {
+		return s.original(original)
+	}
+	maxSide, ok := Sizes[size]
+	if !ok {
+		return "", "", ErrUnknownSize
+	}
+
+	variant := s.variantPath(hash, size)
+	if _, err := os.Stat(variant); err == nil {
+		return variant, "image/jpeg", nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := os.Stat(variant); err == nil {
+		return variant, "image/jpeg", nil
+	}
+
+	data, err := os.ReadFile(original)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", "", ErrNotFound
+	}
+	if err != nil {
+		return "", "", err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return s.original(original)
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() <= maxSide && bounds.Dy() <= maxSide {
+		return s.original(original)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, Resize(img, maxSide), &jpeg.Options{Quality: variantQuality}); err != nil {
+		return "", "", fmt.Errorf("failed to encode %s variant of %s: %w", size, hash, err)
+	}
+	if err := writeFile(variant, buf.Bytes()); err != nil {
+		return "", "", fmt.Errorf("failed to store %s variant of %s: %w", size, hash, err)
+	}
+	return variant, "image/jpeg", nil
+}
+
+func (s *Store) original(path string) (string, string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", "", ErrNotFound
+	}
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, _ := f.Read(head)
+	return path, http.DetectContentType(head[:n]), nil
+}
+
+// Delete removes the original of hash and its variants
+func (s *Store) Delete(hash string) error {
+	if !ValidHash(hash) {
+		return ErrInvalidHash
+	}
+	paths := []string{s.originalPath(hash)}
+	for size := range Sizes {
+		paths = append(paths, s.variantPath(hash, size), s.webpPath(hash, size))
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func describe(data []byte) *Info {
+	info := &Info{
+		Hash:        Hash(data),
+		ContentType: http.DetectContentType(data),
+		Size:        len(data),
+	}
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		info.Width = cfg.Width
+		info.Height = cfg.Height
+	}
+	return info
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
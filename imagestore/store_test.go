@@ -0,0 +1,109 @@
+package imagestore
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"testing"
+)
+
+func testImage(t *testing.T, w, h int, encode func(*bytes.Buffer, image.Image) error) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := encode(&buf, img); err != nil {
+		t.Fatalf("encode test image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(buf *bytes.Buffer, img image.Image) error { return jpeg.Encode(buf, img, nil) }
+func encodePNG(buf *bytes.Buffer, img image.Image) error  { return png.Encode(buf, img) }
+
+func TestStore_PutIsContentAddressed(t *testing.T) {
+	s, _ := New(t.TempDir())
+	data := testImage(t, 40, 30, encodePNG)
+
+	first, err := s.Put(data)
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	second, _ := s.Put(data)
+	if first.Hash != second.Hash || first.Hash != Hash(data) {
+		t.Errorf("hashes = %s, %s", first.Hash, second.Hash)
+	}
+	if first.Width != 40 || first.Height != 30 || first.ContentType != "image/png" {
+		t.Errorf("Put() = %+v", first)
+	}
+	if !s.Has(first.Hash) {
+		t.Error("stored image not found")
+	}
+
+	if _, err := s.Put([]byte("<html></html>")); err == nil {
+		t.Error("Put() accepted a non-image")
+	}
+}
+
+func TestStore_PathResizesVariants(t *testing.T) {
+	s, _ := New(t.TempDir())
+	info, _ := s.Put(testImage(t, 800, 400, encodeJPEG))
+
+	path, contentType, err := s.Path(info.Hash, SizeThumb)
+	if err != nil {
+		t.Fatalf("Path(thumb) error = %v", err)
+	}
+	if contentType != "image/jpeg" {
+		t.Errorf("content type = %s", contentType)
+	}
+	if original, _ := s.Info(info.Hash); original.Width != 800 {
+		t.Errorf("original was changed: %+v", original)
+	}
+
+	thumb, err := openImage(path)
+	if err != nil {
+		t.Fatalf("decode thumb: %v", err)
+	}
+	if b := thumb.Bounds(); b.Dx() != 150 || b.Dy() != 75 {
+		t.Errorf("thumb is %dx%d, want 150x75", b.Dx(), b.Dy())
+	}
+
+	// Originals smaller than the variant are served as they are
+	small, _ := s.Put(testImage(t, 100, 100, encodePNG))
+	path, contentType, _ = s.Path(small.Hash, SizeMedium)
+	if path != s.originalPath(small.Hash) || contentType != "image/png" {
+		t.Errorf("Path(medium) = %s, %s, want the original", path, contentType)
+	}
+}
+
+func TestStore_PathErrors(t *testing.T) {
+	s, _ := New(t.TempDir())
+	missing := Hash([]byte("missing"))
+
+	if _, _, err := s.Path("../../etc/passwd", SizeFull); !errors.Is(err, ErrInvalidHash) {
+		t.Errorf("traversal error = %v", err)
+	}
+	if _, _, err := s.Path(missing, SizeFull); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing error = %v", err)
+	}
+	if _, _, err := s.Path(missing, "huge"); !errors.Is(err, ErrUnknownSize) {
+		t.Errorf("size error = %v", err)
+	}
+}
+
+func openImage(path string) (image.Image, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
@@ -0,0 +1,116 @@
+package imagestore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// webpQuality is the quality WebP variants are encoded at, lower than JPEG's for the same look
+const webpQuality = 80
+
+// webpTimeout bounds one encode, so a stuck encoder does not hold the store's lock
+const webpTimeout = 30 * time.Second
+
+// WebPEncoder converts a JPEG variant to WebP
+// The standard library has no WebP encoder, so variants are encoded by an external binary
+type WebPEncoder interface {
+	EncodeWebP(ctx context.Context, src, dst string) error
+}
+
+// CommandWebPEncoder encodes WebP with cwebp, or with ffmpeg when it is built with libwebp
+type CommandWebPEncoder struct {
+	Binary string // Path to cwebp or ffmpeg
+	FFmpeg bool
+}
+
+func (e CommandWebPEncoder) EncodeWebP(ctx context.Context, src, dst string) error {
+	quality := strconv.Itoa(webpQuality)
+	args := []string{"-quiet", "-q", quality, src, "-o", dst}
+	if e.FFmpeg {
+		args = []string{"-hide_banner", "-loglevel", "error", "-nostdin", "-y",
+			"-i", src, "-c:v", "libwebp", "-quality", quality, "-f", "webp", dst}
+	}
+
+	cmd := exec.CommandContext(ctx, e.Binary, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		name := filepath.Base(e.Binary)
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// DefaultWebPEncoder returns an encoder using cwebp or ffmpeg, whichever is installed, or nil
+// when neither is
+func DefaultWebPEncoder() WebPEncoder {
+	if binary, err := exec.LookPath("cwebp"); err == nil {
+		return CommandWebPEncoder{Binary: binary}
+	}
+	if binary, err := exec.LookPath("ffmpeg"); err == nil {
+		return CommandWebPEncoder{Binary: binary, FFmpeg: true}
+	}
+	return nil
+}
+
+// SetWebPEncoder turns WebP variants on; nil turns them off
+func (s *Store) SetWebPEncoder(enc WebPEncoder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.webp = enc
+}
+
+func (s *Store) webpPath(hash, size string) string {
+	return filepath.Join(s.dir, hash[:2], hash+"_"+size+".webp")
+}
+
+// WebPPath returns the file and content type of a variant of hash as WebP, encoding it from the
+// JPEG variant the first time it is asked for
+// Without an encoder, for originals that are served as they are, or when encoding fails, it
+// returns the same file as Path
+func (s *Store) WebPPath(hash, size string) (string, string, error) {
+	path, contentType, err := s.Path(hash, size)
+	if err != nil || path != s.variantPath(hash, size) {
+		return path, contentType, err
+	}
+
+	webp := s.webpPath(hash, size)
+	if _, err := os.Stat(webp); err == nil {
+		return webp, "image/webp", nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.webp == nil {
+		return path, contentType, nil
+	}
+	if _, err := os.Stat(webp); err == nil {
+		return webp, "image/webp", nil
+	}
+
+	// Encode next to the variant and rename, so a half-written file is never served
+	tmp := webp + ".tmp"
+	ctx, cancel := context.WithTimeout(context.Background(), webpTimeout)
+	defer cancel()
+	err = s.webp.EncodeWebP(ctx, path, tmp)
+	if err == nil {
+		err = os.Rename(tmp, webp)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		log.Printf("imagestore: failed to encode %s variant of %s as WebP: %v", size, hash, err)
+		return path, contentType, nil
+	}
+	return webp, "image/webp", nil
+}
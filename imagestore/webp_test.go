@@ -0,0 +1,60 @@
+package imagestore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+// fakeWebP writes a stand-in WebP file and counts its encodes
+type fakeWebP struct {
+	encodes *int
+	err     error
+}
+
+func (f fakeWebP) EncodeWebP(ctx context.Context, src, dst string) error {
+	*f.encodes++
+	if f.err != nil {
+		return f.err
+	}
+	return os.WriteFile(dst, []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), 0644)
+}
+
+func TestStore_WebPPath(t *testing.T) {
+	s, _ := New(t.TempDir())
+	info, _ := s.Put(testImage(t, 800, 400, encodeJPEG))
+	small, _ := s.Put(testImage(t, 100, 100, encodePNG))
+
+	if path, contentType, _ := s.WebPPath(info.Hash, SizeThumb); contentType != "image/jpeg" || path != s.variantPath(info.Hash, SizeThumb) {
+		t.Errorf("WebPPath() without an encoder = %s, %s, want the JPEG variant", path, contentType)
+	}
+
+	encodes := 0
+	s.SetWebPEncoder(fakeWebP{encodes: &encodes})
+	for i := 0; i < 2; i++ {
+		path, contentType, err := s.WebPPath(info.Hash, SizeThumb)
+		if err != nil || contentType != "image/webp" || path != s.webpPath(info.Hash, SizeThumb) {
+			t.Fatalf("WebPPath() = %s, %s, %v, want the WebP variant", path, contentType, err)
+		}
+	}
+	if encodes != 1 {
+		t.Errorf("encoded %d times, want once", encodes)
+	}
+
+	// Originals served as they are, and failed encodes, fall back to Path
+	if path, contentType, _ := s.WebPPath(small.Hash, SizeMedium); path != s.originalPath(small.Hash) || contentType != "image/png" {
+		t.Errorf("WebPPath(medium) = %s, %s, want the original", path, contentType)
+	}
+	s.SetWebPEncoder(fakeWebP{encodes: &encodes, err: errors.New("no libwebp")})
+	if _, contentType, err := s.WebPPath(info.Hash, SizeSmall); err != nil || contentType != "image/jpeg" {
+		t.Errorf("WebPPath() with a failing encoder = %s, %v, want the JPEG variant", contentType, err)
+	}
+
+	if err := s.Delete(info.Hash); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := os.Stat(s.webpPath(info.Hash, SizeThumb)); !os.IsNotExist(err) {
+		t.Error("Delete() left the WebP variant")
+	}
+}
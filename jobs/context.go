@@ -0,0 +1,211 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"vinylfo/models"
+)
+
+// How often progress updates reach subscribers and the database
+const (
+	publishInterval = 500 * time.Millisecond
+	saveInterval    = 5 * time.Second
+	watchInterval   = time.Second
+)
+
+// Context is a running job as seen by its handler: its cancellation context, progress
+// reporting and pause points
+type Context struct {
+	manager *Manager
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	mu           sync.Mutex
+	job          models.Job
+	paused       bool
+	resumeCh     chan struct{}
+	onPause      func()
+	onResume     func()
+	runningSince time.Time
+	lastPublish  time.Time
+	lastSave     time.Time
+}
+
+// Context is cancelled when the job is cancelled or the app shuts down
+func (jc *Context) Context() context.Context {
+	return jc.ctx
+}
+
+// ID returns the job's ID
+func (jc *Context) ID() uint {
+	return jc.job.ID
+}
+
+// SetProgress records how many items are done out of total and what is being worked on
+func (jc *Context) SetProgress(processed, total int, current string) {
+	jc.mu.Lock()
+	changed := jc.job.Processed != processed || jc.job.Total != total || jc.job.Current != current
+	jc.job.Processed = processed
+	jc.job.Total = total
+	jc.job.Current = current
+	jc.mu.Unlock()
+
+	if changed {
+		jc.report(false)
+	}
+}
+
+// Step marks one more item done and names the next one
+func (jc *Context) Step(current string) {
+	jc.mu.Lock()
+	processed, total := jc.job.Processed+1, jc.job.Total
+	jc.mu.Unlock()
+	jc.SetProgress(processed, total, current)
+}
+
+// SetResult sets the summary shown once the job finishes
+func (jc *Context) SetResult(result string) {
+	jc.mu.Lock()
+	defer jc.mu.Unlock()
+	jc.job.Result = result
+}
+
+// OnPause and OnResume hand pause requests to workers that pause through their own state manager
+func (jc *Context) OnPause(fn func()) {
+	jc.mu.Lock()
+	defer jc.mu.Unlock()
+	jc.onPause = fn
+}
+
+func (jc *Context) OnResume(fn func()) {
+	jc.mu.Lock()
+	defer jc.mu.Unlock()
+	jc.onResume = fn
+}
+
+// SetPaused records a pause or resume that happened outside the job, such as a rate limit
+func (jc *Context) SetPaused(paused bool) {
+	jc.setPaused(paused, false)
+}
+
+// Watch calls poll every second until done is closed, and once more after. Handlers that
+// wrap a worker with its own state manager use it to copy the worker's progress and pauses
+func (jc *Context) Watch(done <-chan struct{}, poll func()) {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		poll()
+		select {
+		case <-done:
+			poll()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Checkpoint blocks while the job is paused and returns an error once it is cancelled.
+// Handlers call it between items
+func (jc *Context) Checkpoint() error {
+	for {
+		jc.mu.Lock()
+		paused, resumeCh := jc.paused, jc.resumeCh
+		jc.mu.Unlock()
+		if !paused {
+			return jc.ctx.Err()
+		}
+
+		select {
+		case <-resumeCh:
+		case <-jc.ctx.Done():
+			return jc.ctx.Err()
+		}
+	}
+}
+
+// setPaused switches between paused and running; notify runs the handler's pause hooks
+func (jc *Context) setPaused(paused, notify bool) bool {
+	jc.mu.Lock()
+	if jc.paused == paused {
+		jc.mu.Unlock()
+		return false
+	}
+
+	now := time.Now()
+	jc.paused = paused
+	if paused {
+		jc.job.RunningSecs += now.Sub(jc.runningSince).Seconds()
+		jc.job.Status = models.JobStatusPaused
+		jc.resumeCh = make(chan struct{})
+	} else {
+		jc.runningSince = now
+		jc.job.Status = models.JobStatusRunning
+		close(jc.resumeCh)
+	}
+	hook := jc.onResume
+	if paused {
+		hook = jc.onPause
+	}
+	jc.mu.Unlock()
+
+	if notify && hook != nil {
+		hook()
+	}
+	jc.report(true)
+	return true
+}
+
+// snapshot returns the job with its running time and progress estimate brought up to date
+func (jc *Context) snapshot() models.Job {
+	jc.mu.Lock()
+	defer jc.mu.Unlock()
+	job := jc.job
+	if !jc.paused && !jc.runningSince.IsZero() {
+		job.RunningSecs += time.Since(jc.runningSince).Seconds()
+	}
+	estimate(&job)
+	return job
+}
+
+// report publishes the job to subscribers and saves it, at most every interval unless forced
+func (jc *Context) report(force bool) {
+	now := time.Now()
+	jc.mu.Lock()
+	publish := force || now.Sub(jc.lastPublish) >= publishInterval
+	save := force || now.Sub(jc.lastSave) >= saveInterval
+	if publish {
+		jc.lastPublish = now
+	}
+	if save {
+		jc.lastSave = now
+	}
+	jc.mu.Unlock()
+
+	if !publish && !save {
+		return
+	}
+	job := jc.snapshot()
+	if save {
+		jc.manager.save(&job)
+	}
+	if publish {
+		jc.manager.publish(job)
+	}
+}
+
+// estimate fills in the percentage done and, for a running job, the time left at its rate so far
+func estimate(job *models.Job) {
+	job.Percent = 0
+	job.ETASeconds = nil
+	if job.Total <= 0 {
+		return
+	}
+	job.Percent = float64(job.Processed) / float64(job.Total) * 100
+	if job.Status == models.JobStatusRunning && job.Processed > 0 && job.Processed < job.Total {
+		eta := int(job.RunningSecs / float64(job.Processed) * float64(job.Total-job.Processed))
+		job.ETASeconds = &eta
+	}
+}
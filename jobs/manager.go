@@ -0,0 +1,505 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCancelled is returned by a handler whose work was stopped before it finished
+	ErrCancelled = errors.New("job cancelled")
+	// ErrNotActive is returned when pausing or resuming a job that is not running
+	ErrNotActive = errors.New("job is not running")
+	// ErrUnknownKind is returned when enqueuing a kind no handler is registered for
+	ErrUnknownKind = errors.New("unknown job kind")
+)
+
+// Retry backoff doubles from the base delay up to the cap
+const (
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = 30 * time.Minute
+	dispatchTick   = 5 * time.Second
+)
+
+// permanentError marks a failure that retrying will not fix
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job fails without being retried
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Options control how jobs of a kind are run
+type Options struct {
+	MaxAttempts int  // Runs before the job is marked failed; 0 means 1
+	Exclusive   bool // Only one job of the kind runs at a time; others wait in the queue
+}
+
+type handler struct {
+	run     func(jc *Context, payload json.RawMessage) error
+	options Options
+}
+
+// Manager runs background jobs, persists their progress and streams it to subscribers
+type Manager struct {
+	db *gorm.DB
+
+	mu          sync.Mutex
+	ctx         context.Context
+	handlers    map[string]handler
+	active      map[uint]*Context
+	subscribers map[int]chan models.Job
+	nextSub     int
+	wake        chan struct{}
+}
+
+func NewManager(db *gorm.DB) *Manager {
+	return &Manager{
+		db:          db,
+		ctx:         context.Background(),
+		handlers:    make(map[string]handler),
+		active:      make(map[uint]*Context),
+		subscribers: make(map[int]chan models.Job),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Register sets the handler for a job kind. The job's payload is decoded into P
+func Register[P any](m *Manager, kind string, options Options, fn func(jc *Context, payload P) error) {
+	if options.MaxAttempts < 1 {
+		options.MaxAttempts = 1
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[kind] = handler{
+		options: options,
+		run: func(jc *Context, raw json.RawMessage) error {
+			var payload P
+			if len(raw) > 0 {
+				if err := json.Unmarshal(raw, &payload); err != nil {
+					return Permanent(fmt.Errorf("invalid payload: %w", err))
+				}
+			}
+			return fn(jc, payload)
+		},
+	}
+}
+
+// Kinds lists the registered job kinds
+func (m *Manager) Kinds() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kinds := make([]string, 0, len(m.handlers))
+	for kind := range m.handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Enqueue queues a job; it starts as soon as the dispatcher is free to run it
+func (m *Manager) Enqueue(kind string, payload interface{}) (*models.Job, error) {
+	m.mu.Lock()
+	h, ok := m.handlers[kind]
+	m.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	job := models.Job{
+		Kind:        kind,
+		Status:      models.JobStatusQueued,
+		Payload:     raw,
+		MaxAttempts: h.options.MaxAttempts,
+	}
+	if err := m.db.Create(&job).Error; err != nil {
+		return nil, err
+	}
+
+	m.publish(job)
+	m.signal()
+	return &job, nil
+}
+
+// Pending returns the unfinished job of a kind, running or waiting, or nil if there is none
+func (m *Manager) Pending(kind string) *models.Job {
+	m.mu.Lock()
+	for _, jc := range m.active {
+		if jc.job.Kind == kind {
+			m.mu.Unlock()
+			job := jc.snapshot()
+			return &job
+		}
+	}
+	m.mu.Unlock()
+
+	var jobs []models.Job
+	m.db.Where("kind = ? AND status IN ?", kind, []string{models.JobStatusQueued, models.JobStatusRetrying}).
+		Order("id ASC").Limit(1).Find(&jobs)
+	if len(jobs) == 0 {
+		return nil
+	}
+	return &jobs[0]
+}
+
+// Get returns a job with live progress if it is running
+func (m *Manager) Get(id uint) (*models.Job, error) {
+	if jc := m.activeJob(id); jc != nil {
+		job := jc.snapshot()
+		return &job, nil
+	}
+
+	var job models.Job
+	if err := m.db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	estimate(&job)
+	return &job, nil
+}
+
+// List returns jobs newest first, with live progress for running ones
+func (m *Manager) List(query *gorm.DB, limit int) ([]models.Job, error) {
+	var jobs []models.Job
+	if err := query.Order("id DESC").Limit(limit).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		if jc := m.activeJob(jobs[i].ID); jc != nil {
+			jobs[i] = jc.snapshot()
+		} else {
+			estimate(&jobs[i])
+		}
+	}
+	return jobs, nil
+}
+
+// Pause asks a running job to pause at its next checkpoint
+func (m *Manager) Pause(id uint) error {
+	jc := m.activeJob(id)
+	if jc == nil {
+		return ErrNotActive
+	}
+	jc.setPaused(true, true)
+	return nil
+}
+
+// Resume continues a paused job
+func (m *Manager) Resume(id uint) error {
+	jc := m.activeJob(id)
+	if jc == nil {
+		return ErrNotActive
+	}
+	jc.setPaused(false, true)
+	return nil
+}
+
+// Cancel stops a running job or drops a waiting one
+func (m *Manager) Cancel(id uint) error {
+	if jc := m.activeJob(id); jc != nil {
+		jc.cancel()
+		return nil
+	}
+
+	var job models.Job
+	if err := m.db.First(&job, id).Error; err != nil {
+		return err
+	}
+	if job.IsFinished() {
+		return fmt.Errorf("job has already %s", job.Status)
+	}
+	now := time.Now()
+	job.Status = models.JobStatusCancelled
+	job.NextAttemptAt = nil
+	job.FinishedAt = &now
+	m.save(&job)
+	m.publish(job)
+	return nil
+}
+
+// Retry queues a failed or cancelled job again with a fresh set of attempts
+func (m *Manager) Retry(id uint) (*models.Job, error) {
+	var job models.Job
+	if err := m.db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	if job.Status != models.JobStatusFailed && job.Status != models.JobStatusCancelled {
+		return nil, fmt.Errorf("only failed or cancelled jobs can be retried")
+	}
+
+	job.Status = models.JobStatusQueued
+	job.Attempts = 0
+	job.Error = ""
+	job.NextAttemptAt = nil
+	job.FinishedAt = nil
+	m.save(&job)
+	m.publish(job)
+	m.signal()
+	return &job, nil
+}
+
+// Wait blocks until the job has finished, including any retries
+func (m *Manager) Wait(ctx context.Context, id uint) (*models.Job, error) {
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		job, err := m.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		if job.IsFinished() {
+			return job, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Subscribe returns a channel of job updates and a function to stop receiving them.
+// Updates are dropped for subscribers that fall behind
+func (m *Manager) Subscribe() (<-chan models.Job, func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := m.nextSub
+	m.nextSub++
+	ch := make(chan models.Job, 50)
+	m.subscribers[id] = ch
+
+	return ch, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if _, ok := m.subscribers[id]; ok {
+			delete(m.subscribers, id)
+			close(ch)
+		}
+	}
+}
+
+// Active returns snapshots of the jobs that are running or paused
+func (m *Manager) Active() []models.Job {
+	m.mu.Lock()
+	contexts := make([]*Context, 0, len(m.active))
+	for _, jc := range m.active {
+		contexts = append(contexts, jc)
+	}
+	m.mu.Unlock()
+
+	jobs := make([]models.Job, 0, len(contexts))
+	for _, jc := range contexts {
+		jobs = append(jobs, jc.snapshot())
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+	return jobs
+}
+
+// Run starts queued jobs and due retries until ctx is cancelled. Jobs that were running
+// when the app last stopped count as a failed attempt
+func (m *Manager) Run(ctx context.Context) {
+	m.mu.Lock()
+	m.ctx = ctx
+	m.mu.Unlock()
+
+	m.recoverInterrupted()
+
+	ticker := time.NewTicker(dispatchTick)
+	defer ticker.Stop()
+
+	for {
+		m.dispatch()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-m.wake:
+		}
+	}
+}
+
+func (m *Manager) recoverInterrupted() {
+	var jobs []models.Job
+	m.db.Where("status IN ?", []string{models.JobStatusRunning, models.JobStatusPaused}).Find(&jobs)
+	for _, job := range jobs {
+		log.Printf("Jobs: job %d (%s) was interrupted by shutdown", job.ID, job.Kind)
+		m.settle(&job, errors.New("interrupted by shutdown"))
+		m.save(&job)
+	}
+}
+
+// dispatch starts every waiting job that is due and whose kind is free to run
+func (m *Manager) dispatch() {
+	var jobs []models.Job
+	err := m.db.Where("status = ? OR (status = ? AND next_attempt_at <= ?)",
+		models.JobStatusQueued, models.JobStatusRetrying, time.Now()).
+		Order("id ASC").Find(&jobs).Error
+	if err != nil {
+		log.Printf("Jobs: failed to load queued jobs: %v", err)
+		return
+	}
+
+	for _, job := range jobs {
+		m.mu.Lock()
+		h, ok := m.handlers[job.Kind]
+		busy := false
+		for _, jc := range m.active {
+			if jc.job.Kind == job.Kind && h.options.Exclusive {
+				busy = true
+			}
+		}
+		if !ok || busy {
+			m.mu.Unlock()
+			continue
+		}
+
+		now := time.Now()
+		job.Status = models.JobStatusRunning
+		job.Attempts++
+		job.NextAttemptAt = nil
+		job.Error = ""
+		if job.StartedAt == nil {
+			job.StartedAt = &now
+		}
+		runCtx, cancel := context.WithCancel(m.ctx)
+		jc := &Context{
+			manager:      m,
+			ctx:          runCtx,
+			cancel:       cancel,
+			job:          job,
+			resumeCh:     make(chan struct{}),
+			runningSince: now,
+			lastSave:     now,
+		}
+		m.active[job.ID] = jc
+		m.mu.Unlock()
+
+		m.save(&job)
+		m.publish(jc.snapshot())
+		log.Printf("Jobs: starting job %d (%s), attempt %d/%d", job.ID, job.Kind, job.Attempts, job.MaxAttempts)
+		go m.execute(jc, h)
+	}
+}
+
+func (m *Manager) execute(jc *Context, h handler) {
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return h.run(jc, jc.job.Payload)
+	}()
+
+	shutdown := m.ctx.Err() != nil
+	userCancelled := jc.ctx.Err() != nil && !shutdown
+	jc.cancel()
+
+	job := jc.snapshot()
+	switch {
+	case shutdown:
+		// Left running so the next start treats it as interrupted
+	case err == nil:
+		now := time.Now()
+		job.Status = models.JobStatusSucceeded
+		job.FinishedAt = &now
+	case userCancelled || errors.Is(err, ErrCancelled):
+		now := time.Now()
+		job.Status = models.JobStatusCancelled
+		job.FinishedAt = &now
+	default:
+		m.settle(&job, err)
+	}
+	estimate(&job)
+
+	m.mu.Lock()
+	delete(m.active, job.ID)
+	m.mu.Unlock()
+
+	m.save(&job)
+	m.publish(job)
+	if err != nil && !shutdown {
+		log.Printf("Jobs: job %d (%s) %s: %v", job.ID, job.Kind, job.Status, err)
+	} else {
+		log.Printf("Jobs: job %d (%s) %s", job.ID, job.Kind, job.Status)
+	}
+	m.signal()
+}
+
+// settle records a failed attempt, scheduling a retry with backoff if attempts remain
+func (m *Manager) settle(job *models.Job, err error) {
+	job.Error = err.Error()
+	var permanent permanentError
+	if errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts {
+		now := time.Now()
+		job.Status = models.JobStatusFailed
+		job.FinishedAt = &now
+		return
+	}
+
+	next := time.Now().Add(RetryDelay(job.Attempts))
+	job.Status = models.JobStatusRetrying
+	job.NextAttemptAt = &next
+}
+
+// RetryDelay is how long to wait before the next attempt after the given number of attempts
+func RetryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay
+}
+
+func (m *Manager) activeJob(id uint) *Context {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.active[id]
+}
+
+func (m *Manager) save(job *models.Job) {
+	if err := m.db.Save(job).Error; err != nil {
+		log.Printf("Jobs: failed to save job %d: %v", job.ID, err)
+	}
+}
+
+func (m *Manager) publish(job models.Job) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, ch := range m.subscribers {
+		select {
+		case ch <- job:
+		default:
+		}
+	}
+}
+
+// signal wakes the dispatcher without blocking
+func (m *Manager) signal() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
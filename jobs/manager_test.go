@@ -0,0 +1,284 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"vinylfo/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+type countPayload struct {
+	Items int    `json:"items"`
+	Label string `json:"label"`
+}
+
+func newTestManager(t *testing.T) (*Manager, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite memory db: %v", err)
+	}
+	// Jobs run on other goroutines; every connection to :memory: is a new database
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.Job{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+	return NewManager(db), db
+}
+
+func startManager(t *testing.T, m *Manager) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go m.Run(ctx)
+}
+
+func waitFor(t *testing.T, m *Manager, id uint) *models.Job {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	job, err := m.Wait(ctx, id)
+	if err != nil {
+		t.Fatalf("wait for job %d: %v (status %v)", id, err, job)
+	}
+	return job
+}
+
+// waitForStatus polls until the job reaches status
+func waitForStatus(t *testing.T, m *Manager, id uint, status string) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, err := m.Get(id); err == nil && job.Status == status {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	job, _ := m.Get(id)
+	t.Fatalf("job %d status = %q, want %q", id, job.Status, status)
+}
+
+func TestJobRunsWithTypedPayload(t *testing.T) {
+	m, _ := newTestManager(t)
+	Register(m, "count", Options{}, func(jc *Context, p countPayload) error {
+		jc.SetProgress(0, p.Items, "")
+		for i := 0; i < p.Items; i++ {
+			if err := jc.Checkpoint(); err != nil {
+				return err
+			}
+			jc.Step(p.Label)
+		}
+		jc.SetResult("counted")
+		return nil
+	})
+	startManager(t, m)
+
+	queued, err := m.Enqueue("count", countPayload{Items: 3, Label: "sheep"})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	job := waitFor(t, m, queued.ID)
+
+	if job.Status != models.JobStatusSucceeded || job.Result != "counted" {
+		t.Errorf("job = %s %q, want succeeded with result", job.Status, job.Result)
+	}
+	if job.Processed != 3 || job.Total != 3 || job.Percent != 100 {
+		t.Errorf("progress = %d/%d (%.0f%%), want 3/3 (100%%)", job.Processed, job.Total, job.Percent)
+	}
+	if string(job.Payload) != `{"items":3,"label":"sheep"}` {
+		t.Errorf("payload = %s", job.Payload)
+	}
+
+	if _, err := m.Enqueue("missing", nil); !errors.Is(err, ErrUnknownKind) {
+		t.Errorf("Enqueue unknown kind error = %v, want ErrUnknownKind", err)
+	}
+}
+
+func TestJobRetriesWithBackoff(t *testing.T) {
+	m, db := newTestManager(t)
+	calls := 0
+	Register(m, "flaky", Options{MaxAttempts: 3}, func(jc *Context, _ struct{}) error {
+		calls++
+		if calls == 1 {
+			return errors.New("temporarily down")
+		}
+		return nil
+	})
+	Register(m, "broken", Options{MaxAttempts: 3}, func(jc *Context, _ struct{}) error {
+		return Permanent(errors.New("bad input"))
+	})
+	startManager(t, m)
+
+	flaky, _ := m.Enqueue("flaky", nil)
+	waitForStatus(t, m, flaky.ID, models.JobStatusRetrying)
+
+	job, _ := m.Get(flaky.ID)
+	if job.Error != "temporarily down" || job.NextAttemptAt == nil {
+		t.Fatalf("retrying job = %+v, want error and next attempt time", job)
+	}
+	if wait := time.Until(*job.NextAttemptAt); wait < 20*time.Second || wait > retryBaseDelay {
+		t.Errorf("next attempt in %v, want about %v", wait, retryBaseDelay)
+	}
+
+	// Skip the backoff
+	db.Model(&models.Job{}).Where("id = ?", flaky.ID).Update("next_attempt_at", time.Now().Add(-time.Second))
+	m.signal()
+	job = waitFor(t, m, flaky.ID)
+	if job.Status != models.JobStatusSucceeded || job.Attempts != 2 || job.Error != "" {
+		t.Errorf("job = %s after %d attempts (error %q), want succeeded after 2", job.Status, job.Attempts, job.Error)
+	}
+
+	broken, _ := m.Enqueue("broken", nil)
+	job = waitFor(t, m, broken.ID)
+	if job.Status != models.JobStatusFailed || job.Attempts != 1 {
+		t.Errorf("permanent failure = %s after %d attempts, want failed after 1", job.Status, job.Attempts)
+	}
+
+	retried, err := m.Retry(broken.ID)
+	if err != nil || retried.Status != models.JobStatusQueued || retried.Attempts != 0 {
+		t.Errorf("Retry = %+v, %v; want queued with attempts reset", retried, err)
+	}
+}
+
+func TestJobPauseResumeCancel(t *testing.T) {
+	m, _ := newTestManager(t)
+	step := make(chan struct{})
+	paused, resumed := 0, 0
+	Register(m, "loop", Options{}, func(jc *Context, _ struct{}) error {
+		jc.OnPause(func() { paused++ })
+		jc.OnResume(func() { resumed++ })
+		for i := 0; ; i++ {
+			if err := jc.Checkpoint(); err != nil {
+				return err
+			}
+			jc.SetProgress(i, 100, "")
+			select {
+			case <-step:
+			case <-jc.Context().Done():
+				return jc.Context().Err()
+			}
+		}
+	})
+	startManager(t, m)
+
+	queued, _ := m.Enqueue("loop", nil)
+	waitForStatus(t, m, queued.ID, models.JobStatusRunning)
+
+	if err := m.Pause(queued.ID); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	step <- struct{}{}
+	waitForStatus(t, m, queued.ID, models.JobStatusPaused)
+
+	// The handler is blocked in Checkpoint, so it cannot take another step
+	select {
+	case step <- struct{}{}:
+		t.Fatal("paused job kept running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := m.Resume(queued.ID); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	step <- struct{}{}
+
+	if err := m.Cancel(queued.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	job := waitFor(t, m, queued.ID)
+	if job.Status != models.JobStatusCancelled {
+		t.Errorf("status = %q, want cancelled", job.Status)
+	}
+	if paused != 1 || resumed != 1 {
+		t.Errorf("hooks ran %d/%d times, want 1/1", paused, resumed)
+	}
+	if err := m.Pause(queued.ID); !errors.Is(err, ErrNotActive) {
+		t.Errorf("Pause finished job error = %v, want ErrNotActive", err)
+	}
+}
+
+func TestExclusiveJobsWaitTheirTurn(t *testing.T) {
+	m, _ := newTestManager(t)
+	release := make(chan struct{})
+	Register(m, "sync", Options{Exclusive: true}, func(jc *Context, _ struct{}) error {
+		<-release
+		return nil
+	})
+	startManager(t, m)
+
+	first, _ := m.Enqueue("sync", nil)
+	second, _ := m.Enqueue("sync", nil)
+	waitForStatus(t, m, first.ID, models.JobStatusRunning)
+
+	time.Sleep(20 * time.Millisecond)
+	if job, _ := m.Get(second.ID); job.Status != models.JobStatusQueued {
+		t.Errorf("second job status = %q, want queued", job.Status)
+	}
+	if pending := m.Pending("sync"); pending == nil || pending.ID != first.ID {
+		t.Errorf("Pending = %v, want the running job", pending)
+	}
+
+	close(release)
+	waitFor(t, m, first.ID)
+	if job := waitFor(t, m, second.ID); job.Status != models.JobStatusSucceeded {
+		t.Errorf("second job status = %q, want succeeded", job.Status)
+	}
+}
+
+func TestInterruptedJobsAreRetried(t *testing.T) {
+	m, db := newTestManager(t)
+	Register(m, "resumable", Options{MaxAttempts: 2}, func(jc *Context, _ struct{}) error { return nil })
+
+	jobs := []models.Job{
+		{Kind: "resumable", Status: models.JobStatusRunning, Attempts: 1, MaxAttempts: 2},
+		{Kind: "resumable", Status: models.JobStatusPaused, Attempts: 2, MaxAttempts: 2},
+	}
+	db.Create(&jobs)
+
+	m.recoverInterrupted()
+
+	var retrying, failed models.Job
+	db.First(&retrying, jobs[0].ID)
+	db.First(&failed, jobs[1].ID)
+	if retrying.Status != models.JobStatusRetrying || retrying.NextAttemptAt == nil {
+		t.Errorf("job with attempts left = %s, want retrying", retrying.Status)
+	}
+	if failed.Status != models.JobStatusFailed || failed.Error == "" {
+		t.Errorf("job out of attempts = %s (%q), want failed", failed.Status, failed.Error)
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	job := models.Job{Status: models.JobStatusRunning, Total: 200, Processed: 50, RunningSecs: 100}
+	estimate(&job)
+	if job.Percent != 25 || job.ETASeconds == nil || *job.ETASeconds != 300 {
+		t.Errorf("estimate = %.0f%%, eta %v; want 25%%, 300s", job.Percent, job.ETASeconds)
+	}
+
+	job.Status = models.JobStatusPaused
+	estimate(&job)
+	if job.ETASeconds != nil {
+		t.Errorf("paused job has ETA %d", *job.ETASeconds)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		10: 30 * time.Minute,
+	}
+	for attempts, want := range tests {
+		if got := RetryDelay(attempts); got != want {
+			t.Errorf("RetryDelay(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
@@ -29,8 +29,11 @@ import (
 	"vinylfo/controllers"
 	"vinylfo/database"
 	"vinylfo/discogs"
+	"vinylfo/httpcache"
+	"vinylfo/imagestore"
 	"vinylfo/models"
 	"vinylfo/routes"
+	"vinylfo/services"
 	"vinylfo/utils"
 )
 
@@ -104,6 +107,40 @@ func cleanupLogsOnStartup(db *gorm.DB) {
 	}
 }
 
+// migrateCoverImages moves covers still kept in the albums table into the image store and
+// extracts the palettes of covers that have none
+func migrateCoverImages(db *gorm.DB) {
+	moved, err := services.MigrateCoverBlobs(db)
+	if err != nil {
+		log.Printf("Failed to move album covers to the image store: %v", err)
+		return
+	}
+	if moved > 0 {
+		log.Printf("Moved %d album covers to the image store", moved)
+	}
+
+	filled, err := services.FillCoverPalettes(db)
+	if err != nil {
+		log.Printf("Failed to extract album cover palettes: %v", err)
+		return
+	}
+	if filled > 0 {
+		log.Printf("Extracted the palettes of %d album covers", filled)
+	}
+}
+
+// seedPlayLog copies track history into the play log the first time the app starts with one
+func seedPlayLog(db *gorm.DB) {
+	seeded, err := services.SeedPlayEvents(db)
+	if err != nil {
+		log.Printf("Failed to seed play events from track history: %v", err)
+		return
+	}
+	if seeded > 0 {
+		log.Printf("Seeded %d play events from track history", seeded)
+	}
+}
+
 func main() {
 	log.Println("Vinylfo starting...")
 	config.LoadEmbeddedEnv()
@@ -128,13 +165,20 @@ func main() {
 
 	utils.InitPKCE(db)
 	utils.InitAuditLog(db)
+	httpcache.Init(db)
+	imagestore.Init()
 
 	cleanupLogsOnStartup(db)
+	go migrateCoverImages(db)
+	go seedPlayLog(db)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	go playbackController.SimulateTimer(ctx)
+	go services.NewPriceTracker(db, func() *discogs.Client {
+		return services.NewDiscogsClientFromConfig(db)
+	}).Run(ctx)
 
 	r := gin.New()
 	r.Use(gin.Recovery())
@@ -158,11 +202,13 @@ func main() {
 		"templates/playlist.html",
 		"templates/track-detail.html",
 		"templates/album-detail.html",
+		"templates/artist-detail.html",
 		"templates/resolution-center.html",
 		"templates/youtube.html",
 		"templates/video-feed.html",
 		"templates/album-art-feed.html",
 		"templates/track-feed.html",
+		"templates/wrapped.html",
 	))
 	r.SetHTMLTemplate(tmpl)
 
@@ -186,6 +232,10 @@ func main() {
 		c.HTML(200, "album-detail-page", nil)
 	})
 
+	r.GET("/artist/:id", func(c *gin.Context) {
+		c.HTML(200, "artist-detail-page", nil)
+	})
+
 	r.GET("/settings", func(c *gin.Context) {
 		c.HTML(200, "settings-page", nil)
 	})
@@ -210,7 +260,7 @@ func main() {
 		c.HTML(200, "youtube-page", nil)
 	})
 
-	routes.SetupRoutes(r)
+	routes.SetupRoutes(ctx, r)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Album image kinds
+const (
+	ImageFront = "front"
+	ImageBack  = "back"
+	ImageLabel = "label"
+	ImageInner = "inner" // Inner sleeves and inserts
+	ImageOther = "other"
+)
+
+// ImageKinds lists the kinds an album image can be given
+var ImageKinds = []string{ImageFront, ImageBack, ImageLabel, ImageInner, ImageOther}
+
+// CoverPalette holds colors picked from an album's cover, as #rrggbb, so feeds and pages can be
+// themed from the record playing
+type CoverPalette struct {
+	Dominant string `gorm:"size:7;default:''" json:"dominant"`
+	Vibrant  string `gorm:"size:7;default:''" json:"vibrant"`
+	Muted    string `gorm:"size:7;default:''" json:"muted"`
+	Text     string `gorm:"size:7;default:''" json:"text"` // Black or white, whichever reads better on Dominant
+}
+
+// AlbumImage is one of an album's images, such as the front or back cover or a label
+// The file lives in the image store under its hash, shared by every album that has the same image
+type AlbumImage struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	AlbumID     uint      `gorm:"not null;index" json:"album_id"`
+	Position    int       `json:"position"`                    // Order on the Discogs release
+	Kind        string    `gorm:"size:10;index" json:"kind"`   // front, back, label, inner, other
+	DiscogsType string    `gorm:"size:20" json:"discogs_type"` // primary or secondary
+	SourceURL   string    `gorm:"type:text" json:"source_url"` // Where the image was downloaded from
+	Hash        string    `gorm:"size:64;index" json:"hash"`   // SHA-256 of the original file
+	ContentType string    `gorm:"size:50" json:"content_type"` // Of the original file
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Size        int       `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// deleteAlbumImages drops the images of a deleted album, or of every deleted album when id is 0
+// The files stay in the store, where other albums may share them
+func deleteAlbumImages(tx *gorm.DB, id uint) error {
+	tx = tx.Session(&gorm.Session{NewDB: true})
+	if id != 0 {
+		return tx.Where("album_id = ?", id).Delete(&AlbumImage{}).Error
+	}
+	return tx.Where("album_id NOT IN (SELECT id FROM albums)").Delete(&AlbumImage{}).Error
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AlbumInstance is one copy of an album's release in the Discogs collection
+// A release owned twice keeps one album row and one instance per copy
+type AlbumInstance struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	AlbumID    uint       `gorm:"not null;index" json:"album_id"`
+	InstanceID int        `gorm:"not null;uniqueIndex" json:"instance_id"`
+	FolderID   int        `json:"folder_id"`
+	AddedAt    *time.Time `json:"added_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// deleteAlbumInstances drops the copies of a deleted album, or of every deleted album when id is 0
+func deleteAlbumInstances(tx *gorm.DB, id uint) error {
+	tx = tx.Session(&gorm.Session{NewDB: true})
+	if id != 0 {
+		return tx.Where("album_id = ?", id).Delete(&AlbumInstance{}).Error
+	}
+	return tx.Where("album_id NOT IN (SELECT id FROM albums)").Delete(&AlbumInstance{}).Error
+}
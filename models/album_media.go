@@ -0,0 +1,101 @@
+package models
+
+import (
+	"strings"
+)
+
+// Goldmine grading scale, best to worst
+const (
+	GradeMint         = "M"
+	GradeNearMint     = "NM"
+	GradeVeryGoodPlus = "VG+"
+	GradeVeryGood     = "VG"
+	GradeGoodPlus     = "G+"
+	GradeGood         = "G"
+	GradeFair         = "F"
+	GradePoor         = "P"
+
+	// Sleeve-only values used by Discogs
+	SleeveGeneric = "Generic"
+	SleeveNoCover = "No Cover"
+)
+
+// GoldmineGrades lists the media grades in descending order of quality
+var GoldmineGrades = []string{
+	GradeMint, GradeNearMint, GradeVeryGoodPlus, GradeVeryGood,
+	GradeGoodPlus, GradeGood, GradeFair, GradePoor,
+}
+
+// gradeAliases maps Discogs labels and common spellings to Goldmine grades
+var gradeAliases = map[string]string{
+	"mint (m)":             GradeMint,
+	"mint":                 GradeMint,
+	"near mint (nm or m-)": GradeNearMint,
+	"near mint":            GradeNearMint,
+	"m-":                   GradeNearMint,
+	"very good plus (vg+)": GradeVeryGoodPlus,
+	"very good plus":       GradeVeryGoodPlus,
+	"very good (vg)":       GradeVeryGood,
+	"very good":            GradeVeryGood,
+	"good plus (g+)":       GradeGoodPlus,
+	"good plus":            GradeGoodPlus,
+	"good (g)":             GradeGood,
+	"good":                 GradeGood,
+	"fair (f)":             GradeFair,
+	"fair":                 GradeFair,
+	"poor (p)":             GradePoor,
+	"poor":                 GradePoor,
+	"generic":              SleeveGeneric,
+	"no cover":             SleeveNoCover,
+}
+
+// NormalizeGrade converts a Discogs condition label ("Very Good Plus (VG+)")
+// or abbreviation ("vg+") to its Goldmine grade. Returns "" if unrecognized.
+func NormalizeGrade(value string) string {
+	v := strings.ToLower(strings.TrimSpace(value))
+	if v == "" {
+		return ""
+	}
+	if grade, ok := gradeAliases[v]; ok {
+		return grade
+	}
+	for _, grade := range GoldmineGrades {
+		if strings.ToLower(grade) == v {
+			return grade
+		}
+	}
+	return ""
+}
+
+// discogsConditionLabels are the condition values Discogs offers in its collection fields
+var discogsConditionLabels = map[string]string{
+	GradeMint:         "Mint (M)",
+	GradeNearMint:     "Near Mint (NM or M-)",
+	GradeVeryGoodPlus: "Very Good Plus (VG+)",
+	GradeVeryGood:     "Very Good (VG)",
+	GradeGoodPlus:     "Good Plus (G+)",
+	GradeGood:         "Good (G)",
+	GradeFair:         "Fair (F)",
+	GradePoor:         "Poor (P)",
+	SleeveGeneric:     "Generic",
+	SleeveNoCover:     "No Cover",
+}
+
+// DiscogsConditionLabel converts a Goldmine grade to the label Discogs uses for it
+// Unknown values are returned unchanged
+func DiscogsConditionLabel(grade string) string {
+	if label, ok := discogsConditionLabels[grade]; ok {
+		return label
+	}
+	return grade
+}
+
+// GradesAtLeast returns the Goldmine grades equal to or better than grade
+func GradesAtLeast(grade string) []string {
+	for i, g := range GoldmineGrades {
+		if g == grade {
+			return GoldmineGrades[:i+1]
+		}
+	}
+	return nil
+}
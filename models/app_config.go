@@ -20,6 +20,14 @@ type AppConfig struct {
 	YouTubeTokenExpiry  time.Time `gorm:"column:youtube_token_expiry" json:"-"`
 	YouTubeConnected    bool      `gorm:"column:youtube_connected;default:false" json:"youtube_connected"`
 	LogRetentionCount   int       `gorm:"default:10" json:"log_retention_count"`
+	MarketCurrency      string    `gorm:"size:3;default:'USD'" json:"market_currency"` // Currency for Discogs price tracking
+	AudioLibraryPaths   string    `gorm:"type:text" json:"audio_library_paths"`        // JSON array of folders scanned for local audio files
+
+	// Duration resolver - JSON object of per-source trust and tolerance overrides
+	DurationSourceWeights string `gorm:"type:text" json:"duration_source_weights"`
+
+	// One-time data migrations - when track history was copied into the play log
+	PlayLogSeededAt *time.Time `json:"play_log_seeded_at"`
 
 	// Feed Settings - Video Feed
 	FeedVideoTheme           string `gorm:"size:20;default:'dark'" json:"feed_video_theme"`
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Credit roles
+const (
+	CreditRolePerformer   = "performer"
+	CreditRoleFeaturing   = "featuring"
+	CreditRoleProducer    = "producer"
+	CreditRoleEngineer    = "engineer"
+	CreditRoleContributor = "contributor" // Any other Discogs role (writing, design, photography...)
+)
+
+// Artist is a person or group credited on albums or tracks
+type Artist struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name      string    `gorm:"size:255;not null;index" json:"name"`
+	DiscogsID *int      `gorm:"uniqueIndex" json:"discogs_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Credit links an artist to an album, or to a single track when TrackID is set
+// AlbumID is always filled so an artist's discography is one lookup away
+type Credit struct {
+	ID       uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	ArtistID uint   `gorm:"not null;index" json:"artist_id"`
+	AlbumID  uint   `gorm:"not null;index" json:"album_id"`
+	TrackID  *uint  `gorm:"index" json:"track_id"`
+	Role     string `gorm:"size:20;not null;index" json:"role"`
+	Detail   string `gorm:"size:255" json:"detail"` // Discogs role text, e.g. "Bass, Vocals"
+	Joiner   string `gorm:"size:20" json:"join"`    // Joiner to the next main artist ("&", "Feat.")
+	Position int    `json:"position"`               // Order within the album or track credits
+
+	Artist Artist `gorm:"foreignKey:ArtistID" json:"artist"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// deleteCredits removes the credits of a deleted album or track
+// A zero ID means a batch delete, so every credit left without a row is dropped
+func deleteCredits(tx *gorm.DB, column string, id uint) error {
+	tx = tx.Session(&gorm.Session{NewDB: true})
+	if id != 0 {
+		return tx.Where(column+" = ?", id).Delete(&Credit{}).Error
+	}
+	if column == "track_id" {
+		return tx.Where("track_id IS NOT NULL AND track_id NOT IN (SELECT id FROM tracks)").Delete(&Credit{}).Error
+	}
+	return tx.Where("album_id NOT IN (SELECT id FROM albums)").Delete(&Credit{}).Error
+}
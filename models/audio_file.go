@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Audio file match methods
+const (
+	AudioMatchAuto   = "auto"   // Linked by the library scan
+	AudioMatchManual = "manual" // Linked or unlinked by the user; scans leave it alone
+)
+
+// AudioFile is a file found in the local audio library, with the tags read from it
+type AudioFile struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Path        string    `gorm:"size:768;not null;uniqueIndex" json:"path"`
+	Format      string    `gorm:"size:10;index" json:"format"` // mp3, flac, ogg or opus
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"mod_time"`
+	Checksum    string    `gorm:"size:64;index" json:"checksum"` // SHA-256 of the file contents
+	Bitrate     int       `json:"bitrate"`                       // Average kbps
+	SampleRate  int       `json:"sample_rate"`
+	Channels    int       `json:"channels"`
+	Duration    int       `json:"duration"` // Duration in seconds, read from the stream
+	Title       string    `json:"title"`
+	Artist      string    `json:"artist"`
+	Album       string    `json:"album"`
+	AlbumArtist string    `json:"album_artist"`
+	TrackNumber int       `json:"track_number"`
+	DiscNumber  int       `json:"disc_number"`
+	Year        int       `json:"year"`
+	ScanError   string    `gorm:"type:text" json:"scan_error"` // Set when the tags could not be read
+
+	TrackID     *uint   `gorm:"index" json:"track_id"`
+	MatchScore  float64 `json:"match_score"`
+	MatchMethod string  `gorm:"size:10" json:"match_method"` // auto or manual, empty when never matched
+
+	ScannedAt time.Time `json:"scanned_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
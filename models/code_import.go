@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Code import states
+const (
+	CodeImportPending  = "pending"
+	CodeImportImported = "imported"
+	CodeImportExists   = "exists" // The release or barcode is already in the collection
+	CodeImportNotFound = "not_found"
+	CodeImportFailed   = "failed"
+)
+
+// CodeImport is one barcode or catalog number from a batch import, such as a scanner's CSV
+type CodeImport struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	JobID     uint      `gorm:"not null;index" json:"job_id"`
+	Code      string    `gorm:"size:100;not null" json:"code"`
+	CodeType  string    `gorm:"size:10" json:"code_type"` // barcode or catno
+	Status    string    `gorm:"size:20;not null;index" json:"status"`
+	DiscogsID *int      `json:"discogs_id"`
+	AlbumID   *uint     `json:"album_id"`
+	Matches   int       `json:"matches"` // Releases Discogs found for the code
+	Error     string    `gorm:"type:text" json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
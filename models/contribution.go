@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Duration contribution targets
+const (
+	ContributionDiscogs     = "discogs"
+	ContributionMusicBrainz = "musicbrainz"
+)
+
+// Duration contribution states
+const (
+	ContributionPending   = "pending"   // Generated, waiting to be entered on the target site
+	ContributionSubmitted = "submitted" // Edit entered, waiting for votes or moderation
+	ContributionAccepted  = "accepted"  // Edit applied
+	ContributionRejected  = "rejected"  // Edit voted down or reverted
+)
+
+// DurationContribution is an edit package of resolved track durations for one release, ready to
+// be entered on Discogs or MusicBrainz
+// Pending packages are rebuilt whenever contributions are generated; the others are kept as history
+type DurationContribution struct {
+	ID            uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	AlbumID       uint       `gorm:"not null;index" json:"album_id"`
+	Target        string     `gorm:"size:20;not null;index" json:"target"`
+	ReleaseID     string     `gorm:"size:36;index" json:"release_id"` // Discogs release ID or MusicBrainz release MBID
+	Title         string     `gorm:"size:255" json:"title"`
+	Artist        string     `gorm:"size:255" json:"artist"`
+	Status        string     `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	TrackCount    int        `json:"track_count"`                     // Tracks whose duration is contributed
+	ResolutionIDs string     `gorm:"type:text" json:"resolution_ids"` // JSON array of the DurationResolution IDs covered
+	Payload       string     `gorm:"type:text" json:"payload"`        // JSON tracklist to enter in the target's release editor
+	EditNote      string     `gorm:"type:text" json:"edit_note"`      // Explains the edit and cites the sources
+	EditURL       string     `gorm:"size:255" json:"edit_url"`        // Release editor on the target site
+	SubmissionID  string     `gorm:"size:100" json:"submission_id"`   // Discogs history ID or MusicBrainz edit ID
+	SubmissionURL string     `gorm:"size:255" json:"submission_url"`
+	SubmittedAt   *time.Time `json:"submitted_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
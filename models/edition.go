@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// PlaylistEdition records which edition of a master release a playlist plays
+// Tracks added from any other edition of the master are swapped for this one's
+type PlaylistEdition struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	PlaylistID string    `gorm:"size:255;not null;uniqueIndex:idx_playlist_edition_master" json:"playlist_id"`
+	MasterID   int       `gorm:"not null;uniqueIndex:idx_playlist_edition_master" json:"master_id"`
+	AlbumID    uint      `gorm:"not null;index" json:"album_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
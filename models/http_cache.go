@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// HTTPCacheEntry is a response from an external metadata API, kept so repeated lookups and
+// retries are answered without a request
+// Responses saying the resource does not exist are kept too, for a shorter time
+type HTTPCacheEntry struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	CacheKey    string    `gorm:"size:64;not null;uniqueIndex" json:"cache_key"` // SHA-256 of the method and URL
+	Source      string    `gorm:"size:20;not null;index" json:"source"`          // musicbrainz, wikipedia, lastfm, discogs, youtube
+	URL         string    `gorm:"type:text" json:"url"`                          // With API keys removed
+	Status      int       `json:"status"`
+	ContentType string    `gorm:"size:100" json:"content_type"`
+	Body        []byte    `gorm:"type:longblob" json:"-"`
+	Size        int       `json:"size"`
+	Negative    bool      `gorm:"default:false" json:"negative"` // Not found, cached for the source's negative TTL
+	Hits        int       `json:"hits"`
+	ExpiresAt   time.Time `gorm:"index" json:"expires_at"`
+	AccessedAt  time.Time `gorm:"index" json:"accessed_at"` // Least recently used entries are evicted first
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Background job states
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusPaused    = "paused"
+	JobStatusRetrying  = "retrying" // Failed and waiting for NextAttemptAt
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+// Job is a unit of background work such as a sync or a bulk duration resolution
+type Job struct {
+	ID            uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	Kind          string          `gorm:"size:50;not null;index" json:"kind"`
+	Status        string          `gorm:"size:20;not null;index" json:"status"`
+	Payload       json.RawMessage `gorm:"type:text" json:"payload"` // Kind-specific parameters
+	Total         int             `json:"total"`
+	Processed     int             `json:"processed"`
+	Current       string          `gorm:"size:500" json:"current"` // Item being worked on, for display
+	Result        string          `gorm:"type:text" json:"result"`
+	Error         string          `gorm:"type:text" json:"error"`
+	Attempts      int             `json:"attempts"`
+	MaxAttempts   int             `json:"max_attempts"`
+	NextAttemptAt *time.Time      `gorm:"index" json:"next_attempt_at"`
+	RunningSecs   float64         `json:"running_secs"` // Time spent running, pauses excluded
+	StartedAt     *time.Time      `json:"started_at"`
+	FinishedAt    *time.Time      `json:"finished_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+
+	Percent    float64 `gorm:"-" json:"percent"`
+	ETASeconds *int    `gorm:"-" json:"eta_seconds"` // Estimated time left, once there is enough progress to tell
+}
+
+// IsFinished reports whether the job will not run again
+func (j *Job) IsFinished() bool {
+	return j.Status == JobStatusSucceeded || j.Status == JobStatusFailed || j.Status == JobStatusCancelled
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// WantlistItem is a release on the user's Discogs wantlist
+type WantlistItem struct {
+	ID              uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	DiscogsID       int       `gorm:"not null;uniqueIndex" json:"discogs_id"`
+	DiscogsMasterID *int      `gorm:"index" json:"discogs_master_id"`
+	Title           string    `gorm:"size:255;not null" json:"title"`
+	Artist          string    `gorm:"size:255;index" json:"artist"`
+	ReleaseYear     int       `json:"release_year"`
+	Format          string    `gorm:"size:50" json:"format"`
+	FormatDetails   string    `json:"format_details"`
+	Label           string    `json:"label"`
+	CatalogNumber   string    `gorm:"size:100" json:"catalog_number"`
+	CoverImageURL   string    `json:"cover_image_url"`
+	Rating          int       `json:"rating"` // 0-5 stars
+	Notes           string    `gorm:"type:text" json:"notes"`
+	DateAdded       string    `gorm:"size:30" json:"date_added"` // When it was added on Discogs (RFC 3339)
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// PriceSnapshot is the Discogs marketplace price of a release at one point in time
+// Snapshots are kept so the collection's value can be charted over time
+type PriceSnapshot struct {
+	ID              uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	DiscogsID       int       `gorm:"not null;index:idx_price_release_fetched" json:"discogs_id"`
+	FetchedAt       time.Time `gorm:"not null;index:idx_price_release_fetched;index" json:"fetched_at"`
+	Currency        string    `gorm:"size:3" json:"currency"`
+	LowestPrice     *float64  `json:"lowest_price"` // Cheapest copy for sale (nil when none are listed)
+	NumForSale      int       `json:"num_for_sale"`
+	SuggestedPrices string    `gorm:"type:text" json:"suggested_prices"` // JSON object of Goldmine grade -> suggested price
+}
@@ -7,8 +7,8 @@ import (
 // Album represents a music album
 type Album struct {
 	ID                    uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	Title                 string    `gorm:"not null;uniqueIndex:idx_title_artist" json:"title"`
-	Artist                string    `gorm:"not null;uniqueIndex:idx_title_artist" json:"artist"`
+	Title                 string    `gorm:"not null;index:idx_album_title_artist" json:"title"`
+	Artist                string    `gorm:"not null;index:idx_album_title_artist" json:"artist"`
 	ReleaseYear           int       `json:"release_year"`
 	Genre                 string    `json:"genre"`
 	Label                 string    `json:"label"`
@@ -16,13 +16,49 @@ type Album struct {
 	ReleaseDate           string    `json:"release_date"`
 	Style                 string    `json:"style"`
 	DiscogsID             *int      `gorm:"uniqueIndex" json:"discogs_id"`
+	DiscogsMasterID       *int      `gorm:"index" json:"discogs_master_id"`
 	DiscogsFolderID       int       `json:"discogs_folder_id"` // Folder ID from Discogs collection
+	DiscogsInstanceID     int       `json:"discogs_instance_id"`
 	CoverImageURL         string    `json:"cover_image_url"`
 	DiscogsCoverImage     []byte    `gorm:"type:longblob" json:"-"`
 	DiscogsCoverImageType string    `json:"discogs_cover_image_type"`
 	CoverImageFailed      bool      `json:"cover_image_failed"`
+	EditionCount          int       `gorm:"-" json:"edition_count,omitempty"` // Local editions of the master release, filled in album lists
 	CreatedAt             time.Time `json:"created_at"`
 	UpdatedAt             time.Time `json:"updated_at"`
+
+	// Cover in the image store; DiscogsCoverImage is only kept for albums not migrated yet
+	CoverImageHash   string       `gorm:"size:64;index" json:"cover_image_hash"`
+	CoverImageSource string       `gorm:"size:20;default:''" json:"cover_image_source"` // discogs (or empty), coverartarchive, itunes, local, manual
+	CoverImageWidth  int          `gorm:"default:0" json:"cover_image_width"`           // 0 until measured by the cover refresh
+	CoverImageHeight int          `gorm:"default:0" json:"cover_image_height"`
+	CoverCheckedAt   *time.Time   `json:"cover_checked_at"` // Last time the cover refresh looked for a better cover
+	CoverPalette     CoverPalette `gorm:"embedded;embeddedPrefix:palette_" json:"cover_palette"`
+
+	// Discogs collection membership
+	DiscogsAddedAt    *time.Time `gorm:"index" json:"discogs_added_at"`    // When the copy was added to the Discogs collection
+	DiscogsOrphanedAt *time.Time `gorm:"index" json:"discogs_orphaned_at"` // Set when the copy is no longer in the Discogs collection
+
+	// MusicBrainz identifiers, filled in when the album is matched to a MusicBrainz release
+	MusicBrainzReleaseID      string `gorm:"column:musicbrainz_release_id;size:36;index" json:"musicbrainz_release_id"`
+	MusicBrainzReleaseGroupID string `gorm:"column:musicbrainz_release_group_id;size:36;index" json:"musicbrainz_release_group_id"`
+
+	// Physical copy details (imported from the Discogs collection, editable by the user)
+	Format           string   `gorm:"size:50;index" json:"format"`           // LP, EP, 7", 10", 12", Box Set, CD...
+	FormatDetails    string   `json:"format_details"`                        // Remaining Discogs descriptions, e.g. "Album, Reissue, Gatefold"
+	RPM              int      `gorm:"index" json:"rpm"`                      // 33, 45 or 78 (0 = unknown)
+	DiscCount        int      `json:"disc_count"`                            // Number of discs in the release
+	MediaCondition   string   `gorm:"size:10;index" json:"media_condition"`  // Goldmine grade (M, NM, VG+, VG, G+, G, F, P)
+	SleeveCondition  string   `gorm:"size:10;index" json:"sleeve_condition"` // Goldmine grade, or Generic / No Cover
+	CatalogNumber    string   `gorm:"size:100;index" json:"catalog_number"`
+	Barcode          string   `gorm:"size:50;index" json:"barcode"`
+	MatrixRunout     string   `gorm:"type:text" json:"matrix_runout"`
+	PurchasePrice    *float64 `json:"purchase_price"`
+	PurchaseCurrency string   `gorm:"size:3" json:"purchase_currency"`
+	PurchaseDate     string   `gorm:"size:10;index" json:"purchase_date"` // YYYY-MM-DD
+	StorageLocation  string   `gorm:"size:255;index" json:"storage_location"`
+	Notes            string   `gorm:"type:text" json:"notes"`
+	Rating           int      `json:"rating"` // Discogs collection rating, 1-5 stars (0 = unrated)
 }
 
 // Track represents a track on an album
@@ -37,6 +73,9 @@ type Track struct {
 	Position     string `json:"position"`              // Full position code
 	AudioFileURL string `json:"audio_file_url"`
 
+	MusicBrainzRecordingID string `gorm:"column:musicbrainz_recording_id;size:36;index" json:"musicbrainz_recording_id"`
+	// Set when the album's MusicBrainz release tracklist is aligned to its tracks
+
 	YouTubeVideoID string `gorm:"-" json:"youtube_video_id,omitempty"`
 	// Populated from track_youtube_matches for display purposes
 
@@ -134,7 +173,7 @@ type SyncLog struct {
 // SyncProgress tracks the sync progress for resume capability
 type SyncProgress struct {
 	ID               uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	SyncMode         string    `gorm:"size:20" json:"sync_mode"`            // "all-folders", "specific"
+	SyncMode         string    `gorm:"size:20" json:"sync_mode"`            // "all-folders", "specific", "incremental"
 	FolderID         int       `gorm:"index" json:"folder_id"`              // Current folder being synced
 	FolderName       string    `gorm:"size:255" json:"folder_name"`         // Current folder name
 	FolderIndex      int       `json:"folder_index"`                        // Index in folders list
@@ -148,12 +187,14 @@ type SyncProgress struct {
 	LastActivityAt   time.Time `json:"last_activity_at"`                    // Last time sync made progress
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at"`
+
+	ProcessedInstancesJSON string `gorm:"type:text" json:"processed_instances_json"` // JSON serialized set of processed collection instance IDs
 }
 
 // SyncHistory stores completed sync runs for historical reporting
 type SyncHistory struct {
 	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	SyncMode     string    `gorm:"size:20" json:"sync_mode"`       // "all-folders", "specific"
+	SyncMode     string    `gorm:"size:20" json:"sync_mode"`       // "all-folders", "specific", "incremental"
 	FolderID     int       `json:"folder_id"`                      // Folder that was synced (0 for all-folders)
 	FolderName   string    `gorm:"size:255" json:"folder_name"`    // Folder name
 	Processed    int       `json:"processed"`                      // Total albums processed
@@ -161,6 +202,8 @@ type SyncHistory struct {
 	DurationSecs int       `json:"duration_secs"`                  // How long the sync took
 	Status       string    `gorm:"size:20" json:"status"`          // "completed", "cancelled", "failed"
 	ErrorMessage string    `gorm:"type:text" json:"error_message"` // Error if failed
+	Added        int       `json:"added"`                          // Albums imported for the first time
+	Orphaned     int       `json:"orphaned"`                       // Albums whose copy was removed from Discogs
 	StartedAt    time.Time `json:"started_at"`                     // When sync started
 	CompletedAt  time.Time `json:"completed_at"`                   // When sync finished
 	CreatedAt    time.Time `json:"created_at"`
@@ -213,6 +256,11 @@ type DurationResolution struct {
 	TotalSourcesQueried int  `json:"total_sources_queried"` // Total APIs that were queried
 	SuccessfulQueries   int  `json:"successful_queries"`    // APIs that returned a result
 
+	// How the duration was chosen, so reviewers can see why
+	ConsensusWeight      float64 `json:"consensus_weight"`                       // Combined weight of the agreeing sources
+	ConsensusExplanation string  `gorm:"type:text" json:"consensus_explanation"` // Which sources agreed, fell outside tolerance or were rejected
+	ConsensusVotes       string  `gorm:"type:text" json:"consensus_votes"`       // JSON array of each source's vote, weight and outcome
+
 	// Application tracking
 	AutoApplied      bool       `gorm:"default:false" json:"auto_applied"`      // Was duration auto-applied (consensus met)?
 	AppliedAt        *time.Time `json:"applied_at"`                             // When duration was applied to track
@@ -222,11 +270,15 @@ type DurationResolution struct {
 	ReviewNotes      string     `gorm:"type:text" json:"review_notes"` // Notes from reviewer
 	ReviewAction     string     `gorm:"size:20" json:"review_action"`  // "apply", "reject", "manual", "skip"
 
-	// Discogs submission tracking (for future Phase 8)
+	// Discogs submission tracking, see DurationContribution
 	DiscogsSubmittable  bool       `gorm:"default:false" json:"discogs_submittable"` // Ready for Discogs submission
 	DiscogsSubmittedAt  *time.Time `json:"discogs_submitted_at"`
 	DiscogsSubmissionID string     `gorm:"size:100" json:"discogs_submission_id"`
 
+	// MusicBrainz submission tracking
+	MusicBrainzSubmittedAt *time.Time `gorm:"column:musicbrainz_submitted_at" json:"musicbrainz_submitted_at"`
+	MusicBrainzEditID      string     `gorm:"column:musicbrainz_edit_id;size:100" json:"musicbrainz_edit_id"`
+
 	// Related data (loaded via Preload, not a database relationship)
 	Sources []DurationSource `gorm:"-" json:"sources"`
 
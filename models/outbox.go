@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Collection change actions
+const (
+	ChangeAdd    = "add"    // Add the release to the Discogs collection
+	ChangeRemove = "remove" // Remove the copy from the Discogs collection
+	ChangeMove   = "move"   // Move the copy to another folder
+	ChangeRating = "rating" // Set the star rating
+	ChangeField  = "field"  // Set a collection note field such as Media Condition or Notes
+)
+
+// Collection change states
+const (
+	ChangeStatusPending   = "pending"
+	ChangeStatusPushed    = "pushed"
+	ChangeStatusConflict  = "conflict"  // Discogs was edited too; waiting for the user to pick a side
+	ChangeStatusFailed    = "failed"    // Gave up after repeated errors
+	ChangeStatusDiscarded = "discarded" // Dropped by the user or superseded by the Discogs value
+)
+
+// CollectionChange is a local collection edit queued to be pushed to Discogs on the next sync
+// Values are stored the way the album holds them (Goldmine grades, folder IDs, "24.99 USD")
+type CollectionChange struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	AlbumID     uint       `gorm:"index" json:"album_id"` // May point to a deleted album for removals
+	DiscogsID   int        `gorm:"not null;index" json:"discogs_id"`
+	InstanceID  int        `json:"instance_id"`
+	Title       string     `gorm:"size:255" json:"title"`
+	Artist      string     `gorm:"size:255" json:"artist"`
+	Action      string     `gorm:"size:20;not null;index" json:"action"`
+	Field       string     `gorm:"size:50" json:"field"` // Album column for field changes, e.g. media_condition
+	OldValue    string     `gorm:"type:text" json:"old_value"`
+	NewValue    string     `gorm:"type:text" json:"new_value"`
+	RemoteValue string     `gorm:"type:text" json:"remote_value"` // Value found on Discogs when a conflict was detected
+	Status      string     `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	Error       string     `gorm:"type:text" json:"error"`
+	Attempts    int        `json:"attempts"`
+	PushedAt    *time.Time `json:"pushed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
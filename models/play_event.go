@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Play event sources
+const (
+	PlaySourceTimer     = "timer"      // The server-side timer reached the end of the track
+	PlaySourceVideoFeed = "video_feed" // Skipped from the video feed
+	PlaySourceManual    = "manual"     // Skipped or reported from a player page
+	PlaySourceHistory   = "history"    // Seeded once per track from track history, at its last play
+)
+
+// PlayEvent is one play of a track, appended when the track ends or playback moves away from it
+// Unlike TrackHistory it is never updated, so it can tell what was played when
+type PlayEvent struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	TrackID       uint      `gorm:"not null;index" json:"track_id"`
+	AlbumID       uint      `gorm:"index" json:"album_id"`
+	PlaylistID    string    `gorm:"size:255;index" json:"playlist_id"`
+	StartedAt     time.Time `gorm:"index" json:"started_at"` // Ended at less the seconds played; pauses are not counted
+	EndedAt       time.Time `gorm:"index" json:"ended_at"`
+	Seconds       int       `json:"seconds"`                     // How long the track played
+	PercentPlayed float64   `json:"percent_played"`              // Of the track's duration, 0-100
+	Skipped       bool      `gorm:"index" json:"skipped"`        // Left before the end of the track
+	Source        string    `gorm:"size:20;index" json:"source"` // timer, video_feed, manual or history
+	CreatedAt     time.Time `json:"created_at"`
+}
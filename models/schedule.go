@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Job kinds, shared by background jobs and the schedules that start them
+const (
+	JobKindDiscogsSync          = "discogs_sync"           // Discogs collection sync
+	JobKindResolveDurations     = "resolve_durations"      // Bulk duration resolution of tracks that still need it
+	JobKindYouTubeRematch       = "youtube_rematch"        // Retry YouTube matching for tracks no video was found for
+	JobKindYouTubeMatchPlaylist = "youtube_match_playlist" // Match every track of a playlist to a YouTube video
+	JobKindCodeImport           = "code_import"            // Import releases from a list of barcodes or catalog numbers
+	JobKindLibraryScan          = "library_scan"           // Scan the local audio library and link files to tracks
+	JobKindCoverRefresh         = "cover_refresh"          // Find covers for albums without one and larger ones for small covers
+)
+
+// Job run states
+const (
+	JobRunRunning   = "running"
+	JobRunSucceeded = "succeeded"
+	JobRunFailed    = "failed"
+	JobRunSkipped   = "skipped" // The job or the work it starts was already running
+)
+
+// Job run triggers
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+// ScheduledJob runs a background task on a cron schedule
+type ScheduledJob struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name      string     `gorm:"size:100;not null;uniqueIndex" json:"name"`
+	Kind      string     `gorm:"size:50;not null;index" json:"kind"`
+	Schedule  string     `gorm:"size:100;not null" json:"schedule"` // Five-field cron expression or @hourly, @daily, @weekly, @monthly
+	Enabled   bool       `gorm:"default:false" json:"enabled"`
+	LastRunAt *time.Time `json:"last_run_at"`
+	NextRunAt *time.Time `gorm:"index" json:"next_run_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// JobRun records one run of a scheduled job
+type JobRun struct {
+	ID           uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	JobID        uint       `gorm:"not null;index" json:"job_id"`
+	Kind         string     `gorm:"size:50;not null;index" json:"kind"`
+	Trigger      string     `gorm:"size:20;not null" json:"trigger"`
+	Status       string     `gorm:"size:20;not null;index" json:"status"`
+	Summary      string     `gorm:"type:text" json:"summary"`
+	Error        string     `gorm:"type:text" json:"error"`
+	StartedAt    time.Time  `gorm:"index" json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	DurationSecs float64    `json:"duration_secs"`
+}
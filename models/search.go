@@ -0,0 +1,108 @@
+package models
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// SearchIndexer keeps the full-text search index in step with album and track writes
+// The search package registers its implementation at startup
+type SearchIndexer interface {
+	IndexAlbum(tx *gorm.DB, albumID uint) error
+	RemoveAlbum(tx *gorm.DB, albumID uint) error
+	IndexTrack(tx *gorm.DB, trackID uint) error
+	RemoveTrack(tx *gorm.DB, trackID uint) error
+	// Prune drops index entries whose rows no longer exist (used after batch deletes)
+	Prune(tx *gorm.DB) error
+}
+
+var searchIndexer SearchIndexer
+
+// SetSearchIndexer registers the indexer used by the Album and Track hooks
+// Passing nil disables index maintenance
+func SetSearchIndexer(indexer SearchIndexer) {
+	searchIndexer = indexer
+}
+
+// Index failures are logged rather than returned so a broken search index
+// never blocks catalogue writes; a rebuild will bring it back in line
+
+// ReindexAlbum refreshes the index entries of an album and its tracks after
+// changes the hooks cannot see, such as new credits
+func ReindexAlbum(tx *gorm.DB, albumID uint) {
+	if searchIndexer == nil || albumID == 0 {
+		return
+	}
+	if err := searchIndexer.IndexAlbum(tx, albumID); err != nil {
+		log.Printf("search: failed to index album %d: %v", albumID, err)
+	}
+}
+
+// AfterSave reindexes the album and its tracks
+func (a *Album) AfterSave(tx *gorm.DB) error {
+	if searchIndexer == nil || a.ID == 0 {
+		return nil
+	}
+	if err := searchIndexer.IndexAlbum(tx, a.ID); err != nil {
+		log.Printf("search: failed to index album %d: %v", a.ID, err)
+	}
+	return nil
+}
+
+// AfterDelete removes the album's credits, images and collection copies and drops it and its tracks from the index
+func (a *Album) AfterDelete(tx *gorm.DB) error {
+	if err := deleteCredits(tx, "album_id", a.ID); err != nil {
+		log.Printf("failed to delete credits for album %d: %v", a.ID, err)
+	}
+	if err := deleteAlbumImages(tx, a.ID); err != nil {
+		log.Printf("failed to delete images for album %d: %v", a.ID, err)
+	}
+	if err := deleteAlbumInstances(tx, a.ID); err != nil {
+		log.Printf("failed to delete collection copies for album %d: %v", a.ID, err)
+	}
+	if searchIndexer == nil {
+		return nil
+	}
+	var err error
+	if a.ID == 0 {
+		err = searchIndexer.Prune(tx)
+	} else {
+		err = searchIndexer.RemoveAlbum(tx, a.ID)
+	}
+	if err != nil {
+		log.Printf("search: failed to remove album %d from index: %v", a.ID, err)
+	}
+	return nil
+}
+
+// AfterSave reindexes the track
+func (t *Track) AfterSave(tx *gorm.DB) error {
+	if searchIndexer == nil || t.ID == 0 {
+		return nil
+	}
+	if err := searchIndexer.IndexTrack(tx, t.ID); err != nil {
+		log.Printf("search: failed to index track %d: %v", t.ID, err)
+	}
+	return nil
+}
+
+// AfterDelete removes the track's credits and drops it from the index
+func (t *Track) AfterDelete(tx *gorm.DB) error {
+	if err := deleteCredits(tx, "track_id", t.ID); err != nil {
+		log.Printf("failed to delete credits for track %d: %v", t.ID, err)
+	}
+	if searchIndexer == nil {
+		return nil
+	}
+	var err error
+	if t.ID == 0 {
+		err = searchIndexer.Prune(tx)
+	} else {
+		err = searchIndexer.RemoveTrack(tx, t.ID)
+	}
+	if err != nil {
+		log.Printf("search: failed to remove track %d from index: %v", t.ID, err)
+	}
+	return nil
+}
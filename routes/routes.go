@@ -9,6 +9,9 @@ import (
 	"vinylfo/controllers"
 	"vinylfo/database"
 	"vinylfo/duration"
+	"vinylfo/jobs"
+	"vinylfo/models"
+	"vinylfo/services"
 	"vinylfo/utils"
 
 	"github.com/gin-gonic/gin"
@@ -51,8 +54,10 @@ func CSPMiddleware() gin.HandlerFunc {
 	}
 }
 
-func SetupRoutes(r *gin.Engine) {
+// SetupRoutes registers all routes and starts the job runner and scheduler, which stop when ctx is cancelled
+func SetupRoutes(ctx context.Context, r *gin.Engine) {
 	db := database.GetDB()
+	jobManager := jobs.NewManager(db)
 
 	playbackController := controllers.NewPlaybackController(db)
 	albumController := controllers.NewAlbumController(db, playbackController.BroadcastState)
@@ -60,8 +65,15 @@ func SetupRoutes(r *gin.Engine) {
 	playlistController := controllers.NewPlaylistController(db)
 	sessionSharingController := controllers.NewSessionSharingController(db)
 	sessionNoteController := controllers.NewSessionNoteController(db)
-	discogsController := controllers.NewDiscogsController(db)
+	discogsController := controllers.NewDiscogsController(db, jobManager)
 	settingsController := controllers.NewSettingsController(db)
+	searchController := controllers.NewSearchController(db)
+	artistController := controllers.NewArtistController(db)
+	marketController := controllers.NewMarketController(db)
+	collectionController := controllers.NewCollectionController(db)
+	libraryController := controllers.NewLibraryController(db, jobManager)
+	coverArtController := controllers.NewCoverArtController(db, jobManager)
+	statsController := controllers.NewStatsController(db)
 
 	r.Use(CSPMiddleware())
 
@@ -121,16 +133,36 @@ func SetupRoutes(r *gin.Engine) {
 	r.GET("/albums/search", albumController.SearchAlbums)
 	r.GET("/albums/:id", albumController.GetAlbumByID)
 	r.GET("/albums/:id/image", albumController.GetAlbumImage)
+	r.GET("/albums/:id/images", albumController.GetAlbumImages)
 	r.GET("/albums/:id/tracks", albumController.GetTracksByAlbumID)
+	r.GET("/albums/:id/credits", artistController.GetAlbumCredits)
+	r.GET("/albums/:id/editions", albumController.GetAlbumEditions)
+	r.GET("/albums/:id/prices", marketController.GetAlbumPrices)
 	r.GET("/albums/:id/delete-preview", albumController.DeleteAlbumPreview)
 	r.POST("/albums/:id/image", albumController.UpdateAlbumImage)
+	r.PUT("/albums/:id/images/:imageId", albumController.UpdateAlbumImageKind)
+	r.GET("/images/:hash", albumController.GetImage)
+	r.GET("/albums/:id/covers", coverArtController.GetCandidates)
+	r.POST("/albums/:id/covers", coverArtController.ApplyCandidate)
+	r.POST("/api/covers/refresh", coverArtController.StartRefresh)
 	r.POST("/albums", albumController.CreateAlbum)
 	r.PUT("/albums/:id", albumController.UpdateAlbum)
 	r.DELETE("/albums/:id", albumController.DeleteAlbum)
 
+	r.GET("/artists", artistController.GetArtists)
+	r.GET("/artists/:id", artistController.GetArtistByID)
+
+	r.GET("/wantlist", marketController.GetWantlist)
+	r.POST("/wantlist/sync", marketController.SyncWantlist)
+	r.GET("/collection/value", marketController.GetCollectionValue)
+	r.GET("/collection/export", collectionController.ExportCollection)
+	r.POST("/collection/import", collectionController.ImportCollection)
+	r.POST("/prices/refresh", marketController.RefreshPrices)
+
 	r.GET("/tracks", trackController.GetTracks)
 	r.GET("/tracks/search", trackController.SearchTracks)
 	r.GET("/tracks/:id", trackController.GetTrackByID)
+	r.GET("/tracks/:id/stream", trackController.StreamTrack)
 	r.POST("/tracks", trackController.CreateTrack)
 	r.PUT("/tracks/:id", trackController.UpdateTrack)
 	r.PUT("/tracks/:id/youtube", trackController.SetYouTubeVideo)
@@ -138,6 +170,10 @@ func SetupRoutes(r *gin.Engine) {
 	r.DELETE("/tracks/:id", trackController.DeleteTrack)
 	r.GET("/api/debug/youtube-matches", trackController.DebugYouTubeMatches)
 
+	// Unified full-text search (the /search path itself is the search page)
+	r.GET("/api/search", searchController.Search)
+	r.POST("/api/search/rebuild", searchController.RebuildIndex)
+
 	r.GET("/playback", playbackController.GetCurrent)
 	r.GET("/playback/current", playbackController.GetPlaybackState)
 	r.GET("/playback/events", playbackController.StreamEvents)
@@ -160,6 +196,14 @@ func SetupRoutes(r *gin.Engine) {
 	r.GET("/playback/history/:track_id", playbackController.GetTrackHistory)
 	r.POST("/playback/update-history", playbackController.UpdateHistory)
 
+	// Listening stats from the play log
+	r.GET("/stats/listening", statsController.GetListeningStats)
+	r.GET("/stats/never-played", statsController.GetNeverPlayed)
+	r.GET("/stats/sides", statsController.GetSideCompletion)
+	r.GET("/stats/wrapped/:year", statsController.GetWrapped)
+	r.GET("/wrapped", statsController.GetWrappedPage)
+	r.GET("/wrapped/:year", statsController.GetWrappedPage)
+
 	// Video Feed for OBS streaming
 	videoFeedController := controllers.NewVideoFeedController(db, playbackController, duration.NewYouTubeOAuthClient(db))
 	r.GET("/feeds/video", videoFeedController.GetVideoFeedPage)
@@ -199,6 +243,9 @@ func SetupRoutes(r *gin.Engine) {
 	r.POST("/sessions/playlist/:id/tracks", playlistController.AddTrackToPlaylist)
 	r.DELETE("/sessions/playlist/:id/tracks/:track_id", playlistController.RemoveTrackFromPlaylist)
 	r.POST("/sessions/playlist/:id/shuffle", playlistController.ShufflePlaylist)
+	r.GET("/sessions/playlist/:id/editions", playlistController.GetPlaylistEditions)
+	r.PUT("/sessions/playlist/:id/editions", playlistController.SetPlaylistEdition)
+	r.DELETE("/sessions/playlist/:id/editions/:master_id", playlistController.ClearPlaylistEdition)
 
 	r.POST("/sessions/:session_id/share", sessionSharingController.CreateSessionSharing)
 	r.GET("/sessions/:session_id/share", sessionSharingController.GetSessionSharing)
@@ -218,6 +265,10 @@ func SetupRoutes(r *gin.Engine) {
 	r.GET("/api/discogs/status", discogsController.GetStatus)
 	r.GET("/api/discogs/folders", discogsController.GetFolders)
 	r.GET("/api/discogs/search", discogsController.Search)
+	r.GET("/api/discogs/lookup", discogsController.LookupCode)
+	r.POST("/api/discogs/lookup/import", discogsController.ImportCode)
+	r.POST("/api/discogs/lookup/batch", discogsController.ImportCodeBatch)
+	r.GET("/api/discogs/lookup/batch/:id", discogsController.GetCodeBatch)
 	r.GET("/api/discogs/albums/:id", discogsController.PreviewAlbum)
 	r.POST("/api/discogs/albums", discogsController.CreateAlbum)
 	r.POST("/api/discogs/sync/start", discogsController.StartSync)
@@ -235,6 +286,10 @@ func SetupRoutes(r *gin.Engine) {
 	r.GET("/api/discogs/unlinked-albums", discogsController.FindUnlinkedAlbums)
 	r.POST("/api/discogs/unlinked-albums/delete", discogsController.DeleteUnlinkedAlbums)
 	r.POST("/api/discogs/cleanup-orphaned-tracks", discogsController.CleanupOrphanedTracks)
+	r.GET("/api/discogs/outbox", discogsController.GetOutbox)
+	r.POST("/api/discogs/outbox/push", discogsController.PushOutbox)
+	r.POST("/api/discogs/outbox/:id/resolve", discogsController.ResolveOutboxChange)
+	r.DELETE("/api/discogs/outbox/:id", discogsController.DiscardOutboxChange)
 
 	r.GET("/api/settings", settingsController.Get)
 	r.PUT("/api/settings", settingsController.Update)
@@ -350,7 +405,11 @@ func SetupRoutes(r *gin.Engine) {
 		})
 	})
 
-	durationController := controllers.NewDurationController(db)
+	httpCacheController := controllers.NewHTTPCacheController()
+	r.GET("/api/cache/stats", httpCacheController.GetStats)
+	r.DELETE("/api/cache", httpCacheController.Purge)
+
+	durationController := controllers.NewDurationController(db, jobManager)
 	durationReviewController := controllers.NewDurationReviewController(db)
 
 	duration := r.Group("/api/duration")
@@ -375,10 +434,15 @@ func SetupRoutes(r *gin.Engine) {
 		duration.GET("/review/:id", durationReviewController.GetReviewDetails)
 		duration.POST("/review/:id", durationReviewController.SubmitReview)
 		duration.POST("/review/bulk", durationReviewController.BulkReview)
+
+		duration.GET("/contributions", durationReviewController.GetContributions)
+		duration.POST("/contributions/generate", durationReviewController.GenerateContributions)
+		duration.GET("/contributions/:id", durationReviewController.GetContribution)
+		duration.PUT("/contributions/:id", durationReviewController.UpdateContribution)
 	}
 
 	youtubeController := controllers.NewYouTubeController(db)
-	youtubeSyncController := controllers.NewYouTubeSyncController(db)
+	youtubeSyncController := controllers.NewYouTubeSyncController(db, jobManager)
 
 	youtube := r.Group("/api/youtube")
 	{
@@ -414,4 +478,61 @@ func SetupRoutes(r *gin.Engine) {
 		youtube.POST("/candidates/:track_id/select/:candidate_id", youtubeSyncController.SelectCandidate)
 		youtube.POST("/clear-cache", youtubeSyncController.ClearWebCache)
 	}
+
+	// Local audio library
+	library := r.Group("/api/library")
+	{
+		library.GET("", libraryController.GetStatus)
+		library.POST("/scan", libraryController.StartScan)
+		library.GET("/files", libraryController.GetFiles)
+		library.PUT("/files/:id/track", libraryController.LinkFile)
+		library.DELETE("/files/:id/track", libraryController.UnlinkFile)
+	}
+
+	// Background jobs
+	jobs.Register(jobManager, models.JobKindDiscogsSync, jobs.Options{Exclusive: true}, discogsController.RunSyncJob)
+	jobs.Register(jobManager, models.JobKindResolveDurations, jobs.Options{MaxAttempts: 3, Exclusive: true}, durationController.RunResolutionJob)
+	jobs.Register(jobManager, models.JobKindYouTubeRematch, jobs.Options{MaxAttempts: 3, Exclusive: true}, youtubeSyncController.RunRematchJob)
+	jobs.Register(jobManager, models.JobKindYouTubeMatchPlaylist, jobs.Options{MaxAttempts: 3, Exclusive: true}, youtubeSyncController.RunMatchPlaylistJob)
+	jobs.Register(jobManager, models.JobKindCodeImport, jobs.Options{MaxAttempts: 3, Exclusive: true}, discogsController.RunCodeImportJob)
+	jobs.Register(jobManager, models.JobKindLibraryScan, jobs.Options{MaxAttempts: 3, Exclusive: true}, libraryController.RunScanJob)
+	jobs.Register(jobManager, models.JobKindCoverRefresh, jobs.Options{MaxAttempts: 3, Exclusive: true}, coverArtController.RunRefreshJob)
+	go jobManager.Run(ctx)
+	go libraryController.Watch(ctx)
+
+	jobController := controllers.NewJobController(db, jobManager)
+
+	jobs := r.Group("/api/jobs")
+	{
+		jobs.GET("", jobController.GetJobs)
+		jobs.POST("", jobController.CreateJob)
+		jobs.GET("/events", jobController.StreamEvents)
+		jobs.GET("/:id", jobController.GetJob)
+		jobs.POST("/:id/pause", jobController.PauseJob)
+		jobs.POST("/:id/resume", jobController.ResumeJob)
+		jobs.POST("/:id/cancel", jobController.CancelJob)
+		jobs.POST("/:id/retry", jobController.RetryJob)
+	}
+
+	// Scheduled background jobs
+	scheduler := services.NewScheduler(db)
+	scheduler.Register(models.JobKindDiscogsSync, discogsController.ScheduledSync)
+	scheduler.Register(models.JobKindResolveDurations, durationController.ScheduledResolution)
+	scheduler.Register(models.JobKindYouTubeRematch, youtubeSyncController.ScheduledRematch)
+	scheduler.Register(models.JobKindLibraryScan, libraryController.ScheduledScan)
+	scheduler.Register(models.JobKindCoverRefresh, coverArtController.ScheduledRefresh)
+	go scheduler.Run(ctx)
+
+	scheduleController := controllers.NewScheduleController(db, scheduler)
+
+	schedules := r.Group("/api/schedules")
+	{
+		schedules.GET("", scheduleController.GetSchedules)
+		schedules.POST("", scheduleController.CreateSchedule)
+		schedules.GET("/runs", scheduleController.GetScheduleRuns)
+		schedules.PUT("/:id", scheduleController.UpdateSchedule)
+		schedules.DELETE("/:id", scheduleController.DeleteSchedule)
+		schedules.POST("/:id/run", scheduleController.RunSchedule)
+		schedules.GET("/:id/runs", scheduleController.GetScheduleRuns)
+	}
 }
@@ -0,0 +1,320 @@
+package search
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// indexTable is the name of the FTS5 virtual table (SQLite) or FULLTEXT table (MySQL)
+const indexTable = "search_index"
+
+// vocabTable exposes the FTS5 term list, used for typo correction on SQLite
+const vocabTable = "search_index_vocab"
+
+// Index maintains and queries the full-text search index
+// SQLite uses an FTS5 virtual table with diacritic folding; MySQL uses an
+// InnoDB table with a FULLTEXT index over the same columns
+type Index struct {
+	db *gorm.DB
+}
+
+// NewIndex creates an Index backed by db
+func NewIndex(db *gorm.DB) *Index {
+	return &Index{db: db}
+}
+
+// document is one row of the search index
+// Rows are keyed by docKey so albums and tracks can share a table
+type document struct {
+	Key     int64
+	DocType string
+	DocID   uint
+	AlbumID uint
+	Year    int
+	Title   string
+	Artist  string
+	Album   string
+	Label   string
+	Genre   string
+	Style   string
+	Credits string
+}
+
+// docKey packs the document type into the low bit of the row key
+func docKey(docType string, id uint) int64 {
+	key := int64(id) << 1
+	if docType == DocTrack {
+		key |= 1
+	}
+	return key
+}
+
+func (ix *Index) isMySQL() bool {
+	return ix.db.Dialector.Name() == "mysql"
+}
+
+// EnsureSchema creates the index tables if they do not exist
+// It reports whether the index was freshly created and needs a rebuild
+func (ix *Index) EnsureSchema() (bool, error) {
+	if ix.db.Migrator().HasTable(indexTable) {
+		return false, nil
+	}
+
+	if ix.isMySQL() {
+		err := ix.db.Exec(`CREATE TABLE ` + indexTable + ` (
+			doc_key BIGINT PRIMARY KEY,
+			doc_type VARCHAR(10) NOT NULL,
+			doc_id BIGINT UNSIGNED NOT NULL,
+			album_id BIGINT UNSIGNED NOT NULL,
+			year INT NOT NULL DEFAULT 0,
+			title TEXT, artist TEXT, album TEXT, label TEXT,
+			genre TEXT, style TEXT, credits TEXT,
+			INDEX idx_search_index_album_id (album_id),
+			FULLTEXT INDEX ft_search_index (title, artist, album, label, genre, style, credits)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`).Error
+		if err != nil {
+			return false, fmt.Errorf("failed to create search index table: %w", err)
+		}
+		return true, nil
+	}
+
+	err := ix.db.Exec(`CREATE VIRTUAL TABLE ` + indexTable + ` USING fts5(
+		doc_type UNINDEXED, doc_id UNINDEXED, album_id UNINDEXED, year UNINDEXED,
+		title, artist, album, label, genre, style, credits,
+		tokenize = 'unicode61 remove_diacritics 2'
+	)`).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to create search index table: %w", err)
+	}
+	if err := ix.db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS ` + vocabTable + ` USING fts5vocab('` + indexTable + `', 'row')`).Error; err != nil {
+		return false, fmt.Errorf("failed to create search vocabulary table: %w", err)
+	}
+	return true, nil
+}
+
+// Rebuild clears the index and reindexes every album and track
+func (ix *Index) Rebuild() error {
+	return ix.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM " + indexTable).Error; err != nil {
+			return err
+		}
+
+		var albums []models.Album
+		err := albumColumns(tx).FindInBatches(&albums, 200, func(_ *gorm.DB, _ int) error {
+			for i := range albums {
+				if err := ix.insertAlbum(tx, &albums[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		tx.Table(indexTable).Count(&count)
+		log.Printf("search: rebuilt index with %d documents", count)
+		return nil
+	})
+}
+
+// IndexAlbum (re)indexes an album together with all of its tracks, since
+// track documents carry the album's artist, title and label
+func (ix *Index) IndexAlbum(tx *gorm.DB, albumID uint) error {
+	tx = tx.Session(&gorm.Session{NewDB: true})
+
+	var album models.Album
+	if err := albumColumns(tx).First(&album, albumID).Error; err != nil {
+		return err
+	}
+	if err := ix.RemoveAlbum(tx, albumID); err != nil {
+		return err
+	}
+	return ix.insertAlbum(tx, &album)
+}
+
+// RemoveAlbum removes an album and its tracks from the index
+func (ix *Index) RemoveAlbum(tx *gorm.DB, albumID uint) error {
+	tx = tx.Session(&gorm.Session{NewDB: true})
+	return tx.Exec("DELETE FROM "+indexTable+" WHERE album_id = ?", albumID).Error
+}
+
+// IndexTrack (re)indexes a single track
+func (ix *Index) IndexTrack(tx *gorm.DB, trackID uint) error {
+	tx = tx.Session(&gorm.Session{NewDB: true})
+
+	var track models.Track
+	if err := tx.First(&track, trackID).Error; err != nil {
+		return err
+	}
+	var album models.Album
+	if err := albumColumns(tx).First(&album, track.AlbumID).Error; err != nil {
+		return err
+	}
+	credits, err := loadCredits(tx, track.AlbumID)
+	if err != nil {
+		return err
+	}
+	if err := ix.RemoveTrack(tx, trackID); err != nil {
+		return err
+	}
+	return ix.insert(tx, trackDocument(&track, &album, credits.forTrack(track.ID)))
+}
+
+// RemoveTrack removes a single track from the index
+func (ix *Index) RemoveTrack(tx *gorm.DB, trackID uint) error {
+	tx = tx.Session(&gorm.Session{NewDB: true})
+	return ix.deleteKey(tx, docKey(DocTrack, trackID))
+}
+
+// Prune removes index entries whose album or track no longer exists
+func (ix *Index) Prune(tx *gorm.DB) error {
+	tx = tx.Session(&gorm.Session{NewDB: true})
+	if err := tx.Exec("DELETE FROM "+indexTable+" WHERE doc_type = ? AND doc_id NOT IN (SELECT id FROM tracks)", DocTrack).Error; err != nil {
+		return err
+	}
+	return tx.Exec("DELETE FROM "+indexTable+" WHERE doc_type = ? AND doc_id NOT IN (SELECT id FROM albums)", DocAlbum).Error
+}
+
+// albumColumns selects the album fields needed for indexing, skipping the cover image blob
+func albumColumns(tx *gorm.DB) *gorm.DB {
+	return tx.Model(&models.Album{}).Select("id", "title", "artist", "release_year", "genre", "label", "style")
+}
+
+// insertAlbum writes the album document and a document for each of its tracks
+func (ix *Index) insertAlbum(tx *gorm.DB, album *models.Album) error {
+	credits, err := loadCredits(tx, album.ID)
+	if err != nil {
+		return err
+	}
+	if err := ix.insert(tx, albumDocument(album, credits.forAlbum())); err != nil {
+		return err
+	}
+
+	var tracks []models.Track
+	if err := tx.Where("album_id = ?", album.ID).Find(&tracks).Error; err != nil {
+		return err
+	}
+	for i := range tracks {
+		if err := ix.insert(tx, trackDocument(&tracks[i], album, credits.forTrack(tracks[i].ID))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func albumDocument(album *models.Album, credits string) document {
+	return document{
+		Key:     docKey(DocAlbum, album.ID),
+		DocType: DocAlbum,
+		DocID:   album.ID,
+		AlbumID: album.ID,
+		Year:    album.ReleaseYear,
+		Title:   album.Title,
+		Artist:  album.Artist,
+		Album:   album.Title,
+		Label:   album.Label,
+		Genre:   album.Genre,
+		Style:   album.Style,
+		Credits: credits,
+	}
+}
+
+func trackDocument(track *models.Track, album *models.Album, credits string) document {
+	return document{
+		Key:     docKey(DocTrack, track.ID),
+		DocType: DocTrack,
+		DocID:   track.ID,
+		AlbumID: track.AlbumID,
+		Year:    album.ReleaseYear,
+		Title:   track.Title,
+		Artist:  album.Artist,
+		Album:   album.Title,
+		Label:   album.Label,
+		Genre:   album.Genre,
+		Style:   album.Style,
+		Credits: credits,
+	}
+}
+
+func (ix *Index) insert(tx *gorm.DB, doc document) error {
+	keyColumn := "rowid"
+	if ix.isMySQL() {
+		keyColumn = "doc_key"
+	}
+	return tx.Exec("INSERT INTO "+indexTable+" ("+keyColumn+", doc_type, doc_id, album_id, year, title, artist, album, label, genre, style, credits) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		doc.Key, doc.DocType, doc.DocID, doc.AlbumID, doc.Year, doc.Title, doc.Artist, doc.Album, doc.Label, doc.Genre, doc.Style, doc.Credits).Error
+}
+
+func (ix *Index) deleteKey(tx *gorm.DB, key int64) error {
+	keyColumn := "rowid"
+	if ix.isMySQL() {
+		keyColumn = "doc_key"
+	}
+	return tx.Exec("DELETE FROM "+indexTable+" WHERE "+keyColumn+" = ?", key).Error
+}
+
+// albumCredits holds the credited artist names of an album and its tracks
+type albumCredits struct {
+	album  []string
+	tracks map[uint][]string
+}
+
+// loadCredits reads the artist names credited on an album or any of its tracks
+func loadCredits(tx *gorm.DB, albumID uint) (albumCredits, error) {
+	var rows []struct {
+		TrackID *uint
+		Name    string
+	}
+	err := tx.Table("credits").
+		Select("credits.track_id, artists.name").
+		Joins("JOIN artists ON artists.id = credits.artist_id").
+		Where("credits.album_id = ?", albumID).
+		Order("credits.track_id, credits.position").
+		Scan(&rows).Error
+	if err != nil {
+		return albumCredits{}, err
+	}
+
+	credits := albumCredits{tracks: make(map[uint][]string)}
+	for _, row := range rows {
+		if row.TrackID == nil {
+			credits.album = append(credits.album, row.Name)
+		} else {
+			credits.tracks[*row.TrackID] = append(credits.tracks[*row.TrackID], row.Name)
+		}
+	}
+	return credits, nil
+}
+
+// forAlbum lists everyone credited on the album or any of its tracks
+func (c albumCredits) forAlbum() string {
+	names := append([]string{}, c.album...)
+	for _, trackNames := range c.tracks {
+		names = append(names, trackNames...)
+	}
+	return joinNames(names)
+}
+
+// forTrack lists the track's own credits plus the album-wide ones
+func (c albumCredits) forTrack(trackID uint) string {
+	return joinNames(append(append([]string{}, c.tracks[trackID]...), c.album...))
+}
+
+func joinNames(names []string) string {
+	seen := make(map[string]bool, len(names))
+	unique := names[:0]
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+	return strings.Join(unique, ", ")
+}
@@ -0,0 +1,270 @@
+package search
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Document types stored in the search index
+const (
+	DocAlbum = "album"
+	DocTrack = "track"
+)
+
+// fieldAliases maps the qualifiers accepted in queries to index columns
+var fieldAliases = map[string]string{
+	"title":   "title",
+	"track":   "title",
+	"artist":  "artist",
+	"album":   "album",
+	"label":   "label",
+	"genre":   "genre",
+	"style":   "style",
+	"credit":  "credits",
+	"credits": "credits",
+}
+
+// Term is a single word or quoted phrase from a search query
+type Term struct {
+	Text   string
+	Phrase bool
+}
+
+// Query is a parsed search query
+// Free text goes into Terms, qualified terms like artist:"pink floyd" go into Fields
+type Query struct {
+	Raw      string
+	Terms    []Term
+	Fields   map[string][]Term
+	YearFrom int
+	YearTo   int
+	Types    []string
+}
+
+// ParseQuery parses a user query into free text, field qualifiers and filters
+// Supported qualifiers: title:, artist:, album:, label:, genre:, style:, credit:,
+// year:1975, year:1970..1979 (either bound may be omitted) and type:album|track
+func ParseQuery(raw string) Query {
+	q := Query{Raw: raw, Fields: make(map[string][]Term)}
+
+	for _, token := range tokenize(raw) {
+		field, value, qualified := splitQualifier(token)
+		if !qualified {
+			if term, ok := newTerm(token); ok {
+				q.Terms = append(q.Terms, term)
+			}
+			continue
+		}
+
+		switch field {
+		case "year":
+			q.YearFrom, q.YearTo = parseYearRange(value)
+		case "type":
+			for _, t := range strings.Split(strings.ToLower(value), "|") {
+				if t == DocAlbum || t == DocTrack {
+					q.Types = append(q.Types, t)
+				}
+			}
+		default:
+			if term, ok := newTerm(value); ok {
+				column := fieldAliases[field]
+				q.Fields[column] = append(q.Fields[column], term)
+			}
+		}
+	}
+
+	return q
+}
+
+// IsEmpty reports whether the query has nothing to search or filter on
+func (q Query) IsEmpty() bool {
+	return len(q.Terms) == 0 && len(q.Fields) == 0 && q.YearFrom == 0 && q.YearTo == 0
+}
+
+// HasText reports whether the query has any full-text terms
+func (q Query) HasText() bool {
+	return len(q.Terms) > 0 || len(q.Fields) > 0
+}
+
+// String renders the query back into the syntax accepted by ParseQuery
+func (q Query) String() string {
+	var parts []string
+	render := func(term Term) string {
+		if term.Phrase || strings.Contains(term.Text, " ") {
+			return `"` + term.Text + `"`
+		}
+		return term.Text
+	}
+
+	for _, term := range q.Terms {
+		parts = append(parts, render(term))
+	}
+	for _, column := range q.fieldColumns() {
+		for _, term := range q.Fields[column] {
+			parts = append(parts, column+":"+render(term))
+		}
+	}
+	switch {
+	case q.YearFrom > 0 && q.YearFrom == q.YearTo:
+		parts = append(parts, "year:"+strconv.Itoa(q.YearFrom))
+	case q.YearFrom > 0 || q.YearTo > 0:
+		parts = append(parts, "year:"+yearBound(q.YearFrom)+".."+yearBound(q.YearTo))
+	}
+	if len(q.Types) > 0 {
+		parts = append(parts, "type:"+strings.Join(q.Types, "|"))
+	}
+	return strings.Join(parts, " ")
+}
+
+func yearBound(year int) string {
+	if year == 0 {
+		return ""
+	}
+	return strconv.Itoa(year)
+}
+
+// fieldColumns returns the qualified columns in a stable order
+func (q Query) fieldColumns() []string {
+	columns := make([]string, 0, len(q.Fields))
+	for column := range q.Fields {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// tokenize splits on whitespace while keeping double-quoted sections together
+func tokenize(s string) []string {
+	var tokens []string
+	var current strings.Builder
+	inQuotes := false
+
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			current.WriteRune(r)
+		case unicode.IsSpace(r) && !inQuotes:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+
+	return tokens
+}
+
+// splitQualifier splits "field:value" when field is a known qualifier
+func splitQualifier(token string) (string, string, bool) {
+	idx := strings.Index(token, ":")
+	if idx <= 0 || idx == len(token)-1 {
+		return "", "", false
+	}
+
+	field := strings.ToLower(token[:idx])
+	if _, ok := fieldAliases[field]; !ok && field != "year" && field != "type" {
+		return "", "", false
+	}
+
+	return field, token[idx+1:], true
+}
+
+// newTerm builds a Term from a token, dropping tokens with no searchable characters
+func newTerm(token string) (Term, bool) {
+	phrase := strings.HasPrefix(token, `"`) && strings.HasSuffix(token, `"`) && len(token) > 1
+	text := strings.TrimSpace(strings.ReplaceAll(token, `"`, ""))
+
+	if !strings.ContainsFunc(text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+		return Term{}, false
+	}
+
+	return Term{Text: text, Phrase: phrase}, true
+}
+
+// parseYearRange parses "1975", "1970..1979", "1970.." or "..1979"
+func parseYearRange(value string) (int, int) {
+	if from, to, found := strings.Cut(value, ".."); found {
+		start, _ := strconv.Atoi(strings.TrimSpace(from))
+		end, _ := strconv.Atoi(strings.TrimSpace(to))
+		if start > 0 && end > 0 && start > end {
+			start, end = end, start
+		}
+		return start, end
+	}
+
+	year, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, 0
+	}
+	return year, year
+}
+
+// ftsExpression builds an SQLite FTS5 MATCH expression
+// Bare words are prefix-matched so partial input still finds results
+func (q Query) ftsExpression() string {
+	var parts []string
+	for _, term := range q.Terms {
+		parts = append(parts, ftsTerm(term))
+	}
+	for _, column := range q.fieldColumns() {
+		for _, term := range q.Fields[column] {
+			parts = append(parts, column+" : "+ftsTerm(term))
+		}
+	}
+	return strings.Join(parts, " AND ")
+}
+
+func ftsTerm(term Term) string {
+	quoted := `"` + strings.ReplaceAll(term.Text, `"`, `""`) + `"`
+	if term.Phrase {
+		return quoted
+	}
+	return quoted + "*"
+}
+
+// booleanExpression builds a MySQL FULLTEXT boolean-mode expression
+// Field qualifiers are included so they contribute to relevance; the column
+// restriction itself is applied separately
+func (q Query) booleanExpression() string {
+	var parts []string
+	add := func(term Term) {
+		text := stripBooleanOperators(term.Text)
+		if text == "" {
+			return
+		}
+		if term.Phrase {
+			parts = append(parts, `+"`+text+`"`)
+			return
+		}
+		for _, word := range strings.Fields(text) {
+			parts = append(parts, "+"+word+"*")
+		}
+	}
+
+	for _, term := range q.Terms {
+		add(term)
+	}
+	for _, column := range q.fieldColumns() {
+		for _, term := range q.Fields[column] {
+			add(term)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func stripBooleanOperators(s string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`+-<>()~*"@`, r) {
+			return ' '
+		}
+		return r
+	}, s))
+}
@@ -0,0 +1,266 @@
+package search
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// bm25Weights are the FTS5 column weights in table order
+// The four UNINDEXED columns come first and carry no weight
+const bm25Weights = "0, 0, 0, 0, 10.0, 8.0, 6.0, 3.0, 2.0, 2.0, 2.0"
+
+// Options controls paging and document types for a search
+// A zero Limit returns every match
+type Options struct {
+	Types   []string
+	Limit   int
+	Offset  int
+	Exclude []uint // Document IDs left out, such as tracks already in a playlist
+}
+
+// Result is a single ranked search hit
+type Result struct {
+	Type    string  `json:"type"`
+	ID      uint    `json:"id"`
+	AlbumID uint    `json:"album_id"`
+	Title   string  `json:"title"`
+	Artist  string  `json:"artist"`
+	Album   string  `json:"album"`
+	Year    int     `json:"year"`
+	Score   float64 `json:"score"`
+}
+
+// Results is a page of hits with the total match count
+// CorrectedQuery is set when no exact hits were found and misspelt terms were replaced
+type Results struct {
+	Hits           []Result `json:"results"`
+	Total          int64    `json:"total"`
+	CorrectedQuery string   `json:"corrected_query,omitempty"`
+}
+
+// Search runs a parsed query against the index, best matches first
+func (ix *Index) Search(q Query, opts Options) (*Results, error) {
+	if len(q.Types) > 0 {
+		opts.Types = q.Types
+	}
+
+	q, corrected, err := ix.resolve(q, opts.Types)
+	if err != nil {
+		return nil, err
+	}
+
+	whereSQL, args, score, scoreArgs := ix.conditions(q, opts.Types)
+	if len(opts.Exclude) > 0 {
+		whereSQL += " AND doc_id NOT IN ?"
+		args = append(args, opts.Exclude)
+	}
+
+	results := &Results{Hits: []Result{}}
+	if err := ix.db.Raw("SELECT COUNT(*) FROM "+indexTable+whereSQL, args...).Scan(&results.Total).Error; err != nil {
+		return nil, err
+	}
+	if results.Total == 0 {
+		return results, nil
+	}
+	if corrected {
+		results.CorrectedQuery = q.String()
+	}
+
+	sql := "SELECT doc_type AS type, doc_id AS id, album_id, title, artist, album, year, " + score + " AS score FROM " + indexTable + whereSQL +
+		" ORDER BY score DESC, doc_type, title"
+	args = append(scoreArgs, args...)
+	if opts.Limit > 0 {
+		sql += " LIMIT ? OFFSET ?"
+		args = append(args, opts.Limit, opts.Offset)
+	}
+
+	if err := ix.db.Raw(sql, args...).Scan(&results.Hits).Error; err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Filter returns a subquery selecting the IDs of docType documents matching q,
+// for use as "id IN (?)" when results are ordered by something other than relevance
+func (ix *Index) Filter(q Query, docType string) (*gorm.DB, error) {
+	types := []string{docType}
+	q, _, err := ix.resolve(q, types)
+	if err != nil {
+		return nil, err
+	}
+
+	whereSQL, args, _, _ := ix.conditions(q, types)
+	return ix.db.Raw("SELECT doc_id FROM "+indexTable+whereSQL, args...), nil
+}
+
+// resolve returns q unchanged when it matches anything, otherwise a copy with
+// misspelt terms swapped for close index terms if that copy does match
+func (ix *Index) resolve(q Query, types []string) (Query, bool, error) {
+	if !q.HasText() || ix.isMySQL() {
+		return q, false, nil
+	}
+
+	total, err := ix.count(q, types)
+	if err != nil || total > 0 {
+		return q, false, err
+	}
+
+	corrected, changed := ix.correct(q)
+	if !changed {
+		return q, false, nil
+	}
+	if total, err := ix.count(corrected, types); err != nil || total == 0 {
+		return q, false, err
+	}
+	return corrected, true, nil
+}
+
+func (ix *Index) count(q Query, types []string) (int64, error) {
+	whereSQL, args, _, _ := ix.conditions(q, types)
+	var total int64
+	err := ix.db.Raw("SELECT COUNT(*) FROM "+indexTable+whereSQL, args...).Scan(&total).Error
+	return total, err
+}
+
+// conditions builds the WHERE clause for q along with the relevance expression
+// The score expression's own placeholders (MySQL only) are returned separately
+// because they precede the WHERE arguments in the final statement
+func (ix *Index) conditions(q Query, types []string) (string, []interface{}, string, []interface{}) {
+	// Tracks without a title are never listed
+	where := []string{"title != ''"}
+	var args []interface{}
+	score := "0"
+	var scoreArgs []interface{}
+
+	if q.HasText() {
+		if ix.isMySQL() {
+			match := "MATCH(title, artist, album, label, genre, style, credits) AGAINST (? IN BOOLEAN MODE)"
+			score = match
+			scoreArgs = append(scoreArgs, q.booleanExpression())
+			where = append(where, match)
+			args = append(args, q.booleanExpression())
+			// FULLTEXT cannot be restricted to one column without its own index,
+			// so qualifiers are enforced with LIKE (accent-insensitive under utf8mb4_unicode_ci)
+			for _, column := range q.fieldColumns() {
+				for _, term := range q.Fields[column] {
+					where = append(where, column+" LIKE ?")
+					args = append(args, "%"+term.Text+"%")
+				}
+			}
+		} else {
+			score = "-bm25(" + indexTable + ", " + bm25Weights + ")"
+			where = append(where, indexTable+" MATCH ?")
+			args = append(args, q.ftsExpression())
+		}
+	}
+
+	if len(types) > 0 {
+		where = append(where, "doc_type IN ?")
+		args = append(args, types)
+	}
+	if q.YearFrom > 0 {
+		where = append(where, "year >= ?")
+		args = append(args, q.YearFrom)
+	}
+	if q.YearTo > 0 {
+		where = append(where, "year <= ?")
+		args = append(args, q.YearTo)
+	}
+
+	return " WHERE " + strings.Join(where, " AND "), args, score, scoreArgs
+}
+
+// correct replaces terms that do not appear in the index vocabulary with the
+// closest term that does, when one is within a small edit distance
+func (ix *Index) correct(q Query) (Query, bool) {
+	changed := false
+	fix := func(terms []Term) []Term {
+		fixed := make([]Term, len(terms))
+		for i, term := range terms {
+			fixed[i] = term
+			if term.Phrase || strings.Contains(term.Text, " ") {
+				continue
+			}
+			if suggestion := ix.suggest(strings.ToLower(term.Text)); suggestion != "" {
+				fixed[i].Text = suggestion
+				changed = true
+			}
+		}
+		return fixed
+	}
+
+	corrected := q
+	corrected.Terms = fix(q.Terms)
+	corrected.Fields = make(map[string][]Term, len(q.Fields))
+	for column, terms := range q.Fields {
+		corrected.Fields[column] = fix(terms)
+	}
+	return corrected, changed
+}
+
+// suggest returns the closest vocabulary term to word, or "" if word is known or nothing is close
+func (ix *Index) suggest(word string) string {
+	length := utf8.RuneCountInString(word)
+	maxDistance := 1
+	switch {
+	case length < 4:
+		return ""
+	case length > 6:
+		maxDistance = 2
+	}
+
+	var known int64
+	ix.db.Raw("SELECT COUNT(*) FROM "+vocabTable+" WHERE term >= ? AND term < ?", word, word+"\uffff").Scan(&known)
+	if known > 0 {
+		return ""
+	}
+
+	// Only consider terms sharing the first letter; typos there are rare and it keeps the scan small
+	first, size := utf8.DecodeRuneInString(word)
+	prefix := word[:size]
+	var candidates []struct {
+		Term string
+		Doc  int
+	}
+	ix.db.Raw("SELECT term, doc FROM "+vocabTable+" WHERE term >= ? AND term < ? AND length(term) BETWEEN ? AND ?",
+		prefix, string(first+1), length-maxDistance, length+maxDistance).Scan(&candidates)
+
+	best := ""
+	bestDistance := maxDistance + 1
+	bestDocs := 0
+	for _, c := range candidates {
+		d := levenshtein(word, c.Term)
+		if d < bestDistance || (d == bestDistance && c.Doc > bestDocs) {
+			best, bestDistance, bestDocs = c.Term, d, c.Doc
+		}
+	}
+	if bestDistance > maxDistance {
+		return ""
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
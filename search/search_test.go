@@ -0,0 +1,269 @@
+package search
+
+import (
+	"testing"
+
+	"vinylfo/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestIndex(t *testing.T) (*gorm.DB, *Index) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite memory db: %v", err)
+	}
+	// Each pooled connection to :memory: would get its own empty database
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(&models.Album{}, &models.Track{}, &models.Artist{}, &models.Credit{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	ix := NewIndex(db)
+	if _, err := ix.EnsureSchema(); err != nil {
+		t.Fatalf("ensure schema: %v", err)
+	}
+	models.SetSearchIndexer(ix)
+	t.Cleanup(func() { models.SetSearchIndexer(nil) })
+
+	return db, ix
+}
+
+func seedAlbum(t *testing.T, db *gorm.DB, album models.Album, trackTitles ...string) models.Album {
+	t.Helper()
+
+	if err := db.Create(&album).Error; err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	for i, title := range trackTitles {
+		track := models.Track{AlbumID: album.ID, Title: title, TrackNumber: i + 1}
+		if err := db.Create(&track).Error; err != nil {
+			t.Fatalf("create track: %v", err)
+		}
+	}
+	return album
+}
+
+func TestParseQuery(t *testing.T) {
+	q := ParseQuery(`dark side artist:"pink floyd" label:Harvest year:1970..1979 type:album`)
+
+	if len(q.Terms) != 2 || q.Terms[0].Text != "dark" || q.Terms[1].Text != "side" {
+		t.Errorf("Terms = %+v, want [dark side]", q.Terms)
+	}
+	if got := q.Fields["artist"]; len(got) != 1 || got[0].Text != "pink floyd" || !got[0].Phrase {
+		t.Errorf("artist field = %+v, want phrase \"pink floyd\"", got)
+	}
+	if got := q.Fields["label"]; len(got) != 1 || got[0].Text != "Harvest" {
+		t.Errorf("label field = %+v, want Harvest", got)
+	}
+	if q.YearFrom != 1970 || q.YearTo != 1979 {
+		t.Errorf("year range = %d..%d, want 1970..1979", q.YearFrom, q.YearTo)
+	}
+	if len(q.Types) != 1 || q.Types[0] != DocAlbum {
+		t.Errorf("Types = %v, want [album]", q.Types)
+	}
+}
+
+func TestParseQuery_YearForms(t *testing.T) {
+	tests := []struct {
+		input    string
+		from, to int
+	}{
+		{"year:1975", 1975, 1975},
+		{"year:1980..", 1980, 0},
+		{"year:..1969", 0, 1969},
+		{"year:1979..1970", 1970, 1979},
+		{"year:abc", 0, 0},
+	}
+
+	for _, tt := range tests {
+		q := ParseQuery(tt.input)
+		if q.YearFrom != tt.from || q.YearTo != tt.to {
+			t.Errorf("ParseQuery(%q) year = %d..%d, want %d..%d", tt.input, q.YearFrom, q.YearTo, tt.from, tt.to)
+		}
+	}
+}
+
+func TestParseQuery_UnknownQualifierIsText(t *testing.T) {
+	q := ParseQuery("re:mix")
+	if len(q.Terms) != 1 || q.Terms[0].Text != "re:mix" || len(q.Fields) != 0 {
+		t.Errorf("ParseQuery(re:mix) = %+v, want a single free-text term", q)
+	}
+}
+
+func TestQuery_FTSExpression(t *testing.T) {
+	q := ParseQuery(`wish artist:"pink floyd"`)
+	want := `"wish"* AND artist : "pink floyd"`
+	if got := q.ftsExpression(); got != want {
+		t.Errorf("ftsExpression() = %q, want %q", got, want)
+	}
+}
+
+func TestIndex_HooksKeepIndexInSync(t *testing.T) {
+	db, ix := newTestIndex(t)
+
+	album := seedAlbum(t, db, models.Album{Title: "Wish You Were Here", Artist: "Pink Floyd", Label: "Harvest", ReleaseYear: 1975},
+		"Shine On You Crazy Diamond", "Welcome to the Machine")
+
+	results, err := ix.Search(ParseQuery("machine"), Options{})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if results.Total != 1 || results.Hits[0].Type != DocTrack || results.Hits[0].Artist != "Pink Floyd" {
+		t.Fatalf("Search(machine) = %+v, want the track hit", results)
+	}
+
+	// Renaming the album artist must flow through to its track documents
+	album.Artist = "The Pink Floyd"
+	if err := db.Save(&album).Error; err != nil {
+		t.Fatalf("save album: %v", err)
+	}
+	results, _ = ix.Search(ParseQuery("artist:the machine"), Options{})
+	if results.Total != 1 {
+		t.Errorf("after rename Search(artist:the machine).Total = %d, want 1", results.Total)
+	}
+
+	if err := db.Where("album_id = ?", album.ID).Delete(&models.Track{}).Error; err != nil {
+		t.Fatalf("delete tracks: %v", err)
+	}
+	results, _ = ix.Search(ParseQuery("machine"), Options{})
+	if results.Total != 0 {
+		t.Errorf("after batch delete Search(machine).Total = %d, want 0", results.Total)
+	}
+
+	if err := db.Delete(&album).Error; err != nil {
+		t.Fatalf("delete album: %v", err)
+	}
+	results, _ = ix.Search(ParseQuery("wish"), Options{})
+	if results.Total != 0 {
+		t.Errorf("after delete Search(wish).Total = %d, want 0", results.Total)
+	}
+}
+
+func TestIndex_SearchRanksAndFilters(t *testing.T) {
+	db, ix := newTestIndex(t)
+
+	seedAlbum(t, db, models.Album{Title: "Blue", Artist: "Joni Mitchell", Label: "Reprise", ReleaseYear: 1971}, "California")
+	seedAlbum(t, db, models.Album{Title: "Kind of Blue", Artist: "Miles Davis", Label: "Columbia", ReleaseYear: 1959}, "So What")
+	seedAlbum(t, db, models.Album{Title: "Homogenic", Artist: "Björk", Label: "One Little Indian", ReleaseYear: 1997}, "Jóga")
+
+	results, err := ix.Search(ParseQuery("blue type:album"), Options{})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if results.Total != 2 || results.Hits[0].Title != "Blue" {
+		t.Errorf("Search(blue) = %+v, want the exact title ranked first", results.Hits)
+	}
+
+	results, _ = ix.Search(ParseQuery("blue year:1950..1960"), Options{})
+	for _, hit := range results.Hits {
+		if hit.Artist != "Miles Davis" {
+			t.Errorf("Search(blue year:1950..1960) returned %q by %s, want only Kind of Blue and its tracks", hit.Title, hit.Artist)
+		}
+	}
+
+	results, _ = ix.Search(ParseQuery("label:reprise"), Options{Types: []string{DocAlbum}})
+	if results.Total != 1 || results.Hits[0].Title != "Blue" {
+		t.Errorf("Search(label:reprise) = %+v, want Blue", results.Hits)
+	}
+
+	// Accents are folded on both sides
+	results, _ = ix.Search(ParseQuery("bjork joga"), Options{})
+	if results.Total != 1 || results.Hits[0].Title != "Jóga" {
+		t.Errorf("Search(bjork joga) = %+v, want Jóga", results.Hits)
+	}
+}
+
+func TestIndex_SearchPagesAndExcludes(t *testing.T) {
+	db, ix := newTestIndex(t)
+
+	seedAlbum(t, db, models.Album{Title: "Blue Train", Artist: "John Coltrane", ReleaseYear: 1957}, "Blue Train", "Moment's Notice", "Locomotion", "I'm Old Fashioned", "Lazy Bird")
+	var tracks []models.Track
+	db.Order("track_number").Find(&tracks)
+
+	opts := Options{Types: []string{DocTrack}, Limit: 2, Offset: 2, Exclude: []uint{tracks[0].ID}}
+	results, err := ix.Search(ParseQuery("coltrane"), opts)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if results.Total != 4 || len(results.Hits) != 2 {
+		t.Fatalf("Search(coltrane) = %d total, %d hits, want 4 total and a page of 2", results.Total, len(results.Hits))
+	}
+	for _, hit := range results.Hits {
+		if hit.ID == tracks[0].ID {
+			t.Errorf("excluded track %d returned", hit.ID)
+		}
+	}
+}
+
+func TestIndex_SearchMatchesCredits(t *testing.T) {
+	db, ix := newTestIndex(t)
+
+	album := seedAlbum(t, db, models.Album{Title: "Let's Dance", Artist: "David Bowie", ReleaseYear: 1983}, "Modern Love", "China Girl")
+	var tracks []models.Track
+	db.Where("album_id = ?", album.ID).Order("track_number").Find(&tracks)
+
+	producer := models.Artist{Name: "Nile Rodgers"}
+	guitarist := models.Artist{Name: "Stevie Ray Vaughan"}
+	db.Create(&producer)
+	db.Create(&guitarist)
+	db.Create(&models.Credit{ArtistID: producer.ID, AlbumID: album.ID, Role: models.CreditRoleProducer})
+	db.Create(&models.Credit{ArtistID: guitarist.ID, AlbumID: album.ID, TrackID: &tracks[1].ID, Role: models.CreditRolePerformer})
+	models.ReindexAlbum(db, album.ID)
+
+	results, err := ix.Search(ParseQuery("credit:rodgers type:album"), Options{})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if results.Total != 1 || results.Hits[0].Title != "Let's Dance" {
+		t.Errorf("Search(credit:rodgers) = %+v, want the album", results.Hits)
+	}
+
+	// Track credits only match the credited track
+	results, _ = ix.Search(ParseQuery("credit:vaughan type:track"), Options{})
+	if results.Total != 1 || results.Hits[0].Title != "China Girl" {
+		t.Errorf("Search(credit:vaughan type:track) = %+v, want China Girl", results.Hits)
+	}
+}
+
+func TestIndex_SearchCorrectsTypos(t *testing.T) {
+	db, ix := newTestIndex(t)
+
+	seedAlbum(t, db, models.Album{Title: "Rumours", Artist: "Fleetwood Mac", ReleaseYear: 1977}, "Dreams")
+
+	results, err := ix.Search(ParseQuery("fleetwod"), Options{})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if results.Total == 0 {
+		t.Fatalf("Search(fleetwod) found nothing, want a corrected match")
+	}
+	if results.CorrectedQuery != "fleetwood" {
+		t.Errorf("CorrectedQuery = %q, want %q", results.CorrectedQuery, "fleetwood")
+	}
+}
+
+func TestIndex_Rebuild(t *testing.T) {
+	db, ix := newTestIndex(t)
+
+	models.SetSearchIndexer(nil)
+	seedAlbum(t, db, models.Album{Title: "Low", Artist: "David Bowie", ReleaseYear: 1977}, "Warszawa")
+
+	results, _ := ix.Search(ParseQuery("warszawa"), Options{})
+	if results.Total != 0 {
+		t.Fatalf("expected empty index before rebuild, got %d hits", results.Total)
+	}
+
+	if err := ix.Rebuild(); err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	results, _ = ix.Search(ParseQuery("warszawa"), Options{})
+	if results.Total != 1 {
+		t.Errorf("after rebuild Search(warszawa).Total = %d, want 1", results.Total)
+	}
+}
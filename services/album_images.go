@@ -0,0 +1,299 @@
+package services
+
+import (
+	"log"
+
+	"vinylfo/coverart"
+	"vinylfo/imagestore"
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// ReleaseImage is an image listed on a Discogs release
+type ReleaseImage struct {
+	URI    string
+	Type   string // primary or secondary
+	Width  int
+	Height int
+}
+
+// ReleaseImagesFromData reads the "images" list of an album from the Discogs client
+func ReleaseImagesFromData(v interface{}) []ReleaseImage {
+	list, ok := v.([]map[string]interface{})
+	if !ok {
+		return nil
+	}
+	images := make([]ReleaseImage, 0, len(list))
+	for _, m := range list {
+		img := ReleaseImage{}
+		img.URI, _ = m["uri"].(string)
+		img.Type, _ = m["type"].(string)
+		img.Width, _ = m["width"].(int)
+		img.Height, _ = m["height"].(int)
+		if img.URI != "" {
+			images = append(images, img)
+		}
+	}
+	return images
+}
+
+// StoreCoverImage keeps a downloaded cover in the image store and returns its hash
+// Without a store, or when the image cannot be stored, the data is returned to be kept in the
+// albums table as before
+func StoreCoverImage(data []byte, contentType string) (hash string, blob []byte) {
+	store := imagestore.Default()
+	if store == nil || len(data) == 0 {
+		return "", data
+	}
+	info, err := store.Put(data)
+	if err != nil {
+		log.Printf("imagestore: failed to store cover (%s): %v", contentType, err)
+		return "", data
+	}
+	return info.Hash, nil
+}
+
+// CoverImageUpdates returns the album columns that point at a downloaded Discogs cover
+func CoverImageUpdates(data []byte, contentType string) map[string]interface{} {
+	hash, blob := StoreCoverImage(data, contentType)
+	updates := map[string]interface{}{
+		"cover_image_hash":         hash,
+		"discogs_cover_image":      blob,
+		"discogs_cover_image_type": contentType,
+		"cover_image_failed":       false,
+		"cover_image_source":       coverart.SourceDiscogs,
+		"cover_image_width":        0,
+		"cover_image_height":       0,
+	}
+	for column, value := range CoverPaletteUpdates(hash) {
+		updates[column] = value
+	}
+	return updates
+}
+
+// CoverPalette extracts the palette of a stored cover
+// The palette is empty when there is no store or the image cannot be decoded
+func CoverPalette(hash string) models.CoverPalette {
+	store := imagestore.Default()
+	if store == nil || hash == "" {
+		return models.CoverPalette{}
+	}
+	palette, err := store.Palette(hash)
+	if err != nil {
+		log.Printf("imagestore: no palette for %s: %v", hash, err)
+		return models.CoverPalette{}
+	}
+	return models.CoverPalette{
+		Dominant: palette.Dominant,
+		Vibrant:  palette.Vibrant,
+		Muted:    palette.Muted,
+		Text:     palette.Text,
+	}
+}
+
+// CoverPaletteUpdates returns the album columns holding the palette of a stored cover
+func CoverPaletteUpdates(hash string) map[string]interface{} {
+	palette := CoverPalette(hash)
+	return map[string]interface{}{
+		"palette_dominant": palette.Dominant,
+		"palette_vibrant":  palette.Vibrant,
+		"palette_muted":    palette.Muted,
+		"palette_text":     palette.Text,
+	}
+}
+
+// releaseImageKind guesses what an image shows from its place on the release: Discogs marks the
+// front cover as primary, and the back cover is usually the first image after it
+// Discogs gives nothing else to tell labels and inner sleeves apart, so they stay other until
+// reclassified
+func releaseImageKind(img ReleaseImage, secondaryIndex int) string {
+	if img.Type == "primary" {
+		return models.ImageFront
+	}
+	if secondaryIndex == 0 {
+		return models.ImageBack
+	}
+	return models.ImageOther
+}
+
+// SaveReleaseImages downloads the images of a Discogs release into the image store and lists
+// them as the album's images
+// Images already downloaded from the same URL are not fetched again and keep the kind they were
+// given; images no longer on the release are dropped from the album
+func (i *AlbumImporter) SaveReleaseImages(db *gorm.DB, albumID uint, images []ReleaseImage) (int, error) {
+	store := imagestore.Default()
+	if store == nil || len(images) == 0 {
+		return 0, nil
+	}
+
+	var existing []models.AlbumImage
+	if err := db.Where("album_id = ?", albumID).Find(&existing).Error; err != nil {
+		return 0, err
+	}
+	byURL := make(map[string]models.AlbumImage, len(existing))
+	for _, row := range existing {
+		byURL[row.SourceURL] = row
+	}
+
+	saved := 0
+	kept := make(map[uint]bool)
+	secondary := 0
+	for position, img := range images {
+		kind := releaseImageKind(img, secondary)
+		if img.Type != "primary" {
+			secondary++
+		}
+
+		if row, ok := byURL[img.URI]; ok && store.Has(row.Hash) {
+			kept[row.ID] = true
+			if row.Position != position || row.DiscogsType != img.Type {
+				db.Model(&row).Updates(map[string]interface{}{"position": position, "discogs_type": img.Type})
+			}
+			continue
+		}
+
+		data, _, err := i.DownloadCoverImageWithRetry(img.URI, 2)
+		if err != nil || len(data) == 0 {
+			log.Printf("SaveReleaseImages: failed to download image %d of album %d: %v", position+1, albumID, err)
+			continue
+		}
+		info, err := store.Put(data)
+		if err != nil {
+			log.Printf("SaveReleaseImages: failed to store image %d of album %d: %v", position+1, albumID, err)
+			continue
+		}
+
+		row := models.AlbumImage{
+			AlbumID:     albumID,
+			Position:    position,
+			Kind:        kind,
+			DiscogsType: img.Type,
+			SourceURL:   img.URI,
+			Hash:        info.Hash,
+			ContentType: info.ContentType,
+			Width:       info.Width,
+			Height:      info.Height,
+			Size:        info.Size,
+		}
+		if old, ok := byURL[img.URI]; ok {
+			row.ID = old.ID
+			row.Kind = old.Kind
+			row.CreatedAt = old.CreatedAt
+		}
+		if err := db.Save(&row).Error; err != nil {
+			return saved, err
+		}
+		kept[row.ID] = true
+		saved++
+	}
+
+	for _, row := range existing {
+		if !kept[row.ID] {
+			db.Delete(&row)
+		}
+	}
+	return saved, nil
+}
+
+// albumImageHash returns the stored hash of an image of the album downloaded from url
+func albumImageHash(db *gorm.DB, albumID uint, url string) string {
+	var row models.AlbumImage
+	if err := db.Where("album_id = ? AND source_url = ?", albumID, url).First(&row).Error; err != nil {
+		return ""
+	}
+	if store := imagestore.Default(); store == nil || !store.Has(row.Hash) {
+		return ""
+	}
+	return row.Hash
+}
+
+const coverMigrationBatch = 50
+
+// MigrateCoverBlobs moves covers still kept in the albums table into the image store
+// Returns how many were moved; covers that cannot be stored stay where they are
+func MigrateCoverBlobs(db *gorm.DB) (int, error) {
+	store := imagestore.Default()
+	if store == nil {
+		return 0, nil
+	}
+
+	type coverRow struct {
+		ID                    uint
+		CoverImageHash        string
+		DiscogsCoverImage     []byte
+		DiscogsCoverImageType string
+	}
+
+	moved := 0
+	var lastID uint
+	for {
+		var rows []coverRow
+		err := db.Model(&models.Album{}).
+			Select("id, cover_image_hash, discogs_cover_image, discogs_cover_image_type").
+			Where("id > ? AND discogs_cover_image IS NOT NULL", lastID).
+			Order("id").Limit(coverMigrationBatch).
+			Find(&rows).Error
+		if err != nil {
+			return moved, err
+		}
+		if len(rows) == 0 {
+			return moved, nil
+		}
+
+		for _, row := range rows {
+			lastID = row.ID
+			updates := map[string]interface{}{"discogs_cover_image": nil}
+			if len(row.DiscogsCoverImage) > 0 {
+				info, err := store.Put(row.DiscogsCoverImage)
+				if err != nil {
+					log.Printf("MigrateCoverBlobs: keeping cover of album %d in the database: %v", row.ID, err)
+					continue
+				}
+				if row.CoverImageHash == "" {
+					updates["cover_image_hash"] = info.Hash
+					for column, value := range CoverPaletteUpdates(info.Hash) {
+						updates[column] = value
+					}
+				}
+				moved++
+			}
+			if err := db.Model(&models.Album{}).Where("id = ?", row.ID).UpdateColumns(updates).Error; err != nil {
+				return moved, err
+			}
+		}
+	}
+}
+
+// FillCoverPalettes extracts the palette of stored covers that have none yet, such as covers
+// stored before palettes were kept
+func FillCoverPalettes(db *gorm.DB) (int, error) {
+	if imagestore.Default() == nil {
+		return 0, nil
+	}
+
+	filled := 0
+	var lastID uint
+	for {
+		var albums []models.Album
+		err := db.Select("id, cover_image_hash").
+			Where("id > ? AND cover_image_hash <> '' AND (palette_dominant = '' OR palette_dominant IS NULL)", lastID).
+			Order("id").Limit(coverMigrationBatch).
+			Find(&albums).Error
+		if err != nil || len(albums) == 0 {
+			return filled, err
+		}
+
+		for _, album := range albums {
+			lastID = album.ID
+			updates := CoverPaletteUpdates(album.CoverImageHash)
+			if updates["palette_dominant"] == "" {
+				continue
+			}
+			if err := db.Model(&models.Album{}).Where("id = ?", album.ID).UpdateColumns(updates).Error; err != nil {
+				return filled, err
+			}
+			filled++
+		}
+	}
+}
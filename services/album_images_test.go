@@ -0,0 +1,145 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"vinylfo/imagestore"
+	"vinylfo/models"
+)
+
+func newTestImageStore(t *testing.T) *imagestore.Store {
+	t.Helper()
+	store, err := imagestore.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("open image store: %v", err)
+	}
+	imagestore.SetDefault(store)
+	t.Cleanup(func() { imagestore.SetDefault(nil) })
+	return store
+}
+
+func pngBytes(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestMigrateCoverBlobs_MovesCoversToTheStore(t *testing.T) {
+	db := newTestDB(t)
+	store := newTestImageStore(t)
+	cover := pngBytes(t, 10, 10)
+
+	withBlob := models.Album{Title: "Blue Train", Artist: "John Coltrane", DiscogsCoverImage: cover, DiscogsCoverImageType: "image/png"}
+	broken := models.Album{Title: "Kind of Blue", Artist: "Miles Davis", DiscogsCoverImage: []byte("not an image")}
+	db.Create(&withBlob)
+	db.Create(&broken)
+
+	moved, err := MigrateCoverBlobs(db)
+	if err != nil || moved != 1 {
+		t.Fatalf("MigrateCoverBlobs() = %d, %v", moved, err)
+	}
+
+	var got models.Album
+	db.First(&got, withBlob.ID)
+	if got.CoverImageHash != imagestore.Hash(cover) || len(got.DiscogsCoverImage) != 0 || !store.Has(got.CoverImageHash) {
+		t.Errorf("album after migration: hash=%q blob=%d bytes", got.CoverImageHash, len(got.DiscogsCoverImage))
+	}
+	if got.CoverPalette.Dominant != "#000000" || got.CoverPalette.Text != "#ffffff" {
+		t.Errorf("palette after migration = %+v, want the black cover's", got.CoverPalette)
+	}
+	var kept models.Album
+	db.First(&kept, broken.ID)
+	if len(kept.DiscogsCoverImage) == 0 {
+		t.Error("a cover that could not be stored was dropped")
+	}
+}
+
+func TestFillCoverPalettes(t *testing.T) {
+	db := newTestDB(t)
+	store := newTestImageStore(t)
+	info, err := store.Put(pngBytes(t, 10, 10))
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	stored := models.Album{Title: "Blue Train", Artist: "John Coltrane", CoverImageHash: info.Hash}
+	missing := models.Album{Title: "Kind of Blue", Artist: "Miles Davis", CoverImageHash: imagestore.Hash([]byte("gone"))}
+	db.Create(&stored)
+	db.Create(&missing)
+
+	filled, err := FillCoverPalettes(db)
+	if err != nil || filled != 1 {
+		t.Fatalf("FillCoverPalettes() = %d, %v, want 1", filled, err)
+	}
+	var got models.Album
+	db.First(&got, stored.ID)
+	if got.CoverPalette.Dominant != "#000000" {
+		t.Errorf("palette = %+v", got.CoverPalette)
+	}
+
+	if filled, _ := FillCoverPalettes(db); filled != 0 {
+		t.Errorf("second FillCoverPalettes() filled %d albums again", filled)
+	}
+}
+
+func TestSaveReleaseImages_StoresGalleryOnce(t *testing.T) {
+	db := newTestDB(t)
+	db.AutoMigrate(&models.AlbumImage{})
+	newTestImageStore(t)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "image/png")
+		switch r.URL.Path {
+		case "/front.png":
+			w.Write(pngBytes(t, 20, 20))
+		case "/back.png":
+			w.Write(pngBytes(t, 20, 21))
+		default:
+			w.Write(pngBytes(t, 20, 22))
+		}
+	}))
+	defer server.Close()
+
+	album := models.Album{Title: "Blue Train", Artist: "John Coltrane"}
+	db.Create(&album)
+	images := []ReleaseImage{
+		{URI: server.URL + "/front.png", Type: "primary"},
+		{URI: server.URL + "/back.png", Type: "secondary"},
+		{URI: server.URL + "/label.png", Type: "secondary"},
+	}
+
+	importer := NewAlbumImporter(db, nil)
+	saved, err := importer.SaveReleaseImages(db, album.ID, images)
+	if err != nil || saved != 3 {
+		t.Fatalf("SaveReleaseImages() = %d, %v", saved, err)
+	}
+
+	var rows []models.AlbumImage
+	db.Where("album_id = ?", album.ID).Order("position").Find(&rows)
+	kinds := []string{rows[0].Kind, rows[1].Kind, rows[2].Kind}
+	if kinds[0] != models.ImageFront || kinds[1] != models.ImageBack || kinds[2] != models.ImageOther {
+		t.Errorf("kinds = %v", kinds)
+	}
+
+	// A user reclassifies the label; syncing again keeps it and downloads nothing
+	db.Model(&rows[2]).Update("kind", models.ImageLabel)
+	saved, _ = importer.SaveReleaseImages(db, album.ID, images[:2])
+	if saved != 0 || requests != 3 {
+		t.Errorf("second sync saved %d images with %d requests in total", saved, requests)
+	}
+	var count int64
+	db.Model(&models.AlbumImage{}).Where("album_id = ?", album.ID).Count(&count)
+	if count != 2 {
+		t.Errorf("album has %d images after the label left the release, want 2", count)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"vinylfo/config"
+	"vinylfo/coverart"
 	"vinylfo/discogs"
 	"vinylfo/models"
 	"vinylfo/utils"
@@ -80,6 +81,7 @@ type AlbumInput struct {
 	Style       string
 	CoverImage  string
 	DiscogsID   int
+	MasterID    int // Discogs master release, shared by all editions of the album
 	FolderID    int
 }
 
@@ -226,6 +228,7 @@ func (i *AlbumImporter) CreateAlbumWithTracks(input AlbumInput, tracks []TrackIn
 		Style:           input.Style,
 		CoverImageURL:   input.CoverImage,
 		DiscogsID:       utils.IntPtr(input.DiscogsID),
+		DiscogsMasterID: utils.IntPtr(input.MasterID),
 		DiscogsFolderID: input.FolderID,
 	}
 
@@ -235,8 +238,9 @@ func (i *AlbumImporter) CreateAlbumWithTracks(input AlbumInput, tracks []TrackIn
 		if err != nil {
 			album.CoverImageFailed = true
 		} else {
-			album.DiscogsCoverImage = imageData
+			album.CoverImageHash, album.DiscogsCoverImage = StoreCoverImage(imageData, imageType)
 			album.DiscogsCoverImageType = imageType
+			album.CoverPalette = CoverPalette(album.CoverImageHash)
 		}
 	}
 
@@ -364,8 +368,8 @@ func (i *AlbumImporter) FetchAndSaveTracks(db *gorm.DB, albumID uint, discogsID
 	}
 
 	// Fetch full album metadata
-	fullAlbumData, err := i.client.GetAlbum(discogsID)
-	if err == nil {
+	fullAlbumData, fullAlbumErr := i.client.GetAlbum(discogsID)
+	if fullAlbumErr == nil {
 		updates := make(map[string]interface{})
 
 		if v, ok := fullAlbumData["genre"].(string); ok && v != "" {
@@ -380,17 +384,34 @@ func (i *AlbumImporter) FetchAndSaveTracks(db *gorm.DB, albumID uint, discogsID
 		if v, ok := fullAlbumData["country"].(string); ok && v != "" {
 			updates["country"] = v
 		}
-		if v, ok := fullAlbumData["cover_image"].(string); ok && v != "" {
+		if v, ok := fullAlbumData["master_id"].(int); ok && v > 0 {
+			updates["discogs_master_id"] = v
+		}
+		if _, err := i.SaveReleaseImages(db, albumID, ReleaseImagesFromData(fullAlbumData["images"])); err != nil {
+			log.Printf("FetchAndSaveTracks: failed to save release images: %v", err)
+		}
+		var current models.Album
+		db.Select("id, cover_image_source").First(&current, albumID)
+		if v, ok := fullAlbumData["cover_image"].(string); ok && v != "" && syncsDiscogsCover(&current) {
 			updates["cover_image_url"] = v
 
-			imageData, imageType, imageErr := i.DownloadCoverImageWithRetry(v, 3)
-			if imageErr != nil {
+			if hash := albumImageHash(db, albumID, v); hash != "" {
+				updates["cover_image_hash"] = hash
+				updates["discogs_cover_image"] = nil
+				updates["cover_image_failed"] = false
+				updates["cover_image_source"] = coverart.SourceDiscogs
+				updates["cover_image_width"] = 0
+				updates["cover_image_height"] = 0
+				for column, value := range CoverPaletteUpdates(hash) {
+					updates[column] = value
+				}
+			} else if imageData, imageType, imageErr := i.DownloadCoverImageWithRetry(v, 3); imageErr != nil {
 				updates["cover_image_failed"] = true
 				log.Printf("FetchAndSaveTracks: failed to download cover image after 3 attempts: %v", imageErr)
 			} else if len(imageData) > 0 {
-				updates["discogs_cover_image"] = imageData
-				updates["discogs_cover_image_type"] = imageType
-				updates["cover_image_failed"] = false
+				for column, value := range CoverImageUpdates(imageData, imageType) {
+					updates[column] = value
+				}
 			}
 		}
 
@@ -477,6 +498,12 @@ func (i *AlbumImporter) FetchAndSaveTracks(db *gorm.DB, albumID uint, discogsID
 		}
 	}
 
+	if fullAlbumErr == nil {
+		if err := SaveReleaseCredits(db, albumID, fullAlbumData); err != nil {
+			log.Printf("FetchAndSaveTracks: failed to save credits for %s - %s: %v", artist, albumTitle, err)
+		}
+	}
+
 	return true, ""
 }
 
@@ -521,6 +548,9 @@ func (i *AlbumImporter) ImportFromDiscogs(discogsID int) (*models.Album, error)
 	if v, ok := discogsData["cover_image"].(string); ok {
 		input.CoverImage = v
 	}
+	if v, ok := discogsData["master_id"].(int); ok {
+		input.MasterID = v
+	}
 
 	// Parse tracks
 	var tracks []TrackInput
@@ -562,7 +592,14 @@ func (i *AlbumImporter) ImportFromDiscogs(discogsID int) (*models.Album, error)
 		}
 	}
 
-	return i.CreateAlbumWithTracks(input, tracks)
+	album, err := i.CreateAlbumWithTracks(input, tracks)
+	if err != nil {
+		return nil, err
+	}
+	if err := SaveReleaseCredits(i.db, album.ID, discogsData); err != nil {
+		log.Printf("ImportFromDiscogs: failed to save credits for album %d: %v", album.ID, err)
+	}
+	return album, nil
 }
 
 // parseDuration parses a duration from various formats
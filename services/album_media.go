@@ -0,0 +1,309 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"vinylfo/models"
+)
+
+// AlbumMedia holds the physical copy details carried by a Discogs collection item
+type AlbumMedia struct {
+	InstanceID       int
+	AddedAt          *time.Time
+	Rating           int
+	Format           string
+	FormatDetails    string
+	RPM              int
+	DiscCount        int
+	Label            string
+	CatalogNumber    string
+	MediaCondition   string
+	SleeveCondition  string
+	Barcode          string
+	MatrixRunout     string
+	PurchasePrice    *float64
+	PurchaseCurrency string
+	PurchaseDate     string
+	StorageLocation  string
+	Notes            string
+}
+
+// Discogs' built-in collection fields, used when the field list could not be fetched
+const (
+	discogsFieldMediaCondition  = 1
+	discogsFieldSleeveCondition = 2
+	discogsFieldNotes           = 3
+)
+
+// MediaFromCollectionItem extracts physical details from a collection release map
+// fieldNames maps collection field IDs to their names so custom fields such as
+// "Purchase Price" or "Storage Location" can be recognised; it may be nil
+func MediaFromCollectionItem(release map[string]interface{}, fieldNames map[int]string) AlbumMedia {
+	media := AlbumMedia{
+		InstanceID:    mapInt(release, "instance_id"),
+		AddedAt:       parseDateAdded(mapString(release, "date_added")),
+		Rating:        mapInt(release, "rating"),
+		Format:        mapString(release, "format"),
+		FormatDetails: mapString(release, "format_details"),
+		RPM:           mapInt(release, "rpm"),
+		DiscCount:     mapInt(release, "disc_count"),
+		Label:         mapString(release, "label"),
+		CatalogNumber: mapString(release, "catno"),
+	}
+
+	for id, value := range collectionNotes(release["notes"]) {
+		switch classifyCollectionField(id, fieldNames) {
+		case "media_condition":
+			media.MediaCondition = models.NormalizeGrade(value)
+		case "sleeve_condition":
+			media.SleeveCondition = models.NormalizeGrade(value)
+		case "notes":
+			media.Notes = value
+		case "purchase_price":
+			media.PurchasePrice, media.PurchaseCurrency = ParsePrice(value)
+		case "purchase_date":
+			media.PurchaseDate = NormalizeDate(value)
+		case "storage_location":
+			media.StorageLocation = value
+		case "barcode":
+			media.Barcode = value
+		case "matrix_runout":
+			media.MatrixRunout = value
+		case "catalog_number":
+			media.CatalogNumber = value
+		}
+	}
+
+	return media
+}
+
+// parseDateAdded reads a collection item's date_added, e.g. "2024-03-01T10:00:00-08:00"
+func parseDateAdded(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// Apply copies the details onto a new album
+func (m AlbumMedia) Apply(album *models.Album) {
+	album.DiscogsInstanceID = m.InstanceID
+	album.DiscogsAddedAt = m.AddedAt
+	album.Rating = m.Rating
+	album.Format = m.Format
+	album.FormatDetails = m.FormatDetails
+	album.RPM = m.RPM
+	album.DiscCount = m.DiscCount
+	if m.Label != "" {
+		album.Label = m.Label
+	}
+	album.CatalogNumber = m.CatalogNumber
+	album.MediaCondition = m.MediaCondition
+	album.SleeveCondition = m.SleeveCondition
+	album.Barcode = m.Barcode
+	album.MatrixRunout = m.MatrixRunout
+	album.PurchasePrice = m.PurchasePrice
+	album.PurchaseCurrency = m.PurchaseCurrency
+	album.PurchaseDate = m.PurchaseDate
+	album.StorageLocation = m.StorageLocation
+	album.Notes = m.Notes
+}
+
+// MissingUpdates returns column updates for details the album does not have yet
+// Values already on the album are left alone so local edits survive a re-sync
+func (m AlbumMedia) MissingUpdates(album *models.Album) map[string]interface{} {
+	updates := make(map[string]interface{})
+	setString := func(column, current, value string) {
+		if current == "" && value != "" {
+			updates[column] = value
+		}
+	}
+
+	setString("format", album.Format, m.Format)
+	setString("format_details", album.FormatDetails, m.FormatDetails)
+	setString("label", album.Label, m.Label)
+	setString("catalog_number", album.CatalogNumber, m.CatalogNumber)
+	setString("media_condition", album.MediaCondition, m.MediaCondition)
+	setString("sleeve_condition", album.SleeveCondition, m.SleeveCondition)
+	setString("barcode", album.Barcode, m.Barcode)
+	setString("matrix_runout", album.MatrixRunout, m.MatrixRunout)
+	setString("purchase_date", album.PurchaseDate, m.PurchaseDate)
+	setString("storage_location", album.StorageLocation, m.StorageLocation)
+	setString("notes", album.Notes, m.Notes)
+
+	if album.DiscogsInstanceID == 0 && m.InstanceID > 0 {
+		updates["discogs_instance_id"] = m.InstanceID
+	}
+	if album.DiscogsAddedAt == nil && m.AddedAt != nil {
+		updates["discogs_added_at"] = *m.AddedAt
+	}
+	if album.Rating == 0 && m.Rating > 0 {
+		updates["rating"] = m.Rating
+	}
+	if album.RPM == 0 && m.RPM > 0 {
+		updates["rpm"] = m.RPM
+	}
+	if album.DiscCount == 0 && m.DiscCount > 0 {
+		updates["disc_count"] = m.DiscCount
+	}
+	if album.PurchasePrice == nil && m.PurchasePrice != nil {
+		updates["purchase_price"] = *m.PurchasePrice
+		setString("purchase_currency", album.PurchaseCurrency, m.PurchaseCurrency)
+	}
+
+	return updates
+}
+
+// classifyCollectionField maps a Discogs collection field to the album column it feeds
+func classifyCollectionField(id int, fieldNames map[int]string) string {
+	name, ok := fieldNames[id]
+	if !ok {
+		switch id {
+		case discogsFieldMediaCondition:
+			return "media_condition"
+		case discogsFieldSleeveCondition:
+			return "sleeve_condition"
+		case discogsFieldNotes:
+			return "notes"
+		}
+		return ""
+	}
+
+	name = strings.ToLower(name)
+	switch {
+	case strings.Contains(name, "media") && strings.Contains(name, "condition"):
+		return "media_condition"
+	case strings.Contains(name, "sleeve") && strings.Contains(name, "condition"):
+		return "sleeve_condition"
+	case strings.Contains(name, "price") || strings.Contains(name, "paid") || strings.Contains(name, "cost"):
+		return "purchase_price"
+	case strings.Contains(name, "purchase") || strings.Contains(name, "bought") || strings.Contains(name, "acquired"):
+		return "purchase_date"
+	case strings.Contains(name, "location") || strings.Contains(name, "storage") || strings.Contains(name, "shelf"):
+		return "storage_location"
+	case strings.Contains(name, "barcode") || name == "upc" || name == "ean":
+		return "barcode"
+	case strings.Contains(name, "matrix") || strings.Contains(name, "runout"):
+		return "matrix_runout"
+	case strings.Contains(name, "catalog") || strings.Contains(name, "cat#") || strings.Contains(name, "catno"):
+		return "catalog_number"
+	case name == "notes" || name == "note":
+		return "notes"
+	}
+	return ""
+}
+
+// collectionNotes reads the notes value, which is map[int]string when fresh from
+// the Discogs client and map[string]interface{} after a JSON round trip through sync progress
+func collectionNotes(value interface{}) map[int]string {
+	switch notes := value.(type) {
+	case map[int]string:
+		return notes
+	case map[string]interface{}:
+		result := make(map[int]string, len(notes))
+		for k, v := range notes {
+			id, err := strconv.Atoi(k)
+			if s, ok := v.(string); ok && err == nil {
+				result[id] = s
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+var currencyCodes = []string{"USD", "EUR", "GBP", "JPY", "CAD", "AUD", "NZD", "CHF", "SEK", "NOK", "DKK"}
+
+// currencySymbols are checked in order after the ISO codes, prefixed dollars before the bare "$"
+var currencySymbols = []struct {
+	symbol, code string
+}{
+	{"US$", "USD"},
+	{"CA$", "CAD"},
+	{"C$", "CAD"},
+	{"NZ$", "NZD"},
+	{"A$", "AUD"},
+	{"€", "EUR"},
+	{"£", "GBP"},
+	{"¥", "JPY"},
+	{"$", "USD"},
+}
+
+var priceNumberPattern = regexp.MustCompile(`\d+(?:[.,]\d+)*`)
+
+// ParsePrice parses a free-form price such as "$24.99", "24,99 €" or "30 GBP"
+// It returns nil when no amount is present; the currency is "" when unknown
+func ParsePrice(value string) (*float64, string) {
+	number := priceNumberPattern.FindString(value)
+	if number == "" {
+		return nil, ""
+	}
+
+	// A separator followed by one or two digits is the decimal point; any others are grouping
+	if idx := strings.LastIndexAny(number, ".,"); idx >= 0 && len(number)-idx-1 <= 2 {
+		number = strings.NewReplacer(",", "", ".", "").Replace(number[:idx]) + "." + number[idx+1:]
+	} else {
+		number = strings.NewReplacer(",", "", ".", "").Replace(number)
+	}
+
+	amount, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return nil, ""
+	}
+
+	currency := ""
+	upper := strings.ToUpper(value)
+	for _, code := range currencyCodes {
+		if strings.Contains(upper, code) {
+			currency = code
+			break
+		}
+	}
+	if currency == "" {
+		for _, s := range currencySymbols {
+			if strings.Contains(upper, s.symbol) {
+				currency = s.code
+				break
+			}
+		}
+	}
+
+	return &amount, currency
+}
+
+var dateLayouts = []string{"2006-01-02", "2006/01/02", "01/02/2006", "1/2/2006", "02.01.2006", "Jan 2, 2006", "2 Jan 2006", "January 2, 2006", "2006-01", "2006"}
+
+// NormalizeDate converts common date spellings to YYYY-MM-DD ("" if unparseable)
+// Month- or year-only dates are anchored to the first day of the period
+func NormalizeDate(value string) string {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return ""
+}
+
+func mapString(m map[string]interface{}, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+// mapInt reads an int that may have become a float64 after a JSON round trip
+func mapInt(m map[string]interface{}, key string) int {
+	switch v := m[key].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return 0
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"vinylfo/models"
+)
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		name         string
+		value        string
+		wantAmount   float64
+		wantCurrency string
+		wantNil      bool
+	}{
+		{"dollar symbol", "$24.99", 24.99, "USD", false},
+		{"euro decimal comma", "24,99 €", 24.99, "EUR", false},
+		{"iso code", "30 GBP", 30, "GBP", false},
+		{"grouped thousands", "1,299.50 USD", 1299.50, "USD", false},
+		{"single decimal digit", "12.5", 12.5, "", false},
+		{"unknown suffix ignored", "15 at HMV", 15, "", false},
+		{"canadian dollar symbol", "C$25", 25, "CAD", false},
+		{"canadian dollar prefix", "CA$25", 25, "CAD", false},
+		{"australian dollar symbol", "A$40", 40, "AUD", false},
+		{"new zealand dollar symbol", "NZ$18", 18, "NZD", false},
+		{"us dollar prefix", "US$12", 12, "USD", false},
+		{"iso code before symbol", "25 CAD $", 25, "CAD", false},
+		{"lowercase prefix", "c$25", 25, "CAD", false},
+		{"no amount", "gift", 0, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, currency := ParsePrice(tt.value)
+			if tt.wantNil {
+				if amount != nil {
+					t.Fatalf("ParsePrice(%q) = %v, want nil", tt.value, *amount)
+				}
+				return
+			}
+			if amount == nil || *amount != tt.wantAmount {
+				t.Fatalf("ParsePrice(%q) amount = %v, want %v", tt.value, amount, tt.wantAmount)
+			}
+			if currency != tt.wantCurrency {
+				t.Errorf("ParsePrice(%q) currency = %q, want %q", tt.value, currency, tt.wantCurrency)
+			}
+		})
+	}
+}
+
+func TestNormalizeDate(t *testing.T) {
+	tests := map[string]string{
+		"2023-04-15":     "2023-04-15",
+		"2023/04/15":     "2023-04-15",
+		"04/15/2023":     "2023-04-15",
+		"Apr 15, 2023":   "2023-04-15",
+		"2023-04":        "2023-04-01",
+		"1998":           "1998-01-01",
+		"last tuesday":   "",
+		"":               "",
+		" 2023-04-15 \n": "2023-04-15",
+	}
+
+	for input, want := range tests {
+		if got := NormalizeDate(input); got != want {
+			t.Errorf("NormalizeDate(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestMediaFromCollectionItem(t *testing.T) {
+	release := map[string]interface{}{
+		"format":         "LP",
+		"format_details": "Album, Reissue",
+		"rpm":            33,
+		"disc_count":     2,
+		"label":          "Harvest",
+		"catno":          "SHVL 804",
+		"notes": map[int]string{
+			1: "Near Mint (NM or M-)",
+			2: "Very Good Plus (VG+)",
+			3: "Gatefold with poster",
+			4: "£18.00",
+			5: "2021-11-02",
+			6: "Shelf B3",
+		},
+	}
+	fields := map[int]string{
+		1: "Media Condition",
+		2: "Sleeve Condition",
+		3: "Notes",
+		4: "Price Paid",
+		5: "Purchase Date",
+		6: "Storage Location",
+	}
+
+	media := MediaFromCollectionItem(release, fields)
+	if media.Format != "LP" || media.RPM != 33 || media.DiscCount != 2 {
+		t.Errorf("format = %q/%d/%d, want LP/33/2", media.Format, media.RPM, media.DiscCount)
+	}
+	if media.CatalogNumber != "SHVL 804" {
+		t.Errorf("CatalogNumber = %q, want SHVL 804", media.CatalogNumber)
+	}
+	if media.MediaCondition != models.GradeNearMint || media.SleeveCondition != models.GradeVeryGoodPlus {
+		t.Errorf("conditions = %q/%q, want NM/VG+", media.MediaCondition, media.SleeveCondition)
+	}
+	if media.PurchasePrice == nil || *media.PurchasePrice != 18 || media.PurchaseCurrency != "GBP" {
+		t.Errorf("price = %v %q, want 18 GBP", media.PurchasePrice, media.PurchaseCurrency)
+	}
+	if media.PurchaseDate != "2021-11-02" || media.StorageLocation != "Shelf B3" || media.Notes != "Gatefold with poster" {
+		t.Errorf("got date %q, location %q, notes %q", media.PurchaseDate, media.StorageLocation, media.Notes)
+	}
+}
+
+func TestMediaFromCollectionItemAfterJSONRoundTrip(t *testing.T) {
+	original := map[string]interface{}{
+		"format":     `7"`,
+		"rpm":        45,
+		"disc_count": 1,
+		"notes":      map[int]string{1: "VG", 2: "Generic"},
+	}
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored map[string]interface{}
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without field names the default Discogs field IDs apply
+	media := MediaFromCollectionItem(restored, nil)
+	if media.Format != `7"` || media.RPM != 45 || media.DiscCount != 1 {
+		t.Errorf("format = %q/%d/%d, want 7\"/45/1", media.Format, media.RPM, media.DiscCount)
+	}
+	if media.MediaCondition != models.GradeVeryGood || media.SleeveCondition != models.SleeveGeneric {
+		t.Errorf("conditions = %q/%q, want VG/Generic", media.MediaCondition, media.SleeveCondition)
+	}
+}
+
+func TestMissingUpdatesKeepsLocalEdits(t *testing.T) {
+	price := 10.0
+	album := &models.Album{Format: "LP", MediaCondition: models.GradeMint, PurchasePrice: &price}
+	newPrice := 25.0
+	media := AlbumMedia{
+		Format:          "LP",
+		MediaCondition:  models.GradeVeryGood,
+		StorageLocation: "Crate 1",
+		PurchasePrice:   &newPrice,
+		RPM:             33,
+	}
+
+	updates := media.MissingUpdates(album)
+	if _, ok := updates["media_condition"]; ok {
+		t.Error("media_condition should not overwrite an existing grade")
+	}
+	if _, ok := updates["purchase_price"]; ok {
+		t.Error("purchase_price should not overwrite an existing price")
+	}
+	if updates["storage_location"] != "Crate 1" || updates["rpm"] != 33 {
+		t.Errorf("updates = %v, want storage_location and rpm filled in", updates)
+	}
+}
@@ -0,0 +1,512 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"vinylfo/audio"
+	"vinylfo/duration"
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// Library matching thresholds
+const (
+	libraryAlbumThreshold = 0.7 // Album tag and artist against the album
+	libraryTrackThreshold = 0.8 // Title and artist against the track, with position and duration bonuses
+)
+
+// ErrNoLibraryPaths is returned when a scan is started without any library folders configured
+var ErrNoLibraryPaths = errors.New("no audio library folders configured")
+
+// LibraryPaths returns the configured audio library folders
+func LibraryPaths(db *gorm.DB) []string {
+	var config models.AppConfig
+	if err := db.First(&config).Error; err != nil || config.AudioLibraryPaths == "" {
+		return nil
+	}
+	var paths []string
+	if err := json.Unmarshal([]byte(config.AudioLibraryPaths), &paths); err != nil {
+		log.Printf("LibraryPaths: invalid audio_library_paths: %v", err)
+		return nil
+	}
+	return paths
+}
+
+// LibraryScanResult summarizes a library scan
+type LibraryScanResult struct {
+	Files     int `json:"files"`
+	Added     int `json:"added"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Removed   int `json:"removed"`
+	Failed    int `json:"failed"` // Files whose tags could not be read
+	Linked    int `json:"linked"` // Files newly linked to a track
+}
+
+// AudioLibrary scans the local audio library folders and links the files it finds to tracks
+type AudioLibrary struct {
+	db *gorm.DB
+}
+
+func NewAudioLibrary(db *gorm.DB) *AudioLibrary {
+	return &AudioLibrary{db: db}
+}
+
+// libraryEntry is an audio file found on disk
+type libraryEntry struct {
+	path string
+	info fs.FileInfo
+}
+
+// walk lists the supported audio files under the library folders
+func (l *AudioLibrary) walk(ctx context.Context, roots []string) ([]libraryEntry, error) {
+	var entries []libraryEntry
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == root {
+					return err
+				}
+				log.Printf("AudioLibrary: skipping %s: %v", path, err)
+				if d != nil && d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if d.IsDir() {
+				if path != root && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if audio.FormatOf(path) == "" {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			entries = append(entries, libraryEntry{path: path, info: info})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+		}
+	}
+	return entries, nil
+}
+
+// Scan reads new and changed files and removes files that are gone
+// Unchanged files are skipped unless full is set; unlinked files are matched again either way,
+// since tracks may have been added since the last scan
+func (l *AudioLibrary) Scan(ctx context.Context, full bool, progress func(done, total int, last string) error) (*LibraryScanResult, error) {
+	roots := LibraryPaths(l.db)
+	if len(roots) == 0 {
+		return nil, ErrNoLibraryPaths
+	}
+
+	entries, err := l.walk(ctx, roots)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []models.AudioFile
+	if err := l.db.Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	known := make(map[string]*models.AudioFile, len(existing))
+	for i := range existing {
+		known[existing[i].Path] = &existing[i]
+	}
+
+	matcher, err := newLibraryMatcher(l.db)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LibraryScanResult{Files: len(entries)}
+	seen := make(map[string]bool, len(entries))
+	for i, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		seen[entry.path] = true
+
+		file, ok := known[entry.path]
+		switch {
+		case !ok:
+			file = &models.AudioFile{Path: entry.path}
+			result.Added++
+		case full || file.Size != entry.info.Size() || !file.ModTime.Equal(entry.info.ModTime()):
+			result.Updated++
+		default:
+			result.Unchanged++
+			l.matchUnlinked(file, matcher, result)
+			if err := reportProgress(progress, i+1, len(entries), entry.path); err != nil {
+				return result, err
+			}
+			continue
+		}
+
+		if err := l.read(file, entry.info); err != nil {
+			result.Failed++
+			file.ScanError = err.Error()
+			log.Printf("AudioLibrary: failed to read %s: %v", entry.path, err)
+		}
+		if err := l.db.Save(file).Error; err != nil {
+			return result, fmt.Errorf("failed to save %s: %w", entry.path, err)
+		}
+		l.matchUnlinked(file, matcher, result)
+
+		if err := reportProgress(progress, i+1, len(entries), entry.path); err != nil {
+			return result, err
+		}
+	}
+
+	for _, file := range existing {
+		if seen[file.Path] {
+			continue
+		}
+		if err := l.remove(&file); err != nil {
+			return result, err
+		}
+		result.Removed++
+	}
+	return result, nil
+}
+
+func reportProgress(progress func(done, total int, last string) error, done, total int, last string) error {
+	if progress == nil {
+		return nil
+	}
+	return progress(done, total, last)
+}
+
+// read fills a file's tags, stream details and checksum
+func (l *AudioLibrary) read(file *models.AudioFile, info fs.FileInfo) error {
+	file.Format = audio.FormatOf(file.Path)
+	file.Size = info.Size()
+	file.ModTime = info.ModTime()
+	file.ScannedAt = time.Now()
+	file.ScanError = ""
+
+	checksum, err := fileChecksum(file.Path)
+	if err != nil {
+		return err
+	}
+	file.Checksum = checksum
+
+	meta, err := audio.ReadFile(file.Path)
+	if err != nil {
+		return err
+	}
+	file.Title = meta.Title
+	file.Artist = meta.Artist
+	file.Album = meta.Album
+	file.AlbumArtist = meta.AlbumArtist
+	file.TrackNumber = meta.TrackNumber
+	file.DiscNumber = meta.DiscNumber
+	file.Year = meta.Year
+	file.Duration = int(meta.Duration.Round(time.Second) / time.Second)
+	file.Bitrate = meta.Bitrate
+	file.SampleRate = meta.SampleRate
+	file.Channels = meta.Channels
+	return nil
+}
+
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// matchUnlinked links a file to its best matching track, unless the user linked or unlinked it
+func (l *AudioLibrary) matchUnlinked(file *models.AudioFile, matcher *libraryMatcher, result *LibraryScanResult) {
+	if file.TrackID != nil || file.MatchMethod == models.AudioMatchManual {
+		return
+	}
+
+	track, score := matcher.match(file)
+	if track == nil {
+		return
+	}
+	if err := l.link(file, track, score, models.AudioMatchAuto); err != nil {
+		log.Printf("AudioLibrary: failed to link %s to track %d: %v", file.Path, track.ID, err)
+		return
+	}
+	matcher.taken[track.ID] = true
+	result.Linked++
+}
+
+// Link links a file to a track by hand; later scans keep the link
+func (l *AudioLibrary) Link(fileID, trackID uint) (*models.AudioFile, error) {
+	var file models.AudioFile
+	if err := l.db.First(&file, fileID).Error; err != nil {
+		return nil, err
+	}
+	var track models.Track
+	if err := l.db.First(&track, trackID).Error; err != nil {
+		return nil, err
+	}
+
+	// A track plays one file, so a file linked to it before is unlinked
+	var previous []models.AudioFile
+	l.db.Where("track_id = ? AND id <> ?", trackID, fileID).Find(&previous)
+	for i := range previous {
+		if err := l.unlink(&previous[i], models.AudioMatchManual); err != nil {
+			return nil, err
+		}
+	}
+	if file.TrackID != nil && *file.TrackID != trackID {
+		if err := l.unlink(&file, models.AudioMatchManual); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := l.link(&file, &track, 1, models.AudioMatchManual); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// Unlink detaches a file from its track; later scans leave it unlinked
+func (l *AudioLibrary) Unlink(fileID uint) (*models.AudioFile, error) {
+	var file models.AudioFile
+	if err := l.db.First(&file, fileID).Error; err != nil {
+		return nil, err
+	}
+	if err := l.unlink(&file, models.AudioMatchManual); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// link points the track at the file, leaving tracks that play from a web URL alone
+func (l *AudioLibrary) link(file *models.AudioFile, track *models.Track, score float64, method string) error {
+	return l.db.Transaction(func(tx *gorm.DB) error {
+		file.TrackID = &track.ID
+		file.MatchScore = score
+		file.MatchMethod = method
+		if err := tx.Model(file).Select("track_id", "match_score", "match_method").Updates(file).Error; err != nil {
+			return err
+		}
+
+		if track.AudioFileURL != "" && !strings.HasPrefix(track.AudioFileURL, "file:") {
+			return nil
+		}
+		track.AudioFileURL = audio.FileURL(file.Path)
+		return tx.Model(track).Update("audio_file_url", track.AudioFileURL).Error
+	})
+}
+
+func (l *AudioLibrary) unlink(file *models.AudioFile, method string) error {
+	return l.db.Transaction(func(tx *gorm.DB) error {
+		if file.TrackID != nil {
+			if err := tx.Model(&models.Track{}).
+				Where("id = ? AND audio_file_url = ?", *file.TrackID, audio.FileURL(file.Path)).
+				Update("audio_file_url", "").Error; err != nil {
+				return err
+			}
+		}
+
+		file.TrackID = nil
+		file.MatchScore = 0
+		file.MatchMethod = method
+		return tx.Model(file).Select("track_id", "match_score", "match_method").Updates(file).Error
+	})
+}
+
+// remove deletes a file that is no longer on disk
+func (l *AudioLibrary) remove(file *models.AudioFile) error {
+	if err := l.unlink(file, file.MatchMethod); err != nil {
+		return err
+	}
+	return l.db.Delete(file).Error
+}
+
+// Changed reports whether any file was added, modified or removed since the last scan,
+// without reading the files
+func (l *AudioLibrary) Changed(ctx context.Context) (bool, error) {
+	roots := LibraryPaths(l.db)
+	if len(roots) == 0 {
+		return false, nil
+	}
+
+	entries, err := l.walk(ctx, roots)
+	if err != nil {
+		return false, err
+	}
+
+	var existing []models.AudioFile
+	if err := l.db.Select("path", "size", "mod_time").Find(&existing).Error; err != nil {
+		return false, err
+	}
+	if len(existing) != len(entries) {
+		return true, nil
+	}
+	known := make(map[string]models.AudioFile, len(existing))
+	for _, file := range existing {
+		known[file.Path] = file
+	}
+	for _, entry := range entries {
+		file, ok := known[entry.path]
+		if !ok || file.Size != entry.info.Size() || !file.ModTime.Equal(entry.info.ModTime()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Watch checks the library folders for changes every interval and calls onChange when
+// there are any, until ctx is cancelled
+// Polling works the same on every platform and for network shares, which do not
+// reliably deliver file system events
+func (l *AudioLibrary) Watch(ctx context.Context, interval time.Duration, onChange func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := l.Changed(ctx)
+			if err != nil {
+				log.Printf("AudioLibrary: watch failed: %v", err)
+				continue
+			}
+			if changed {
+				onChange()
+			}
+		}
+	}
+}
+
+// libraryMatcher finds the track a file belongs to among the collection's albums
+type libraryMatcher struct {
+	db     *gorm.DB
+	albums []models.Album
+	tracks map[uint][]models.Track // Loaded per album as needed
+	taken  map[uint]bool           // Tracks already linked to a file
+}
+
+func newLibraryMatcher(db *gorm.DB) (*libraryMatcher, error) {
+	m := &libraryMatcher{db: db, tracks: make(map[uint][]models.Track), taken: make(map[uint]bool)}
+	if err := db.Select("id", "title", "artist").Find(&m.albums).Error; err != nil {
+		return nil, err
+	}
+
+	var linked []uint
+	if err := db.Model(&models.AudioFile{}).Where("track_id IS NOT NULL").Pluck("track_id", &linked).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range linked {
+		m.taken[id] = true
+	}
+	return m, nil
+}
+
+// Leading track numbers in file names, such as "01 - ", "A2. " or "1-03 "
+var fileTrackPrefix = regexp.MustCompile(`^(?:\d+-)?([A-Za-z]?)(\d{1,3})[\s._-]+`)
+
+// fileTags returns a file's title, artist, album and track number, falling back to its
+// path for tags it lacks: Artist/Album/01 - Title.ext
+func fileTags(file *models.AudioFile) (title, artist, album string, number int) {
+	title, artist, album, number = file.Title, file.AlbumArtist, file.Album, file.TrackNumber
+	if artist == "" {
+		artist = file.Artist
+	}
+
+	dir := filepath.Dir(file.Path)
+	if album == "" {
+		album = filepath.Base(dir)
+	}
+	if artist == "" {
+		artist = filepath.Base(filepath.Dir(dir))
+	}
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(file.Path), filepath.Ext(file.Path))
+		if m := fileTrackPrefix.FindStringSubmatch(title); m != nil {
+			title = title[len(m[0]):]
+			if number == 0 {
+				fmt.Sscanf(m[2], "%d", &number)
+			}
+		}
+	}
+	return title, artist, album, number
+}
+
+// match returns the best unlinked track for a file and its score, or nil if none is close enough
+func (m *libraryMatcher) match(file *models.AudioFile) (*models.Track, float64) {
+	title, artist, album, number := fileTags(file)
+	if title == "" || album == "" {
+		return nil, 0
+	}
+
+	var best *models.Track
+	var bestScore float64
+	for _, a := range m.albums {
+		if duration.CalculateMatchScore(album, artist, a.Title, a.Artist) < libraryAlbumThreshold {
+			continue
+		}
+
+		tracks, ok := m.tracks[a.ID]
+		if !ok {
+			m.db.Where("album_id = ?", a.ID).Find(&tracks)
+			m.tracks[a.ID] = tracks
+		}
+		for i := range tracks {
+			track := &tracks[i]
+			if m.taken[track.ID] {
+				continue
+			}
+
+			trackArtist := file.Artist
+			if trackArtist == "" {
+				trackArtist = artist
+			}
+			score := duration.CalculateMatchScore(title, trackArtist, track.Title, a.Artist)
+			if number > 0 && number == track.TrackNumber && (file.DiscNumber == 0 || track.DiscNumber == 0 || file.DiscNumber == track.DiscNumber) {
+				score += 0.1
+			}
+			if file.Duration > 0 && track.Duration > 0 && abs(file.Duration-track.Duration) <= 3 {
+				score += 0.1
+			}
+			score = min(score, 1)
+
+			if score > bestScore {
+				best, bestScore = track, score
+			}
+		}
+	}
+
+	if best == nil || bestScore < libraryTrackThreshold {
+		return nil, 0
+	}
+	return best, bestScore
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"vinylfo/audio"
+	"vinylfo/models"
+)
+
+// writeMP3 writes an untagged constant bitrate MP3 of about the given length
+func writeMP3(t *testing.T, path string, seconds int) {
+	t.Helper()
+	// MPEG-1 Layer III, 128 kbps, 44.1 kHz: 417 byte frames, 1152 samples each
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	frames := seconds * 44100 / 1152
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, bytes.Repeat(frame, frames), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAudioLibraryScan(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&models.AppConfig{}, &models.AudioFile{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	root := t.TempDir()
+	paths, _ := json.Marshal([]string{root})
+	db.Create(&models.AppConfig{AudioLibraryPaths: string(paths)})
+
+	album := models.Album{Title: "Kind of Blue", Artist: "Miles Davis"}
+	db.Create(&album)
+	soWhat := models.Track{AlbumID: album.ID, Title: "So What", TrackNumber: 1, Duration: 20}
+	freddie := models.Track{AlbumID: album.ID, Title: "Freddie Freeloader", TrackNumber: 2, Duration: 30}
+	streamed := models.Track{AlbumID: album.ID, Title: "Blue in Green", TrackNumber: 3, AudioFileURL: "https://example.com/blue.mp3"}
+	db.Create(&soWhat)
+	db.Create(&freddie)
+	db.Create(&streamed)
+
+	dir := filepath.Join(root, "Miles Davis", "Kind of Blue")
+	writeMP3(t, filepath.Join(dir, "01 - So What.mp3"), 20)
+	writeMP3(t, filepath.Join(dir, "03 - Blue in Green.mp3"), 10)
+	writeMP3(t, filepath.Join(root, "Unknown", "Other", "01 - Something Else.mp3"), 10)
+	os.WriteFile(filepath.Join(dir, "broken.flac"), []byte("not a flac file"), 0644)
+	os.WriteFile(filepath.Join(dir, "cover.jpg"), []byte("jpeg"), 0644)
+
+	library := NewAudioLibrary(db)
+	result, err := library.Scan(context.Background(), false, nil)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if result.Files != 4 || result.Added != 4 || result.Failed != 1 || result.Linked != 2 {
+		t.Errorf("Scan() = %+v, want 4 files added, 1 failed, 2 linked", result)
+	}
+
+	var file models.AudioFile
+	db.Where("path = ?", filepath.Join(dir, "01 - So What.mp3")).First(&file)
+	if file.Format != audio.FormatMP3 || file.Duration < 19 || file.Duration > 21 || file.Bitrate != 128 || len(file.Checksum) != 64 {
+		t.Errorf("file = %+v", file)
+	}
+	if file.TrackID == nil || *file.TrackID != soWhat.ID || file.MatchMethod != models.AudioMatchAuto {
+		t.Errorf("So What linked to %v (%s), want track %d", file.TrackID, file.MatchMethod, soWhat.ID)
+	}
+	db.First(&soWhat, soWhat.ID)
+	if soWhat.AudioFileURL != audio.FileURL(file.Path) {
+		t.Errorf("AudioFileURL = %q, want %q", soWhat.AudioFileURL, audio.FileURL(file.Path))
+	}
+	// Linked, but the web URL is kept
+	db.First(&streamed, streamed.ID)
+	if streamed.AudioFileURL != "https://example.com/blue.mp3" {
+		t.Errorf("streamed AudioFileURL = %q, want the web URL kept", streamed.AudioFileURL)
+	}
+
+	// Nothing changed: files are not read again
+	if changed, err := library.Changed(context.Background()); err != nil || changed {
+		t.Errorf("Changed() = %v, %v, want false", changed, err)
+	}
+	result, _ = library.Scan(context.Background(), false, nil)
+	if result.Unchanged != 4 || result.Updated != 0 || result.Linked != 0 {
+		t.Errorf("rescan = %+v, want 4 unchanged", result)
+	}
+
+	// A manual unlink sticks across scans
+	if _, err := library.Unlink(file.ID); err != nil {
+		t.Fatalf("Unlink() error = %v", err)
+	}
+	db.First(&soWhat, soWhat.ID)
+	if soWhat.AudioFileURL != "" {
+		t.Errorf("AudioFileURL after unlink = %q, want empty", soWhat.AudioFileURL)
+	}
+	if result, _ = library.Scan(context.Background(), true, nil); result.Linked != 0 || result.Updated != 4 {
+		t.Errorf("full rescan = %+v, want 4 updated, none linked", result)
+	}
+
+	// Linking by hand moves the track to the file
+	var other models.AudioFile
+	db.Where("path LIKE ?", "%Something Else.mp3").First(&other)
+	if _, err := library.Link(other.ID, freddie.ID); err != nil {
+		t.Fatalf("Link() error = %v", err)
+	}
+	db.First(&freddie, freddie.ID)
+	if freddie.AudioFileURL != audio.FileURL(other.Path) {
+		t.Errorf("AudioFileURL after link = %q", freddie.AudioFileURL)
+	}
+
+	// Deleted files are removed along with the track URL
+	os.Remove(other.Path)
+	if changed, _ := library.Changed(context.Background()); !changed {
+		t.Error("Changed() = false after deleting a file")
+	}
+	if result, _ = library.Scan(context.Background(), false, nil); result.Removed != 1 {
+		t.Errorf("scan after delete = %+v, want 1 removed", result)
+	}
+	db.First(&freddie, freddie.ID)
+	if freddie.AudioFileURL != "" {
+		t.Errorf("AudioFileURL after delete = %q, want empty", freddie.AudioFileURL)
+	}
+}
+
+func TestAudioLibraryScanWithoutPaths(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&models.AppConfig{}, &models.AudioFile{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+	db.Create(&models.AppConfig{})
+
+	if _, err := NewAudioLibrary(db).Scan(context.Background(), false, nil); err != ErrNoLibraryPaths {
+		t.Errorf("Scan() error = %v, want ErrNoLibraryPaths", err)
+	}
+}
@@ -0,0 +1,423 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+
+	"vinylfo/discogs"
+	"vinylfo/models"
+	"vinylfo/utils"
+
+	"gorm.io/gorm"
+)
+
+// Code types told apart by ClassifyCode
+const (
+	CodeTypeBarcode = "barcode"
+	CodeTypeCatNo   = "catno"
+)
+
+// MaxCodeImportBatch is the most codes a batch import takes at once
+const MaxCodeImportBatch = 500
+
+var (
+	// ErrNoReleaseFound is returned when Discogs has no release for a code
+	ErrNoReleaseFound = errors.New("no Discogs release found")
+	// ErrAlreadyInCollection is returned with the existing album when a code or release is already in the collection
+	ErrAlreadyInCollection = errors.New("already in the collection")
+)
+
+// codeListHeaders are the column names recognised in the header row of a scanner CSV
+var codeListHeaders = map[string]bool{
+	"barcode": true, "upc": true, "ean": true, "code": true,
+	"catno": true, "cat no": true, "catalog number": true, "catalog_number": true,
+}
+
+// CodeLookupSource is the part of the Discogs client code lookups search
+type CodeLookupSource interface {
+	SearchReleasesByCode(barcode, catno string) ([]discogs.ReleaseMatch, error)
+}
+
+// ImportProgress is called after each code of a batch with the number done and the code
+// just handled. Returning an error stops the batch
+type ImportProgress func(done, total int, last string) error
+
+// LookupPreferences rank releases from the preferred country and format first
+type LookupPreferences struct {
+	Country string `json:"country"`
+	Format  string `json:"format"` // A Discogs format such as Vinyl, LP or CD; empty means Vinyl, "any" means no preference
+}
+
+// RankedRelease is a release found for a code with its rank among the others
+type RankedRelease struct {
+	discogs.ReleaseMatch
+	Score        int   `json:"score"`
+	CodeMatch    bool  `json:"code_match"` // The release lists exactly the code searched for
+	CountryMatch bool  `json:"country_match"`
+	FormatMatch  bool  `json:"format_match"`
+	AlbumID      *uint `json:"album_id"` // Album already in the collection for this release
+}
+
+// CodeImportSummary counts the outcomes of a batch import
+type CodeImportSummary struct {
+	Total    int `json:"total"`
+	Imported int `json:"imported"`
+	Exists   int `json:"exists"`
+	NotFound int `json:"not_found"`
+	Failed   int `json:"failed"`
+}
+
+// CodeImporter finds Discogs releases by barcode or catalog number and imports them
+type CodeImporter struct {
+	db            *gorm.DB
+	source        CodeLookupSource
+	importRelease func(discogsID int) (*models.Album, error)
+}
+
+// NewCodeImporter creates a CodeImporter that imports releases through AlbumImporter
+func NewCodeImporter(db *gorm.DB, client *discogs.Client) *CodeImporter {
+	return &CodeImporter{
+		db:            db,
+		source:        client,
+		importRelease: NewAlbumImporter(db, client).ImportFromDiscogs,
+	}
+}
+
+// ClassifyCode tells a UPC/EAN barcode from a catalog number. Barcodes are returned as
+// digits only, with the spaces and dashes printed on sleeves removed
+func ClassifyCode(code string) (string, string) {
+	code = strings.TrimSpace(code)
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(code)
+	switch len(digits) {
+	case 8, 12, 13, 14:
+		if strings.Trim(digits, "0123456789") == "" {
+			return digits, CodeTypeBarcode
+		}
+	}
+	return code, CodeTypeCatNo
+}
+
+// normalizeCode reduces a barcode or catalog number to the characters that identify it,
+// so "0 7599-27313-1 4" matches the EAN-13 "0075992731314"
+func normalizeCode(code, codeType string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		if (r >= '0' && r <= '9') || (codeType == CodeTypeCatNo && r >= 'A' && r <= 'Z') {
+			b.WriteRune(r)
+		}
+	}
+	if codeType == CodeTypeBarcode {
+		return strings.TrimLeft(b.String(), "0")
+	}
+	return b.String()
+}
+
+// ParseCodeList reads codes from a scanner export: one per line, or a CSV whose header names
+// a barcode or catalog number column. Blank and repeated codes are dropped
+func ParseCodeList(r io.Reader) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read code list: %w", err)
+	}
+
+	column := 0
+	if len(records) > 0 {
+		for i, cell := range records[0] {
+			if codeListHeaders[strings.ToLower(strings.TrimSpace(cell))] {
+				column = i
+				records = records[1:]
+				break
+			}
+		}
+	}
+
+	codes := make([]string, 0, len(records))
+	seen := make(map[string]bool)
+	for _, record := range records {
+		if column >= len(record) {
+			continue
+		}
+		code := strings.TrimSpace(record[column])
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// RankReleases orders releases so those that list the exact code, come from the preferred
+// country and have the preferred format come first. Ties go to the more commonly owned pressing
+func RankReleases(matches []discogs.ReleaseMatch, code, codeType string, prefs LookupPreferences) []RankedRelease {
+	format := prefs.Format
+	if format == "" {
+		format = "Vinyl"
+	}
+	want := normalizeCode(code, codeType)
+
+	ranked := make([]RankedRelease, 0, len(matches))
+	for _, m := range matches {
+		r := RankedRelease{ReleaseMatch: m}
+		if codeType == CodeTypeBarcode {
+			for _, barcode := range m.Barcodes {
+				if normalizeCode(barcode, CodeTypeBarcode) == want {
+					r.CodeMatch = true
+				}
+			}
+		} else {
+			r.CodeMatch = normalizeCode(m.CatalogNumber, CodeTypeCatNo) == want
+		}
+		r.CountryMatch = prefs.Country != "" && strings.EqualFold(m.Country, prefs.Country)
+		if !strings.EqualFold(format, "any") {
+			r.FormatMatch = strings.EqualFold(m.Format, format)
+			for _, f := range m.Formats {
+				if strings.EqualFold(f, format) {
+					r.FormatMatch = true
+				}
+			}
+		}
+
+		if r.CodeMatch {
+			r.Score += 3
+		}
+		if r.CountryMatch {
+			r.Score += 2
+		}
+		if r.FormatMatch {
+			r.Score += 2
+		}
+		ranked = append(ranked, r)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Have > ranked[j].Have
+	})
+	return ranked
+}
+
+// Lookup searches Discogs for a barcode or catalog number and ranks the releases found,
+// marking those already in the collection
+func (ci *CodeImporter) Lookup(code string, prefs LookupPreferences) ([]RankedRelease, error) {
+	value, codeType := ClassifyCode(code)
+	if value == "" {
+		return nil, fmt.Errorf("barcode or catalog number required")
+	}
+
+	var matches []discogs.ReleaseMatch
+	var err error
+	if codeType == CodeTypeBarcode {
+		matches, err = ci.source.SearchReleasesByCode(value, "")
+	} else {
+		matches, err = ci.source.SearchReleasesByCode("", value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Discogs search failed: %w", err)
+	}
+
+	ranked := RankReleases(matches, value, codeType, prefs)
+	if len(ranked) == 0 {
+		return ranked, nil
+	}
+
+	ids := make([]int, len(ranked))
+	for i, r := range ranked {
+		ids[i] = r.DiscogsID
+	}
+	var albums []models.Album
+	ci.db.Select("id", "discogs_id").Where("discogs_id IN ?", ids).Find(&albums)
+	owned := make(map[int]uint, len(albums))
+	for _, album := range albums {
+		owned[*album.DiscogsID] = album.ID
+	}
+	for i := range ranked {
+		if id, ok := owned[ranked[i].DiscogsID]; ok {
+			ranked[i].AlbumID = &id
+		}
+	}
+	return ranked, nil
+}
+
+// Import adds the release for a code to the collection: the given Discogs release, or the
+// best ranked one when discogsID is 0. The code and the release's catalog number and format
+// fill in the album's media details, and the release is queued to be added to the Discogs collection
+func (ci *CodeImporter) Import(code string, discogsID int, prefs LookupPreferences) (*models.Album, error) {
+	album, _, err := ci.importCode(code, discogsID, prefs)
+	return album, err
+}
+
+// importCode is Import that also returns how many releases Discogs found for the code
+func (ci *CodeImporter) importCode(code string, discogsID int, prefs LookupPreferences) (*models.Album, int, error) {
+	value, codeType := ClassifyCode(code)
+	if codeType == CodeTypeBarcode {
+		var existing models.Album
+		if err := ci.db.Where("barcode = ?", value).Limit(1).Find(&existing).Error; err == nil && existing.ID != 0 {
+			return &existing, 0, ErrAlreadyInCollection
+		}
+	}
+
+	ranked, err := ci.Lookup(code, prefs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var match *RankedRelease
+	for i := range ranked {
+		if discogsID == 0 || ranked[i].DiscogsID == discogsID {
+			match = &ranked[i]
+			break
+		}
+	}
+	if match != nil {
+		discogsID = match.DiscogsID
+	}
+	if discogsID == 0 {
+		return nil, len(ranked), ErrNoReleaseFound
+	}
+
+	var existing models.Album
+	if err := ci.db.Where("discogs_id = ?", discogsID).Limit(1).Find(&existing).Error; err == nil && existing.ID != 0 {
+		return &existing, len(ranked), ErrAlreadyInCollection
+	}
+
+	album, err := ci.importRelease(discogsID)
+	if err != nil {
+		return nil, len(ranked), err
+	}
+
+	updates := map[string]interface{}{}
+	if codeType == CodeTypeBarcode && album.Barcode == "" {
+		updates["barcode"] = value
+	}
+	if match != nil {
+		if album.CatalogNumber == "" && match.CatalogNumber != "" {
+			updates["catalog_number"] = match.CatalogNumber
+		}
+		if album.Format == "" && match.Format != "" {
+			updates["format"] = match.Format
+			updates["format_details"] = match.FormatDetails
+		}
+		if album.DiscogsMasterID == nil && match.MasterID > 0 {
+			updates["discogs_master_id"] = utils.IntPtr(match.MasterID)
+		}
+	}
+	if len(updates) > 0 {
+		if err := ci.db.Model(album).Updates(updates).Error; err != nil {
+			log.Printf("CodeImporter: failed to save media details for album %d: %v", album.ID, err)
+		}
+	}
+
+	if err := QueueCollectionAdd(ci.db, album, 0); err != nil {
+		log.Printf("CodeImporter: failed to queue Discogs collection add for album %d: %v", album.ID, err)
+	}
+	return album, len(ranked), nil
+}
+
+// ImportBatch imports each code of a batch, recording the outcome of every code against the
+// job. Codes already handled by an earlier attempt of the job are skipped, so a retry only
+// repeats the codes that failed. It returns an error when any code failed
+func (ci *CodeImporter) ImportBatch(ctx context.Context, jobID uint, codes []string, prefs LookupPreferences, progress ImportProgress) (CodeImportSummary, error) {
+	summary := CodeImportSummary{Total: len(codes)}
+
+	var rows []models.CodeImport
+	if err := ci.db.Where("job_id = ?", jobID).Find(&rows).Error; err != nil {
+		return summary, fmt.Errorf("failed to load batch: %w", err)
+	}
+	byCode := make(map[string]models.CodeImport, len(rows))
+	for _, row := range rows {
+		byCode[row.Code] = row
+	}
+
+	// Every code is listed as pending before the first is imported
+	var missing []models.CodeImport
+	for _, code := range codes {
+		if _, ok := byCode[code]; !ok {
+			_, codeType := ClassifyCode(code)
+			missing = append(missing, models.CodeImport{JobID: jobID, Code: code, CodeType: codeType, Status: models.CodeImportPending})
+		}
+	}
+	if len(missing) > 0 {
+		if err := ci.db.Create(&missing).Error; err != nil {
+			return summary, fmt.Errorf("failed to record codes: %w", err)
+		}
+		for _, row := range missing {
+			byCode[row.Code] = row
+		}
+	}
+
+	for i, code := range codes {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		row := byCode[code]
+		if row.Status == models.CodeImportPending || row.Status == models.CodeImportFailed {
+			ci.importRow(&row, prefs)
+		}
+		switch row.Status {
+		case models.CodeImportImported:
+			summary.Imported++
+		case models.CodeImportExists:
+			summary.Exists++
+		case models.CodeImportNotFound:
+			summary.NotFound++
+		default:
+			summary.Failed++
+		}
+
+		if err := reportImport(progress, i+1, len(codes), code); err != nil {
+			return summary, err
+		}
+	}
+
+	if summary.Failed > 0 {
+		return summary, fmt.Errorf("%d of %d codes failed", summary.Failed, summary.Total)
+	}
+	return summary, nil
+}
+
+// importRow imports one code of a batch and saves its outcome
+func (ci *CodeImporter) importRow(row *models.CodeImport, prefs LookupPreferences) {
+	album, matches, err := ci.importCode(row.Code, 0, prefs)
+	row.Matches = matches
+	row.Error = ""
+	switch {
+	case err == nil, errors.Is(err, ErrAlreadyInCollection):
+		row.Status = models.CodeImportImported
+		if err != nil {
+			row.Status = models.CodeImportExists
+		}
+		row.AlbumID = &album.ID
+		row.DiscogsID = album.DiscogsID
+	case errors.Is(err, ErrNoReleaseFound):
+		row.Status = models.CodeImportNotFound
+	default:
+		row.Status = models.CodeImportFailed
+		row.Error = err.Error()
+	}
+
+	if err := ci.db.Save(row).Error; err != nil {
+		log.Printf("CodeImporter: failed to save outcome of code %q: %v", row.Code, err)
+	}
+}
+
+func reportImport(progress ImportProgress, done, total int, last string) error {
+	if progress == nil {
+		return nil
+	}
+	return progress(done, total, last)
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"vinylfo/discogs"
+	"vinylfo/models"
+	"vinylfo/utils"
+)
+
+type fakeCodeSource struct {
+	byBarcode map[string][]discogs.ReleaseMatch
+	byCatNo   map[string][]discogs.ReleaseMatch
+	err       error
+	searches  int
+}
+
+func (f *fakeCodeSource) SearchReleasesByCode(barcode, catno string) ([]discogs.ReleaseMatch, error) {
+	f.searches++
+	if f.err != nil {
+		return nil, f.err
+	}
+	if barcode != "" {
+		return f.byBarcode[barcode], nil
+	}
+	return f.byCatNo[catno], nil
+}
+
+func TestClassifyCode(t *testing.T) {
+	tests := []struct {
+		code, value, codeType string
+	}{
+		{"0 7599-27313-1 4", "075992731314", CodeTypeBarcode},
+		{" 5099902894819 ", "5099902894819", CodeTypeBarcode},
+		{"12345678", "12345678", CodeTypeBarcode},
+		{"SHVL 804", "SHVL 804", CodeTypeCatNo},
+		{"1234567", "1234567", CodeTypeCatNo},
+		{"BSK-3010", "BSK-3010", CodeTypeCatNo},
+	}
+	for _, tt := range tests {
+		value, codeType := ClassifyCode(tt.code)
+		if value != tt.value || codeType != tt.codeType {
+			t.Errorf("ClassifyCode(%q) = %q, %q; want %q, %q", tt.code, value, codeType, tt.value, tt.codeType)
+		}
+	}
+}
+
+func TestParseCodeList(t *testing.T) {
+	codes, err := ParseCodeList(strings.NewReader("Scanned At,Barcode\n2024-05-01,075992731314\n2024-05-01,\n2024-05-02,5099902894819\n2024-05-03,075992731314\n"))
+	if err != nil {
+		t.Fatalf("ParseCodeList: %v", err)
+	}
+	if len(codes) != 2 || codes[0] != "075992731314" || codes[1] != "5099902894819" {
+		t.Errorf("codes = %v, want the barcode column without blanks or repeats", codes)
+	}
+
+	codes, err = ParseCodeList(strings.NewReader("075992731314\nSHVL 804\n"))
+	if err != nil {
+		t.Fatalf("ParseCodeList: %v", err)
+	}
+	if len(codes) != 2 || codes[1] != "SHVL 804" {
+		t.Errorf("codes = %v, want one code per line", codes)
+	}
+}
+
+func TestRankReleases(t *testing.T) {
+	matches := []discogs.ReleaseMatch{
+		{DiscogsID: 1, Country: "US", Formats: []string{"CD", "Album"}, Format: "CD", Barcodes: []string{"075992731314"}, Have: 9000},
+		{DiscogsID: 2, Country: "UK", Formats: []string{"Vinyl", "LP"}, Format: "LP", Barcodes: []string{"0 7599-27313-1 4"}, Have: 100},
+		{DiscogsID: 3, Country: "US", Formats: []string{"Vinyl", "LP"}, Format: "LP", Barcodes: []string{"0 7599-27313-1 4"}, Have: 50},
+		{DiscogsID: 4, Country: "US", Formats: []string{"Vinyl", "LP"}, Format: "LP", Barcodes: []string{"999"}, Have: 5000},
+	}
+
+	ranked := RankReleases(matches, "75992731314", CodeTypeBarcode, LookupPreferences{Country: "us"})
+	order := []int{3, 1, 2, 4}
+	for i, id := range order {
+		if ranked[i].DiscogsID != id {
+			t.Fatalf("rank %d = release %d, want %d (ranked: %+v)", i, ranked[i].DiscogsID, id, ranked)
+		}
+	}
+	if !ranked[0].CodeMatch || !ranked[0].CountryMatch || !ranked[0].FormatMatch || ranked[0].Score != 7 {
+		t.Errorf("unexpected top release: %+v", ranked[0])
+	}
+
+	ranked = RankReleases(matches, "075992731314", CodeTypeBarcode, LookupPreferences{Format: "CD"})
+	if ranked[0].DiscogsID != 1 {
+		t.Errorf("top release = %d with a CD preference, want 1", ranked[0].DiscogsID)
+	}
+
+	ranked = RankReleases(matches, "075992731314", CodeTypeBarcode, LookupPreferences{Format: "any"})
+	if ranked[0].DiscogsID != 1 || ranked[0].FormatMatch {
+		t.Errorf("top release = %+v with no format preference, want the most owned exact match", ranked[0])
+	}
+
+	ranked = RankReleases([]discogs.ReleaseMatch{
+		{DiscogsID: 5, CatalogNumber: "SHVL 8040"},
+		{DiscogsID: 6, CatalogNumber: "shvl-804"},
+	}, "SHVL 804", CodeTypeCatNo, LookupPreferences{Format: "any"})
+	if ranked[0].DiscogsID != 6 || !ranked[0].CodeMatch {
+		t.Errorf("top release = %+v, want the exact catalog number", ranked[0])
+	}
+}
+
+func TestCodeImporterImport(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&models.CollectionChange{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	source := &fakeCodeSource{byBarcode: map[string][]discogs.ReleaseMatch{
+		"075992731314": {
+			{DiscogsID: 10, Country: "US", Formats: []string{"CD"}, Format: "CD", Barcodes: []string{"075992731314"}},
+			{DiscogsID: 11, MasterID: 500, Country: "US", Formats: []string{"Vinyl", "LP", "Album"}, Format: "LP", FormatDetails: "Album", CatalogNumber: "BSK 3010", Barcodes: []string{"075992731314"}},
+		},
+	}}
+	var imported []int
+	importer := &CodeImporter{db: db, source: source, importRelease: func(discogsID int) (*models.Album, error) {
+		imported = append(imported, discogsID)
+		album := models.Album{Title: "Rumours", Artist: "Fleetwood Mac", DiscogsID: utils.IntPtr(discogsID)}
+		return &album, db.Create(&album).Error
+	}}
+
+	album, err := importer.Import("0 7599-27313-1 4", 0, LookupPreferences{})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(imported) != 1 || imported[0] != 11 {
+		t.Fatalf("imported %v, want the vinyl release 11", imported)
+	}
+
+	var saved models.Album
+	db.First(&saved, album.ID)
+	if saved.Barcode != "075992731314" || saved.CatalogNumber != "BSK 3010" || saved.Format != "LP" || saved.FormatDetails != "Album" {
+		t.Errorf("media details not filled in: %+v", saved)
+	}
+	if saved.DiscogsMasterID == nil || *saved.DiscogsMasterID != 500 {
+		t.Errorf("DiscogsMasterID = %v, want 500", saved.DiscogsMasterID)
+	}
+	var queued int64
+	db.Model(&models.CollectionChange{}).Where("album_id = ? AND action = ?", album.ID, models.ChangeAdd).Count(&queued)
+	if queued != 1 {
+		t.Errorf("queued %d collection adds, want 1", queued)
+	}
+
+	// The barcode is now on an album, so scanning it again finds that album without a search
+	searches := source.searches
+	existing, err := importer.Import("075992731314", 0, LookupPreferences{})
+	if !errors.Is(err, ErrAlreadyInCollection) || existing == nil || existing.ID != album.ID {
+		t.Errorf("Import of a scanned barcode = %v, %v; want the existing album", existing, err)
+	}
+	if source.searches != searches {
+		t.Errorf("searched Discogs for a barcode already in the collection")
+	}
+
+	// Picking a release that is already in the collection
+	if _, err := importer.Import("BSK 3010", 11, LookupPreferences{}); !errors.Is(err, ErrAlreadyInCollection) {
+		t.Errorf("Import of an owned release: err = %v, want ErrAlreadyInCollection", err)
+	}
+
+	if _, err := importer.Import("0000000000000", 0, LookupPreferences{}); !errors.Is(err, ErrNoReleaseFound) {
+		t.Errorf("Import of an unknown barcode: err = %v, want ErrNoReleaseFound", err)
+	}
+}
+
+func TestCodeImporterImportBatch(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&models.CollectionChange{}, &models.CodeImport{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	source := &fakeCodeSource{
+		byBarcode: map[string][]discogs.ReleaseMatch{
+			"075992731314": {{DiscogsID: 11, Format: "LP", Barcodes: []string{"075992731314"}}},
+		},
+		byCatNo: map[string][]discogs.ReleaseMatch{
+			"SHVL 804": {{DiscogsID: 12, Format: "LP", CatalogNumber: "SHVL 804"}},
+		},
+	}
+	failing := true
+	importer := &CodeImporter{db: db, source: source, importRelease: func(discogsID int) (*models.Album, error) {
+		if discogsID == 12 && failing {
+			return nil, errors.New("rate limited")
+		}
+		album := models.Album{Title: "Album", Artist: "Artist", DiscogsID: utils.IntPtr(discogsID)}
+		return &album, db.Create(&album).Error
+	}}
+
+	codes := []string{"075992731314", "SHVL 804", "BLP 1577"}
+	var reported []string
+	progress := func(done, total int, last string) error {
+		reported = append(reported, last)
+		return nil
+	}
+
+	summary, err := importer.ImportBatch(context.Background(), 7, codes, LookupPreferences{}, progress)
+	if err == nil {
+		t.Fatal("ImportBatch succeeded with a failed code")
+	}
+	if summary != (CodeImportSummary{Total: 3, Imported: 1, NotFound: 1, Failed: 1}) {
+		t.Errorf("summary = %+v", summary)
+	}
+	if len(reported) != 3 {
+		t.Errorf("progress reported %v, want every code", reported)
+	}
+
+	var failed models.CodeImport
+	db.Where("job_id = ? AND code = ?", 7, "SHVL 804").First(&failed)
+	if failed.Status != models.CodeImportFailed || failed.CodeType != CodeTypeCatNo || failed.Error != "rate limited" {
+		t.Errorf("unexpected failed row: %+v", failed)
+	}
+
+	// A retry only repeats the failed code
+	failing = false
+	searches := source.searches
+	summary, err = importer.ImportBatch(context.Background(), 7, codes, LookupPreferences{}, nil)
+	if err != nil {
+		t.Fatalf("ImportBatch retry: %v", err)
+	}
+	if summary != (CodeImportSummary{Total: 3, Imported: 2, NotFound: 1}) {
+		t.Errorf("summary after retry = %+v", summary)
+	}
+	if source.searches != searches+1 {
+		t.Errorf("retry made %d searches, want 1", source.searches-searches)
+	}
+
+	var rows int64
+	db.Model(&models.CodeImport{}).Where("job_id = ?", 7).Count(&rows)
+	if rows != 3 {
+		t.Errorf("recorded %d codes, want 3", rows)
+	}
+
+	// Stopping partway leaves the rest pending
+	stop := errors.New("paused")
+	_, err = importer.ImportBatch(context.Background(), 8, codes, LookupPreferences{}, func(done, total int, last string) error {
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("err = %v, want the progress error", err)
+	}
+	var pending int64
+	db.Model(&models.CodeImport{}).Where("job_id = ? AND status = ?", 8, models.CodeImportPending).Count(&pending)
+	if pending != 2 {
+		t.Errorf("%d codes left pending, want 2", pending)
+	}
+}
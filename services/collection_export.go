@@ -0,0 +1,296 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// CollectionSchemaVersion is the version of the JSON collection export format
+// Bump it when a field changes meaning; new optional fields do not need a bump
+const CollectionSchemaVersion = 1
+
+// CollectionExport is a full collection in the JSON export format
+type CollectionExport struct {
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Albums     []CollectionAlbum `json:"albums"`
+}
+
+// CollectionAlbum is an album with its tracks in the export format
+type CollectionAlbum struct {
+	Title             string     `json:"title"`
+	Artist            string     `json:"artist"`
+	ReleaseYear       int        `json:"release_year,omitempty"`
+	ReleaseDate       string     `json:"release_date,omitempty"`
+	Genre             string     `json:"genre,omitempty"`
+	Style             string     `json:"style,omitempty"`
+	Label             string     `json:"label,omitempty"`
+	Country           string     `json:"country,omitempty"`
+	DiscogsID         *int       `json:"discogs_id,omitempty"`
+	DiscogsMasterID   *int       `json:"discogs_master_id,omitempty"`
+	DiscogsFolderID   int        `json:"discogs_folder_id,omitempty"`
+	DiscogsInstanceID int        `json:"discogs_instance_id,omitempty"`
+	DiscogsAddedAt    *time.Time `json:"discogs_added_at,omitempty"`
+	CoverImageURL     string     `json:"cover_image_url,omitempty"`
+	Format            string     `json:"format,omitempty"`
+	FormatDetails     string     `json:"format_details,omitempty"`
+	RPM               int        `json:"rpm,omitempty"`
+	DiscCount         int        `json:"disc_count,omitempty"`
+	MediaCondition    string     `json:"media_condition,omitempty"`
+	SleeveCondition   string     `json:"sleeve_condition,omitempty"`
+	CatalogNumber     string     `json:"catalog_number,omitempty"`
+	Barcode           string     `json:"barcode,omitempty"`
+	MatrixRunout      string     `json:"matrix_runout,omitempty"`
+	PurchasePrice     *float64   `json:"purchase_price,omitempty"`
+	PurchaseCurrency  string     `json:"purchase_currency,omitempty"`
+	PurchaseDate      string     `json:"purchase_date,omitempty"`
+	StorageLocation   string     `json:"storage_location,omitempty"`
+	Notes             string     `json:"notes,omitempty"`
+	Rating            int        `json:"rating,omitempty"`
+
+	Tracks []CollectionTrack `json:"tracks,omitempty"`
+}
+
+// CollectionTrack is a track with its duration, YouTube match and play history
+type CollectionTrack struct {
+	Title               string     `json:"title"`
+	TrackNumber         int        `json:"track_number,omitempty"`
+	DiscNumber          int        `json:"disc_number,omitempty"`
+	Side                string     `json:"side,omitempty"`
+	Position            string     `json:"position,omitempty"`
+	Duration            int        `json:"duration,omitempty"`
+	DurationSource      string     `json:"duration_source,omitempty"`
+	DurationNeedsReview bool       `json:"duration_needs_review,omitempty"`
+	DurationResolvedAt  *time.Time `json:"duration_resolved_at,omitempty"`
+
+	YouTube *CollectionYouTube `json:"youtube,omitempty"`
+	Plays   []CollectionPlay   `json:"plays,omitempty"`
+}
+
+// CollectionYouTube is a track's YouTube match
+type CollectionYouTube struct {
+	VideoID       string     `json:"video_id"`
+	VideoTitle    string     `json:"video_title,omitempty"`
+	VideoDuration int        `json:"video_duration,omitempty"`
+	ChannelName   string     `json:"channel_name,omitempty"`
+	ThumbnailURL  string     `json:"thumbnail_url,omitempty"`
+	MatchScore    float64    `json:"match_score,omitempty"`
+	MatchMethod   string     `json:"match_method,omitempty"`
+	Status        string     `json:"status,omitempty"`
+	NeedsReview   bool       `json:"needs_review,omitempty"`
+	MatchedAt     *time.Time `json:"matched_at,omitempty"`
+}
+
+// CollectionPlay is a track's play history in one playlist
+type CollectionPlay struct {
+	PlaylistID  string    `json:"playlist_id,omitempty"`
+	ListenCount int       `json:"listen_count"`
+	LastPlayed  time.Time `json:"last_played"`
+	Progress    int       `json:"progress,omitempty"`
+}
+
+// ExportCollection loads the whole collection in the export format, albums by artist and title
+func ExportCollection(db *gorm.DB) (*CollectionExport, error) {
+	var albums []models.Album
+	if err := db.Omit("discogs_cover_image").Order("artist ASC, title ASC, id ASC").Find(&albums).Error; err != nil {
+		return nil, fmt.Errorf("failed to load albums: %w", err)
+	}
+
+	var tracks []models.Track
+	if err := db.Order("album_id ASC, disc_number ASC, track_number ASC, id ASC").Find(&tracks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load tracks: %w", err)
+	}
+
+	var matches []models.TrackYouTubeMatch
+	if err := db.Find(&matches).Error; err != nil {
+		return nil, fmt.Errorf("failed to load YouTube matches: %w", err)
+	}
+	matchByTrack := make(map[uint]models.TrackYouTubeMatch, len(matches))
+	for _, m := range matches {
+		matchByTrack[m.TrackID] = m
+	}
+
+	var history []models.TrackHistory
+	if err := db.Order("track_id ASC, playlist_id ASC").Find(&history).Error; err != nil {
+		return nil, fmt.Errorf("failed to load play history: %w", err)
+	}
+	playsByTrack := make(map[uint][]CollectionPlay)
+	for _, h := range history {
+		playsByTrack[h.TrackID] = append(playsByTrack[h.TrackID], CollectionPlay{
+			PlaylistID:  h.PlaylistID,
+			ListenCount: h.ListenCount,
+			LastPlayed:  h.LastPlayed,
+			Progress:    h.Progress,
+		})
+	}
+
+	tracksByAlbum := make(map[uint][]CollectionTrack)
+	for _, t := range tracks {
+		track := exportTrack(t)
+		if m, ok := matchByTrack[t.ID]; ok && m.YouTubeVideoID != "" {
+			track.YouTube = exportYouTube(m)
+		}
+		track.Plays = playsByTrack[t.ID]
+		tracksByAlbum[t.AlbumID] = append(tracksByAlbum[t.AlbumID], track)
+	}
+
+	export := &CollectionExport{
+		Version:    CollectionSchemaVersion,
+		ExportedAt: time.Now().UTC(),
+		Albums:     make([]CollectionAlbum, 0, len(albums)),
+	}
+	for _, a := range albums {
+		album := exportAlbum(a)
+		album.Tracks = tracksByAlbum[a.ID]
+		export.Albums = append(export.Albums, album)
+	}
+	return export, nil
+}
+
+func exportAlbum(a models.Album) CollectionAlbum {
+	return CollectionAlbum{
+		Title:             a.Title,
+		Artist:            a.Artist,
+		ReleaseYear:       a.ReleaseYear,
+		ReleaseDate:       a.ReleaseDate,
+		Genre:             a.Genre,
+		Style:             a.Style,
+		Label:             a.Label,
+		Country:           a.Country,
+		DiscogsID:         a.DiscogsID,
+		DiscogsMasterID:   a.DiscogsMasterID,
+		DiscogsFolderID:   a.DiscogsFolderID,
+		DiscogsInstanceID: a.DiscogsInstanceID,
+		DiscogsAddedAt:    a.DiscogsAddedAt,
+		CoverImageURL:     a.CoverImageURL,
+		Format:            a.Format,
+		FormatDetails:     a.FormatDetails,
+		RPM:               a.RPM,
+		DiscCount:         a.DiscCount,
+		MediaCondition:    a.MediaCondition,
+		SleeveCondition:   a.SleeveCondition,
+		CatalogNumber:     a.CatalogNumber,
+		Barcode:           a.Barcode,
+		MatrixRunout:      a.MatrixRunout,
+		PurchasePrice:     a.PurchasePrice,
+		PurchaseCurrency:  a.PurchaseCurrency,
+		PurchaseDate:      a.PurchaseDate,
+		StorageLocation:   a.StorageLocation,
+		Notes:             a.Notes,
+		Rating:            a.Rating,
+	}
+}
+
+func exportTrack(t models.Track) CollectionTrack {
+	return CollectionTrack{
+		Title:               t.Title,
+		TrackNumber:         t.TrackNumber,
+		DiscNumber:          t.DiscNumber,
+		Side:                t.Side,
+		Position:            t.Position,
+		Duration:            t.Duration,
+		DurationSource:      t.DurationSource,
+		DurationNeedsReview: t.DurationNeedsReview,
+		DurationResolvedAt:  t.DurationResolvedAt,
+	}
+}
+
+func exportYouTube(m models.TrackYouTubeMatch) *CollectionYouTube {
+	return &CollectionYouTube{
+		VideoID:       m.YouTubeVideoID,
+		VideoTitle:    m.VideoTitle,
+		VideoDuration: m.VideoDuration,
+		ChannelName:   m.ChannelName,
+		ThumbnailURL:  m.ThumbnailURL,
+		MatchScore:    m.MatchScore,
+		MatchMethod:   m.MatchMethod,
+		Status:        m.Status,
+		NeedsReview:   m.NeedsReview,
+		MatchedAt:     m.MatchedAt,
+	}
+}
+
+// Columns of the Discogs collection CSV export, followed by the custom fields Vinylfo
+// keeps. Custom fields use Discogs' "Collection <field name>" naming
+var collectionCSVHeader = []string{
+	"Catalog#", "Artist", "Title", "Label", "Format", "Rating", "Released", "release_id",
+	"CollectionFolder", "Date Added", "Collection Media Condition", "Collection Sleeve Condition",
+	"Collection Notes", "Collection Storage Location", "Collection Purchase Price",
+	"Collection Purchase Date", "Collection Barcode", "Collection Matrix / Runout",
+}
+
+// csvDateAdded is the layout of the Date Added column
+const csvDateAdded = "2006-01-02 15:04:05"
+
+// WriteCollectionCSV writes albums in the Discogs collection export layout
+// Tracks, durations, YouTube matches and plays only fit in the JSON format
+func WriteCollectionCSV(w io.Writer, albums []CollectionAlbum) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(collectionCSVHeader); err != nil {
+		return err
+	}
+
+	for _, a := range albums {
+		releaseID, rating, released, dateAdded, price := "", "", a.ReleaseDate, "", ""
+		if a.DiscogsID != nil {
+			releaseID = strconv.Itoa(*a.DiscogsID)
+		}
+		if a.Rating > 0 {
+			rating = strconv.Itoa(a.Rating)
+		}
+		if released == "" && a.ReleaseYear > 0 {
+			released = strconv.Itoa(a.ReleaseYear)
+		}
+		if a.DiscogsAddedAt != nil {
+			dateAdded = a.DiscogsAddedAt.UTC().Format(csvDateAdded)
+		}
+		if a.PurchasePrice != nil {
+			price = strings.TrimSpace(strconv.FormatFloat(*a.PurchasePrice, 'f', 2, 64) + " " + a.PurchaseCurrency)
+		}
+
+		row := []string{
+			a.CatalogNumber, a.Artist, a.Title, a.Label, csvFormat(a), rating, released, releaseID,
+			csvFolder(a.DiscogsFolderID), dateAdded, models.DiscogsConditionLabel(a.MediaCondition),
+			models.DiscogsConditionLabel(a.SleeveCondition), a.Notes, a.StorageLocation, price,
+			a.PurchaseDate, a.Barcode, a.MatrixRunout,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvFormat writes the format the way Discogs does, e.g. "2xLP, Album, Gatefold"
+func csvFormat(a CollectionAlbum) string {
+	format := a.Format
+	if format != "" && a.DiscCount > 1 {
+		format = fmt.Sprintf("%dx%s", a.DiscCount, format)
+	}
+	if a.FormatDetails == "" {
+		return format
+	}
+	if format == "" {
+		return a.FormatDetails
+	}
+	return format + ", " + a.FormatDetails
+}
+
+// csvFolder names the default folder like Discogs does; other folders are written by ID
+// because their names are not stored locally
+func csvFolder(folderID int) string {
+	if folderID <= 1 {
+		return "Uncategorized"
+	}
+	return strconv.Itoa(folderID)
+}
@@ -0,0 +1,746 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"vinylfo/discogs"
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// Import actions reported for each album
+const (
+	ImportCreate    = "create"
+	ImportUpdate    = "update"
+	ImportUnchanged = "unchanged"
+	ImportSkipped   = "skipped" // Repeated in the file or missing its title or artist
+)
+
+// Ways an imported album was matched to an existing one
+const (
+	MatchedByDiscogsID   = "discogs_id"
+	MatchedByTitleArtist = "title_artist"
+)
+
+// ImportOptions controls how a collection import is applied
+type ImportOptions struct {
+	DryRun    bool // Work out the changes without writing them
+	Overwrite bool // Replace local values that differ; otherwise only empty values are filled in
+}
+
+// ImportAlbumReview describes what an import does to one album
+// Changes use the sync review's severities: info fills an empty value, conflict replaces one
+type ImportAlbumReview struct {
+	discogs.AlbumReview
+	Action    string `json:"action"`
+	MatchedBy string `json:"matched_by,omitempty"`
+	NewTracks int    `json:"new_tracks"`
+	Plays     int    `json:"plays"` // Play history entries added or raised
+}
+
+// CollectionImportResult summarizes a collection import or its dry run
+type CollectionImportResult struct {
+	DryRun    bool                `json:"dry_run"`
+	Total     int                 `json:"total"`
+	Created   int                 `json:"created"`
+	Updated   int                 `json:"updated"`
+	Unchanged int                 `json:"unchanged"`
+	Skipped   int                 `json:"skipped"`
+	Conflicts int                 `json:"conflicts"` // Albums with local values the import would replace
+	Albums    []ImportAlbumReview `json:"albums"`
+}
+
+// ParseCollectionJSON reads a JSON collection export
+func ParseCollectionJSON(r io.Reader) (*CollectionExport, error) {
+	var export CollectionExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("invalid collection JSON: %w", err)
+	}
+	if export.Version == 0 {
+		return nil, errors.New("collection JSON has no version")
+	}
+	if export.Version > CollectionSchemaVersion {
+		return nil, fmt.Errorf("collection JSON version %d is newer than the supported version %d", export.Version, CollectionSchemaVersion)
+	}
+	return &export, nil
+}
+
+// ParseCollectionCSV reads a Discogs collection CSV export, or one written by WriteCollectionCSV
+// "Collection ..." custom field columns are recognised by name like the sync's collection fields
+func ParseCollectionCSV(r io.Reader) ([]CollectionAlbum, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	fieldNames := make(map[int]string)
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		columns[strings.ToLower(name)] = i
+		if field, ok := strings.CutPrefix(name, "Collection "); ok {
+			fieldNames[i] = field
+		}
+	}
+	if _, ok := columns["artist"]; !ok {
+		return nil, errors.New("CSV has no Artist column")
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New("CSV has no Title column")
+	}
+
+	var albums []CollectionAlbum
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		get := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		album := CollectionAlbum{
+			Artist: get("artist"),
+			Title:  get("title"),
+			Label:  get("label"),
+		}
+		if album.Artist == "" && album.Title == "" {
+			continue
+		}
+
+		if catno := get("catalog#"); !strings.EqualFold(catno, "none") {
+			album.CatalogNumber = catno
+		}
+		album.Format, album.FormatDetails, album.DiscCount = parseCSVFormat(get("format"))
+		album.Rating, _ = strconv.Atoi(get("rating"))
+		if released := get("released"); released != "" {
+			album.ReleaseYear, _ = strconv.Atoi(released[:min(4, len(released))])
+			if len(released) > 4 {
+				album.ReleaseDate = released
+			}
+		}
+		if id, _ := strconv.Atoi(get("release_id")); id > 0 {
+			album.DiscogsID = &id
+		}
+		if folder := get("collectionfolder"); strings.EqualFold(folder, "Uncategorized") {
+			album.DiscogsFolderID = 1
+		} else {
+			album.DiscogsFolderID, _ = strconv.Atoi(folder)
+		}
+		if added := get("date added"); added != "" {
+			if t, err := time.Parse(csvDateAdded, added); err == nil {
+				album.DiscogsAddedAt = &t
+			} else if t, err := time.Parse(time.RFC3339, added); err == nil {
+				album.DiscogsAddedAt = &t
+			}
+		}
+
+		notes := make(map[int]string)
+		for i := range fieldNames {
+			if i < len(record) && strings.TrimSpace(record[i]) != "" {
+				notes[i] = strings.TrimSpace(record[i])
+			}
+		}
+		media := MediaFromCollectionItem(map[string]interface{}{"notes": notes}, fieldNames)
+		album.MediaCondition = media.MediaCondition
+		album.SleeveCondition = media.SleeveCondition
+		album.Notes = media.Notes
+		album.StorageLocation = media.StorageLocation
+		album.PurchasePrice = media.PurchasePrice
+		album.PurchaseCurrency = media.PurchaseCurrency
+		album.PurchaseDate = media.PurchaseDate
+		album.Barcode = media.Barcode
+		album.MatrixRunout = media.MatrixRunout
+		if media.CatalogNumber != "" {
+			album.CatalogNumber = media.CatalogNumber
+		}
+
+		albums = append(albums, album)
+	}
+	return albums, nil
+}
+
+var discCountPattern = regexp.MustCompile(`^(\d+)x(.+)$`)
+
+// parseCSVFormat splits a Discogs export format such as "2xLP, Album, Gatefold"
+func parseCSVFormat(value string) (format, details string, discCount int) {
+	var parts []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return "", "", 0
+	}
+
+	format = parts[0]
+	if m := discCountPattern.FindStringSubmatch(format); m != nil {
+		discCount, _ = strconv.Atoi(m[1])
+		format = m[2]
+	}
+	parts = parts[1:]
+	if format == "Vinyl" && len(parts) > 0 {
+		format, parts = parts[0], parts[1:]
+	}
+	return format, strings.Join(parts, ", "), discCount
+}
+
+// ImportCollection adds or updates albums from an export
+// Albums are matched by Discogs ID, then by title and artist among albums without a Discogs
+// ID (or any album when the import has none). Nothing is written on a dry run, and a real
+// import is written in one transaction
+func ImportCollection(db *gorm.DB, albums []CollectionAlbum, opts ImportOptions) (*CollectionImportResult, error) {
+	result := &CollectionImportResult{
+		DryRun: opts.DryRun,
+		Total:  len(albums),
+		Albums: make([]ImportAlbumReview, 0, len(albums)),
+	}
+
+	run := func(tx *gorm.DB) error {
+		seen := make(map[string]bool)
+		for i := range albums {
+			in := &albums[i]
+			var review *ImportAlbumReview
+			key := importKey(in)
+			switch {
+			case in.Title == "" || in.Artist == "":
+				review = skippedImport(in, "Title and artist are required")
+			case seen[key]:
+				review = skippedImport(in, "Repeats an earlier album in the import")
+			default:
+				seen[key] = true
+				var err error
+				if review, err = importAlbum(tx, in, opts); err != nil {
+					return fmt.Errorf("failed to import %s - %s: %w", in.Artist, in.Title, err)
+				}
+			}
+
+			switch review.Action {
+			case ImportCreate:
+				result.Created++
+			case ImportUpdate:
+				result.Updated++
+			case ImportUnchanged:
+				result.Unchanged++
+			case ImportSkipped:
+				result.Skipped++
+			}
+			if review.HasConflicts {
+				result.Conflicts++
+			}
+			result.Albums = append(result.Albums, *review)
+		}
+		return nil
+	}
+
+	var err error
+	if opts.DryRun {
+		err = run(db)
+	} else {
+		err = db.Transaction(run)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// importKey identifies an album within one import so repeats are only applied once
+func importKey(a *CollectionAlbum) string {
+	if a.DiscogsID != nil && *a.DiscogsID > 0 {
+		return "discogs:" + strconv.Itoa(*a.DiscogsID)
+	}
+	return "album:" + strings.ToLower(strings.TrimSpace(a.Artist)) + "\x00" + strings.ToLower(strings.TrimSpace(a.Title))
+}
+
+func newImportReview(in *CollectionAlbum, action string) *ImportAlbumReview {
+	review := &ImportAlbumReview{
+		AlbumReview: discogs.AlbumReview{
+			Title:   in.Title,
+			Artist:  in.Artist,
+			Changes: make([]discogs.FieldChange, 0),
+		},
+		Action: action,
+	}
+	if in.DiscogsID != nil {
+		review.DiscogsID = *in.DiscogsID
+	}
+	return review
+}
+
+func skippedImport(in *CollectionAlbum, reason string) *ImportAlbumReview {
+	review := newImportReview(in, ImportSkipped)
+	review.Summary = reason
+	return review
+}
+
+// findImportMatch finds the local album an imported album duplicates, if any
+func findImportMatch(tx *gorm.DB, in *CollectionAlbum) (*models.Album, string, error) {
+	var album models.Album
+	if in.DiscogsID != nil && *in.DiscogsID > 0 {
+		err := tx.Omit("discogs_cover_image").Where("discogs_id = ?", *in.DiscogsID).First(&album).Error
+		if err == nil {
+			return &album, MatchedByDiscogsID, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", err
+		}
+	}
+
+	query := tx.Omit("discogs_cover_image").Where("LOWER(title) = LOWER(?) AND LOWER(artist) = LOWER(?)", in.Title, in.Artist)
+	if in.DiscogsID != nil && *in.DiscogsID > 0 {
+		// Another pressing with its own release ID is a separate edition
+		query = query.Where("discogs_id IS NULL OR discogs_id = 0")
+	}
+	err := query.Order("id ASC").First(&album).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return &album, MatchedByTitleArtist, nil
+}
+
+func importAlbum(tx *gorm.DB, in *CollectionAlbum, opts ImportOptions) (*ImportAlbumReview, error) {
+	existing, matchedBy, err := findImportMatch(tx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		review := newImportReview(in, ImportCreate)
+		review.NewTracks = len(in.Tracks)
+		for _, t := range in.Tracks {
+			review.Plays += len(t.Plays)
+		}
+		review.CanAutoApply = true
+		review.Summary = fmt.Sprintf("New album with %d tracks", len(in.Tracks))
+		if opts.DryRun {
+			return review, nil
+		}
+
+		album := in.album()
+		if err := tx.Create(&album).Error; err != nil {
+			return nil, err
+		}
+		review.AlbumID = album.ID
+		for _, t := range in.Tracks {
+			if err := createImportedTrack(tx, album.ID, t); err != nil {
+				return nil, err
+			}
+		}
+		return review, nil
+	}
+
+	review := newImportReview(in, ImportUpdate)
+	review.AlbumID = existing.ID
+	review.MatchedBy = matchedBy
+
+	current := exportAlbum(*existing).values()
+	updates := make(map[string]interface{})
+	for i, v := range in.values() {
+		if matchedBy == MatchedByTitleArtist && (v.column == "title" || v.column == "artist") {
+			continue // Matched regardless of case, so the local spelling stays
+		}
+		if change, apply := importChange(v.column, current[i].value, v.value, opts); change != nil {
+			review.Changes = append(review.Changes, *change)
+			if apply {
+				updates[v.column] = change.NewValue
+			}
+		}
+	}
+	if len(updates) > 0 && !opts.DryRun {
+		if err := tx.Model(existing).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if err := importTracks(tx, existing.ID, in.Tracks, opts, review); err != nil {
+		return nil, err
+	}
+
+	for _, change := range review.Changes {
+		if change.Severity == discogs.SeverityConflict {
+			review.HasConflicts = true
+		}
+	}
+	review.CanAutoApply = !review.HasConflicts || opts.Overwrite
+	if len(review.Changes) == 0 && review.NewTracks == 0 && review.Plays == 0 {
+		review.Action = ImportUnchanged
+	}
+	review.Summary = importSummary(review, opts)
+	return review, nil
+}
+
+// importChange compares a local value with an imported one. Empty imported values never
+// clear local ones, and differing local values are only replaced with Overwrite
+func importChange(field string, current, incoming interface{}, opts ImportOptions) (*discogs.FieldChange, bool) {
+	newValue, ok := setValue(incoming)
+	if !ok {
+		return nil, false
+	}
+	currentValue, hasCurrent := setValue(current)
+	if hasCurrent && sameValue(currentValue, newValue) {
+		return nil, false
+	}
+
+	change := &discogs.FieldChange{Field: field, NewValue: newValue, Severity: discogs.SeverityInfo}
+	if hasCurrent {
+		change.CurrentValue = currentValue
+		change.Severity = discogs.SeverityConflict
+	}
+	return change, !hasCurrent || opts.Overwrite
+}
+
+func importSummary(review *ImportAlbumReview, opts ImportOptions) string {
+	if review.Action == ImportUnchanged {
+		return "Already up to date"
+	}
+
+	var parts []string
+	conflicts := 0
+	for _, change := range review.Changes {
+		if change.Severity == discogs.SeverityConflict {
+			conflicts++
+		}
+	}
+	if n := len(review.Changes) - conflicts; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d new values", n))
+	}
+	if conflicts > 0 {
+		if opts.Overwrite {
+			parts = append(parts, fmt.Sprintf("%d values replaced", conflicts))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d conflicts kept", conflicts))
+		}
+	}
+	if review.NewTracks > 0 {
+		parts = append(parts, fmt.Sprintf("%d new tracks", review.NewTracks))
+	}
+	if review.Plays > 0 {
+		parts = append(parts, fmt.Sprintf("%d plays", review.Plays))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// importTracks matches imported tracks to the album's tracks by position, then title
+// Matched tracks get missing durations, YouTube matches and play history
+func importTracks(tx *gorm.DB, albumID uint, tracks []CollectionTrack, opts ImportOptions, review *ImportAlbumReview) error {
+	if len(tracks) == 0 {
+		return nil
+	}
+
+	var local []models.Track
+	if err := tx.Where("album_id = ?", albumID).Order("id ASC").Find(&local).Error; err != nil {
+		return err
+	}
+	used := make(map[uint]bool)
+	find := func(in CollectionTrack) *models.Track {
+		for i := range local {
+			if !used[local[i].ID] && in.Position != "" && strings.EqualFold(local[i].Position, in.Position) {
+				return &local[i]
+			}
+		}
+		for i := range local {
+			if !used[local[i].ID] && strings.EqualFold(strings.TrimSpace(local[i].Title), strings.TrimSpace(in.Title)) {
+				return &local[i]
+			}
+		}
+		return nil
+	}
+
+	for _, in := range tracks {
+		track := find(in)
+		if track == nil {
+			review.NewTracks++
+			review.Plays += len(in.Plays)
+			if !opts.DryRun {
+				if err := createImportedTrack(tx, albumID, in); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		used[track.ID] = true
+
+		label := in.Position
+		if label == "" {
+			label = in.Title
+		}
+
+		if change, apply := importChange("track "+label+" duration", track.Duration, in.Duration, opts); change != nil {
+			review.Changes = append(review.Changes, *change)
+			if apply && !opts.DryRun {
+				updates := map[string]interface{}{
+					"duration":              in.Duration,
+					"duration_needs_review": in.DurationNeedsReview,
+					"duration_resolved_at":  in.DurationResolvedAt,
+				}
+				if in.DurationSource != "" {
+					updates["duration_source"] = in.DurationSource
+				}
+				if err := tx.Model(track).Updates(updates).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		if in.YouTube != nil && in.YouTube.VideoID != "" {
+			var match models.TrackYouTubeMatch
+			err := tx.Where("track_id = ?", track.ID).First(&match).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if change, apply := importChange("track "+label+" youtube_video_id", match.YouTubeVideoID, in.YouTube.VideoID, opts); change != nil {
+				review.Changes = append(review.Changes, *change)
+				if apply && !opts.DryRun {
+					if err := saveImportedMatch(tx, track.ID, match.ID, in.YouTube); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		plays, err := mergePlays(tx, track.ID, in.Plays, opts.DryRun)
+		if err != nil {
+			return err
+		}
+		review.Plays += plays
+	}
+	return nil
+}
+
+func createImportedTrack(tx *gorm.DB, albumID uint, in CollectionTrack) error {
+	track := in.track(albumID)
+	if err := tx.Create(&track).Error; err != nil {
+		return err
+	}
+	if in.YouTube != nil && in.YouTube.VideoID != "" {
+		if err := saveImportedMatch(tx, track.ID, 0, in.YouTube); err != nil {
+			return err
+		}
+	}
+	_, err := mergePlays(tx, track.ID, in.Plays, false)
+	return err
+}
+
+// saveImportedMatch creates a track's YouTube match, or replaces the one with matchID
+func saveImportedMatch(tx *gorm.DB, trackID, matchID uint, in *CollectionYouTube) error {
+	status := in.Status
+	if status == "" {
+		status = "matched"
+	}
+	method := in.MatchMethod
+	if method == "" {
+		method = "manual"
+	}
+	match := models.TrackYouTubeMatch{
+		ID:             matchID,
+		TrackID:        trackID,
+		YouTubeVideoID: in.VideoID,
+		VideoTitle:     in.VideoTitle,
+		VideoDuration:  in.VideoDuration,
+		ChannelName:    in.ChannelName,
+		ThumbnailURL:   in.ThumbnailURL,
+		MatchScore:     in.MatchScore,
+		MatchMethod:    method,
+		Status:         status,
+		NeedsReview:    in.NeedsReview,
+		MatchedAt:      in.MatchedAt,
+	}
+	if matchID == 0 {
+		return tx.Create(&match).Error
+	}
+	return tx.Save(&match).Error
+}
+
+// mergePlays adds play history, keeping the higher listen count and later play of
+// entries for the same playlist. It returns the number of entries added or raised
+func mergePlays(tx *gorm.DB, trackID uint, plays []CollectionPlay, dryRun bool) (int, error) {
+	merged := 0
+	for _, play := range plays {
+		var history models.TrackHistory
+		err := tx.Where("track_id = ? AND playlist_id = ?", trackID, play.PlaylistID).First(&history).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			merged++
+			if dryRun {
+				continue
+			}
+			history = models.TrackHistory{
+				TrackID:     trackID,
+				PlaylistID:  play.PlaylistID,
+				ListenCount: play.ListenCount,
+				LastPlayed:  play.LastPlayed,
+				Progress:    play.Progress,
+			}
+			if err := tx.Create(&history).Error; err != nil {
+				return merged, err
+			}
+			continue
+		}
+		if err != nil {
+			return merged, err
+		}
+
+		if play.ListenCount <= history.ListenCount && !play.LastPlayed.After(history.LastPlayed) {
+			continue
+		}
+		merged++
+		if dryRun {
+			continue
+		}
+		updates := map[string]interface{}{"listen_count": max(play.ListenCount, history.ListenCount)}
+		if play.LastPlayed.After(history.LastPlayed) {
+			updates["last_played"] = play.LastPlayed
+			updates["progress"] = play.Progress
+		}
+		if err := tx.Model(&history).Updates(updates).Error; err != nil {
+			return merged, err
+		}
+	}
+	return merged, nil
+}
+
+// albumValue is an album column an import can set
+type albumValue struct {
+	column string
+	value  interface{}
+}
+
+// values lists the album columns in a fixed order, so two albums' lists line up
+func (a CollectionAlbum) values() []albumValue {
+	return []albumValue{
+		{"title", a.Title},
+		{"artist", a.Artist},
+		{"release_year", a.ReleaseYear},
+		{"release_date", a.ReleaseDate},
+		{"genre", a.Genre},
+		{"style", a.Style},
+		{"label", a.Label},
+		{"country", a.Country},
+		{"discogs_id", a.DiscogsID},
+		{"discogs_master_id", a.DiscogsMasterID},
+		{"discogs_folder_id", a.DiscogsFolderID},
+		{"discogs_instance_id", a.DiscogsInstanceID},
+		{"discogs_added_at", a.DiscogsAddedAt},
+		{"cover_image_url", a.CoverImageURL},
+		{"format", a.Format},
+		{"format_details", a.FormatDetails},
+		{"rpm", a.RPM},
+		{"disc_count", a.DiscCount},
+		{"media_condition", a.MediaCondition},
+		{"sleeve_condition", a.SleeveCondition},
+		{"catalog_number", a.CatalogNumber},
+		{"barcode", a.Barcode},
+		{"matrix_runout", a.MatrixRunout},
+		{"purchase_price", a.PurchasePrice},
+		{"purchase_currency", a.PurchaseCurrency},
+		{"purchase_date", a.PurchaseDate},
+		{"storage_location", a.StorageLocation},
+		{"notes", a.Notes},
+		{"rating", a.Rating},
+	}
+}
+
+// setValue dereferences a column value, reporting false for empty ones
+func setValue(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, v != ""
+	case int:
+		return v, v != 0
+	case *int:
+		if v == nil || *v == 0 {
+			return nil, false
+		}
+		return *v, true
+	case *float64:
+		if v == nil {
+			return nil, false
+		}
+		return *v, true
+	case *time.Time:
+		if v == nil || v.IsZero() {
+			return nil, false
+		}
+		return *v, true
+	}
+	return v, v != nil
+}
+
+func sameValue(a, b interface{}) bool {
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		return ok && ta.Equal(tb)
+	}
+	return a == b
+}
+
+func (a CollectionAlbum) album() models.Album {
+	return models.Album{
+		Title:             a.Title,
+		Artist:            a.Artist,
+		ReleaseYear:       a.ReleaseYear,
+		ReleaseDate:       a.ReleaseDate,
+		Genre:             a.Genre,
+		Style:             a.Style,
+		Label:             a.Label,
+		Country:           a.Country,
+		DiscogsID:         a.DiscogsID,
+		DiscogsMasterID:   a.DiscogsMasterID,
+		DiscogsFolderID:   a.DiscogsFolderID,
+		DiscogsInstanceID: a.DiscogsInstanceID,
+		DiscogsAddedAt:    a.DiscogsAddedAt,
+		CoverImageURL:     a.CoverImageURL,
+		Format:            a.Format,
+		FormatDetails:     a.FormatDetails,
+		RPM:               a.RPM,
+		DiscCount:         a.DiscCount,
+		MediaCondition:    a.MediaCondition,
+		SleeveCondition:   a.SleeveCondition,
+		CatalogNumber:     a.CatalogNumber,
+		Barcode:           a.Barcode,
+		MatrixRunout:      a.MatrixRunout,
+		PurchasePrice:     a.PurchasePrice,
+		PurchaseCurrency:  a.PurchaseCurrency,
+		PurchaseDate:      a.PurchaseDate,
+		StorageLocation:   a.StorageLocation,
+		Notes:             a.Notes,
+		Rating:            a.Rating,
+	}
+}
+
+func (t CollectionTrack) track(albumID uint) models.Track {
+	return models.Track{
+		AlbumID:             albumID,
+		Title:               t.Title,
+		TrackNumber:         t.TrackNumber,
+		DiscNumber:          t.DiscNumber,
+		Side:                t.Side,
+		Position:            t.Position,
+		Duration:            t.Duration,
+		DurationSource:      t.DurationSource,
+		DurationNeedsReview: t.DurationNeedsReview,
+		DurationResolvedAt:  t.DurationResolvedAt,
+	}
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"vinylfo/discogs"
+	"vinylfo/models"
+	"vinylfo/utils"
+)
+
+func TestParseCollectionCSV(t *testing.T) {
+	data := "\ufeffCatalog#,Artist,Title,Label,Format,Rating,Released,release_id,CollectionFolder,Date Added,Collection Media Condition,Collection Sleeve Condition,Collection Notes,Collection Shelf\n" +
+		"SHVL 804,Pink Floyd,The Dark Side Of The Moon,Harvest,\"LP, Album, Gatefold\",5,1973,1873013,Uncategorized,2019-03-08 14:43:13,Near Mint (NM or M-),Very Good Plus (VG+),First pressing,B3\n" +
+		"none,Fleetwood Mac,Rumours,Warner Bros.,\"2xVinyl, LP, Album\",,1977-02-04,,3,,,,,\n"
+
+	albums, err := ParseCollectionCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseCollectionCSV: %v", err)
+	}
+	if len(albums) != 2 {
+		t.Fatalf("got %d albums, want 2", len(albums))
+	}
+
+	a := albums[0]
+	if a.DiscogsID == nil || *a.DiscogsID != 1873013 || a.CatalogNumber != "SHVL 804" || a.Rating != 5 || a.ReleaseYear != 1973 {
+		t.Errorf("unexpected album: %+v", a)
+	}
+	if a.Format != "LP" || a.FormatDetails != "Album, Gatefold" || a.DiscogsFolderID != 1 {
+		t.Errorf("Format = %q, FormatDetails = %q, folder = %d", a.Format, a.FormatDetails, a.DiscogsFolderID)
+	}
+	if a.MediaCondition != models.GradeNearMint || a.SleeveCondition != models.GradeVeryGoodPlus || a.Notes != "First pressing" || a.StorageLocation != "B3" {
+		t.Errorf("collection fields not read: %+v", a)
+	}
+	if a.DiscogsAddedAt == nil || !a.DiscogsAddedAt.Equal(time.Date(2019, 3, 8, 14, 43, 13, 0, time.UTC)) {
+		t.Errorf("DiscogsAddedAt = %v", a.DiscogsAddedAt)
+	}
+
+	b := albums[1]
+	if b.DiscogsID != nil || b.CatalogNumber != "" || b.Format != "LP" || b.DiscCount != 2 || b.ReleaseDate != "1977-02-04" || b.DiscogsFolderID != 3 {
+		t.Errorf("unexpected album: %+v", b)
+	}
+
+	if _, err := ParseCollectionCSV(strings.NewReader("Name,Year\nx,1\n")); err == nil {
+		t.Error("ParseCollectionCSV accepted a CSV without Artist and Title columns")
+	}
+}
+
+func TestWriteCollectionCSVRoundTrip(t *testing.T) {
+	added := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	price := 24.5
+	album := CollectionAlbum{
+		Title: "Rumours", Artist: "Fleetwood Mac", ReleaseYear: 1977, Label: "Warner Bros.",
+		DiscogsID: utils.IntPtr(11), DiscogsFolderID: 4, DiscogsAddedAt: &added,
+		Format: "LP", FormatDetails: "Album, Gatefold", DiscCount: 2,
+		MediaCondition: models.GradeVeryGood, SleeveCondition: models.SleeveGeneric,
+		CatalogNumber: "BSK 3010", Barcode: "075992731314", MatrixRunout: "BSK-1-3010 A",
+		PurchasePrice: &price, PurchaseCurrency: "EUR", PurchaseDate: "2024-04-30",
+		StorageLocation: "Shelf 2", Notes: "Signed, \"mint\" insert", Rating: 4,
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCollectionCSV(&buf, []CollectionAlbum{album}); err != nil {
+		t.Fatalf("WriteCollectionCSV: %v", err)
+	}
+	albums, err := ParseCollectionCSV(&buf)
+	if err != nil {
+		t.Fatalf("ParseCollectionCSV: %v", err)
+	}
+	if len(albums) != 1 {
+		t.Fatalf("got %d albums, want 1", len(albums))
+	}
+
+	got := albums[0]
+	if got.PurchasePrice == nil || *got.PurchasePrice != 24.5 {
+		t.Errorf("PurchasePrice = %v, want 24.5", got.PurchasePrice)
+	}
+	if got.DiscogsAddedAt == nil || !got.DiscogsAddedAt.Equal(added) {
+		t.Errorf("DiscogsAddedAt = %v, want %v", got.DiscogsAddedAt, added)
+	}
+	got.PurchasePrice, album.PurchasePrice = nil, nil
+	got.DiscogsAddedAt, album.DiscogsAddedAt = nil, nil
+	if *got.DiscogsID != *album.DiscogsID {
+		t.Errorf("DiscogsID = %d, want %d", *got.DiscogsID, *album.DiscogsID)
+	}
+	got.DiscogsID, album.DiscogsID = nil, nil
+	if got.Title != album.Title || got.Format != album.Format || got.FormatDetails != album.FormatDetails ||
+		got.DiscCount != album.DiscCount || got.MediaCondition != album.MediaCondition || got.SleeveCondition != album.SleeveCondition ||
+		got.Barcode != album.Barcode || got.MatrixRunout != album.MatrixRunout || got.PurchaseCurrency != album.PurchaseCurrency ||
+		got.PurchaseDate != album.PurchaseDate || got.StorageLocation != album.StorageLocation || got.Notes != album.Notes ||
+		got.DiscogsFolderID != album.DiscogsFolderID || got.Rating != album.Rating || got.CatalogNumber != album.CatalogNumber {
+		t.Errorf("round trip changed the album:\n got %+v\nwant %+v", got, album)
+	}
+}
+
+func TestImportCollection(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&models.TrackYouTubeMatch{}, &models.TrackHistory{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	synced := models.Album{Title: "Rumours", Artist: "Fleetwood Mac", DiscogsID: utils.IntPtr(11), Notes: "Mine"}
+	manual := models.Album{Title: "Blue Train", Artist: "John Coltrane"}
+	for _, a := range []*models.Album{&synced, &manual} {
+		if err := db.Create(a).Error; err != nil {
+			t.Fatalf("create album: %v", err)
+		}
+	}
+	dreams := models.Track{AlbumID: synced.ID, Title: "Dreams", Position: "A2"}
+	if err := db.Create(&dreams).Error; err != nil {
+		t.Fatalf("create track: %v", err)
+	}
+
+	played := time.Date(2024, 6, 1, 20, 0, 0, 0, time.UTC)
+	albums := []CollectionAlbum{
+		{
+			Title: "Rumours", Artist: "Fleetwood Mac", DiscogsID: utils.IntPtr(11),
+			MediaCondition: models.GradeVeryGoodPlus, Notes: "Theirs",
+			Tracks: []CollectionTrack{
+				{Title: "Dreams", Position: "A2", Duration: 257, DurationSource: "manual",
+					YouTube: &CollectionYouTube{VideoID: "mrZRURcb1cM"},
+					Plays:   []CollectionPlay{{PlaylistID: "road-trip", ListenCount: 3, LastPlayed: played}}},
+				{Title: "Go Your Own Way", Position: "B1", Duration: 223},
+			},
+		},
+		{Title: "blue train", Artist: "john coltrane", DiscogsID: utils.IntPtr(22), ReleaseYear: 1957},
+		{Title: "Kind of Blue", Artist: "Miles Davis", Tracks: []CollectionTrack{{Title: "So What", Duration: 562}}},
+		{Title: "Kind Of Blue", Artist: "Miles Davis"},
+		{Title: "", Artist: "Nobody"},
+	}
+
+	preview, err := ImportCollection(db, albums, ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("ImportCollection dry run: %v", err)
+	}
+	if preview.Created != 1 || preview.Updated != 2 || preview.Skipped != 2 || preview.Conflicts != 1 {
+		t.Errorf("dry run = %+v", preview)
+	}
+
+	rumours := preview.Albums[0]
+	if rumours.AlbumID != synced.ID || rumours.MatchedBy != MatchedByDiscogsID || rumours.NewTracks != 1 || rumours.Plays != 1 {
+		t.Errorf("unexpected review: %+v", rumours)
+	}
+	changes := make(map[string]discogs.FieldChange)
+	for _, c := range rumours.Changes {
+		changes[c.Field] = c
+	}
+	if c := changes["media_condition"]; c.Severity != discogs.SeverityInfo || c.NewValue != models.GradeVeryGoodPlus {
+		t.Errorf("media_condition change = %+v", c)
+	}
+	if c := changes["notes"]; c.Severity != discogs.SeverityConflict || c.CurrentValue != "Mine" {
+		t.Errorf("notes change = %+v", c)
+	}
+	if _, ok := changes["track A2 duration"]; !ok {
+		t.Errorf("no duration change in %+v", rumours.Changes)
+	}
+	if rumours.CanAutoApply {
+		t.Error("review with a conflict can be auto-applied without overwrite")
+	}
+	if coltrane := preview.Albums[1]; coltrane.AlbumID != manual.ID || coltrane.MatchedBy != MatchedByTitleArtist {
+		t.Errorf("manual album not matched by title and artist: %+v", coltrane)
+	}
+
+	var count int64
+	db.Model(&models.Album{}).Count(&count)
+	if count != 2 {
+		t.Fatalf("dry run wrote albums: %d albums", count)
+	}
+
+	result, err := ImportCollection(db, albums, ImportOptions{})
+	if err != nil {
+		t.Fatalf("ImportCollection: %v", err)
+	}
+	if result.Created != 1 || result.Updated != 2 {
+		t.Errorf("import = %+v", result)
+	}
+
+	var saved models.Album
+	db.First(&saved, synced.ID)
+	if saved.MediaCondition != models.GradeVeryGoodPlus || saved.Notes != "Mine" {
+		t.Errorf("media_condition = %q, notes = %q; want the empty value filled and the conflict kept", saved.MediaCondition, saved.Notes)
+	}
+	saved = models.Album{}
+	db.First(&saved, manual.ID)
+	if saved.DiscogsID == nil || *saved.DiscogsID != 22 || saved.ReleaseYear != 1957 {
+		t.Errorf("manual album not updated: %+v", saved)
+	}
+	db.First(&dreams, dreams.ID)
+	if dreams.Duration != 257 || dreams.DurationSource != "manual" {
+		t.Errorf("duration = %d from %q, want 257 from manual", dreams.Duration, dreams.DurationSource)
+	}
+	var match models.TrackYouTubeMatch
+	if err := db.Where("track_id = ?", dreams.ID).First(&match).Error; err != nil || match.YouTubeVideoID != "mrZRURcb1cM" {
+		t.Errorf("YouTube match = %+v, %v", match, err)
+	}
+	var history models.TrackHistory
+	if err := db.Where("track_id = ?", dreams.ID).First(&history).Error; err != nil || history.ListenCount != 3 {
+		t.Errorf("play history = %+v, %v", history, err)
+	}
+	db.Model(&models.Track{}).Where("album_id = ?", synced.ID).Count(&count)
+	if count != 2 {
+		t.Errorf("Rumours has %d tracks, want 2", count)
+	}
+
+	// Importing the collection's own export changes nothing
+	export, err := ExportCollection(db)
+	if err != nil {
+		t.Fatalf("ExportCollection: %v", err)
+	}
+	if export.Version != CollectionSchemaVersion || len(export.Albums) != 3 {
+		t.Fatalf("export has version %d and %d albums", export.Version, len(export.Albums))
+	}
+	again, err := ImportCollection(db, export.Albums, ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("ImportCollection of export: %v", err)
+	}
+	if again.Unchanged != 3 {
+		for _, review := range again.Albums {
+			t.Logf("%s: %s %+v", review.Title, review.Action, review.Changes)
+		}
+		t.Errorf("re-import = %+v, want every album unchanged", again)
+	}
+
+	// Overwrite replaces the conflicting value
+	if _, err := ImportCollection(db, albums[:1], ImportOptions{Overwrite: true}); err != nil {
+		t.Fatalf("ImportCollection with overwrite: %v", err)
+	}
+	saved = models.Album{}
+	db.First(&saved, synced.ID)
+	if saved.Notes != "Theirs" {
+		t.Errorf("notes = %q after overwrite, want Theirs", saved.Notes)
+	}
+}
+
+func TestParseCollectionJSON(t *testing.T) {
+	export, err := ParseCollectionJSON(strings.NewReader(`{"version": 1, "albums": [{"title": "Rumours", "artist": "Fleetwood Mac", "tracks": [{"title": "Dreams", "duration": 257}]}]}`))
+	if err != nil {
+		t.Fatalf("ParseCollectionJSON: %v", err)
+	}
+	if len(export.Albums) != 1 || export.Albums[0].Tracks[0].Duration != 257 {
+		t.Errorf("unexpected export: %+v", export)
+	}
+
+	if _, err := ParseCollectionJSON(strings.NewReader(`{"albums": []}`)); err == nil {
+		t.Error("ParseCollectionJSON accepted JSON without a version")
+	}
+	if _, err := ParseCollectionJSON(strings.NewReader(`{"version": 99, "albums": []}`)); err == nil {
+		t.Error("ParseCollectionJSON accepted a newer version")
+	}
+}
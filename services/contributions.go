@@ -0,0 +1,467 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// minContributionWeight is the consensus weight an automatically resolved duration needs before it
+// is offered to Discogs or MusicBrainz; durations approved in review are always offered
+const minContributionWeight = 2.0
+
+// contributionTolerance is how close a source's duration must be to the resolved one to be cited,
+// and how close a target's own duration must be for the track to be left out of its edit
+const contributionTolerance = 2
+
+// ContributionTrack is one track of a contribution payload
+type ContributionTrack struct {
+	Position     string   `json:"position"`
+	Title        string   `json:"title"`
+	Duration     string   `json:"duration"`                // m:ss, as both sites' editors take it
+	LengthMS     int      `json:"length_ms,omitempty"`     // MusicBrainz only
+	RecordingID  string   `json:"recording_id,omitempty"`  // MusicBrainz recording MBID
+	ResolutionID uint     `json:"resolution_id,omitempty"` // Zero for tracks that keep their current duration
+	Sources      []string `json:"sources,omitempty"`       // Pages that back the duration
+	Current      string   `json:"current,omitempty"`       // Duration the target lists now, if any
+}
+
+// DiscogsReleaseEdit is the tracklist to enter in the Discogs release editor
+// Discogs replaces the whole tracklist on save, so every track is listed, with the durations it
+// already has where nothing new was resolved
+type DiscogsReleaseEdit struct {
+	ReleaseID int                 `json:"release_id"`
+	Tracklist []ContributionTrack `json:"tracklist"`
+	Notes     string              `json:"notes"`
+}
+
+// MusicBrainzReleaseEdit lists the track lengths to set in the MusicBrainz release editor
+type MusicBrainzReleaseEdit struct {
+	ReleaseID string              `json:"release_id"`
+	Tracks    []ContributionTrack `json:"tracks"`
+	EditNote  string              `json:"edit_note"`
+}
+
+// ContributionResult counts what GenerateContributions built
+type ContributionResult struct {
+	Contributions []models.DurationContribution `json:"contributions"`
+	Discogs       int                           `json:"discogs"`
+	MusicBrainz   int                           `json:"musicbrainz"`
+	Tracks        int                           `json:"tracks"`
+}
+
+// contributionEntry is an eligible resolution with its track and the sources agreeing with it
+type contributionEntry struct {
+	resolution models.DurationResolution
+	track      models.Track
+	sources    []models.DurationSource
+}
+
+func (e contributionEntry) duration() int {
+	return *e.resolution.ResolvedDuration
+}
+
+// citations returns the pages of the sources that back the resolved duration
+func (e contributionEntry) citations() []string {
+	var urls []string
+	for _, src := range e.sources {
+		if strings.HasPrefix(src.ExternalURL, "http://") || strings.HasPrefix(src.ExternalURL, "https://") {
+			urls = append(urls, src.ExternalURL)
+		}
+	}
+	return urls
+}
+
+// basis describes how the duration was arrived at, for the edit note
+func (e contributionEntry) basis() string {
+	var parts []string
+	for _, src := range e.sources {
+		if src.SourceName == "local_file" {
+			parts = append(parts, "measured from a rip of my own copy")
+			break
+		}
+	}
+	if e.resolution.ManuallyReviewed {
+		parts = append(parts, "checked by hand")
+	} else if e.resolution.ConsensusCount >= 2 {
+		parts = append(parts, fmt.Sprintf("%d sources agree", e.resolution.ConsensusCount))
+	} else {
+		parts = append(parts, "one source")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// contributionEligible reports whether a resolved duration is trustworthy enough to submit
+func contributionEligible(r models.DurationResolution) bool {
+	if r.ResolvedDuration == nil || *r.ResolvedDuration <= 0 {
+		return false
+	}
+	switch r.Status {
+	case "approved":
+		return true
+	case "resolved":
+		return r.ConsensusWeight >= minContributionWeight
+	}
+	return false
+}
+
+// GenerateContributions rebuilds the pending edit packages from the durations resolved so far
+// Resolutions already part of a submitted package are left out
+func GenerateContributions(db *gorm.DB) (*ContributionResult, error) {
+	var resolutions []models.DurationResolution
+	if err := db.Where("status IN ?", []string{"approved", "resolved"}).
+		Where("discogs_submitted_at IS NULL OR musicbrainz_submitted_at IS NULL").
+		Order("album_id, id").Find(&resolutions).Error; err != nil {
+		return nil, err
+	}
+
+	byAlbum := make(map[uint][]models.DurationResolution)
+	var albumIDs []uint
+	for _, r := range resolutions {
+		if !contributionEligible(r) {
+			continue
+		}
+		if _, seen := byAlbum[r.AlbumID]; !seen {
+			albumIDs = append(albumIDs, r.AlbumID)
+		}
+		byAlbum[r.AlbumID] = append(byAlbum[r.AlbumID], r)
+	}
+
+	result := &ContributionResult{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("status = ?", models.ContributionPending).Delete(&models.DurationContribution{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.DurationResolution{}).
+			Where("discogs_submittable = ? AND discogs_submitted_at IS NULL", true).
+			Update("discogs_submittable", false).Error; err != nil {
+			return err
+		}
+
+		for _, albumID := range albumIDs {
+			var album models.Album
+			if err := tx.First(&album, albumID).Error; err != nil {
+				continue
+			}
+			var tracks []models.Track
+			if err := tx.Where("album_id = ?", albumID).Find(&tracks).Error; err != nil {
+				return err
+			}
+			sortContributionTracks(tracks)
+			entries, err := contributionEntries(tx, byAlbum[albumID], tracks)
+			if err != nil {
+				return err
+			}
+
+			built := []*models.DurationContribution{
+				discogsContribution(&album, tracks, entries),
+				musicBrainzContribution(&album, tracks, entries),
+			}
+			for _, c := range built {
+				if c == nil {
+					continue
+				}
+				if err := tx.Create(c).Error; err != nil {
+					return err
+				}
+				if c.Target == models.ContributionDiscogs {
+					var ids []uint
+					if err := json.Unmarshal([]byte(c.ResolutionIDs), &ids); err != nil {
+						return err
+					}
+					if err := tx.Model(&models.DurationResolution{}).Where("id IN ?", ids).
+						Update("discogs_submittable", true).Error; err != nil {
+						return err
+					}
+					result.Discogs++
+				} else {
+					result.MusicBrainz++
+				}
+				result.Tracks += c.TrackCount
+				result.Contributions = append(result.Contributions, *c)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func sortContributionTracks(tracks []models.Track) {
+	sort.SliceStable(tracks, func(i, j int) bool {
+		if tracks[i].DiscNumber != tracks[j].DiscNumber {
+			return tracks[i].DiscNumber < tracks[j].DiscNumber
+		}
+		if tracks[i].TrackNumber != tracks[j].TrackNumber {
+			return tracks[i].TrackNumber < tracks[j].TrackNumber
+		}
+		return tracks[i].ID < tracks[j].ID
+	})
+}
+
+// contributionEntries pairs the album's eligible resolutions with their tracks and agreeing sources
+func contributionEntries(db *gorm.DB, resolutions []models.DurationResolution, tracks []models.Track) (map[uint]contributionEntry, error) {
+	byID := make(map[uint]models.Track, len(tracks))
+	for _, t := range tracks {
+		byID[t.ID] = t
+	}
+
+	entries := make(map[uint]contributionEntry)
+	for _, r := range resolutions {
+		track, ok := byID[r.TrackID]
+		if !ok {
+			continue
+		}
+		var sources []models.DurationSource
+		if err := db.Where("resolution_id = ? AND duration_value > 0", r.ID).Order("id").Find(&sources).Error; err != nil {
+			return nil, err
+		}
+		entry := contributionEntry{resolution: r, track: track}
+		for _, src := range sources {
+			if abs(src.DurationValue-*r.ResolvedDuration) <= contributionTolerance {
+				entry.sources = append(entry.sources, src)
+			}
+		}
+		entries[track.ID] = entry
+	}
+	return entries, nil
+}
+
+func contributionPosition(t models.Track) string {
+	switch {
+	case t.Position != "":
+		return t.Position
+	case t.Side != "":
+		return t.Side
+	case t.TrackNumber > 0:
+		return strconv.Itoa(t.TrackNumber)
+	}
+	return ""
+}
+
+// discogsContribution builds the album's Discogs release edit, or nil when it has nothing to add
+func discogsContribution(album *models.Album, tracks []models.Track, entries map[uint]contributionEntry) *models.DurationContribution {
+	if album.DiscogsID == nil {
+		return nil
+	}
+
+	edit := DiscogsReleaseEdit{ReleaseID: *album.DiscogsID}
+	var contributed []contributionEntry
+	for _, t := range tracks {
+		item := ContributionTrack{Position: contributionPosition(t), Title: t.Title}
+		entry, ok := entries[t.ID]
+		original := 0
+		if ok {
+			original = entry.resolution.OriginalDuration
+		}
+		switch {
+		case ok && entry.resolution.DiscogsSubmittedAt != nil:
+			item.Duration = formatSeconds(entry.duration()) // Entered with an earlier edit
+		case ok && (original == 0 || abs(original-entry.duration()) > contributionTolerance):
+			item.Duration = formatSeconds(entry.duration())
+			item.ResolutionID = entry.resolution.ID
+			item.Sources = entry.citations()
+			if original > 0 {
+				item.Current = formatSeconds(original)
+			}
+			contributed = append(contributed, entry)
+		case original > 0:
+			item.Duration = formatSeconds(original)
+		case !ok && t.Duration > 0 && t.DurationSource == "discogs":
+			item.Duration = formatSeconds(t.Duration)
+		}
+		edit.Tracklist = append(edit.Tracklist, item)
+	}
+	if len(contributed) == 0 {
+		return nil
+	}
+
+	edit.Notes = contributionNote(contributed)
+	return newContribution(album, models.ContributionDiscogs, strconv.Itoa(*album.DiscogsID),
+		fmt.Sprintf("https://www.discogs.com/release/edit/%d", *album.DiscogsID), edit, edit.Notes, contributed)
+}
+
+// musicBrainzContribution builds the album's MusicBrainz track length edit, or nil when it has
+// nothing to add; tracks whose MusicBrainz length already agrees are left out
+func musicBrainzContribution(album *models.Album, tracks []models.Track, entries map[uint]contributionEntry) *models.DurationContribution {
+	if album.MusicBrainzReleaseID == "" {
+		return nil
+	}
+
+	edit := MusicBrainzReleaseEdit{ReleaseID: album.MusicBrainzReleaseID}
+	var contributed []contributionEntry
+	for _, t := range tracks {
+		entry, ok := entries[t.ID]
+		if !ok || entry.resolution.MusicBrainzSubmittedAt != nil {
+			continue
+		}
+		if entry.agreesWith("musicbrainz") {
+			continue
+		}
+		item := ContributionTrack{
+			Position:     contributionPosition(t),
+			Title:        t.Title,
+			Duration:     formatSeconds(entry.duration()),
+			LengthMS:     entry.duration() * 1000,
+			RecordingID:  t.MusicBrainzRecordingID,
+			ResolutionID: entry.resolution.ID,
+			Sources:      entry.citations(),
+		}
+		edit.Tracks = append(edit.Tracks, item)
+		contributed = append(contributed, entry)
+	}
+	if len(contributed) == 0 {
+		return nil
+	}
+
+	edit.EditNote = contributionNote(contributed)
+	return newContribution(album, models.ContributionMusicBrainz, album.MusicBrainzReleaseID,
+		"https://musicbrainz.org/release/"+album.MusicBrainzReleaseID+"/edit", edit, edit.EditNote, contributed)
+}
+
+// agreesWith reports whether the named source reported the resolved duration
+func (e contributionEntry) agreesWith(name string) bool {
+	for _, src := range e.sources {
+		if src.SourceName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// contributionBasis summarizes how the durations of a package were resolved, for the edit note
+func contributionBasis(entries []contributionEntry) string {
+	var manual, consensus, single int
+	for _, e := range entries {
+		switch {
+		case e.resolution.ManuallyReviewed:
+			manual++
+		case e.resolution.ConsensusCount >= 2:
+			consensus++
+		default:
+			single++
+		}
+	}
+
+	var parts []string
+	if consensus > 0 {
+		parts = append(parts, fmt.Sprintf("%d confirmed by independent sources", consensus))
+	}
+	if manual > 0 {
+		parts = append(parts, fmt.Sprintf("%d checked by hand", manual))
+	}
+	if single > 0 {
+		parts = append(parts, fmt.Sprintf("%d from a single source", single))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// contributionNote explains the edit and cites the sources of every track, one line each
+func contributionNote(entries []contributionEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Adding track lengths for %d track(s), %s.\n", len(entries), contributionBasis(entries))
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\n%s %s: %s (%s)", contributionPosition(e.track), e.track.Title, formatSeconds(e.duration()), e.basis())
+		for _, url := range e.citations() {
+			fmt.Fprintf(&b, "\n  %s", url)
+		}
+	}
+	return b.String()
+}
+
+func newContribution(album *models.Album, target, releaseID, editURL string, payload interface{}, note string, entries []contributionEntry) *models.DurationContribution {
+	ids := make([]uint, len(entries))
+	for i, e := range entries {
+		ids[i] = e.resolution.ID
+	}
+	idsJSON, _ := json.Marshal(ids)
+	payloadJSON, _ := json.MarshalIndent(payload, "", "  ")
+	return &models.DurationContribution{
+		AlbumID:       album.ID,
+		Target:        target,
+		ReleaseID:     releaseID,
+		Title:         album.Title,
+		Artist:        album.Artist,
+		Status:        models.ContributionPending,
+		TrackCount:    len(entries),
+		ResolutionIDs: string(idsJSON),
+		Payload:       string(payloadJSON),
+		EditNote:      note,
+		EditURL:       editURL,
+	}
+}
+
+// UpdateContributionStatus records how far a contribution got on its target site
+// Submitted and accepted packages mark their resolutions as submitted, so they are not offered
+// again; moving a package back to pending or rejecting it clears that, so its durations are
+// offered in the next package
+func UpdateContributionStatus(db *gorm.DB, id uint, status, submissionID, submissionURL string) (*models.DurationContribution, error) {
+	switch status {
+	case models.ContributionPending, models.ContributionSubmitted, models.ContributionAccepted, models.ContributionRejected:
+	default:
+		return nil, fmt.Errorf("invalid status: %s", status)
+	}
+
+	var contribution models.DurationContribution
+	if err := db.First(&contribution, id).Error; err != nil {
+		return nil, err
+	}
+	var ids []uint
+	if err := json.Unmarshal([]byte(contribution.ResolutionIDs), &ids); err != nil {
+		return nil, fmt.Errorf("invalid resolution IDs: %w", err)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		contribution.Status = status
+		if submissionID != "" {
+			contribution.SubmissionID = submissionID
+		}
+		if submissionURL != "" {
+			contribution.SubmissionURL = submissionURL
+		}
+
+		var submittedAt *time.Time
+		switch status {
+		case models.ContributionSubmitted, models.ContributionAccepted:
+			if contribution.SubmittedAt == nil {
+				contribution.SubmittedAt = timePtr(time.Now())
+			}
+			submittedAt = contribution.SubmittedAt
+		case models.ContributionPending:
+			contribution.SubmittedAt = nil
+		}
+		if err := tx.Save(&contribution).Error; err != nil {
+			return err
+		}
+
+		recordedID := contribution.SubmissionID
+		if submittedAt == nil {
+			recordedID = ""
+		}
+		updates := map[string]interface{}{
+			"musicbrainz_submitted_at": submittedAt,
+			"musicbrainz_edit_id":      recordedID,
+		}
+		if contribution.Target == models.ContributionDiscogs {
+			updates = map[string]interface{}{
+				"discogs_submitted_at":  submittedAt,
+				"discogs_submission_id": recordedID,
+				"discogs_submittable":   submittedAt == nil,
+			}
+		}
+		return tx.Model(&models.DurationResolution{}).Where("id IN ?", ids).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &contribution, nil
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+func seedContributionAlbum(t *testing.T, db *gorm.DB) (models.Album, []models.Track) {
+	t.Helper()
+	if err := db.AutoMigrate(&models.DurationContribution{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	discogsID := 1234
+	album := models.Album{Title: "Blue Train", Artist: "John Coltrane", DiscogsID: &discogsID, MusicBrainzReleaseID: "0b8cdb4e-8d1d-4a2c-9f3f-1b6e3c7a1d11"}
+	if err := db.Create(&album).Error; err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	tracks := []models.Track{
+		{AlbumID: album.ID, Title: "Blue Train", TrackNumber: 1, Position: "A1"},
+		{AlbumID: album.ID, Title: "Moment's Notice", TrackNumber: 2, Position: "A2"},
+		{AlbumID: album.ID, Title: "Locomotion", TrackNumber: 3, Position: "B1", Duration: 434},
+	}
+	for i := range tracks {
+		if err := db.Create(&tracks[i]).Error; err != nil {
+			t.Fatalf("create track: %v", err)
+		}
+	}
+	return album, tracks
+}
+
+func seedContributionResolution(t *testing.T, db *gorm.DB, track models.Track, r models.DurationResolution, sources ...models.DurationSource) models.DurationResolution {
+	t.Helper()
+	r.TrackID = track.ID
+	r.AlbumID = track.AlbumID
+	if err := db.Create(&r).Error; err != nil {
+		t.Fatalf("create resolution: %v", err)
+	}
+	for _, src := range sources {
+		src.ResolutionID = r.ID
+		if err := db.Create(&src).Error; err != nil {
+			t.Fatalf("create source: %v", err)
+		}
+	}
+	return r
+}
+
+func TestGenerateContributions_BuildsReleaseEdits(t *testing.T) {
+	db := newTestDB(t)
+	_, tracks := seedContributionAlbum(t, db)
+
+	blueTrain := 643
+	approved := seedContributionResolution(t, db, tracks[0],
+		models.DurationResolution{Status: "approved", ResolvedDuration: &blueTrain, ManuallyReviewed: true},
+		models.DurationSource{SourceName: "discogs", DurationValue: 643, ExternalURL: "https://www.discogs.com/release/99"},
+		models.DurationSource{SourceName: "youtube", DurationValue: 660, ExternalURL: "https://www.youtube.com/watch?v=x"},
+	)
+	notice := 551
+	resolved := seedContributionResolution(t, db, tracks[1],
+		models.DurationResolution{Status: "resolved", ResolvedDuration: &notice, ConsensusCount: 2, ConsensusWeight: 2.4},
+		models.DurationSource{SourceName: "musicbrainz", DurationValue: 551, ExternalURL: "https://musicbrainz.org/recording/abc"},
+		models.DurationSource{SourceName: "lastfm", DurationValue: 552, ExternalURL: "https://www.last.fm/music/x"},
+	)
+
+	result, err := GenerateContributions(db)
+	if err != nil {
+		t.Fatalf("GenerateContributions() error = %v", err)
+	}
+	if result.Discogs != 1 || result.MusicBrainz != 1 {
+		t.Fatalf("built %d Discogs and %d MusicBrainz packages, want 1 each", result.Discogs, result.MusicBrainz)
+	}
+
+	var discogs, mb models.DurationContribution
+	db.Where("target = ?", models.ContributionDiscogs).First(&discogs)
+	db.Where("target = ?", models.ContributionMusicBrainz).First(&mb)
+
+	var edit DiscogsReleaseEdit
+	if err := json.Unmarshal([]byte(discogs.Payload), &edit); err != nil {
+		t.Fatalf("Discogs payload: %v", err)
+	}
+	if edit.ReleaseID != 1234 || len(edit.Tracklist) != 3 {
+		t.Fatalf("Discogs edit = release %d with %d tracks, want release 1234 with the full tracklist", edit.ReleaseID, len(edit.Tracklist))
+	}
+	if edit.Tracklist[0].Duration != "10:43" || edit.Tracklist[1].Duration != "9:11" || edit.Tracklist[2].Duration != "7:14" {
+		t.Errorf("Discogs durations = %s, %s, %s", edit.Tracklist[0].Duration, edit.Tracklist[1].Duration, edit.Tracklist[2].Duration)
+	}
+	if discogs.TrackCount != 2 || discogs.EditURL != "https://www.discogs.com/release/edit/1234" {
+		t.Errorf("Discogs contribution = %d tracks at %s", discogs.TrackCount, discogs.EditURL)
+	}
+	if !strings.Contains(discogs.EditNote, "https://www.discogs.com/release/99") || strings.Contains(discogs.EditNote, "youtube.com") {
+		t.Errorf("edit note should cite only agreeing sources:\n%s", discogs.EditNote)
+	}
+	if !strings.Contains(discogs.EditNote, "2 track(s), 1 confirmed by independent sources, 1 checked by hand.") {
+		t.Errorf("edit note should say how each duration was resolved:\n%s", discogs.EditNote)
+	}
+
+	// MusicBrainz already lists Moment's Notice at the resolved length
+	var mbEdit MusicBrainzReleaseEdit
+	if err := json.Unmarshal([]byte(mb.Payload), &mbEdit); err != nil {
+		t.Fatalf("MusicBrainz payload: %v", err)
+	}
+	if len(mbEdit.Tracks) != 1 || mbEdit.Tracks[0].ResolutionID != approved.ID || mbEdit.Tracks[0].LengthMS != 643000 {
+		t.Errorf("MusicBrainz tracks = %+v, want only Blue Train at 643000 ms", mbEdit.Tracks)
+	}
+
+	var reloaded models.DurationResolution
+	db.First(&reloaded, resolved.ID)
+	if !reloaded.DiscogsSubmittable {
+		t.Error("resolution in a Discogs package should be marked submittable")
+	}
+}
+
+func TestGenerateContributions_SkipsWeakConsensus(t *testing.T) {
+	db := newTestDB(t)
+	_, tracks := seedContributionAlbum(t, db)
+
+	d := 643
+	seedContributionResolution(t, db, tracks[0],
+		models.DurationResolution{Status: "resolved", ResolvedDuration: &d, ConsensusCount: 2, ConsensusWeight: 1.6},
+		models.DurationSource{SourceName: "lastfm", DurationValue: 643},
+	)
+
+	result, err := GenerateContributions(db)
+	if err != nil {
+		t.Fatalf("GenerateContributions() error = %v", err)
+	}
+	if len(result.Contributions) != 0 {
+		t.Errorf("built %d packages from a weak consensus, want none", len(result.Contributions))
+	}
+}
+
+func TestUpdateContributionStatus_SubmittedIsNotRegenerated(t *testing.T) {
+	db := newTestDB(t)
+	_, tracks := seedContributionAlbum(t, db)
+
+	d := 643
+	resolution := seedContributionResolution(t, db, tracks[0],
+		models.DurationResolution{Status: "approved", ResolvedDuration: &d, ManuallyReviewed: true})
+
+	if _, err := GenerateContributions(db); err != nil {
+		t.Fatalf("GenerateContributions() error = %v", err)
+	}
+	var discogs models.DurationContribution
+	db.Where("target = ?", models.ContributionDiscogs).First(&discogs)
+
+	updated, err := UpdateContributionStatus(db, discogs.ID, models.ContributionSubmitted, "55512", "")
+	if err != nil {
+		t.Fatalf("UpdateContributionStatus() error = %v", err)
+	}
+	if updated.SubmittedAt == nil {
+		t.Error("submitted contribution should have a submission time")
+	}
+
+	var reloaded models.DurationResolution
+	db.First(&reloaded, resolution.ID)
+	if reloaded.DiscogsSubmittedAt == nil || reloaded.DiscogsSubmissionID != "55512" || reloaded.DiscogsSubmittable {
+		t.Errorf("resolution not marked submitted: %+v", reloaded)
+	}
+
+	result, err := GenerateContributions(db)
+	if err != nil {
+		t.Fatalf("GenerateContributions() error = %v", err)
+	}
+	if result.Discogs != 0 || result.MusicBrainz != 1 {
+		t.Errorf("regenerated %d Discogs and %d MusicBrainz packages, want only the MusicBrainz one", result.Discogs, result.MusicBrainz)
+	}
+
+	if _, err := UpdateContributionStatus(db, discogs.ID, "sent", "", ""); err == nil {
+		t.Error("expected an error for an unknown status")
+	}
+}
+
+func TestUpdateContributionStatus_RejectedIsOfferedAgain(t *testing.T) {
+	db := newTestDB(t)
+	_, tracks := seedContributionAlbum(t, db)
+
+	d := 643
+	resolution := seedContributionResolution(t, db, tracks[0],
+		models.DurationResolution{Status: "approved", ResolvedDuration: &d, ManuallyReviewed: true})
+
+	if _, err := GenerateContributions(db); err != nil {
+		t.Fatalf("GenerateContributions() error = %v", err)
+	}
+	var discogs models.DurationContribution
+	db.Where("target = ?", models.ContributionDiscogs).First(&discogs)
+
+	if _, err := UpdateContributionStatus(db, discogs.ID, models.ContributionSubmitted, "55512", ""); err != nil {
+		t.Fatalf("UpdateContributionStatus(submitted) error = %v", err)
+	}
+	if _, err := UpdateContributionStatus(db, discogs.ID, models.ContributionRejected, "", ""); err != nil {
+		t.Fatalf("UpdateContributionStatus(rejected) error = %v", err)
+	}
+
+	var reloaded models.DurationResolution
+	db.First(&reloaded, resolution.ID)
+	if reloaded.DiscogsSubmittedAt != nil || reloaded.DiscogsSubmissionID != "" || !reloaded.DiscogsSubmittable {
+		t.Errorf("rejected resolution still marked submitted: %+v", reloaded)
+	}
+
+	result, err := GenerateContributions(db)
+	if err != nil {
+		t.Fatalf("GenerateContributions() error = %v", err)
+	}
+	if result.Discogs != 1 || result.MusicBrainz != 1 {
+		t.Errorf("regenerated %d Discogs and %d MusicBrainz packages, want the rejected Discogs one offered again", result.Discogs, result.MusicBrainz)
+	}
+}
@@ -0,0 +1,405 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"vinylfo/coverart"
+	"vinylfo/imagestore"
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// minCoverSide is the longest side below which the cover refresh looks for a larger cover
+	minCoverSide = 500
+
+	// coverRecheckInterval is how long the cover refresh leaves an album alone after looking at it
+	coverRecheckInterval = 30 * 24 * time.Hour
+
+	// coverRefreshBatch caps how many albums one cover refresh looks at
+	coverRefreshBatch = 200
+
+	// maxCoverCandidates caps how many candidates the cover refresh downloads for one album
+	maxCoverCandidates = 4
+)
+
+var (
+	ErrNotACoverCandidate = errors.New("not one of the album's cover candidates")
+	ErrNoImageStore       = errors.New("image store unavailable")
+)
+
+// coverColumns are the album columns the cover art service reads, leaving out legacy cover blobs
+const coverColumns = "id, title, artist, musicbrainz_release_id, musicbrainz_release_group_id, " +
+	"cover_image_url, cover_image_hash, cover_image_source, cover_image_width, cover_image_height, cover_image_failed"
+
+// CoverArtService finds covers for albums Discogs has none for, or only a small one
+// Providers are asked in order: local files, the Cover Art Archive, then iTunes
+type CoverArtService struct {
+	db        *gorm.DB
+	importer  *AlbumImporter
+	providers []coverart.Provider
+	local     *coverart.LocalProvider
+}
+
+// CoverRefreshResult counts what a cover refresh did
+type CoverRefreshResult struct {
+	Measured int `json:"measured"` // Covers whose size was not known yet
+	Checked  int `json:"checked"`
+	Found    int `json:"found"`    // Albums without a cover that got one
+	Upgraded int `json:"upgraded"` // Small covers replaced by larger ones
+}
+
+func NewCoverArtService(db *gorm.DB) *CoverArtService {
+	local := coverart.NewLocalProvider()
+	return &CoverArtService{
+		db:       db,
+		importer: NewAlbumImporter(db, nil),
+		providers: []coverart.Provider{
+			local,
+			coverart.NewCoverArtArchiveClient(),
+			coverart.NewITunesClient(),
+		},
+		local: local,
+	}
+}
+
+// syncsDiscogsCover reports whether a sync may replace the album's cover with the Discogs one;
+// covers found elsewhere or picked by the user are kept
+func syncsDiscogsCover(album *models.Album) bool {
+	return album.CoverImageSource == "" || album.CoverImageSource == coverart.SourceDiscogs
+}
+
+func (s *CoverArtService) loadAlbum(albumID uint) (*models.Album, error) {
+	var album models.Album
+	if err := s.db.Select(coverColumns).First(&album, albumID).Error; err != nil {
+		return nil, err
+	}
+	return &album, nil
+}
+
+// query describes the album to the providers
+func (s *CoverArtService) query(album *models.Album) coverart.Album {
+	q := coverart.Album{
+		Title:                     album.Title,
+		Artist:                    album.Artist,
+		MusicBrainzReleaseID:      album.MusicBrainzReleaseID,
+		MusicBrainzReleaseGroupID: album.MusicBrainzReleaseGroupID,
+	}
+
+	var paths []string
+	s.db.Model(&models.AudioFile{}).
+		Joins("JOIN tracks ON tracks.id = audio_files.track_id").
+		Where("tracks.album_id = ?", album.ID).
+		Pluck("audio_files.path", &paths)
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		dir := parentDir(path)
+		if !seen[dir] {
+			seen[dir] = true
+			q.Dirs = append(q.Dirs, dir)
+		}
+	}
+	return q
+}
+
+// parentDir returns the folder of a library path, whether it was scanned on Windows or not
+func parentDir(path string) string {
+	i := strings.LastIndexAny(path, `/\`)
+	if i <= 0 {
+		return "."
+	}
+	return path[:i]
+}
+
+// Candidates lists the covers the album could have: its Discogs front images and what each
+// provider offers
+// Local files are added to the image store so they can be previewed
+func (s *CoverArtService) Candidates(ctx context.Context, albumID uint) ([]coverart.Candidate, error) {
+	album, err := s.loadAlbum(albumID)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []coverart.Candidate
+	var fronts []models.AlbumImage
+	s.db.Where("album_id = ? AND kind = ?", album.ID, models.ImageFront).Order("position").Find(&fronts)
+	for _, img := range fronts {
+		candidates = append(candidates, coverart.Candidate{
+			Source:       coverart.SourceDiscogs,
+			URL:          img.SourceURL,
+			ThumbnailURL: "/images/" + img.Hash + "?size=small",
+			Width:        img.Width,
+			Height:       img.Height,
+			Description:  "Discogs release",
+		})
+	}
+	if len(fronts) == 0 && isRemoteURL(album.CoverImageURL) && syncsDiscogsCover(album) {
+		candidates = append(candidates, coverart.Candidate{
+			Source:       coverart.SourceDiscogs,
+			URL:          album.CoverImageURL,
+			ThumbnailURL: album.CoverImageURL,
+			Description:  "Discogs release",
+		})
+	}
+
+	query := s.query(album)
+	for _, provider := range s.providers {
+		found, err := provider.Candidates(ctx, query)
+		if err != nil {
+			log.Printf("CoverArt: %s lookup failed for album %d: %v", provider.Name(), album.ID, err)
+			continue
+		}
+		for _, c := range found {
+			if c.Source == coverart.SourceLocal {
+				s.previewLocal(&c)
+			}
+			candidates = append(candidates, c)
+		}
+	}
+	return candidates, nil
+}
+
+func (s *CoverArtService) previewLocal(c *coverart.Candidate) {
+	store := imagestore.Default()
+	if store == nil {
+		return
+	}
+	data, err := os.ReadFile(c.URL)
+	if err != nil {
+		return
+	}
+	if info, err := store.Put(data); err == nil {
+		c.ThumbnailURL = "/images/" + info.Hash + "?size=small"
+		c.Width, c.Height = info.Width, info.Height
+	}
+}
+
+func isRemoteURL(u string) bool {
+	return strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://")
+}
+
+// fetch downloads a candidate, or reads it when it is a local file the album offers
+func (s *CoverArtService) fetch(query coverart.Album, c coverart.Candidate) ([]byte, error) {
+	if c.Source == coverart.SourceLocal {
+		if !s.local.IsCandidate(query, c.URL) {
+			return nil, ErrNotACoverCandidate
+		}
+		return os.ReadFile(c.URL)
+	}
+	if !isRemoteURL(c.URL) {
+		return nil, ErrNotACoverCandidate
+	}
+	data, _, err := s.importer.DownloadCoverImageWithRetry(c.URL, 2)
+	if err == nil && len(data) == 0 {
+		err = fmt.Errorf("empty image")
+	}
+	return data, err
+}
+
+// Apply makes a candidate the album's cover
+func (s *CoverArtService) Apply(ctx context.Context, albumID uint, c coverart.Candidate) (*models.Album, error) {
+	store := imagestore.Default()
+	if store == nil {
+		return nil, ErrNoImageStore
+	}
+	album, err := s.loadAlbum(albumID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.fetch(s.query(album), c)
+	if err != nil {
+		return nil, err
+	}
+	info, err := store.Put(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.setCover(album, c, info); err != nil {
+		return nil, err
+	}
+	return album, nil
+}
+
+func (s *CoverArtService) setCover(album *models.Album, c coverart.Candidate, info *imagestore.Info) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"cover_image_hash":         info.Hash,
+		"cover_image_url":          c.URL,
+		"cover_image_source":       c.Source,
+		"cover_image_width":        info.Width,
+		"cover_image_height":       info.Height,
+		"cover_image_failed":       false,
+		"cover_checked_at":         now,
+		"discogs_cover_image":      nil,
+		"discogs_cover_image_type": info.ContentType,
+	}
+	for column, value := range CoverPaletteUpdates(info.Hash) {
+		updates[column] = value
+	}
+	if err := s.db.Model(album).Updates(updates).Error; err != nil {
+		return err
+	}
+	album.CoverImageHash = info.Hash
+	album.CoverImageURL = c.URL
+	album.CoverImageSource = c.Source
+	album.CoverImageWidth, album.CoverImageHeight = info.Width, info.Height
+	album.CoverImageFailed = false
+	album.CoverCheckedAt = &now
+	return nil
+}
+
+// discard removes a downloaded candidate that was not used, unless an album refers to it
+func (s *CoverArtService) discard(hash string) {
+	var refs int64
+	s.db.Model(&models.Album{}).Where("cover_image_hash = ?", hash).Count(&refs)
+	if refs == 0 {
+		s.db.Model(&models.AlbumImage{}).Where("hash = ?", hash).Count(&refs)
+	}
+	if refs == 0 {
+		if store := imagestore.Default(); store != nil {
+			store.Delete(hash)
+		}
+	}
+}
+
+// measure records the size of covers stored before it was known
+func (s *CoverArtService) measure(ctx context.Context, store *imagestore.Store) (int, error) {
+	measured := 0
+	var lastID uint
+	for {
+		var albums []models.Album
+		err := s.db.Select("id, cover_image_hash").
+			Where("id > ? AND cover_image_hash <> '' AND cover_image_width = 0", lastID).
+			Order("id").Limit(coverRefreshBatch).Find(&albums).Error
+		if err != nil || len(albums) == 0 {
+			return measured, err
+		}
+		for _, album := range albums {
+			if err := ctx.Err(); err != nil {
+				return measured, err
+			}
+			lastID = album.ID
+			info, err := store.Info(album.CoverImageHash)
+			if err != nil || info.Width == 0 {
+				continue
+			}
+			s.db.Model(&models.Album{}).Where("id = ?", album.ID).UpdateColumns(map[string]interface{}{
+				"cover_image_width":  info.Width,
+				"cover_image_height": info.Height,
+			})
+			measured++
+		}
+	}
+}
+
+// RefreshCovers looks for covers for albums whose Discogs cover failed to download or that
+// have none, and for larger covers for albums whose cover is under minCoverSide pixels
+// Covers picked by hand are never replaced, and albums are looked at again after
+// coverRecheckInterval at the earliest
+func (s *CoverArtService) RefreshCovers(ctx context.Context, progress func(done, total int, last string) error) (*CoverRefreshResult, error) {
+	store := imagestore.Default()
+	if store == nil {
+		return nil, ErrNoImageStore
+	}
+
+	result := &CoverRefreshResult{}
+	measured, err := s.measure(ctx, store)
+	result.Measured = measured
+	if err != nil {
+		return result, err
+	}
+
+	var albums []models.Album
+	err = s.db.Select(coverColumns).
+		Where("cover_checked_at IS NULL OR cover_checked_at < ?", time.Now().Add(-coverRecheckInterval)).
+		Where(s.db.Where("cover_image_failed = ?", true).
+			Or("(cover_image_hash = '' OR cover_image_hash IS NULL) AND discogs_cover_image IS NULL").
+			Or("cover_image_width > 0 AND cover_image_width < ? AND cover_image_height < ? AND cover_image_source <> ?",
+				minCoverSide, minCoverSide, coverart.SourceManual)).
+		Order("id").Limit(coverRefreshBatch).Find(&albums).Error
+	if err != nil {
+		return result, err
+	}
+
+	for i := range albums {
+		album := &albums[i]
+		if progress != nil {
+			if err := progress(i, len(albums), album.Artist+" - "+album.Title); err != nil {
+				return result, err
+			}
+		}
+		missing := album.CoverImageFailed || album.CoverImageHash == ""
+		if s.refreshCover(ctx, store, album, missing) {
+			if missing {
+				result.Found++
+			} else {
+				result.Upgraded++
+			}
+		} else {
+			s.db.Model(album).UpdateColumn("cover_checked_at", time.Now())
+		}
+		result.Checked++
+	}
+	if progress != nil {
+		progress(len(albums), len(albums), "")
+	}
+	return result, ctx.Err()
+}
+
+// refreshCover tries the album's candidates until one can replace its cover
+func (s *CoverArtService) refreshCover(ctx context.Context, store *imagestore.Store, album *models.Album, missing bool) bool {
+	query := s.query(album)
+
+	var candidates []coverart.Candidate
+	if missing && isRemoteURL(album.CoverImageURL) && syncsDiscogsCover(album) {
+		candidates = append(candidates, coverart.Candidate{Source: coverart.SourceDiscogs, URL: album.CoverImageURL})
+	}
+	for _, provider := range s.providers {
+		found, err := provider.Candidates(ctx, query)
+		if err != nil {
+			log.Printf("CoverArt: %s lookup failed for album %d: %v", provider.Name(), album.ID, err)
+			continue
+		}
+		candidates = append(candidates, found...)
+	}
+
+	currentSide := max(album.CoverImageWidth, album.CoverImageHeight)
+	for i, c := range candidates {
+		if i == maxCoverCandidates || ctx.Err() != nil {
+			break
+		}
+		if c.URL == album.CoverImageURL && !missing {
+			continue
+		}
+		data, err := s.fetch(query, c)
+		if err != nil {
+			log.Printf("CoverArt: failed to fetch %s cover for album %d: %v", c.Source, album.ID, err)
+			continue
+		}
+		info, err := store.Put(data)
+		if err != nil {
+			continue
+		}
+		if !missing && max(info.Width, info.Height) <= currentSide {
+			if info.Hash != album.CoverImageHash {
+				s.discard(info.Hash)
+			}
+			continue
+		}
+		if err := s.setCover(album, c, info); err != nil {
+			log.Printf("CoverArt: failed to save cover of album %d: %v", album.ID, err)
+			return false
+		}
+		log.Printf("CoverArt: album %d now has a %dx%d %s cover", album.ID, info.Width, info.Height, c.Source)
+		return true
+	}
+	return false
+}
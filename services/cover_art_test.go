@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"vinylfo/coverart"
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// newTestCoverArt returns a cover art service that only looks at local files
+func newTestCoverArt(t *testing.T) (*CoverArtService, *gorm.DB) {
+	t.Helper()
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&models.AudioFile{}, &models.AlbumImage{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+	local := coverart.NewLocalProvider()
+	return &CoverArtService{
+		db:        db,
+		importer:  NewAlbumImporter(db, nil),
+		providers: []coverart.Provider{local},
+		local:     local,
+	}, db
+}
+
+// addAlbumFolder creates an album whose one track is ripped to dir
+func addAlbumFolder(t *testing.T, db *gorm.DB, album *models.Album, dir string) {
+	t.Helper()
+	if err := db.Create(album).Error; err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	if dir == "" {
+		return
+	}
+	track := models.Track{AlbumID: album.ID, Title: "Track 1", TrackNumber: 1}
+	db.Create(&track)
+	db.Create(&models.AudioFile{Path: filepath.Join(dir, album.Title+".flac"), TrackID: &track.ID})
+}
+
+func TestRefreshCovers_FindsMissingAndUpgradesSmallCovers(t *testing.T) {
+	covers, db := newTestCoverArt(t)
+	store := newTestImageStore(t)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "folder.jpg"), pngBytes(t, 800, 800), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	small, err := store.Put(pngBytes(t, 100, 100))
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	missing := models.Album{Title: "Blue Train", Artist: "John Coltrane", CoverImageFailed: true}
+	lowRes := models.Album{Title: "Kind of Blue", Artist: "Miles Davis", CoverImageHash: small.Hash, CoverImageSource: coverart.SourceDiscogs}
+	manual := models.Album{Title: "Giant Steps", Artist: "John Coltrane", CoverImageHash: small.Hash, CoverImageSource: coverart.SourceManual}
+	nothing := models.Album{Title: "Mingus Ah Um", Artist: "Charles Mingus"}
+	addAlbumFolder(t, db, &missing, dir)
+	addAlbumFolder(t, db, &lowRes, dir)
+	addAlbumFolder(t, db, &manual, dir)
+	addAlbumFolder(t, db, &nothing, "")
+
+	result, err := covers.RefreshCovers(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("RefreshCovers() error = %v", err)
+	}
+	if result.Measured != 2 || result.Checked != 3 || result.Found != 1 || result.Upgraded != 1 {
+		t.Errorf("result = %+v, want 2 measured, 3 checked, 1 found, 1 upgraded", result)
+	}
+
+	for _, album := range []models.Album{missing, lowRes} {
+		var got models.Album
+		db.First(&got, album.ID)
+		if got.CoverImageSource != coverart.SourceLocal || got.CoverImageWidth != 800 || got.CoverImageFailed {
+			t.Errorf("%s cover = %s %dx%d (failed %v), want the local 800px cover",
+				got.Title, got.CoverImageSource, got.CoverImageWidth, got.CoverImageHeight, got.CoverImageFailed)
+		}
+	}
+	var kept models.Album
+	db.First(&kept, manual.ID)
+	if kept.CoverImageHash != small.Hash || kept.CoverImageSource != coverart.SourceManual {
+		t.Errorf("manual cover replaced by %s %s", kept.CoverImageSource, kept.CoverImageHash)
+	}
+	var checked models.Album
+	db.First(&checked, nothing.ID)
+	if checked.CoverCheckedAt == nil {
+		t.Error("album without candidates not marked as checked")
+	}
+
+	again, err := covers.RefreshCovers(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("second RefreshCovers() error = %v", err)
+	}
+	if again.Checked != 0 {
+		t.Errorf("second run checked %d albums, want none until they are due again", again.Checked)
+	}
+}
+
+func TestApplyCover_OnlyReadsOfferedLocalFiles(t *testing.T) {
+	covers, db := newTestCoverArt(t)
+	newTestImageStore(t)
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "cover.png"), pngBytes(t, 600, 400), 0o644)
+	os.WriteFile(filepath.Join(dir, "secret.png"), pngBytes(t, 10, 10), 0o644)
+	album := models.Album{Title: "Blue Train", Artist: "John Coltrane"}
+	addAlbumFolder(t, db, &album, dir)
+
+	_, err := covers.Apply(context.Background(), album.ID, coverart.Candidate{Source: coverart.SourceLocal, URL: filepath.Join(dir, "secret.png")})
+	if !errors.Is(err, ErrNotACoverCandidate) {
+		t.Errorf("Apply(secret.png) error = %v, want ErrNotACoverCandidate", err)
+	}
+
+	candidates, err := covers.Candidates(context.Background(), album.ID)
+	if err != nil || len(candidates) != 1 {
+		t.Fatalf("Candidates() = %+v, %v, want cover.png", candidates, err)
+	}
+	if candidates[0].Width != 600 || candidates[0].ThumbnailURL == "" {
+		t.Errorf("local candidate = %+v, want a stored preview", candidates[0])
+	}
+
+	got, err := covers.Apply(context.Background(), album.ID, candidates[0])
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got.CoverImageSource != coverart.SourceLocal || got.CoverImageWidth != 600 || got.CoverImageHeight != 400 {
+		t.Errorf("album cover = %s %dx%d", got.CoverImageSource, got.CoverImageWidth, got.CoverImageHeight)
+	}
+}
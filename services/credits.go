@@ -0,0 +1,171 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+
+	"vinylfo/discogs"
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// SaveReleaseCredits replaces an album's credits with those of a parsed Discogs
+// release (the map returned by discogs.Client.GetAlbum). Track credits are matched
+// to the album's saved tracks by position, falling back to title
+func SaveReleaseCredits(db *gorm.DB, albumID uint, release map[string]interface{}) error {
+	albumCredits, _ := release["credits"].([]discogs.Credit)
+	tracklist, _ := release["tracklist"].([]map[string]interface{})
+
+	var tracks []models.Track
+	if err := db.Select("id", "title", "position").Where("album_id = ?", albumID).Find(&tracks).Error; err != nil {
+		return err
+	}
+	byPosition := make(map[string]uint, len(tracks))
+	byTitle := make(map[string]uint, len(tracks))
+	for _, t := range tracks {
+		if t.Position != "" {
+			byPosition[strings.ToUpper(t.Position)] = t.ID
+		}
+		byTitle[strings.ToLower(t.Title)] = t.ID
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("album_id = ?", albumID).Delete(&models.Credit{}).Error; err != nil {
+			return err
+		}
+
+		saver := newCreditSaver(tx, albumID)
+		if err := saver.save(nil, albumCredits); err != nil {
+			return err
+		}
+
+		for _, t := range tracklist {
+			credits, _ := t["credits"].([]discogs.Credit)
+			if len(credits) == 0 {
+				continue
+			}
+			position, _ := t["position"].(string)
+			title, _ := t["title"].(string)
+			trackID, ok := byPosition[strings.ToUpper(position)]
+			if !ok || position == "" {
+				trackID, ok = byTitle[strings.ToLower(title)]
+			}
+			if !ok {
+				continue
+			}
+			if err := saver.save(&trackID, credits); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	models.ReindexAlbum(db, albumID)
+	return nil
+}
+
+// creditSaver writes the credits of one album, reusing artists across calls
+type creditSaver struct {
+	tx      *gorm.DB
+	albumID uint
+	artists map[string]uint
+}
+
+func newCreditSaver(tx *gorm.DB, albumID uint) *creditSaver {
+	return &creditSaver{tx: tx, albumID: albumID, artists: make(map[string]uint)}
+}
+
+// save writes credits for the album (trackID nil) or one of its tracks
+// An artist credited several times in the same role gets one row with the details combined
+func (s *creditSaver) save(trackID *uint, credits []discogs.Credit) error {
+	rows := make([]*models.Credit, 0, len(credits))
+	byKey := make(map[string]*models.Credit)
+
+	for _, c := range credits {
+		artistID, err := s.artistID(c)
+		if err != nil {
+			return err
+		}
+
+		key := c.Role + "|" + c.Name
+		if existing, ok := byKey[key]; ok {
+			if c.Detail != "" && !strings.Contains(existing.Detail, c.Detail) {
+				existing.Detail = strings.TrimPrefix(existing.Detail+", "+c.Detail, ", ")
+			}
+			continue
+		}
+
+		row := &models.Credit{
+			ArtistID: artistID,
+			AlbumID:  s.albumID,
+			TrackID:  trackID,
+			Role:     c.Role,
+			Detail:   c.Detail,
+			Joiner:   truncate(c.Join, 20),
+			Position: len(rows),
+		}
+		byKey[key] = row
+		rows = append(rows, row)
+	}
+
+	for _, row := range rows {
+		row.Detail = truncate(row.Detail, 255)
+		if err := s.tx.Create(row).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// artistID finds or creates the artist for a credit
+// Artists with a Discogs ID are matched on it; others on their exact name
+func (s *creditSaver) artistID(c discogs.Credit) (uint, error) {
+	key := "name:" + c.Name
+	if c.ArtistID > 0 {
+		key = "discogs:" + strconv.Itoa(c.ArtistID)
+	}
+	if id, ok := s.artists[key]; ok {
+		return id, nil
+	}
+
+	var artist models.Artist
+	var err error
+	if c.ArtistID > 0 {
+		err = s.tx.Where("discogs_id = ?", c.ArtistID).First(&artist).Error
+	} else {
+		err = s.tx.Where("name = ? AND discogs_id IS NULL", c.Name).First(&artist).Error
+	}
+
+	switch {
+	case err == gorm.ErrRecordNotFound:
+		artist = models.Artist{Name: c.Name}
+		if c.ArtistID > 0 {
+			discogsID := c.ArtistID
+			artist.DiscogsID = &discogsID
+		}
+		if err := s.tx.Create(&artist).Error; err != nil {
+			return 0, err
+		}
+	case err != nil:
+		return 0, err
+	case artist.Name != c.Name && c.Name != "":
+		// Discogs renamed the artist (or corrected the disambiguation suffix)
+		if err := s.tx.Model(&artist).Update("name", c.Name).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	s.artists[key] = artist.ID
+	return artist.ID, nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
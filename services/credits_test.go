@@ -0,0 +1,75 @@
+package services
+
+import (
+	"testing"
+
+	"vinylfo/discogs"
+	"vinylfo/models"
+)
+
+func TestSaveReleaseCredits(t *testing.T) {
+	db := newTestDB(t)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.Artist{}, &models.Credit{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	album := models.Album{Title: "Random Access Memories", Artist: "Daft Punk"}
+	db.Create(&album)
+	a1 := models.Track{AlbumID: album.ID, Title: "Give Life Back To Music", Position: "A1"}
+	a2 := models.Track{AlbumID: album.ID, Title: "The Game Of Love", Position: "A2"}
+	db.Create(&a1)
+	db.Create(&a2)
+
+	release := map[string]interface{}{
+		"credits": []discogs.Credit{
+			{ArtistID: 1, Name: "Daft Punk", Role: models.CreditRolePerformer},
+			{ArtistID: 5, Name: "Thomas Bangalter", Role: models.CreditRoleProducer, Detail: "Producer"},
+		},
+		"tracklist": []map[string]interface{}{
+			{"position": "A1", "title": "Give Life Back To Music", "credits": []discogs.Credit{
+				{ArtistID: 4, Name: "Nile Rodgers", Role: models.CreditRolePerformer, Detail: "Guitar"},
+				{ArtistID: 4, Name: "Nile Rodgers", Role: models.CreditRolePerformer, Detail: "Rhythm Guitar"},
+			}},
+			// No position match, so the title is used
+			{"position": "", "title": "The Game Of Love", "credits": []discogs.Credit{
+				{Name: "Session Choir", Role: models.CreditRolePerformer, Detail: "Choir"},
+			}},
+		},
+	}
+
+	if err := SaveReleaseCredits(db, album.ID, release); err != nil {
+		t.Fatalf("SaveReleaseCredits() error = %v", err)
+	}
+
+	var credits []models.Credit
+	db.Preload("Artist").Order("id").Find(&credits)
+	if len(credits) != 4 {
+		t.Fatalf("saved %d credits, want 4: %+v", len(credits), credits)
+	}
+	if credits[2].TrackID == nil || *credits[2].TrackID != a1.ID || credits[2].Detail != "Guitar, Rhythm Guitar" {
+		t.Errorf("Nile Rodgers credit = %+v, want one A1 credit with merged details", credits[2])
+	}
+	if credits[3].TrackID == nil || *credits[3].TrackID != a2.ID || credits[3].Artist.DiscogsID != nil {
+		t.Errorf("choir credit = %+v, want A2 credit for an artist without a Discogs ID", credits[3])
+	}
+
+	// Saving again replaces the credits and reuses the artists
+	if err := SaveReleaseCredits(db, album.ID, release); err != nil {
+		t.Fatalf("SaveReleaseCredits() second run error = %v", err)
+	}
+	var creditCount, artistCount int64
+	db.Model(&models.Credit{}).Count(&creditCount)
+	db.Model(&models.Artist{}).Count(&artistCount)
+	if creditCount != 4 || artistCount != 4 {
+		t.Errorf("after re-save got %d credits and %d artists, want 4 and 4", creditCount, artistCount)
+	}
+
+	// Deleting a track drops its credits
+	db.Delete(&a1)
+	db.Model(&models.Credit{}).Count(&creditCount)
+	if creditCount != 3 {
+		t.Errorf("after deleting A1 got %d credits, want 3", creditCount)
+	}
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"log"
+	"os"
+
+	"vinylfo/discogs"
+	"vinylfo/models"
+	"vinylfo/utils"
+
+	"gorm.io/gorm"
+)
+
+// NewDiscogsClientFromConfig builds an OAuth Discogs client from the tokens stored in
+// AppConfig. Returns nil when Discogs is not connected or the tokens can't be decrypted
+func NewDiscogsClientFromConfig(db *gorm.DB) *discogs.Client {
+	var config models.AppConfig
+	err := db.First(&config).Error
+	if err != nil {
+		log.Printf("OAUTH: ERROR loading config from database: %v", err)
+		return nil
+	}
+
+	// Check if tokens exist before trying to decrypt
+	if config.DiscogsAccessToken == "" || config.DiscogsAccessSecret == "" {
+		log.Printf("OAUTH: No tokens stored - Discogs not connected")
+		return nil
+	}
+
+	// Decrypt the stored tokens
+	accessToken, err := utils.Decrypt(config.DiscogsAccessToken)
+	if err != nil {
+		log.Printf("OAUTH: ERROR decrypting access token: %v", err)
+		return nil
+	}
+
+	accessSecret, err := utils.Decrypt(config.DiscogsAccessSecret)
+	if err != nil {
+		log.Printf("OAUTH: ERROR decrypting access secret: %v", err)
+		return nil
+	}
+
+	oauth := &discogs.OAuthConfig{
+		ConsumerKey:    os.Getenv("DISCOGS_CONSUMER_KEY"),
+		ConsumerSecret: os.Getenv("DISCOGS_CONSUMER_SECRET"),
+		AccessToken:    accessToken,
+		AccessSecret:   accessSecret,
+	}
+	return discogs.NewClientWithOAuth("", oauth)
+}
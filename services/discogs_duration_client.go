@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"vinylfo/discogs"
+	"vinylfo/duration"
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// discogsVersionsTTL is how long a master's tracklists are reused across its album's tracks
+const discogsVersionsTTL = time.Hour
+
+type cachedVersions struct {
+	versions  []discogs.MasterVersion
+	fetchedAt time.Time
+}
+
+// DiscogsDurationClient is a duration source that looks a track up on every release of its
+// album's Discogs master, walking the releases the same way album import cross-references them
+// Like LocalFileClient it needs the track being resolved, so it only answers inside the resolver
+type DiscogsDurationClient struct {
+	db *gorm.DB
+
+	mu       sync.Mutex
+	byMaster map[int]cachedVersions
+}
+
+func NewDiscogsDurationClient(db *gorm.DB) *DiscogsDurationClient {
+	return &DiscogsDurationClient{db: db, byMaster: make(map[int]cachedVersions)}
+}
+
+func (c *DiscogsDurationClient) Name() string {
+	return "discogs"
+}
+
+func (c *DiscogsDurationClient) SearchTrack(ctx context.Context, title, artist, album string) (*duration.TrackSearchResult, error) {
+	track, ok := resolverTrack(ctx)
+	if !ok {
+		return nil, nil
+	}
+	var albumRow models.Album
+	if err := c.db.First(&albumRow, track.AlbumID).Error; err != nil {
+		return nil, err
+	}
+
+	versions, err := c.versions(ctx, &albumRow)
+	if len(versions) == 0 {
+		return nil, err
+	}
+
+	found := discogs.FindTrackVersions(versions, title)
+	if len(found) == 0 {
+		return nil, nil
+	}
+
+	// The most common duration stands for Discogs; every other version is listed alongside it
+	counts := make(map[int]int)
+	for _, v := range found {
+		counts[v.Duration]++
+	}
+	best := 0
+	for i, v := range found {
+		if counts[v.Duration] > counts[found[best].Duration] {
+			best = i
+		}
+	}
+
+	result := discogsResult(found[best], artist, float64(counts[found[best].Duration])/float64(len(found)))
+	for i, v := range found {
+		if i != best {
+			result.Versions = append(result.Versions, *discogsResult(v, artist, 0))
+		}
+	}
+	return result, nil
+}
+
+// discogsResult converts a track version; agreement is the share of versions with the same duration
+func discogsResult(v discogs.TrackVersion, artist string, agreement float64) *duration.TrackSearchResult {
+	raw, _ := json.Marshal(v)
+	return &duration.TrackSearchResult{
+		ExternalID:  strconv.Itoa(v.ReleaseID),
+		ExternalURL: discogs.ReleaseURL(v.ReleaseID),
+		Title:       v.Title,
+		Artist:      artist,
+		Album:       v.Release,
+		Duration:    v.Duration,
+		MatchScore:  v.Score,
+		Confidence:  0.6 + 0.3*agreement,
+		RawResponse: string(raw),
+	}
+}
+
+// versions returns the tracklists of the album's master, or of its own release when it has no master
+func (c *DiscogsDurationClient) versions(ctx context.Context, album *models.Album) ([]discogs.MasterVersion, error) {
+	if album.DiscogsID == nil && album.DiscogsMasterID == nil {
+		return nil, nil
+	}
+
+	client := NewDiscogsClientFromConfig(c.db)
+	if client == nil {
+		client = discogs.NewClient("")
+	}
+
+	masterID := 0
+	if album.DiscogsMasterID != nil {
+		masterID = *album.DiscogsMasterID
+	} else {
+		tracks, id, err := client.GetTracksForAlbumWithMaster(*album.DiscogsID)
+		if err != nil {
+			return nil, err
+		}
+		if id == 0 {
+			return []discogs.MasterVersion{{ReleaseID: *album.DiscogsID, Title: album.Title, Tracks: tracks}}, nil
+		}
+		masterID = id
+		c.db.Model(album).Update("discogs_master_id", masterID)
+	}
+
+	c.mu.Lock()
+	cached, ok := c.byMaster[masterID]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < discogsVersionsTTL {
+		return cached.versions, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	versions, err := client.GetMasterVersions(masterID)
+	if err != nil && !errors.Is(err, discogs.ErrRateLimited) {
+		return nil, err
+	}
+	if err == nil {
+		// Partial results after a rate limit are used once but not kept, so the next track retries the rest
+		c.mu.Lock()
+		c.byMaster[masterID] = cachedVersions{versions: versions, fetchedAt: time.Now()}
+		c.mu.Unlock()
+	}
+	return versions, err
+}
+
+func (c *DiscogsDurationClient) IsConfigured() bool {
+	return true
+}
+
+func (c *DiscogsDurationClient) GetRateLimitRemaining() int {
+	return discogs.GetGlobalRateLimiter().GetRemaining()
+}
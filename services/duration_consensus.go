@@ -0,0 +1,222 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// SourceWeight is how far the resolver trusts a duration source
+type SourceWeight struct {
+	Trust            float64 `json:"trust"`             // 0.0-1.0, multiplied by each result's match score
+	ToleranceSeconds int     `json:"tolerance_seconds"` // How far its values may be from others and still agree
+}
+
+// DefaultSourceWeights trusts sources that list a release's own tracklist over those that search
+// loosely; YouTube uploads often carry intros, outros or silence and get the widest tolerance
+func DefaultSourceWeights() map[string]SourceWeight {
+	return map[string]SourceWeight{
+		"local_file":  {Trust: 1.0, ToleranceSeconds: 1},
+		"musicbrainz": {Trust: 1.0, ToleranceSeconds: 2},
+		"discogs":     {Trust: 0.8, ToleranceSeconds: 2},
+		"lastfm":      {Trust: 0.6, ToleranceSeconds: 3},
+		"wikipedia":   {Trust: 0.4, ToleranceSeconds: 3},
+		"youtube":     {Trust: 0.3, ToleranceSeconds: 8},
+	}
+}
+
+// SourceWeights returns the default source weights with the overrides saved in settings
+func SourceWeights(db *gorm.DB) map[string]SourceWeight {
+	weights := DefaultSourceWeights()
+	var config models.AppConfig
+	if err := db.First(&config).Error; err != nil || config.DurationSourceWeights == "" {
+		return weights
+	}
+	var overrides map[string]SourceWeight
+	if err := json.Unmarshal([]byte(config.DurationSourceWeights), &overrides); err != nil {
+		log.Printf("SourceWeights: invalid duration_source_weights: %v", err)
+		return weights
+	}
+	for source, weight := range overrides {
+		weights[source] = weight
+	}
+	return weights
+}
+
+// DurationVote is one source's duration for a track and how it counted
+type DurationVote struct {
+	Source     string  `json:"source"`
+	Duration   int     `json:"duration"`
+	MatchScore float64 `json:"match_score"`
+	Weight     float64 `json:"weight"`    // Trust times match score
+	Tolerance  int     `json:"tolerance"` // Seconds
+	Agreed     bool    `json:"agreed"`    // Part of the chosen value
+	Outlier    bool    `json:"outlier"`   // Too far from the rest to count at all
+}
+
+// ConsensusDecision is the duration a ConsensusModel picked and why
+type ConsensusDecision struct {
+	Duration    int
+	Reached     bool // Strong enough to resolve without review
+	Agreeing    int
+	Weight      float64 // Of the agreeing votes
+	TotalWeight float64 // Of all votes that were not outliers
+	Votes       []DurationVote
+	Explanation string
+}
+
+// ConsensusModel picks a track's duration from the values its sources reported
+type ConsensusModel interface {
+	Decide(votes []DurationVote) ConsensusDecision
+}
+
+// WeightedConsensus weighs each vote by its source's trust and its match score, drops values far
+// from the weighted median, and picks the heaviest group of votes that agree within their
+// sources' tolerances
+type WeightedConsensus struct {
+	Weights        map[string]SourceWeight
+	Default        SourceWeight // For sources without a weight of their own
+	MinSources     int          // Agreeing sources needed to resolve
+	MinWeight      float64      // Agreeing weight needed to resolve
+	MinShare       float64      // Share of the total weight the agreeing votes need
+	OutlierSeconds int          // Values further than this from the median are outliers...
+	OutlierRatio   float64      // ...if also further than this share of it
+}
+
+func (m *WeightedConsensus) weight(source string) SourceWeight {
+	if w, ok := m.Weights[source]; ok {
+		return w
+	}
+	return m.Default
+}
+
+func (m *WeightedConsensus) Decide(votes []DurationVote) ConsensusDecision {
+	votes = append([]DurationVote(nil), votes...)
+	for i := range votes {
+		w := m.weight(votes[i].Source)
+		votes[i].Weight = w.Trust * votes[i].MatchScore
+		votes[i].Tolerance = w.ToleranceSeconds
+	}
+	sort.SliceStable(votes, func(i, j int) bool { return votes[i].Duration < votes[j].Duration })
+
+	decision := ConsensusDecision{Votes: votes}
+	if len(votes) == 0 {
+		decision.Explanation = "No source reported a duration"
+		return decision
+	}
+
+	median := weightedMedian(votes)
+	limit := math.Max(float64(m.OutlierSeconds), m.OutlierRatio*float64(median))
+	for i := range votes {
+		if math.Abs(float64(votes[i].Duration-median)) > limit {
+			votes[i].Outlier = true
+		} else {
+			decision.TotalWeight += votes[i].Weight
+		}
+	}
+
+	// Each vote anchors a group of the votes within tolerance of it; the heaviest group wins
+	var best []int
+	var bestWeight float64
+	for i := range votes {
+		if votes[i].Outlier {
+			continue
+		}
+		var group []int
+		var weight float64
+		for j := range votes {
+			if !votes[j].Outlier && abs(votes[j].Duration-votes[i].Duration) <= max(votes[i].Tolerance, votes[j].Tolerance) {
+				group = append(group, j)
+				weight += votes[j].Weight
+			}
+		}
+		if weight > bestWeight || (weight == bestWeight && len(group) > len(best)) {
+			best, bestWeight = group, weight
+		}
+	}
+
+	var sum float64
+	for _, j := range best {
+		votes[j].Agreed = true
+		sum += float64(votes[j].Duration) * votes[j].Weight
+	}
+	if bestWeight > 0 {
+		decision.Duration = int(math.Round(sum / bestWeight))
+	} else if len(best) > 0 {
+		decision.Duration = votes[best[0]].Duration
+	}
+	decision.Agreeing = len(best)
+	decision.Weight = bestWeight
+	decision.Reached = decision.Agreeing >= m.MinSources && bestWeight >= m.MinWeight &&
+		decision.TotalWeight > 0 && bestWeight/decision.TotalWeight >= m.MinShare
+	decision.Explanation = m.explain(decision)
+	return decision
+}
+
+// weightedMedian returns the duration at which half the weight of the sorted votes is reached
+func weightedMedian(votes []DurationVote) int {
+	var total float64
+	for _, v := range votes {
+		total += v.Weight
+	}
+	if total == 0 {
+		return votes[len(votes)/2].Duration
+	}
+	var acc float64
+	for _, v := range votes {
+		acc += v.Weight
+		if acc >= total/2 {
+			return v.Duration
+		}
+	}
+	return votes[len(votes)-1].Duration
+}
+
+func (m *WeightedConsensus) explain(d ConsensusDecision) string {
+	var agreed, apart, outliers []string
+	for _, v := range d.Votes {
+		label := fmt.Sprintf("%s %s (weight %.2f)", v.Source, formatSeconds(v.Duration), v.Weight)
+		switch {
+		case v.Agreed:
+			agreed = append(agreed, label)
+		case v.Outlier:
+			outliers = append(outliers, label)
+		default:
+			apart = append(apart, label)
+		}
+	}
+
+	share := 0.0
+	if d.TotalWeight > 0 {
+		share = d.Weight / d.TotalWeight * 100
+	}
+	var b strings.Builder
+	if d.Reached {
+		fmt.Fprintf(&b, "Chose %s: ", formatSeconds(d.Duration))
+	} else {
+		fmt.Fprintf(&b, "No consensus, best candidate %s: ", formatSeconds(d.Duration))
+	}
+	fmt.Fprintf(&b, "%s agreed with weight %.2f of %.2f (%.0f%%)", strings.Join(agreed, ", "), d.Weight, d.TotalWeight, share)
+	if !d.Reached {
+		fmt.Fprintf(&b, ", needs %d sources, weight %.2f and %.0f%%", m.MinSources, m.MinWeight, m.MinShare*100)
+	}
+	b.WriteString(".")
+	if len(apart) > 0 {
+		fmt.Fprintf(&b, " Outside tolerance: %s.", strings.Join(apart, ", "))
+	}
+	if len(outliers) > 0 {
+		fmt.Fprintf(&b, " Rejected as outliers: %s.", strings.Join(outliers, ", "))
+	}
+	return b.String()
+}
+
+func formatSeconds(seconds int) string {
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
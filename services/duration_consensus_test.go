@@ -0,0 +1,86 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func testConsensus() *WeightedConsensus {
+	config := DefaultDurationResolverConfig()
+	return &WeightedConsensus{
+		Weights:        DefaultSourceWeights(),
+		Default:        SourceWeight{Trust: 0.7, ToleranceSeconds: config.ToleranceSeconds},
+		MinSources:     config.ConsensusThreshold,
+		MinWeight:      config.MinConsensusWeight,
+		MinShare:       config.MinConsensusShare,
+		OutlierSeconds: 20,
+		OutlierRatio:   0.15,
+	}
+}
+
+func TestWeightedConsensus(t *testing.T) {
+	t.Run("trusted sources outweigh loose ones", func(t *testing.T) {
+		decision := testConsensus().Decide([]DurationVote{
+			{Source: "wikipedia", Duration: 250, MatchScore: 1},
+			{Source: "lastfm", Duration: 251, MatchScore: 0.8},
+			{Source: "musicbrainz", Duration: 243, MatchScore: 1},
+			{Source: "discogs", Duration: 244, MatchScore: 0.9},
+		})
+		if !decision.Reached || decision.Agreeing != 2 || decision.Duration != 243 {
+			t.Errorf("decision = %+v, want musicbrainz and discogs at 4:03", decision)
+		}
+		if !strings.Contains(decision.Explanation, "Outside tolerance: wikipedia 4:10") {
+			t.Errorf("explanation = %q", decision.Explanation)
+		}
+	})
+
+	t.Run("youtube gets a wider tolerance", func(t *testing.T) {
+		decision := testConsensus().Decide([]DurationVote{
+			{Source: "musicbrainz", Duration: 300, MatchScore: 1},
+			{Source: "youtube", Duration: 306, MatchScore: 0.9},
+			{Source: "lastfm", Duration: 301, MatchScore: 1},
+		})
+		if !decision.Reached || decision.Agreeing != 3 {
+			t.Errorf("decision = %+v, want all three agreeing", decision)
+		}
+	})
+
+	t.Run("outliers are rejected", func(t *testing.T) {
+		decision := testConsensus().Decide([]DurationVote{
+			{Source: "musicbrainz", Duration: 200, MatchScore: 1},
+			{Source: "discogs", Duration: 201, MatchScore: 1},
+			{Source: "youtube", Duration: 600, MatchScore: 1},
+		})
+		var outlier DurationVote
+		for _, v := range decision.Votes {
+			if v.Source == "youtube" {
+				outlier = v
+			}
+		}
+		if !outlier.Outlier || outlier.Agreed {
+			t.Errorf("youtube vote = %+v, want an outlier", outlier)
+		}
+		if !decision.Reached || decision.TotalWeight != 1.8 {
+			t.Errorf("decision = %+v, want reached without the outlier's weight", decision)
+		}
+	})
+
+	t.Run("weak agreement needs review", func(t *testing.T) {
+		decision := testConsensus().Decide([]DurationVote{
+			{Source: "wikipedia", Duration: 180, MatchScore: 1},
+			{Source: "youtube", Duration: 182, MatchScore: 0.6},
+		})
+		if decision.Reached || decision.Agreeing != 2 {
+			t.Errorf("decision = %+v, want two agreeing but too little weight", decision)
+		}
+		if !strings.HasPrefix(decision.Explanation, "No consensus") {
+			t.Errorf("explanation = %q", decision.Explanation)
+		}
+	})
+
+	t.Run("no votes", func(t *testing.T) {
+		if decision := testConsensus().Decide(nil); decision.Reached || decision.Duration != 0 {
+			t.Errorf("decision = %+v", decision)
+		}
+	})
+}
@@ -2,9 +2,9 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
-	"sort"
 	"time"
 
 	"vinylfo/duration"
@@ -14,19 +14,27 @@ import (
 )
 
 type DurationResolverConfig struct {
-	ConsensusThreshold   int
-	ToleranceSeconds     int
+	ConsensusThreshold   int     // Agreeing sources needed for consensus
+	ToleranceSeconds     int     // For sources without a weight of their own
+	MinConsensusWeight   float64 // Agreeing source weight needed for consensus
+	MinConsensusShare    float64 // Share of all source weight the agreeing sources need
 	AutoApplyOnConsensus bool
+	AutoApplyLocalFile   bool // A duration read from the track's own audio file is applied without consensus
 	MinMatchScore        float64
 	ContactEmail         string
 	YouTubeAPIKey        string
 	LastFMAPIKey         string
+
+	SourceWeights map[string]SourceWeight // nil uses the weights saved in settings
+	Consensus     ConsensusModel          // nil uses a WeightedConsensus built from the fields above
 }
 
 func DefaultDurationResolverConfig() DurationResolverConfig {
 	return DurationResolverConfig{
 		ConsensusThreshold:   2,
 		ToleranceSeconds:     3,
+		MinConsensusWeight:   1.4,
+		MinConsensusShare:    0.5,
 		AutoApplyOnConsensus: true,
 		MinMatchScore:        0.4,
 		ContactEmail:         "https://github.com/xphox2/Vinylfo",
@@ -34,23 +42,28 @@ func DefaultDurationResolverConfig() DurationResolverConfig {
 }
 
 type DurationResolverService struct {
-	db      *gorm.DB
-	clients []duration.MusicAPIClient
-	config  DurationResolverConfig
+	db          *gorm.DB
+	clients     []duration.MusicAPIClient
+	musicBrainz *duration.MusicBrainzClient // Also used for album-level release lookups
+	config      DurationResolverConfig
 }
 
 func NewDurationResolverService(db *gorm.DB, config DurationResolverConfig) *DurationResolverService {
+	musicBrainz := duration.NewMusicBrainzClient(config.ContactEmail)
 	clients := []duration.MusicAPIClient{
-		duration.NewMusicBrainzClient(config.ContactEmail),
+		NewLocalFileClient(db),
+		musicBrainz,
 		duration.NewWikipediaClient(),
 		duration.NewLastFMClient(config.LastFMAPIKey),
+		NewDiscogsDurationClient(db),
 		duration.NewYouTubeClient(config.YouTubeAPIKey),
 	}
 
 	return &DurationResolverService{
-		db:      db,
-		clients: clients,
-		config:  config,
+		db:          db,
+		clients:     clients,
+		musicBrainz: musicBrainz,
+		config:      config,
 	}
 }
 
@@ -119,10 +132,18 @@ func (s *DurationResolverService) ResolveTrackDuration(ctx context.Context, trac
 	}
 
 	var successfulQueries int
-	var allDurations []int
+	var votes []DurationVote
+	consensus := s.consensusModel()
 	var skippedExpensiveSources []string
+	var localDuration int
 
 	for _, client := range s.clients {
+		if localDuration > 0 && s.config.AutoApplyLocalFile {
+			// The local file settles it, so the online sources are not worth their rate limits
+			skippedExpensiveSources = append(skippedExpensiveSources, client.Name())
+			resolution.TotalSourcesQueried--
+			continue
+		}
 		if !client.IsConfigured() {
 			resolution.TotalSourcesQueried--
 			log.Printf("DEBUG: Skipping %s - not configured", client.Name())
@@ -132,17 +153,23 @@ func (s *DurationResolverService) ResolveTrackDuration(ctx context.Context, trac
 		log.Printf("DEBUG: Querying %s for track '%s' by '%s'", client.Name(), track.Title, artist)
 
 		// Skip expensive sources (YouTube) if we already have consensus from free sources
-		if client.Name() == "youtube" && len(allDurations) >= s.config.ConsensusThreshold {
-			_, consensusCount := s.findConsensus(allDurations)
-			if consensusCount >= s.config.ConsensusThreshold {
-				log.Printf("DEBUG: Skipping YouTube API - consensus already reached with %d sources", consensusCount)
+		if client.Name() == "youtube" && len(votes) >= s.config.ConsensusThreshold {
+			if decision := consensus.Decide(votes); decision.Reached {
+				log.Printf("DEBUG: Skipping YouTube API - consensus already reached with %d sources", decision.Agreeing)
 				skippedExpensiveSources = append(skippedExpensiveSources, client.Name())
 				resolution.TotalSourcesQueried--
 				continue
 			}
 		}
 
-		result, err := client.SearchTrack(ctx, track.Title, artist, albumTitle)
+		var result *duration.TrackSearchResult
+		var err error
+		if known, ok := sourceResult(ctx, client.Name()); ok {
+			log.Printf("DEBUG: Using %s result from the album's release for track '%s'", client.Name(), track.Title)
+			result = known
+		} else {
+			result, err = client.SearchTrack(withResolverTrack(ctx, track), track.Title, artist, albumTitle)
+		}
 		if err != nil {
 			log.Printf("DEBUG: %s error: %v", client.Name(), err)
 			source := models.DurationSource{
@@ -175,7 +202,10 @@ func (s *DurationResolverService) ResolveTrackDuration(ctx context.Context, trac
 
 			// Only count toward consensus if match score meets threshold
 			if result.MatchScore >= s.config.MinMatchScore && result.Duration > 0 {
-				allDurations = append(allDurations, result.Duration)
+				votes = append(votes, DurationVote{Source: client.Name(), Duration: result.Duration, MatchScore: result.MatchScore})
+				if _, ok := client.(*LocalFileClient); ok {
+					localDuration = result.Duration
+				}
 			}
 		} else {
 			// No result found - still save the source record to show it was queried
@@ -184,6 +214,22 @@ func (s *DurationResolverService) ResolveTrackDuration(ctx context.Context, trac
 		}
 
 		s.db.Create(&source)
+
+		if result != nil {
+			for _, version := range result.Versions {
+				s.db.Create(&models.DurationSource{
+					ResolutionID:  resolution.ID,
+					SourceName:    client.Name(),
+					DurationValue: version.Duration,
+					Confidence:    version.Confidence,
+					MatchScore:    version.MatchScore,
+					ExternalID:    version.ExternalID,
+					ExternalURL:   version.ExternalURL,
+					RawResponse:   version.RawResponse,
+					QueriedAt:     source.QueriedAt,
+				})
+			}
+		}
 	}
 
 	// Log if we saved API calls by skipping expensive sources
@@ -193,7 +239,7 @@ func (s *DurationResolverService) ResolveTrackDuration(ctx context.Context, trac
 
 	resolution.SuccessfulQueries = successfulQueries
 
-	if len(allDurations) == 0 {
+	if len(votes) == 0 {
 		resolution.Status = "failed"
 		log.Printf("DEBUG: Track '%s' FAILED - no durations found from %d sources. Match threshold: %.2f",
 			track.Title, successfulQueries, s.config.MinMatchScore)
@@ -210,22 +256,32 @@ func (s *DurationResolverService) ResolveTrackDuration(ctx context.Context, trac
 			log.Printf("ResolveTrackDuration: refusing to mark duration_needs_review for zero track ID")
 		}
 	} else {
-		resolvedDuration, consensusCount := s.findConsensus(allDurations)
-		resolution.ConsensusCount = consensusCount
+		decision := consensus.Decide(votes)
+		resolvedDuration := decision.Duration
+		fromLocalFile := localDuration > 0 && s.config.AutoApplyLocalFile
+		if fromLocalFile {
+			resolvedDuration = localDuration
+			decision.Explanation = fmt.Sprintf("Used the local file's length %s, which is applied on its own. %s",
+				formatSeconds(localDuration), decision.Explanation)
+		}
+		resolution.ConsensusCount = decision.Agreeing
+		resolution.ConsensusWeight = decision.Weight
+		resolution.ConsensusExplanation = decision.Explanation
+		if encoded, err := json.Marshal(decision.Votes); err == nil {
+			resolution.ConsensusVotes = string(encoded)
+		}
 
-		if consensusCount >= s.config.ConsensusThreshold {
+		if decision.Reached || fromLocalFile {
 			resolution.Status = "resolved"
 			resolution.ResolvedDuration = &resolvedDuration
-			log.Printf("DEBUG: Track '%s' RESOLVED - duration %d seconds (%d sources agreed)",
-				track.Title, resolvedDuration, consensusCount)
+			log.Printf("DEBUG: Track '%s' RESOLVED - %s", track.Title, decision.Explanation)
 
-			if s.config.AutoApplyOnConsensus {
+			if s.config.AutoApplyOnConsensus || fromLocalFile {
 				s.applyResolution(resolution, track)
 			}
 		} else if successfulQueries > 0 {
 			resolution.Status = "needs_review"
-			log.Printf("DEBUG: Track '%s' NEEDS REVIEW - %d durations found, %d agreed (need %d for consensus)",
-				track.Title, len(allDurations), consensusCount, s.config.ConsensusThreshold)
+			log.Printf("DEBUG: Track '%s' NEEDS REVIEW - %s", track.Title, decision.Explanation)
 		} else {
 			resolution.Status = "failed"
 			log.Printf("DEBUG: Track '%s' FAILED - no successful queries", track.Title)
@@ -242,40 +298,24 @@ func (s *DurationResolverService) ResolveTrackDuration(ctx context.Context, trac
 	return resolution, nil
 }
 
-func (s *DurationResolverService) findConsensus(durations []int) (int, int) {
-	if len(durations) == 0 {
-		return 0, 0
+// consensusModel returns the configured model, or weighted consensus with the current source weights
+func (s *DurationResolverService) consensusModel() ConsensusModel {
+	if s.config.Consensus != nil {
+		return s.config.Consensus
 	}
-
-	sort.Ints(durations)
-
-	durationCounts := make(map[int]int)
-	for _, d := range durations {
-		durationCounts[d]++
+	weights := s.config.SourceWeights
+	if weights == nil {
+		weights = SourceWeights(s.db)
 	}
-
-	var bestDuration int
-	var bestCount int
-
-	for duration, count := range durationCounts {
-		if count > bestCount {
-			bestCount = count
-			bestDuration = duration
-		}
+	return &WeightedConsensus{
+		Weights:        weights,
+		Default:        SourceWeight{Trust: 0.7, ToleranceSeconds: s.config.ToleranceSeconds},
+		MinSources:     s.config.ConsensusThreshold,
+		MinWeight:      s.config.MinConsensusWeight,
+		MinShare:       s.config.MinConsensusShare,
+		OutlierSeconds: 20,
+		OutlierRatio:   0.15,
 	}
-
-	if bestCount >= s.config.ConsensusThreshold {
-		return bestDuration, bestCount
-	}
-
-	for i := 0; i < len(durations)-1; i++ {
-		if abs(durations[i]-durations[i+1]) <= s.config.ToleranceSeconds {
-			avg := (durations[i] + durations[i+1]) / 2
-			return avg, 2
-		}
-	}
-
-	return durations[0], 1
 }
 
 func (s *DurationResolverService) applyResolution(resolution *models.DurationResolution, track models.Track) {
@@ -2,8 +2,11 @@ package services
 
 import (
 	"context"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"vinylfo/audio"
 	"vinylfo/duration"
 	"vinylfo/models"
 
@@ -32,6 +35,7 @@ func newTestDB(t *testing.T) *gorm.DB {
 
 	if err := db.AutoMigrate(
 		&models.Album{},
+		&models.AlbumInstance{},
 		&models.Track{},
 		&models.DurationResolution{},
 		&models.DurationSource{},
@@ -144,3 +148,184 @@ func TestGetTracksNeedingResolution_FiltersInvalidTracks(t *testing.T) {
 		t.Fatalf("expected track id=%d, got id=%d", valid.ID, tracks[0].ID)
 	}
 }
+
+type mockMusicClientDuration struct {
+	seconds int
+	queried *int
+}
+
+func (m mockMusicClientDuration) Name() string { return "mock" }
+
+func (m mockMusicClientDuration) SearchTrack(ctx context.Context, title, artist, album string) (*duration.TrackSearchResult, error) {
+	*m.queried++
+	return &duration.TrackSearchResult{Title: title, Duration: m.seconds, MatchScore: 0.9, Confidence: 0.8}, nil
+}
+
+func (m mockMusicClientDuration) IsConfigured() bool         { return true }
+func (m mockMusicClientDuration) GetRateLimitRemaining() int { return -1 }
+
+func TestResolveTrackDuration_LocalFile(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&models.AppConfig{}, &models.AudioFile{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	album := models.Album{Title: "Kind of Blue", Artist: "Miles Davis"}
+	db.Create(&album)
+
+	path := filepath.Join(t.TempDir(), "01 - So What.mp3")
+	writeMP3(t, path, 20)
+
+	resolve := func(t *testing.T, config DurationResolverConfig, remote int) (*models.DurationResolution, models.Track, int) {
+		t.Helper()
+		track := models.Track{AlbumID: album.ID, Title: "So What", AudioFileURL: audio.FileURL(path)}
+		db.Create(&track)
+
+		var queried int
+		svc := &DurationResolverService{
+			db:      db,
+			clients: []duration.MusicAPIClient{NewLocalFileClient(db), mockMusicClientDuration{seconds: remote, queried: &queried}},
+			config:  config,
+		}
+		res, err := svc.ResolveTrackDuration(context.Background(), track)
+		if err != nil {
+			t.Fatalf("ResolveTrackDuration error: %v", err)
+		}
+		db.First(&track, track.ID)
+		return res, track, queried
+	}
+
+	t.Run("counts toward consensus", func(t *testing.T) {
+		res, track, queried := resolve(t, DefaultDurationResolverConfig(), 21)
+		if queried != 1 || res.Status != "resolved" || res.ConsensusCount != 2 {
+			t.Fatalf("resolution = %s with %d agreeing (%d remote queries), want resolved by 2", res.Status, res.ConsensusCount, queried)
+		}
+		if track.Duration < 19 || track.Duration > 21 {
+			t.Errorf("track duration = %d, want about 20", track.Duration)
+		}
+		if !strings.HasPrefix(res.ConsensusExplanation, "Chose") || !strings.Contains(res.ConsensusVotes, `"source":"local_file"`) {
+			t.Errorf("explanation = %q, votes = %s", res.ConsensusExplanation, res.ConsensusVotes)
+		}
+
+		var local models.DurationSource
+		db.Where("resolution_id = ? AND source_name = ?", res.ID, "local_file").First(&local)
+		if local.Confidence < 0.9 || local.ExternalURL != audio.FileURL(path) {
+			t.Errorf("local source = %+v", local)
+		}
+	})
+
+	t.Run("alone is not enough by default", func(t *testing.T) {
+		res, track, _ := resolve(t, DefaultDurationResolverConfig(), 300)
+		if res.Status != "needs_review" || track.Duration != 0 {
+			t.Errorf("resolution = %s, track duration %d, want needs_review and unchanged", res.Status, track.Duration)
+		}
+	})
+
+	t.Run("applied on its own when configured", func(t *testing.T) {
+		config := DefaultDurationResolverConfig()
+		config.AutoApplyOnConsensus = false
+		config.AutoApplyLocalFile = true
+		res, track, queried := resolve(t, config, 300)
+		if queried != 0 {
+			t.Errorf("remote source queried %d times, want skipped", queried)
+		}
+		if res.Status != "resolved" || !res.AutoApplied || track.Duration < 19 || track.Duration > 21 {
+			t.Errorf("resolution = %s (applied %v), track duration %d, want the local length applied", res.Status, res.AutoApplied, track.Duration)
+		}
+	})
+}
+
+type mockMusicClientVersions struct{}
+
+func (m mockMusicClientVersions) Name() string { return "discogs" }
+
+func (m mockMusicClientVersions) SearchTrack(ctx context.Context, title, artist, album string) (*duration.TrackSearchResult, error) {
+	return &duration.TrackSearchResult{
+		ExternalID: "1", Duration: 562, MatchScore: 1, Confidence: 0.8,
+		Versions: []duration.TrackSearchResult{
+			{ExternalID: "2", Duration: 562, MatchScore: 1},
+			{ExternalID: "3", Duration: 562, MatchScore: 1},
+		},
+	}, nil
+}
+
+func (m mockMusicClientVersions) IsConfigured() bool         { return true }
+func (m mockMusicClientVersions) GetRateLimitRemaining() int { return -1 }
+
+func TestResolveTrackDuration_VersionsCountOnce(t *testing.T) {
+	db := newTestDB(t)
+
+	album := models.Album{Title: "Kind of Blue", Artist: "Miles Davis"}
+	db.Create(&album)
+	track := models.Track{AlbumID: album.ID, Title: "So What"}
+	db.Create(&track)
+
+	svc := &DurationResolverService{
+		db:      db,
+		clients: []duration.MusicAPIClient{mockMusicClientVersions{}},
+		config:  DefaultDurationResolverConfig(),
+	}
+	res, err := svc.ResolveTrackDuration(context.Background(), track)
+	if err != nil {
+		t.Fatalf("ResolveTrackDuration error: %v", err)
+	}
+	if res.Status != "needs_review" {
+		t.Errorf("status = %s, want needs_review from a single source's versions", res.Status)
+	}
+
+	var sources []models.DurationSource
+	db.Where("resolution_id = ?", res.ID).Order("id").Find(&sources)
+	if len(sources) != 3 || sources[0].ExternalID != "1" || sources[2].ExternalID != "3" || sources[2].SourceName != "discogs" {
+		t.Errorf("sources = %+v, want one per version", sources)
+	}
+}
+
+type mockMusicSource struct {
+	name    string
+	seconds int
+	score   float64
+}
+
+func (m mockMusicSource) Name() string { return m.name }
+
+func (m mockMusicSource) SearchTrack(ctx context.Context, title, artist, album string) (*duration.TrackSearchResult, error) {
+	return &duration.TrackSearchResult{Title: title, Duration: m.seconds, MatchScore: m.score, Confidence: 0.8}, nil
+}
+
+func (m mockMusicSource) IsConfigured() bool         { return true }
+func (m mockMusicSource) GetRateLimitRemaining() int { return -1 }
+
+func TestResolveTrackDuration_DefaultConsensusWeight(t *testing.T) {
+	db := newTestDB(t)
+	album := models.Album{Title: "Kind of Blue", Artist: "Miles Davis"}
+	db.Create(&album)
+
+	tests := []struct {
+		name    string
+		sources []duration.MusicAPIClient
+		want    string
+	}{
+		{"two trusted sources at a 0.9 match", []duration.MusicAPIClient{
+			mockMusicSource{"musicbrainz", 562, 0.9},
+			mockMusicSource{"lastfm", 563, 0.9},
+		}, "resolved"},
+		{"two loose sources", []duration.MusicAPIClient{
+			mockMusicSource{"wikipedia", 562, 1},
+			mockMusicSource{"youtube", 565, 0.9},
+		}, "needs_review"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			track := models.Track{AlbumID: album.ID, Title: "So What"}
+			db.Create(&track)
+			svc := &DurationResolverService{db: db, clients: tt.sources, config: DefaultDurationResolverConfig()}
+			res, err := svc.ResolveTrackDuration(context.Background(), track)
+			if err != nil {
+				t.Fatalf("ResolveTrackDuration error: %v", err)
+			}
+			if res.Status != tt.want {
+				t.Errorf("status = %s (weight %.2f), want %s", res.Status, res.ConsensusWeight, tt.want)
+			}
+		})
+	}
+}
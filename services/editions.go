@@ -0,0 +1,205 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"vinylfo/duration"
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// editionTitleKey normalizes a track title so remaster and edition suffixes don't block a match
+func editionTitleKey(title string) string {
+	return strings.ToLower(duration.NormalizeTitle(strings.TrimSpace(title)))
+}
+
+// matchEditionTrack picks the counterpart of track among another edition's tracks
+// Tracks match on title; the position only breaks ties, because reissues often
+// re-side the tracklist or add bonus tracks. Returns false when the edition lacks the track
+func matchEditionTrack(track models.Track, candidates []models.Track) (uint, bool) {
+	key := editionTitleKey(track.Title)
+	var match *models.Track
+	for i := range candidates {
+		if editionTitleKey(candidates[i].Title) != key {
+			continue
+		}
+		if strings.EqualFold(candidates[i].Position, track.Position) {
+			return candidates[i].ID, true
+		}
+		if match == nil {
+			match = &candidates[i]
+		}
+	}
+	if match == nil {
+		return 0, false
+	}
+	return match.ID, true
+}
+
+// PreferredTrackIDs swaps each track for its counterpart on the edition the playlist
+// prefers for the track's master release. Tracks without a preference or a counterpart are kept
+func PreferredTrackIDs(db *gorm.DB, playlistID string, trackIDs []uint) ([]uint, error) {
+	result := append([]uint(nil), trackIDs...)
+	if playlistID == "" || len(trackIDs) == 0 {
+		return result, nil
+	}
+
+	var preferences []models.PlaylistEdition
+	if err := db.Where("playlist_id = ?", playlistID).Find(&preferences).Error; err != nil {
+		return nil, err
+	}
+	if len(preferences) == 0 {
+		return result, nil
+	}
+	preferred := make(map[int]uint, len(preferences))
+	for _, p := range preferences {
+		preferred[p.MasterID] = p.AlbumID
+	}
+
+	var rows []struct {
+		models.Track
+		MasterID *int
+	}
+	err := db.Table("tracks").
+		Select("tracks.id, tracks.album_id, tracks.title, tracks.position, albums.discogs_master_id AS master_id").
+		Joins("JOIN albums ON albums.id = tracks.album_id").
+		Where("tracks.id IN ?", trackIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	swaps := make(map[uint]uint)
+	editions := make(map[uint][]models.Track)
+	for _, row := range rows {
+		if row.MasterID == nil {
+			continue
+		}
+		albumID, ok := preferred[*row.MasterID]
+		if !ok || albumID == row.AlbumID {
+			continue
+		}
+		candidates, loaded := editions[albumID]
+		if !loaded {
+			if err := db.Select("id", "title", "position").Where("album_id = ?", albumID).Find(&candidates).Error; err != nil {
+				return nil, err
+			}
+			editions[albumID] = candidates
+		}
+		if id, ok := matchEditionTrack(row.Track, candidates); ok {
+			swaps[row.ID] = id
+		}
+	}
+
+	for i, id := range result {
+		if swapped, ok := swaps[id]; ok {
+			result[i] = swapped
+		}
+	}
+	return result, nil
+}
+
+// SetPreferredEdition makes the album the playlist's edition of its master release and
+// moves the playlist's tracks, and the queue of its playback session, onto it
+// Returns the number of playlist entries that were switched
+func SetPreferredEdition(db *gorm.DB, playlistID string, albumID uint) (int, error) {
+	var album models.Album
+	if err := db.Select("id", "discogs_master_id").First(&album, albumID).Error; err != nil {
+		return 0, err
+	}
+	if album.DiscogsMasterID == nil {
+		return 0, fmt.Errorf("album %d is not linked to a Discogs master release", albumID)
+	}
+
+	switched := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var preference models.PlaylistEdition
+		err := tx.Where("playlist_id = ? AND master_id = ?", playlistID, *album.DiscogsMasterID).First(&preference).Error
+		switch {
+		case err == gorm.ErrRecordNotFound:
+			preference = models.PlaylistEdition{PlaylistID: playlistID, MasterID: *album.DiscogsMasterID, AlbumID: album.ID}
+			if err := tx.Create(&preference).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			if err := tx.Model(&preference).Update("album_id", album.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		var entries []models.SessionPlaylist
+		if err := tx.Where("session_id = ? AND track_id > 0", playlistID).Find(&entries).Error; err != nil {
+			return err
+		}
+		current := make([]uint, len(entries))
+		for i, entry := range entries {
+			current[i] = entry.TrackID
+		}
+		preferred, err := PreferredTrackIDs(tx, playlistID, current)
+		if err != nil {
+			return err
+		}
+		swaps := make(map[uint]uint)
+		for i, entry := range entries {
+			if preferred[i] == entry.TrackID {
+				continue
+			}
+			swaps[entry.TrackID] = preferred[i]
+			if err := tx.Model(&entry).Update("track_id", preferred[i]).Error; err != nil {
+				return err
+			}
+			switched++
+		}
+
+		return swapSessionQueue(tx, playlistID, swaps)
+	})
+	return switched, err
+}
+
+// ClearPreferredEdition forgets the playlist's preferred edition of a master release
+// Tracks already in the playlist stay on the edition they were switched to
+func ClearPreferredEdition(db *gorm.DB, playlistID string, masterID int) error {
+	return db.Where("playlist_id = ? AND master_id = ?", playlistID, masterID).Delete(&models.PlaylistEdition{}).Error
+}
+
+// swapSessionQueue replaces swapped tracks in the playlist's playback session
+func swapSessionQueue(tx *gorm.DB, playlistID string, swaps map[uint]uint) error {
+	if len(swaps) == 0 {
+		return nil
+	}
+
+	var session models.PlaybackSession
+	if err := tx.Where("playlist_id = ?", playlistID).First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	var queue []uint
+	if session.Queue != "" {
+		if err := json.Unmarshal([]byte(session.Queue), &queue); err != nil {
+			return nil
+		}
+	}
+	for i, id := range queue {
+		if swapped, ok := swaps[id]; ok {
+			queue[i] = swapped
+		}
+	}
+	queueJSON, _ := json.Marshal(queue)
+
+	updates := map[string]interface{}{
+		"queue":    string(queueJSON),
+		"revision": session.Revision + 1,
+	}
+	if swapped, ok := swaps[session.TrackID]; ok {
+		updates["track_id"] = swapped
+	}
+	return tx.Model(&session).Updates(updates).Error
+}
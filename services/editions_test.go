@@ -0,0 +1,122 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"vinylfo/models"
+)
+
+func TestMatchEditionTrack(t *testing.T) {
+	candidates := []models.Track{
+		{ID: 10, Title: "Time", Position: "A4"},
+		{ID: 11, Title: "Money (2011 Remaster)", Position: "B1"},
+		{ID: 12, Title: "Intro", Position: "A1"},
+		{ID: 13, Title: "Intro", Position: "C1"},
+	}
+
+	tests := []struct {
+		name   string
+		track  models.Track
+		wantID uint
+		wantOK bool
+	}{
+		{"same title", models.Track{Title: "Time", Position: "4"}, 10, true},
+		{"remaster suffix ignored", models.Track{Title: "Money", Position: "5"}, 11, true},
+		{"position breaks ties", models.Track{Title: "intro", Position: "C1"}, 13, true},
+		{"missing on edition", models.Track{Title: "Bonus Demo", Position: "A1"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := matchEditionTrack(tt.track, candidates)
+			if id != tt.wantID || ok != tt.wantOK {
+				t.Errorf("matchEditionTrack() = %d, %v, want %d, %v", id, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSetPreferredEdition(t *testing.T) {
+	db := newTestDB(t)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.SessionPlaylist{}, &models.PlaybackSession{}, &models.PlaylistEdition{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	master := 10362
+	original := models.Album{Title: "The Dark Side Of The Moon", Artist: "Pink Floyd", ReleaseYear: 1973, DiscogsMasterID: &master}
+	reissue := models.Album{Title: "The Dark Side Of The Moon", Artist: "Pink Floyd", ReleaseYear: 2016, DiscogsMasterID: &master}
+	other := models.Album{Title: "Animals", Artist: "Pink Floyd", ReleaseYear: 1977}
+	for _, a := range []*models.Album{&original, &reissue, &other} {
+		if err := db.Create(a).Error; err != nil {
+			t.Fatalf("create album: %v", err)
+		}
+	}
+
+	origTime := models.Track{AlbumID: original.ID, Title: "Time", Position: "A4"}
+	origMoney := models.Track{AlbumID: original.ID, Title: "Money", Position: "B1"}
+	reTime := models.Track{AlbumID: reissue.ID, Title: "Time", Position: "A4"}
+	reMoney := models.Track{AlbumID: reissue.ID, Title: "Money (2016 Remaster)", Position: "B1"}
+	dogs := models.Track{AlbumID: other.ID, Title: "Dogs", Position: "A2"}
+	for _, tr := range []*models.Track{&origTime, &origMoney, &reTime, &reMoney, &dogs} {
+		db.Create(tr)
+	}
+
+	for i, id := range []uint{origTime.ID, dogs.ID, origMoney.ID} {
+		db.Create(&models.SessionPlaylist{SessionID: "Sunday", TrackID: id, Order: i + 1})
+	}
+	queue, _ := json.Marshal([]uint{origTime.ID, dogs.ID, origMoney.ID})
+	db.Create(&models.PlaybackSession{PlaylistID: "Sunday", TrackID: origMoney.ID, Queue: string(queue), QueueIndex: 2})
+
+	switched, err := SetPreferredEdition(db, "Sunday", reissue.ID)
+	if err != nil {
+		t.Fatalf("SetPreferredEdition() error = %v", err)
+	}
+	if switched != 2 {
+		t.Errorf("switched = %d, want 2", switched)
+	}
+
+	var entries []models.SessionPlaylist
+	db.Where("session_id = ?", "Sunday").Order("`order`").Find(&entries)
+	want := []uint{reTime.ID, dogs.ID, reMoney.ID}
+	for i, entry := range entries {
+		if entry.TrackID != want[i] {
+			t.Errorf("entry %d track = %d, want %d", i, entry.TrackID, want[i])
+		}
+	}
+
+	var session models.PlaybackSession
+	db.First(&session, "playlist_id = ?", "Sunday")
+	if session.TrackID != reMoney.ID {
+		t.Errorf("session track = %d, want %d", session.TrackID, reMoney.ID)
+	}
+	var gotQueue []uint
+	json.Unmarshal([]byte(session.Queue), &gotQueue)
+	if len(gotQueue) != 3 || gotQueue[0] != reTime.ID || gotQueue[2] != reMoney.ID {
+		t.Errorf("session queue = %v, want %v", gotQueue, want)
+	}
+	if session.Revision != 1 {
+		t.Errorf("session revision = %d, want 1", session.Revision)
+	}
+
+	// Tracks added later from the original are swapped as well
+	ids, err := PreferredTrackIDs(db, "Sunday", []uint{origMoney.ID, dogs.ID})
+	if err != nil {
+		t.Fatalf("PreferredTrackIDs() error = %v", err)
+	}
+	if ids[0] != reMoney.ID || ids[1] != dogs.ID {
+		t.Errorf("PreferredTrackIDs() = %v, want [%d %d]", ids, reMoney.ID, dogs.ID)
+	}
+
+	// Other playlists are unaffected
+	ids, _ = PreferredTrackIDs(db, "Monday", []uint{origMoney.ID})
+	if ids[0] != origMoney.ID {
+		t.Errorf("PreferredTrackIDs() for another playlist = %v, want [%d]", ids, origMoney.ID)
+	}
+
+	if _, err := SetPreferredEdition(db, "Sunday", other.ID); err == nil {
+		t.Error("SetPreferredEdition() on an album without a master should fail")
+	}
+}
@@ -0,0 +1,509 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// Listening stats periods
+const (
+	StatsPeriodDay   = "day"
+	StatsPeriodWeek  = "week"
+	StatsPeriodMonth = "month"
+)
+
+// statsTopCount is how many artists, albums, tracks, genres, labels and decades a stats range ranks
+const statsTopCount = 10
+
+// yearInReviewTopCount is how many of each a year in review ranks
+const yearInReviewTopCount = 5
+
+// StatsTotals counts the plays in a range; skipped plays add to the listening time but not the plays
+type StatsTotals struct {
+	Plays   int `json:"plays"`
+	Skips   int `json:"skips"`
+	Seconds int `json:"seconds"`
+	Tracks  int `json:"tracks"`
+	Albums  int `json:"albums"`
+	Artists int `json:"artists"`
+}
+
+// StatsBucket is the listening in one day, week or month
+type StatsBucket struct {
+	Period  string    `json:"period"` // 2026-03-01, 2026-W09 or 2026-03
+	Start   time.Time `json:"start"`
+	Plays   int       `json:"plays"`
+	Seconds int       `json:"seconds"`
+}
+
+// StatsEntry is one ranked artist, album, track, genre, label or decade
+type StatsEntry struct {
+	Name    string `json:"name"`
+	Artist  string `json:"artist,omitempty"`
+	AlbumID uint   `json:"album_id,omitempty"`
+	TrackID uint   `json:"track_id,omitempty"`
+	Plays   int    `json:"plays"`
+	Seconds int    `json:"seconds"`
+}
+
+// ListeningStreak is a run of consecutive days with at least one play
+type ListeningStreak struct {
+	Days  int    `json:"days"`
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+// ListeningStats summarises the play log between From and To
+type ListeningStats struct {
+	From          time.Time       `json:"from"`
+	To            time.Time       `json:"to"`
+	Period        string          `json:"period"`
+	Totals        StatsTotals     `json:"totals"`
+	Timeline      []StatsBucket   `json:"timeline"`
+	TopArtists    []StatsEntry    `json:"top_artists"`
+	TopAlbums     []StatsEntry    `json:"top_albums"`
+	TopTracks     []StatsEntry    `json:"top_tracks"`
+	TopGenres     []StatsEntry    `json:"top_genres"`
+	TopLabels     []StatsEntry    `json:"top_labels"`
+	TopDecades    []StatsEntry    `json:"top_decades"`
+	CurrentStreak ListeningStreak `json:"current_streak"` // Ending on the last day of the range or the day before
+	LongestStreak ListeningStreak `json:"longest_streak"`
+}
+
+// SideCompletion is how much of one side of a record was played
+type SideCompletion struct {
+	AlbumID  uint   `json:"album_id"`
+	Title    string `json:"title"`
+	Artist   string `json:"artist"`
+	Side     string `json:"side"`
+	Tracks   int    `json:"tracks"`
+	Played   int    `json:"played"` // Tracks of the side played through at least once
+	Complete bool   `json:"complete"`
+}
+
+// YearInReview is the listening of one calendar year
+type YearInReview struct {
+	Year           int             `json:"year"`
+	Totals         StatsTotals     `json:"totals"`
+	Months         []StatsBucket   `json:"months"`
+	TopArtists     []StatsEntry    `json:"top_artists"`
+	TopAlbums      []StatsEntry    `json:"top_albums"`
+	TopTracks      []StatsEntry    `json:"top_tracks"`
+	TopGenres      []StatsEntry    `json:"top_genres"`
+	TopDecades     []StatsEntry    `json:"top_decades"`
+	LongestStreak  ListeningStreak `json:"longest_streak"`
+	BusiestDay     *StatsBucket    `json:"busiest_day"`
+	BusiestMonth   *StatsBucket    `json:"busiest_month"`
+	NewAlbums      int             `json:"new_albums"` // Albums first played this year
+	SidesCompleted int             `json:"sides_completed"`
+}
+
+// statsPlay is a play event with the track and album details the stats group by
+type statsPlay struct {
+	TrackID     uint
+	AlbumID     uint
+	StartedAt   time.Time
+	Seconds     int
+	Skipped     bool
+	Title       string
+	AlbumTitle  string
+	Artist      string
+	Genre       string
+	Label       string
+	ReleaseYear int
+}
+
+// loadStatsPlays returns the play events started in [from, to), oldest first
+// Plays seeded from track history are left out, as they only record when a track was last played
+func loadStatsPlays(db *gorm.DB, from, to time.Time) ([]statsPlay, error) {
+	var plays []statsPlay
+	err := db.Table("play_events").
+		Select("play_events.track_id, play_events.album_id, play_events.started_at, play_events.seconds, play_events.skipped, "+
+			"tracks.title, albums.title AS album_title, albums.artist, albums.genre, albums.label, albums.release_year").
+		Joins("LEFT JOIN tracks ON tracks.id = play_events.track_id").
+		Joins("LEFT JOIN albums ON albums.id = play_events.album_id").
+		Where("play_events.started_at >= ? AND play_events.started_at < ?", from, to).
+		Where("play_events.source <> ?", models.PlaySourceHistory).
+		Order("play_events.started_at, play_events.id").
+		Scan(&plays).Error
+	return plays, err
+}
+
+// periodStart returns the start of the day, ISO week or month t falls in, and its label
+func periodStart(t time.Time, period string) (time.Time, string) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch period {
+	case StatsPeriodWeek:
+		start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		year, week := start.ISOWeek()
+		return start, fmt.Sprintf("%d-W%02d", year, week)
+	case StatsPeriodMonth:
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+		return start, start.Format("2006-01")
+	}
+	return day, day.Format("2006-01-02")
+}
+
+func nextPeriod(start time.Time, period string) time.Time {
+	switch period {
+	case StatsPeriodWeek:
+		return start.AddDate(0, 0, 7)
+	case StatsPeriodMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// timeline buckets the plays by period, with empty buckets for periods without plays
+func timeline(plays []statsPlay, period string, from, to time.Time) []StatsBucket {
+	buckets := make([]StatsBucket, 0)
+	index := make(map[string]int)
+	for start, _ := periodStart(from, period); start.Before(to); start = nextPeriod(start, period) {
+		_, label := periodStart(start, period)
+		index[label] = len(buckets)
+		buckets = append(buckets, StatsBucket{Period: label, Start: start})
+	}
+	for _, play := range plays {
+		_, label := periodStart(play.StartedAt.In(from.Location()), period)
+		i, ok := index[label]
+		if !ok {
+			continue
+		}
+		buckets[i].Seconds += play.Seconds
+		if !play.Skipped {
+			buckets[i].Plays++
+		}
+	}
+	return buckets
+}
+
+// ranking adds up plays under a key and keeps the entry details of the first play seen
+type ranking struct {
+	entries map[string]*StatsEntry
+}
+
+func newRanking() *ranking {
+	return &ranking{entries: make(map[string]*StatsEntry)}
+}
+
+func (r *ranking) add(key string, play statsPlay, entry StatsEntry) {
+	if key == "" {
+		return
+	}
+	e := r.entries[key]
+	if e == nil {
+		e = &entry
+		r.entries[key] = e
+	}
+	e.Seconds += play.Seconds
+	if !play.Skipped {
+		e.Plays++
+	}
+}
+
+// top returns the n entries with the most plays, then the most listening time
+func (r *ranking) top(n int) []StatsEntry {
+	entries := make([]StatsEntry, 0, len(r.entries))
+	for _, e := range r.entries {
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Plays != entries[j].Plays {
+			return entries[i].Plays > entries[j].Plays
+		}
+		if entries[i].Seconds != entries[j].Seconds {
+			return entries[i].Seconds > entries[j].Seconds
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// decade returns the decade of a release year, e.g. 1970s, or "" when the year is unknown
+func decade(year int) string {
+	if year < 1000 {
+		return ""
+	}
+	return fmt.Sprintf("%ds", year/10*10)
+}
+
+// summarizePlays fills the totals and rankings of stats from the plays
+func summarizePlays(stats *ListeningStats, plays []statsPlay, n int) {
+	artists, albums, tracks := newRanking(), newRanking(), newRanking()
+	genres, labels, decades := newRanking(), newRanking(), newRanking()
+	for _, play := range plays {
+		stats.Totals.Seconds += play.Seconds
+		if play.Skipped {
+			stats.Totals.Skips++
+		} else {
+			stats.Totals.Plays++
+		}
+		artists.add(play.Artist, play, StatsEntry{Name: play.Artist})
+		albums.add(fmt.Sprint(play.AlbumID), play, StatsEntry{Name: play.AlbumTitle, Artist: play.Artist, AlbumID: play.AlbumID})
+		tracks.add(fmt.Sprint(play.TrackID), play, StatsEntry{Name: play.Title, Artist: play.Artist, AlbumID: play.AlbumID, TrackID: play.TrackID})
+		genres.add(play.Genre, play, StatsEntry{Name: play.Genre})
+		labels.add(play.Label, play, StatsEntry{Name: play.Label})
+		d := decade(play.ReleaseYear)
+		decades.add(d, play, StatsEntry{Name: d})
+	}
+	stats.Totals.Tracks = len(tracks.entries)
+	stats.Totals.Albums = len(albums.entries)
+	stats.Totals.Artists = len(artists.entries)
+
+	stats.TopArtists = artists.top(n)
+	stats.TopAlbums = albums.top(n)
+	stats.TopTracks = tracks.top(n)
+	stats.TopGenres = genres.top(n)
+	stats.TopLabels = labels.top(n)
+	stats.TopDecades = decades.top(n)
+}
+
+// streaks returns the longest run of consecutive days with a play, and the run ending on last or
+// the day before it
+func streaks(plays []statsPlay, last time.Time) (current, longest ListeningStreak) {
+	var days []time.Time
+	seen := make(map[string]bool)
+	for _, play := range plays {
+		if play.Skipped {
+			continue
+		}
+		day, label := periodStart(play.StartedAt.In(last.Location()), StatsPeriodDay)
+		if !seen[label] {
+			seen[label] = true
+			days = append(days, day)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	var run ListeningStreak
+	for i, day := range days {
+		if i > 0 && days[i-1].AddDate(0, 0, 1).Equal(day) {
+			run.Days++
+		} else {
+			run = ListeningStreak{Days: 1, Start: day.Format("2006-01-02")}
+		}
+		run.End = day.Format("2006-01-02")
+		if run.Days > longest.Days {
+			longest = run
+		}
+	}
+
+	lastDay, label := periodStart(last, StatsPeriodDay)
+	if run.End == label || run.End == lastDay.AddDate(0, 0, -1).Format("2006-01-02") {
+		current = run
+	}
+	return current, longest
+}
+
+// SummarizeListening summarises the plays started in [from, to), bucketed by day, week or month
+// in from's time zone
+func SummarizeListening(db *gorm.DB, from, to time.Time, period string) (*ListeningStats, error) {
+	plays, err := loadStatsPlays(db, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ListeningStats{From: from, To: to, Period: period}
+	stats.Timeline = timeline(plays, period, from, to)
+	summarizePlays(stats, plays, statsTopCount)
+
+	last := to.Add(-time.Nanosecond)
+	if now := time.Now().In(from.Location()); now.Before(last) {
+		last = now
+	}
+	stats.CurrentStreak, stats.LongestStreak = streaks(plays, last)
+	return stats, nil
+}
+
+// NeverPlayedAlbums returns a page of the albums in the collection with no plays in the log, the
+// ones added longest ago first, and how many there are
+func NeverPlayedAlbums(db *gorm.DB, offset, limit int) ([]models.Album, int64, error) {
+	base := db.Model(&models.Album{}).
+		Where("discogs_orphaned_at IS NULL").
+		Where("NOT EXISTS (SELECT 1 FROM play_events WHERE play_events.album_id = albums.id)")
+
+	var total int64
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var albums []models.Album
+	err := base.Omit("discogs_cover_image").Order("COALESCE(discogs_added_at, created_at), id").
+		Offset(offset).Limit(limit).Find(&albums).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return albums, total, nil
+}
+
+// trackSide returns the side of a vinyl track from the letters its position starts with, A for
+// A1, or "" for positions without a side such as CD track numbers
+func trackSide(track models.Track) string {
+	position := track.Position
+	if position == "" {
+		position = track.Side
+	}
+	end := 0
+	for end < len(position) && (position[end] >= 'A' && position[end] <= 'Z' || position[end] >= 'a' && position[end] <= 'z') {
+		end++
+	}
+	return strings.ToUpper(position[:end])
+}
+
+// SideCompletions returns how much of each side with plays in [from, to) was played
+// A track counts once it has been played through, so skipping across a side does not complete it
+func SideCompletions(db *gorm.DB, from, to time.Time) ([]SideCompletion, error) {
+	plays, err := loadStatsPlays(db, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return sideCompletion(db, plays)
+}
+
+func sideCompletion(db *gorm.DB, plays []statsPlay) ([]SideCompletion, error) {
+	played := make(map[uint]bool)
+	albumSet := make(map[uint]bool)
+	for _, play := range plays {
+		if !play.Skipped {
+			played[play.TrackID] = true
+		}
+		albumSet[play.AlbumID] = true
+	}
+	sides := make([]SideCompletion, 0)
+	if len(albumSet) == 0 {
+		return sides, nil
+	}
+	albumIDs := make([]uint, 0, len(albumSet))
+	for id := range albumSet {
+		albumIDs = append(albumIDs, id)
+	}
+
+	var albums []models.Album
+	if err := db.Select("id, title, artist").Where("id IN ?", albumIDs).Find(&albums).Error; err != nil {
+		return nil, err
+	}
+	var tracks []models.Track
+	if err := db.Where("album_id IN ?", albumIDs).Order("album_id, disc_number, track_number, id").Find(&tracks).Error; err != nil {
+		return nil, err
+	}
+
+	albumByID := make(map[uint]models.Album, len(albums))
+	for _, album := range albums {
+		albumByID[album.ID] = album
+	}
+	index := make(map[string]int)
+	for _, track := range tracks {
+		side := trackSide(track)
+		album, ok := albumByID[track.AlbumID]
+		if side == "" || !ok {
+			continue
+		}
+		key := fmt.Sprintf("%d/%s", track.AlbumID, side)
+		i, ok := index[key]
+		if !ok {
+			i = len(sides)
+			index[key] = i
+			sides = append(sides, SideCompletion{AlbumID: album.ID, Title: album.Title, Artist: album.Artist, Side: side})
+		}
+		sides[i].Tracks++
+		if played[track.ID] {
+			sides[i].Played++
+		}
+	}
+
+	// Sides of a played album that were never touched are left out
+	kept := sides[:0]
+	for _, side := range sides {
+		if side.Played == 0 {
+			continue
+		}
+		side.Complete = side.Played == side.Tracks
+		kept = append(kept, side)
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		if kept[i].Artist != kept[j].Artist {
+			return kept[i].Artist < kept[j].Artist
+		}
+		if kept[i].Title != kept[j].Title {
+			return kept[i].Title < kept[j].Title
+		}
+		return kept[i].Side < kept[j].Side
+	})
+	return kept, nil
+}
+
+// busiest returns the bucket with the most plays, or nil when nothing was played
+func busiest(buckets []StatsBucket) *StatsBucket {
+	var best *StatsBucket
+	for i := range buckets {
+		if buckets[i].Plays > 0 && (best == nil || buckets[i].Plays > best.Plays) {
+			best = &buckets[i]
+		}
+	}
+	return best
+}
+
+// ReviewYear summarises the listening of a calendar year in loc
+func ReviewYear(db *gorm.DB, year int, loc *time.Location) (*YearInReview, error) {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	to := from.AddDate(1, 0, 0)
+	plays, err := loadStatsPlays(db, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ListeningStats{}
+	summarizePlays(stats, plays, yearInReviewTopCount)
+	_, longest := streaks(plays, to.Add(-time.Nanosecond))
+	review := &YearInReview{
+		Year:          year,
+		Totals:        stats.Totals,
+		Months:        timeline(plays, StatsPeriodMonth, from, to),
+		TopArtists:    stats.TopArtists,
+		TopAlbums:     stats.TopAlbums,
+		TopTracks:     stats.TopTracks,
+		TopGenres:     stats.TopGenres,
+		TopDecades:    stats.TopDecades,
+		LongestStreak: longest,
+	}
+	review.BusiestMonth = busiest(review.Months)
+	review.BusiestDay = busiest(timeline(plays, StatsPeriodDay, from, to))
+
+	sides, err := sideCompletion(db, plays)
+	if err != nil {
+		return nil, err
+	}
+	for _, side := range sides {
+		if side.Complete {
+			review.SidesCompleted++
+		}
+	}
+
+	if len(plays) > 0 {
+		albumIDs := make([]uint, 0, stats.Totals.Albums)
+		seen := make(map[uint]bool)
+		for _, play := range plays {
+			if !seen[play.AlbumID] {
+				seen[play.AlbumID] = true
+				albumIDs = append(albumIDs, play.AlbumID)
+			}
+		}
+		var earlier int64
+		err := db.Model(&models.PlayEvent{}).
+			Where("started_at < ? AND album_id IN ?", from, albumIDs).
+			Distinct("album_id").Count(&earlier).Error
+		if err != nil {
+			return nil, err
+		}
+		review.NewAlbums = len(albumIDs) - int(earlier)
+	}
+	return review, nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// newTestStatsDB returns a database with three records, one with sides, one without and one never
+// played, and a helper that logs a play of one of their tracks
+func newTestStatsDB(t *testing.T) (*gorm.DB, []models.Album, []models.Track, func(track models.Track, started time.Time, seconds int, skipped bool)) {
+	t.Helper()
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&models.PlayEvent{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	albums := []models.Album{
+		{Title: "Blue Train", Artist: "John Coltrane", Genre: "Jazz", Label: "Blue Note", ReleaseYear: 1957},
+		{Title: "Rumours", Artist: "Fleetwood Mac", Genre: "Rock", Label: "Warner Bros.", ReleaseYear: 1977},
+		{Title: "Kind of Blue", Artist: "Miles Davis", Genre: "Jazz", Label: "Columbia", ReleaseYear: 1959},
+	}
+	db.Create(&albums)
+	tracks := []models.Track{
+		{AlbumID: albums[0].ID, Title: "Blue Train", Position: "A1", Duration: 600},
+		{AlbumID: albums[0].ID, Title: "Moment's Notice", Position: "A2", Duration: 540},
+		{AlbumID: albums[0].ID, Title: "Locomotion", Position: "B1", Duration: 420},
+		{AlbumID: albums[1].ID, Title: "Dreams", Position: "3", Duration: 250},
+	}
+	db.Create(&tracks)
+
+	play := func(track models.Track, started time.Time, seconds int, skipped bool) {
+		t.Helper()
+		err := db.Create(&models.PlayEvent{
+			TrackID:   track.ID,
+			AlbumID:   track.AlbumID,
+			StartedAt: started,
+			EndedAt:   started.Add(time.Duration(seconds) * time.Second),
+			Seconds:   seconds,
+			Skipped:   skipped,
+			Source:    models.PlaySourceTimer,
+		}).Error
+		if err != nil {
+			t.Fatalf("create play event: %v", err)
+		}
+	}
+	return db, albums, tracks, play
+}
+
+func TestSummarizeListening(t *testing.T) {
+	db, albums, tracks, play := newTestStatsDB(t)
+	day := func(d, hour int) time.Time { return time.Date(2026, time.March, d, hour, 0, 0, 0, time.Local) }
+
+	play(tracks[0], day(2, 20), 600, false)
+	play(tracks[1], day(2, 21), 540, false)
+	play(tracks[0], day(3, 20), 600, false)
+	play(tracks[3], day(4, 9), 250, false)
+	play(tracks[2], day(4, 10), 30, true)
+	play(tracks[3], day(6, 9), 250, false)
+	play(tracks[3], day(20, 9), 250, false) // Outside the range
+	// Seeded from track history, so only the time of the last play is known
+	db.Create(&models.PlayEvent{TrackID: tracks[3].ID, AlbumID: albums[1].ID, StartedAt: day(5, 9), EndedAt: day(5, 9), Source: models.PlaySourceHistory})
+
+	stats, err := SummarizeListening(db, day(1, 0), day(8, 0), StatsPeriodDay)
+	if err != nil {
+		t.Fatalf("SummarizeListening() error = %v", err)
+	}
+
+	want := StatsTotals{Plays: 5, Skips: 1, Seconds: 2270, Tracks: 4, Albums: 2, Artists: 2}
+	if stats.Totals != want {
+		t.Errorf("totals = %+v, want %+v", stats.Totals, want)
+	}
+	if len(stats.Timeline) != 7 || stats.Timeline[1].Period != "2026-03-02" || stats.Timeline[1].Plays != 2 || stats.Timeline[4].Plays != 0 {
+		t.Errorf("timeline = %+v, want 7 days with 2 plays on March 2", stats.Timeline)
+	}
+	if top := stats.TopArtists[0]; top.Name != "John Coltrane" || top.Plays != 3 || top.Seconds != 1770 {
+		t.Errorf("top artist = %+v, want John Coltrane with 3 plays", top)
+	}
+	if top := stats.TopAlbums[0]; top.AlbumID != albums[0].ID || top.Artist != "John Coltrane" {
+		t.Errorf("top album = %+v, want Blue Train", top)
+	}
+	if top := stats.TopTracks[0]; top.TrackID != tracks[0].ID || top.Plays != 2 {
+		t.Errorf("top track = %+v, want Blue Train ahead of Dreams on listening time", top)
+	}
+	if len(stats.TopDecades) != 2 || stats.TopDecades[0].Name != "1950s" || stats.TopLabels[0].Name != "Blue Note" {
+		t.Errorf("top decades = %+v, labels = %+v", stats.TopDecades, stats.TopLabels)
+	}
+	if stats.LongestStreak != (ListeningStreak{Days: 3, Start: "2026-03-02", End: "2026-03-04"}) {
+		t.Errorf("longest streak = %+v, want March 2 to 4", stats.LongestStreak)
+	}
+	if stats.CurrentStreak.Days != 1 || stats.CurrentStreak.End != "2026-03-06" {
+		t.Errorf("current streak = %+v, want the day before the end of the range", stats.CurrentStreak)
+	}
+
+	weeks, err := SummarizeListening(db, day(1, 0), day(22, 0), StatsPeriodWeek)
+	if err != nil {
+		t.Fatalf("SummarizeListening(week) error = %v", err)
+	}
+	if len(weeks.Timeline) != 4 || weeks.Timeline[0].Period != "2026-W09" || weeks.Timeline[1].Plays != 5 || weeks.Timeline[3].Plays != 1 {
+		t.Errorf("weekly timeline = %+v", weeks.Timeline)
+	}
+	if weeks.CurrentStreak.Start != "2026-03-20" || weeks.LongestStreak.Days != 3 {
+		t.Errorf("streaks = %+v and %+v, want the current one restarted after the gap", weeks.CurrentStreak, weeks.LongestStreak)
+	}
+}
+
+func TestSideCompletionsAndNeverPlayed(t *testing.T) {
+	db, albums, tracks, play := newTestStatsDB(t)
+	start := time.Date(2026, time.May, 1, 20, 0, 0, 0, time.Local)
+
+	play(tracks[0], start, 600, false)
+	play(tracks[1], start.Add(10*time.Minute), 540, false)
+	play(tracks[2], start.Add(20*time.Minute), 30, true)
+	play(tracks[3], start.Add(30*time.Minute), 250, false)
+
+	sides, err := SideCompletions(db, start.AddDate(0, 0, -1), start.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("SideCompletions() error = %v", err)
+	}
+	// Side B was only skipped and Rumours' positions have no sides
+	if len(sides) != 1 || sides[0].Side != "A" || !sides[0].Complete || sides[0].Tracks != 2 {
+		t.Errorf("sides = %+v, want a complete side A of Blue Train", sides)
+	}
+
+	never, total, err := NeverPlayedAlbums(db, 0, 10)
+	if err != nil {
+		t.Fatalf("NeverPlayedAlbums() error = %v", err)
+	}
+	if total != 1 || len(never) != 1 || never[0].ID != albums[2].ID {
+		t.Errorf("never played = %d %+v, want Kind of Blue", total, never)
+	}
+}
+
+func TestReviewYear(t *testing.T) {
+	db, _, tracks, play := newTestStatsDB(t)
+	play(tracks[3], time.Date(2025, time.December, 30, 21, 0, 0, 0, time.Local), 250, false)
+	play(tracks[0], time.Date(2026, time.February, 1, 20, 0, 0, 0, time.Local), 600, false)
+	play(tracks[1], time.Date(2026, time.February, 1, 21, 0, 0, 0, time.Local), 540, false)
+	play(tracks[3], time.Date(2026, time.April, 2, 9, 0, 0, 0, time.Local), 250, false)
+
+	review, err := ReviewYear(db, 2026, time.Local)
+	if err != nil {
+		t.Fatalf("ReviewYear() error = %v", err)
+	}
+	if review.Totals.Plays != 3 || len(review.Months) != 12 || review.Months[1].Plays != 2 {
+		t.Errorf("review totals = %+v, months = %+v", review.Totals, review.Months)
+	}
+	if review.BusiestMonth == nil || review.BusiestMonth.Period != "2026-02" || review.BusiestDay.Period != "2026-02-01" {
+		t.Errorf("busiest month = %+v, day = %+v", review.BusiestMonth, review.BusiestDay)
+	}
+	if review.NewAlbums != 1 || review.SidesCompleted != 1 {
+		t.Errorf("new albums = %d, sides completed = %d, want Blue Train new with side A complete", review.NewAlbums, review.SidesCompleted)
+	}
+
+	empty, err := ReviewYear(db, 2024, time.Local)
+	if err != nil {
+		t.Fatalf("ReviewYear(2024) error = %v", err)
+	}
+	if empty.Totals.Plays != 0 || empty.BusiestDay != nil || len(empty.TopAlbums) != 0 {
+		t.Errorf("empty year = %+v", empty)
+	}
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"vinylfo/audio"
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrNoLocalAudio is returned for tracks without a playable local audio file
+var ErrNoLocalAudio = errors.New("track has no local audio file")
+
+// LocalAudio is the local file a track plays from
+type LocalAudio struct {
+	Path     string
+	Format   string
+	Size     int64
+	ModTime  time.Time
+	Duration int // Seconds, 0 when the file's length could not be read
+}
+
+// FindLocalAudio returns the library file linked to a track, or the file its file:// audio URL
+// points to when that is inside a library folder
+// Other paths are never served, so a track's URL cannot expose files outside the library
+func FindLocalAudio(db *gorm.DB, track *models.Track) (*LocalAudio, error) {
+	var files []models.AudioFile
+	if err := db.Where("track_id = ?", track.ID).Limit(1).Find(&files).Error; err != nil {
+		return nil, err
+	}
+
+	var local *LocalAudio
+	if len(files) > 0 {
+		local = &LocalAudio{Path: files[0].Path, Format: files[0].Format, Duration: files[0].Duration}
+	} else if path, ok := audio.PathFromURL(track.AudioFileURL); ok && audio.FormatOf(path) != "" && inLibrary(path, LibraryPaths(db)) {
+		local = &LocalAudio{Path: path, Format: audio.FormatOf(path)}
+		var durations []int
+		if err := db.Model(&models.AudioFile{}).Where("path = ?", path).Limit(1).Pluck("duration", &durations).Error; err == nil && len(durations) > 0 {
+			local.Duration = durations[0]
+		}
+	}
+	if local == nil {
+		return nil, ErrNoLocalAudio
+	}
+
+	info, err := os.Stat(local.Path)
+	if err != nil || info.IsDir() {
+		return nil, ErrNoLocalAudio // Moved or deleted since the last scan
+	}
+	local.Size = info.Size()
+	local.ModTime = info.ModTime()
+
+	if local.Duration == 0 {
+		if meta, err := audio.ReadFile(local.Path); err == nil {
+			local.Duration = int(meta.Duration.Round(time.Second) / time.Second)
+		}
+	}
+	return local, nil
+}
+
+// inLibrary reports whether a path is inside one of the library folders
+func inLibrary(path string, roots []string) bool {
+	path = filepath.Clean(path)
+	for _, root := range roots {
+		rel, err := filepath.Rel(filepath.Clean(root), path)
+		if err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel) {
+			return true
+		}
+	}
+	return false
+}
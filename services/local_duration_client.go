@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"vinylfo/audio"
+	"vinylfo/duration"
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// localFileConfidence is reported for durations decoded from the track's own audio file
+const localFileConfidence = 0.99
+
+type resolverTrackKey struct{}
+
+// withResolverTrack attaches the track being resolved to ctx, for clients that need more than
+// its title, artist and album
+func withResolverTrack(ctx context.Context, track models.Track) context.Context {
+	return context.WithValue(ctx, resolverTrackKey{}, track)
+}
+
+func resolverTrack(ctx context.Context) (models.Track, bool) {
+	track, ok := ctx.Value(resolverTrackKey{}).(models.Track)
+	return track, ok
+}
+
+// LocalFileClient is a duration source that decodes the length of a track's local audio file
+// It only answers for the track being resolved, so it has no use outside the resolver
+type LocalFileClient struct {
+	db *gorm.DB
+}
+
+func NewLocalFileClient(db *gorm.DB) *LocalFileClient {
+	return &LocalFileClient{db: db}
+}
+
+func (c *LocalFileClient) Name() string {
+	return "local_file"
+}
+
+func (c *LocalFileClient) SearchTrack(ctx context.Context, title, artist, album string) (*duration.TrackSearchResult, error) {
+	track, ok := resolverTrack(ctx)
+	if !ok {
+		return nil, nil
+	}
+
+	var path string
+	var seconds int
+	local, err := FindLocalAudio(c.db, &track)
+	switch {
+	case err == nil:
+		path, seconds = local.Path, local.Duration
+	case errors.Is(err, ErrNoLocalAudio):
+		// Only the length is read here, so a file URL outside the library folders is still usable
+		p, ok := audio.PathFromURL(track.AudioFileURL)
+		if !ok || audio.FormatOf(p) == "" {
+			return nil, nil
+		}
+		meta, err := audio.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		path, seconds = p, int(meta.Duration.Round(time.Second)/time.Second)
+	default:
+		return nil, err
+	}
+	if seconds <= 0 {
+		return nil, nil
+	}
+
+	return &duration.TrackSearchResult{
+		ExternalID:  path,
+		ExternalURL: audio.FileURL(path),
+		Title:       title,
+		Artist:      artist,
+		Album:       album,
+		Duration:    seconds,
+		MatchScore:  1.0,
+		Confidence:  localFileConfidence,
+	}, nil
+}
+
+func (c *LocalFileClient) IsConfigured() bool {
+	return true
+}
+
+func (c *LocalFileClient) GetRateLimitRemaining() int {
+	return -1
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"vinylfo/discogs"
+	"vinylfo/models"
+)
+
+type fakeWantlist struct {
+	pages [][]discogs.Want
+}
+
+func (f *fakeWantlist) GetWantlist(username string, page, perPage int) ([]discogs.Want, int, error) {
+	return f.pages[page-1], len(f.pages), nil
+}
+
+type fakePrices struct {
+	lowest      map[int]float64
+	suggestions map[string]discogs.Price
+	calls       []int
+}
+
+func (f *fakePrices) GetPriceStats(releaseID int, currency string) (*discogs.PriceStats, error) {
+	f.calls = append(f.calls, releaseID)
+	stats := &discogs.PriceStats{Currency: currency, NumForSale: 3}
+	if price, ok := f.lowest[releaseID]; ok {
+		stats.LowestPrice = &price
+	}
+	return stats, nil
+}
+
+func (f *fakePrices) GetPriceSuggestions(releaseID int) (map[string]discogs.Price, error) {
+	return f.suggestions, nil
+}
+
+func (f *fakePrices) IsAuthenticated() bool {
+	return f.suggestions != nil
+}
+
+func TestSyncWantlist(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&models.WantlistItem{}, &models.PriceSnapshot{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	db.Create(&models.WantlistItem{DiscogsID: 1, Title: "Old Title"})
+	db.Create(&models.WantlistItem{DiscogsID: 2, Title: "No Longer Wanted"})
+	db.Create(&models.PriceSnapshot{DiscogsID: 2, FetchedAt: time.Now(), Currency: "USD"})
+
+	source := &fakeWantlist{pages: [][]discogs.Want{
+		{{DiscogsID: 1, Title: "Blue Train", Artist: "John Coltrane", MasterID: 64476}},
+		{{DiscogsID: 3, Title: "Kind Of Blue", Artist: "Miles Davis"}},
+	}}
+
+	result, err := SyncWantlist(db, source, "collector")
+	if err != nil {
+		t.Fatalf("SyncWantlist: %v", err)
+	}
+	if result.Added != 1 || result.Updated != 1 || result.Removed != 1 || result.Total != 2 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	var items []models.WantlistItem
+	db.Order("discogs_id").Find(&items)
+	if len(items) != 2 || items[0].Title != "Blue Train" || items[0].DiscogsMasterID == nil || items[1].DiscogsMasterID != nil {
+		t.Errorf("unexpected wantlist: %+v", items)
+	}
+
+	var snapshots int64
+	db.Model(&models.PriceSnapshot{}).Count(&snapshots)
+	if snapshots != 0 {
+		t.Errorf("expected the removed release's prices to be deleted, %d left", snapshots)
+	}
+}
+
+func TestRefreshPrices(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&models.AppConfig{}, &models.WantlistItem{}, &models.PriceSnapshot{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+	db.Create(&models.AppConfig{MarketCurrency: "eur"})
+
+	owned, stale, fresh := 100, 200, 300
+	db.Create(&models.Album{Title: "Owned", Artist: "A", DiscogsID: &owned})
+	db.Create(&models.Album{Title: "Stale", Artist: "A", DiscogsID: &stale})
+	db.Create(&models.WantlistItem{DiscogsID: fresh, Title: "Fresh"})
+	db.Create(&models.PriceSnapshot{DiscogsID: stale, FetchedAt: time.Now().AddDate(0, 0, -30), Currency: "EUR"})
+	db.Create(&models.PriceSnapshot{DiscogsID: fresh, FetchedAt: time.Now().Add(-time.Hour), Currency: "EUR"})
+
+	source := &fakePrices{
+		lowest:      map[int]float64{owned: 12.5},
+		suggestions: map[string]discogs.Price{"VG+": {Value: 20, Currency: "EUR"}, "NM": {Value: 30, Currency: "USD"}},
+	}
+	refreshed, err := RefreshPrices(db, source, 10, PriceMaxAge)
+	if err != nil {
+		t.Fatalf("RefreshPrices: %v", err)
+	}
+	if refreshed != 2 || len(source.calls) != 2 || source.calls[0] != owned || source.calls[1] != stale {
+		t.Errorf("refreshed %d releases in order %v, want never-priced %d then stale %d", refreshed, source.calls, owned, stale)
+	}
+
+	var snapshot models.PriceSnapshot
+	db.Where("discogs_id = ?", owned).First(&snapshot)
+	if snapshot.Currency != "EUR" || snapshot.LowestPrice == nil || *snapshot.LowestPrice != 12.5 {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+	if snapshot.SuggestedPrices != `{"VG+":20}` {
+		t.Errorf("SuggestedPrices = %q, want only prices in the tracked currency", snapshot.SuggestedPrices)
+	}
+}
+
+func TestValueCollection(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&models.WantlistItem{}, &models.PriceSnapshot{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	price := func(v float64) *float64 { return &v }
+	a, b, c, wanted := 1, 2, 3, 4
+	db.Create(&models.Album{Title: "Graded", Artist: "A", DiscogsID: &a, MediaCondition: "VG+"})
+	db.Create(&models.Album{Title: "Riser", Artist: "B", DiscogsID: &b})
+	db.Create(&models.Album{Title: "Unpriced", Artist: "C", DiscogsID: &c})
+	db.Create(&models.Album{Title: "Local Only", Artist: "D"})
+	db.Create(&models.WantlistItem{DiscogsID: wanted, Title: "Wanted"})
+
+	now := time.Now()
+	db.Create(&models.PriceSnapshot{DiscogsID: a, FetchedAt: now.Add(-time.Hour), Currency: "USD",
+		LowestPrice: price(5), SuggestedPrices: `{"VG+":25,"NM":40}`})
+	db.Create(&models.PriceSnapshot{DiscogsID: b, FetchedAt: now.AddDate(0, 0, -40), Currency: "USD", LowestPrice: price(10)})
+	db.Create(&models.PriceSnapshot{DiscogsID: b, FetchedAt: now.Add(-time.Hour), Currency: "USD", LowestPrice: price(15)})
+	db.Create(&models.PriceSnapshot{DiscogsID: c, FetchedAt: now.Add(-time.Hour), Currency: "EUR", LowestPrice: price(99)})
+	db.Create(&models.PriceSnapshot{DiscogsID: wanted, FetchedAt: now.Add(-time.Hour), Currency: "USD", LowestPrice: price(7.25)})
+
+	value, err := ValueCollection(db, "USD", 30)
+	if err != nil {
+		t.Fatalf("ValueCollection: %v", err)
+	}
+
+	if value.TotalValue != 40 || value.MedianValue != 20 || value.PricedAlbums != 2 || value.UnpricedAlbums != 1 {
+		t.Errorf("unexpected totals: %+v", value)
+	}
+	if len(value.History) != 30 {
+		t.Fatalf("history has %d points, want 30", len(value.History))
+	}
+	if first := value.History[0].Value; first != 10 {
+		t.Errorf("value 29 days ago = %v, want 10", first)
+	}
+	if last := value.History[29].Value; last != 40 {
+		t.Errorf("value today = %v, want 40", last)
+	}
+	if len(value.Movers) != 1 || value.Movers[0].Title != "Riser" || value.Movers[0].Change != 5 || math.Abs(value.Movers[0].ChangePct-50) > 0.01 {
+		t.Errorf("unexpected movers: %+v", value.Movers)
+	}
+	if value.Wantlist.Items != 1 || value.Wantlist.PricedItems != 1 || value.Wantlist.LowestTotal != 7.25 {
+		t.Errorf("unexpected wantlist value: %+v", value.Wantlist)
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"strings"
+
+	"vinylfo/duration"
+	"vinylfo/models"
+)
+
+// minAlignedTitleSimilarity is how alike a track's title and the release track at its position
+// must be; positions carry the match, so titles only need to roughly agree
+const minAlignedTitleSimilarity = 0.5
+
+// AlbumResolution is the outcome of resolving an album's tracks
+type AlbumResolution struct {
+	Release     *duration.MBRelease // nil when no MusicBrainz release matched
+	Aligned     int                 // Album tracks aligned to the release
+	Resolutions []models.DurationResolution
+	Errors      int // Tracks that could not be resolved at all
+}
+
+type sourceResultKey struct{ source string }
+
+// withSourceResult makes the resolver use result for a source instead of querying it
+func withSourceResult(ctx context.Context, source string, result *duration.TrackSearchResult) context.Context {
+	return context.WithValue(ctx, sourceResultKey{source}, result)
+}
+
+func sourceResult(ctx context.Context, source string) (*duration.TrackSearchResult, bool) {
+	result, ok := ctx.Value(sourceResultKey{source}).(*duration.TrackSearchResult)
+	return result, ok
+}
+
+// ResolveAlbum resolves the album's tracks that need a duration
+// The album is matched to a MusicBrainz release first, so one tracklist lookup stands in for a
+// recording search per track; tracks the release does not cover are searched one by one as usual
+func (s *DurationResolverService) ResolveAlbum(ctx context.Context, albumID uint) (*AlbumResolution, error) {
+	var album models.Album
+	if err := s.db.First(&album, albumID).Error; err != nil {
+		return nil, err
+	}
+	pending, err := s.GetTracksNeedingResolutionForAlbum(albumID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &AlbumResolution{}
+	if len(pending) == 0 {
+		return out, nil
+	}
+
+	aligned := make(map[uint]duration.MBTrack)
+	release, err := s.matchRelease(ctx, &album)
+	if err != nil {
+		log.Printf("ResolveAlbum: MusicBrainz release lookup failed for album %d: %v", album.ID, err)
+	} else if release != nil {
+		out.Release = release
+		aligned = s.storeRelease(release, &album)
+		out.Aligned = len(aligned)
+	}
+
+	for _, track := range pending {
+		trackCtx := ctx
+		if mb, ok := aligned[track.ID]; ok {
+			track.MusicBrainzRecordingID = mb.RecordingID
+			if mb.Length > 0 {
+				trackCtx = withSourceResult(ctx, "musicbrainz", releaseTrackResult(release, mb, track))
+			}
+		}
+
+		resolution, err := s.ResolveTrackDuration(trackCtx, track)
+		if err != nil {
+			log.Printf("ResolveAlbum: failed to resolve track %d: %v", track.ID, err)
+			out.Errors++
+			continue
+		}
+		out.Resolutions = append(out.Resolutions, *resolution)
+	}
+	return out, nil
+}
+
+// matchRelease returns the album's MusicBrainz release, looking it up by the stored MBID or
+// searching for it and storing what was found
+func (s *DurationResolverService) matchRelease(ctx context.Context, album *models.Album) (*duration.MBRelease, error) {
+	if s.musicBrainz == nil || !s.musicBrainz.IsConfigured() {
+		return nil, nil
+	}
+	if album.MusicBrainzReleaseID != "" {
+		return s.musicBrainz.GetRelease(ctx, album.MusicBrainzReleaseID)
+	}
+
+	var trackCount int64
+	s.db.Model(&models.Track{}).Where("album_id = ?", album.ID).Count(&trackCount)
+	release, err := s.musicBrainz.FindRelease(ctx, duration.ReleaseQuery{
+		Artist:        album.Artist,
+		Title:         album.Title,
+		Barcode:       album.Barcode,
+		CatalogNumber: album.CatalogNumber,
+		TrackCount:    int(trackCount),
+	})
+	if err != nil || release == nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(album).Updates(map[string]interface{}{
+		"musicbrainz_release_id":       release.ID,
+		"musicbrainz_release_group_id": release.ReleaseGroupID,
+	}).Error; err != nil {
+		log.Printf("ResolveAlbum: failed to store MusicBrainz IDs for album %d: %v", album.ID, err)
+	}
+	return release, nil
+}
+
+// storeRelease aligns the release to all of the album's tracks and stores their recording MBIDs
+func (s *DurationResolverService) storeRelease(release *duration.MBRelease, album *models.Album) map[uint]duration.MBTrack {
+	var tracks []models.Track
+	if err := s.db.Where("album_id = ?", album.ID).Find(&tracks).Error; err != nil {
+		log.Printf("ResolveAlbum: failed to load tracks of album %d: %v", album.ID, err)
+		return nil
+	}
+
+	aligned := alignRelease(release, tracks)
+	for id, mb := range aligned {
+		if mb.RecordingID == "" {
+			continue
+		}
+		if err := s.db.Model(&models.Track{}).Where("id = ?", id).Update("musicbrainz_recording_id", mb.RecordingID).Error; err != nil {
+			log.Printf("ResolveAlbum: failed to store recording MBID for track %d: %v", id, err)
+		}
+	}
+	log.Printf("ResolveAlbum: aligned %d of %d tracks to MusicBrainz release %s", len(aligned), len(tracks), release.ID)
+	return aligned
+}
+
+// alignRelease pairs tracks with release tracks, by printed position (A1, B2...) where both
+// have one and otherwise by running order when the track counts agree
+// Pairs whose titles do not roughly agree are dropped
+func alignRelease(release *duration.MBRelease, tracks []models.Track) map[uint]duration.MBTrack {
+	mbTracks := release.Tracks()
+	aligned := make(map[uint]duration.MBTrack)
+	used := make(map[int]bool)
+
+	byNumber := make(map[string]int)
+	for i, mb := range mbTracks {
+		number := strings.ToUpper(strings.TrimSpace(mb.Number))
+		if _, dup := byNumber[number]; dup {
+			byNumber[number] = -1
+		} else if number != "" {
+			byNumber[number] = i
+		}
+	}
+	for _, track := range tracks {
+		for _, position := range []string{track.Position, track.Side} {
+			i, ok := byNumber[strings.ToUpper(strings.TrimSpace(position))]
+			if !ok || i < 0 || used[i] || duration.TitleSimilarity(track.Title, mbTracks[i].Title) < minAlignedTitleSimilarity {
+				continue
+			}
+			aligned[track.ID] = mbTracks[i]
+			used[i] = true
+			break
+		}
+	}
+
+	if len(aligned) == len(tracks) || len(mbTracks) != len(tracks) {
+		return aligned
+	}
+	ordered := append([]models.Track(nil), tracks...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].DiscNumber != ordered[j].DiscNumber {
+			return ordered[i].DiscNumber < ordered[j].DiscNumber
+		}
+		if ordered[i].TrackNumber != ordered[j].TrackNumber {
+			return ordered[i].TrackNumber < ordered[j].TrackNumber
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+	for i, track := range ordered {
+		if _, done := aligned[track.ID]; done || used[i] {
+			continue
+		}
+		if duration.TitleSimilarity(track.Title, mbTracks[i].Title) >= minAlignedTitleSimilarity {
+			aligned[track.ID] = mbTracks[i]
+			used[i] = true
+		}
+	}
+	return aligned
+}
+
+// releaseTrackResult reports an aligned release track as a MusicBrainz search result
+func releaseTrackResult(release *duration.MBRelease, mb duration.MBTrack, track models.Track) *duration.TrackSearchResult {
+	raw, _ := json.Marshal(mb)
+	return &duration.TrackSearchResult{
+		ExternalID:  mb.RecordingID,
+		ExternalURL: "https://musicbrainz.org/recording/" + mb.RecordingID,
+		Title:       mb.Title,
+		Artist:      release.Artist,
+		Album:       release.Title,
+		Duration:    mb.Length,
+		MatchScore:  duration.TitleSimilarity(track.Title, mb.Title),
+		Confidence:  release.Score,
+		RawResponse: string(raw),
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"testing"
+
+	"vinylfo/duration"
+	"vinylfo/models"
+)
+
+func TestAlignRelease(t *testing.T) {
+	release := &duration.MBRelease{ID: "rel", Media: []duration.MBMedium{
+		{Position: 1, Tracks: []duration.MBTrack{
+			{RecordingID: "r1", Number: "A1", Title: "Intro", Length: 60},
+			{RecordingID: "r2", Number: "A2", Title: "So What", Length: 562},
+		}},
+		{Position: 2, Tracks: []duration.MBTrack{
+			{RecordingID: "r3", Number: "B1", Title: "Intro", Length: 45},
+			{RecordingID: "r4", Number: "B2", Title: "Flamenco Sketches (Alternate Take)", Length: 570},
+		}},
+	}}
+
+	t.Run("by position", func(t *testing.T) {
+		tracks := []models.Track{
+			{ID: 1, Title: "Intro", Side: "B1"},
+			{ID: 2, Title: "Intro", Side: "A1"},
+			{ID: 3, Title: "Blue in Green", Side: "A2"}, // Titles disagree
+		}
+		aligned := alignRelease(release, tracks)
+		if aligned[1].RecordingID != "r3" || aligned[2].RecordingID != "r1" {
+			t.Errorf("aligned = %+v, want the intros by side", aligned)
+		}
+		if _, ok := aligned[3]; ok {
+			t.Errorf("track with a different title aligned to %+v", aligned[3])
+		}
+	})
+
+	t.Run("by running order", func(t *testing.T) {
+		tracks := []models.Track{
+			{ID: 4, Title: "Flamenco Sketches", DiscNumber: 2, TrackNumber: 2, Position: "4"},
+			{ID: 1, Title: "Intro", DiscNumber: 1, TrackNumber: 1, Position: "1"},
+			{ID: 3, Title: "Intro", DiscNumber: 2, TrackNumber: 1, Position: "3"},
+			{ID: 2, Title: "So What", DiscNumber: 1, TrackNumber: 2, Position: "2"},
+		}
+		aligned := alignRelease(release, tracks)
+		want := map[uint]string{1: "r1", 2: "r2", 3: "r3", 4: "r4"}
+		for id, recording := range want {
+			if aligned[id].RecordingID != recording {
+				t.Errorf("track %d aligned to %q, want %q", id, aligned[id].RecordingID, recording)
+			}
+		}
+	})
+
+	t.Run("counts differ", func(t *testing.T) {
+		if aligned := alignRelease(release, []models.Track{{ID: 1, Title: "Intro", TrackNumber: 1}}); len(aligned) != 0 {
+			t.Errorf("aligned = %+v, want nothing without positions or matching counts", aligned)
+		}
+	})
+}
+
+func TestStoreRelease_SavesRecordingIDs(t *testing.T) {
+	db := newTestDB(t)
+	album := models.Album{Title: "Kind of Blue", Artist: "Miles Davis"}
+	db.Create(&album)
+	track := models.Track{AlbumID: album.ID, Title: "So What", Position: "A1"}
+	db.Create(&track)
+
+	release := &duration.MBRelease{ID: "rel", Media: []duration.MBMedium{
+		{Position: 1, Tracks: []duration.MBTrack{{RecordingID: "r1", Number: "A1", Title: "So What", Length: 562}}},
+	}}
+	s := &DurationResolverService{db: db}
+	if aligned := s.storeRelease(release, &album); len(aligned) != 1 {
+		t.Fatalf("aligned %d tracks, want 1", len(aligned))
+	}
+
+	var reloaded models.Track
+	db.First(&reloaded, track.ID)
+	if reloaded.MusicBrainzRecordingID != "r1" {
+		t.Errorf("MusicBrainzRecordingID = %q, want r1", reloaded.MusicBrainzRecordingID)
+	}
+}
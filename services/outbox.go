@@ -0,0 +1,497 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"vinylfo/discogs"
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// maxPushAttempts is how often a change is retried before it is marked failed
+const maxPushAttempts = 5
+
+// outboxFields are the album columns kept in Discogs collection note fields
+var outboxFields = []string{
+	"media_condition", "sleeve_condition", "notes", "storage_location",
+	"purchase_price", "purchase_date", "barcode", "matrix_runout",
+}
+
+// CollectionWriter is the part of the Discogs client used to push collection changes
+type CollectionWriter interface {
+	GetCollectionFields(username string) (map[int]string, error)
+	GetReleaseInstances(username string, releaseID int) ([]discogs.CollectionInstance, error)
+	AddToCollection(username string, folderID, releaseID int) (int, error)
+	RemoveFromCollection(username string, folderID, releaseID, instanceID int) error
+	MoveToFolder(username string, folderID, releaseID, instanceID, newFolderID int) error
+	SetRating(username string, folderID, releaseID, instanceID, rating int) error
+	SetCollectionField(username string, folderID, releaseID, instanceID, fieldID int, value string) error
+}
+
+// formatPrice renders a purchase price the way collection changes store it, e.g. "24.99 USD"
+func formatPrice(price *float64, currency string) string {
+	if price == nil {
+		return ""
+	}
+	return strings.TrimSpace(strconv.FormatFloat(*price, 'f', 2, 64) + " " + currency)
+}
+
+// albumFieldValue returns an album column as a collection change value
+func albumFieldValue(album *models.Album, column string) string {
+	switch column {
+	case "media_condition":
+		return album.MediaCondition
+	case "sleeve_condition":
+		return album.SleeveCondition
+	case "notes":
+		return album.Notes
+	case "storage_location":
+		return album.StorageLocation
+	case "purchase_price":
+		return formatPrice(album.PurchasePrice, album.PurchaseCurrency)
+	case "purchase_date":
+		return album.PurchaseDate
+	case "barcode":
+		return album.Barcode
+	case "matrix_runout":
+		return album.MatrixRunout
+	}
+	return ""
+}
+
+// localFieldValue converts a Discogs collection field value to the album's representation
+func localFieldValue(column, raw string) string {
+	raw = strings.TrimSpace(raw)
+	switch column {
+	case "media_condition", "sleeve_condition":
+		if grade := models.NormalizeGrade(raw); grade != "" {
+			return grade
+		}
+	case "purchase_price":
+		price, currency := ParsePrice(raw)
+		return formatPrice(price, currency)
+	case "purchase_date":
+		if date := NormalizeDate(raw); date != "" {
+			return date
+		}
+	}
+	return raw
+}
+
+// remoteFieldValue converts an album value to what is written to the Discogs field
+func remoteFieldValue(column, value string) string {
+	switch column {
+	case "media_condition", "sleeve_condition":
+		return models.DiscogsConditionLabel(value)
+	}
+	return value
+}
+
+// fieldIDForColumn finds the collection field that holds an album column, or 0 if there is none
+func fieldIDForColumn(column string, fieldNames map[int]string) int {
+	if len(fieldNames) == 0 {
+		for _, id := range []int{discogsFieldMediaCondition, discogsFieldSleeveCondition, discogsFieldNotes} {
+			if classifyCollectionField(id, nil) == column {
+				return id
+			}
+		}
+		return 0
+	}
+
+	ids := make([]int, 0, len(fieldNames))
+	for id := range fieldNames {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		if classifyCollectionField(id, fieldNames) == column {
+			return id
+		}
+	}
+	return 0
+}
+
+// queueChange records a pending change, folding it into an earlier unpushed change of the same field
+func queueChange(db *gorm.DB, album *models.Album, action, field, oldValue, newValue string) error {
+	var pending models.CollectionChange
+	result := db.Where("album_id = ? AND action = ? AND field = ? AND status = ?",
+		album.ID, action, field, models.ChangeStatusPending).Limit(1).Find(&pending)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		// Editing a field back to the value Discogs has cancels the change
+		if pending.OldValue == newValue {
+			return db.Delete(&pending).Error
+		}
+		return db.Model(&pending).Update("new_value", newValue).Error
+	}
+	if oldValue == newValue {
+		return nil
+	}
+
+	return db.Create(&models.CollectionChange{
+		AlbumID:    album.ID,
+		DiscogsID:  *album.DiscogsID,
+		InstanceID: album.DiscogsInstanceID,
+		Title:      album.Title,
+		Artist:     album.Artist,
+		Action:     action,
+		Field:      field,
+		OldValue:   oldValue,
+		NewValue:   newValue,
+		Status:     models.ChangeStatusPending,
+	}).Error
+}
+
+// QueueAlbumChanges queues the collection fields that differ between two versions of an album
+// Albums without a Discogs release are not in the Discogs collection and are ignored
+func QueueAlbumChanges(db *gorm.DB, before, after *models.Album) error {
+	if after.DiscogsID == nil {
+		return nil
+	}
+
+	if before.Rating != after.Rating {
+		if err := queueChange(db, after, models.ChangeRating, "", strconv.Itoa(before.Rating), strconv.Itoa(after.Rating)); err != nil {
+			return err
+		}
+	}
+	if before.DiscogsFolderID != after.DiscogsFolderID && after.DiscogsFolderID > 0 {
+		if err := queueChange(db, after, models.ChangeMove, "", strconv.Itoa(before.DiscogsFolderID), strconv.Itoa(after.DiscogsFolderID)); err != nil {
+			return err
+		}
+	}
+	for _, column := range outboxFields {
+		oldValue, newValue := albumFieldValue(before, column), albumFieldValue(after, column)
+		if oldValue == newValue {
+			continue
+		}
+		if err := queueChange(db, after, models.ChangeField, column, oldValue, newValue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueueCollectionAdd queues adding an album's release to the Discogs collection
+func QueueCollectionAdd(db *gorm.DB, album *models.Album, folderID int) error {
+	if album.DiscogsID == nil {
+		return nil
+	}
+	if folderID <= 0 {
+		folderID = discogs.DefaultCollectionFolder
+	}
+	return queueChange(db, album, models.ChangeAdd, "", "", strconv.Itoa(folderID))
+}
+
+// QueueCollectionRemoval queues removing a deleted album's copy from the Discogs collection
+// Only albums that came from the collection are removed; pending edits of the album are dropped
+func QueueCollectionRemoval(db *gorm.DB, album *models.Album) error {
+	if album.DiscogsID == nil {
+		return nil
+	}
+
+	var pendingAdds int64
+	db.Model(&models.CollectionChange{}).
+		Where("album_id = ? AND action = ? AND status = ?", album.ID, models.ChangeAdd, models.ChangeStatusPending).
+		Count(&pendingAdds)
+
+	err := db.Model(&models.CollectionChange{}).
+		Where("album_id = ? AND status IN ?", album.ID, []string{models.ChangeStatusPending, models.ChangeStatusConflict, models.ChangeStatusFailed}).
+		Update("status", models.ChangeStatusDiscarded).Error
+	if err != nil {
+		return err
+	}
+
+	// Never pushed, so there is nothing to remove on Discogs
+	if pendingAdds > 0 || (album.DiscogsInstanceID == 0 && album.DiscogsFolderID == 0) {
+		return nil
+	}
+	return queueChange(db, album, models.ChangeRemove, "", strconv.Itoa(album.DiscogsFolderID), "")
+}
+
+// HasUnsettledChange reports whether an album has a change of the given action that is
+// still waiting to be pushed or resolved
+func HasUnsettledChange(db *gorm.DB, albumID uint, action string) bool {
+	var count int64
+	db.Model(&models.CollectionChange{}).
+		Where("album_id = ? AND action = ? AND status IN ?", albumID, action, []string{models.ChangeStatusPending, models.ChangeStatusConflict}).
+		Count(&count)
+	return count > 0
+}
+
+// outboxPusher sends queued changes for one user
+type outboxPusher struct {
+	db           *gorm.DB
+	client       CollectionWriter
+	username     string
+	fields       map[int]string
+	fieldsLoaded bool
+}
+
+// OutboxPushResult counts what happened to the changes in one push
+type OutboxPushResult struct {
+	Pushed    int                  `json:"pushed"`
+	Conflicts int                  `json:"conflicts"`
+	Failed    int                  `json:"failed"`
+	Remaining int                  `json:"remaining"`
+	Review    *discogs.BatchReview `json:"review"`
+}
+
+// PushCollectionChanges pushes pending collection changes to Discogs, oldest first
+// A change whose field was also edited on Discogs is held as a conflict instead of overwriting it.
+// Pushing stops early when Discogs rate limits; the rest stay pending for the next sync
+func PushCollectionChanges(db *gorm.DB, client CollectionWriter, username string) (*OutboxPushResult, error) {
+	var changes []models.CollectionChange
+	if err := db.Where("status = ?", models.ChangeStatusPending).Order("id ASC").Find(&changes).Error; err != nil {
+		return nil, err
+	}
+
+	p := &outboxPusher{db: db, client: client, username: username}
+	result := &OutboxPushResult{}
+	for i := range changes {
+		change := &changes[i]
+		err := p.push(change)
+		if errors.Is(err, discogs.ErrRateLimited) {
+			log.Printf("Outbox: rate limited, %d changes left for the next sync", len(changes)-i)
+			break
+		}
+
+		switch {
+		case err != nil:
+			change.Attempts++
+			change.Error = err.Error()
+			if change.Attempts >= maxPushAttempts {
+				change.Status = models.ChangeStatusFailed
+				result.Failed++
+			}
+		case change.Status == models.ChangeStatusConflict:
+			result.Conflicts++
+		default:
+			now := time.Now()
+			change.Status = models.ChangeStatusPushed
+			change.PushedAt = &now
+			change.Error = ""
+			result.Pushed++
+		}
+		if err := db.Save(change).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var remaining int64
+	db.Model(&models.CollectionChange{}).Where("status = ?", models.ChangeStatusPending).Count(&remaining)
+	result.Remaining = int(remaining)
+
+	review, err := OutboxReview(db)
+	if err != nil {
+		return nil, err
+	}
+	result.Review = review
+	return result, nil
+}
+
+// OutboxReview summarises the changes that are waiting to be pushed or need attention
+func OutboxReview(db *gorm.DB) (*discogs.BatchReview, error) {
+	var changes []models.CollectionChange
+	err := db.Where("status IN ?", []string{models.ChangeStatusPending, models.ChangeStatusConflict, models.ChangeStatusFailed}).
+		Order("id ASC").Find(&changes).Error
+	if err != nil {
+		return nil, err
+	}
+	return discogs.NewDataReviewService(false).ReviewCollectionChanges(changes), nil
+}
+
+func (p *outboxPusher) fieldNames() map[int]string {
+	if !p.fieldsLoaded {
+		p.fieldsLoaded = true
+		fields, err := p.client.GetCollectionFields(p.username)
+		if err != nil {
+			log.Printf("Outbox: failed to fetch collection fields, using defaults: %v", err)
+		}
+		p.fields = fields
+	}
+	return p.fields
+}
+
+// instance finds the copy a change applies to: the recorded instance, or the first copy
+func (p *outboxPusher) instance(change *models.CollectionChange) (*discogs.CollectionInstance, error) {
+	instances, err := p.client.GetReleaseInstances(p.username, change.DiscogsID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range instances {
+		if change.InstanceID == 0 || instances[i].InstanceID == change.InstanceID {
+			return &instances[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// push applies one change on Discogs, setting the change's status to conflict when needed
+func (p *outboxPusher) push(change *models.CollectionChange) error {
+	if change.Action == models.ChangeAdd {
+		return p.pushAdd(change)
+	}
+
+	if change.InstanceID == 0 {
+		var album models.Album
+		if err := p.db.Select("discogs_instance_id").First(&album, change.AlbumID).Error; err == nil {
+			change.InstanceID = album.DiscogsInstanceID
+		}
+	}
+	instance, err := p.instance(change)
+	if err != nil {
+		return err
+	}
+
+	if change.Action == models.ChangeRemove {
+		if instance == nil {
+			return nil // Already gone
+		}
+		return p.client.RemoveFromCollection(p.username, instance.FolderID, change.DiscogsID, instance.InstanceID)
+	}
+
+	if instance == nil {
+		change.Status = models.ChangeStatusConflict
+		change.RemoteValue = ""
+		change.Error = "Release is no longer in the Discogs collection"
+		return nil
+	}
+	change.InstanceID = instance.InstanceID
+
+	var remote string
+	fieldID := 0
+	switch change.Action {
+	case models.ChangeRating:
+		remote = strconv.Itoa(instance.Rating)
+	case models.ChangeMove:
+		remote = strconv.Itoa(instance.FolderID)
+	case models.ChangeField:
+		fieldID = fieldIDForColumn(change.Field, p.fieldNames())
+		if fieldID == 0 {
+			change.Attempts = maxPushAttempts - 1
+			return fmt.Errorf("no Discogs collection field for %s", change.Field)
+		}
+		remote = localFieldValue(change.Field, instance.Notes[fieldID])
+	default:
+		change.Attempts = maxPushAttempts - 1
+		return fmt.Errorf("unknown collection change action %q", change.Action)
+	}
+
+	if remote == change.NewValue {
+		return nil // Discogs already has it
+	}
+	if remote != change.OldValue {
+		change.Status = models.ChangeStatusConflict
+		change.RemoteValue = remote
+		change.Error = ""
+		return nil
+	}
+
+	switch change.Action {
+	case models.ChangeRating:
+		rating, err := strconv.Atoi(change.NewValue)
+		if err != nil {
+			return err
+		}
+		return p.client.SetRating(p.username, instance.FolderID, change.DiscogsID, instance.InstanceID, rating)
+	case models.ChangeMove:
+		folderID, err := strconv.Atoi(change.NewValue)
+		if err != nil {
+			return err
+		}
+		return p.client.MoveToFolder(p.username, instance.FolderID, change.DiscogsID, instance.InstanceID, folderID)
+	}
+	return p.client.SetCollectionField(p.username, instance.FolderID, change.DiscogsID, instance.InstanceID,
+		fieldID, remoteFieldValue(change.Field, change.NewValue))
+}
+
+// pushAdd adds the release to the collection, or adopts a copy that is already there
+func (p *outboxPusher) pushAdd(change *models.CollectionChange) error {
+	folderID, _ := strconv.Atoi(change.NewValue)
+
+	instances, err := p.client.GetReleaseInstances(p.username, change.DiscogsID)
+	if err != nil {
+		return err
+	}
+	if len(instances) > 0 {
+		change.InstanceID = instances[0].InstanceID
+		folderID = instances[0].FolderID
+	} else {
+		instanceID, err := p.client.AddToCollection(p.username, folderID, change.DiscogsID)
+		if err != nil {
+			return err
+		}
+		change.InstanceID = instanceID
+	}
+
+	return p.db.Model(&models.Album{}).Where("id = ?", change.AlbumID).Updates(map[string]interface{}{
+		"discogs_instance_id": change.InstanceID,
+		"discogs_folder_id":   folderID,
+	}).Error
+}
+
+// ResolveCollectionChange settles a conflict. Keeping "local" pushes the local value over
+// the Discogs one on the next sync; keeping "remote" copies the Discogs value onto the album
+func ResolveCollectionChange(db *gorm.DB, id uint, keep string) (*models.CollectionChange, error) {
+	var change models.CollectionChange
+	if err := db.First(&change, id).Error; err != nil {
+		return nil, err
+	}
+	if change.Status != models.ChangeStatusConflict {
+		return nil, fmt.Errorf("change %d is not a conflict", id)
+	}
+
+	switch keep {
+	case "local":
+		change.Status = models.ChangeStatusPending
+		change.OldValue = change.RemoteValue
+		change.Attempts = 0
+	case "remote":
+		if err := applyRemoteValue(db, &change); err != nil {
+			return nil, err
+		}
+		change.Status = models.ChangeStatusDiscarded
+	default:
+		return nil, fmt.Errorf("keep must be \"local\" or \"remote\"")
+	}
+	change.RemoteValue = ""
+	change.Error = ""
+
+	if err := db.Save(&change).Error; err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// applyRemoteValue writes the Discogs side of a conflict onto the album
+func applyRemoteValue(db *gorm.DB, change *models.CollectionChange) error {
+	updates := make(map[string]interface{})
+	switch change.Action {
+	case models.ChangeRating:
+		rating, _ := strconv.Atoi(change.RemoteValue)
+		updates["rating"] = rating
+	case models.ChangeMove:
+		folderID, _ := strconv.Atoi(change.RemoteValue)
+		updates["discogs_folder_id"] = folderID
+	case models.ChangeField:
+		if change.Field == "purchase_price" {
+			price, currency := ParsePrice(change.RemoteValue)
+			updates["purchase_price"] = price
+			updates["purchase_currency"] = currency
+		} else {
+			updates[change.Field] = change.RemoteValue
+		}
+	default:
+		return nil
+	}
+	return db.Model(&models.Album{}).Where("id = ?", change.AlbumID).Updates(updates).Error
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"testing"
+
+	"vinylfo/discogs"
+	"vinylfo/models"
+	"vinylfo/utils"
+)
+
+type fakeCollection struct {
+	instances map[int][]discogs.CollectionInstance
+	writes    []string
+}
+
+func (f *fakeCollection) GetCollectionFields(username string) (map[int]string, error) {
+	return map[int]string{1: "Media Condition", 2: "Sleeve Condition", 3: "Notes", 4: "Shelf"}, nil
+}
+
+func (f *fakeCollection) GetReleaseInstances(username string, releaseID int) ([]discogs.CollectionInstance, error) {
+	return f.instances[releaseID], nil
+}
+
+func (f *fakeCollection) AddToCollection(username string, folderID, releaseID int) (int, error) {
+	f.writes = append(f.writes, "add")
+	f.instances[releaseID] = []discogs.CollectionInstance{{InstanceID: 900, FolderID: folderID, Notes: map[int]string{}}}
+	return 900, nil
+}
+
+func (f *fakeCollection) RemoveFromCollection(username string, folderID, releaseID, instanceID int) error {
+	f.writes = append(f.writes, "remove")
+	delete(f.instances, releaseID)
+	return nil
+}
+
+func (f *fakeCollection) MoveToFolder(username string, folderID, releaseID, instanceID, newFolderID int) error {
+	f.writes = append(f.writes, "move")
+	return nil
+}
+
+func (f *fakeCollection) SetRating(username string, folderID, releaseID, instanceID, rating int) error {
+	f.writes = append(f.writes, "rating")
+	return nil
+}
+
+func (f *fakeCollection) SetCollectionField(username string, folderID, releaseID, instanceID, fieldID int, value string) error {
+	f.writes = append(f.writes, "field:"+value)
+	return nil
+}
+
+func TestQueueAlbumChanges(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&models.CollectionChange{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	before := models.Album{Title: "Blue Train", Artist: "John Coltrane", DiscogsID: utils.IntPtr(1873013), DiscogsInstanceID: 148842, MediaCondition: "VG"}
+	db.Create(&before)
+
+	after := before
+	after.MediaCondition = "VG+"
+	after.Rating = 4
+	if err := QueueAlbumChanges(db, &before, &after); err != nil {
+		t.Fatalf("QueueAlbumChanges: %v", err)
+	}
+
+	var changes []models.CollectionChange
+	db.Order("id").Find(&changes)
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want 2: %+v", len(changes), changes)
+	}
+	if changes[0].Action != models.ChangeRating || changes[0].NewValue != "4" {
+		t.Errorf("unexpected rating change: %+v", changes[0])
+	}
+	if changes[1].Field != "media_condition" || changes[1].OldValue != "VG" || changes[1].NewValue != "VG+" {
+		t.Errorf("unexpected field change: %+v", changes[1])
+	}
+
+	// A second edit folds into the pending change; editing back cancels it
+	again := after
+	again.MediaCondition = "NM"
+	QueueAlbumChanges(db, &after, &again)
+	reverted := again
+	reverted.Rating = 0
+	QueueAlbumChanges(db, &again, &reverted)
+
+	changes = nil
+	db.Order("id").Find(&changes)
+	if len(changes) != 1 || changes[0].NewValue != "NM" {
+		t.Errorf("expected only the media condition change to NM, got %+v", changes)
+	}
+
+	// Albums that are not on Discogs are never queued
+	local := models.Album{Title: "Demo Tape"}
+	edited := local
+	edited.Rating = 5
+	QueueAlbumChanges(db, &local, &edited)
+	var count int64
+	db.Model(&models.CollectionChange{}).Count(&count)
+	if count != 1 {
+		t.Errorf("expected 1 change, got %d", count)
+	}
+}
+
+func TestPushCollectionChanges(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&models.CollectionChange{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	kept := models.Album{Title: "Blue Train", Artist: "John Coltrane", DiscogsID: utils.IntPtr(1873013), DiscogsInstanceID: 148842, DiscogsFolderID: 1, MediaCondition: "VG"}
+	added := models.Album{Title: "Kind Of Blue", Artist: "Miles Davis", DiscogsID: utils.IntPtr(5467)}
+	removed := models.Album{Title: "Giant Steps", Artist: "John Coltrane", DiscogsID: utils.IntPtr(2221), DiscogsInstanceID: 777, DiscogsFolderID: 1}
+	db.Create(&kept)
+	db.Create(&added)
+	db.Create(&removed)
+
+	client := &fakeCollection{instances: map[int][]discogs.CollectionInstance{
+		1873013: {{InstanceID: 148842, FolderID: 1, Rating: 2, Notes: map[int]string{1: "Very Good (VG)", 4: "Box 9"}}},
+		2221:    {{InstanceID: 777, FolderID: 1}},
+	}}
+
+	edited := kept
+	edited.MediaCondition = "VG+"
+	edited.StorageLocation = "Shelf A1"
+	edited.Rating = 5
+	QueueAlbumChanges(db, &kept, &edited)
+	QueueCollectionAdd(db, &added, 0)
+	QueueCollectionRemoval(db, &removed)
+
+	result, err := PushCollectionChanges(db, client, "collector")
+	if err != nil {
+		t.Fatalf("PushCollectionChanges: %v", err)
+	}
+
+	// The rating and shelf were changed on Discogs too, so both are held as conflicts
+	if result.Pushed != 3 || result.Conflicts != 2 || result.Remaining != 0 {
+		t.Errorf("unexpected result: %+v, writes %v", result, client.writes)
+	}
+	want := []string{"field:Very Good Plus (VG+)", "add", "remove"}
+	if len(client.writes) != len(want) {
+		t.Fatalf("writes = %v, want %v", client.writes, want)
+	}
+	for i := range want {
+		if client.writes[i] != want[i] {
+			t.Errorf("writes[%d] = %q, want %q", i, client.writes[i], want[i])
+		}
+	}
+
+	var saved models.Album
+	db.First(&saved, added.ID)
+	if saved.DiscogsInstanceID != 900 || saved.DiscogsFolderID != discogs.DefaultCollectionFolder {
+		t.Errorf("added album not linked to its instance: %+v", saved)
+	}
+
+	if result.Review == nil || result.Review.ConflictCount != 1 || len(result.Review.Reviews[0].Changes) != 2 {
+		t.Fatalf("expected one album with 2 conflicts in review, got %+v", result.Review)
+	}
+
+	var conflict models.CollectionChange
+	db.Where("field = ?", "storage_location").First(&conflict)
+	if conflict.Status != models.ChangeStatusConflict || conflict.RemoteValue != "Box 9" {
+		t.Fatalf("unexpected conflict: %+v", conflict)
+	}
+	if _, err := ResolveCollectionChange(db, conflict.ID, "remote"); err != nil {
+		t.Fatalf("ResolveCollectionChange: %v", err)
+	}
+	var resolved models.Album
+	db.First(&resolved, kept.ID)
+	if resolved.StorageLocation != "Box 9" {
+		t.Errorf("storage location = %q, want the Discogs value", resolved.StorageLocation)
+	}
+
+	var rating models.CollectionChange
+	db.Where("action = ?", models.ChangeRating).First(&rating)
+	if _, err := ResolveCollectionChange(db, rating.ID, "local"); err != nil {
+		t.Fatalf("ResolveCollectionChange: %v", err)
+	}
+	client.writes = nil
+	if _, err := PushCollectionChanges(db, client, "collector"); err != nil {
+		t.Fatalf("PushCollectionChanges: %v", err)
+	}
+	if len(client.writes) != 1 || client.writes[0] != "rating" {
+		t.Errorf("expected the kept rating to be pushed, writes %v", client.writes)
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"time"
+
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// playedThroughPercent is how much of a track must have played for leaving it not to count as a
+// skip, so moving on during the run-out of a track is not held against it
+const playedThroughPercent = 90
+
+// playEventSeedBatch is how many play events are created at a time when seeding the log
+const playEventSeedBatch = 500
+
+// Play describes a track that stopped playing
+type Play struct {
+	Track      *models.Track
+	PlaylistID string
+	Seconds    int       // How long it played
+	Duration   int       // How long it lasts; 0 when unknown
+	Left       bool      // Playback moved on before the track ended
+	Source     string    // One of the models.PlaySource constants
+	EndedAt    time.Time // Now when zero
+}
+
+// RecordPlay appends the play event of a track that ended or was left
+// Tracks left after under playedThroughPercent of their duration are marked skipped
+func RecordPlay(db *gorm.DB, play Play) (*models.PlayEvent, error) {
+	endedAt := play.EndedAt
+	if endedAt.IsZero() {
+		endedAt = time.Now()
+	}
+	seconds := max(play.Seconds, 0)
+	if play.Duration > 0 {
+		seconds = min(seconds, play.Duration)
+	}
+
+	percent := 0.0
+	if play.Duration > 0 {
+		percent = float64(seconds) * 100 / float64(play.Duration)
+	} else if !play.Left {
+		percent = 100
+	}
+
+	event := models.PlayEvent{
+		TrackID:       play.Track.ID,
+		AlbumID:       play.Track.AlbumID,
+		PlaylistID:    play.PlaylistID,
+		StartedAt:     endedAt.Add(-time.Duration(seconds) * time.Second),
+		EndedAt:       endedAt,
+		Seconds:       seconds,
+		PercentPlayed: percent,
+		Skipped:       play.Left && percent < playedThroughPercent,
+		Source:        play.Source,
+	}
+	if err := db.Create(&event).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// SeedPlayEvents copies track history into the play log once, marking it done in AppConfig
+// Each played track gets one play at its last play, since earlier times were never kept; its
+// listen count stays in track history, and these plays are left out of the stats by date
+func SeedPlayEvents(db *gorm.DB) (int, error) {
+	var config models.AppConfig
+	if err := db.First(&config).Error; err != nil || config.PlayLogSeededAt != nil {
+		return 0, err
+	}
+
+	type historyRow struct {
+		TrackID    uint
+		AlbumID    uint
+		PlaylistID string
+		LastPlayed time.Time
+		Duration   int
+	}
+	var rows []historyRow
+	err := db.Table("track_histories").
+		Select("track_histories.track_id, tracks.album_id, track_histories.playlist_id, track_histories.last_played, tracks.duration").
+		Joins("JOIN tracks ON tracks.id = track_histories.track_id").
+		Where("track_histories.listen_count > 0").
+		Order("track_histories.id").
+		Scan(&rows).Error
+	if err != nil {
+		return 0, err
+	}
+
+	events := make([]models.PlayEvent, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, models.PlayEvent{
+			TrackID:       row.TrackID,
+			AlbumID:       row.AlbumID,
+			PlaylistID:    row.PlaylistID,
+			StartedAt:     row.LastPlayed.Add(-time.Duration(row.Duration) * time.Second),
+			EndedAt:       row.LastPlayed,
+			Seconds:       row.Duration,
+			PercentPlayed: 100,
+			Source:        models.PlaySourceHistory,
+		})
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if len(events) > 0 {
+			if err := tx.CreateInBatches(events, playEventSeedBatch).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&config).Update("play_log_seeded_at", time.Now()).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"vinylfo/models"
+)
+
+func TestRecordPlay(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&models.PlayEvent{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+	track := &models.Track{ID: 7, AlbumID: 3, Duration: 200}
+	end := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		play        Play
+		wantSeconds int
+		wantPercent float64
+		wantSkipped bool
+	}{
+		{"played out", Play{Seconds: 200, Duration: 200}, 200, 100, false},
+		{"left early", Play{Seconds: 30, Duration: 200, Left: true}, 30, 15, true},
+		{"left in the run-out", Play{Seconds: 190, Duration: 200, Left: true}, 190, 95, false},
+		{"position past the end", Play{Seconds: 260, Duration: 200}, 200, 100, false},
+		{"unknown duration", Play{Seconds: 45}, 45, 100, false},
+		{"left with unknown duration", Play{Seconds: 45, Left: true}, 45, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.play.Track = track
+			tt.play.EndedAt = end
+			event, err := RecordPlay(db, tt.play)
+			if err != nil {
+				t.Fatalf("RecordPlay() error = %v", err)
+			}
+			if event.Seconds != tt.wantSeconds || event.PercentPlayed != tt.wantPercent || event.Skipped != tt.wantSkipped {
+				t.Errorf("event = %ds %.0f%% skipped %v, want %ds %.0f%% skipped %v",
+					event.Seconds, event.PercentPlayed, event.Skipped, tt.wantSeconds, tt.wantPercent, tt.wantSkipped)
+			}
+			if event.AlbumID != 3 || !event.StartedAt.Equal(end.Add(-time.Duration(tt.wantSeconds)*time.Second)) {
+				t.Errorf("event album %d started %v", event.AlbumID, event.StartedAt)
+			}
+		})
+	}
+}
+
+func TestSeedPlayEvents(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&models.PlayEvent{}, &models.TrackHistory{}, &models.AppConfig{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+	db.Create(&models.AppConfig{})
+	album := models.Album{Title: "Blue Train", Artist: "John Coltrane"}
+	db.Create(&album)
+	played := models.Track{AlbumID: album.ID, Title: "Blue Train", Duration: 643}
+	unplayed := models.Track{AlbumID: album.ID, Title: "Locomotion", Duration: 434}
+	db.Create(&played)
+	db.Create(&unplayed)
+	last := time.Date(2026, 2, 14, 21, 0, 0, 0, time.UTC)
+	db.Create(&models.TrackHistory{PlaylistID: "side-a", TrackID: played.ID, ListenCount: 3, LastPlayed: last})
+	db.Create(&models.TrackHistory{PlaylistID: "side-a", TrackID: unplayed.ID, ListenCount: 0, LastPlayed: last})
+
+	seeded, err := SeedPlayEvents(db)
+	if err != nil {
+		t.Fatalf("SeedPlayEvents() error = %v", err)
+	}
+	if seeded != 1 {
+		t.Fatalf("seeded %d events, want one per played track rather than one per listen", seeded)
+	}
+	var event models.PlayEvent
+	db.First(&event)
+	if event.TrackID != played.ID || event.AlbumID != album.ID || event.Seconds != 643 ||
+		event.Source != models.PlaySourceHistory || !event.EndedAt.Equal(last) {
+		t.Errorf("seeded event = %+v", event)
+	}
+
+	// The seed runs once, even when the log is emptied later
+	db.Where("1 = 1").Delete(&models.PlayEvent{})
+	if again, err := SeedPlayEvents(db); err != nil || again != 0 {
+		t.Errorf("second SeedPlayEvents() = %d, %v, want nothing once seeded", again, err)
+	}
+}
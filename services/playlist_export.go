@@ -18,7 +18,11 @@ type MatchPlaylistResult struct {
 	Tracks      []MatchResult `json:"tracks"`
 }
 
-func (s *YouTubeSyncService) MatchPlaylist(ctx context.Context, playlistID string, force bool, useApiFallback bool) (*MatchPlaylistResult, error) {
+// MatchProgress is called after each track is matched with the number done and the track
+// just matched. Returning an error stops matching
+type MatchProgress func(done, total int, last string) error
+
+func (s *YouTubeSyncService) MatchPlaylist(ctx context.Context, playlistID string, force bool, useApiFallback bool, progress MatchProgress) (*MatchPlaylistResult, error) {
 	var playlistTracks []models.SessionPlaylist
 	if err := s.db.Where("session_id = ?", playlistID).Order("`order` ASC").Find(&playlistTracks).Error; err != nil {
 		return nil, fmt.Errorf("failed to get playlist tracks: %w", err)
@@ -29,7 +33,7 @@ func (s *YouTubeSyncService) MatchPlaylist(ctx context.Context, playlistID strin
 		TotalTracks: len(playlistTracks),
 	}
 
-	for _, pt := range playlistTracks {
+	for i, pt := range playlistTracks {
 		select {
 		case <-ctx.Done():
 			return result, ctx.Err()
@@ -43,6 +47,9 @@ func (s *YouTubeSyncService) MatchPlaylist(ctx context.Context, playlistID strin
 				TrackID: pt.TrackID,
 				Error:   err.Error(),
 			})
+			if err := reportMatch(progress, i+1, len(playlistTracks), ""); err != nil {
+				return result, err
+			}
 			continue
 		}
 
@@ -58,6 +65,65 @@ func (s *YouTubeSyncService) MatchPlaylist(ctx context.Context, playlistID strin
 				result.Unavailable++
 			}
 		}
+		if err := reportMatch(progress, i+1, len(playlistTracks), matchResult.Artist+" - "+matchResult.TrackTitle); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+func reportMatch(progress MatchProgress, done, total int, last string) error {
+	if progress == nil {
+		return nil
+	}
+	return progress(done, total, last)
+}
+
+type RematchResult struct {
+	TotalTracks int `json:"total_tracks"`
+	Matched     int `json:"matched"`
+	NeedsReview int `json:"needs_review"`
+	Unavailable int `json:"unavailable"`
+	Errors      int `json:"errors"`
+}
+
+// RematchUnavailable searches again for tracks no video was found for. Tracks marked
+// unavailable by hand are left alone
+func (s *YouTubeSyncService) RematchUnavailable(ctx context.Context, useApiFallback bool, progress MatchProgress) (*RematchResult, error) {
+	var trackIDs []uint
+	if err := s.db.Model(&models.TrackYouTubeMatch{}).
+		Where("status = ? AND match_method <> ?", "unavailable", "manual").
+		Order("track_id ASC").Pluck("track_id", &trackIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get unavailable tracks: %w", err)
+	}
+
+	result := &RematchResult{TotalTracks: len(trackIDs)}
+	for i, trackID := range trackIDs {
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		default:
+		}
+
+		last := ""
+		matchResult, err := s.MatchTrack(ctx, trackID, true, useApiFallback)
+		switch {
+		case err != nil:
+			result.Errors++
+		case matchResult.BestMatch == nil || matchResult.BestMatch.Status == "unavailable":
+			result.Unavailable++
+		case matchResult.NeedsReview:
+			result.NeedsReview++
+		default:
+			result.Matched++
+		}
+		if matchResult != nil {
+			last = matchResult.Artist + " - " + matchResult.TrackTitle
+		}
+		if err := reportMatch(progress, i+1, len(trackIDs), last); err != nil {
+			return result, err
+		}
 	}
 
 	return result, nil
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"vinylfo/discogs"
+	"vinylfo/models"
+	"vinylfo/sync"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// priceRefreshInterval is how often the tracker wakes up to refresh a batch
+	priceRefreshInterval = time.Hour
+	// priceRefreshBatch keeps each pass well inside the Discogs rate limit
+	priceRefreshBatch = 25
+	// PriceMaxAge is how old a release's latest snapshot may get before it is refetched
+	PriceMaxAge = 7 * 24 * time.Hour
+)
+
+// PriceSource is the part of the Discogs client price tracking reads from
+type PriceSource interface {
+	GetPriceStats(releaseID int, currency string) (*discogs.PriceStats, error)
+	GetPriceSuggestions(releaseID int) (map[string]discogs.Price, error)
+	IsAuthenticated() bool
+}
+
+// MarketCurrency returns the configured price tracking currency
+func MarketCurrency(db *gorm.DB) string {
+	var config models.AppConfig
+	if err := db.First(&config).Error; err != nil || config.MarketCurrency == "" {
+		return "USD"
+	}
+	return strings.ToUpper(config.MarketCurrency)
+}
+
+// trackedReleaseIDs returns the Discogs IDs of every owned or wanted release
+func trackedReleaseIDs(db *gorm.DB) ([]int, error) {
+	var owned []int
+	if err := db.Model(&models.Album{}).Where("discogs_id IS NOT NULL").Order("id").Pluck("discogs_id", &owned).Error; err != nil {
+		return nil, err
+	}
+	var wanted []int
+	if err := db.Model(&models.WantlistItem{}).Order("id").Pluck("discogs_id", &wanted).Error; err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool, len(owned)+len(wanted))
+	ids := make([]int, 0, len(owned)+len(wanted))
+	for _, id := range append(owned, wanted...) {
+		if id > 0 && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// StaleReleaseIDs returns up to limit tracked releases whose prices are older than maxAge
+// Releases that have never been priced come first
+func StaleReleaseIDs(db *gorm.DB, currency string, limit int, maxAge time.Duration) ([]int, error) {
+	ids, err := trackedReleaseIDs(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var fresh []int
+	err = db.Model(&models.PriceSnapshot{}).
+		Where("currency = ? AND fetched_at > ?", currency, time.Now().Add(-maxAge)).
+		Distinct().Pluck("discogs_id", &fresh).Error
+	if err != nil {
+		return nil, err
+	}
+	var priced []int
+	if err := db.Model(&models.PriceSnapshot{}).Where("currency = ?", currency).Distinct().Pluck("discogs_id", &priced).Error; err != nil {
+		return nil, err
+	}
+
+	skip := make(map[int]bool, len(fresh))
+	for _, id := range fresh {
+		skip[id] = true
+	}
+	hasPrice := make(map[int]bool, len(priced))
+	for _, id := range priced {
+		hasPrice[id] = true
+	}
+
+	never := make([]int, 0)
+	stale := make([]int, 0)
+	for _, id := range ids {
+		switch {
+		case skip[id]:
+		case hasPrice[id]:
+			stale = append(stale, id)
+		default:
+			never = append(never, id)
+		}
+	}
+
+	result := append(never, stale...)
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// FetchPriceSnapshot fetches and stores the current marketplace price of a release
+// Suggested prices are only kept when Discogs quotes them in the tracked currency
+func FetchPriceSnapshot(db *gorm.DB, source PriceSource, releaseID int, currency string) (*models.PriceSnapshot, error) {
+	stats, err := source.GetPriceStats(releaseID, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := models.PriceSnapshot{
+		DiscogsID:   releaseID,
+		FetchedAt:   time.Now(),
+		Currency:    currency,
+		LowestPrice: stats.LowestPrice,
+		NumForSale:  stats.NumForSale,
+	}
+	if stats.LowestPrice != nil && stats.Currency != "" && !strings.EqualFold(stats.Currency, currency) {
+		snapshot.LowestPrice = nil
+	}
+
+	if source.IsAuthenticated() {
+		suggestions, err := source.GetPriceSuggestions(releaseID)
+		if err != nil {
+			log.Printf("Price suggestions for release %d unavailable: %v", releaseID, err)
+		}
+		byGrade := make(map[string]float64)
+		for grade, price := range suggestions {
+			if strings.EqualFold(price.Currency, currency) {
+				byGrade[grade] = price.Value
+			}
+		}
+		if len(byGrade) > 0 {
+			data, _ := json.Marshal(byGrade)
+			snapshot.SuggestedPrices = string(data)
+		}
+	}
+
+	if err := db.Create(&snapshot).Error; err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// RefreshPrices fetches prices for up to limit stale releases and returns how many were stored
+func RefreshPrices(db *gorm.DB, source PriceSource, limit int, maxAge time.Duration) (int, error) {
+	currency := MarketCurrency(db)
+	ids, err := StaleReleaseIDs(db, currency, limit, maxAge)
+	if err != nil {
+		return 0, err
+	}
+
+	refreshed := 0
+	for _, id := range ids {
+		if _, err := FetchPriceSnapshot(db, source, id, currency); err != nil {
+			log.Printf("Failed to fetch price for release %d: %v", id, err)
+			if errors.Is(err, discogs.ErrRateLimited) {
+				break
+			}
+			continue
+		}
+		refreshed++
+	}
+	return refreshed, nil
+}
+
+// priceRefreshRunning stops the background tracker and manual refreshes from overlapping
+var priceRefreshRunning atomic.Bool
+
+// PriceTracker periodically refreshes marketplace prices in the background
+type PriceTracker struct {
+	db        *gorm.DB
+	newClient func() *discogs.Client
+}
+
+func NewPriceTracker(db *gorm.DB, newClient func() *discogs.Client) *PriceTracker {
+	return &PriceTracker{db: db, newClient: newClient}
+}
+
+// Run refreshes a small batch of stale prices every interval until ctx is cancelled
+func (t *PriceTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(priceRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.refresh()
+		}
+	}
+}
+
+// RefreshNow starts a refresh in the background unless one is already running
+func (t *PriceTracker) RefreshNow() bool {
+	if priceRefreshRunning.Load() {
+		return false
+	}
+	go t.refresh()
+	return true
+}
+
+func (t *PriceTracker) refresh() {
+	if !priceRefreshRunning.CompareAndSwap(false, true) {
+		return
+	}
+	defer priceRefreshRunning.Store(false)
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("PriceTracker panic: %v", r)
+		}
+	}()
+
+	// Collection sync needs the rate limit more than price tracking does
+	state := sync.DefaultManager.GetState()
+	if state.IsActive() {
+		return
+	}
+
+	client := t.newClient()
+	if client == nil || client.RateLimiter.IsRateLimited() {
+		return
+	}
+
+	refreshed, err := RefreshPrices(t.db, client, priceRefreshBatch, PriceMaxAge)
+	if err != nil {
+		log.Printf("PriceTracker: refresh failed: %v", err)
+		return
+	}
+	if refreshed > 0 {
+		log.Printf("PriceTracker: refreshed %d prices", refreshed)
+	}
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// ScheduledTask runs a job to completion and returns a short summary of what it did
+type ScheduledTask func(ctx context.Context) (string, error)
+
+// ErrTaskBusy is returned by a task when the work it would start is already underway,
+// for example a sync started by hand or a bulk resolution that is paused
+var ErrTaskBusy = errors.New("already running")
+
+// schedulerTick is how often the scheduler looks for due jobs; schedules have minute resolution
+const schedulerTick = 30 * time.Second
+
+// defaultJobs are created disabled on first start so they only need switching on
+var defaultJobs = []models.ScheduledJob{
+	{Name: "Nightly Discogs sync", Kind: models.JobKindDiscogsSync, Schedule: "0 3 * * *"},
+	{Name: "Resolve new track durations", Kind: models.JobKindResolveDurations, Schedule: "0 * * * *"},
+	{Name: "Re-match unavailable YouTube videos", Kind: models.JobKindYouTubeRematch, Schedule: "0 4 * * 0"},
+	{Name: "Find missing and better covers", Kind: models.JobKindCoverRefresh, Schedule: "0 5 * * 0"},
+}
+
+// Scheduler runs registered tasks on the cron schedules stored in the scheduled_jobs table
+type Scheduler struct {
+	db      *gorm.DB
+	mu      sync.Mutex
+	ctx     context.Context
+	tasks   map[string]ScheduledTask
+	running map[uint]bool
+}
+
+func NewScheduler(db *gorm.DB) *Scheduler {
+	return &Scheduler{
+		db:      db,
+		ctx:     context.Background(),
+		tasks:   make(map[string]ScheduledTask),
+		running: make(map[uint]bool),
+	}
+}
+
+// Register sets the task run for jobs of the given kind
+func (s *Scheduler) Register(kind string, task ScheduledTask) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks[kind] = task
+}
+
+// HasTask reports whether jobs of the given kind can be run
+func (s *Scheduler) HasTask(kind string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.tasks[kind]
+	return ok
+}
+
+// Kinds lists the job kinds with a registered task
+func (s *Scheduler) Kinds() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kinds := make([]string, 0, len(s.tasks))
+	for kind := range s.tasks {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// IsRunning reports whether a run of the job is in progress
+func (s *Scheduler) IsRunning(jobID uint) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running[jobID]
+}
+
+// SeedDefaults creates the default jobs when there are no jobs yet, so deleted defaults stay deleted
+func (s *Scheduler) SeedDefaults() error {
+	var count int64
+	if err := s.db.Model(&models.ScheduledJob{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	jobs := make([]models.ScheduledJob, len(defaultJobs))
+	copy(jobs, defaultJobs)
+	return s.db.Create(&jobs).Error
+}
+
+// NextRunTime returns when an enabled job is next due, or nil if it is disabled
+func NextRunTime(job models.ScheduledJob, after time.Time) (*time.Time, error) {
+	schedule, err := ParseCron(job.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	if !job.Enabled {
+		return nil, nil
+	}
+	next := schedule.Next(after)
+	if next.IsZero() {
+		return nil, fmt.Errorf("schedule %q never matches", job.Schedule)
+	}
+	return &next, nil
+}
+
+// Run starts due jobs until ctx is cancelled. Jobs missed while the app was closed run once on start
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+
+	if err := s.SeedDefaults(); err != nil {
+		log.Printf("Scheduler: failed to create default jobs: %v", err)
+	}
+
+	// Runs left running by the last shutdown will never finish
+	now := time.Now()
+	s.db.Model(&models.JobRun{}).Where("status = ?", models.JobRunRunning).Updates(map[string]interface{}{
+		"status":      models.JobRunFailed,
+		"error":       "Interrupted by shutdown",
+		"finished_at": now,
+	})
+
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+
+	s.runDue(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.runDue(now)
+		}
+	}
+}
+
+// runDue starts every enabled job whose next run time has passed and schedules its next run
+func (s *Scheduler) runDue(now time.Time) {
+	var jobs []models.ScheduledJob
+	if err := s.db.Where("enabled = ?", true).Find(&jobs).Error; err != nil {
+		log.Printf("Scheduler: failed to load jobs: %v", err)
+		return
+	}
+
+	for _, job := range jobs {
+		due := job.NextRunAt != nil && !job.NextRunAt.After(now)
+		if job.NextRunAt != nil && !due {
+			continue
+		}
+
+		next, err := NextRunTime(job, now)
+		if err != nil {
+			log.Printf("Scheduler: job %d (%s) has an invalid schedule: %v", job.ID, job.Name, err)
+			continue
+		}
+		s.db.Model(&models.ScheduledJob{}).Where("id = ?", job.ID).Update("next_run_at", next)
+
+		if due {
+			if _, err := s.start(job, models.JobTriggerSchedule); err != nil {
+				log.Printf("Scheduler: failed to start job %d (%s): %v", job.ID, job.Name, err)
+			}
+		}
+	}
+}
+
+// RunNow starts a job immediately regardless of its schedule or whether it is enabled
+func (s *Scheduler) RunNow(jobID uint) (*models.JobRun, error) {
+	var job models.ScheduledJob
+	if err := s.db.First(&job, jobID).Error; err != nil {
+		return nil, err
+	}
+	return s.start(job, models.JobTriggerManual)
+}
+
+// start records a run of the job and runs its task in the background. A job that is
+// still running from its previous trigger gets a skipped run instead of a second copy
+func (s *Scheduler) start(job models.ScheduledJob, trigger string) (*models.JobRun, error) {
+	s.mu.Lock()
+	task, ok := s.tasks[job.Kind]
+	busy := s.running[job.ID]
+	if ok && !busy {
+		s.running[job.ID] = true
+	}
+	ctx := s.ctx
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("no task for job kind %q", job.Kind)
+	}
+
+	now := time.Now()
+	run := models.JobRun{
+		JobID:     job.ID,
+		Kind:      job.Kind,
+		Trigger:   trigger,
+		Status:    models.JobRunRunning,
+		StartedAt: now,
+	}
+	if busy {
+		run.Status = models.JobRunSkipped
+		run.Summary = "Previous run still in progress"
+		run.FinishedAt = &now
+	}
+
+	if err := s.db.Create(&run).Error; err != nil {
+		if !busy {
+			s.finish(job.ID)
+		}
+		return nil, err
+	}
+	if busy {
+		log.Printf("Scheduler: skipped job %d (%s), previous run still in progress", job.ID, job.Name)
+		return &run, nil
+	}
+
+	log.Printf("Scheduler: starting job %d (%s), trigger=%s", job.ID, job.Name, trigger)
+	go s.execute(ctx, job, run, task)
+	return &run, nil
+}
+
+func (s *Scheduler) execute(ctx context.Context, job models.ScheduledJob, run models.JobRun, task ScheduledTask) {
+	defer s.finish(job.ID)
+
+	summary, err := func() (summary string, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return task(ctx)
+	}()
+
+	finished := time.Now()
+	run.Status = models.JobRunSucceeded
+	run.Summary = summary
+	switch {
+	case errors.Is(err, ErrTaskBusy):
+		run.Status = models.JobRunSkipped
+		if run.Summary == "" {
+			run.Summary = err.Error()
+		}
+	case err != nil:
+		run.Status = models.JobRunFailed
+		run.Error = err.Error()
+	}
+	run.FinishedAt = &finished
+	run.DurationSecs = finished.Sub(run.StartedAt).Seconds()
+
+	if err := s.db.Save(&run).Error; err != nil {
+		log.Printf("Scheduler: failed to save run %d of job %d: %v", run.ID, job.ID, err)
+	}
+	s.db.Model(&models.ScheduledJob{}).Where("id = ?", job.ID).Update("last_run_at", run.StartedAt)
+	log.Printf("Scheduler: job %d (%s) %s after %.0fs", job.ID, job.Name, run.Status, run.DurationSecs)
+}
+
+func (s *Scheduler) finish(jobID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, jobID)
+}
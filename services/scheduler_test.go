@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"vinylfo/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newSchedulerTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite memory db: %v", err)
+	}
+	// Runs finish on other goroutines; every connection to :memory: is a new database
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(&models.ScheduledJob{}, &models.JobRun{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+	return db
+}
+
+// waitForRun waits until the run has finished and returns it
+func waitForRun(t *testing.T, db *gorm.DB, runID uint) models.JobRun {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		var run models.JobRun
+		db.First(&run, runID)
+		if run.Status != models.JobRunRunning {
+			return run
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("run %d did not finish", runID)
+	return models.JobRun{}
+}
+
+func TestSchedulerSkipsOverlappingRun(t *testing.T) {
+	db := newSchedulerTestDB(t)
+	release := make(chan struct{})
+	s := NewScheduler(db)
+	s.Register(models.JobKindDiscogsSync, func(ctx context.Context) (string, error) {
+		<-release
+		return "done", nil
+	})
+
+	job := models.ScheduledJob{Name: "Sync", Kind: models.JobKindDiscogsSync, Schedule: "@daily"}
+	db.Create(&job)
+
+	first, err := s.RunNow(job.ID)
+	if err != nil {
+		t.Fatalf("RunNow: %v", err)
+	}
+	second, err := s.RunNow(job.ID)
+	if err != nil {
+		t.Fatalf("RunNow: %v", err)
+	}
+	if second.Status != models.JobRunSkipped {
+		t.Errorf("overlapping run status = %q, want skipped", second.Status)
+	}
+
+	close(release)
+	run := waitForRun(t, db, first.ID)
+	if run.Status != models.JobRunSucceeded || run.Summary != "done" || run.FinishedAt == nil {
+		t.Errorf("run = %+v, want succeeded with summary", run)
+	}
+
+	var saved models.ScheduledJob
+	db.First(&saved, job.ID)
+	if saved.LastRunAt == nil {
+		t.Error("LastRunAt not set")
+	}
+}
+
+func TestSchedulerRecordsTaskOutcome(t *testing.T) {
+	db := newSchedulerTestDB(t)
+	s := NewScheduler(db)
+	s.Register(models.JobKindDiscogsSync, func(ctx context.Context) (string, error) {
+		return "", ErrTaskBusy
+	})
+	s.Register(models.JobKindResolveDurations, func(ctx context.Context) (string, error) {
+		return "", errors.New("resolver offline")
+	})
+	s.Register(models.JobKindYouTubeRematch, func(ctx context.Context) (string, error) {
+		panic("boom")
+	})
+
+	tests := []struct {
+		kind   string
+		status string
+	}{
+		{models.JobKindDiscogsSync, models.JobRunSkipped},
+		{models.JobKindResolveDurations, models.JobRunFailed},
+		{models.JobKindYouTubeRematch, models.JobRunFailed},
+	}
+	for _, tt := range tests {
+		job := models.ScheduledJob{Name: tt.kind, Kind: tt.kind, Schedule: "@daily"}
+		db.Create(&job)
+		started, err := s.RunNow(job.ID)
+		if err != nil {
+			t.Fatalf("%s: RunNow: %v", tt.kind, err)
+		}
+		run := waitForRun(t, db, started.ID)
+		if run.Status != tt.status {
+			t.Errorf("%s: status = %q, want %q", tt.kind, run.Status, tt.status)
+		}
+		if run.Status == models.JobRunFailed && run.Error == "" {
+			t.Errorf("%s: failed run has no error", tt.kind)
+		}
+	}
+}
+
+func TestSchedulerRunDue(t *testing.T) {
+	db := newSchedulerTestDB(t)
+	ran := make(chan string, 3)
+	s := NewScheduler(db)
+	s.Register(models.JobKindDiscogsSync, func(ctx context.Context) (string, error) {
+		ran <- "sync"
+		return "", nil
+	})
+
+	now := time.Date(2024, 5, 15, 10, 20, 0, 0, time.UTC)
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+	jobs := []models.ScheduledJob{
+		{Name: "due", Kind: models.JobKindDiscogsSync, Schedule: "0 3 * * *", Enabled: true, NextRunAt: &past},
+		{Name: "later", Kind: models.JobKindDiscogsSync, Schedule: "0 3 * * *", Enabled: true, NextRunAt: &future},
+		{Name: "new", Kind: models.JobKindDiscogsSync, Schedule: "0 3 * * *", Enabled: true},
+		{Name: "disabled", Kind: models.JobKindDiscogsSync, Schedule: "0 3 * * *", NextRunAt: &past},
+	}
+	db.Create(&jobs)
+
+	s.runDue(now)
+
+	select {
+	case <-ran:
+	case <-time.After(2 * time.Second):
+		t.Fatal("due job did not run")
+	}
+
+	var runs int64
+	db.Model(&models.JobRun{}).Count(&runs)
+	if runs != 1 {
+		t.Errorf("runs = %d, want 1", runs)
+	}
+
+	want := time.Date(2024, 5, 16, 3, 0, 0, 0, time.UTC)
+	for _, name := range []string{"due", "new"} {
+		var job models.ScheduledJob
+		db.Where("name = ?", name).First(&job)
+		if job.NextRunAt == nil || !job.NextRunAt.Equal(want) {
+			t.Errorf("%s: NextRunAt = %v, want %v", name, job.NextRunAt, want)
+		}
+	}
+}
+
+func TestSchedulerSeedDefaultsOnlyWhenEmpty(t *testing.T) {
+	db := newSchedulerTestDB(t)
+	s := NewScheduler(db)
+
+	if err := s.SeedDefaults(); err != nil {
+		t.Fatalf("SeedDefaults: %v", err)
+	}
+	var jobs []models.ScheduledJob
+	db.Find(&jobs)
+	if len(jobs) != len(defaultJobs) {
+		t.Fatalf("seeded %d jobs, want %d", len(jobs), len(defaultJobs))
+	}
+	for _, job := range jobs {
+		if job.Enabled {
+			t.Errorf("default job %q is enabled", job.Name)
+		}
+	}
+
+	db.Delete(&jobs[0])
+	s.SeedDefaults()
+	var count int64
+	db.Model(&models.ScheduledJob{}).Count(&count)
+	if count != int64(len(defaultJobs)-1) {
+		t.Errorf("jobs after re-seeding = %d, want deleted default to stay deleted", count)
+	}
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"time"
+
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// SyncModeIncremental syncs only what was added to or removed from the collection since the last sync
+const SyncModeIncremental = "incremental"
+
+// scanPageSize is the largest page Discogs serves
+const scanPageSize = 100
+
+// CollectionScanner is the part of the Discogs client used by incremental syncs
+type CollectionScanner interface {
+	GetCollectionByDateAdded(username string, page, perPage int) ([]map[string]interface{}, int, error)
+}
+
+// CollectionDelta is what changed in the Discogs collection since the last sync
+type CollectionDelta struct {
+	New      []map[string]interface{} // Collection items added since the last-seen instance, newest first
+	Total    int                      // Items in the Discogs collection
+	Pages    int                      // Pages requested during the scan
+	Orphaned int                      // Local albums whose copy is gone from Discogs
+	Restored int                      // Previously orphaned albums found in the collection again
+	Walked   bool                     // The whole collection was listed, so removals were checked
+}
+
+// ScanCollection pages through the collection newest first and stops at the newest copy
+// the library already has. When the collection size does not add up, some copies were
+// removed; the scan then lists the rest of the collection and marks their albums orphaned
+func ScanCollection(db *gorm.DB, source CollectionScanner, username string) (*CollectionDelta, error) {
+	known := make(map[int]bool)
+	var instanceIDs []int
+	if err := db.Model(&models.Album{}).Where("discogs_instance_id > 0").Pluck("discogs_instance_id", &instanceIDs).Error; err != nil {
+		return nil, err
+	}
+	var copyIDs []int
+	if err := db.Model(&models.AlbumInstance{}).Pluck("instance_id", &copyIDs).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range append(instanceIDs, copyIDs...) {
+		known[id] = true
+	}
+
+	var latest models.Album
+	db.Select("discogs_added_at").
+		Where("discogs_added_at IS NOT NULL AND discogs_orphaned_at IS NULL").
+		Order("discogs_added_at DESC").Limit(1).Find(&latest)
+	lastAdded := latest.DiscogsAddedAt
+
+	// A release owned twice is one album, so its other copies are counted on their own
+	var inCollection, extraCopies int64
+	collectionAlbums(db).Count(&inCollection)
+	db.Model(&models.AlbumInstance{}).
+		Joins("JOIN albums ON albums.id = album_instances.album_id").
+		Where("albums.discogs_orphaned_at IS NULL AND album_instances.instance_id <> albums.discogs_instance_id").
+		Count(&extraCopies)
+	inCollection += extraCopies
+
+	delta := &CollectionDelta{}
+	seenInstances := make(map[int]bool)
+	seenReleases := make(map[int]bool)
+	caughtUp := false
+
+	for page := 1; ; page++ {
+		releases, total, err := source.GetCollectionByDateAdded(username, page, scanPageSize)
+		if err != nil {
+			return nil, err
+		}
+		delta.Pages++
+		delta.Total = total
+
+		for _, release := range releases {
+			instanceID := mapInt(release, "instance_id")
+			seenInstances[instanceID] = true
+			seenReleases[mapInt(release, "discogs_id")] = true
+			if caughtUp {
+				continue
+			}
+
+			addedAt := parseDateAdded(mapString(release, "date_added"))
+			if known[instanceID] || (lastAdded != nil && addedAt != nil && addedAt.Before(*lastAdded)) {
+				caughtUp = true
+				continue
+			}
+			delta.New = append(delta.New, release)
+		}
+
+		if len(releases) < scanPageSize || page*scanPageSize >= total {
+			delta.Walked = true
+			break
+		}
+		// Without removals the collection is what we had plus what was added
+		if caughtUp && int64(total) == inCollection+int64(len(delta.New)) {
+			break
+		}
+	}
+
+	if delta.Walked {
+		orphaned, restored, err := markOrphans(db, seenInstances, seenReleases)
+		if err != nil {
+			return nil, err
+		}
+		delta.Orphaned, delta.Restored = orphaned, restored
+	}
+	return delta, nil
+}
+
+// collectionAlbums selects albums that were imported from the collection and are still in it
+func collectionAlbums(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.Album{}).
+		Where("discogs_id IS NOT NULL AND discogs_orphaned_at IS NULL").
+		Where("discogs_instance_id > 0 OR discogs_folder_id > 0")
+}
+
+// recordAlbumInstance remembers a collection copy of an album, so a release owned more than
+// once is known by every one of its instances
+func recordAlbumInstance(db *gorm.DB, albumID uint, folderID int, media AlbumMedia) error {
+	if albumID == 0 || media.InstanceID <= 0 {
+		return nil
+	}
+	var instance models.AlbumInstance
+	return db.Where("instance_id = ?", media.InstanceID).
+		Assign(models.AlbumInstance{AlbumID: albumID, FolderID: folderID, AddedAt: media.AddedAt}).
+		FirstOrCreate(&instance, models.AlbumInstance{InstanceID: media.InstanceID}).Error
+}
+
+// markOrphans flags collection albums missing from a full listing of the collection and
+// clears the flag on orphans that are back. An album stays while any of its copies is
+// listed. Albums from before instance IDs were stored are matched by release instead
+func markOrphans(db *gorm.DB, instances, releases map[int]bool) (orphaned, restored int, err error) {
+	var copies []models.AlbumInstance
+	if err := db.Select("id", "album_id", "instance_id", "folder_id").Find(&copies).Error; err != nil {
+		return 0, 0, err
+	}
+	listed := make(map[uint][]models.AlbumInstance)
+	var removed []uint
+	for _, c := range copies {
+		if instances[c.InstanceID] {
+			listed[c.AlbumID] = append(listed[c.AlbumID], c)
+		} else {
+			removed = append(removed, c.ID)
+		}
+	}
+	if len(removed) > 0 {
+		if err := db.Where("id IN ?", removed).Delete(&models.AlbumInstance{}).Error; err != nil {
+			return 0, 0, err
+		}
+	}
+
+	present := func(album models.Album) bool {
+		if album.DiscogsInstanceID > 0 {
+			return instances[album.DiscogsInstanceID] || len(listed[album.ID]) > 0
+		}
+		return releases[*album.DiscogsID]
+	}
+
+	var albums []models.Album
+	err = db.Select("id", "discogs_id", "discogs_instance_id", "discogs_folder_id", "discogs_orphaned_at").
+		Where("discogs_id IS NOT NULL").
+		Where("discogs_instance_id > 0 OR discogs_folder_id > 0").
+		Find(&albums).Error
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var gone, back []uint
+	for _, album := range albums {
+		// The album's own copy was removed but another is still listed, so that copy takes over
+		if album.DiscogsInstanceID > 0 && !instances[album.DiscogsInstanceID] && len(listed[album.ID]) > 0 {
+			next := listed[album.ID][0]
+			err := db.Model(&models.Album{}).Where("id = ?", album.ID).
+				Updates(map[string]interface{}{"discogs_instance_id": next.InstanceID, "discogs_folder_id": next.FolderID}).Error
+			if err != nil {
+				return 0, 0, err
+			}
+		}
+		switch {
+		case album.DiscogsOrphanedAt == nil && !present(album):
+			gone = append(gone, album.ID)
+		case album.DiscogsOrphanedAt != nil && present(album):
+			back = append(back, album.ID)
+		}
+	}
+
+	if len(gone) > 0 {
+		if err := db.Model(&models.Album{}).Where("id IN ?", gone).Update("discogs_orphaned_at", time.Now()).Error; err != nil {
+			return 0, 0, err
+		}
+	}
+	if len(back) > 0 {
+		if err := db.Model(&models.Album{}).Where("id IN ?", back).Update("discogs_orphaned_at", nil).Error; err != nil {
+			return 0, 0, err
+		}
+	}
+	return len(gone), len(back), nil
+}
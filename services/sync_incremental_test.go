@@ -0,0 +1,239 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"vinylfo/models"
+	"vinylfo/sync"
+	"vinylfo/utils"
+)
+
+// fakeScanner serves a collection of items sorted newest first
+type fakeScanner struct {
+	items    []map[string]interface{}
+	requests int
+}
+
+func (f *fakeScanner) GetCollectionByDateAdded(username string, page, perPage int) ([]map[string]interface{}, int, error) {
+	f.requests++
+	start := (page - 1) * perPage
+	if start >= len(f.items) {
+		return nil, len(f.items), nil
+	}
+	end := start + perPage
+	if end > len(f.items) {
+		end = len(f.items)
+	}
+	return f.items[start:end], len(f.items), nil
+}
+
+func collectionItem(instanceID, releaseID int, added time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"instance_id": instanceID,
+		"discogs_id":  releaseID,
+		"folder_id":   1,
+		"title":       fmt.Sprintf("Release %d", releaseID),
+		"date_added":  added.Format(time.RFC3339),
+	}
+}
+
+// seedCollection creates n synced albums and the matching collection items, newest first
+func seedCollection(t *testing.T, n int) ([]models.Album, []map[string]interface{}) {
+	t.Helper()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var albums []models.Album
+	var items []map[string]interface{}
+	for i := n; i >= 1; i-- {
+		added := base.Add(time.Duration(i) * time.Hour)
+		albums = append(albums, models.Album{
+			Title: fmt.Sprintf("Release %d", i), Artist: "Artist",
+			DiscogsID: utils.IntPtr(1000 + i), DiscogsInstanceID: i, DiscogsFolderID: 1, DiscogsAddedAt: &added,
+		})
+		items = append(items, collectionItem(i, 1000+i, added))
+	}
+	return albums, items
+}
+
+func TestScanCollection(t *testing.T) {
+	later := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("stops at the last seen instance", func(t *testing.T) {
+		db := newTestDB(t)
+		albums, items := seedCollection(t, 250)
+		db.CreateInBatches(albums, 100)
+
+		source := &fakeScanner{items: append([]map[string]interface{}{
+			collectionItem(900, 5, later.Add(time.Hour)),
+			collectionItem(901, 6, later),
+		}, items...)}
+
+		delta, err := ScanCollection(db, source, "collector")
+		if err != nil {
+			t.Fatalf("ScanCollection: %v", err)
+		}
+		if len(delta.New) != 2 || mapInt(delta.New[0], "instance_id") != 900 {
+			t.Errorf("expected the 2 new items, got %v", delta.New)
+		}
+		if source.requests != 1 || delta.Walked || delta.Orphaned != 0 {
+			t.Errorf("expected a single request without a full listing, got %d requests, %+v", source.requests, delta)
+		}
+	})
+
+	t.Run("lists the collection when copies were removed", func(t *testing.T) {
+		db := newTestDB(t)
+		albums, items := seedCollection(t, 250)
+		db.CreateInBatches(albums, 100)
+
+		// Instance 10 was removed on Discogs and one release was added
+		var remaining []map[string]interface{}
+		for _, item := range items {
+			if mapInt(item, "instance_id") != 10 {
+				remaining = append(remaining, item)
+			}
+		}
+		source := &fakeScanner{items: append([]map[string]interface{}{collectionItem(900, 5, later)}, remaining...)}
+
+		delta, err := ScanCollection(db, source, "collector")
+		if err != nil {
+			t.Fatalf("ScanCollection: %v", err)
+		}
+		if len(delta.New) != 1 || !delta.Walked || delta.Orphaned != 1 || source.requests != 3 {
+			t.Errorf("unexpected delta after %d requests: new=%d %+v", source.requests, len(delta.New), delta)
+		}
+
+		var orphan models.Album
+		db.Where("discogs_instance_id = ?", 10).First(&orphan)
+		if orphan.DiscogsOrphanedAt == nil {
+			t.Error("expected the removed copy's album to be orphaned")
+		}
+	})
+
+	t.Run("knows a second copy of a release it already has", func(t *testing.T) {
+		db := newTestDB(t)
+		albums, items := seedCollection(t, 250)
+		db.CreateInBatches(albums, 100)
+
+		// Release 1001 was added to the collection again, after everything else
+		source := &fakeScanner{items: append([]map[string]interface{}{collectionItem(900, 1001, later)}, items...)}
+
+		delta, err := ScanCollection(db, source, "collector")
+		if err != nil {
+			t.Fatalf("ScanCollection: %v", err)
+		}
+		if len(delta.New) != 1 || mapInt(delta.New[0], "instance_id") != 900 {
+			t.Fatalf("expected the second copy to be new, got %v", delta.New)
+		}
+
+		// Syncing merges the copy into the release's album
+		var album models.Album
+		db.Where("discogs_id = ?", 1001).First(&album)
+		for _, item := range delta.New {
+			if err := recordAlbumInstance(db, album.ID, 1, MediaFromCollectionItem(item, nil)); err != nil {
+				t.Fatalf("recordAlbumInstance: %v", err)
+			}
+		}
+
+		source.requests = 0
+		delta, err = ScanCollection(db, source, "collector")
+		if err != nil {
+			t.Fatalf("ScanCollection: %v", err)
+		}
+		if len(delta.New) != 0 || source.requests != 1 || delta.Walked {
+			t.Errorf("expected nothing new from a single request, got %d requests, new=%v %+v", source.requests, delta.New, delta)
+		}
+
+		// The first copy is removed; the album stays and follows the remaining copy
+		var remaining []map[string]interface{}
+		for _, item := range source.items {
+			if mapInt(item, "instance_id") != 1 {
+				remaining = append(remaining, item)
+			}
+		}
+		source.items = remaining
+
+		delta, err = ScanCollection(db, source, "collector")
+		if err != nil {
+			t.Fatalf("ScanCollection: %v", err)
+		}
+		if !delta.Walked || delta.Orphaned != 0 {
+			t.Errorf("expected a full listing without orphans, got %+v", delta)
+		}
+		db.First(&album, album.ID)
+		if album.DiscogsOrphanedAt != nil || album.DiscogsInstanceID != 900 {
+			t.Errorf("expected the album to keep the remaining copy, got instance %d orphaned %v", album.DiscogsInstanceID, album.DiscogsOrphanedAt)
+		}
+
+		source.requests = 0
+		delta, err = ScanCollection(db, source, "collector")
+		if err != nil {
+			t.Fatalf("ScanCollection: %v", err)
+		}
+		if len(delta.New) != 0 || source.requests != 1 {
+			t.Errorf("expected the next scan to stop after one request, got %d requests, new=%d", source.requests, len(delta.New))
+		}
+	})
+
+	t.Run("first scan imports everything and restores orphans", func(t *testing.T) {
+		db := newTestDB(t)
+		orphanedAt := later
+		db.Create(&models.Album{Title: "Back Again", Artist: "Artist", DiscogsID: utils.IntPtr(7), DiscogsFolderID: 1, DiscogsOrphanedAt: &orphanedAt})
+		db.Create(&models.Album{Title: "Manual", Artist: "Artist"})
+
+		source := &fakeScanner{items: []map[string]interface{}{
+			collectionItem(2, 8, later),
+			collectionItem(1, 7, later.Add(-time.Hour)),
+		}}
+
+		delta, err := ScanCollection(db, source, "collector")
+		if err != nil {
+			t.Fatalf("ScanCollection: %v", err)
+		}
+		if len(delta.New) != 2 || delta.Restored != 1 || delta.Orphaned != 0 {
+			t.Errorf("unexpected delta: new=%d %+v", len(delta.New), delta)
+		}
+	})
+}
+
+func TestAlreadyProcessed_TellsCopiesApart(t *testing.T) {
+	added := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	first, second := collectionItem(900, 5, added), collectionItem(901, 5, added)
+	state := sync.SyncState{ProcessedIDs: map[int]bool{5: true}, ProcessedInstances: map[int]bool{900: true}}
+
+	if !alreadyProcessed(state, first) {
+		t.Error("the synced copy was not recognised")
+	}
+	if alreadyProcessed(state, second) {
+		t.Error("the second copy of the release was taken for the first")
+	}
+
+	// Items without an instance, from folder listings, still match by release
+	legacy := map[string]interface{}{"discogs_id": 5}
+	if !alreadyProcessed(state, legacy) {
+		t.Error("the release was not recognised without an instance ID")
+	}
+}
+
+func TestArchiveToHistoryRecordsDelta(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&models.SyncProgress{}, &models.SyncHistory{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	started := time.Now().Add(-time.Minute)
+	orphanedAt := time.Now()
+	db.Create(&models.Album{Title: "Old", Artist: "Artist", DiscogsID: utils.IntPtr(1), CreatedAt: started.Add(-time.Hour)})
+	db.Create(&models.Album{Title: "New", Artist: "Artist", DiscogsID: utils.IntPtr(2)})
+	db.Create(&models.Album{Title: "Gone", Artist: "Artist", DiscogsID: utils.IntPtr(3), CreatedAt: started.Add(-time.Hour), DiscogsOrphanedAt: &orphanedAt})
+
+	NewSyncProgressService(db).ArchiveToHistory(&models.SyncProgress{
+		SyncMode: SyncModeIncremental, Status: "completed", CreatedAt: started, LastActivityAt: time.Now(),
+	})
+
+	var history models.SyncHistory
+	db.First(&history)
+	if history.Added != 1 || history.Orphaned != 1 || history.SyncMode != SyncModeIncremental {
+		t.Errorf("unexpected history: %+v", history)
+	}
+}
@@ -25,7 +25,7 @@ func (s *SyncProgressService) Load(state sync.SyncState) *models.SyncProgress {
 	var progress models.SyncProgress
 
 	// Use raw SQL with a short timeout to avoid hanging on locked tables
-	err := s.db.Raw("SELECT id, folder_id, folder_name, folder_index, current_page, processed, total_albums, last_activity_at, status, last_batch_json, sync_mode, processed_ids_json FROM sync_progresses ORDER BY id DESC LIMIT 1").Scan(&progress).Error
+	err := s.db.Raw("SELECT id, folder_id, folder_name, folder_index, current_page, processed, total_albums, last_activity_at, status, last_batch_json, sync_mode, processed_ids_json, created_at FROM sync_progresses ORDER BY id DESC LIMIT 1").Scan(&progress).Error
 
 	if err != nil || progress.ID == 0 {
 		return nil
@@ -98,6 +98,12 @@ func (s *SyncProgressService) Save(state sync.SyncState) {
 	} else {
 		progress.ProcessedIDsJSON = ""
 	}
+	progress.ProcessedInstancesJSON = ""
+	if len(state.ProcessedInstances) > 0 {
+		if instancesJSON, err := json.Marshal(state.ProcessedInstances); err == nil {
+			progress.ProcessedInstancesJSON = string(instancesJSON)
+		}
+	}
 
 	s.db.Save(&progress)
 }
@@ -121,6 +127,13 @@ func (s *SyncProgressService) ArchiveToHistory(progress *models.SyncProgress) {
 		history.DurationSecs = 1
 	}
 
+	// Delta of the run: albums first imported and albums whose copy left the collection
+	var added, orphaned int64
+	s.db.Model(&models.Album{}).Where("discogs_id IS NOT NULL AND created_at >= ?", history.StartedAt).Count(&added)
+	s.db.Model(&models.Album{}).Where("discogs_orphaned_at >= ?", history.StartedAt).Count(&orphaned)
+	history.Added = int(added)
+	history.Orphaned = int(orphaned)
+
 	s.db.Create(&history)
 }
 
@@ -146,6 +159,12 @@ func (s *SyncProgressService) RestoreLastBatch(state *sync.SyncState) {
 			state.ProcessedIDs = processedIDs
 		}
 	}
+	if progress.ProcessedInstancesJSON != "" {
+		var instances map[int]bool
+		if err := json.Unmarshal([]byte(progress.ProcessedInstancesJSON), &instances); err == nil {
+			state.ProcessedInstances = instances
+		}
+	}
 }
 
 // Clear deletes all sync progress records
@@ -25,6 +25,16 @@ func getAlbumDiscogsID(album map[string]interface{}) (int, bool) {
 	return 0, false
 }
 
+// alreadyProcessed reports whether a collection item was synced earlier in this run
+// Items are matched by instance when they have one, since a collection can hold two copies of a release
+func alreadyProcessed(state sync.SyncState, album map[string]interface{}) bool {
+	if instanceID := mapInt(album, "instance_id"); instanceID > 0 {
+		return state.ProcessedInstances[instanceID]
+	}
+	id, ok := getAlbumDiscogsID(album)
+	return ok && state.ProcessedIDs[id]
+}
+
 // SyncWorker handles the batch sync process
 type SyncWorker struct {
 	db              *gorm.DB
@@ -36,6 +46,11 @@ type SyncWorker struct {
 	ctx             context.Context
 	cancel          context.CancelFunc
 	workerID        string
+
+	collectionFields       map[int]string
+	collectionFieldsLoaded bool
+
+	delta *CollectionDelta // Result of the incremental scan, once it has run
 }
 
 // SyncConfig holds configuration for the sync worker
@@ -110,9 +125,12 @@ func (w *SyncWorker) Run() {
 
 	go w.monitorContextCancellation()
 
+	w.pushOutbox()
+
 	w.stateManager.UpdateState(func(s *sync.SyncState) {
 		s.WorkerID = w.workerID
 		s.ProcessedIDs = make(map[int]bool)
+		s.ProcessedInstances = make(map[int]bool)
 	})
 
 	initialState := w.stateManager.GetState()
@@ -260,13 +278,9 @@ func (w *SyncWorker) Run() {
 		// Process each album with context cancellation support
 		for _, album := range currentReleases {
 			// Skip albums that have already been processed (handles re-fetch after rate limit)
-			albumID, hasID := getAlbumDiscogsID(album)
-			if hasID {
+			if albumID, hasID := getAlbumDiscogsID(album); hasID {
 				state = w.stateManager.GetState()
-				if state.ProcessedIDs == nil {
-					state.ProcessedIDs = make(map[int]bool)
-				}
-				if state.ProcessedIDs[albumID] {
+				if alreadyProcessed(state, album) {
 					w.logToFile("Sync: skipping already processed album ID %d", albumID)
 					// Just remove from batch, don't increment any counters
 					// The album was already counted when it was first processed
@@ -412,6 +426,10 @@ func (w *SyncWorker) fetchNextBatch(page, folderID int, state sync.SyncState) ([
 		return nil, true
 	}
 
+	if w.config.SyncMode == SyncModeIncremental {
+		return w.nextIncrementalBatch(state)
+	}
+
 	var releases []map[string]interface{}
 	var err error
 	var totalItems int
@@ -473,6 +491,48 @@ func (w *SyncWorker) fetchNextBatch(page, folderID int, state sync.SyncState) ([
 	return releases, false
 }
 
+// nextIncrementalBatch scans the collection for changes on first use, then hands out
+// the newly added releases that have not been processed yet
+func (w *SyncWorker) nextIncrementalBatch(state sync.SyncState) ([]map[string]interface{}, bool) {
+	if w.delta == nil {
+		// Start the progress record first so the sync history covers albums orphaned by the scan
+		w.progressService.Save(state)
+
+		delta, err := ScanCollection(w.db, w.client, w.config.Username)
+		if err != nil {
+			return w.handleFetchError(err, 1, state)
+		}
+		w.delta = delta
+		w.logToFile("processSyncBatches: incremental scan found %d new of %d items in %d requests (orphaned=%d, restored=%d, full listing=%v)",
+			len(delta.New), delta.Total, delta.Pages, delta.Orphaned, delta.Restored, delta.Walked)
+		w.stateManager.UpdateState(func(s *sync.SyncState) {
+			s.Total = len(delta.New)
+		})
+	}
+
+	state = w.stateManager.GetState()
+	var releases []map[string]interface{}
+	for _, release := range w.delta.New {
+		if alreadyProcessed(state, release) {
+			continue
+		}
+		releases = append(releases, release)
+	}
+	if len(releases) == 0 {
+		w.markComplete(state)
+		return nil, true
+	}
+
+	w.stateManager.UpdateState(func(s *sync.SyncState) {
+		s.LastBatch = &sync.SyncBatch{
+			ID:     s.CurrentPage,
+			Albums: releases,
+		}
+		s.CurrentPage++
+	})
+	return releases, false
+}
+
 // handleFetchError handles errors during fetching
 func (w *SyncWorker) handleFetchError(err error, page int, state sync.SyncState) ([]map[string]interface{}, bool) {
 	// Check if this is a rate limit error - pause instead of stopping
@@ -564,6 +624,7 @@ func (w *SyncWorker) processAlbum(album map[string]interface{}, state sync.SyncS
 	if v, ok := album["discogs_id"].(int); ok {
 		discogsID = v
 	}
+	masterID := mapInt(album, "master_id")
 	albumFolderID := 0
 	if f, ok := album["folder_id"].(int); ok {
 		albumFolderID = f
@@ -579,19 +640,56 @@ func (w *SyncWorker) processAlbum(album map[string]interface{}, state sync.SyncS
 	if discogsID > 0 {
 		result = w.db.Where("discogs_id = ?", discogsID).First(&existingAlbum)
 		if result.Error == gorm.ErrRecordNotFound {
-			result = w.db.Where("title = ? AND artist = ?", title, artist).First(&existingAlbum)
+			// Only adopt a manually added album; one with another Discogs ID is a different edition
+			result = w.db.Where("title = ? AND artist = ? AND discogs_id IS NULL", title, artist).First(&existingAlbum)
 		}
 	} else {
 		result = w.db.Where("title = ? AND artist = ?", title, artist).First(&existingAlbum)
 	}
 
+	media := MediaFromCollectionItem(album, w.getCollectionFields())
+
 	if result.Error == gorm.ErrRecordNotFound {
-		w.createNewAlbum(title, artist, year, coverImage, discogsID, albumFolderID)
+		w.createNewAlbum(title, artist, year, coverImage, discogsID, masterID, albumFolderID, media)
 	} else {
-		w.updateExistingAlbum(&existingAlbum, title, artist, year, coverImage, discogsID, albumFolderID)
+		w.updateExistingAlbum(&existingAlbum, title, artist, year, coverImage, discogsID, masterID, albumFolderID, media)
+	}
+}
+
+// pushOutbox sends queued local collection edits to Discogs before pulling the collection
+// so the pull sees the pushed values. Errors are logged; the changes stay queued
+func (w *SyncWorker) pushOutbox() {
+	if w.config.Username == "" {
+		return
+	}
+	result, err := PushCollectionChanges(w.db, w.client, w.config.Username)
+	if err != nil {
+		w.logToFile("Sync: failed to push collection changes: %v", err)
+		return
+	}
+	if result.Pushed+result.Conflicts+result.Failed > 0 {
+		w.logToFile("Sync: pushed %d collection changes, %d conflicts, %d failed, %d still pending",
+			result.Pushed, result.Conflicts, result.Failed, result.Remaining)
 	}
 }
 
+// getCollectionFields loads the user's collection field names once per worker
+// A failed lookup falls back to the default Discogs field IDs
+func (w *SyncWorker) getCollectionFields() map[int]string {
+	if w.collectionFieldsLoaded {
+		return w.collectionFields
+	}
+	w.collectionFieldsLoaded = true
+
+	fields, err := w.client.GetCollectionFields(w.config.Username)
+	if err != nil {
+		w.logToFile("Sync: failed to fetch collection fields, using defaults: %v", err)
+		return nil
+	}
+	w.collectionFields = fields
+	return fields
+}
+
 func (w *SyncWorker) fetchTracksWithRetry(albumID uint, discogsID int, title, artist string) (bool, string) {
 	const maxAttempts = 3
 	var lastErr string
@@ -622,7 +720,7 @@ func (w *SyncWorker) fetchTracksWithRetry(albumID uint, discogsID int, title, ar
 }
 
 // createNewAlbum creates a new album in the database with context-aware retry backoff
-func (w *SyncWorker) createNewAlbum(title, artist string, year int, coverImage string, discogsID, albumFolderID int) {
+func (w *SyncWorker) createNewAlbum(title, artist string, year int, coverImage string, discogsID, masterID, albumFolderID int, media AlbumMedia) {
 	maxRetries := 3
 	var newAlbum models.Album
 	var tx *gorm.DB
@@ -633,6 +731,8 @@ func (w *SyncWorker) createNewAlbum(title, artist string, year int, coverImage s
 	if imageErr != nil {
 		w.logToFile("processSyncBatches: failed to download image for %s - %s after 3 attempts: %v", artist, title, imageErr)
 	}
+	coverHash, imageData := StoreCoverImage(imageData, imageType)
+	coverPalette := CoverPalette(coverHash)
 
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		// Check context before retry
@@ -648,12 +748,16 @@ func (w *SyncWorker) createNewAlbum(title, artist string, year int, coverImage s
 			Artist:                artist,
 			ReleaseYear:           year,
 			CoverImageURL:         coverImage,
+			CoverImageHash:        coverHash,
 			DiscogsCoverImage:     imageData,
 			DiscogsCoverImageType: imageType,
 			CoverImageFailed:      imageFailed,
+			CoverPalette:          coverPalette,
 			DiscogsID:             utils.IntPtr(discogsID),
+			DiscogsMasterID:       utils.IntPtr(masterID),
 			DiscogsFolderID:       albumFolderID,
 		}
+		media.Apply(&newAlbum)
 		tx = w.db.Begin()
 		if tx.Error != nil {
 			if attempt < maxRetries && w.isLockTimeout(tx.Error) {
@@ -756,6 +860,10 @@ func (w *SyncWorker) createNewAlbum(title, artist string, year int, coverImage s
 			w.logToFile("processSyncBatches: Successfully synced album with tracks: %s - %s", artist, title)
 		}
 
+		if err := recordAlbumInstance(w.db, newAlbum.ID, albumFolderID, media); err != nil {
+			w.logToFile("processSyncBatches: failed to record collection copy %d of %s - %s: %v", media.InstanceID, artist, title, err)
+		}
+
 		w.stateManager.UpdateState(func(s *sync.SyncState) {
 			// Check if we've exceeded total using UniqueProcessed - this can happen when processing across pages/folders
 			uniqueProcessed := s.UniqueProcessed
@@ -778,6 +886,12 @@ func (w *SyncWorker) createNewAlbum(title, artist string, year int, coverImage s
 				}
 				s.ProcessedIDs[discogsID] = true
 			}
+			if media.InstanceID > 0 {
+				if s.ProcessedInstances == nil {
+					s.ProcessedInstances = make(map[int]bool)
+				}
+				s.ProcessedInstances[media.InstanceID] = true
+			}
 		})
 		w.progressService.Save(w.stateManager.GetState())
 		w.stateManager.UpdateState(func(s *sync.SyncState) {
@@ -790,7 +904,7 @@ func (w *SyncWorker) createNewAlbum(title, artist string, year int, coverImage s
 }
 
 // updateExistingAlbum updates an existing album with new data and context support
-func (w *SyncWorker) updateExistingAlbum(existingAlbum *models.Album, title, artist string, year int, coverImage string, discogsID, albumFolderID int) {
+func (w *SyncWorker) updateExistingAlbum(existingAlbum *models.Album, title, artist string, year int, coverImage string, discogsID, masterID, albumFolderID int, media AlbumMedia) {
 	updated := false
 	updates := make(map[string]interface{})
 
@@ -800,19 +914,32 @@ func (w *SyncWorker) updateExistingAlbum(existingAlbum *models.Album, title, art
 		updated = true
 	}
 
-	// Update folder ID if changed
-	if albumFolderID > 0 && existingAlbum.DiscogsFolderID != albumFolderID {
+	// Group the album with its other editions
+	if existingAlbum.DiscogsMasterID == nil && masterID > 0 {
+		updates["discogs_master_id"] = masterID
+		updated = true
+	}
+
+	// The copy is in the collection again
+	if existingAlbum.DiscogsOrphanedAt != nil {
+		updates["discogs_orphaned_at"] = nil
+		updated = true
+	}
+
+	// Update folder ID if changed, unless a local move has not reached Discogs yet
+	if albumFolderID > 0 && existingAlbum.DiscogsFolderID != albumFolderID && !HasUnsettledChange(w.db, existingAlbum.ID, models.ChangeMove) {
 		updates["discogs_folder_id"] = albumFolderID
 		updated = true
 	}
 
-	// Update cover image if we have one and it's different or was missing
-	if coverImage != "" && existingAlbum.CoverImageURL != coverImage {
+	// Update cover image if we have one and it's different or was missing, unless the cover
+	// came from elsewhere
+	if coverImage != "" && existingAlbum.CoverImageURL != coverImage && syncsDiscogsCover(existingAlbum) {
 		updates["cover_image_url"] = coverImage
 		if imageData, imageType, err := w.importer.DownloadCoverImageWithRetry(coverImage, 3); err == nil && imageData != nil {
-			updates["discogs_cover_image"] = imageData
-			updates["discogs_cover_image_type"] = imageType
-			updates["cover_image_failed"] = false
+			for column, value := range CoverImageUpdates(imageData, imageType) {
+				updates[column] = value
+			}
 		} else if err != nil {
 			updates["cover_image_failed"] = true
 			w.logToFile("Sync: failed to download cover image for %s - %s after 3 attempts: %v", artist, title, err)
@@ -826,6 +953,12 @@ func (w *SyncWorker) updateExistingAlbum(existingAlbum *models.Album, title, art
 		updated = true
 	}
 
+	// Fill in physical details the album is missing without overwriting local edits
+	for column, value := range media.MissingUpdates(existingAlbum) {
+		updates[column] = value
+		updated = true
+	}
+
 	if updated {
 		if err := w.db.Model(existingAlbum).Updates(updates).Error; err != nil {
 			w.logToFile("Sync: failed to update album %s - %s: %v", artist, title, err)
@@ -836,6 +969,10 @@ func (w *SyncWorker) updateExistingAlbum(existingAlbum *models.Album, title, art
 		w.logToFile("Sync: album exists (no updates needed): %s - %s", artist, title)
 	}
 
+	if err := recordAlbumInstance(w.db, existingAlbum.ID, albumFolderID, media); err != nil {
+		w.logToFile("Sync: failed to record collection copy %d of %s - %s: %v", media.InstanceID, artist, title, err)
+	}
+
 	w.stateManager.UpdateState(func(s *sync.SyncState) {
 		// Check if we've exceeded total using UniqueProcessed - this can happen when processing across pages/folders
 		uniqueProcessed := s.UniqueProcessed
@@ -858,6 +995,12 @@ func (w *SyncWorker) updateExistingAlbum(existingAlbum *models.Album, title, art
 			}
 			s.ProcessedIDs[discogsID] = true
 		}
+		if media.InstanceID > 0 {
+			if s.ProcessedInstances == nil {
+				s.ProcessedInstances = make(map[int]bool)
+			}
+			s.ProcessedInstances[media.InstanceID] = true
+		}
 	})
 	w.progressService.Save(w.stateManager.GetState())
 	w.stateManager.UpdateState(func(s *sync.SyncState) {
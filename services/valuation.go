@@ -0,0 +1,203 @@
+package services
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"vinylfo/models"
+
+	"gorm.io/gorm"
+)
+
+// moverCount is how many of the biggest price changes a valuation reports
+const moverCount = 10
+
+// ValuePoint is the collection's value at the end of a day
+type ValuePoint struct {
+	Date  string  `json:"date"`
+	Value float64 `json:"value"`
+}
+
+// ValueMover is an album whose value changed over the valuation window
+type ValueMover struct {
+	AlbumID   uint    `json:"album_id"`
+	DiscogsID int     `json:"discogs_id"`
+	Title     string  `json:"title"`
+	Artist    string  `json:"artist"`
+	Previous  float64 `json:"previous"`
+	Current   float64 `json:"current"`
+	Change    float64 `json:"change"`
+	ChangePct float64 `json:"change_pct"`
+}
+
+// WantlistValue summarises what buying the wantlist would cost today
+type WantlistValue struct {
+	Items       int     `json:"items"`
+	PricedItems int     `json:"priced_items"`
+	LowestTotal float64 `json:"lowest_total"`
+}
+
+// CollectionValue is the estimated market value of the collection
+type CollectionValue struct {
+	Currency       string        `json:"currency"`
+	TotalValue     float64       `json:"total_value"`
+	MedianValue    float64       `json:"median_value"`
+	PricedAlbums   int           `json:"priced_albums"`
+	UnpricedAlbums int           `json:"unpriced_albums"`
+	AsOf           *time.Time    `json:"as_of"`
+	History        []ValuePoint  `json:"history"`
+	Movers         []ValueMover  `json:"movers"`
+	Wantlist       WantlistValue `json:"wantlist"`
+}
+
+// snapshotValue is what one copy is worth according to a snapshot: the suggested price
+// for its media grade when Discogs has one, otherwise the cheapest copy for sale
+func snapshotValue(snapshot models.PriceSnapshot, grade string) (float64, bool) {
+	if grade != "" && snapshot.SuggestedPrices != "" {
+		var byGrade map[string]float64
+		if json.Unmarshal([]byte(snapshot.SuggestedPrices), &byGrade) == nil {
+			if price, ok := byGrade[grade]; ok {
+				return price, true
+			}
+		}
+	}
+	if snapshot.LowestPrice != nil {
+		return *snapshot.LowestPrice, true
+	}
+	return 0, false
+}
+
+// latestBefore returns the newest snapshot fetched before t, assuming snapshots are oldest first
+func latestBefore(snapshots []models.PriceSnapshot, t time.Time) (models.PriceSnapshot, bool) {
+	i := sort.Search(len(snapshots), func(i int) bool { return !snapshots[i].FetchedAt.Before(t) })
+	if i == 0 {
+		return models.PriceSnapshot{}, false
+	}
+	return snapshots[i-1], true
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// ValueCollection estimates the collection's value from stored price snapshots
+// History has one point per day for the last days days; movers compare today to the start of that window
+func ValueCollection(db *gorm.DB, currency string, days int) (*CollectionValue, error) {
+	var albums []models.Album
+	if err := db.Select("id, title, artist, discogs_id, media_condition").Where("discogs_id IS NOT NULL").Find(&albums).Error; err != nil {
+		return nil, err
+	}
+	var wants []models.WantlistItem
+	if err := db.Select("discogs_id").Find(&wants).Error; err != nil {
+		return nil, err
+	}
+
+	var snapshots []models.PriceSnapshot
+	if err := db.Where("currency = ?", currency).Order("fetched_at ASC, id ASC").Find(&snapshots).Error; err != nil {
+		return nil, err
+	}
+	byRelease := make(map[int][]models.PriceSnapshot)
+	for _, snapshot := range snapshots {
+		byRelease[snapshot.DiscogsID] = append(byRelease[snapshot.DiscogsID], snapshot)
+	}
+
+	now := time.Now()
+	result := &CollectionValue{
+		Currency: currency,
+		History:  make([]ValuePoint, 0, days),
+		Movers:   make([]ValueMover, 0),
+	}
+	if len(snapshots) > 0 {
+		asOf := snapshots[len(snapshots)-1].FetchedAt
+		result.AsOf = &asOf
+	}
+
+	values := make([]float64, 0, len(albums))
+	windowStart := now.AddDate(0, 0, -days)
+	for _, album := range albums {
+		history := byRelease[*album.DiscogsID]
+		current, ok := latestBefore(history, now.Add(time.Second))
+		if !ok {
+			result.UnpricedAlbums++
+			continue
+		}
+		value, ok := snapshotValue(current, album.MediaCondition)
+		if !ok {
+			result.UnpricedAlbums++
+			continue
+		}
+		values = append(values, value)
+		result.TotalValue += value
+
+		previous, ok := latestBefore(history, windowStart)
+		if !ok {
+			continue
+		}
+		before, ok := snapshotValue(previous, album.MediaCondition)
+		if !ok || before == value {
+			continue
+		}
+		mover := ValueMover{
+			AlbumID:   album.ID,
+			DiscogsID: *album.DiscogsID,
+			Title:     album.Title,
+			Artist:    album.Artist,
+			Previous:  roundCents(before),
+			Current:   roundCents(value),
+			Change:    roundCents(value - before),
+		}
+		if before > 0 {
+			mover.ChangePct = math.Round((value-before)/before*1000) / 10
+		}
+		result.Movers = append(result.Movers, mover)
+	}
+	result.PricedAlbums = len(values)
+	result.TotalValue = roundCents(result.TotalValue)
+
+	if len(values) > 0 {
+		sort.Float64s(values)
+		mid := len(values) / 2
+		if len(values)%2 == 0 {
+			result.MedianValue = roundCents((values[mid-1] + values[mid]) / 2)
+		} else {
+			result.MedianValue = roundCents(values[mid])
+		}
+	}
+
+	sort.Slice(result.Movers, func(i, j int) bool {
+		return math.Abs(result.Movers[i].Change) > math.Abs(result.Movers[j].Change)
+	})
+	if len(result.Movers) > moverCount {
+		result.Movers = result.Movers[:moverCount]
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for d := days - 1; d >= 0; d-- {
+		day := today.AddDate(0, 0, -d)
+		end := day.AddDate(0, 0, 1)
+		total := 0.0
+		for _, album := range albums {
+			if snapshot, ok := latestBefore(byRelease[*album.DiscogsID], end); ok {
+				if value, ok := snapshotValue(snapshot, album.MediaCondition); ok {
+					total += value
+				}
+			}
+		}
+		result.History = append(result.History, ValuePoint{Date: day.Format("2006-01-02"), Value: roundCents(total)})
+	}
+
+	result.Wantlist.Items = len(wants)
+	for _, want := range wants {
+		history := byRelease[want.DiscogsID]
+		if len(history) == 0 || history[len(history)-1].LowestPrice == nil {
+			continue
+		}
+		result.Wantlist.PricedItems++
+		result.Wantlist.LowestTotal += *history[len(history)-1].LowestPrice
+	}
+	result.Wantlist.LowestTotal = roundCents(result.Wantlist.LowestTotal)
+
+	return result, nil
+}
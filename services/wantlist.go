@@ -0,0 +1,115 @@
+package services
+
+import (
+	"fmt"
+
+	"vinylfo/discogs"
+	"vinylfo/models"
+	"vinylfo/utils"
+
+	"gorm.io/gorm"
+)
+
+// wantlistPageSize is the largest page Discogs serves
+const wantlistPageSize = 100
+
+// WantlistSource is the part of the Discogs client the wantlist sync reads from
+type WantlistSource interface {
+	GetWantlist(username string, page, perPage int) ([]discogs.Want, int, error)
+}
+
+// WantlistSyncResult counts the changes made by a wantlist sync
+type WantlistSyncResult struct {
+	Added   int `json:"added"`
+	Updated int `json:"updated"`
+	Removed int `json:"removed"`
+	Total   int `json:"total"`
+}
+
+// SyncWantlist mirrors the user's Discogs wantlist into the local table
+// Releases no longer wanted on Discogs are removed along with their price history
+func SyncWantlist(db *gorm.DB, source WantlistSource, username string) (WantlistSyncResult, error) {
+	var result WantlistSyncResult
+
+	wants := make([]discogs.Want, 0)
+	for page, pages := 1, 1; page <= pages; page++ {
+		batch, totalPages, err := source.GetWantlist(username, page, wantlistPageSize)
+		if err != nil {
+			return result, fmt.Errorf("failed to fetch wantlist page %d: %w", page, err)
+		}
+		wants = append(wants, batch...)
+		pages = totalPages
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var existing []models.WantlistItem
+		if err := tx.Find(&existing).Error; err != nil {
+			return err
+		}
+		byDiscogsID := make(map[int]models.WantlistItem, len(existing))
+		for _, item := range existing {
+			byDiscogsID[item.DiscogsID] = item
+		}
+
+		seen := make(map[int]bool, len(wants))
+		for _, want := range wants {
+			if seen[want.DiscogsID] {
+				continue
+			}
+			seen[want.DiscogsID] = true
+
+			item := models.WantlistItem{
+				DiscogsID:       want.DiscogsID,
+				DiscogsMasterID: utils.IntPtr(want.MasterID),
+				Title:           want.Title,
+				Artist:          want.Artist,
+				ReleaseYear:     want.Year,
+				Format:          want.Format,
+				FormatDetails:   want.FormatDetails,
+				Label:           want.Label,
+				CatalogNumber:   want.CatalogNumber,
+				CoverImageURL:   want.CoverImage,
+				Rating:          want.Rating,
+				Notes:           want.Notes,
+				DateAdded:       want.DateAdded,
+			}
+
+			if current, ok := byDiscogsID[want.DiscogsID]; ok {
+				item.ID = current.ID
+				item.CreatedAt = current.CreatedAt
+				if err := tx.Save(&item).Error; err != nil {
+					return err
+				}
+				result.Updated++
+				continue
+			}
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+			result.Added++
+		}
+
+		removed := make([]int, 0)
+		for discogsID := range byDiscogsID {
+			if !seen[discogsID] {
+				removed = append(removed, discogsID)
+			}
+		}
+		if len(removed) > 0 {
+			if err := tx.Where("discogs_id IN ?", removed).Delete(&models.WantlistItem{}).Error; err != nil {
+				return err
+			}
+			// Keep the price history of releases that are also in the collection
+			err := tx.Where("discogs_id IN ? AND discogs_id NOT IN (?)", removed,
+				tx.Model(&models.Album{}).Select("discogs_id").Where("discogs_id IS NOT NULL")).
+				Delete(&models.PriceSnapshot{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		result.Removed = len(removed)
+		result.Total = len(seen)
+		return nil
+	})
+	return result, err
+}
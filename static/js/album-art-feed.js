@@ -3,6 +3,8 @@
  * Handles SSE connection and album art display for OBS streaming
  */
 
+import { applyAlbumPalette } from './modules/utils.js';
+
 class AlbumArtFeedManager {
     constructor() {
         this.config = {
@@ -149,6 +151,7 @@ class AlbumArtFeedManager {
 
             if (this.currentTrackId !== newTrackId) {
                 this.currentTrackId = newTrackId;
+                applyAlbumPalette(track.album_palette);
                 this.showAlbumArt(track);
             }
         } else {
@@ -192,6 +195,7 @@ class AlbumArtFeedManager {
     handleNoTrack() {
         console.log('[AlbumArtFeed] No track playing, showing placeholder');
         this.currentTrackId = null;
+        applyAlbumPalette(null);
         this.elements.albumArt.classList.remove('loaded');
         // Show vinyl icon placeholder instead of empty
         this.elements.albumArt.src = '/icons/vinyl-icon.png';
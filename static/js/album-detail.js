@@ -38,14 +38,17 @@ function loadAlbumDetail(albumId) {
         fetch('/albums/' + albumId + '/tracks').then(response => {
             if (!response.ok) throw new Error('Failed to load tracks');
             return response.json();
-        })
+        }),
+        fetch('/albums/' + albumId + '/credits').then(response => response.ok ? response.json() : []),
+        fetch('/albums/' + albumId + '/editions').then(response => response.ok ? response.json() : { editions: [] }),
+        fetch('/albums/' + albumId + '/images').then(response => response.ok ? response.json() : { images: [] })
     ])
-    .then(([album, tracks]) => {
+    .then(([album, tracks, credits, editions, images]) => {
         const detail = document.getElementById('album-detail');
         
         let coverHtml = '<div class="album-detail-cover-placeholder">No Cover</div>';
-        if (album.cover_image_url || album.discogs_cover_image_type || album.cover_image_failed) {
-            coverHtml = `<img src="/albums/${album.id}/image" alt="${album.title}" class="album-detail-cover" onerror="this.style.display='none';this.parentElement.innerHTML='<div class=\\'album-detail-cover-placeholder\\'>No Cover</div>';">`;
+        if (album.cover_image_url || album.cover_image_hash || album.discogs_cover_image_type || album.cover_image_failed) {
+            coverHtml = `<img src="/albums/${album.id}/image?size=medium" alt="${album.title}" class="album-detail-cover" onerror="this.style.display='none';this.parentElement.innerHTML='<div class=\\'album-detail-cover-placeholder\\'>No Cover</div>';">`;
         }
         
         const tracksHtml = tracks && tracks.length > 0 ? `
@@ -68,6 +71,8 @@ function loadAlbumDetail(albumId) {
                 <div class="album-detail-cover-container">
                     ${coverHtml}
                     <button class="btn-update-cover" id="btn-update-cover">Change Cover</button>
+                    <button class="btn-update-cover" id="btn-find-cover">Find Cover</button>
+                    <div id="cover-candidates" class="cover-candidates" style="display: none;"></div>
                     <div id="cover-update-form" class="cover-update-form" style="display: none;">
                         <input type="text" id="cover-url-input" placeholder="Enter image URL" class="cover-url-input">
                         <div class="cover-update-buttons">
@@ -100,9 +105,13 @@ function loadAlbumDetail(albumId) {
                             <strong>Country:</strong>
                             <span>${escapeHtml(album.country || 'Unknown')}</span>
                         </div>
+                        ${mediaDetailsHtml(album)}
                     </div>
                 </div>
             </div>
+            ${imagesHtml(images.images, album.id)}
+            ${editionsHtml(editions.editions, album.id)}
+            ${creditsHtml(credits, tracks)}
             ${tracksHtml}
         `;
         
@@ -115,6 +124,161 @@ function loadAlbumDetail(albumId) {
     });
 }
 
+const IMAGE_KIND_LABELS = {
+    front: 'Front',
+    back: 'Back',
+    label: 'Label',
+    inner: 'Inner Sleeve',
+    other: 'Other'
+};
+
+function imagesHtml(images, albumId) {
+    if (!images || images.length < 2) return '';
+
+    return `
+        <div class="album-tracks">
+            <h3>Images</h3>
+            <div class="album-gallery">
+                ${images.map(image => `
+                    <div class="album-gallery-item">
+                        <a href="${image.urls.full}" target="_blank" rel="noopener">
+                            <img src="${image.urls.small}" alt="${escapeHtml(IMAGE_KIND_LABELS[image.kind] || image.kind)}" loading="lazy">
+                        </a>
+                        <select onchange="updateImageKind(${albumId}, ${image.id}, this.value)">
+                            ${Object.entries(IMAGE_KIND_LABELS).map(([kind, label]) =>
+                                `<option value="${kind}"${kind === image.kind ? ' selected' : ''}>${label}</option>`).join('')}
+                        </select>
+                    </div>
+                `).join('')}
+            </div>
+        </div>
+    `;
+}
+
+function updateImageKind(albumId, imageId, kind) {
+    fetch('/albums/' + albumId + '/images/' + imageId, {
+        method: 'PUT',
+        headers: {
+            'Content-Type': 'application/json'
+        },
+        body: JSON.stringify({ kind: kind })
+    })
+    .then(response => {
+        if (!response.ok) {
+            return response.json().then(data => {
+                throw new Error(data.error || 'Failed to update image');
+            });
+        }
+        // A new front image is also the new cover
+        if (kind === 'front') {
+            loadAlbumDetail(albumId);
+        }
+    })
+    .catch(error => {
+        console.error('Error updating image:', error);
+        alert(error.message);
+    });
+}
+
+function editionsHtml(editions, currentId) {
+    if (!editions || editions.length < 2) return '';
+
+    return `
+        <div class="album-tracks">
+            <h3>Editions</h3>
+            <div class="tracks-list">
+                ${editions.map(edition => {
+                    const summary = [edition.release_year || '', edition.country, edition.label, edition.catalog_number, edition.format]
+                        .filter(Boolean).join(' · ');
+                    const current = edition.id === currentId;
+                    return `
+                    <div class="track-item"${current ? '' : ` onclick="window.location.href='/album/${edition.id}'"`}>
+                        <div class="track-title">${escapeHtml(summary || edition.title)}</div>
+                        <div class="track-duration">${current ? 'This copy' : ''}</div>
+                    </div>`;
+                }).join('')}
+            </div>
+        </div>
+    `;
+}
+
+const CREDIT_ROLE_LABELS = {
+    performer: 'Performer',
+    featuring: 'Featuring',
+    producer: 'Producer',
+    engineer: 'Engineer',
+    contributor: 'Contributor'
+};
+
+function creditsHtml(credits, tracks) {
+    if (!credits || credits.length === 0) return '';
+
+    const trackTitles = {};
+    (tracks || []).forEach(track => {
+        trackTitles[track.id] = track.position || cleanTrackTitle(track.title);
+    });
+
+    // Group credits by artist so each person is listed once
+    const byArtist = new Map();
+    credits.forEach(credit => {
+        if (!byArtist.has(credit.artist_id)) {
+            byArtist.set(credit.artist_id, { name: credit.artist_name, roles: new Set(), tracks: new Set() });
+        }
+        const entry = byArtist.get(credit.artist_id);
+        entry.roles.add(credit.detail || CREDIT_ROLE_LABELS[credit.role] || credit.role);
+        if (credit.track_id && trackTitles[credit.track_id]) {
+            entry.tracks.add(trackTitles[credit.track_id]);
+        }
+    });
+
+    return `
+        <div class="album-tracks">
+            <h3>Credits</h3>
+            <div class="album-detail-info-grid">
+                ${[...byArtist.entries()].map(([artistId, entry]) => `
+                    <div class="album-detail-info-item">
+                        <strong>${escapeHtml([...entry.roles].join(', '))}${entry.tracks.size > 0 ? ' (' + escapeHtml([...entry.tracks].join(', ')) + ')' : ''}</strong>
+                        <span><a href="/artist/${artistId}">${escapeHtml(cleanArtistName(entry.name))}</a></span>
+                    </div>
+                `).join('')}
+            </div>
+        </div>
+    `;
+}
+
+function mediaDetailsHtml(album) {
+    const format = [album.format, album.rpm ? album.rpm + ' RPM' : '', album.disc_count > 1 ? album.disc_count + ' discs' : '']
+        .filter(Boolean).join(' · ');
+    const condition = album.media_condition || album.sleeve_condition
+        ? `${album.media_condition || '?'} / ${album.sleeve_condition || '?'}`
+        : '';
+    const price = album.purchase_price != null
+        ? `${album.purchase_price.toFixed(2)} ${album.purchase_currency || ''}`.trim()
+        : '';
+    const purchased = [price, album.purchase_date].filter(Boolean).join(' on ');
+
+    const items = [
+        ['Format', format],
+        ['Details', album.format_details],
+        ['Condition', condition],
+        ['Catalog #', album.catalog_number],
+        ['Barcode', album.barcode],
+        ['Matrix / Runout', album.matrix_runout],
+        ['Purchased', purchased],
+        ['Location', album.storage_location],
+        ['Notes', album.notes]
+    ];
+
+    return items
+        .filter(([, value]) => value)
+        .map(([label, value]) => `
+                        <div class="album-detail-info-item">
+                            <strong>${label}:</strong>
+                            <span>${escapeHtml(String(value))}</span>
+                        </div>`)
+        .join('');
+}
+
 function formatDuration(seconds) {
     if (!seconds || seconds <= 0) return '0:00';
 
@@ -135,7 +299,11 @@ function attachCoverUpdateListeners(albumId) {
     const updateBtn = document.getElementById('btn-update-cover');
     const saveBtn = document.getElementById('btn-save-cover');
     const cancelBtn = document.getElementById('btn-cancel-cover');
+    const findBtn = document.getElementById('btn-find-cover');
     
+    if (findBtn) {
+        findBtn.addEventListener('click', () => showCoverCandidates(albumId));
+    }
     if (updateBtn) {
         updateBtn.addEventListener('click', () => showUpdateCoverForm(albumId));
     }
@@ -218,3 +386,73 @@ function updateAlbumCover(albumId) {
         }
     });
 }
+
+const COVER_SOURCE_LABELS = {
+    discogs: 'Discogs',
+    coverartarchive: 'Cover Art Archive',
+    itunes: 'iTunes',
+    local: 'Local file'
+};
+
+let coverCandidates = [];
+
+function showCoverCandidates(albumId) {
+    const container = document.getElementById('cover-candidates');
+    if (!container) return;
+    container.style.display = 'block';
+    container.innerHTML = '<p>Looking for covers...</p>';
+
+    fetch('/albums/' + albumId + '/covers')
+    .then(response => {
+        if (!response.ok) {
+            return response.json().then(data => {
+                throw new Error(data.error || 'Failed to look up covers');
+            });
+        }
+        return response.json();
+    })
+    .then(data => {
+        coverCandidates = data.candidates || [];
+        if (coverCandidates.length === 0) {
+            container.innerHTML = '<p>No other covers found</p>';
+            return;
+        }
+        container.innerHTML = coverCandidates.map((candidate, index) => `
+            <div class="cover-candidate" onclick="applyCoverCandidate(${albumId}, ${index})" title="${escapeHtml(candidate.description || '')}">
+                <img src="${escapeHtml(candidate.thumbnail_url || candidate.url)}" alt="" loading="lazy">
+                <span>${COVER_SOURCE_LABELS[candidate.source] || escapeHtml(candidate.source)}${candidate.width ? ` &middot; ${candidate.width}&times;${candidate.height}` : ''}</span>
+            </div>
+        `).join('');
+    })
+    .catch(error => {
+        console.error('Error looking up covers:', error);
+        container.innerHTML = `<p class="cover-update-error">${escapeHtml(error.message)}</p>`;
+    });
+}
+
+function applyCoverCandidate(albumId, index) {
+    const candidate = coverCandidates[index];
+    if (!candidate) return;
+    const container = document.getElementById('cover-candidates');
+    if (container) container.innerHTML = '<p>Saving cover...</p>';
+
+    fetch('/albums/' + albumId + '/covers', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json'
+        },
+        body: JSON.stringify(candidate)
+    })
+    .then(response => {
+        if (!response.ok) {
+            return response.json().then(data => {
+                throw new Error(data.error || 'Failed to update cover image');
+            });
+        }
+        loadAlbumDetail(albumId);
+    })
+    .catch(error => {
+        console.error('Error applying cover:', error);
+        if (container) container.innerHTML = `<p class="cover-update-error">${escapeHtml(error.message)}</p>`;
+    });
+}
@@ -315,7 +315,7 @@ function renderAlbums(albums) {
         
         if (album.cover_image_url || album.discogs_cover_image_type || album.cover_image_failed) {
             const img = document.createElement('img');
-            img.src = '/albums/' + album.id + '/image';
+            img.src = '/albums/' + album.id + '/image?size=small';
             img.alt = album.title || '';
             img.className = 'album-cover';
             img.onerror = function() {
@@ -330,6 +330,9 @@ function renderAlbums(albums) {
         const infoDiv = document.createElement('div');
         infoDiv.className = 'album-info';
         infoDiv.innerHTML = '<h3>' + (album.title || 'Unknown Title') + '</h3><p>Artist: ' + cleanArtistName(album.artist) + '</p><p>Year: ' + (album.release_year || 'Unknown Year') + '</p>';
+        if (album.edition_count > 1) {
+            infoDiv.innerHTML += '<p>' + album.edition_count + ' editions</p>';
+        }
         
         // Add delete icon
         const deleteIcon = document.createElement('div');
@@ -383,7 +386,7 @@ function renderTracks(tracks) {
         
         item.innerHTML = `
             <div class="track-cover-small">
-                <img src="/albums/${track.album_id}/image" alt="" class="track-cover-img" onerror="this.style.display='none';this.parentElement.innerHTML='<div class=\\'track-cover-placeholder-small\\'>♪</div>';">
+                <img src="/albums/${track.album_id}/image?size=thumb" alt="" class="track-cover-img" onerror="this.style.display='none';this.parentElement.innerHTML='<div class=\\'track-cover-placeholder-small\\'>♪</div>';">
             </div>
             <div class="track-info">
                 <h3>${cleanTrackTitle(track.title) || 'Unknown Title'}</h3>
@@ -459,7 +462,8 @@ function searchTracks(query) {
 
 function loadAlbums() {
     pagination.album.query = '';
-    const url = `/albums?page=${pagination.album.page}&limit=${pagination.album.limit}&sort=${pagination.album.sort}&order=${pagination.album.order}`;
+    // Editions of the same album are listed once; the album page shows the others
+    const url = `/albums?page=${pagination.album.page}&limit=${pagination.album.limit}&sort=${pagination.album.sort}&order=${pagination.album.order}&group_editions=true`;
     fetch(url)
         .then(response => response.json())
         .then(data => {
@@ -518,7 +522,7 @@ function showTracksForAlbum(albumID) {
                     
                     let coverHtml = '<div class="album-cover-placeholder">No Cover</div>';
                     if (album.cover_image_url || album.cover_image_type) {
-                        coverHtml = `<img src="/albums/${album.id}/image" alt="${album.title}" class="album-cover" onerror="this.style.display='none';this.parentElement.innerHTML='<div class=\\'album-cover-placeholder\\'>No Cover</div>';">`;
+                        coverHtml = `<img src="/albums/${album.id}/image?size=small" alt="${album.title}" class="album-cover" onerror="this.style.display='none';this.parentElement.innerHTML='<div class=\\'album-cover-placeholder\\'>No Cover</div>';">`;
                     }
                     
                     const headerHtml = `
@@ -546,7 +550,7 @@ function showTracksForAlbum(albumID) {
                         item.className = 'track-item';
                         item.innerHTML = `
                             <div class="track-cover-small">
-                                <img src="/albums/${track.album_id}/image" alt="" class="track-cover-img" onerror="this.style.display='none';this.parentElement.innerHTML='<div class=\\'track-cover-placeholder-small\\'>♪</div>';">
+                                <img src="/albums/${track.album_id}/image?size=thumb" alt="" class="track-cover-img" onerror="this.style.display='none';this.parentElement.innerHTML='<div class=\\'track-cover-placeholder-small\\'>♪</div>';">
                             </div>
                             <div class="track-info">
                                 <h3>${cleanTrackTitle(track.title) || 'Unknown Title'}</h3>
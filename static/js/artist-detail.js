@@ -0,0 +1,109 @@
+// Artist detail page JavaScript
+
+import { escapeHtml, normalizeArtistName, normalizeTitle } from './modules/utils.js';
+
+const ROLE_LABELS = {
+    performer: 'Performer',
+    featuring: 'Featuring',
+    producer: 'Producer',
+    engineer: 'Engineer',
+    contributor: 'Contributor'
+};
+
+document.addEventListener('DOMContentLoaded', function() {
+    const pathParts = window.location.pathname.split('/');
+    const artistId = pathParts[pathParts.length - 1];
+
+    if (!artistId || isNaN(artistId)) {
+        document.getElementById('artist-detail').innerHTML = '<p>Invalid artist ID</p>';
+        return;
+    }
+
+    loadArtistDetail(artistId);
+});
+
+function loadArtistDetail(artistId) {
+    fetch('/artists/' + artistId)
+        .then(response => {
+            if (!response.ok) throw new Error('Failed to load artist');
+            return response.json();
+        })
+        .then(data => {
+            const name = normalizeArtistName(data.artist.name) || data.artist.name;
+            document.title = name + ' - Vinylfo';
+
+            const albumsHtml = data.albums.length > 0 ? `
+                <div class="album-tracks">
+                    <h3>Albums in your collection</h3>
+                    <div class="tracks-list">
+                        ${data.albums.map(album => `
+                            <div class="track-item" onclick="window.location.href='/album/${album.id}'">
+                                <div class="track-number">${album.release_year || ''}</div>
+                                <div class="track-title">
+                                    ${escapeHtml(normalizeTitle(album.title) || album.title)}
+                                    <small> — ${escapeHtml(normalizeArtistName(album.artist) || album.artist || '')}</small>
+                                </div>
+                                <div class="track-duration">${escapeHtml(albumRoleSummary(album))}</div>
+                            </div>
+                        `).join('')}
+                    </div>
+                </div>
+            ` : '<p>No albums found for this artist.</p>';
+
+            const tracksHtml = data.tracks.length > 0 ? `
+                <div class="album-tracks">
+                    <h3>Track credits</h3>
+                    <div class="tracks-list">
+                        ${data.tracks.map(track => `
+                            <div class="track-item" onclick="window.location.href='/track/${track.id}'">
+                                <div class="track-number">${escapeHtml(track.position || '')}</div>
+                                <div class="track-title">
+                                    ${escapeHtml(normalizeTitle(track.title) || track.title)}
+                                    <small> — ${escapeHtml(track.album_title)}</small>
+                                </div>
+                                <div class="track-duration">${escapeHtml(track.details.length > 0 ? track.details.join(', ') : roleLabels(track.roles))}</div>
+                            </div>
+                        `).join('')}
+                    </div>
+                </div>
+            ` : '';
+
+            document.getElementById('artist-detail').innerHTML = `
+                <div class="album-detail-info">
+                    <h2>${escapeHtml(name)}</h2>
+                    <div class="album-detail-info-grid">
+                        <div class="album-detail-info-item">
+                            <strong>Albums:</strong>
+                            <span>${data.albums.length}</span>
+                        </div>
+                        <div class="album-detail-info-item">
+                            <strong>Track credits:</strong>
+                            <span>${data.tracks.length}</span>
+                        </div>
+                        ${data.artist.discogs_id ? `
+                        <div class="album-detail-info-item">
+                            <strong>Discogs:</strong>
+                            <span><a href="https://www.discogs.com/artist/${data.artist.discogs_id}" target="_blank" rel="noopener">View on Discogs</a></span>
+                        </div>` : ''}
+                    </div>
+                </div>
+                ${albumsHtml}
+                ${tracksHtml}
+            `;
+        })
+        .catch(error => {
+            console.error('Error loading artist detail:', error);
+            document.getElementById('artist-detail').innerHTML = '<p>Error loading artist details</p>';
+        });
+}
+
+function albumRoleSummary(album) {
+    const parts = [];
+    if (album.roles.length > 0) parts.push(roleLabels(album.roles));
+    if (album.track_credits > 0) parts.push(album.track_credits + (album.track_credits === 1 ? ' track' : ' tracks'));
+    return parts.join(' · ');
+}
+
+function roleLabels(roles) {
+    return roles.map(role => ROLE_LABELS[role] || role).join(', ');
+}
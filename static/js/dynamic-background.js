@@ -362,6 +362,38 @@ class DynamicBackground {
         }
     }
 
+    // Theme the background from the colors of the album cover; without a palette the configured
+    // theme is used again
+    setPalette(palette) {
+        if (!palette || !palette.dominant) {
+            this.setTheme(this.options.theme);
+            return;
+        }
+
+        // Keep the theme's brightness: dark themes get darkened cover colors, light ones lightened
+        const target = this.options.theme === 'light' ? 255 : 0;
+        const amount = this.options.theme === 'light' ? 0.75 : 0.6;
+        const mix = (hex, extra = 0) => {
+            const { r, g, b } = this.hexToRgb(hex);
+            const t = Math.min(1, amount + extra);
+            const channel = c => Math.round(c + (target - c) * t).toString(16).padStart(2, '0');
+            return '#' + channel(r) + channel(g) + channel(b);
+        };
+
+        this.currentTheme = {
+            colors: [
+                mix(palette.dominant, 0.1),
+                mix(palette.muted),
+                mix(palette.vibrant),
+                mix(palette.dominant),
+                mix(palette.muted, 0.15)
+            ],
+            accent: palette.vibrant
+        };
+        this.initMeshPoints();
+        this.initFlowParticles();
+    }
+
     setSpeed(speed) {
         this.options.speed = Math.max(0.1, Math.min(5.0, speed));
     }
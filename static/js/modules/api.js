@@ -161,6 +161,10 @@ export const durationAPI = {
     resumeBulkResolution: () => api.post('/duration/resolve/resume'),
     cancelBulkResolution: () => api.post('/duration/resolve/cancel'),
     getProgress: () => api.get('/duration/resolve/progress'),
+    getContributions: (page = 1, limit = 20, status = '') => api.get('/duration/contributions', { page, limit, status }),
+    generateContributions: () => api.post('/duration/contributions/generate'),
+    updateContribution: (id, status, submissionId = '', submissionUrl = '') =>
+        api.put(`/duration/contributions/${id}`, { status, submission_id: submissionId, submission_url: submissionUrl }),
 };
 
 // Settings API
@@ -283,6 +283,20 @@ export function normalizeTitle(title) {
     return normalized;
 }
 
+// applyAlbumPalette exposes the colors of the album cover as --album-* CSS variables; styles fall
+// back to their own colors when the cover has no palette
+export function applyAlbumPalette(palette) {
+    const root = document.documentElement;
+    ['dominant', 'vibrant', 'muted', 'text'].forEach(name => {
+        if (palette && palette[name]) {
+            root.style.setProperty('--album-' + name, palette[name]);
+        } else {
+            root.style.removeProperty('--album-' + name);
+        }
+    });
+    document.body.classList.toggle('album-palette', !!(palette && palette.dominant));
+}
+
 // Make utility functions globally available for backward compatibility
 window.escapeHtml = escapeHtml;
 window.formatDuration = formatDuration;
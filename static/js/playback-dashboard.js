@@ -1,6 +1,6 @@
 // Playback Dashboard JavaScript with Queue and Resume Support
 
-import { normalizeArtistName, normalizeTitle } from './modules/utils.js';
+import { applyAlbumPalette, normalizeArtistName, normalizeTitle } from './modules/utils.js';
 
 document.addEventListener('DOMContentLoaded', function() {
     console.log('Playback dashboard loaded');
@@ -1307,6 +1307,7 @@ class PlaybackManager {
         document.getElementById('track-title').textContent = cleanTrackTitle(track.title) || 'Unknown Track';
         document.getElementById('track-artist').textContent = cleanArtistName(track.album_artist);
         document.getElementById('track-album').textContent = 'Album: ' + cleanAlbumTitle(track.album_title, track.title);
+        applyAlbumPalette(track.album_palette);
         document.getElementById('track-duration').textContent = 'Duration: ' + this.formatTime(this.getEffectiveDuration());
         document.getElementById('track-position').textContent = 'Position: ' + this.formatTime(this.currentPosition);
         document.getElementById('progress-bar').style.width = '0%';
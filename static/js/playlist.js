@@ -174,6 +174,7 @@ function loadPlaylistTracksForDetail(sessionId) {
         })
         .then(data => {
             renderPlaylistTracks(data.tracks || [], sessionId);
+            loadPlaylistEditions(sessionId);
         })
         .catch(error => {
             console.error('Error loading playlist tracks:', error);
@@ -182,6 +183,70 @@ function loadPlaylistTracksForDetail(sessionId) {
         });
 }
 
+// Albums in the playlist with several editions in the collection get a picker
+// for the edition the playlist should play
+function loadPlaylistEditions(sessionId) {
+    const container = document.getElementById('playlist-editions');
+    if (!container) return;
+
+    fetch(`/sessions/playlist/${sessionId}/editions`)
+        .then(response => response.ok ? response.json() : [])
+        .then(groups => {
+            if (!groups || groups.length === 0) {
+                container.style.display = 'none';
+                container.innerHTML = '';
+                return;
+            }
+
+            container.innerHTML = '<h3>Editions</h3>' + groups.map(group => {
+                const selected = group.preferred_album_id || group.album_ids[0];
+                const options = group.editions.map(edition => {
+                    const label = [edition.release_year || '', edition.country, edition.label, edition.catalog_number, edition.format]
+                        .filter(Boolean).join(' · ');
+                    return `<option value="${edition.id}"${edition.id === selected ? ' selected' : ''}>${escapeHtml(label || 'Edition ' + edition.id)}</option>`;
+                }).join('');
+                const title = group.editions[0];
+                return `
+                    <div class="playlist-edition">
+                        <label>${escapeHtml(cleanArtistName(title.artist))} - ${escapeHtml(title.title)}</label>
+                        <select data-master-id="${group.master_id}">${options}</select>
+                    </div>
+                `;
+            }).join('');
+            container.style.display = 'block';
+
+            container.querySelectorAll('select').forEach(select => {
+                select.addEventListener('change', function() {
+                    setPlaylistEdition(sessionId, parseInt(this.value, 10));
+                });
+            });
+        })
+        .catch(error => {
+            console.error('Error loading playlist editions:', error);
+        });
+}
+
+function setPlaylistEdition(sessionId, albumId) {
+    fetch(`/sessions/playlist/${sessionId}/editions`, {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ album_id: albumId })
+    })
+        .then(response => response.json())
+        .then(data => {
+            if (data.error) {
+                showNotification('Error: ' + data.error, 'error');
+                return;
+            }
+            showNotification(`Switched ${data.tracks_switched} track(s) to the selected edition`, 'success');
+            loadPlaylistTracksForDetail(sessionId);
+        })
+        .catch(error => {
+            console.error('Error setting playlist edition:', error);
+            showNotification('Error setting edition', 'error');
+        });
+}
+
 function attachPlaylistCardEventListeners() {
     document.querySelectorAll('.add-tracks-card-btn').forEach(btn => {
         btn.addEventListener('click', function(e) {
@@ -515,7 +580,7 @@ function renderAvailableTracks() {
 
                     item.innerHTML = `
                         <div class="track-cover-small">
-                            <img src="/albums/${track.album_id}/image" alt="" class="track-cover-img" onerror="this.style.display='none';this.parentElement.innerHTML='<div class=\\'track-cover-placeholder-small\\'>♪</div>';">
+                            <img src="/albums/${track.album_id}/image?size=thumb" alt="" class="track-cover-img" onerror="this.style.display='none';this.parentElement.innerHTML='<div class=\\'track-cover-placeholder-small\\'>♪</div>';">
                         </div>
                         <div class="track-info">
                             <h3>${escapeHtml(cleanTrackTitle(track.title) || 'Unknown Title')}</h3>
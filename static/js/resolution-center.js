@@ -1,5 +1,5 @@
 import { api, durationAPI } from './modules/api.js';
-import { escapeHtml, formatDuration, showNotification, normalizeArtistName, normalizeTitle } from './modules/utils.js';
+import { escapeHtml, formatDuration, showNotification, normalizeArtistName, normalizeTitle, copyToClipboard } from './modules/utils.js';
 
 const API_BASE = '/api';
 
@@ -83,6 +83,7 @@ class ResolutionQueueManager {
                 <div class="sources-summary" id="sources-${resolutionId}">
                     ${sourceBadges}
                 </div>
+                ${item.resolution.consensus_explanation ? `<div class="consensus-explanation">${escapeHtml(item.resolution.consensus_explanation)}</div>` : ''}
                 <div class="review-actions-item">
                     <button class="btn btn-primary btn-small" onclick="reviewManager.handleReviewClick(${resolutionId})">Apply</button>
                 </div>
@@ -249,6 +250,118 @@ class ResolutionQueueManager {
         });
     }
 
+    async loadContributions() {
+        try {
+            const status = document.getElementById('contributions-status').value;
+            const data = await durationAPI.getContributions(this.manager.contributionsPage, this.manager.pageSize, status);
+
+            this.manager.contributionsTotalPages = data.total_pages || 1;
+            this.manager.contributionItems = data.items || [];
+
+            this.renderContributions();
+            this.updateContributionsPagination();
+        } catch (error) {
+            console.error('Failed to load contributions:', error);
+            document.getElementById('contributions-list').innerHTML =
+                '<div class="loading">Failed to load contributions</div>';
+        }
+    }
+
+    renderContributions() {
+        const container = document.getElementById('contributions-list');
+
+        if (this.manager.contributionItems.length === 0) {
+            container.innerHTML = `
+                <div class="empty-state">
+                    <h3>No contributions</h3>
+                    <p>Generate edit packages from reviewed and high-consensus durations.</p>
+                </div>
+            `;
+            return;
+        }
+
+        container.innerHTML = this.manager.contributionItems.map(item => this.renderContribution(item)).join('');
+        this.bindContributionEvents();
+    }
+
+    renderContribution(item) {
+        const target = item.target === 'discogs' ? 'Discogs' : 'MusicBrainz';
+        const submission = item.submission_url
+            ? `<a href="${escapeHtml(item.submission_url)}" target="_blank" rel="noopener">Edit ${escapeHtml(item.submission_id || '')}</a>`
+            : escapeHtml(item.submission_id || '');
+
+        let actions = `
+            <button class="btn btn-small contribution-copy-btn" data-id="${item.id}" data-field="payload">Copy Tracklist</button>
+            <button class="btn btn-small contribution-copy-btn" data-id="${item.id}" data-field="edit_note">Copy Note</button>
+            <a class="btn btn-small" href="${escapeHtml(item.edit_url)}" target="_blank" rel="noopener">Open Editor</a>
+        `;
+        if (item.status === 'pending') {
+            actions += `<button class="btn btn-success btn-small contribution-status-btn" data-id="${item.id}" data-status="submitted">Mark Submitted</button>`;
+        } else if (item.status === 'submitted') {
+            actions += `
+                <button class="btn btn-success btn-small contribution-status-btn" data-id="${item.id}" data-status="accepted">Accepted</button>
+                <button class="btn btn-warning btn-small contribution-status-btn" data-id="${item.id}" data-status="rejected">Rejected</button>
+            `;
+        }
+
+        return `
+            <div class="review-item contribution-item" data-contribution-id="${item.id}">
+                <div class="track-info">
+                    <div class="track-title">${escapeHtml(normalizeTitle(item.title))}</div>
+                    <div class="track-meta">
+                        ${escapeHtml(normalizeArtistName(item.artist))} - ${item.track_count} track${item.track_count === 1 ? '' : 's'}
+                    </div>
+                    <div class="consensus-explanation">${submission}</div>
+                </div>
+                <div class="sources-summary">
+                    <span class="source-badge ${item.target}"><span class="source-name">${target}</span></span>
+                    <span class="contribution-status ${item.status}">${escapeHtml(item.status)}</span>
+                </div>
+                <div class="review-actions-item">
+                    ${actions}
+                </div>
+            </div>
+        `;
+    }
+
+    bindContributionEvents() {
+        document.querySelectorAll('.contribution-copy-btn').forEach(btn => {
+            btn.addEventListener('click', async (e) => {
+                const item = this.findContribution(e.target.dataset.id);
+                if (item && await copyToClipboard(item[e.target.dataset.field])) {
+                    showNotification('Copied to clipboard', 'success');
+                } else {
+                    showNotification('Failed to copy', 'error');
+                }
+            });
+        });
+
+        document.querySelectorAll('.contribution-status-btn').forEach(btn => {
+            btn.addEventListener('click', (e) => {
+                this.manager.updateContributionStatus(e.target.dataset.id, e.target.dataset.status);
+            });
+        });
+    }
+
+    findContribution(id) {
+        return this.manager.contributionItems.find(item => String(item.id) === String(id));
+    }
+
+    updateContributionsPagination() {
+        document.getElementById('contributions-page-info').textContent =
+            `Page ${this.manager.contributionsPage} of ${this.manager.contributionsTotalPages}`;
+        document.getElementById('contributions-prev-page').disabled = this.manager.contributionsPage <= 1;
+        document.getElementById('contributions-next-page').disabled = this.manager.contributionsPage >= this.manager.contributionsTotalPages;
+    }
+
+    changeContributionsPage(delta) {
+        const newPage = this.manager.contributionsPage + delta;
+        if (newPage >= 1 && newPage <= this.manager.contributionsTotalPages) {
+            this.manager.contributionsPage = newPage;
+            this.loadContributions();
+        }
+    }
+
     updatePagination() {
         document.getElementById('page-info').textContent = `Page ${this.manager.currentPage} of ${this.manager.totalPages}`;
         document.getElementById('prev-page').disabled = this.manager.currentPage <= 1;
@@ -290,13 +403,16 @@ class ResolutionCenterManager {
         this.currentPage = 1;
         this.resolvedPage = 1;
         this.unprocessedPage = 1;
+        this.contributionsPage = 1;
         this.pageSize = 20;
         this.totalPages = 1;
         this.resolvedTotalPages = 1;
         this.unprocessedTotalPages = 1;
+        this.contributionsTotalPages = 1;
         this.reviewItems = [];
         this.resolvedItems = [];
         this.unprocessedItems = [];
+        this.contributionItems = [];
         this.selectedSources = {};
         this.wasRunning = false;
         this.currentTab = 'review';
@@ -325,6 +441,13 @@ class ResolutionCenterManager {
         document.getElementById('resolved-next-page').addEventListener('click', () => this.queueManager.changeResolvedPage(1));
         document.getElementById('unprocessed-prev-page').addEventListener('click', () => this.queueManager.changeUnprocessedPage(-1));
         document.getElementById('unprocessed-next-page').addEventListener('click', () => this.queueManager.changeUnprocessedPage(1));
+        document.getElementById('contributions-prev-page').addEventListener('click', () => this.queueManager.changeContributionsPage(-1));
+        document.getElementById('contributions-next-page').addEventListener('click', () => this.queueManager.changeContributionsPage(1));
+        document.getElementById('contributions-status').addEventListener('change', () => {
+            this.contributionsPage = 1;
+            this.queueManager.loadContributions();
+        });
+        document.getElementById('generate-contributions').addEventListener('click', () => this.generateContributions());
 
         document.querySelectorAll('.tab-btn').forEach(btn => {
             btn.addEventListener('click', (e) => this.switchTab(e.target.dataset.tab));
@@ -430,26 +553,22 @@ class ResolutionCenterManager {
         document.querySelectorAll('.tab-btn').forEach(btn => {
             btn.classList.toggle('active', btn.dataset.tab === tab);
         });
+        ['review', 'unprocessed', 'resolved', 'contributions'].forEach(name => {
+            document.getElementById(`${name}-section`).classList.toggle('hidden', name !== tab);
+        });
 
         if (tab === 'review') {
-            document.getElementById('review-section').classList.remove('hidden');
-            document.getElementById('unprocessed-section').classList.add('hidden');
-            document.getElementById('resolved-section').classList.add('hidden');
             document.getElementById('queue-title').textContent = 'Needs Review';
             document.getElementById('review-pagination').classList.remove('hidden');
         } else if (tab === 'unprocessed') {
-            document.getElementById('review-section').classList.add('hidden');
-            document.getElementById('unprocessed-section').classList.remove('hidden');
-            document.getElementById('resolved-section').classList.add('hidden');
             document.getElementById('unprocessed-queue-title').textContent = 'Unprocessed Tracks';
             document.getElementById('unprocessed-pagination').classList.remove('hidden');
             if (this.unprocessedItems.length === 0) {
                 this.queueManager.loadUnprocessedQueue();
             }
+        } else if (tab === 'contributions') {
+            this.queueManager.loadContributions();
         } else {
-            document.getElementById('review-section').classList.add('hidden');
-            document.getElementById('unprocessed-section').classList.add('hidden');
-            document.getElementById('resolved-section').classList.remove('hidden');
             document.getElementById('resolved-queue-title').textContent = 'Resolved Queue';
             document.getElementById('resolved-pagination').classList.remove('hidden');
             if (this.resolvedItems.length === 0) {
@@ -458,12 +577,50 @@ class ResolutionCenterManager {
         }
     }
 
+    async generateContributions() {
+        try {
+            const result = await durationAPI.generateContributions();
+            showNotification(`Generated ${result.discogs} Discogs and ${result.musicbrainz} MusicBrainz edits covering ${result.tracks} tracks`, 'success');
+            this.contributionsPage = 1;
+            await this.queueManager.loadContributions();
+        } catch (error) {
+            showNotification('Failed to generate contributions: ' + error.message, 'error');
+        }
+    }
+
+    async updateContributionStatus(id, status) {
+        let submissionId = '';
+        let submissionUrl = '';
+        if (status === 'submitted') {
+            const entered = prompt('Edit ID or URL on the target site (optional):', '');
+            if (entered === null) {
+                return;
+            }
+            if (/^https?:\/\//.test(entered.trim())) {
+                submissionUrl = entered.trim();
+                submissionId = submissionUrl.replace(/\/+$/, '').split('/').pop();
+            } else {
+                submissionId = entered.trim();
+            }
+        }
+
+        try {
+            await durationAPI.updateContribution(id, status, submissionId, submissionUrl);
+            showNotification(`Contribution marked ${status}`, 'success');
+            await this.queueManager.loadContributions();
+        } catch (error) {
+            showNotification('Failed to update contribution: ' + error.message, 'error');
+        }
+    }
+
     async refreshAll() {
         await this.loadStats();
         if (this.currentTab === 'review') {
             await this.queueManager.loadReviewQueue();
         } else if (this.currentTab === 'unprocessed') {
             await this.queueManager.loadUnprocessedQueue();
+        } else if (this.currentTab === 'contributions') {
+            await this.queueManager.loadContributions();
         } else {
             await this.queueManager.loadResolvedQueue();
         }
@@ -586,6 +743,7 @@ class ResolutionCenterManager {
                 <h4>Available Sources</h4>
                 ${sourceDetails}
             </div>
+            ${reviewItem.resolution.consensus_explanation ? `<div class="consensus-explanation">${escapeHtml(reviewItem.resolution.consensus_explanation)}</div>` : ''}
             <div class="manual-input">
                 <label>Or enter duration manually:</label>
                 <div class="duration-inputs">
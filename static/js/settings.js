@@ -25,6 +25,8 @@ class SettingsManager {
             this.renderYouTubeAPIKey(settings.youtube_api_key);
             this.renderLastFMAPIKey(settings.lastfm_api_key);
             this.renderLogRetention(settings.log_retention_count);
+            this.renderMarketCurrency(settings.market_currency);
+            this.loadCollectionValue();
 
             const logSettingsRes = await fetch(`${API_BASE}/settings/logs`);
             const logSettings = await logSettingsRes.json();
@@ -100,6 +102,35 @@ class SettingsManager {
         }
     }
 
+    renderMarketCurrency(currency) {
+        const input = document.getElementById('market-currency');
+        if (input && currency) {
+            input.value = currency;
+        }
+    }
+
+    async loadCollectionValue() {
+        const valueSpan = document.getElementById('collection-value');
+        const wantlistSpan = document.getElementById('wantlist-summary');
+        if (!valueSpan || !wantlistSpan) return;
+
+        try {
+            const response = await fetch('/collection/value?days=30');
+            if (!response.ok) return;
+            const value = await response.json();
+            const format = (amount) => `${amount.toFixed(2)} ${value.currency}`;
+
+            valueSpan.textContent = value.priced_albums > 0
+                ? `${format(value.total_value)} (${value.priced_albums} priced, median ${format(value.median_value)})`
+                : 'No prices yet';
+            wantlistSpan.textContent = value.wantlist.items > 0
+                ? `${value.wantlist.items} items, ${format(value.wantlist.lowest_total)} at lowest prices`
+                : 'Not synced';
+        } catch (error) {
+            console.error('Failed to load collection value:', error);
+        }
+    }
+
     renderLogSettings(settings) {
         const countSpan = document.getElementById('current-log-count');
         if (countSpan) {
@@ -142,6 +173,9 @@ class SettingsManager {
         const saveYouTubeKey = document.getElementById('save-youtube-key');
         const saveLastFMKey = document.getElementById('save-lastfm-key');
         const saveLogRetention = document.getElementById('save-log-retention');
+        const saveMarketCurrency = document.getElementById('save-market-currency');
+        const syncWantlist = document.getElementById('sync-wantlist');
+        const refreshPrices = document.getElementById('refresh-prices');
         const cleanupLogs = document.getElementById('cleanup-logs');
         const exportSupport = document.getElementById('export-support');
 
@@ -154,6 +188,9 @@ class SettingsManager {
         if (saveYouTubeKey) saveYouTubeKey.addEventListener('click', () => this.saveYouTubeAPIKey());
         if (saveLastFMKey) saveLastFMKey.addEventListener('click', () => this.saveLastFMAPIKey());
         if (saveLogRetention) saveLogRetention.addEventListener('click', () => this.saveLogRetention());
+        if (saveMarketCurrency) saveMarketCurrency.addEventListener('click', () => this.saveMarketCurrency());
+        if (syncWantlist) syncWantlist.addEventListener('click', () => this.syncWantlist());
+        if (refreshPrices) refreshPrices.addEventListener('click', () => this.refreshPrices());
         if (cleanupLogs) cleanupLogs.addEventListener('click', () => this.cleanupLogs());
         if (exportSupport) exportSupport.addEventListener('click', () => this.exportSupport());
     }
@@ -260,6 +297,73 @@ class SettingsManager {
         }
     }
 
+    async saveMarketCurrency() {
+        const input = document.getElementById('market-currency');
+        const status = document.getElementById('market-currency-status');
+        const currency = input.value.trim().toUpperCase();
+
+        if (!/^[A-Z]{3}$/.test(currency)) {
+            status.textContent = 'Please enter a 3-letter currency code';
+            status.className = 'status-message error';
+            return;
+        }
+
+        try {
+            const response = await fetch(`${API_BASE}/settings`, {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ market_currency: currency })
+            });
+
+            if (response.ok) {
+                status.textContent = 'Currency saved';
+                status.className = 'status-message success';
+                this.loadCollectionValue();
+            } else {
+                const data = await response.json();
+                status.textContent = data.error || 'Failed to save';
+                status.className = 'status-message error';
+            }
+        } catch (error) {
+            console.error('Failed to save market currency:', error);
+            status.textContent = 'Failed to save';
+            status.className = 'status-message error';
+        }
+    }
+
+    async syncWantlist() {
+        try {
+            const response = await fetch('/wantlist/sync', { method: 'POST' });
+            const data = await response.json();
+
+            if (response.ok) {
+                this.showNotification(`Wantlist synced: ${data.added} added, ${data.removed} removed`, 'success');
+                this.loadCollectionValue();
+            } else {
+                this.showNotification(data.error || 'Failed to sync wantlist', 'error');
+            }
+        } catch (error) {
+            console.error('Failed to sync wantlist:', error);
+            this.showNotification('Failed to sync wantlist', 'error');
+        }
+    }
+
+    async refreshPrices() {
+        try {
+            const response = await fetch('/prices/refresh', { method: 'POST' });
+            const data = await response.json();
+
+            if (response.ok) {
+                this.showNotification('Price refresh started', 'success');
+            } else {
+                this.showNotification(data.error || 'Failed to refresh prices', 'error');
+            }
+        } catch (error) {
+            console.error('Failed to refresh prices:', error);
+            this.showNotification('Failed to refresh prices', 'error');
+        }
+    }
+
     async cleanupLogs() {
         try {
             const response = await fetch(`${API_BASE}/settings/logs/cleanup`, {
@@ -41,7 +41,7 @@ function loadTrackDetail(trackId) {
             
             let coverHtml = '<div class="track-cover-placeholder">No Cover</div>';
             if (track.album_id) {
-                coverHtml = `<img src="/albums/${track.album_id}/image" alt="${track.album_title}" class="track-cover" onerror="this.style.display='none';this.parentElement.innerHTML='<div class=\\'track-cover-placeholder\\'>No Cover</div>';">`;
+                coverHtml = `<img src="/albums/${track.album_id}/image?size=medium" alt="${track.album_title}" class="track-cover" onerror="this.style.display='none';this.parentElement.innerHTML='<div class=\\'track-cover-placeholder\\'>No Cover</div>';">`;
             }
             
             const trackTitle = cleanTrackTitle(track.title) || 'Unknown Title';
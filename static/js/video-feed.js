@@ -5,7 +5,7 @@
  * Enhanced with multiple visualization modes, beat detection, and dynamic backgrounds
  */
 
-import { normalizeArtistName, normalizeTitle } from './modules/utils.js';
+import { applyAlbumPalette, normalizeArtistName, normalizeTitle } from './modules/utils.js';
 
 function cleanArtistName(artistName) {
     if (!artistName) return 'Unknown Artist';
@@ -45,6 +45,8 @@ class VideoFeedManager {
         this.isPaused = false;
         this.player = null;
         this.playerReady = false;
+        this.localAudio = null;
+        this.usingLocalAudio = false; // The track has no video and plays from its local file
         this.preloadedVideoId = null;
         this.overlayTimeout = null;
         this.reconnectAttempts = 0;
@@ -171,6 +173,11 @@ class VideoFeedManager {
             return;
         }
 
+        if (this.usingLocalAudio) {
+            this.seekLocalAudio(position);
+            return;
+        }
+
         if (!this.player || !this.playerReady) {
             console.log('[VideoFeed] Player not ready for seek');
             return;
@@ -414,6 +421,11 @@ class VideoFeedManager {
         if (trackChanged) {
             console.log('[VideoFeed] Track changed:', track.track_title);
 
+            applyAlbumPalette(track.album_palette);
+            if (this.backgroundEffect) {
+                this.backgroundEffect.setPalette(track.album_palette);
+            }
+
             // Perform transition
             this.transitionToTrack(track);
 
@@ -438,6 +450,11 @@ class VideoFeedManager {
             this.operationTimeout = null;
         }
 
+        if (this.usingLocalAudio) {
+            this.applyLocalAudioState();
+            return;
+        }
+
         const applyPlayState = (attempt = 1) => {
             if (this.playerReady && this.player) {
                 console.log('[VideoFeed] Applying play state - isPlaying:', this.isPlaying, 'isPaused:', this.isPaused);
@@ -473,7 +490,10 @@ class VideoFeedManager {
                 clearTimeout(this.operationTimeout);
                 this.operationTimeout = null;
             }
-            if (this.playerReady && this.player) {
+            if (this.usingLocalAudio) {
+                this.localAudio.pause();
+                this.localAudio.currentTime = 0;
+            } else if (this.playerReady && this.player) {
                 this.player.pauseVideo();
                 this.player.seekTo(0, true);
             } else {
@@ -487,12 +507,17 @@ class VideoFeedManager {
     }
 
     handlePositionUpdate(data) {
-        if (!this.player || !this.playerReady) {
+        const position = data.position;
+        if (typeof position !== 'number' || position < 0) {
             return;
         }
 
-        const position = data.position;
-        if (typeof position !== 'number' || position < 0) {
+        if (this.usingLocalAudio) {
+            this.seekLocalAudio(position);
+            return;
+        }
+
+        if (!this.player || !this.playerReady) {
             return;
         }
 
@@ -520,11 +545,55 @@ class VideoFeedManager {
         }
     }
 
+    startLocalAudio(track) {
+        if (!this.localAudio) {
+            this.localAudio = new Audio();
+            this.localAudio.preload = 'auto';
+        }
+        // Muted like the YouTube player unless audio is enabled
+        this.localAudio.muted = !this.config.enableAudio;
+
+        if (!this.usingLocalAudio || this.localAudio.getAttribute('src') !== track.audio_url) {
+            this.localAudio.src = track.audio_url;
+        }
+        this.usingLocalAudio = true;
+    }
+
+    stopLocalAudio() {
+        if (!this.usingLocalAudio) {
+            return;
+        }
+        this.usingLocalAudio = false;
+        this.localAudio.pause();
+        this.localAudio.removeAttribute('src');
+        this.localAudio.load();
+    }
+
+    applyLocalAudioState() {
+        if (this.isPlaying && !this.isPaused) {
+            this.localAudio.play().catch((e) => {
+                console.log('[VideoFeed] Local audio playback blocked:', e.message);
+            });
+        } else {
+            this.localAudio.pause();
+        }
+    }
+
+    seekLocalAudio(position) {
+        if (Math.abs(this.localAudio.currentTime - position) <= 2) {
+            return;
+        }
+
+        console.log('[VideoFeed] Seeking local audio from', this.localAudio.currentTime, 'to', position);
+        this.localAudio.currentTime = position;
+    }
+
     transitionToTrack(track) {
         const hasVideo = track.has_video && track.youtube_video_id;
         console.log('[VideoFeed] transitionToTrack - hasVideo:', hasVideo, 'youtube_video_id:', track.youtube_video_id);
 
         if (hasVideo) {
+            this.stopLocalAudio();
             this.transitionTo('video', () => {
                 if (!this.player) {
                     this.initYouTubePlayer(track.youtube_video_id);
@@ -545,7 +614,16 @@ class VideoFeedManager {
                 }
             });
         } else {
-            console.log('[VideoFeed] No YouTube video, showing album art fallback');
+            if (track.has_audio && track.audio_url) {
+                console.log('[VideoFeed] No YouTube video, playing local audio over album art');
+                if (this.player && this.playerReady) {
+                    this.player.pauseVideo();
+                }
+                this.startLocalAudio(track);
+            } else {
+                console.log('[VideoFeed] No YouTube video, showing album art fallback');
+                this.stopLocalAudio();
+            }
             this.transitionTo('album-art', () => {
                 this.showAlbumArtFallback(track);
             });
@@ -688,10 +766,15 @@ class VideoFeedManager {
     showNoTrack() {
         this.currentTrack = null;
         this.currentVideoId = null;
+        applyAlbumPalette(null);
+        if (this.backgroundEffect) {
+            this.backgroundEffect.setPalette(null);
+        }
 
         if (this.player && this.playerReady) {
             this.player.stopVideo();
         }
+        this.stopLocalAudio();
 
         // Show placeholder vinyl icon instead of empty screen
         this.elements.albumArt.src = '/icons/vinyl-icon.png';
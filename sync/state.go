@@ -42,6 +42,8 @@ type SyncState struct {
 	RateLimitMessage string                   `json:"rate_limit_message,omitempty"`
 	// ProcessedIDs tracks which Discogs release IDs have been processed to avoid re-processing after rate limits
 	ProcessedIDs map[int]bool `json:"processed_ids,omitempty"`
+	// ProcessedInstances tracks collection instance IDs, so a second copy of a release is not taken for the first
+	ProcessedInstances map[int]bool `json:"processed_instances,omitempty"`
 }
 
 type StateManager struct {